@@ -1,21 +1,58 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/kznrluk/describe-kun/internal/api"
 	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/audit"
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/kznrluk/describe-kun/internal/config"
+	"github.com/kznrluk/describe-kun/internal/cost"
+	"github.com/kznrluk/describe-kun/internal/daemon"
+	"github.com/kznrluk/describe-kun/internal/feature"
 	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/footer"
+	"github.com/kznrluk/describe-kun/internal/health"
+	"github.com/kznrluk/describe-kun/internal/history"
 	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/llmcache"
+	"github.com/kznrluk/describe-kun/internal/metrics"
+	"github.com/kznrluk/describe-kun/internal/output"
+	"github.com/kznrluk/describe-kun/internal/persona"
+	"github.com/kznrluk/describe-kun/internal/policy"
+	"github.com/kznrluk/describe-kun/internal/queue"
+	"github.com/kznrluk/describe-kun/internal/readinglist"
+	"github.com/kznrluk/describe-kun/internal/reload"
+	"github.com/kznrluk/describe-kun/internal/retry"
+	"github.com/kznrluk/describe-kun/internal/router"
+	"github.com/kznrluk/describe-kun/internal/serverless"
+	"github.com/kznrluk/describe-kun/internal/slackapi"
 	"github.com/kznrluk/describe-kun/internal/slackhandler"
+	"github.com/kznrluk/describe-kun/internal/snapshot"
+	"github.com/kznrluk/describe-kun/internal/subscription"
+	"github.com/kznrluk/describe-kun/internal/tracker"
 )
 
 func main() {
-	// Check for necessary environment variables
-	if os.Getenv("OPENAI_API_KEY") == "" {
-		log.Fatal("Error: OPENAI_API_KEY environment variable not set")
+	status := flag.Bool("status", false, "Report whether the server is running, its queue depth and browser health, then exit")
+	flag.Parse()
+	if *status {
+		os.Exit(printStatus())
 	}
+
+	// Check for necessary environment variables
 	if os.Getenv("SLACK_BOT_TOKEN") == "" {
 		log.Fatal("Error: SLACK_BOT_TOKEN environment variable not set")
 	}
@@ -23,44 +60,490 @@ func main() {
 		log.Fatal("Error: SLACK_SIGNING_SECRET environment variable not set")
 	}
 
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	// Fail fast on a missing API key, even where the clients are only created on the first request
+	for _, name := range append([]string{cfg.LLMProvider}, cfg.FallbackLLMProviders...) {
+		if _, err := llm.New(name); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+	if cfg.PIDFile != "" {
+		removePIDFile, err := daemon.WritePIDFile(cfg.PIDFile)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		defer removePIDFile()
+	}
+
+	// Add a simple health check endpoint; it never waits for initialization
+	mux := router.New(router.Recover, router.RequestID)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+
+	lambdaAPI := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	var jobs *queue.Queue
+	closeAll := func() {}
+	if lambdaAPI != "" || os.Getenv("K_SERVICE") != "" {
+		// Serverless (AWS Lambda, Cloud Run, Cloud Functions): start answering right away and
+		// initialize the browser and clients on the first request instead of during the cold start
+		var mu sync.Mutex
+		mux.Handle("/", serverless.Lazy(func() http.Handler {
+			h, q, c := newServer(cfg)
+			mu.Lock()
+			jobs, closeAll = q, c
+			mu.Unlock()
+			return h
+		}))
+		defer func() {
+			mu.Lock()
+			defer mu.Unlock()
+			closeAll()
+		}()
+	} else {
+		h, q, c := newServer(cfg)
+		jobs, closeAll = q, c
+		defer closeAll()
+		mux.Handle("/", h)
+	}
+
+	if lambdaAPI != "" {
+		log.Printf("Starting describe-kun Slack bot as an AWS Lambda function")
+		// Mentions are acknowledged immediately; finish them before Lambda freezes the environment
+		err := serverless.ServeLambda(lambdaAPI, mux, func() {
+			if jobs != nil {
+				jobs.Wait()
+			}
+		})
+		log.Fatalf("Error from the Lambda runtime API: %v", err)
+	}
+
+	port := listenPort()
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatalf("Error starting server: %v", err)
+	}
+	server := &http.Server{Handler: mux}
+
+	// Stop accepting requests on SIGTERM/SIGINT, then let running jobs finish (deferred above)
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+		sig := <-stop
+		log.Printf("Received %s, shutting down after running jobs finish", sig)
+		if err := daemon.Notify(daemon.Stopping); err != nil {
+			log.Printf("Error notifying systemd: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down server: %v", err)
+		}
+	}()
+
+	log.Printf("Starting describe-kun Slack bot server on port %s", port)
+	log.Printf("Listening for Slack events on /slack/events")
+	if err := daemon.Notify(daemon.Ready, daemon.StatusNotification("Listening on port %s", port)); err != nil {
+		log.Printf("Error notifying systemd: %v", err)
+	}
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Error starting server: %v", err)
+	}
+}
+
+// healthCheckTimeout bounds each periodic check of a dependency.
+const healthCheckTimeout = 10 * time.Second
+
+// sloCheckInterval is how often the error rate and latency of summaries are compared to the SLO.
+const sloCheckInterval = time.Minute
+
+// shutdownTimeout bounds how long in-flight HTTP requests may take once the server is stopping.
+const shutdownTimeout = 30 * time.Second
+
+// listenPort returns the port the server listens on.
+func listenPort() string {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080" // Default port if not specified
+	}
+	return port
+}
+
+// printStatus prints the status of the server started with the same configuration and returns the
+// exit code, following LSB init scripts: 0 running and healthy, 1 unhealthy or unreachable, 3 not running.
+func printStatus() int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+	pid := 0
+	if cfg.PIDFile != "" {
+		pid, err = daemon.ReadPIDFile(cfg.PIDFile)
+		if errors.Is(err, daemon.ErrNotRunning) {
+			fmt.Println("describe-kun-slack is not running")
+			return 3
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s, err := daemon.FetchStatus(ctx, "http://127.0.0.1:"+listenPort()+"/status")
+	if err != nil {
+		if pid == 0 {
+			fmt.Printf("describe-kun-slack is not reachable: %v\n", err)
+			return 3
+		}
+		fmt.Printf("describe-kun-slack is running (pid %d) but not responding: %v\n", pid, err)
+		return 1
+	}
+	fmt.Print(s.Format(time.Now()))
+	if !s.Healthy() {
+		return 1
+	}
+	return 0
+}
+
+// newVendorTracker loads the vendors configured in cfg, checking that each has a channel and that the
+// URL policy allows fetching its pages.
+func newVendorTracker(cfg *config.Config, application *app.App) (*slackhandler.VendorTracker, error) {
+	vendors, err := tracker.Load(cfg.Vendors.File)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vendors {
+		if v.Channel == "" && cfg.Vendors.Channel == "" {
+			return nil, fmt.Errorf("vendor %q has no channel and VENDOR_CHANNEL is not set", v.Name)
+		}
+		for _, u := range v.URLs {
+			if err := application.CheckURL(u); err != nil {
+				return nil, fmt.Errorf("vendor %q: %w", v.Name, err)
+			}
+		}
+	}
+	state, err := tracker.NewState(cfg.Vendors.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := snapshot.NewStore(cfg.Vendors.SnapshotDir, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &slackhandler.VendorTracker{
+		Vendors:   vendors,
+		Channel:   cfg.Vendors.Channel,
+		Interval:  cfg.Vendors.Interval,
+		MinChange: cfg.Watch.MinChange,
+		State:     state,
+		Snapshots: snapshots,
+	}, nil
+}
+
+// withMeetings wraps f so meeting links configured in cfg are read as transcripts.
+func withMeetings(f fetcher.Fetcher, cfg config.Meetings) (fetcher.Fetcher, error) {
+	var zoom, google fetcher.TokenFunc
+	if cfg.ZoomAccountID != "" {
+		zoom = fetcher.ZoomToken(cfg.ZoomAccountID, cfg.ZoomClientID, cfg.ZoomClientSecret)
+	}
+	if cfg.GoogleMeet {
+		var err error
+		if google, err = fetcher.GoogleToken(); err != nil {
+			return nil, fmt.Errorf("failed to load Google credentials: %w", err)
+		}
+	}
+	if zoom == nil && google == nil {
+		return f, nil
+	}
+	return fetcher.NewMeetings(f, zoom, google), nil
+}
+
+// newServer initializes the fetcher, LLM client, App and Slack handler and returns the routes,
+// the worker pool running mentions and a function releasing everything.
+func newServer(cfg *config.Config) (http.Handler, *queue.Queue, func()) {
 	// Initialize Fetcher
-	f, err := fetcher.NewChromeDPFetcher()
+	f, err := fetcher.New(cfg.Fetcher)
 	if err != nil {
 		log.Fatalf("Error creating fetcher: %v", err)
 	}
-	defer f.Close() // Ensure browser resources are released
+	f.SetTimeouts(cfg.Timeouts.Navigation, cfg.Timeouts.Extraction)
 
-	// Initialize LLM Client
-	l, err := llm.NewOpenAIClient()
+	// Initialize the LLM clients: the provider and its fallbacks, in order of preference
+	// Unchanged pages summarized again with the same prompt are answered without a paid call
+	cache, err := llmcache.Open(cfg.Cache.Dir, cfg.Cache.Size)
 	if err != nil {
-		log.Fatalf("Error creating LLM client: %v", err)
+		log.Fatalf("Error creating LLM cache: %v", err)
+	}
+	var providers []health.Provider
+	for _, name := range append([]string{cfg.LLMProvider}, cfg.FallbackLLMProviders...) {
+		l, err := llm.New(name)
+		if err != nil {
+			log.Fatalf("Error creating LLM client: %v", err)
+		}
+		if cache != nil {
+			l = llmcache.New(l, cache, cfg.Cache.TTL)
+		}
+		providers = append(providers, health.Provider{Name: name, LLM: l})
+	}
+
+	// Enforce token budgets before any LLM call
+	tracker, err := budget.NewTracker(
+		budget.Limits{Daily: int64(cfg.Budget.DailyTokens), Monthly: int64(cfg.Budget.MonthlyTokens)},
+		budget.Limits{Daily: int64(cfg.Budget.ChannelDailyTokens), Monthly: int64(cfg.Budget.ChannelMonthlyTokens)},
+		cfg.Budget.StateFile,
+	)
+	if err != nil {
+		log.Fatalf("Error creating budget tracker: %v", err)
+	}
+
+	// Local-only domains are summarized by the on-prem model, or refused without one
+	local, err := llm.NewLocal()
+	if err != nil {
+		log.Fatalf("Error creating local LLM client: %v", err)
+	}
+
+	// Probe the LLM providers and the browser in the background, so requests route around failing providers
+	var probes []health.Probe
+	for _, p := range providers {
+		if probe := health.ProviderProbe(p); probe != nil {
+			probes = append(probes, *probe)
+		}
+	}
+	if local != nil {
+		probes = append(probes, *health.ProviderProbe(health.Provider{Name: "local", LLM: local}))
+	}
+	probes = append(probes, health.Probe{Name: "browser", Check: f.Check})
+	monitor := health.NewMonitor(healthCheckTimeout, probes...)
+	if cfg.HealthCheckInterval > 0 {
+		go monitor.Run(context.Background(), cfg.HealthCheckInterval)
 	}
 
 	// Initialize App Core
-	application := app.NewApp(f, l)
+	application := app.NewApp(f, budget.NewGuard(health.NewFailover(monitor, providers...), tracker))
+	application.SetToolFetchBudget(cfg.ToolFetchBudget)
+	application.SetChannelActions(policy.ChannelActions(cfg.ChannelFetchLimits, cfg.ChannelFetchDomains))
+	application.SetLLMTimeout(cfg.Timeouts.LLM)
+	application.SetJSONRepairAttempts(cfg.JSONRepairAttempts)
+	application.SetModelParams(llm.Params{
+		Temperature:     cfg.Sampling.Temperature,
+		TopP:            cfg.Sampling.TopP,
+		PresencePenalty: cfg.Sampling.PresencePenalty,
+		MaxTokens:       cfg.Sampling.MaxTokens,
+	})
+	application.SetChunking(cfg.ChunkThreshold, cfg.ChunkSize)
+	application.SetLengthRouting(app.LengthRouting(cfg.Routing))
+	prices, err := cost.ParsePrices(cfg.LLMPrices)
+	if err != nil {
+		log.Fatalf("Error loading LLM prices: %v", err)
+	}
+	cost.SetPrices(prices)
+	meetings, err := withMeetings(f, cfg.Meetings)
+	if err != nil {
+		log.Fatalf("Error setting up meeting transcripts: %v", err)
+	}
+	application.SetFetcher(meetings)
+	retry.SetLimits(cfg.Retry.MaxAttempts, cfg.Retry.MaxElapsed)
+	if cfg.Transcription.Enabled {
+		transcriber, err := llm.NewOpenAITranscriber(cfg.Transcription.Model)
+		if err != nil {
+			log.Fatalf("Error creating the transcriber: %v", err)
+		}
+		application.SetTranscriber(transcriber, cfg.Transcription.MaxBytes)
+	}
+	if cfg.Relevance.Enabled {
+		embedder, err := llm.NewOpenAIEmbedder(cfg.Relevance.Model)
+		if err != nil {
+			log.Fatalf("Error creating the embedder: %v", err)
+		}
+		application.SetRelevanceFilter(embedder, app.RelevanceFilter{
+			Threshold: cfg.Relevance.Threshold,
+			ChunkSize: cfg.Relevance.ChunkSize,
+			MaxChars:  cfg.Relevance.MaxChars,
+		})
+	}
+	application.SetSigningKey([]byte(cfg.ProvenanceSigningKey))
+	screeningModel := cfg.ScreeningModel
+	if screeningModel == "" {
+		screeningModel = llm.DefaultQuickModel(cfg.LLMProvider)
+	}
+	application.SetScreening(screeningModel, cfg.ScreeningTopics)
+	if cfg.AuditLog != "" {
+		sink, err := audit.Open(cfg.AuditLog)
+		if err != nil {
+			log.Fatalf("Error opening the audit log: %v", err)
+		}
+		application.SetAuditLog(audit.New(sink))
+	}
+	application.SetVisionModel(cfg.VisionModel)
+	registry := metrics.NewRegistry()
+	registry.SetBudget(tracker)
+	application.SetMetrics(registry)
+	costs := cost.NewMeter()
+	application.SetCostMeter(costs)
+	registry.SetCost(costs)
+	if cfg.SLO.ErrorRate > 0 || cfg.SLO.P95Latency > 0 {
+		var notifiers []metrics.Notifier
+		if cfg.SLO.SlackWebhookURL != "" {
+			notifiers = append(notifiers, metrics.SlackWebhook{URL: cfg.SLO.SlackWebhookURL})
+		}
+		if cfg.SLO.PagerDutyRoutingKey != "" {
+			notifiers = append(notifiers, metrics.PagerDuty{RoutingKey: cfg.SLO.PagerDutyRoutingKey})
+		}
+		alerter := metrics.NewAlerter(registry, metrics.SLO{
+			ErrorRate:   cfg.SLO.ErrorRate,
+			P95:         cfg.SLO.P95Latency,
+			Window:      cfg.SLO.Window,
+			MinRequests: cfg.SLO.MinRequests,
+		}, notifiers...)
+		go alerter.Run(context.Background(), sloCheckInterval)
+	}
+	var historyStore *history.Store
+	if cfg.HistoryFile != "" {
+		historyStore = history.NewStore(cfg.HistoryFile)
+		application.SetHistory(historyStore)
+		application.SetHistoryContent(cfg.HistoryContent)
+	}
 
 	// Initialize Slack Handler
 	slackHandler, err := slackhandler.NewSlackHandler(application)
 	if err != nil {
 		log.Fatalf("Error creating Slack handler: %v", err)
 	}
+	slackapi.SetRateLimit(cfg.SlackRateLimit)
+	slackHandler.SetTimeouts(cfg.Timeouts.SlackPost, cfg.Timeouts.Request)
+	slackHandler.SetBudget(tracker)
+	slackHandler.SetStatusReactions(cfg.StatusReactions)
+	slackHandler.SetTriggerPrefix(cfg.TriggerPrefix)
+	slackHandler.SetEditDetection(cfg.EditDetection)
+	slackHandler.SetThreadLimits(cfg.Thread.MaxMessages, cfg.Thread.MaxAge)
+	slackHandler.SetNewsletter(cfg.Newsletter.Channel, cfg.Newsletter.Token)
+	subscriptions, err := subscription.NewStore(cfg.SubscriptionsFile)
+	if err != nil {
+		log.Fatalf("Error loading subscriptions: %v", err)
+	}
+	slackHandler.SetSubscriptions(subscriptions)
+	readingList, err := readinglist.NewStore(cfg.ReadingListFile)
+	if err != nil {
+		log.Fatalf("Error loading reading lists: %v", err)
+	}
+	slackHandler.SetReadingList(readingList, historyStore)
+	if cfg.ReportChannel != "" {
+		go slackHandler.RunWeeklyReports(context.Background(), cfg.ReportChannel, historyStore)
+	}
 
-	// Set up HTTP routes
-	http.HandleFunc("/slack/events", slackHandler.HandleEvent)
-	// Add a simple health check endpoint
-	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+	// Settings that can change without a restart, which would drop in-flight jobs
+	applySettings := func(cfg *config.Config) error {
+		p, err := persona.Open(cfg.SystemPromptPrefix, cfg.SystemPromptPrefixFile)
+		if err != nil {
+			return err
+		}
+		application.SetPersona(p)
+		profile, err := persona.Open(cfg.JobProfile, cfg.JobProfileFile)
+		if err != nil {
+			return fmt.Errorf("job profile: %w", err)
+		}
+		application.SetJobProfile(profile)
+		flags, err := feature.New(cfg.Features, cfg.ChannelFeatures)
+		if err != nil {
+			return err
+		}
+		application.SetFeatures(flags)
+		filters, err := output.New(cfg.Output.Filters, cfg.Output.MaxLength, cfg.Output.RequiredSections)
+		if err != nil {
+			return err
+		}
+		application.SetOutputFilters(filters)
+		urlPolicy, err := policy.NewFromEnv()
+		if err != nil {
+			return err
+		}
+		application.SetPolicy(urlPolicy, local)
+		slackHandler.SetChannelLanguages(cfg.ChannelLanguages)
+		footers, err := footer.Open(cfg.Footer, cfg.FooterFile)
+		if err != nil {
+			return err
+		}
+		slackHandler.SetFooters(footers)
+		slackHandler.SetAlerts(cfg.Alerts.Channel, cfg.Alerts.Keywords)
+		if cfg.Shadow.File == "" {
+			application.SetShadow(nil)
+		} else {
+			candidate, err := persona.Open(cfg.Shadow.SystemPromptPrefix, cfg.Shadow.SystemPromptFile)
+			if err != nil {
+				return fmt.Errorf("shadow: %w", err)
+			}
+			application.SetShadow(&app.Shadow{
+				Model:   cfg.Shadow.Model,
+				Persona: candidate,
+				Sample:  cfg.Shadow.Sample,
+				Store:   history.NewStore(cfg.Shadow.File),
+			})
+		}
+		return nil
+	}
+	if err := applySettings(cfg); err != nil {
+		log.Fatalf("Error applying configuration: %v", err)
+	}
+	// Vendor pages are checked against the URL policy applied above
+	if cfg.Vendors.File != "" {
+		vendorTracker, err := newVendorTracker(cfg, application)
+		if err != nil {
+			log.Fatalf("Error setting up the vendor tracker: %v", err)
+		}
+		log.Printf("Tracking %d vendor(s) every %s", len(vendorTracker.Vendors), cfg.Vendors.Interval)
+		go slackHandler.RunVendorTracker(context.Background(), vendorTracker)
+	}
+	// Reload on SIGHUP or when CONFIG_FILE changes; a broken configuration keeps the previous settings
+	var watched []string
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		watched = append(watched, path)
+	}
+	go reload.Watch(context.Background(), watched, 5*time.Second, func() {
+		cfg, err := config.Load()
+		if err == nil {
+			err = applySettings(cfg)
+		}
+		if err != nil {
+			log.Printf("Error reloading configuration, keeping the previous settings: %v", err)
+			return
+		}
+		log.Printf("Configuration reloaded")
 	})
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port if not specified
+	// Run mentions on a bounded worker pool; interactive jobs take precedence over background work
+	jobs := queue.New(cfg.Workers)
+	slackHandler.SetQueue(jobs)
+
+	// Set up HTTP routes; Slack endpoints verify request signatures
+	mux := router.New()
+	slackHandler.Register(mux)
+	// Queue depth and browser health for -status (loopback clients only)
+	mux.HandleFunc("/status", daemon.StatusHandler(time.Now(), jobs, f.Check, monitor))
+	// Latest dependency checks for monitoring systems and operators, and stage latency histograms and
+	// budget burn rate for Prometheus; they reveal internals, so they need API_TOKEN (or a loopback client)
+	internal := router.RequireToken(cfg.APIToken)
+	mux.HandleFunc("/statusz", monitor.Handler(), internal)
+	mux.HandleFunc("/admin", monitor.Dashboard(), internal)
+	mux.HandleFunc("/metrics", registry.Handler(), internal)
+	if cfg.APIToken != "" {
+		apiHandler := api.NewHandler(application, cfg.APIToken)
+		apiHandler.SetTimeout(cfg.Timeouts.Request)
+		apiHandler.SetCostMeter(costs)
+		mux.HandleFunc("/api/summarize", apiHandler.HandleSummarize, router.Log)
+		mux.HandleFunc("/api/usage", apiHandler.HandleUsage, router.Log)
+		log.Printf("HTTP API enabled on /api/summarize and /api/usage")
 	}
 
-	log.Printf("Starting describe-kun Slack bot server on port %s", port)
-	log.Printf("Listening for Slack events on /slack/events")
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatalf("Error starting server: %v", err)
+	closeAll := func() {
+		jobs.Close()
+		f.Close() // Ensure browser resources are released
 	}
+	return mux, jobs, closeAll
 }
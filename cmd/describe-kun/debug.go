@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/config"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+)
+
+// runDebug implements `describe-kun debug -url X`, which summarizes one page and prints a step-by-step
+// trace: the fetcher and wait strategy used, the extracted content, the prompt, the model and the time
+// each stage took. It exits with status 1 if a stage failed, after printing the trace so far.
+func runDebug(args []string) {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	url := fs.String("url", "", "URL of the web page to trace (required)")
+	prompt := fs.String("prompt", "", "Optional user prompt/question about the content")
+	wait := fs.String("wait", string(fetcher.WaitLoad), "When the page is ready: load or networkidle (for client-side rendered pages)")
+	preview := fs.Int("preview", 300, "Characters of each prompt message to print (0 prints them whole)")
+	timeout := fs.Duration("timeout", 90*time.Second, "Timeout for the entire operation")
+	registerTimeoutFlags(fs, cfg)
+	registerProviderFlag(fs, cfg)
+	fs.Parse(args)
+
+	if *url == "" {
+		fs.Usage()
+		log.Fatal("Error: -url flag is required")
+	}
+	strategy := fetcher.WaitStrategy(*wait)
+	if strategy != fetcher.WaitLoad && strategy != fetcher.WaitNetworkIdle {
+		log.Fatalf("Error: -wait must be %s or %s, got %q", fetcher.WaitLoad, fetcher.WaitNetworkIdle, *wait)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	application, _, closeApp := newApp(cfg)
+	defer closeApp()
+
+	trace, err := application.Debug(ctx, fetcher.FetchRequest{URL: *url, WaitStrategy: strategy}, *prompt)
+	printTrace(os.Stdout, cfg, trace, *preview)
+	if err != nil {
+		closeApp()
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// printTrace writes trace as readable sections, cutting prompt messages to preview characters.
+func printTrace(w io.Writer, cfg *config.Config, trace *app.Trace, preview int) {
+	fmt.Fprintln(w, "== Request")
+	fmt.Fprintf(w, "URL:      %s\n", trace.Request.URL)
+	fmt.Fprintf(w, "Fetcher:  %s (profile %s)\n", trace.Fetcher, orDefault(cfg.Fetcher))
+	fmt.Fprintf(w, "Wait:     %s\n", trace.Request.WaitStrategy)
+	fmt.Fprintf(w, "Provider: %s\n", cfg.LLMProvider)
+	fmt.Fprintf(w, "Route:    %s\n", trace.Route)
+
+	if p := trace.Page; p != nil {
+		fmt.Fprintln(w, "\n== Page")
+		fmt.Fprintf(w, "Final URL: %s\n", p.FinalURL)
+		if p.StatusCode != 0 {
+			fmt.Fprintf(w, "Status:    %d\n", p.StatusCode)
+		}
+		fmt.Fprintf(w, "Title:     %s\n", orNone(p.Metadata.Title))
+		fmt.Fprintf(w, "Language:  %s\n", orNone(p.Metadata.Language))
+		fmt.Fprintf(w, "Content:   %s\n", trace.ContentStats())
+		if p.Video != nil {
+			fmt.Fprintf(w, "Video:     %d chapters\n", len(p.Video.Chapters))
+		}
+		if !p.Diagnostics.Empty() {
+			fmt.Fprintln(w, "\n== Diagnostics")
+			fmt.Fprintln(w, indent(p.Diagnostics.String()))
+		}
+	}
+
+	if len(trace.Messages) > 0 {
+		fmt.Fprintf(w, "\n== Prompt (mode %s)\n", trace.Mode)
+		for _, m := range trace.Messages {
+			text := m.Text()
+			runes := []rune(text)
+			fmt.Fprintf(w, "[%s, %d characters]\n", m.Role, len(runes))
+			if preview > 0 && len(runes) > preview {
+				text = string(runes[:preview]) + " …"
+			}
+			fmt.Fprintln(w, indent(text))
+		}
+	}
+
+	if r := trace.Response; r != nil {
+		fmt.Fprintln(w, "\n== Response")
+		fmt.Fprintf(w, "Model:  %s\n", r.Model)
+		fmt.Fprintf(w, "Tokens: %d prompt + %d completion = %d\n", r.Usage.PromptTokens, r.Usage.CompletionTokens, r.Usage.TotalTokens)
+		fmt.Fprintln(w, indent(r.Text))
+	}
+
+	fmt.Fprintln(w, "\n== Timing")
+	var total time.Duration
+	for _, s := range trace.Stages {
+		total += s.Duration
+		status := "ok"
+		if s.Err != nil {
+			status = "FAILED: " + s.Err.Error()
+		}
+		fmt.Fprintf(w, "%-7s %8s  %s\n", s.Name, s.Duration.Round(time.Millisecond), status)
+	}
+	fmt.Fprintf(w, "%-7s %8s\n", "total", total.Round(time.Millisecond))
+}
+
+// indent prefixes every line of text for display under a section header.
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n    ")
+}
+
+// orDefault returns s, or "default" if it is empty.
+func orDefault(s string) string {
+	if s == "" {
+		return "default"
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/eval"
+)
+
+// runEval implements `describe-kun eval`, scoring summaries against an eval set.
+func runEval(args []string) {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	setPath := fs.String("set", "", "Path to the eval set JSON file (required)")
+	minPassRate := fs.Float64("min-pass-rate", 0, "Exit with status 1 if the case pass rate is below this value (0-1)")
+	verbose := fs.Bool("v", false, "Print each generated summary")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Timeout for the whole eval run")
+	registerTimeoutFlags(fs, cfg)
+	registerProviderFlag(fs, cfg)
+	fs.Parse(args)
+
+	if *setPath == "" {
+		fs.Usage()
+		log.Fatal("Error: -set flag is required")
+	}
+
+	set, err := eval.LoadSet(*setPath)
+	if err != nil {
+		log.Fatalf("Error loading eval set: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	application, judge, closeApp := newBatchApp(cfg)
+	defer closeApp()
+
+	log.Printf("Running %d eval cases from %s", len(set.Cases), *setPath)
+	report := eval.NewRunner(application, judge).Run(ctx, set)
+
+	for _, c := range report.Cases {
+		status := "PASS"
+		if !c.Passed() {
+			status = "FAIL"
+		}
+		fmt.Printf("%s  %s\n", status, c.Name)
+		if c.Err != nil {
+			fmt.Printf("      error: %v\n", c.Err)
+		}
+		for _, f := range c.Facts {
+			if !f.Found && c.Err == nil {
+				fmt.Printf("      missing: %s\n", f.Fact)
+			}
+		}
+		if *verbose && c.Summary != "" {
+			fmt.Printf("%s\n\n", c.Summary)
+		}
+	}
+	fmt.Printf("\nCases passed: %.1f%%  Facts found: %.1f%%\n", report.PassRate()*100, report.FactRate()*100)
+
+	if report.PassRate() < *minPassRate {
+		closeApp()
+		os.Exit(1)
+	}
+}
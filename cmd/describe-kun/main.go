@@ -2,68 +2,398 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/audit"
+	"github.com/kznrluk/describe-kun/internal/config"
+	"github.com/kznrluk/describe-kun/internal/cost"
+	"github.com/kznrluk/describe-kun/internal/feature"
 	"github.com/kznrluk/describe-kun/internal/fetcher"
 	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/llmcache"
+	"github.com/kznrluk/describe-kun/internal/output"
+	"github.com/kznrluk/describe-kun/internal/persona"
+	"github.com/kznrluk/describe-kun/internal/policy"
+	"github.com/kznrluk/describe-kun/internal/retry"
 )
 
 func main() {
+	// Dispatch subcommands; plain flags run the default summarize command
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "debug":
+			runDebug(os.Args[2:])
+			return
+		case "eval":
+			runEval(os.Args[2:])
+			return
+		case "pack":
+			runPack(os.Args[2:])
+			return
+		case "podcast-digest":
+			runPodcastDigest(os.Args[2:])
+			return
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "report":
+			runReport(os.Args[2:])
+			return
+		case "watch":
+			runWatch(os.Args[2:])
+			return
+		}
+	}
+
+	cfg := loadConfig()
+
 	// Define command-line flags
-	url := flag.String("url", "", "URL of the web page to process (required)")
+	url := flag.String("url", "", "URL of the web page to process (required unless -html-file is given)")
+	htmlFile := flag.String("html-file", "", "Summarize this HTML file (\"-\" for stdin) instead of fetching; -url then only sets the base URL")
 	prompt := flag.String("prompt", "", "Optional user prompt/question about the content")
+	lang := flag.String("lang", "", "Write the summary in these languages, e.g. \"en\" or \"ja,en\" (default: the language of the prompt or page)")
+	templateFile := flag.String("template", "", "Render the result with this Go template file instead of printing the summary")
+	format := flag.String("format", "text", "Output format: text, or markdown or json to generate the summary as structured data and render it")
+	style := flag.String("style", "", "Summary style: "+strings.Join(app.Styles, ", ")+" (default: the format of the kind of page)")
+	stream := flag.Bool("stream", false, "Show the summary on stderr while it is generated; the final result still goes to stdout")
+	quickstart := flag.Bool("quickstart", false, "Treat -url as a library's docs homepage and print a quickstart from its getting started pages")
+	quick := flag.Bool("quick", false, "Print a one-line TL;DR using the quick model (QUICK_MODEL), without logs; for launchers and shell aliases")
 	timeout := flag.Duration("timeout", 90*time.Second, "Timeout for the entire operation") // Increased timeout to 90s
+	registerTimeoutFlags(flag.CommandLine, cfg)
+	registerProviderFlag(flag.CommandLine, cfg)
 
 	flag.Parse()
 
 	// Validate required flags
-	if *url == "" {
+	if *url == "" && *htmlFile == "" {
 		flag.Usage()
-		log.Fatal("Error: -url flag is required")
+		log.Fatal("Error: -url or -html-file flag is required")
+	}
+	if *format != "text" && *format != "markdown" && *format != "json" {
+		log.Fatalf("Error: unknown -format %q (text, markdown or json)", *format)
+	}
+	if *style != "" && !app.ValidStyle(*style) {
+		log.Fatalf("Error: unknown -style %q (%s)", *style, strings.Join(app.Styles, ", "))
+	}
+	if *style != "" && *format != "text" {
+		log.Fatal("Error: -style cannot be combined with -format")
+	}
+	if *quick {
+		if *htmlFile != "" || *templateFile != "" {
+			log.Fatal("Error: -quick cannot be combined with -html-file or -template")
+		}
+		runQuick(cfg, *url, *prompt, *timeout)
+		return
+	}
+
+	if *quickstart && (*htmlFile != "" || *templateFile != "" || *format != "text") {
+		log.Fatal("Error: -quickstart cannot be combined with -html-file, -template or -format")
 	}
 
-	// Check for API key (handled within NewOpenAIClient, but good practice to check early)
-	if os.Getenv("OPENAI_API_KEY") == "" {
-		log.Fatal("Error: OPENAI_API_KEY environment variable not set")
+	// Load the template before doing any work so mistakes fail fast
+	var tmpl *template.Template
+	if *templateFile != "" {
+		var err error
+		if tmpl, err = loadTemplate(*templateFile); err != nil {
+			log.Fatalf("Error loading template: %v", err)
+		}
 	}
 
 	// Set up context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
 
+	application, _, closeApp := newApp(cfg)
+	defer closeApp() // Ensure browser resources are released
+
+	if *prompt != "" {
+		log.Printf("With user prompt: %s", *prompt)
+	}
+
+	req := fetcher.FetchRequest{URL: *url}
+	if *htmlFile != "" {
+		// Process the provided HTML, skipping the page load
+		html, err := readInput(*htmlFile)
+		if err != nil {
+			log.Fatalf("Error reading HTML file: %v", err)
+		}
+		if html == "" {
+			log.Fatalf("Error: HTML file %s is empty", *htmlFile)
+		}
+		req.HTML = html
+		log.Printf("Processing HTML from %s", *htmlFile)
+	} else {
+		log.Printf("Processing URL: %s", *url)
+	}
+
+	if *lang != "" {
+		ctx = app.WithLanguages(ctx, strings.Split(*lang, ","))
+	}
+	if *format != "text" {
+		ctx = app.WithStructured(ctx)
+	}
+	if *style != "" {
+		ctx = app.WithStyle(ctx, *style)
+	}
+	if *stream {
+		ctx = app.WithStream(ctx, func(delta string) { fmt.Fprint(os.Stderr, delta) })
+	}
+	if *quickstart {
+		text, err := application.ProcessQuickstart(ctx, *url, *prompt, func(message string) { log.Print(message) })
+		if err != nil {
+			log.Fatalf("Error writing the quickstart: %v", err)
+		}
+		if *stream {
+			fmt.Fprintln(os.Stderr)
+		}
+		fmt.Println(text)
+		return
+	}
+	result, err := application.Summarize(ctx, req, *prompt)
+	if err != nil {
+		log.Fatalf("Error processing content: %v", err)
+	}
+	if *stream {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	// Print the result
+	switch {
+	case tmpl != nil:
+		if err := tmpl.Execute(os.Stdout, result); err != nil {
+			log.Fatalf("Error rendering template: %v", err)
+		}
+	case *format == "json":
+		out, err := json.MarshalIndent(result.Structured, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding the summary: %v", err)
+		}
+		fmt.Println(string(out))
+	case *format == "markdown":
+		fmt.Print(result.Structured.Markdown())
+	default:
+		fmt.Println(result.Summary)
+	}
+	log.Println("Processing finished successfully.")
+}
+
+// runQuick prints a one-line TL;DR of url. Progress logging is silenced so only the answer reaches the terminal.
+func runQuick(cfg *config.Config, url, prompt string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	application, _, closeApp := newApp(cfg)
+	defer closeApp()
+	quickModel := cfg.QuickModel
+	if quickModel == "" {
+		quickModel = llm.DefaultQuickModel(cfg.LLMProvider)
+	}
+	application.SetQuickModel(quickModel)
+	// Silence only after setup, so configuration errors are still reported
+	log.SetOutput(io.Discard)
+
+	line, err := application.QuickSummary(ctx, url, prompt)
+	if err != nil {
+		closeApp()
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(line)
+}
+
+// readInput reads the file at path, or stdin for "-".
+func readInput(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// loadConfig reads the environment configuration or exits.
+func loadConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	return cfg
+}
+
+// registerTimeoutFlags adds per-stage timeout flags defaulting to the environment configuration.
+func registerTimeoutFlags(fs *flag.FlagSet, cfg *config.Config) {
+	fs.DurationVar(&cfg.Timeouts.Navigation, "navigation-timeout", cfg.Timeouts.Navigation, "Timeout for loading a page (0 disables)")
+	fs.DurationVar(&cfg.Timeouts.Extraction, "extraction-timeout", cfg.Timeouts.Extraction, "Timeout for extracting content from a loaded page (0 disables)")
+	fs.DurationVar(&cfg.Timeouts.LLM, "llm-timeout", cfg.Timeouts.LLM, "Timeout for a single LLM call (0 disables)")
+}
+
+// registerProviderFlag adds the LLM provider flag defaulting to the environment configuration.
+func registerProviderFlag(fs *flag.FlagSet, cfg *config.Config) {
+	fs.StringVar(&cfg.LLMProvider, "provider", cfg.LLMProvider, "LLM provider: openai, anthropic, gemini, ollama, bedrock or mock (default: LLM_PROVIDER)")
+}
+
+// modelParams returns the configured sampling parameters of LLM calls.
+func modelParams(cfg *config.Config) llm.Params {
+	return llm.Params{
+		Temperature:     cfg.Sampling.Temperature,
+		TopP:            cfg.Sampling.TopP,
+		PresencePenalty: cfg.Sampling.PresencePenalty,
+		MaxTokens:       cfg.Sampling.MaxTokens,
+	}
+}
+
+// newApp initializes the fetcher, LLM client and App shared by all subcommands.
+// The returned function releases the browser and must be called when done.
+func newApp(cfg *config.Config) (*app.App, llm.LLM, func()) {
+	// Check the provider's API key before starting the browser
+	l, err := llm.New(cfg.LLMProvider)
+	if err != nil {
+		log.Fatalf("Error creating LLM client: %v", err)
+	}
+	// Unchanged pages summarized again with the same prompt are answered without a paid call
+	cache, err := llmcache.Open(cfg.Cache.Dir, cfg.Cache.Size)
+	if err != nil {
+		log.Fatalf("Error creating LLM cache: %v", err)
+	}
+	if cache != nil {
+		l = llmcache.New(l, cache, cfg.Cache.TTL)
+	}
+
 	// Initialize Fetcher
-	f, err := fetcher.NewChromeDPFetcher()
+	f, err := fetcher.New(cfg.Fetcher)
 	if err != nil {
 		log.Fatalf("Error creating fetcher: %v", err)
 	}
-	defer f.Close() // Ensure browser resources are released
+	f.SetTimeouts(cfg.Timeouts.Navigation, cfg.Timeouts.Extraction)
 
-	// Initialize LLM Client
-	l, err := llm.NewOpenAIClient()
+	// Local-only domains are summarized by the on-prem model, or refused without one
+	local, err := llm.NewLocal()
 	if err != nil {
-		log.Fatalf("Error creating LLM client: %v", err)
+		f.Close()
+		log.Fatalf("Error creating local LLM client: %v", err)
+	}
+
+	urlPolicy, err := policy.NewFromEnv()
+	if err != nil {
+		f.Close()
+		log.Fatalf("Error loading URL policy: %v", err)
 	}
 
 	// Initialize App
 	application := app.NewApp(f, l)
-
-	// Process the URL
-	log.Printf("Processing URL: %s", *url)
-	if *prompt != "" {
-		log.Printf("With user prompt: %s", *prompt)
+	application.SetPolicy(urlPolicy, local)
+	application.SetLLMTimeout(cfg.Timeouts.LLM)
+	application.SetJSONRepairAttempts(cfg.JSONRepairAttempts)
+	application.SetModelParams(modelParams(cfg))
+	application.SetChunking(cfg.ChunkThreshold, cfg.ChunkSize)
+	application.SetLengthRouting(app.LengthRouting(cfg.Routing))
+	prices, err := cost.ParsePrices(cfg.LLMPrices)
+	if err != nil {
+		f.Close()
+		log.Fatalf("Error loading LLM prices: %v", err)
 	}
-
-	result, err := application.ProcessURL(ctx, *url, *prompt)
+	cost.SetPrices(prices)
+	application.SetCostMeter(cost.NewMeter()) // Logs the cost of every call
+	meetings, err := withMeetings(f, cfg.Meetings)
+	if err != nil {
+		f.Close()
+		log.Fatalf("Error setting up meeting transcripts: %v", err)
+	}
+	application.SetFetcher(meetings)
+	retry.SetLimits(cfg.Retry.MaxAttempts, cfg.Retry.MaxElapsed)
+	if cfg.Transcription.Enabled {
+		transcriber, err := llm.NewOpenAITranscriber(cfg.Transcription.Model)
+		if err != nil {
+			f.Close()
+			log.Fatalf("Error creating the transcriber: %v", err)
+		}
+		application.SetTranscriber(transcriber, cfg.Transcription.MaxBytes)
+	}
+	if cfg.Relevance.Enabled {
+		embedder, err := llm.NewOpenAIEmbedder(cfg.Relevance.Model)
+		if err != nil {
+			f.Close()
+			log.Fatalf("Error creating the embedder: %v", err)
+		}
+		application.SetRelevanceFilter(embedder, app.RelevanceFilter{
+			Threshold: cfg.Relevance.Threshold,
+			ChunkSize: cfg.Relevance.ChunkSize,
+			MaxChars:  cfg.Relevance.MaxChars,
+		})
+	}
+	application.SetSigningKey([]byte(cfg.ProvenanceSigningKey))
+	screeningModel := cfg.ScreeningModel
+	if screeningModel == "" {
+		screeningModel = llm.DefaultQuickModel(cfg.LLMProvider)
+	}
+	application.SetScreening(screeningModel, cfg.ScreeningTopics)
+	if cfg.AuditLog != "" {
+		sink, err := audit.Open(cfg.AuditLog)
+		if err != nil {
+			f.Close()
+			log.Fatalf("Error opening the audit log: %v", err)
+		}
+		application.SetAuditLog(audit.New(sink))
+	}
+	p, err := persona.Open(cfg.SystemPromptPrefix, cfg.SystemPromptPrefixFile)
+	if err != nil {
+		f.Close()
+		log.Fatalf("Error loading system prompt prefix: %v", err)
+	}
+	application.SetPersona(p)
+	profile, err := persona.Open(cfg.JobProfile, cfg.JobProfileFile)
+	if err != nil {
+		f.Close()
+		log.Fatalf("Error loading job profile: %v", err)
+	}
+	application.SetJobProfile(profile)
+	flags, err := feature.New(cfg.Features, cfg.ChannelFeatures)
 	if err != nil {
-		log.Fatalf("Error processing URL: %v", err)
+		f.Close()
+		log.Fatalf("Error loading feature flags: %v", err)
 	}
+	application.SetFeatures(flags)
+	filters, err := output.New(cfg.Output.Filters, cfg.Output.MaxLength, cfg.Output.RequiredSections)
+	if err != nil {
+		f.Close()
+		log.Fatalf("Error loading output filters: %v", err)
+	}
+	application.SetOutputFilters(filters)
 
-	// Print the result
-	fmt.Println(result)
-	log.Println("Processing finished successfully.")
+	return application, l, f.Close
+}
+
+// withMeetings wraps f so meeting links configured in cfg are read as transcripts.
+func withMeetings(f fetcher.Fetcher, cfg config.Meetings) (fetcher.Fetcher, error) {
+	var zoom, google fetcher.TokenFunc
+	if cfg.ZoomAccountID != "" {
+		zoom = fetcher.ZoomToken(cfg.ZoomAccountID, cfg.ZoomClientID, cfg.ZoomClientSecret)
+	}
+	if cfg.GoogleMeet {
+		var err error
+		if google, err = fetcher.GoogleToken(); err != nil {
+			return nil, fmt.Errorf("failed to load Google credentials: %w", err)
+		}
+	}
+	if zoom == nil && google == nil {
+		return f, nil
+	}
+	return fetcher.NewMeetings(f, zoom, google), nil
+}
+
+// newBatchApp is newApp for commands fetching many pages unattended (eval, watch). Fetches are
+// limited per host so a batch never hammers one site.
+func newBatchApp(cfg *config.Config) (*app.App, llm.LLM, func()) {
+	application, l, closeApp := newApp(cfg)
+	application.SetFetcher(fetcher.NewPolite(application.GetFetcher(), cfg.Politeness.PerHost, cfg.Politeness.Delay))
+	return application, l, closeApp
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// runPack implements `describe-kun pack -urls ...`, which bundles the extracted text and summary of every URL
+// into a context pack to paste into other AI tools.
+func runPack(args []string) {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("pack", flag.ExitOnError)
+	urls := fs.String("urls", "", "Comma-separated URLs of the pages to pack (required)")
+	output := fs.String("o", "", "Write the pack to this file instead of stdout")
+	format := fs.String("format", "markdown", "Output format: markdown or json")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Timeout for the entire pack")
+	registerTimeoutFlags(fs, cfg)
+	registerProviderFlag(fs, cfg)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: describe-kun pack -urls <url,url,...> [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var pages []string
+	for _, u := range strings.Split(*urls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			pages = append(pages, u)
+		}
+	}
+	if len(pages) == 0 {
+		fs.Usage()
+		log.Fatal("Error: -urls is required")
+	}
+	if *format != "markdown" && *format != "json" {
+		log.Fatalf("Error: unknown -format %q (markdown or json)", *format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	application, _, closeApp := newApp(cfg)
+	defer closeApp()
+
+	pack, err := application.ProcessContextPack(ctx, pages, func(message string) { log.Print(message) })
+	if err != nil {
+		closeApp()
+		log.Fatalf("Error creating the context pack: %v", err)
+	}
+
+	text := pack.Markdown()
+	if *format == "json" {
+		data, err := json.MarshalIndent(pack, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding the context pack: %v", err)
+		}
+		text = string(data) + "\n"
+	}
+	if *output == "" {
+		fmt.Print(text)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(text), 0o644); err != nil {
+		closeApp()
+		log.Fatalf("Error writing %s: %v", *output, err)
+	}
+	log.Printf("Wrote a context pack of %d page(s) to %s", len(pack.Pages), *output)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/feed"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/slackapi"
+	"github.com/slack-go/slack"
+)
+
+// runPodcastDigest implements `describe-kun podcast-digest`, meant to be run weekly (e.g. from cron).
+// It summarizes the show notes of new episodes and posts a single rollup to Slack, or prints it.
+func runPodcastDigest(args []string) {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("podcast-digest", flag.ExitOnError)
+	feeds := fs.String("feeds", strings.Join(cfg.Podcast.Feeds, ","), "Comma-separated RSS feed URLs (default: PODCAST_FEEDS)")
+	channel := fs.String("channel", cfg.Podcast.Channel, "Slack channel ID to post the digest to; empty prints it (default: PODCAST_DIGEST_CHANNEL)")
+	since := fs.Duration("since", 7*24*time.Hour, "Include episodes published within this period")
+	timeout := fs.Duration("timeout", 30*time.Minute, "Timeout for the whole digest")
+	fs.DurationVar(&cfg.Timeouts.LLM, "llm-timeout", cfg.Timeouts.LLM, "Timeout for a single LLM call (0 disables)")
+	batch := fs.Bool("batch", false, "Submit the summaries through the OpenAI Batch API (about half the price) and deliver the digest from a later run once they are done")
+	collect := fs.Bool("collect", false, "Only deliver the digests of finished batches, without building a new digest")
+	batchState := fs.String("batch-state", cfg.Podcast.BatchStateFile, "File where submitted batches are kept until delivered (default: PODCAST_BATCH_STATE_FILE)")
+	registerProviderFlag(fs, cfg)
+	fs.Parse(args)
+
+	if (*batch || *collect) && *batchState == "" {
+		log.Fatal("Error: -batch and -collect need a state file; set -batch-state or PODCAST_BATCH_STATE_FILE")
+	}
+
+	var urls []string
+	for _, u := range strings.Split(*feeds, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 && !*collect {
+		fs.Usage()
+		log.Fatal("Error: no feeds given; set -feeds or PODCAST_FEEDS")
+	}
+	if *channel != "" && os.Getenv("SLACK_BOT_TOKEN") == "" {
+		log.Fatal("Error: SLACK_BOT_TOKEN environment variable not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	l, err := llm.New(cfg.LLMProvider)
+	if err != nil {
+		log.Fatalf("Error creating LLM client: %v", err)
+	}
+	application := app.NewApp(nil, l)
+	application.SetLLMTimeout(cfg.Timeouts.LLM)
+	application.SetModelParams(modelParams(cfg))
+
+	// Deliver the digests of batches submitted by earlier runs that have finished since
+	if *batchState != "" {
+		collectPodcastDigests(ctx, application, *batchState)
+	}
+	if *collect {
+		return
+	}
+
+	// Feeds are plain XML, so no browser is needed. Unchanged feeds are answered from the state file.
+	poller, err := feed.NewPoller(&http.Client{Timeout: cfg.Timeouts.Navigation}, cfg.Podcast.StateFile)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	var parsed []*feed.Feed
+	for _, u := range urls {
+		f, _, err := poller.Poll(ctx, u)
+		if err != nil {
+			log.Printf("Skipping feed: %v", err)
+			continue
+		}
+		parsed = append(parsed, f)
+	}
+
+	if *batch {
+		p, err := application.SubmitPodcastDigest(ctx, parsed, time.Now().Add(-*since))
+		if err != nil {
+			log.Fatalf("Error submitting digest: %v", err)
+		}
+		if p == nil {
+			log.Println("No new episodes.")
+			return
+		}
+		pending, err := loadPendingDigests(*batchState)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if err := savePendingDigests(*batchState, append(pending, pendingDigest{PendingDigest: *p, Channel: *channel})); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		log.Printf("Submitted batch %s; run podcast-digest -collect to deliver the digest once it is done", p.BatchID)
+		return
+	}
+
+	digest, err := application.PodcastDigest(ctx, parsed, time.Now().Add(-*since))
+	if err != nil {
+		log.Fatalf("Error building digest: %v", err)
+	}
+	if digest == "" {
+		log.Println("No new episodes.")
+		return
+	}
+	if err := deliverDigest(ctx, *channel, digest); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// pendingDigest is a submitted digest together with where to deliver it.
+type pendingDigest struct {
+	app.PendingDigest
+	Channel string `json:"channel,omitempty"`
+}
+
+// collectPodcastDigests delivers the finished digests kept in the state file at path and keeps the others.
+func collectPodcastDigests(ctx context.Context, application *app.App, path string) {
+	pending, err := loadPendingDigests(path)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	var remaining []pendingDigest
+	for _, p := range pending {
+		digest, done, err := application.CollectPodcastDigest(ctx, &p.PendingDigest)
+		switch {
+		case err != nil && done:
+			log.Printf("Dropping batch %s: %v", p.BatchID, err)
+		case err != nil:
+			log.Printf("Error collecting batch %s, retrying on the next run: %v", p.BatchID, err)
+			remaining = append(remaining, p)
+		case !done:
+			log.Printf("Batch %s is still running", p.BatchID)
+			remaining = append(remaining, p)
+		default:
+			if err := deliverDigest(ctx, p.Channel, digest); err != nil {
+				log.Printf("Error delivering batch %s, retrying on the next run: %v", p.BatchID, err)
+				remaining = append(remaining, p)
+			}
+		}
+	}
+	if err := savePendingDigests(path, remaining); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+// deliverDigest posts digest to channel, or prints it if channel is empty.
+func deliverDigest(ctx context.Context, channel, digest string) error {
+	if channel == "" {
+		fmt.Println(digest)
+		return nil
+	}
+	if os.Getenv("SLACK_BOT_TOKEN") == "" {
+		return errors.New("SLACK_BOT_TOKEN environment variable not set")
+	}
+	api := slackapi.New(os.Getenv("SLACK_BOT_TOKEN"))
+	if _, _, err := api.PostMessageContext(ctx, channel, slack.MsgOptionText(digest, false)); err != nil {
+		return fmt.Errorf("posting digest to Slack: %w", err)
+	}
+	log.Printf("Posted podcast digest to %s", channel)
+	return nil
+}
+
+// loadPendingDigests reads the state file at path; a missing file has no pending digests.
+func loadPendingDigests(path string) ([]pendingDigest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading batch state: %w", err)
+	}
+	var pending []pendingDigest
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, fmt.Errorf("decoding batch state %s: %w", path, err)
+	}
+	return pending, nil
+}
+
+// savePendingDigests replaces the state file at path.
+func savePendingDigests(path string, pending []pendingDigest) error {
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding batch state: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("writing batch state: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("writing batch state: %w", err)
+	}
+	return nil
+}
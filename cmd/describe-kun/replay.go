@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/history"
+	"github.com/kznrluk/describe-kun/internal/textdiff"
+)
+
+// runReplay implements `describe-kun replay <job-id>`, which re-runs a job recorded in the history
+// with the current prompts and models and diffs the new summary against the recorded one.
+// It exits with status 1 when the summaries differ, so upgrades can be checked in scripts.
+func runReplay(args []string) {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	historyFile := fs.String("history", cfg.HistoryFile, "History file the job was recorded in (default: HISTORY_FILE)")
+	cached := fs.Bool("cached", false, "Summarize the recorded page text instead of refetching (needs HISTORY_CONTENT when recorded)")
+	timeout := fs.Duration("timeout", 5*time.Minute, "Timeout for the replay")
+	registerTimeoutFlags(fs, cfg)
+	registerProviderFlag(fs, cfg)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: describe-kun replay [flags] <job-id>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		log.Fatal("Error: exactly one job ID is required")
+	}
+	if *historyFile == "" {
+		log.Fatal("Error: no history file; set -history or HISTORY_FILE")
+	}
+	entry, err := history.NewStore(*historyFile).Get(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *cached && entry.Content == "" {
+		log.Printf("Job %s has no recorded content; refetching %s", entry.ID, entry.URL)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	application, _, closeApp := newApp(cfg)
+	defer closeApp()
+
+	log.Printf("Replaying job %s from %s: %s", entry.ID, entry.Time.Format(time.RFC3339), entry.URL)
+	result, err := application.Replay(ctx, entry, *cached)
+	if err != nil {
+		closeApp()
+		log.Fatalf("Error replaying job: %v", err)
+	}
+
+	fmt.Printf("URL:    %s\n", entry.URL)
+	if entry.Prompt != "" {
+		fmt.Printf("Prompt: %s\n", entry.Prompt)
+	}
+	fmt.Printf("Model:  %s -> %s\n", orNone(entry.Model), result.Model)
+	fmt.Printf("Tokens: %d -> %d\n\n", entry.Tokens, result.Usage.TotalTokens)
+	if entry.Error != "" {
+		fmt.Printf("The recorded job failed: %s\n\n%s\n", entry.Error, result.Summary)
+		return
+	}
+
+	diff := textdiff.Lines(entry.Summary, result.Summary)
+	if !textdiff.Changed(diff) {
+		fmt.Println("The summary is unchanged.")
+		return
+	}
+	fmt.Print(textdiff.Format(diff))
+	closeApp()
+	os.Exit(1)
+}
+
+// orNone returns s, or "(none)" when it is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// runReport implements `describe-kun report -urls ... -question ...`, which writes a multi-section research
+// report answering the question from every URL, citing the sources of each claim.
+func runReport(args []string) {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	urls := fs.String("urls", "", "Comma-separated URLs of the sources (required)")
+	question := fs.String("question", "", "Question the report answers (required)")
+	output := fs.String("o", "", "Write the report to this file instead of stdout")
+	format := fs.String("format", "markdown", "Output format: markdown or json")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Timeout for the entire report")
+	registerTimeoutFlags(fs, cfg)
+	registerProviderFlag(fs, cfg)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: describe-kun report -urls <url,url,...> -question <question> [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var sources []string
+	for _, u := range strings.Split(*urls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			sources = append(sources, u)
+		}
+	}
+	if len(sources) == 0 || *question == "" {
+		fs.Usage()
+		log.Fatal("Error: -urls and -question are required")
+	}
+	if *format != "markdown" && *format != "json" {
+		log.Fatalf("Error: unknown -format %q (markdown or json)", *format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	application, _, closeApp := newApp(cfg)
+	defer closeApp()
+
+	report, err := application.ProcessReport(ctx, sources, *question, func(message string) { log.Print(message) })
+	if err != nil {
+		closeApp()
+		log.Fatalf("Error writing the report: %v", err)
+	}
+
+	text := report.Markdown()
+	if *format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding the report: %v", err)
+		}
+		text = string(data) + "\n"
+	}
+	if *output == "" {
+		fmt.Print(text)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(text), 0o644); err != nil {
+		closeApp()
+		log.Fatalf("Error writing %s: %v", *output, err)
+	}
+	log.Printf("Wrote the report %q to %s", report.Title, *output)
+}
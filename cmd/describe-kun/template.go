@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// slackLinkRegex matches Slack-style links (<url|label>) that summaries may contain, e.g. video timestamps.
+var slackLinkRegex = regexp.MustCompile(`<(https?://[^|>]+)\|([^>]+)>`)
+
+// templateFuncs are available to -template files in addition to the text/template builtins.
+var templateFuncs = template.FuncMap{
+	// csv quotes s as a single CSV field
+	"csv": func(s string) (string, error) {
+		var b bytes.Buffer
+		w := csv.NewWriter(&b)
+		if err := w.Write([]string{s}); err != nil {
+			return "", err
+		}
+		w.Flush()
+		return strings.TrimSuffix(b.String(), "\n"), w.Error()
+	},
+	// oneline collapses all whitespace, newlines included, into single spaces
+	"oneline": func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	},
+	// md rewrites Slack links as Markdown links
+	"md": func(s string) string {
+		return slackLinkRegex.ReplaceAllString(s, "[$2]($1)")
+	},
+	// org rewrites Slack links as org-mode links
+	"org": func(s string) string {
+		return slackLinkRegex.ReplaceAllString(s, "[[$1][$2]]")
+	},
+}
+
+// loadTemplate parses the Go template at path for rendering an app.Result.
+func loadTemplate(path string) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+)
+
+func TestLoadTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "row.tmpl")
+	content := `{{csv .Metadata.Title}},{{csv (oneline .Summary)}},{{org .Summary | oneline}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := loadTemplate(path)
+	if err != nil {
+		t.Fatalf("loadTemplate failed: %v", err)
+	}
+	result := &app.Result{
+		Metadata: fetcher.Metadata{Title: `Go "1.24"`},
+		Summary:  "<https://youtu.be/x?t=0s|00:00> Intro,\n- point",
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, result); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	want := `"Go ""1.24""","<https://youtu.be/x?t=0s|00:00> Intro, - point",[[https://youtu.be/x?t=0s][00:00]] Intro, - point`
+	if b.String() != want {
+		t.Errorf("Rendered\n%s\nwant\n%s", b.String(), want)
+	}
+
+	if _, err := loadTemplate(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("Expected an error for a missing template")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/slackapi"
+	"github.com/kznrluk/describe-kun/internal/snapshot"
+	"github.com/slack-go/slack"
+)
+
+// runWatch implements `describe-kun watch`, which monitors pages for meaningful changes. Each run
+// compares the pages with their previous snapshots and reports what changed, described by the LLM.
+// Without -interval it checks once, for use from cron.
+func runWatch(args []string) {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	urls := fs.String("urls", strings.Join(cfg.Watch.URLs, ","), "Comma-separated page URLs to watch (default: WATCH_URLS)")
+	channel := fs.String("channel", cfg.Watch.Channel, "Slack channel ID to post changes to; empty prints them (default: WATCH_CHANNEL)")
+	dir := fs.String("snapshots", cfg.Watch.SnapshotDir, "Directory where page snapshots are kept (default: WATCH_SNAPSHOT_DIR)")
+	minChange := fs.Float64("min-change", cfg.Watch.MinChange, "Ignore changes to less than this fraction of lines (0-1) (default: WATCH_MIN_CHANGE)")
+	interval := fs.Duration("interval", 0, "Check again after this interval; 0 checks once and exits")
+	registerTimeoutFlags(fs, cfg)
+	registerProviderFlag(fs, cfg)
+	fs.Parse(args)
+
+	var pages []string
+	for _, u := range strings.Split(*urls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			pages = append(pages, u)
+		}
+	}
+	if len(pages) == 0 {
+		fs.Usage()
+		log.Fatal("Error: no pages given; set -urls or WATCH_URLS")
+	}
+	if *dir == "" {
+		log.Fatal("Error: no snapshot directory; set -snapshots or WATCH_SNAPSHOT_DIR")
+	}
+	if *channel != "" && os.Getenv("SLACK_BOT_TOKEN") == "" {
+		log.Fatal("Error: SLACK_BOT_TOKEN environment variable not set")
+	}
+	store, err := snapshot.NewStore(*dir, 0)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	application, _, closeApp := newBatchApp(cfg)
+	defer closeApp()
+
+	for {
+		for _, u := range pages {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			change, err := application.CheckPage(ctx, store, u, *minChange)
+			if err == nil && change != nil {
+				err = reportChange(ctx, *channel, change)
+			}
+			cancel()
+			if err != nil {
+				log.Printf("Error checking %s: %v", u, err)
+			}
+		}
+		if *interval == 0 {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// reportChange posts change to channel, or prints it without one.
+func reportChange(ctx context.Context, channel string, change *app.Change) error {
+	text := fmt.Sprintf(":mag: <%s> が変更されました（%s 以降、%.0f%%の行）\n%s",
+		change.URL, change.Since.Format("2006-01-02 15:04"), change.Ratio*100, change.Description)
+	if channel == "" {
+		fmt.Printf("%s\n\n", text)
+		return nil
+	}
+	api := slackapi.New(os.Getenv("SLACK_BOT_TOKEN"))
+	if _, _, err := api.PostMessageContext(ctx, channel, slack.MsgOptionText(text, false)); err != nil {
+		return fmt.Errorf("posting change to Slack: %w", err)
+	}
+	return nil
+}
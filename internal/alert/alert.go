@@ -0,0 +1,51 @@
+package alert
+
+import "strings"
+
+// Matcher finds configured keywords in text, ignoring case.
+// Matching is by substring so that it also works for languages written without spaces, such as Japanese.
+type Matcher struct {
+	keywords []string
+	lower    []string
+}
+
+// NewMatcher creates a Matcher for keywords; empty entries are ignored.
+func NewMatcher(keywords []string) *Matcher {
+	m := &Matcher{}
+	for _, k := range keywords {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		m.keywords = append(m.keywords, k)
+		m.lower = append(m.lower, strings.ToLower(k))
+	}
+	return m
+}
+
+// Empty reports whether the matcher has no keywords. A nil Matcher is empty.
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.keywords) == 0
+}
+
+// Match returns the keywords found in any of texts, in configuration order.
+func (m *Matcher) Match(texts ...string) []string {
+	if m.Empty() {
+		return nil
+	}
+	lowered := make([]string, len(texts))
+	for i, t := range texts {
+		lowered[i] = strings.ToLower(t)
+	}
+
+	var found []string
+	for i, k := range m.lower {
+		for _, t := range lowered {
+			if strings.Contains(t, k) {
+				found = append(found, m.keywords[i])
+				break
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,23 @@
+package alert
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	m := NewMatcher([]string{"describe-kun", " Competitor ", "", "セキュリティ"})
+
+	got := m.Match("A review of COMPETITOR X", "新しいセキュリティ機能")
+	if want := []string{"Competitor", "セキュリティ"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Match = %q, want %q", got, want)
+	}
+	if got := m.Match("nothing relevant"); got != nil {
+		t.Errorf("Expected no match, got %q", got)
+	}
+
+	var empty *Matcher
+	if !empty.Empty() || empty.Match("describe-kun") != nil {
+		t.Error("Expected a nil Matcher to be empty")
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/kznrluk/describe-kun/internal/cost"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/provenance"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// maxBodyBytes bounds the size of a request body, provided HTML included.
+const maxBodyBytes = 10 << 20
+
+// budgetScope is the budget scope API usage is accounted to.
+const budgetScope = "api"
+
+// SummarizeRequest is the body of POST /api/summarize.
+// Exactly one of URL alone, HTML (URL then only sets the base URL) or Text must be given.
+type SummarizeRequest struct {
+	URL    string `json:"url,omitempty"`
+	HTML   string `json:"html,omitempty"`
+	Text   string `json:"text,omitempty"`
+	Prompt string `json:"prompt,omitempty"`
+	Format string `json:"format,omitempty"` // "text" (default), or "markdown" or "json" for a summary generated as structured data
+}
+
+// SummarizeResponse is the response of POST /api/summarize.
+type SummarizeResponse struct {
+	Summary    string       `json:"summary,omitempty"`    // The summary as Slack text, or Markdown with the markdown format
+	Structured *app.Summary `json:"structured,omitempty"` // Set with the json format
+	Error      string       `json:"error,omitempty"`
+
+	// Provenance describes the page text and model the summary was made from, for URL and HTML requests.
+	// Its signature covers the summary field, so it is unsigned with the json format.
+	Provenance *provenance.Provenance `json:"provenance,omitempty"`
+}
+
+// Handler serves the HTTP API for systems that want summaries without going through Slack.
+type Handler struct {
+	app     *app.App
+	token   string
+	timeout time.Duration // Limit for one request, 0 means none
+	costs   *cost.Meter   // Served by HandleUsage; nil disables it
+}
+
+// NewHandler creates a Handler; requests must carry token as a bearer token.
+func NewHandler(appCore *app.App, token string) *Handler {
+	return &Handler{app: appCore, token: token}
+}
+
+// SetTimeout sets the limit for handling one request. Zero disables it.
+func (h *Handler) SetTimeout(d time.Duration) {
+	h.timeout = d
+}
+
+// SetCostMeter makes HandleUsage serve the LLM usage and cost totals of m.
+func (h *Handler) SetCostMeter(m *cost.Meter) {
+	h.costs = m
+}
+
+// HandleUsage serves the LLM usage and estimated cost of a day per channel, user and model as JSON,
+// see cost.Meter.Handler for the parameters.
+func (h *Handler) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, SummarizeResponse{Error: "method not allowed"})
+		return
+	}
+	if !h.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, SummarizeResponse{Error: "unauthorized"})
+		return
+	}
+	if h.costs == nil {
+		writeJSON(w, http.StatusNotFound, SummarizeResponse{Error: "usage is not tracked"})
+		return
+	}
+	h.costs.Handler()(w, r)
+}
+
+// authorized reports whether r carries the API token as a bearer token.
+func (h *Handler) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+// HandleSummarize summarizes a URL, or content the caller already has (HTML or plain text), bypassing the fetcher.
+func (h *Handler) HandleSummarize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, SummarizeResponse{Error: "method not allowed"})
+		return
+	}
+	if !h.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, SummarizeResponse{Error: "unauthorized"})
+		return
+	}
+
+	var req SummarizeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, SummarizeResponse{Error: "invalid JSON body: " + err.Error()})
+		return
+	}
+	if (req.HTML != "" && req.Text != "") || (req.URL == "" && req.HTML == "" && req.Text == "") {
+		writeJSON(w, http.StatusBadRequest, SummarizeResponse{Error: "exactly one of url, html or text is required"})
+		return
+	}
+	switch req.Format {
+	case "", "text", "markdown", "json":
+	default:
+		writeJSON(w, http.StatusBadRequest, SummarizeResponse{Error: "format must be text, markdown or json"})
+		return
+	}
+
+	ctx := budget.WithScope(r.Context(), budgetScope)
+	ctx = reqmeta.Update(ctx, func(m *reqmeta.Metadata) {
+		m.Source = reqmeta.SourceAPI
+		m.Trigger = reqmeta.TriggerHTTP
+	})
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	var resp SummarizeResponse
+	var err error
+	switch {
+	case req.Format == "markdown" || req.Format == "json":
+		var result *app.Result
+		result, err = h.structured(ctx, req)
+		if err == nil {
+			resp.Structured = result.Structured
+			if result.Provenance != nil {
+				// The structure is not signed, only the page and model it was made from are described
+				p := *result.Provenance
+				p.SummarySHA256, p.Signature = "", ""
+				resp.Provenance = &p
+			}
+		}
+		if err == nil && req.Format == "markdown" {
+			resp.Summary, resp.Structured = resp.Structured.Markdown(), nil
+			resp.Provenance = h.app.ProvenanceFor(result, resp.Summary)
+		}
+	case req.Text != "":
+		resp.Summary, err = h.app.ProcessContent(ctx, req.Text, req.Prompt)
+	default:
+		var result *app.Result
+		result, err = h.app.Summarize(ctx, fetcher.FetchRequest{URL: req.URL, HTML: req.HTML}, req.Prompt)
+		if err == nil {
+			resp.Summary, resp.Provenance = result.Summary, result.Provenance
+		}
+	}
+	switch {
+	case errors.Is(err, budget.ErrExhausted):
+		writeJSON(w, http.StatusTooManyRequests, SummarizeResponse{Error: err.Error()})
+	case err != nil:
+		reqmeta.Logf(ctx, "[API] Error summarizing: %v", err)
+		writeJSON(w, http.StatusInternalServerError, SummarizeResponse{Error: err.Error()})
+	default:
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// structured summarizes the URL, HTML or text of req as structured data. Results of text have no provenance.
+func (h *Handler) structured(ctx context.Context, req SummarizeRequest) (*app.Result, error) {
+	if req.Text != "" {
+		summary, err := h.app.StructuredContent(ctx, req.Text, req.Prompt)
+		if err != nil {
+			return nil, err
+		}
+		return &app.Result{Structured: summary}, nil
+	}
+	return h.app.Summarize(app.WithStructured(ctx), fetcher.FetchRequest{URL: req.URL, HTML: req.HTML}, req.Prompt)
+}
+
+// writeJSON writes v as a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("[API] Error writing response: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/cost"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/provenance"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// echoLLM returns the user message as the summary.
+type echoLLM struct{}
+
+func (echoLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	return &llm.Response{Text: messages[len(messages)-1].Text()}, nil
+}
+
+func TestHandleSummarize(t *testing.T) {
+	h := NewHandler(app.NewApp(nil, echoLLM{}), "secret")
+
+	call := func(token, body string) (int, SummarizeResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/summarize", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.HandleSummarize(rec, req)
+		var resp SummarizeResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp
+	}
+
+	if code, _ := call("wrong", `{"text":"hello"}`); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", code)
+	}
+	if code, _ := call("secret", `{}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without input, got %d", code)
+	}
+	if code, _ := call("secret", `{"html":"<p>a</p>","text":"a"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for both html and text, got %d", code)
+	}
+
+	code, resp := call("secret", `{"text":"Newsletter body","prompt":"what is new?"}`)
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d (%s)", code, resp.Error)
+	}
+	if !strings.Contains(resp.Summary, "Newsletter body") || !strings.Contains(resp.Summary, "what is new?") {
+		t.Errorf("Expected the text to be summarized directly, got %q", resp.Summary)
+	}
+}
+
+// pageFetcher returns the same page text for every request.
+type pageFetcher struct{ text string }
+
+func (f pageFetcher) Fetch(ctx context.Context, req fetcher.FetchRequest) (*fetcher.FetchResult, error) {
+	return &fetcher.FetchResult{Text: f.text, FinalURL: req.URL}, nil
+}
+
+func TestHandleSummarize_Provenance(t *testing.T) {
+	a := app.NewApp(pageFetcher{text: "Quarterly report"}, echoLLM{})
+	key := []byte("signing-key")
+	a.SetSigningKey(key)
+	h := NewHandler(a, "")
+
+	call := func(body string) SummarizeResponse {
+		rec := httptest.NewRecorder()
+		h.HandleSummarize(rec, httptest.NewRequest(http.MethodPost, "/api/summarize", strings.NewReader(body)))
+		var resp SummarizeResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d (%s)", rec.Code, resp.Error)
+		}
+		return resp
+	}
+
+	resp := call(`{"url":"https://example.com/report"}`)
+	p := resp.Provenance
+	if p == nil || p.URL != "https://example.com/report" || p.ContentSHA256 != provenance.Hash("Quarterly report") || p.PromptVersion != llm.PromptVersion {
+		t.Fatalf("Expected the provenance of the page, got %+v", p)
+	}
+	if !p.Verify(resp.Summary, key) {
+		t.Error("Expected the signature to verify the summary")
+	}
+
+	// Text has no source to describe
+	if resp := call(`{"text":"Quarterly report"}`); resp.Provenance != nil {
+		t.Errorf("Expected no provenance for text, got %+v", resp.Provenance)
+	}
+}
+
+// summaryLLM replies with a structured summary.
+type summaryLLM struct{}
+
+func (summaryLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	return &llm.Response{Text: `{"title": "Release", "tldr": ["One", "Two", "Three"], "sections": [], "answer_to_question": ""}`}, nil
+}
+
+func TestHandleSummarize_Format(t *testing.T) {
+	h := NewHandler(app.NewApp(nil, summaryLLM{}), "secret")
+	call := func(body string) (int, SummarizeResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/summarize", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		h.HandleSummarize(rec, req)
+		var resp SummarizeResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp
+	}
+
+	code, resp := call(`{"text":"Release notes","format":"json"}`)
+	if code != http.StatusOK || resp.Structured == nil || resp.Structured.Title != "Release" || len(resp.Structured.TLDR) != 3 || resp.Summary != "" {
+		t.Errorf("Expected the structured summary, got %d %+v", code, resp)
+	}
+	code, resp = call(`{"text":"Release notes","format":"markdown"}`)
+	if code != http.StatusOK || !strings.HasPrefix(resp.Summary, "# Release\n") || resp.Structured != nil {
+		t.Errorf("Expected the summary as Markdown, got %d %+v", code, resp)
+	}
+	if code, _ := call(`{"text":"Release notes","format":"xml"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", code)
+	}
+}
+
+func TestHandleUsage(t *testing.T) {
+	h := NewHandler(app.NewApp(nil, echoLLM{}), "secret")
+	call := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/usage", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		h.HandleUsage(rec, req)
+		return rec
+	}
+
+	if rec := call("secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a meter, got %d", rec.Code)
+	}
+	meter := cost.NewMeter()
+	h.SetCostMeter(meter)
+	meter.Record(reqmeta.With(context.Background(), reqmeta.Metadata{Channel: "C1", User: "U1"}), "gpt-4o", llm.Usage{PromptTokens: 1000, CompletionTokens: 100})
+	if rec := call("wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", rec.Code)
+	}
+	rec := call("secret")
+	var rows []cost.Row
+	if err := json.NewDecoder(rec.Body).Decode(&rows); err != nil || len(rows) != 1 || rows[0].Channel != "C1" || rows[0].Calls != 1 {
+		t.Errorf("Expected today's usage of C1, got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"context"
+
+	"github.com/kznrluk/describe-kun/internal/policy"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// SetChannelActions limits the additional pages requests of each channel may fetch through tool calls and
+// crawling, and their domains; see policy.ChannelActions. Requests of other channels keep the limits of
+// each feature. It must be called before any request is made.
+func (a *App) SetChannelActions(actions map[string]policy.Actions) {
+	a.channelActions = actions
+}
+
+// actions returns the action policy of the channel of ctx.
+func (a *App) actions(ctx context.Context) policy.Actions {
+	if act, ok := a.channelActions[reqmeta.From(ctx).Channel]; ok {
+		return act
+	}
+	return policy.Actions{MaxFetches: -1}
+}
@@ -4,15 +4,74 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/kznrluk/describe-kun/internal/audit"
+	"github.com/kznrluk/describe-kun/internal/cost"
+	"github.com/kznrluk/describe-kun/internal/feature"
 	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/history"
 	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/metrics"
+	"github.com/kznrluk/describe-kun/internal/output"
+	"github.com/kznrluk/describe-kun/internal/persona"
+	"github.com/kznrluk/describe-kun/internal/policy"
+	"github.com/kznrluk/describe-kun/internal/provenance"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/kznrluk/describe-kun/internal/timeout"
 )
 
 // App encapsulates the core application logic.
 type App struct {
 	fetcher fetcher.Fetcher
 	llm     llm.LLM
+
+	mu       sync.RWMutex     // Guards the settings below, which may be reloaded while requests run
+	localLLM llm.LLM          // Optional on-prem model used for local-only domains
+	policy   *policy.Policy   // Optional domain routing policy
+	persona  *persona.Persona // Optional operator system prompt prefix
+	features *feature.Flags   // Experimental capabilities; nil uses the defaults
+
+	jobProfile *persona.Persona // Optional profile job postings are scored against
+
+	toolFetchBudget    int           // Extra pages the model may fetch per thread question
+	llmTimeout         time.Duration // Limit for a single LLM call, 0 means none
+	params             llm.Params    // Sampling parameters of calls that do not set their own
+	jsonRepairAttempts int           // Repair prompts allowed for a malformed JSON reply
+	chunkThreshold     int           // Content length above which pages are summarized in chunks; 0 disables chunking
+	chunkSize          int           // Length of the chunks
+	lengthRouting      LengthRouting // Models of short and long pages; guarded by mu
+
+	screeningModel  string   // Cheap model used by IsRelevant
+	screeningTopics []string // Topics pipelines care about; empty disables screening
+
+	visionModel string // Model used for images; empty uses the default model
+	quickModel  string // Cheap model used by QuickSummary; empty uses the default model
+
+	transcriber           Transcriber // Optional speech to text of audio and video links
+	transcriptionMaxBytes int64       // Largest audio file downloaded for transcription
+
+	signingKey []byte // Signs the provenance of summaries; empty leaves it unsigned
+
+	channelActions map[string]policy.Actions // Limits of additional fetches by channel
+
+	checkToolURL func(ctx context.Context, rawURL string) error // Rejects tool fetches of internal addresses; nil uses policy.CheckPublicURL
+
+	embedder  Embedder        // Optional embeddings of page chunks and questions
+	relevance RelevanceFilter // Which pages are narrowed to the chunks relevant to the question
+
+	summaryHooks   []SummaryHook  // Run after every successful page summary; guarded by mu
+	history        *history.Store // Optional record of page summaries
+	historyContent bool           // Whether history entries keep the extracted text
+	shadow         *Shadow        // Optional candidate tried on a sample of summaries; guarded by mu
+	outputFilters  output.Chain   // Post-processing of page summaries; guarded by mu
+
+	metrics *metrics.Registry // Optional latency histograms of page summaries
+	costs   *cost.Meter       // Optional totals of LLM usage and cost
+	audit   *audit.Logger     // Optional record of every prompt and completion
+
+	flights flights // Concurrent summaries of the same page
 }
 
 // GetFetcher returns the fetcher instance for direct access
@@ -20,6 +79,11 @@ func (a *App) GetFetcher() fetcher.Fetcher {
 	return a.fetcher
 }
 
+// SetFetcher replaces the fetcher, e.g. to wrap it. It must be called before any request is made.
+func (a *App) SetFetcher(f fetcher.Fetcher) {
+	a.fetcher = f
+}
+
 // NewApp creates a new App instance.
 func NewApp(f fetcher.Fetcher, l llm.LLM) *App {
 	return &App{
@@ -28,6 +92,96 @@ func NewApp(f fetcher.Fetcher, l llm.LLM) *App {
 	}
 }
 
+// SetLLMTimeout sets the limit for a single LLM call. Zero disables the limit.
+func (a *App) SetLLMTimeout(d time.Duration) {
+	a.llmTimeout = d
+}
+
+// SetModelParams sets the temperature, token limit and other sampling parameters of every LLM call.
+// It must be called before any request is made.
+func (a *App) SetModelParams(p llm.Params) {
+	a.params = p
+}
+
+// SetMetrics records the latency of every page summary and its stages in r.
+// It must be called before any request is made.
+func (a *App) SetMetrics(r *metrics.Registry) {
+	a.metrics = r
+}
+
+// SetCostMeter logs the usage and estimated cost of every LLM call and totals them in m.
+// It must be called before any request is made.
+func (a *App) SetCostMeter(m *cost.Meter) {
+	a.costs = m
+}
+
+// SetAuditLog records every prompt sent to an LLM and its completion in l, with the request they were made for.
+// It must be called before any request is made.
+func (a *App) SetAuditLog(l *audit.Logger) {
+	a.audit = l
+}
+
+// SetPersona sets the operator-provided system prompt prefix applied to every user-facing LLM call.
+// It is safe to call while requests are running.
+func (a *App) SetPersona(p *persona.Persona) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.persona = p
+}
+
+// SetFeatures sets the feature flags. It is safe to call while requests are running.
+func (a *App) SetFeatures(f *feature.Flags) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.features = f
+}
+
+// enabled reports whether the feature flag is on for the channel the request came from, if any.
+func (a *App) enabled(ctx context.Context, name string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.features.Enabled(name, reqmeta.From(ctx).Channel)
+}
+
+// SetPolicy configures the domain policy and the local model used for domains it marks as local-only.
+// localLLM may be nil, in which case local-only URLs are refused instead of being sent to the default LLM.
+// It is safe to call while requests are running.
+func (a *App) SetPolicy(p *policy.Policy, localLLM llm.LLM) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.policy = p
+	a.localLLM = localLLM
+}
+
+// CheckURL returns an error if the policy does not allow fetching url, because of its scheme or port.
+func (a *App) CheckURL(url string) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.policy.CheckURL(url)
+}
+
+// llmFor selects the LLM allowed to see content from the given URLs, or returns an error if the policy
+// does not allow fetching one of them.
+func (a *App) llmFor(urls ...string) (llm.LLM, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, url := range urls {
+		if err := a.policy.CheckURL(url); err != nil {
+			return nil, err
+		}
+	}
+	for _, url := range urls {
+		if !a.policy.RequiresLocal(url) {
+			continue
+		}
+		if a.localLLM == nil {
+			return nil, fmt.Errorf("%s is restricted to local models, but no local model is configured", url)
+		}
+		return a.localLLM, nil
+	}
+	return a.llm, nil
+}
+
 // ProgressCallback is a function type for progress updates
 type ProgressCallback func(message string)
 
@@ -38,31 +192,228 @@ func (a *App) ProcessURL(ctx context.Context, url string, userPrompt string) (st
 
 // ProcessURLWithProgress fetches content from a URL and generates a summary using the LLM with progress updates.
 func (a *App) ProcessURLWithProgress(ctx context.Context, url string, userPrompt string, progressCallback ProgressCallback) (string, error) {
+	result, err := a.summarizeRequest(ctx, fetcher.FetchRequest{URL: url}, userPrompt, progressCallback)
+	if err != nil {
+		return "", err
+	}
+	return result.Summary, nil
+}
+
+// ProcessHTML summarizes a document the caller already has (from a crawler, mail pipeline, ...).
+// The HTML goes through the same extraction as fetched pages; baseURL, if known, resolves relative links
+// and decides the local-only policy.
+func (a *App) ProcessHTML(ctx context.Context, html string, baseURL string, userPrompt string) (string, error) {
+	if html == "" {
+		return "", fmt.Errorf("html is empty")
+	}
+	result, err := a.summarizeRequest(ctx, fetcher.FetchRequest{URL: baseURL, HTML: html}, userPrompt, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.Summary, nil
+}
+
+// Result is a summary together with the page it was generated from, for callers that render their own output.
+type Result struct {
+	ID         string           // History entry ID, empty without a history store
+	URL        string           // Requested URL
+	FinalURL   string           // URL after redirects
+	Metadata   fetcher.Metadata // Page metadata
+	Video      *fetcher.Video   // Video metadata and chapters, nil unless the page is a video
+	Meeting    *fetcher.Meeting // Meeting metadata, nil unless the page is a meeting transcript
+	Structured *Summary         // The summary as data, nil unless generated with WithStructured or the structured-output flag
+	Prompt     string           // The user's question, if any
+	Content    string           // Extracted text the summary was generated from
+	Summary    string           // Generated summary
+	Model      string           // Model that generated the summary, as reported by the provider
+	Usage      llm.Usage        // Tokens spent on the summary
+	CreatedAt  time.Time
+
+	Provenance *provenance.Provenance // How Summary was made, signed if a signing key is set
+}
+
+// SummaryHook is called after every successful page summary, e.g. to cross-post alerts.
+// Hooks run synchronously with the request's context and must not modify the result.
+type SummaryHook func(ctx context.Context, r *Result)
+
+// AddSummaryHook registers h to run after every page summary.
+func (a *App) AddSummaryHook(h SummaryHook) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.summaryHooks = append(a.summaryHooks, h)
+}
+
+// Summarize fetches (or, with req.HTML set, loads) req and returns the summary with page details.
+func (a *App) Summarize(ctx context.Context, req fetcher.FetchRequest, userPrompt string) (*Result, error) {
+	return a.summarizeRequest(ctx, req, userPrompt, nil)
+}
+
+// SummarizeWithProgress is Summarize reporting progress to progressCallback.
+func (a *App) SummarizeWithProgress(ctx context.Context, req fetcher.FetchRequest, userPrompt string, progressCallback ProgressCallback) (*Result, error) {
+	return a.summarizeRequest(ctx, req, userPrompt, progressCallback)
+}
+
+// summarizeRequest summarizes req, records it in the history and runs the summary hooks.
+func (a *App) summarizeRequest(ctx context.Context, req fetcher.FetchRequest, userPrompt string, progressCallback ProgressCallback) (*Result, error) {
+	start := time.Now()
+	result, err := a.sharedSummarize(ctx, req, userPrompt, progressCallback)
+	a.metrics.Observe(metrics.StageTotal, req.URL, time.Since(start), err)
+	if id := a.recordHistory(ctx, req.URL, userPrompt, start, result, err); result != nil {
+		result.ID = id
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.mu.RLock()
+	hooks := a.summaryHooks
+	a.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(ctx, result)
+	}
+	a.runShadow(ctx, result)
+	return result, nil
+}
+
+// fetchAndSummarize fetches (or loads) req and summarizes the extracted content.
+func (a *App) fetchAndSummarize(ctx context.Context, req fetcher.FetchRequest, userPrompt string, progressCallback ProgressCallback) (*Result, error) {
+	url := req.URL
+	model, err := a.llmFor(url)
+	if err != nil {
+		return nil, err
+	}
+
 	if progressCallback != nil {
 		progressCallback(fmt.Sprintf(":loading: Fetching content from %s...", url))
 	}
 
 	// Fetch content from the URL
-	content, err := a.fetcher.Fetch(ctx, url)
+	start := time.Now()
+	page, err := a.fetchWithAudio(ctx, model, req, progressCallback)
+	a.metrics.Observe(metrics.StageFetch, url, time.Since(start), err)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch content: %w", err)
+		return nil, fmt.Errorf("failed to fetch content: %w", err)
 	}
+	content := page.Text
 
-	if content == "" {
-		return "", fmt.Errorf("fetched content is empty for url: %s", url)
+	// Canvas-rendered and image-heavy pages are read from how they look
+	var screenshot []byte
+	if sparse(content) && a.enabled(ctx, feature.VisionFallback) {
+		screenshot = a.screenshot(ctx, req, page, progressCallback)
+	}
+	if content == "" && screenshot == nil {
+		return nil, fetcher.NewPageError(url, page.Diagnostics)
 	}
 
+	if a.enabled(ctx, feature.ContentLanguage) {
+		ctx = matchLanguage(ctx, page, userPrompt)
+	}
+
+	// Questions about long pages are answered from the parts about them
+	content = a.relevantContent(ctx, model, url, content, userPrompt, progressCallback)
+
 	if progressCallback != nil {
 		progressCallback(fmt.Sprintf(":loading: Generating summary for %s...", url))
 	}
+	if progressCallback != nil && streamFrom(ctx) == nil && a.enabled(ctx, feature.Streaming) {
+		ctx = WithStream(ctx, streamProgress(url, progressCallback))
+	}
+
+	var resp *llm.Response
+	var structured *Summary
+	styled := styleMode(ctx) != ""
+	long := a.chunkThreshold > 0 && len([]rune(content)) > a.chunkThreshold
+	// Only the summary is routed; the extras after it are small calls of their own
+	summaryModel, _ := a.routeByLength(ctx, model, content)
+	start = time.Now()
+	if screenshot != nil {
+		resp, err = a.summarizeScreenshot(ctx, model, screenshot, userPrompt)
+	} else if structuredFrom(ctx) {
+		// Callers rendering the summary themselves get the same structure for every kind of page
+		resp, structured, err = a.summarizeStructured(ctx, summaryModel, content, userPrompt)
+	} else if a.enabled(ctx, feature.Citations) && !long {
+		// Claims are only posted with quotes found in the page
+		resp, err = a.summarizeCited(ctx, summaryModel, content, userPrompt)
+	} else if styled && long {
+		// A requested style applies to every kind of page, even one too long to summarize at once
+		resp, err = a.summarizeChunked(ctx, summaryModel, url, content, userPrompt, progressCallback)
+	} else if styled {
+		resp, err = a.summarize(ctx, summaryModel, content, userPrompt)
+	} else if page.Video != nil && len(page.Video.Chapters) > 0 {
+		// Videos with chapters get a chaptered summary
+		resp, err = a.summarizeVideo(ctx, summaryModel, url, page.Video, content, userPrompt)
+	} else if page.Meeting != nil {
+		// Meeting transcripts get notes with decisions and action items
+		resp, err = a.summarizeMeeting(ctx, summaryModel, page.Meeting, content, userPrompt)
+	} else if mode := pageModeFor(pageURL(page, url)); mode != "" {
+		// Pages of known kinds get their own format
+		resp, err = a.summarizeAs(ctx, summaryModel, mode, content, userPrompt)
+	} else if long {
+		// Too long to summarize at once
+		resp, err = a.summarizeChunked(ctx, summaryModel, url, content, userPrompt, progressCallback)
+	} else if a.enabled(ctx, feature.StructuredOutput) {
+		// The format is rendered by the app rather than left to the model
+		resp, structured, err = a.summarizeStructured(ctx, summaryModel, content, userPrompt)
+	} else {
+		// Process the content using the LLM
+		resp, err = a.summarize(ctx, summaryModel, content, userPrompt)
+	}
+	a.metrics.Observe(metrics.StageLLM, url, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	summary := a.titleHeader(ctx, model, page) + resp.Text
+	if screenshot == nil && a.enabled(ctx, feature.NumbersTable) {
+		summary += a.numbersTable(ctx, model, content)
+	}
+	if a.enabled(ctx, feature.RelatedLinks) {
+		summary += a.relatedLinks(ctx, model, page)
+	}
+	if a.enabled(ctx, feature.SourceType) {
+		summary += a.sourceType(ctx, model, page)
+	}
+
+	created := time.Now()
+	return &Result{
+		URL:        url,
+		FinalURL:   page.FinalURL,
+		Metadata:   page.Metadata,
+		Video:      page.Video,
+		Meeting:    page.Meeting,
+		Structured: structured,
+		Prompt:     userPrompt,
+		Content:    page.Text,
+		Summary:    summary,
+		Model:      resp.Model,
+		Usage:      resp.Usage,
+		CreatedAt:  created,
+		Provenance: provenance.New(url, page.Text, summary, resp.Model, llm.PromptVersion, created, a.signingKey),
+	}, nil
+}
 
-	// Process the content using the LLM
-	summary, err := a.llm.ProcessContent(ctx, content, userPrompt)
+// ProcessContent generates a summary for content the caller already has, bypassing the fetcher.
+func (a *App) ProcessContent(ctx context.Context, content string, userPrompt string) (string, error) {
+	if content == "" {
+		return "", fmt.Errorf("content is empty")
+	}
+	resp, err := a.summarize(ctx, a.llm, content, userPrompt)
 	if err != nil {
-		return "", fmt.Errorf("failed to process content: %w", err)
+		return "", err
 	}
+	return resp.Text, nil
+}
 
-	return summary, nil
+// summarize runs the summary mode over content.
+func (a *App) summarize(ctx context.Context, model llm.LLM, content string, userPrompt string) (*llm.Response, error) {
+	mode := llm.ModeSummary
+	if style := styleMode(ctx); style != "" {
+		mode = style
+	}
+	messages := localize(ctx, llm.BuildMessages(mode, content, userPrompt))
+	resp, err := a.generateStream(ctx, model, messages, llm.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process content: %w", err)
+	}
+	return a.filterOutput(ctx, model, messages, llm.Options{}, resp), nil
 }
 
 // ThreadContext represents the context of a thread conversation
@@ -79,17 +430,23 @@ func (a *App) ProcessThreadMention(ctx context.Context, threadContext *ThreadCon
 
 // ProcessThreadMentionWithProgress processes a mention within a thread context with progress updates
 func (a *App) ProcessThreadMentionWithProgress(ctx context.Context, threadContext *ThreadContext, latestMentionText string, latestMentionURLs []string, progressCallback ProgressCallback) (string, error) {
+	// The whole thread goes into one prompt, so a single local-only URL keeps it on the local model
+	model, err := a.llmFor(append(append([]string{}, threadContext.URLs...), latestMentionURLs...)...)
+	if err != nil {
+		return "", err
+	}
+
 	// Fetch content for any new URLs in the latest mention
 	latestURLContents := make(map[string]string)
 	for i, url := range latestMentionURLs {
 		if progressCallback != nil {
 			progressCallback(fmt.Sprintf(":loading: Fetching new URL %d/%d: %s", i+1, len(latestMentionURLs), url))
 		}
-		content, err := a.fetcher.Fetch(ctx, url)
+		result, err := a.fetcher.Fetch(ctx, fetcher.FetchRequest{URL: url})
 		if err != nil {
 			return "", fmt.Errorf("failed to fetch content for URL %s: %w", url, err)
 		}
-		latestURLContents[url] = content
+		latestURLContents[url] = result.Text
 	}
 
 	if progressCallback != nil {
@@ -100,12 +457,62 @@ func (a *App) ProcessThreadMentionWithProgress(ctx context.Context, threadContex
 	prompt := a.buildThreadPrompt(threadContext, latestMentionText, latestURLContents)
 
 	// Process with LLM using thread mode
-	response, err := a.llm.ProcessContentWithMode(ctx, prompt, "", "thread")
+	response, fetched, err := a.generateWithTools(ctx, model, llm.BuildMessages(llm.ModeThread, prompt, ""), progressCallback)
 	if err != nil {
 		return "", fmt.Errorf("failed to process thread content: %w", err)
 	}
 
-	return response, nil
+	// Only pages that were actually retrieved for this answer may appear in the footer
+	retrieved := append([]string{}, latestMentionURLs...)
+	for url := range threadContext.URLContents {
+		retrieved = append(retrieved, url)
+	}
+	for _, page := range fetched {
+		retrieved = append(retrieved, page.URL)
+	}
+	response, cited := splitSources(response)
+
+	if len(retrieved) > 0 && a.enabled(ctx, feature.Verification) {
+		if progressCallback != nil {
+			progressCallback(":loading: Checking the answer against the pages...")
+		}
+		response = a.verifyAnswer(ctx, model, prompt, fetched, latestMentionText, response)
+	}
+	return response + sourcesFooter(cited, retrieved), nil
+}
+
+// generate calls model with the operator's system prompt prefix, bounded by the configured LLM timeout.
+func (a *App) generate(ctx context.Context, model llm.LLM, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	return a.call(ctx, model, a.withPersona(messages), opts)
+}
+
+// withPersona places the operator's system prompt prefix before the system prompt of messages.
+func (a *App) withPersona(messages []llm.Message) []llm.Message {
+	a.mu.RLock()
+	p := a.persona
+	a.mu.RUnlock()
+	return llm.WithSystemPrefix(messages, p.Prefix())
+}
+
+// call calls model as is, bounded by the configured LLM timeout and with the configured sampling
+// parameters. Internal classifiers use it directly so operator instructions cannot change their expected output.
+func (a *App) call(ctx context.Context, model llm.LLM, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	opts.Params = opts.Params.Or(a.params)
+	var resp *llm.Response
+	err := timeout.Run(ctx, timeout.LLM, a.llmTimeout, func(ctx context.Context) error {
+		var err error
+		resp, err = model.Generate(ctx, messages, opts)
+		return err
+	})
+	a.audit.Record(ctx, opts.Model, messages, resp, err)
+	if err == nil {
+		name := resp.Model
+		if name == "" {
+			name = opts.Model
+		}
+		a.costs.Record(ctx, name, resp.Usage)
+	}
+	return resp, err
 }
 
 // buildThreadPrompt constructs the prompt for thread processing
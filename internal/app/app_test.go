@@ -2,40 +2,79 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/audit"
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/feed"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/history"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/output"
+	"github.com/kznrluk/describe-kun/internal/persona"
+	"github.com/kznrluk/describe-kun/internal/policy"
+	"github.com/kznrluk/describe-kun/internal/provenance"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/kznrluk/describe-kun/internal/snapshot"
 )
 
 // MockFetcher is a mock implementation of the Fetcher interface.
 type MockFetcher struct {
 	FetchFunc func(ctx context.Context, url string) (string, error)
+	Video     *fetcher.Video   // Returned with every result when set
+	Meeting   *fetcher.Meeting // Returned with every result when set
+	Metadata  fetcher.Metadata // Returned with every result
+
+	Diagnostics *fetcher.Diagnostics // Returned with every result
+	Screenshot  []byte               // Returned when a screenshot is requested
+	LastRequest fetcher.FetchRequest // The most recent request passed to Fetch
 }
 
-func (m *MockFetcher) Fetch(ctx context.Context, url string) (string, error) {
+func (m *MockFetcher) Fetch(ctx context.Context, req fetcher.FetchRequest) (*fetcher.FetchResult, error) {
+	m.LastRequest = req
 	if m.FetchFunc != nil {
-		return m.FetchFunc(ctx, url)
+		text, err := m.FetchFunc(ctx, req.URL)
+		if err != nil {
+			return nil, err
+		}
+		result := &fetcher.FetchResult{Text: text, FinalURL: req.URL, Video: m.Video, Meeting: m.Meeting, Metadata: m.Metadata, Diagnostics: m.Diagnostics}
+		if req.Screenshot {
+			result.Screenshot = m.Screenshot
+		}
+		return result, nil
 	}
-	return "", errors.New("FetchFunc not implemented")
+	return nil, errors.New("FetchFunc not implemented")
 }
 
 // MockLLM is a mock implementation of the LLM interface.
 type MockLLM struct {
-	ProcessContentFunc     func(ctx context.Context, content string, userPrompt string) (string, error)
-	ProcessContentWithModeFunc func(ctx context.Context, content string, userPrompt string, mode string) (string, error)
+	GenerateFunc func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error)
 }
 
-func (m *MockLLM) ProcessContent(ctx context.Context, content string, userPrompt string) (string, error) {
-	if m.ProcessContentFunc != nil {
-		return m.ProcessContentFunc(ctx, content, userPrompt)
+func (m *MockLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	if m.GenerateFunc != nil {
+		return m.GenerateFunc(ctx, messages, opts)
 	}
-	return "", errors.New("ProcessContentFunc not implemented")
+	return nil, errors.New("GenerateFunc not implemented")
 }
 
-func (m *MockLLM) ProcessContentWithMode(ctx context.Context, content string, userPrompt string, mode string) (string, error) {
-	if m.ProcessContentWithModeFunc != nil {
-		return m.ProcessContentWithModeFunc(ctx, content, userPrompt, mode)
+// userText returns the text of the last user message.
+func userText(messages []llm.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == llm.RoleUser {
+			return messages[i].Text()
+		}
 	}
-	return "", errors.New("ProcessContentWithModeFunc not implemented")
+	return ""
 }
 
 func TestApp_ProcessURL_Success(t *testing.T) {
@@ -49,14 +88,15 @@ func TestApp_ProcessURL_Success(t *testing.T) {
 	}
 
 	mockLLM := &MockLLM{
-		ProcessContentFunc: func(ctx context.Context, content string, userPrompt string) (string, error) {
-			if content != "Mock page content" {
-				return "", errors.New("unexpected content")
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			text := userText(messages)
+			if !strings.Contains(text, "Mock page content") {
+				return nil, errors.New("unexpected content")
 			}
-			if userPrompt != "test prompt" {
-				return "", errors.New("unexpected user prompt")
+			if !strings.Contains(text, "test prompt") {
+				return nil, errors.New("unexpected user prompt")
 			}
-			return "Mock summary", nil
+			return &llm.Response{Text: "Mock summary"}, nil
 		},
 	}
 
@@ -90,6 +130,27 @@ func TestApp_ProcessURL_FetchError(t *testing.T) {
 	}
 }
 
+func TestApp_ProcessURL_EmptyPage(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "", nil
+		},
+		Diagnostics: &fetcher.Diagnostics{Exceptions: []string{"Uncaught TypeError: app is undefined"}},
+	}
+	mockLLM := &MockLLM{} // Summarize should not be called
+
+	app := NewApp(mockFetcher, mockLLM)
+	_, err := app.ProcessURL(context.Background(), "http://example.com/spa", "")
+
+	var pageErr *fetcher.PageError
+	if !errors.As(err, &pageErr) || pageErr.Reason != fetcher.ReasonJSCrashed {
+		t.Fatalf("Expected a page error for the crashed script, got '%v'", err)
+	}
+	if !strings.Contains(err.Error(), "app is undefined") {
+		t.Errorf("Expected the exception in the error, got '%v'", err)
+	}
+}
+
 func TestApp_ProcessURL_SummarizeError(t *testing.T) {
 	summarizeErr := errors.New("summarize failed")
 	mockFetcher := &MockFetcher{
@@ -98,8 +159,8 @@ func TestApp_ProcessURL_SummarizeError(t *testing.T) {
 		},
 	}
 	mockLLM := &MockLLM{
-		ProcessContentFunc: func(ctx context.Context, content string, userPrompt string) (string, error) {
-			return "", summarizeErr
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return nil, summarizeErr
 		},
 	}
 
@@ -111,3 +172,2492 @@ func TestApp_ProcessURL_SummarizeError(t *testing.T) {
 		t.Fatalf("Expected summarize error '%v', got '%v'", summarizeErr, err)
 	}
 }
+
+func TestApp_ProcessURL_DisallowedPort(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			t.Fatal("URLs on internal ports must not be fetched")
+			return "", nil
+		},
+	}
+	app := NewApp(mockFetcher, &MockLLM{})
+	app.SetPolicy(policy.New(nil), nil)
+
+	_, err := app.ProcessURL(context.Background(), "http://internal-service:8500/v1/kv", "")
+	if err == nil || !strings.Contains(err.Error(), "port 8500") {
+		t.Fatalf("Expected the port to be refused, got '%v'", err)
+	}
+}
+
+func TestApp_ProcessURL_LocalOnlyDomain(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Internal wiki content", nil
+		},
+	}
+	remoteLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			t.Fatal("local-only content must not reach the remote LLM")
+			return nil, nil
+		},
+	}
+	localLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{Text: "Local summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, remoteLLM)
+	app.SetPolicy(policy.New([]string{"wiki.example.com"}), nil)
+	if _, err := app.ProcessURL(context.Background(), "https://wiki.example.com/page", ""); err == nil {
+		t.Fatal("Expected an error when no local model is configured, but got nil")
+	}
+
+	app.SetPolicy(policy.New([]string{"wiki.example.com"}), localLLM)
+	result, err := app.ProcessURL(context.Background(), "https://wiki.example.com/page", "")
+	if err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if result != "Local summary" {
+		t.Errorf("Expected result 'Local summary', got '%s'", result)
+	}
+}
+
+func TestApp_ProcessThreadMention_ToolFetch(t *testing.T) {
+	var fetched []string
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			fetched = append(fetched, url)
+			return "RFC body", nil
+		},
+	}
+
+	calls := 0
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			calls++
+			if len(opts.Tools) == 0 {
+				// Budget spent: the model must answer with what it has
+				last := messages[len(messages)-1]
+				if last.Role != llm.RoleTool || last.Text() != "RFC body" {
+					return nil, errors.New("expected the fetched page as the last tool result")
+				}
+				return &llm.Response{Text: "Answer from RFC"}, nil
+			}
+			return &llm.Response{ToolCalls: []llm.ToolCall{
+				{ID: "call-1", Name: "fetch", Arguments: `{"url":"https://example.com/rfc"}`},
+			}}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetToolFetchBudget(1)
+	app.checkToolURL = allowToolURL
+	threadContext := &ThreadContext{URLContents: map[string]string{}}
+	result, err := app.ProcessThreadMention(context.Background(), threadContext, "what does the linked RFC say?", nil)
+	if err != nil {
+		t.Fatalf("ProcessThreadMention failed: %v", err)
+	}
+	if result != "Answer from RFC" {
+		t.Errorf("Expected result 'Answer from RFC', got '%s'", result)
+	}
+	if calls != 2 || len(fetched) != 1 || fetched[0] != "https://example.com/rfc" {
+		t.Errorf("Expected one tool fetch and two LLM calls, got fetched=%v calls=%d", fetched, calls)
+	}
+}
+
+func TestApp_ProcessThreadMention_FinalToolCalls(t *testing.T) {
+	var final []llm.Message
+	calls := 0
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			calls++
+			if len(opts.Tools) > 0 {
+				return nil, errors.New("tools offered without a budget")
+			}
+			// Like Bedrock, which cannot forbid calling the tools of earlier turns
+			if calls == 1 {
+				return &llm.Response{ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "fetch", Arguments: `{"url":"https://example.com/a"}`}}}, nil
+			}
+			final = messages
+			return &llm.Response{Text: "Answer"}, nil
+		},
+	}
+
+	app := NewApp(&MockFetcher{}, mockLLM)
+	result, err := app.ProcessThreadMention(context.Background(), &ThreadContext{URLContents: map[string]string{}}, "what does it say?", nil)
+	if err != nil {
+		t.Fatalf("ProcessThreadMention failed: %v", err)
+	}
+	if result != "Answer" || calls != 2 {
+		t.Errorf("Expected the model to be asked again, got %q after %d calls", result, calls)
+	}
+	if last := final[len(final)-1]; last.Role != llm.RoleTool || last.ToolCallID != "call-1" || !strings.Contains(last.Text(), "No more pages") {
+		t.Errorf("Expected the tool call answered before asking again, got %+v", last)
+	}
+
+	// A model that never answers fails instead of posting an empty answer
+	app = NewApp(&MockFetcher{}, &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{ToolCalls: []llm.ToolCall{{ID: "call-1", Name: "fetch", Arguments: `{}`}}}, nil
+		},
+	})
+	if result, err := app.ProcessThreadMention(context.Background(), &ThreadContext{URLContents: map[string]string{}}, "what does it say?", nil); err == nil {
+		t.Errorf("Expected an error, got %q", result)
+	}
+}
+
+// allowToolURL lets tool fetches of the test hosts through without resolving them.
+func allowToolURL(context.Context, string) error { return nil }
+
+func TestApp_ProcessThreadMention_ToolFetchInternal(t *testing.T) {
+	for _, url := range []string{
+		"http://127.0.0.1/admin",
+		"http://localhost:8080/",
+		"http://10.0.0.5/",
+		"http://192.168.1.1/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/",
+		"http://0.0.0.0/",
+	} {
+		var fetched []string
+		mockFetcher := &MockFetcher{
+			FetchFunc: func(ctx context.Context, url string) (string, error) {
+				fetched = append(fetched, url)
+				return "Internal page", nil
+			},
+		}
+		var toolResult string
+		mockLLM := &MockLLM{
+			GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+				if last := messages[len(messages)-1]; last.Role == llm.RoleTool {
+					toolResult = last.Text()
+					return &llm.Response{Text: "Answer"}, nil
+				}
+				return &llm.Response{ToolCalls: []llm.ToolCall{
+					{ID: "call-1", Name: "fetch", Arguments: `{"url":"` + url + `"}`},
+				}}, nil
+			},
+		}
+
+		app := NewApp(mockFetcher, mockLLM)
+		app.SetToolFetchBudget(1)
+		if _, err := app.ProcessThreadMention(context.Background(), &ThreadContext{URLContents: map[string]string{}}, "what does it say?", nil); err != nil {
+			t.Fatalf("ProcessThreadMention failed: %v", err)
+		}
+		if len(fetched) != 0 || !strings.Contains(toolResult, "only public addresses") {
+			t.Errorf("Expected the tool fetch of %s to be refused, got fetched=%v result=%q", url, fetched, toolResult)
+		}
+	}
+}
+
+func TestApp_ProcessThreadMention_ToolRoundsCapped(t *testing.T) {
+	calls := 0
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			calls++
+			if calls > 10 {
+				return nil, errors.New("tools were offered without end")
+			}
+			if len(opts.Tools) == 0 {
+				return &llm.Response{Text: "Answer"}, nil
+			}
+			// Calls that are never run must still use up the budget
+			return &llm.Response{ToolCalls: []llm.ToolCall{
+				{ID: "call-1", Name: "search", Arguments: `{}`},
+				{ID: "call-2", Name: "fetch", Arguments: `not json`},
+			}}, nil
+		},
+	}
+
+	app := NewApp(&MockFetcher{}, mockLLM)
+	app.SetToolFetchBudget(2)
+	result, err := app.ProcessThreadMention(context.Background(), &ThreadContext{URLContents: map[string]string{}}, "what does it say?", nil)
+	if err != nil {
+		t.Fatalf("ProcessThreadMention failed: %v", err)
+	}
+	if result != "Answer" || calls != 3 {
+		t.Errorf("Expected two tool rounds and a final answer, got %q after %d calls", result, calls)
+	}
+}
+
+func TestApp_ProcessThreadMention_ChannelActions(t *testing.T) {
+	var fetched []string
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			fetched = append(fetched, url)
+			return "Page body", nil
+		},
+	}
+	var toolResults []string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if last := messages[len(messages)-1]; last.Role == llm.RoleTool {
+				toolResults = append(toolResults, last.Text())
+			}
+			if len(opts.Tools) == 0 || len(toolResults) > 0 {
+				return &llm.Response{Text: "Answer"}, nil
+			}
+			return &llm.Response{ToolCalls: []llm.ToolCall{
+				{ID: "call-1", Name: "fetch", Arguments: `{"url":"https://evil.example.net/page"}`},
+			}}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetToolFetchBudget(3)
+	app.checkToolURL = allowToolURL
+	app.SetChannelActions(policy.ChannelActions(map[string]int{"C-NONE": 0}, map[string][]string{"C-DOCS": {"docs.example.com"}}))
+	ask := func(channel string) {
+		toolResults = nil
+		ctx := reqmeta.With(context.Background(), reqmeta.Metadata{Channel: channel})
+		if _, err := app.ProcessThreadMention(ctx, &ThreadContext{URLContents: map[string]string{}}, "what does it say?", nil); err != nil {
+			t.Fatalf("ProcessThreadMention failed: %v", err)
+		}
+	}
+
+	// Domains outside the channel's list are refused
+	ask("C-DOCS")
+	if len(fetched) != 0 || len(toolResults) != 1 || !strings.Contains(toolResults[0], "not permitted in this channel") {
+		t.Errorf("Expected the fetch to be refused, got fetched=%v results=%v", fetched, toolResults)
+	}
+
+	// A limit of zero offers no tools
+	ask("C-NONE")
+	if len(fetched) != 0 || len(toolResults) != 0 {
+		t.Errorf("Expected no tool calls, got fetched=%v results=%v", fetched, toolResults)
+	}
+
+	// Other channels keep the default limits
+	ask("C-OTHER")
+	if len(fetched) != 1 || fetched[0] != "https://evil.example.net/page" {
+		t.Errorf("Expected the fetch to go ahead, got fetched=%v", fetched)
+	}
+}
+
+func TestApp_ProcessThreadMention_SourcesFooter(t *testing.T) {
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{Text: "The limit is 10 requests per second.\n\nSOURCES: https://example.com/docs (Rate limits); https://unseen.example.com/"}, nil
+		},
+	}
+
+	app := NewApp(&MockFetcher{}, mockLLM)
+	threadContext := &ThreadContext{
+		URLs:        []string{"https://example.com/docs"},
+		URLContents: map[string]string{"https://example.com/docs": "Rate limits: 10 rps"},
+	}
+	result, err := app.ProcessThreadMention(context.Background(), threadContext, "what is the limit?", nil)
+	if err != nil {
+		t.Fatalf("ProcessThreadMention failed: %v", err)
+	}
+
+	want := "The limit is 10 requests per second.\n\n:link: *Sources used*\n• https://example.com/docs — Rate limits"
+	if result != want {
+		t.Errorf("Expected only retrieved sources in the footer, got %q", result)
+	}
+}
+
+func TestApp_ProcessThreadMention_Verification(t *testing.T) {
+	for _, tc := range []struct {
+		verdict string
+		flagged bool
+	}{
+		{"YES", false},
+		{"NO", true},
+	} {
+		mockLLM := &MockLLM{
+			GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+				if strings.Contains(messages[0].Text(), "fact checker") {
+					if !strings.Contains(messages[1].Text(), "Rate limits: 10 rps") {
+						return nil, errors.New("expected the page content in the verification call")
+					}
+					return &llm.Response{Text: tc.verdict}, nil
+				}
+				return &llm.Response{Text: "The limit is 10 requests per second."}, nil
+			},
+		}
+
+		app := NewApp(&MockFetcher{}, mockLLM)
+		flags, err := feature.New(map[string]bool{feature.Verification: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		app.SetFeatures(flags)
+		threadContext := &ThreadContext{URLContents: map[string]string{"https://example.com/docs": "Rate limits: 10 rps"}}
+		result, err := app.ProcessThreadMention(context.Background(), threadContext, "what is the limit?", nil)
+		if err != nil {
+			t.Fatalf("ProcessThreadMention failed: %v", err)
+		}
+		if flagged := strings.HasPrefix(result, notFoundPrefix); flagged != tc.flagged {
+			t.Errorf("Verdict %s: expected flagged=%v, got %q", tc.verdict, tc.flagged, result)
+		}
+		if !strings.HasSuffix(result, "The limit is 10 requests per second.") {
+			t.Errorf("Verdict %s: expected the answer to be kept, got %q", tc.verdict, result)
+		}
+	}
+}
+
+func TestSplitSources_NoLine(t *testing.T) {
+	answer, cited := splitSources("Just an answer.")
+	if answer != "Just an answer." || cited != nil {
+		t.Errorf("Expected the answer untouched without a SOURCES line, got %q %v", answer, cited)
+	}
+	if footer := sourcesFooter(cited, []string{"https://example.com/"}); footer != "" {
+		t.Errorf("Expected no footer, got %q", footer)
+	}
+}
+
+func TestApp_ProcessThreadMention_ToolCallingFlag(t *testing.T) {
+	var offered []bool
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			offered = append(offered, len(opts.Tools) > 0)
+			return &llm.Response{Text: "Answer"}, nil
+		},
+	}
+
+	app := NewApp(&MockFetcher{}, mockLLM)
+	app.SetToolFetchBudget(1)
+	flags, err := feature.New(map[string]bool{feature.ToolCalling: false}, map[string]map[string]bool{"C1": {feature.ToolCalling: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetFeatures(flags)
+
+	threadContext := &ThreadContext{URLContents: map[string]string{}}
+	for _, channel := range []string{"C1", "C2"} {
+		if _, err := app.ProcessThreadMention(reqmeta.With(context.Background(), reqmeta.Metadata{Channel: channel}), threadContext, "question", nil); err != nil {
+			t.Fatalf("ProcessThreadMention failed: %v", err)
+		}
+	}
+	if len(offered) != 2 || !offered[0] || offered[1] {
+		t.Errorf("Expected tools only in the overriding channel, got %v", offered)
+	}
+}
+
+func TestApp_IsRelevant(t *testing.T) {
+	var gotModel string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			gotModel = opts.Model
+			if strings.Contains(userText(messages), "generics in Go") {
+				return &llm.Response{Text: "YES"}, nil
+			}
+			return &llm.Response{Text: "no"}, nil
+		},
+	}
+	app := NewApp(&MockFetcher{}, mockLLM)
+
+	if ok, err := app.IsRelevant(context.Background(), "https://example.com", "anything"); !ok || err != nil {
+		t.Errorf("Expected everything relevant when screening is disabled, got %v, %v", ok, err)
+	}
+
+	app.SetScreening("cheap-model", []string{"security", "golang"})
+	if ok, err := app.IsRelevant(context.Background(), "https://example.com", "An article about generics in Go"); !ok || err != nil {
+		t.Errorf("Expected relevant content to pass, got %v, %v", ok, err)
+	}
+	if ok, _ := app.IsRelevant(context.Background(), "https://example.com", "A post about cooking"); ok {
+		t.Error("Expected irrelevant content to be skipped")
+	}
+	if gotModel != "cheap-model" {
+		t.Errorf("Expected the screening model to be used, got %q", gotModel)
+	}
+
+	// Local-only pages are screened by the local model, under its own model name
+	var localModel string
+	localLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			localModel = opts.Model
+			return &llm.Response{Text: "NO"}, nil
+		},
+	}
+	gotModel = "unused"
+	app.SetPolicy(policy.New([]string{"wiki.example.com"}), localLLM)
+	if ok, _ := app.IsRelevant(context.Background(), "https://wiki.example.com/page", "An article about generics in Go"); ok {
+		t.Error("Expected the local model's verdict")
+	}
+	if gotModel != "unused" || localModel != "" {
+		t.Errorf("Expected only the local model with its default model, got remote %q, local %q", gotModel, localModel)
+	}
+
+	failing := NewApp(&MockFetcher{}, &MockLLM{})
+	failing.SetScreening("cheap-model", []string{"security"})
+	if ok, err := failing.IsRelevant(context.Background(), "https://example.com", "content"); !ok || err == nil {
+		t.Errorf("Expected fail-open with an error, got %v, %v", ok, err)
+	}
+}
+
+func TestApp_ProcessImagesWithProgress(t *testing.T) {
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if opts.Model != "vision-model" {
+				return nil, errors.New("expected the vision model")
+			}
+			user := messages[len(messages)-1]
+			if len(user.Parts) != 2 || user.Parts[1].Type != llm.PartImage || user.Parts[1].MIMEType != "image/png" {
+				return nil, errors.New("expected the image as a message part")
+			}
+			if !strings.Contains(user.Text(), "what does this say?") {
+				return nil, errors.New("expected the user question")
+			}
+			return &llm.Response{Text: "Screenshot summary"}, nil
+		},
+	}
+
+	app := NewApp(&MockFetcher{}, mockLLM)
+	app.SetVisionModel("vision-model")
+	images := []Image{{Name: "shot.png", Data: []byte("png"), MIMEType: "image/png"}}
+	result, err := app.ProcessImagesWithProgress(context.Background(), images, "what does this say?", nil)
+	if err != nil {
+		t.Fatalf("ProcessImagesWithProgress failed: %v", err)
+	}
+	if result != "Screenshot summary" {
+		t.Errorf("Expected result 'Screenshot summary', got '%s'", result)
+	}
+}
+
+func TestApp_Summarize_VisionFallback(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			if url == "https://example.com/article" {
+				return strings.Repeat("Text ", visionFallbackChars), nil
+			}
+			return "Loading...", nil
+		},
+		Screenshot: []byte("png"),
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			user := messages[len(messages)-1]
+			if len(user.Parts) == 2 && user.Parts[1].Type == llm.PartImage && string(user.Parts[1].Data) == "png" {
+				if opts.Model != "vision-model" {
+					return nil, errors.New("expected the vision model")
+				}
+				return &llm.Response{Text: "Screenshot summary"}, nil
+			}
+			return &llm.Response{Text: "Text summary"}, nil
+		},
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetVisionModel("vision-model")
+	ctx := context.Background()
+
+	// Off by default
+	if result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://example.com/canvas"}, ""); err != nil || result.Summary != "Text summary" {
+		t.Fatalf("Expected the text summary with the flag off, got %+v, %v", result, err)
+	}
+
+	flags, err := feature.New(map[string]bool{feature.VisionFallback: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetFeatures(flags)
+	result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://example.com/canvas"}, "")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Summary != "Screenshot summary" || !mockFetcher.LastRequest.Screenshot {
+		t.Errorf("Expected the page read from a screenshot, got %q", result.Summary)
+	}
+	if result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://example.com/article"}, ""); err != nil || result.Summary != "Text summary" || mockFetcher.LastRequest.Screenshot {
+		t.Errorf("Expected pages with enough text summarized from it, got %+v, %v", result, err)
+	}
+
+	// Without a screenshot, the text is summarized
+	mockFetcher.Screenshot = nil
+	if result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://example.com/canvas"}, ""); err != nil || result.Summary != "Text summary" {
+		t.Errorf("Expected the text summary without a screenshot, got %+v, %v", result, err)
+	}
+}
+
+// fakeTranscriber transcribes every file as its name and contents.
+type fakeTranscriber struct{}
+
+func (fakeTranscriber) Transcribe(ctx context.Context, name string, audio []byte) (string, error) {
+	return fmt.Sprintf("Speech of %s: %s", name, audio), nil
+}
+
+func TestApp_Summarize_Transcription(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("audio bytes"))
+	}))
+	defer srv.Close()
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Show notes", nil
+		},
+		Metadata: fetcher.Metadata{Audio: srv.URL + "/episode-1.mp3?source=feed"},
+	}
+	var prompt string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			prompt = messages[len(messages)-1].Text()
+			return &llm.Response{Text: "Summary"}, nil
+		},
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetTranscriber(fakeTranscriber{}, 1<<20)
+	ctx := context.Background()
+
+	// A link to an audio file is read as its transcript
+	result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: srv.URL + "/talk.m4a"}, "")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Content != "Speech of talk.m4a: audio bytes" || result.Metadata.Title != "talk.m4a" || mockFetcher.LastRequest.URL != "" {
+		t.Errorf("Expected the transcript without fetching the page, got %+v", result)
+	}
+	if !strings.Contains(prompt, "Speech of talk.m4a") {
+		t.Errorf("Expected the transcript summarized, got %q", prompt)
+	}
+
+	// Podcast pages get the transcript of their episode
+	result, err = app.Summarize(ctx, fetcher.FetchRequest{URL: "https://podcast.example/episodes/1"}, "")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Content != "Show notes\n\nEpisode transcript:\nSpeech of episode-1.mp3: audio bytes" {
+		t.Errorf("Expected the show notes with the transcript, got %q", result.Content)
+	}
+
+	// Files over the limit are not downloaded
+	app.SetTranscriber(fakeTranscriber{}, 4)
+	if _, err := app.Summarize(ctx, fetcher.FetchRequest{URL: srv.URL + "/long.mp3"}, ""); err == nil || !strings.Contains(err.Error(), "more than") {
+		t.Errorf("Expected files over the limit to fail, got %v", err)
+	}
+	if result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://podcast.example/episodes/2"}, ""); err != nil || result.Content != "Show notes" {
+		t.Errorf("Expected the show notes alone when the episode cannot be transcribed, got %+v, %v", result, err)
+	}
+}
+
+// keywordEmbedder embeds texts mentioning prices apart from the rest.
+type keywordEmbedder struct{ calls int }
+
+func (e *keywordEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{0, 1}
+		if strings.Contains(strings.ToLower(text), "price") {
+			vectors[i] = []float32{1, 0}
+		}
+	}
+	return vectors, nil
+}
+
+// memorySink keeps audit records in memory.
+type memorySink struct {
+	mu      sync.Mutex
+	records []audit.Record
+}
+
+func (s *memorySink) Write(ctx context.Context, r audit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+func TestApp_AuditLog(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Confidential page text", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{Text: "Summary", Model: "gpt-4o", Usage: llm.Usage{TotalTokens: 42}}, nil
+		},
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	sink := &memorySink{}
+	app.SetAuditLog(audit.New(sink))
+	ctx := reqmeta.With(context.Background(), reqmeta.Metadata{RequestID: "req-1", Channel: "C123", User: "U456"})
+
+	if _, err := app.ProcessURL(ctx, "https://example.com", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if len(sink.records) != 1 {
+		t.Fatalf("Expected the call to be recorded once, got %d records", len(sink.records))
+	}
+	r := sink.records[0]
+	if r.RequestID != "req-1" || r.Channel != "C123" || r.User != "U456" || r.Model != "gpt-4o" || r.Completion != "Summary" || r.TotalTokens != 42 {
+		t.Errorf("Unexpected record %+v", r)
+	}
+	if last := r.Messages[len(r.Messages)-1]; !strings.Contains(last.Text, "Confidential page text") {
+		t.Errorf("Expected the prompt with the page to be recorded, got %+v", r.Messages)
+	}
+}
+
+func TestApp_Summarize_Provenance(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Page text", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{Text: "Summary", Model: "gpt-4o"}, nil
+		},
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	key := []byte("secret")
+	app.SetSigningKey(key)
+
+	result, err := app.Summarize(context.Background(), fetcher.FetchRequest{URL: "https://example.com"}, "")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	p := result.Provenance
+	if p == nil {
+		t.Fatal("Expected the result to have a provenance")
+	}
+	if p.URL != "https://example.com" || p.ContentSHA256 != provenance.Hash("Page text") || p.Model != "gpt-4o" || p.PromptVersion != llm.PromptVersion {
+		t.Errorf("Unexpected provenance %+v", p)
+	}
+	if !p.Verify(result.Summary, key) {
+		t.Error("Expected the provenance to verify the summary")
+	}
+	if q := app.ProvenanceFor(result, "*Summary*"); !q.Verify("*Summary*", key) || q.ContentSHA256 != p.ContentSHA256 {
+		t.Errorf("Unexpected provenance of the rendered summary %+v", q)
+	}
+}
+
+func TestApp_Summarize_RelevanceFilter(t *testing.T) {
+	var parts []string
+	for i := 0; i < 10; i++ {
+		part := fmt.Sprintf("Part %d is about the architecture.", i)
+		if i == 3 || i == 7 {
+			part = fmt.Sprintf("Part %d lists the price of each plan.", i)
+		}
+		parts = append(parts, part+strings.Repeat(" filler", (90-len(part))/7))
+	}
+	page := strings.Join(parts, "\n\n")
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return page, nil
+		},
+	}
+	var prompt string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			prompt = messages[len(messages)-1].Text()
+			return &llm.Response{Text: "Summary"}, nil
+		},
+	}
+	embedder := &keywordEmbedder{}
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetRelevanceFilter(embedder, RelevanceFilter{Threshold: 500, ChunkSize: 100, MaxChars: 200})
+	ctx := context.Background()
+
+	result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://example.com/docs"}, "What is the price?")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if !strings.Contains(prompt, "Part 3 lists the price") || !strings.Contains(prompt, "Part 7 lists the price") || strings.Contains(prompt, "Part 5") || !strings.Contains(prompt, "[…]") {
+		t.Errorf("Expected only the parts about prices, got %q", prompt)
+	}
+	if result.Content != page {
+		t.Error("Expected the result to keep the whole page")
+	}
+
+	// Summaries without a question read the whole page
+	if _, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://example.com/docs"}, ""); err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if !strings.Contains(prompt, "Part 5") || embedder.calls != 1 {
+		t.Errorf("Expected the whole page without a question, got %q", prompt)
+	}
+}
+
+func TestApp_ProcessURL_VideoChapters(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Transcript", nil
+		},
+		Video: &fetcher.Video{
+			Platform: "youtube",
+			Title:    "Release deep dive",
+			Chapters: []fetcher.Chapter{{Start: 0, Title: "Intro"}, {Start: 750, Title: "Benchmarks"}},
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if !strings.Contains(userText(messages), "12:30 Benchmarks") {
+				return nil, errors.New("expected the chapter list in the prompt")
+			}
+			return &llm.Response{Text: ":clapper: チャプター\n0:00 Intro - Overview\n12:30 Benchmarks - Numbers from 10:00 runs\n99:99 Unknown"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	result, err := app.ProcessURL(context.Background(), "https://www.youtube.com/watch?v=abc", "")
+	if err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	for _, want := range []string{
+		"<https://www.youtube.com/watch?t=0s&v=abc|0:00> Intro",
+		"<https://www.youtube.com/watch?t=750s&v=abc|12:30> Benchmarks - Numbers from 10:00 runs",
+		"\n99:99 Unknown",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in result, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestApp_ProcessURL_Meeting(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "[00:00:01] Alice: Bob will send the estimate by Friday.", nil
+		},
+		Meeting: &fetcher.Meeting{Platform: "zoom", Topic: "Weekly sync", DurationMinutes: 30},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if !strings.Contains(messages[0].Text(), "アクションアイテム") {
+				return nil, errors.New("expected the meeting notes prompt")
+			}
+			if !strings.Contains(userText(messages), "Meeting: Weekly sync\nDuration: 30 minutes\n\nTranscript:\n[00:00:01] Alice") {
+				return nil, errors.New("expected the meeting metadata and transcript in the prompt")
+			}
+			return &llm.Response{Text: ":pushpin: アクションアイテム\n- Send the estimate — Bob (Friday)"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	result, err := app.Summarize(context.Background(), fetcher.FetchRequest{URL: "https://example.zoom.us/j/85012345678"}, "")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Meeting == nil || !strings.Contains(result.Summary, "Send the estimate — Bob (Friday)") {
+		t.Errorf("Expected meeting notes, got %+v", result)
+	}
+}
+
+func TestApp_ProcessURL_Product(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Notes app. Free with in-app purchases. 4.7 stars.", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if !strings.Contains(messages[0].Text(), ":iphone: 対応プラットフォーム") {
+				return nil, errors.New("expected the product prompt")
+			}
+			return &llm.Response{Text: ":package: 概要\nA notes app"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	if _, err := app.ProcessURL(context.Background(), "https://apps.apple.com/jp/app/notes/id123456", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+}
+
+func TestApp_ProcessURL_JobProfile(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Senior Go engineer. Remote (Japan). 8,000,000-12,000,000 JPY.", nil
+		},
+	}
+	var prompt string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if !strings.Contains(messages[0].Text(), ":hammer_and_wrench: 技術スタック") {
+				return nil, errors.New("expected the job prompt")
+			}
+			prompt = userText(messages)
+			return &llm.Response{Text: ":briefcase: 職種\nSenior Go engineer"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	const url = "https://boards.greenhouse.io/example/jobs/4012345"
+	if _, err := app.ProcessURL(context.Background(), url, ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if strings.Contains(prompt, ":dart:") {
+		t.Errorf("Expected no scoring without a profile, got %q", prompt)
+	}
+
+	app.SetJobProfile(persona.New("Backend engineers with 5+ years of Go, remote only."))
+	if _, err := app.ProcessURL(context.Background(), url, "any visa support?"); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if !strings.Contains(prompt, "5+ years of Go") || !strings.Contains(prompt, ":dart: マッチ度") {
+		t.Errorf("Expected the profile and the scoring instruction, got %q", prompt)
+	}
+}
+
+// docsFetcher serves a docs site whose homepage links to its getting started pages.
+type docsFetcher struct{}
+
+func (docsFetcher) Fetch(ctx context.Context, req fetcher.FetchRequest) (*fetcher.FetchResult, error) {
+	switch req.URL {
+	case "https://docs.example.com/":
+		return &fetcher.FetchResult{Text: "Welcome to Lib", FinalURL: req.URL, Markdown: "[Installation](https://docs.example.com/install#pip)\n" +
+			"[API reference](https://docs.example.com/api)\n[Getting Started](https://docs.example.com/guide/start)\n" +
+			"[Setup on GitHub](https://github.com/example/lib)\n[Install again](https://docs.example.com/install)"}, nil
+	case "https://docs.example.com/install":
+		return &fetcher.FetchResult{Text: "pip install lib", FinalURL: req.URL}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestApp_ProcessQuickstart(t *testing.T) {
+	var content string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			content = userText(messages)
+			return &llm.Response{Text: ":package: インストール"}, nil
+		},
+	}
+	app := NewApp(docsFetcher{}, mockLLM)
+	quickstart, err := app.ProcessQuickstart(context.Background(), "https://docs.example.com/", "", nil)
+	if err != nil {
+		t.Fatalf("ProcessQuickstart failed: %v", err)
+	}
+
+	for _, page := range []string{"Page: https://docs.example.com/\nWelcome to Lib", "Page: https://docs.example.com/install\npip install lib"} {
+		if !strings.Contains(content, page) {
+			t.Errorf("Expected %q in the prompt, got %q", page, content)
+		}
+	}
+	if strings.Contains(content, "api") || strings.Contains(content, "github.com") {
+		t.Errorf("Expected only the getting started pages of the docs to be crawled, got %q", content)
+	}
+	want := ":package: インストール\n\n*Sources*\n• https://docs.example.com/\n• https://docs.example.com/install"
+	if quickstart != want {
+		t.Errorf("Expected %q, got %q", want, quickstart)
+	}
+}
+
+func TestApp_ProcessReport(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			if url == "https://example.com/broken" {
+				return "", errors.New("not found")
+			}
+			return "Text of " + url, nil
+		},
+	}
+	var sections []string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			text := userText(messages)
+			if !strings.Contains(text, "Source [1]: https://example.com/a\nText of https://example.com/a") || !strings.Contains(text, "Source [3]: https://example.com/b") {
+				t.Errorf("Expected the numbered sources, got %q", text)
+			}
+			if !strings.Contains(text, "Write the section") {
+				return &llm.Response{Text: `{"title": "A vs B", "sections": [{"heading": "Overview", "focus": "Both"}, {"heading": "Verdict", "focus": "Which wins"}]}`}, nil
+			}
+			sections = append(sections, text)
+			return &llm.Response{Text: fmt.Sprintf("Body %d [1][3]", len(sections))}, nil
+		},
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	report, err := app.ProcessReport(context.Background(), []string{"https://example.com/a", "https://example.com/broken", "https://example.com/b"}, "Which is better?", nil)
+	if err != nil {
+		t.Fatalf("ProcessReport failed: %v", err)
+	}
+
+	if len(sections) != 2 || !strings.Contains(sections[1], "- Overview\n- Verdict") || !strings.Contains(sections[1], `Write the section "Verdict": Which wins`) {
+		t.Errorf("Expected each section written with the outline, got %q", sections)
+	}
+	want := "# A vs B\n\n> Which is better?\n\n## Overview\n\nBody 1 [1][3]\n\n## Verdict\n\nBody 2 [1][3]\n\n## Sources\n\n" +
+		"- [1] https://example.com/a\n- [2] https://example.com/broken (could not be read: failed to fetch content: not found)\n- [3] https://example.com/b\n"
+	if got := report.Markdown(); got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestApp_ProcessContextPack(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			switch url {
+			case "https://example.com/broken":
+				return "", errors.New("not found")
+			case "https://example.com/long":
+				return strings.Repeat("x", packContentBytes+10), nil
+			}
+			return "Text of " + url, nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{Text: "Summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	pack, err := app.ProcessContextPack(context.Background(), []string{"https://example.com/a", "https://example.com/broken", "https://example.com/long"}, nil)
+	if err != nil {
+		t.Fatalf("ProcessContextPack failed: %v", err)
+	}
+	if len(pack.Pages) != 3 || pack.Pages[1].Error == "" || !pack.Pages[2].Truncated || len(pack.Pages[2].Content) != packContentBytes {
+		t.Fatalf("Unexpected pages %+v", pack.Pages)
+	}
+	md := pack.Markdown()
+	for _, want := range []string{"2. https://example.com/broken (could not be read: ", "## 1. https://example.com/a\n\nSource: https://example.com/a\n\n### Summary\n\nSummary\n\n### Content\n\nText of https://example.com/a", "[The rest of the page was cut"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Expected %q in the pack, got:\n%s", want, md)
+		}
+	}
+
+	if _, err := app.ProcessContextPack(context.Background(), []string{"https://example.com/broken"}, nil); err == nil {
+		t.Error("Expected an error when no page can be read")
+	}
+}
+
+func TestApp_FollowUpQuestions(t *testing.T) {
+	reply := `{"questions": ["What does it cost?", "` + strings.Repeat("x", followUpMaxRunes+1) + `"]}`
+	calls := 0
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			calls++
+			if calls > 1 {
+				reply = `{"questions": [" What does it cost? ", "Who is it for?"]}`
+			}
+			return &llm.Response{Text: reply}, nil
+		},
+	}
+	app := NewApp(&MockFetcher{}, mockLLM)
+	r := &Result{URL: "https://example.com", Content: "Text", Summary: "Summary"}
+
+	// Off by default
+	if questions, err := app.FollowUpQuestions(context.Background(), r); err != nil || questions != nil || calls != 0 {
+		t.Fatalf("Expected no questions with the feature off, got %q, %v", questions, err)
+	}
+
+	flags, err := feature.New(map[string]bool{feature.FollowUps: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetFeatures(flags)
+	app.SetJSONRepairAttempts(1)
+	questions, err := app.FollowUpQuestions(context.Background(), r)
+	if err != nil {
+		t.Fatalf("FollowUpQuestions failed: %v", err)
+	}
+	// The question too long for a button is repaired
+	if calls != 2 || strings.Join(questions, "|") != "What does it cost?|Who is it for?" {
+		t.Errorf("Unexpected questions %q after %d calls", questions, calls)
+	}
+}
+
+func TestApp_ThreadTitle(t *testing.T) {
+	var prompt string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			prompt = messages[len(messages)-1].Text()
+			return &llm.Response{Text: "**Rolling out the new billing API.**\nextra"}, nil
+		},
+	}
+	app := NewApp(&MockFetcher{}, mockLLM)
+	thread := &ThreadContext{Messages: []string{"<https://example.com>", strings.Repeat("x", threadTitleMessageRunes+1)}}
+
+	// Off by default
+	if title, err := app.ThreadTitle(context.Background(), thread, "answer"); err != nil || title != "" || prompt != "" {
+		t.Fatalf("Expected no title with the feature off, got %q, %v", title, err)
+	}
+
+	flags, err := feature.New(map[string]bool{feature.ThreadTitles: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetFeatures(flags)
+	title, err := app.ThreadTitle(context.Background(), thread, "answer")
+	if err != nil {
+		t.Fatalf("ThreadTitle failed: %v", err)
+	}
+	if title != "Rolling out the new billing API" {
+		t.Errorf("Unexpected title %q", title)
+	}
+	if !strings.Contains(prompt, "Message 3: answer") || strings.Contains(prompt, strings.Repeat("x", threadTitleMessageRunes+1)) {
+		t.Errorf("Expected the answer and the messages cut, got %q", prompt)
+	}
+}
+
+func TestCleanThreadTitle(t *testing.T) {
+	tests := map[string]string{
+		`Title: "Choosing a queue"`: "Choosing a queue",
+		"「キューの選定」。":                 "キューの選定",
+		"":                          "",
+		".NET 9 migration.":         ".NET 9 migration",
+		strings.Repeat("a", 100):    strings.Repeat("a", threadTitleMaxRunes-1) + "…",
+	}
+	for in, want := range tests {
+		if got := cleanThreadTitle(in); got != want {
+			t.Errorf("cleanThreadTitle(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestApp_ModelParams(t *testing.T) {
+	var got []llm.Params
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			got = append(got, opts.Params)
+			return &llm.Response{Text: "Summary"}, nil
+		},
+	}
+	app := NewApp(nil, mockLLM)
+	temperature, topP := 0.3, 0.9
+	app.SetModelParams(llm.Params{Temperature: &temperature, MaxTokens: 400})
+
+	if _, err := app.ProcessContent(context.Background(), "Text", ""); err != nil {
+		t.Fatalf("ProcessContent failed: %v", err)
+	}
+	if _, err := app.call(context.Background(), mockLLM, nil, llm.Options{Params: llm.Params{TopP: &topP, MaxTokens: 50}}); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if p := got[0]; p.Temperature != &temperature || p.TopP != nil || p.MaxTokens != 400 {
+		t.Errorf("Expected the configured parameters, got %+v", p)
+	}
+	if p := got[1]; p.Temperature != &temperature || p.TopP != &topP || p.MaxTokens != 50 {
+		t.Errorf("Expected the parameters of the call to override the configured ones, got %+v", p)
+	}
+}
+
+func TestApp_Summarize_Structured(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Go 1.24 adds generic type aliases.", nil
+		},
+	}
+	replies := []string{
+		`{"title": "Go 1.24", "tldr": [], "sections": [], "answer_to_question": ""}`,
+		`{"title": "Go 1.24", "tldr": ["Generic type aliases", "Faster maps", "New tool directive"], "sections": [{"heading": "Language", "body": "Type aliases can be generic."}], "answer_to_question": "Yes."}`,
+	}
+	var calls int
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if opts.Schema == nil || opts.Schema.Name != "summary" {
+				return nil, errors.New("expected the summary schema")
+			}
+			calls++
+			return &llm.Response{Text: replies[min(calls, len(replies))-1], Model: "gpt-4o", Usage: llm.Usage{TotalTokens: 10}}, nil
+		},
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetJSONRepairAttempts(1)
+
+	// A reply without a TL;DR is sent back for repair
+	result, err := app.Summarize(WithStructured(context.Background()), fetcher.FetchRequest{URL: "https://go.dev/blog/go1.24"}, "aliases?")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	s := result.Structured
+	if s == nil || s.Title != "Go 1.24" || len(s.TLDR) != 3 || s.AnswerToQuestion != "Yes." || calls != 2 {
+		t.Fatalf("Expected the repaired summary, got %+v after %d calls", s, calls)
+	}
+	if result.Usage.TotalTokens != 20 {
+		t.Errorf("Expected the usage of both attempts, got %+v", result.Usage)
+	}
+	if !strings.HasPrefix(result.Summary, "Yes.\n\n:white_check_mark: 3行要約\n- Generic type aliases\n") || !strings.Contains(result.Summary, ":memo: 説明\n*Language*\nType aliases can be generic.") {
+		t.Errorf("Expected the summary rendered in the regular format, got %q", result.Summary)
+	}
+	if md := s.Markdown(); !strings.HasPrefix(md, "# Go 1.24\n\nYes.\n\n## TL;DR\n\n- Generic type aliases\n") || !strings.Contains(md, "\n## Language\n\nType aliases can be generic.\n") {
+		t.Errorf("Unexpected Markdown %q", md)
+	}
+
+	// With the flag, regular summaries are rendered from the structure too
+	flags, err := feature.New(map[string]bool{feature.StructuredOutput: true}, nil)
+	if err != nil {
+		t.Fatalf("feature.New failed: %v", err)
+	}
+	app.SetFeatures(flags)
+	summary, err := app.ProcessURL(context.Background(), "https://go.dev/blog/go1.24", "")
+	if err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if !strings.HasPrefix(summary, ":white_check_mark: 3行要約\n") || strings.Contains(summary, "Yes.") {
+		t.Errorf("Expected the rendered summary without an answer, got %q", summary)
+	}
+}
+
+func TestPageModeFor(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.producthunt.com/posts/some-launch", llm.ModeProduct},
+		{"https://apps.apple.com/us/app/some-app/id123456789", llm.ModeProduct},
+		{"https://play.google.com/store/apps/details?id=com.example", llm.ModeProduct},
+		{"https://www.producthunt.com/leaderboard", ""},
+		{"https://example.com/posts/some-launch", ""},
+		{"https://example.com/terms-of-service", llm.ModeLegal},
+		{"https://example.com/legal/privacy", llm.ModeLegal},
+		{"https://policies.google.com/privacy?hl=ja", llm.ModeLegal},
+		{"https://example.jp/kiyaku.html", llm.ModeLegal},
+		{"https://example.com/blog/privacy-matters-to-us", ""},
+		{"https://example.com/termsheet", ""},
+		{"https://boards.greenhouse.io/example/jobs/4012345", llm.ModeJob},
+		{"https://jobs.lever.co/example/0c3f1a2b-1234-4cde-9f00-0123456789ab", llm.ModeJob},
+		{"https://www.linkedin.com/jobs/view/3912345678/", llm.ModeJob},
+		{"https://jp.indeed.com/viewjob?jk=abc123", llm.ModeJob},
+		{"https://example.recruitee.com/o/backend-engineer", llm.ModeJob},
+		{"https://jobs.lever.co/example", ""},
+		{"https://www.linkedin.com/in/someone", ""},
+		{"https://www.sec.gov/Archives/edgar/data/320193/000032019324000123/aapl-20240928.htm", llm.ModeFiling},
+		{"https://www.sec.gov/cgi-bin/browse-edgar?action=getcompany", ""},
+		{"https://release.tdnet.info/inbs/140120240508512345.pdf", llm.ModeFiling},
+		{"https://investor.example.com/news-releases/q3-2024", llm.ModeFiling},
+		{"https://example.co.jp/ir/library/kessan.html", llm.ModeFiling},
+		{"https://example.com/news/earnings-release-q2", llm.ModeFiling},
+		{"https://example.com/blog/irrelevant-results", ""},
+		{"https://www.githubstatus.com/incidents/abc123xyz", llm.ModeIncident},
+		{"https://status.openai.com/", llm.ModeIncident},
+		{"https://example.statuspage.io/incidents/1", llm.ModeIncident},
+		{"https://blog.example.com/2024/06/postmortem-of-the-june-outage", llm.ModeIncident},
+		{"https://example.com/blog/stateful-services", ""},
+		{"https://cookpad.com/recipe/1234567", llm.ModeRecipe},
+		{"https://www.allrecipes.com/recipe/12345/best-chocolate-chip-cookies/", llm.ModeRecipe},
+		{"https://www.allrecipes.com/gallery/weeknight-dinners/", ""},
+		{"https://ja.wikihow.com/%E3%83%8D%E3%82%AF%E3%82%BF%E3%82%A4%E3%82%92%E7%B5%90%E3%81%B6", llm.ModeRecipe},
+		{"https://myfoodblog.example.com/recipes/grandmas-lasagna/", llm.ModeRecipe},
+		{"https://example.com/how-to/replace-a-bike-chain", llm.ModeRecipe},
+		{"https://example.com/blog/how-to-think-about-pricing", ""},
+	}
+	for _, tt := range tests {
+		if got := pageModeFor(tt.url); got != tt.want {
+			t.Errorf("pageModeFor(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestApp_ProcessURL_Chunked(t *testing.T) {
+	paragraph := strings.Repeat("word ", 19) + "end.\n\n"
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return strings.Repeat(paragraph, 9), nil
+		},
+	}
+	var notes int
+	var progress []string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			text := userText(messages)
+			if strings.Contains(messages[0].Text(), "long document") {
+				notes++
+				if !strings.Contains(text, "What is the word?") {
+					return nil, errors.New("expected the question in the chunk prompt")
+				}
+				return &llm.Response{Text: "- note", Usage: llm.Usage{TotalTokens: 10}}, nil
+			}
+			if !strings.Contains(text, "Notes on part 3/3:\n- note") {
+				return nil, errors.New("expected the notes in the final prompt")
+			}
+			return &llm.Response{Text: "Summary of notes", Usage: llm.Usage{TotalTokens: 5}}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetChunking(500, 400)
+	result, err := app.Summarize(context.Background(), fetcher.FetchRequest{URL: "https://example.com/long"}, "What is the word?")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if notes != 3 || result.Summary != "Summary of notes" || result.Usage.TotalTokens != 35 {
+		t.Errorf("Expected 3 chunks and their usage, got %d chunks, %+v", notes, result)
+	}
+
+	_, err = app.ProcessURLWithProgress(context.Background(), "https://example.com/long", "What is the word?", func(message string) {
+		progress = append(progress, message)
+	})
+	if err != nil {
+		t.Fatalf("ProcessURLWithProgress failed: %v", err)
+	}
+	if !slices.Contains(progress, ":loading: Reading part 2/3 of https://example.com/long...") {
+		t.Errorf("Expected progress for each chunk, got %v", progress)
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"abc", 5, "abc"},
+		{"abcdef", 3, "abc"},
+		{"日本語", 4, "日"}, // 3 bytes per character: the second one is not cut in half
+		{"日本語", 6, "日本"},
+	} {
+		if got := truncateBytes(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateBytes(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSplitChunks(t *testing.T) {
+	chunks := splitChunks("first paragraph\n\nsecond paragraph\nthird line", 30)
+	if len(chunks) != 2 || chunks[0] != "first paragraph" || chunks[1] != "second paragraph\nthird line" {
+		t.Errorf("Expected a break between paragraphs, got %q", chunks)
+	}
+	chunks = splitChunks(strings.Repeat("あ", 25), 10)
+	if len(chunks) != 3 || chunks[2] != strings.Repeat("あ", 5) {
+		t.Errorf("Expected text without breaks cut at the size, got %q", chunks)
+	}
+}
+
+func TestApp_PodcastDigest(t *testing.T) {
+	now := time.Now()
+	feeds := []*feed.Feed{
+		{Title: "Tech Talk", Items: []feed.Item{
+			{Title: "New episode", Link: "https://example.com/ep2", Description: "Show notes", Published: now.Add(-24 * time.Hour)},
+			{Title: "Old episode", Link: "https://example.com/ep1", Description: "Old notes", Published: now.Add(-30 * 24 * time.Hour)},
+		}},
+		{Title: "Quiet Show", Items: []feed.Item{
+			{Title: "Ancient", Published: now.Add(-60 * 24 * time.Hour)},
+		}},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if strings.Contains(userText(messages), "Old notes") {
+				return nil, errors.New("old episodes should not be summarized")
+			}
+			return &llm.Response{Text: "- Topic A\n- Topic B"}, nil
+		},
+	}
+
+	app := NewApp(&MockFetcher{}, mockLLM)
+	digest, err := app.PodcastDigest(context.Background(), feeds, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("PodcastDigest failed: %v", err)
+	}
+	for _, want := range []string{"1 episodes", "*Tech Talk*", "<https://example.com/ep2|New episode>", "    - Topic B"} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("Expected %q in digest, got:\n%s", want, digest)
+		}
+	}
+	if strings.Contains(digest, "Quiet Show") || strings.Contains(digest, "Old episode") {
+		t.Errorf("Expected only new episodes in digest, got:\n%s", digest)
+	}
+
+	empty, err := app.PodcastDigest(context.Background(), feeds, now)
+	if err != nil || empty != "" {
+		t.Errorf("Expected an empty digest without new episodes, got %q, %v", empty, err)
+	}
+}
+
+func TestApp_PodcastDigest_Screening(t *testing.T) {
+	now := time.Now()
+	feeds := []*feed.Feed{
+		{Title: "Tech Talk", Items: []feed.Item{
+			{Title: "Go 1.30", Link: "https://example.com/go", Description: "Notes about golang", Published: now.Add(-time.Hour)},
+			{Title: "Baking", Link: "https://example.com/bread", Description: "Notes about bread", Published: now.Add(-time.Hour)},
+		}},
+		{Title: "Food Show", Items: []feed.Item{
+			{Title: "Pasta", Link: "https://example.com/pasta", Description: "Notes about pasta", Published: now.Add(-time.Hour)},
+		}},
+	}
+	var summarized []string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			text := userText(messages)
+			if opts.Model == "cheap-model" {
+				if strings.Contains(text, "Notes about golang") {
+					return &llm.Response{Text: "YES"}, nil
+				}
+				return &llm.Response{Text: "NO"}, nil
+			}
+			summarized = append(summarized, text)
+			return &llm.Response{Text: "- Summary"}, nil
+		},
+	}
+	app := NewApp(&MockFetcher{}, mockLLM)
+	app.SetScreening("cheap-model", []string{"golang"})
+
+	digest, err := app.PodcastDigest(context.Background(), feeds, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("PodcastDigest failed: %v", err)
+	}
+	if len(summarized) != 1 || !strings.Contains(summarized[0], "golang") {
+		t.Errorf("Expected only the relevant episode to be summarized, got %q", summarized)
+	}
+	if !strings.Contains(digest, "Go 1.30") || strings.Contains(digest, "Baking") || strings.Contains(digest, "Food Show") {
+		t.Errorf("Expected only the relevant episode in the digest, got:\n%s", digest)
+	}
+}
+
+// mockBatcher is a MockLLM that also runs batches, finishing them on the second poll.
+type mockBatcher struct {
+	MockLLM
+	submitted []llm.BatchRequest
+	polls     int
+}
+
+func (m *mockBatcher) SubmitBatch(ctx context.Context, requests []llm.BatchRequest) (string, error) {
+	m.submitted = requests
+	return "batch_1", nil
+}
+
+func (m *mockBatcher) BatchResults(ctx context.Context, batchID string) (map[string]llm.BatchResult, bool, error) {
+	m.polls++
+	if m.polls < 2 {
+		return nil, false, nil
+	}
+	results := make(map[string]llm.BatchResult)
+	for i, r := range m.submitted {
+		if i == 0 {
+			results[r.ID] = llm.BatchResult{Response: &llm.Response{Text: "- Batched topic"}}
+		} else {
+			results[r.ID] = llm.BatchResult{Err: errors.New("rate limited")}
+		}
+	}
+	return results, true, nil
+}
+
+func TestApp_PodcastDigestBatch(t *testing.T) {
+	now := time.Now()
+	feeds := []*feed.Feed{
+		{Title: "Tech Talk", Items: []feed.Item{
+			{Title: "New episode", Link: "https://example.com/ep2", Description: "Show notes", Published: now.Add(-24 * time.Hour)},
+			{Title: "Other episode", Link: "https://example.com/ep3", Description: "More notes", Published: now.Add(-48 * time.Hour)},
+			{Title: "Bare episode", Published: now.Add(-72 * time.Hour)},
+		}},
+	}
+	batcher := &mockBatcher{}
+	app := NewApp(&MockFetcher{}, batcher)
+
+	p, err := app.SubmitPodcastDigest(context.Background(), feeds, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("SubmitPodcastDigest failed: %v", err)
+	}
+	if p.BatchID != "batch_1" || len(batcher.submitted) != 2 || !strings.Contains(userText(batcher.submitted[0].Messages), "Show notes") {
+		t.Fatalf("Expected the episodes with show notes in one batch, got %+v", batcher.submitted)
+	}
+
+	// The pending digest survives a round trip through a state file
+	data, _ := json.Marshal(p)
+	var restored PendingDigest
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if _, done, err := app.CollectPodcastDigest(context.Background(), &restored); done || err != nil {
+		t.Fatalf("Expected the batch to be running, got %v, %v", done, err)
+	}
+	digest, done, err := app.CollectPodcastDigest(context.Background(), &restored)
+	if !done || err != nil {
+		t.Fatalf("Expected the finished digest, got %v, %v", done, err)
+	}
+	for _, want := range []string{"3 episodes", "<https://example.com/ep2|New episode>", "    - Batched topic", "<https://example.com/ep3|Other episode>", "• Bare episode"} {
+		if !strings.Contains(digest, want) {
+			t.Errorf("Expected %q in digest, got:\n%s", want, digest)
+		}
+	}
+
+	if p, err := app.SubmitPodcastDigest(context.Background(), feeds, now); p != nil || err != nil {
+		t.Errorf("Expected nothing to submit without new episodes, got %+v, %v", p, err)
+	}
+	if _, err := NewApp(&MockFetcher{}, &MockLLM{}).SubmitPodcastDigest(context.Background(), feeds, now.Add(-7*24*time.Hour)); err == nil {
+		t.Error("Expected an error for providers without batches")
+	}
+}
+
+func TestApp_ProcessHTML(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Extracted text", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if !strings.Contains(userText(messages), "Extracted text") {
+				return nil, errors.New("expected the extracted text")
+			}
+			return &llm.Response{Text: "HTML summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	result, err := app.ProcessHTML(context.Background(), "<p>Hello</p>", "https://example.com/a", "")
+	if err != nil {
+		t.Fatalf("ProcessHTML failed: %v", err)
+	}
+	if result != "HTML summary" {
+		t.Errorf("Expected result 'HTML summary', got '%s'", result)
+	}
+	if mockFetcher.LastRequest.HTML != "<p>Hello</p>" || mockFetcher.LastRequest.URL != "https://example.com/a" {
+		t.Errorf("Expected the HTML to be handed to the fetcher, got %+v", mockFetcher.LastRequest)
+	}
+
+	app.SetPolicy(policy.New([]string{"example.com"}), nil)
+	if _, err := app.ProcessHTML(context.Background(), "<p>Hello</p>", "https://example.com/a", ""); err == nil {
+		t.Error("Expected the local-only policy to apply to the base URL")
+	}
+}
+
+func TestApp_Summarize_LengthRouting(t *testing.T) {
+	pages := map[string]string{
+		"https://example.com/short":  "A 200-word page.",
+		"https://example.com/medium": strings.Repeat("word ", 20),
+		"https://example.com/long":   strings.Repeat("word ", 100),
+	}
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return pages[url], nil
+		},
+	}
+	var models []string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			models = append(models, opts.Model)
+			return &llm.Response{Text: "Summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetLengthRouting(LengthRouting{ShortModel: "fast-model", ShortMaxChars: 50, LongModel: "large-model", LongMinChars: 400})
+	for _, url := range []string{"https://example.com/short", "https://example.com/medium", "https://example.com/long"} {
+		if _, err := app.Summarize(context.Background(), fetcher.FetchRequest{URL: url}, ""); err != nil {
+			t.Fatalf("Summarize failed: %v", err)
+		}
+	}
+	if want := []string{"fast-model", "", "large-model"}; strings.Join(models, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected models %q, got %q", want, models)
+	}
+
+	// Pages kept on the local model stay on it
+	models = nil
+	local := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			models = append(models, "local:"+opts.Model)
+			return &llm.Response{Text: "Summary"}, nil
+		},
+	}
+	app.SetPolicy(policy.New([]string{"example.com"}), local)
+	if _, err := app.Summarize(context.Background(), fetcher.FetchRequest{URL: "https://example.com/short"}, ""); err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if len(models) != 1 || models[0] != "local:" {
+		t.Errorf("Expected the local model's default, got %q", models)
+	}
+}
+
+func TestApp_QuickSummary(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Long article", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if opts.Model != "cheap-model" {
+				return nil, errors.New("expected the quick model")
+			}
+			return &llm.Response{Text: "  Go 1.24 ships generic type aliases.\nExtra line"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetQuickModel("cheap-model")
+	result, err := app.QuickSummary(context.Background(), "https://example.com", "")
+	if err != nil {
+		t.Fatalf("QuickSummary failed: %v", err)
+	}
+	if result != "Go 1.24 ships generic type aliases." {
+		t.Errorf("Expected a single trimmed line, got %q", result)
+	}
+	if mockFetcher.LastRequest.MaxBytes != quickMaxBytes {
+		t.Errorf("Expected extraction limited to %d bytes, got %d", quickMaxBytes, mockFetcher.LastRequest.MaxBytes)
+	}
+}
+
+func TestApp_History(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			if url == "https://example.com/broken" {
+				return "", errors.New("navigation failed")
+			}
+			return "Mock page content", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{Text: "Mock summary", Model: "mock-model", Usage: llm.Usage{TotalTokens: 42}}, nil
+		},
+	}
+
+	store := history.NewStore("")
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetHistory(store)
+
+	ctx := reqmeta.With(context.Background(), reqmeta.Metadata{RequestID: "r1", Source: reqmeta.SourceSlack, Trigger: reqmeta.TriggerMention, Channel: "C1", User: "U1"})
+	result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://example.com/ok"}, "question")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if _, err := app.ProcessURL(ctx, "https://example.com/broken", ""); err == nil {
+		t.Fatal("Expected the fetch error")
+	}
+
+	entries, _ := store.Since(time.Time{})
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(entries))
+	}
+	ok, failed := entries[0], entries[1]
+	if ok.ID != result.ID || ok.User != "U1" || ok.RequestID != "r1" || ok.Trigger != reqmeta.TriggerMention || ok.Tokens != 42 || ok.Model != "mock-model" || ok.Summary != "Mock summary" || ok.Prompt != "question" {
+		t.Errorf("Unexpected entry %+v", ok)
+	}
+	if !strings.Contains(failed.Error, "navigation failed") || failed.Channel != "C1" {
+		t.Errorf("Unexpected failure entry %+v", failed)
+	}
+}
+
+func TestApp_Replay(t *testing.T) {
+	fetches := 0
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			fetches++
+			return "Fresh page content", nil
+		},
+	}
+	var content string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			content = userText(messages)
+			return &llm.Response{Text: "New summary", Model: "mock-model"}, nil
+		},
+	}
+
+	store := history.NewStore("")
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetHistory(store)
+	app.SetHistoryContent(true)
+	result, err := app.Summarize(context.Background(), fetcher.FetchRequest{URL: "https://example.com/a"}, "question")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	entry, err := store.Get(result.ID)
+	if err != nil || entry.Content != "Fresh page content" {
+		t.Fatalf("Expected the content to be recorded, got %+v, %v", entry, err)
+	}
+
+	entry.Content = "Cached page content"
+	replayed, err := app.Replay(context.Background(), entry, true)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if fetches != 1 || !strings.Contains(content, "Cached page content") || !strings.Contains(content, "question") || replayed.Summary != "New summary" {
+		t.Errorf("Expected the cached content to be summarized without fetching, got %d fetches, %q", fetches, content)
+	}
+
+	if _, err := app.Replay(context.Background(), entry, false); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if fetches != 2 || !strings.Contains(content, "Fresh page content") {
+		t.Errorf("Expected the page to be refetched, got %d fetches, %q", fetches, content)
+	}
+	if entries, _ := store.Since(time.Time{}); len(entries) != 1 {
+		t.Errorf("Expected replays not to be recorded, got %d entries", len(entries))
+	}
+}
+
+func TestApp_OutputFilters(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Mock page content", nil
+		},
+	}
+	var calls int
+	var correction string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			calls++
+			if calls == 1 {
+				return &llm.Response{Text: "Sure! Here is the summary:\n:white_check_mark: 3行要約\n- Point", Usage: llm.Usage{TotalTokens: 10}}, nil
+			}
+			correction = userText(messages)
+			return &llm.Response{Text: ":white_check_mark: 3行要約\n- Point\n\n:memo: 説明\n![chart](https://example.com/c.png)Details", Usage: llm.Usage{TotalTokens: 5}}, nil
+		},
+	}
+
+	filters, err := output.New([]string{output.StripImages, output.StripBoilerplate, output.RequireSections}, 0, []string{":white_check_mark:", ":memo:"})
+	if err != nil {
+		t.Fatalf("output.New failed: %v", err)
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetOutputFilters(filters)
+	result, err := app.Summarize(context.Background(), fetcher.FetchRequest{URL: "https://example.com"}, "")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if calls != 2 || !strings.Contains(correction, ":memo:") {
+		t.Fatalf("Expected one corrective prompt naming the missing section, got %d calls, %q", calls, correction)
+	}
+	if result.Summary != ":white_check_mark: 3行要約\n- Point\n\n:memo: 説明\nDetails" {
+		t.Errorf("Expected the filtered correction, got %q", result.Summary)
+	}
+	if result.Usage.TotalTokens != 15 {
+		t.Errorf("Expected the tokens of both calls, got %d", result.Usage.TotalTokens)
+	}
+}
+
+func TestApp_Shadow(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Mock page content", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if opts.Model != "candidate-model" {
+				return &llm.Response{Text: "Production summary", Model: "prod-model"}, nil
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(messages[0].Text(), "Be terse.") {
+				return nil, errors.New("expected the candidate prefix")
+			}
+			return &llm.Response{Text: "Candidate summary", Model: "candidate-model", Usage: llm.Usage{TotalTokens: 7}}, nil
+		},
+	}
+
+	prod := history.NewStore("")
+	shadow := history.NewStore("")
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetHistory(prod)
+	app.SetPersona(persona.New("Be polite."))
+	app.SetShadow(&Shadow{Model: "candidate-model", Persona: persona.New("Be terse."), Sample: 1, Store: shadow})
+
+	ctx, cancel := context.WithCancel(reqmeta.With(context.Background(), reqmeta.Metadata{Channel: "C1"}))
+	result, err := app.Summarize(ctx, fetcher.FetchRequest{URL: "https://example.com/a"}, "")
+	cancel()
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if result.Summary != "Production summary" {
+		t.Errorf("Expected the production summary to be returned, got %q", result.Summary)
+	}
+
+	var entries []history.Entry
+	for deadline := time.Now().Add(2 * time.Second); len(entries) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		entries, _ = shadow.Since(time.Time{})
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 shadow entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Summary != "Candidate summary" || e.Model != "candidate-model" || e.Baseline != "Production summary" || e.BaselineID != result.ID || e.Channel != "C1" || e.Error != "" {
+		t.Errorf("Unexpected shadow entry %+v", e)
+	}
+	if recorded, _ := prod.Since(time.Time{}); len(recorded) != 1 {
+		t.Errorf("Expected only the production summary in the history, got %d entries", len(recorded))
+	}
+
+	app.SetShadow(&Shadow{Model: "candidate-model", Sample: 0, Store: shadow})
+	if _, err := app.Summarize(context.Background(), fetcher.FetchRequest{URL: "https://example.com/b"}, ""); err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if entries, _ := shadow.Since(time.Time{}); len(entries) != 1 {
+		t.Errorf("Expected no shadow run with a zero sample, got %d entries", len(entries))
+	}
+}
+
+func TestApp_ProcessURL_Languages(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Mock page content", nil
+		},
+	}
+	var prompt string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			prompt = userText(messages)
+			return &llm.Response{Text: "Mock summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	if _, err := app.ProcessURL(WithLanguages(context.Background(), []string{"ja", "en"}), "https://example.com", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if !strings.Contains(prompt, "in Japanese; then") || !strings.Contains(prompt, "in English") {
+		t.Errorf("Expected a bilingual instruction, got %q", prompt)
+	}
+
+	if _, err := app.ProcessURL(context.Background(), "https://example.com", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if strings.Contains(prompt, "Japanese") {
+		t.Errorf("Expected no language instruction by default, got %q", prompt)
+	}
+}
+
+func TestApp_ProcessURL_TranslatedTitle(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Mock page content", nil
+		},
+		Metadata: fetcher.Metadata{Title: "Announcing Go 2", Language: "en-US"},
+	}
+	var translations []string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if strings.Contains(messages[0].Text(), "translate web page titles") {
+				translations = append(translations, userText(messages))
+				return &llm.Response{Text: "Go 2 の発表"}, nil
+			}
+			return &llm.Response{Text: "Mock summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	summary, err := app.ProcessURL(WithLanguages(context.Background(), []string{"ja", "en"}), "https://example.com", "")
+	if err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if summary != "*Go 2 の発表*\n_Announcing Go 2_\n\nMock summary" {
+		t.Errorf("Expected the translated and original titles as a header, got %q", summary)
+	}
+	if len(translations) != 1 || !strings.Contains(translations[0], "Languages: Japanese") || strings.Contains(translations[0], "English") {
+		t.Errorf("Expected the title to be translated into Japanese only, got %q", translations)
+	}
+
+	// Same language: no header and no extra call
+	summary, err = app.ProcessURL(WithLanguages(context.Background(), []string{"en"}), "https://example.com/en", "")
+	if err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if summary != "Mock summary" || len(translations) != 1 {
+		t.Errorf("Expected no title header for an English page in English, got %q", summary)
+	}
+}
+
+func TestPageLanguage(t *testing.T) {
+	for _, tc := range []struct{ declared, title, want string }{
+		{"en-US", "", "en"},
+		{"pt_BR", "", "pt"},
+		{"", "Go 1.24 リリースノート", "ja"},
+		{"", "Release notes", "en"},
+		{"", "1.24", ""},
+	} {
+		if got := pageLanguage(tc.declared, tc.title); got != tc.want {
+			t.Errorf("pageLanguage(%q, %q) = %q, want %q", tc.declared, tc.title, got, tc.want)
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for _, tc := range []struct{ text, want string }{
+		{"This is the announcement of the new release, and what changed in it.", "en"},
+		{"Go 1.24 がリリースされました。主な変更点を紹介します。", "ja"},
+		{"새로운 버전이 출시되었습니다.", "ko"},
+		{"新版本已经发布，主要变化如下。", "zh"},
+		{"La nouvelle version est disponible pour les utilisateurs.", "fr"},
+		{"Die neue Version ist da und bringt viele Änderungen mit sich.", "de"},
+		{"La nueva versión está disponible para los usuarios y es gratis.", "es"},
+		{"Kubernetes 1.30", ""},
+		{"", ""},
+	} {
+		if got := detectLanguage(tc.text); got != tc.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestApp_ProcessURL_ContentLanguage(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "The team announced the release of the new version, with a focus on speed.", nil
+		},
+	}
+	var prompt string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			prompt = userText(messages)
+			return &llm.Response{Text: "Mock summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	if _, err := app.ProcessURL(context.Background(), "https://example.com/en", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if !strings.Contains(prompt, "entire output in English") {
+		t.Errorf("Expected an English page to be summarized in English, got %q", prompt)
+	}
+
+	// A question is answered in its own language
+	if _, err := app.ProcessURL(context.Background(), "https://example.com/en", "速くなった理由は？"); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if !strings.Contains(prompt, "entire output in Japanese") {
+		t.Errorf("Expected a Japanese question to be answered in Japanese, got %q", prompt)
+	}
+
+	// Languages set for the channel take precedence
+	if _, err := app.ProcessURL(WithLanguages(context.Background(), []string{"ja"}), "https://example.com/en", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if !strings.Contains(prompt, "entire output in Japanese") || strings.Contains(prompt, "English") {
+		t.Errorf("Expected the requested language only, got %q", prompt)
+	}
+
+	flags, err := feature.New(map[string]bool{feature.ContentLanguage: false}, nil)
+	if err != nil {
+		t.Fatalf("feature.New failed: %v", err)
+	}
+	app.SetFeatures(flags)
+	if _, err := app.ProcessURL(context.Background(), "https://example.com/en", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if strings.Contains(prompt, "entire output in") {
+		t.Errorf("Expected no language instruction with the feature off, got %q", prompt)
+	}
+}
+
+func TestApp_RelatedLinks(t *testing.T) {
+	page := &fetcher.FetchResult{
+		FinalURL: "https://blog.example/post",
+		Metadata: fetcher.Metadata{Title: "HTTP/3 in practice"},
+		Markdown: "[Home](https://blog.example/) | [Share](https://social.example/share)\n\n" +
+			"The protocol is specified in [RFC 9114](https://www.rfc-editor.org/rfc/rfc9114) and our code is [on GitHub](https://github.com/example/h3).\n" +
+			"See [RFC 9114](https://www.rfc-editor.org/rfc/rfc9114#section-4) again and [this post](https://blog.example/post).",
+	}
+	links := outboundLinks(page.Markdown, page.FinalURL)
+	if len(links) != 4 || links[2].Text != "RFC 9114" || !strings.HasPrefix(links[2].Context, "The protocol is specified in RFC 9114") {
+		t.Fatalf("Expected distinct links with their context, got %+v", links)
+	}
+
+	var candidates string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			candidates = userText(messages)
+			return &llm.Response{Text: "3, 4, 42"}, nil
+		},
+	}
+	app := NewApp(&MockFetcher{}, mockLLM)
+	got := app.relatedLinks(context.Background(), mockLLM, page)
+	want := "\n\n:paperclip: *Related links*\n• <https://www.rfc-editor.org/rfc/rfc9114|RFC 9114>\n• <https://github.com/example/h3|on GitHub>"
+	if got != want {
+		t.Errorf("Expected the chosen links, got %q", got)
+	}
+	if !strings.Contains(candidates, "[3] RFC 9114 (https://www.rfc-editor.org/rfc/rfc9114) — The protocol is specified") {
+		t.Errorf("Expected numbered candidates with context, got %q", candidates)
+	}
+}
+
+func TestApp_ProcessURL_SourceType(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Introducing our new product!", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if strings.Contains(messages[0].Text(), "classify web pages") {
+				return &llm.Response{Text: "TYPE: Vendor blog\nPROMOTIONAL: yes"}, nil
+			}
+			return &llm.Response{Text: "Mock summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	summary, err := app.ProcessURL(context.Background(), "https://example.com", "")
+	if err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if summary != "Mock summary" {
+		t.Errorf("Expected no annotation by default, got %q", summary)
+	}
+
+	flags, err := feature.New(map[string]bool{feature.SourceType: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetFeatures(flags)
+	summary, err = app.ProcessURL(context.Background(), "https://example.com", "")
+	if err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if summary != "Mock summary\n\n:label: *Source:* vendor blog · :loudspeaker: promotional tone" {
+		t.Errorf("Expected a source type annotation, got %q", summary)
+	}
+}
+
+func TestApp_NumbersTable(t *testing.T) {
+	content := "Pricing. The Pro plan costs $20 per month. Teams pay $35 per seat. " +
+		"Benchmarks: p99 latency dropped to 12 ms on c7g.large, throughput rose by 40% and memory use fell to 300 MB."
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{Text: "```json\n" + `[
+				{"metric": "Pro plan price", "value": "$20", "context": "per month", "quote": "The Pro plan costs $20 per month."},
+				{"metric": "p99 latency", "value": "12 ms", "context": "c7g.large", "quote": "p99 latency dropped to 12 ms on c7g.large,"},
+				{"metric": "Teams price", "value": "$30", "context": "per seat", "quote": "Teams pay $35 per seat."},
+				{"metric": "Uptime", "value": "99.99%", "context": "", "quote": "Uptime is 99.99%."}
+			]` + "\n```"}, nil
+		},
+	}
+
+	app := NewApp(&MockFetcher{}, mockLLM)
+	got := app.numbersTable(context.Background(), mockLLM, content)
+	want := "\n\n:bar_chart: *Key numbers*\n```\n" +
+		"#  Metric          Value  Context\n" +
+		"1  Pro plan price  $20    per month\n" +
+		"2  p99 latency     12 ms  c7g.large\n" +
+		"```\n" +
+		"1. _“The Pro plan costs $20 per month.”_\n" +
+		"2. _“p99 latency dropped to 12 ms on c7g.large,”_"
+	if got != want {
+		t.Errorf("Expected only rows backed by the page, got %q", got)
+	}
+
+	if got := app.numbersTable(context.Background(), mockLLM, "An essay about the history of computing."); got != "" {
+		t.Errorf("Expected no table for pages without figures, got %q", got)
+	}
+}
+
+func TestApp_NumbersTableRepair(t *testing.T) {
+	content := "The Pro plan costs $20 per month. Teams pay $35 per seat. Latency is 12 ms, throughput rose by 40% and memory use fell to 300 MB."
+	replies := []string{
+		`[{"metric": "Pro plan price", "value": "$20", "quote": "The Pro plan costs $20 per month."`,
+		`[{"metric": "Pro plan price", "value": "$20", "unit": "USD", "quote": "The Pro plan costs $20 per month."}]`,
+		`[{"metric": "Pro plan price", "value": "$20", "context": "per month", "quote": "The Pro plan costs $20 per month."}]`,
+	}
+	var repairs []string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			if len(messages) > 2 {
+				repairs = append(repairs, userText(messages))
+			}
+			return &llm.Response{Text: replies[len(repairs)]}, nil
+		},
+	}
+
+	app := NewApp(&MockFetcher{}, mockLLM)
+	app.SetJSONRepairAttempts(2)
+	got := app.numbersTable(context.Background(), mockLLM, content)
+	if len(repairs) != 2 || !strings.Contains(repairs[0], "malformed JSON") || !strings.Contains(repairs[1], `unknown field "unit"`) {
+		t.Fatalf("Expected two repair prompts naming the problems, got %q", repairs)
+	}
+	if !strings.Contains(got, "Pro plan price  $20    per month") {
+		t.Errorf("Expected the repaired table, got %q", got)
+	}
+
+	repairs = nil
+	app.SetJSONRepairAttempts(1)
+	if got := app.numbersTable(context.Background(), mockLLM, content); got != "" || len(repairs) != 1 {
+		t.Errorf("Expected no table once the repairs run out, got %q after %d repairs", got, len(repairs))
+	}
+}
+
+func TestApp_Persona(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Mock page content", nil
+		},
+	}
+	var systems []string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			systems = append(systems, messages[0].Text())
+			return &llm.Response{Text: "YES"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetPersona(persona.New("Never give legal advice."))
+	app.SetScreening("cheap-model", []string{"law"})
+	if _, err := app.ProcessURL(context.Background(), "https://example.com", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if _, err := app.IsRelevant(context.Background(), "https://example.com", "content"); err != nil {
+		t.Fatalf("IsRelevant failed: %v", err)
+	}
+
+	if len(systems) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(systems))
+	}
+	if !strings.HasPrefix(systems[0], "Never give legal advice.") {
+		t.Errorf("Expected the persona before the summary prompt, got %q", systems[0])
+	}
+	if strings.Contains(systems[1], "legal advice") {
+		t.Errorf("Expected screening to ignore the persona, got %q", systems[1])
+	}
+}
+
+func TestApp_CheckPage(t *testing.T) {
+	page := "Pricing\nFree plan: $0\nPro plan: $10 per month\nTeam plan: $30 per month\nContact sales"
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return page, nil
+		},
+	}
+	reply, calls := "- The Pro plan price changed from $10 to $12 per month", 0
+	var diff string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			calls++
+			diff = userText(messages)
+			return &llm.Response{Text: reply}, nil
+		},
+	}
+	store, err := snapshot.NewStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	ctx := context.Background()
+	const url = "https://example.com/pricing"
+
+	// The first check only records a baseline, and an unchanged page is not described
+	for i := 0; i < 2; i++ {
+		if change, err := app.CheckPage(ctx, store, url, 0.1); err != nil || change != nil {
+			t.Fatalf("Expected no change, got %+v, %v", change, err)
+		}
+	}
+
+	// A change below the threshold is ignored and not saved
+	page = strings.Replace(page, "$10", "$12", 1)
+	if change, err := app.CheckPage(ctx, store, url, 0.5); err != nil || change != nil || calls != 0 {
+		t.Fatalf("Expected a trivial change to be ignored, got %+v, %v", change, err)
+	}
+
+	change, err := app.CheckPage(ctx, store, url, 0.1)
+	if err != nil || change == nil {
+		t.Fatalf("Expected a change, got %v", err)
+	}
+	if change.Description != reply || change.Ratio != 0.2 {
+		t.Errorf("Unexpected change %+v", change)
+	}
+	if !strings.Contains(diff, "- Pro plan: $10 per month\n+ Pro plan: $12 per month") {
+		t.Errorf("Expected the line diff in the prompt, got %q", diff)
+	}
+	if strings.Contains(diff, llm.LegalChangesFocus) {
+		t.Errorf("Expected no legal focus for a pricing page, got %q", diff)
+	}
+
+	// Changes the model finds cosmetic are not reported but become the new baseline
+	page += "\nLast updated: today"
+	reply = llm.NoChanges
+	if change, err := app.CheckPage(ctx, store, url, 0.05); err != nil || change != nil {
+		t.Fatalf("Expected a cosmetic change to be dropped, got %+v, %v", change, err)
+	}
+	if latest, _ := store.Latest(url); latest == nil || latest.Content != page {
+		t.Error("Expected the cosmetic change to be saved")
+	}
+}
+
+func TestApp_CheckPage_Legal(t *testing.T) {
+	page := "Privacy Policy\nWe keep your data for 30 days.\nWe do not sell your data."
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return page, nil
+		},
+	}
+	var prompt string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			prompt = userText(messages)
+			return &llm.Response{Text: "- :rotating_light: Data is now kept for a year"}, nil
+		},
+	}
+	store, err := snapshot.NewStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	ctx := context.Background()
+	const url = "https://example.com/privacy-policy"
+
+	if _, err := app.CheckPage(ctx, store, url, 0); err != nil {
+		t.Fatalf("CheckPage failed: %v", err)
+	}
+	page = strings.Replace(page, "30 days", "one year", 1)
+	if change, err := app.CheckPage(ctx, store, url, 0); err != nil || change == nil {
+		t.Fatalf("Expected a change, got %+v, %v", change, err)
+	}
+	if !strings.Contains(prompt, llm.LegalChangesFocus) {
+		t.Errorf("Expected the legal focus in the prompt, got %q", prompt)
+	}
+}
+
+func TestApp_Summarize_SharesConcurrentRequests(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	fetches, calls := 0, 0
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			mu.Lock()
+			fetches++
+			mu.Unlock()
+			<-release
+			return "Launch announcement", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			return &llm.Response{Text: "Mock summary"}, nil
+		},
+	}
+	store := history.NewStore("")
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetHistory(store)
+
+	req := fetcher.FetchRequest{URL: "https://example.com/launch"}
+	cancelled, cancel := context.WithCancel(context.Background())
+	var joined sync.WaitGroup
+	joined.Add(3)
+	results := make([]*Result, 3)
+	errs := make([]error, 3)
+	for i, ctx := range []context.Context{context.Background(), cancelled, context.Background()} {
+		go func() {
+			defer joined.Done()
+			results[i], errs[i] = app.summarizeRequest(ctx, req, "", nil)
+		}()
+	}
+	// Wait until all three requests share the flight, then give up on one of them
+	for {
+		app.flights.mu.Lock()
+		n := 0
+		for _, w := range app.flights.waiting {
+			n = w.n
+		}
+		app.flights.mu.Unlock()
+		if n == 3 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	joined.Wait()
+
+	if fetches != 1 || calls != 1 {
+		t.Errorf("Expected one fetch and one LLM call, got %d and %d", fetches, calls)
+	}
+	if errs[0] != nil || errs[2] != nil || results[0].Summary != "Mock summary" || results[2].Summary != "Mock summary" {
+		t.Errorf("Expected both remaining requests to get the summary, got %v, %v", errs[0], errs[2])
+	}
+	if !errors.Is(errs[1], context.Canceled) {
+		t.Errorf("Expected the cancelled request to fail, got %v", errs[1])
+	}
+	if results[0].ID == results[2].ID {
+		t.Error("Expected each request to be recorded separately")
+	}
+
+	// Requests carrying their own HTML are not shared
+	if app.flightKey(context.Background(), fetcher.FetchRequest{URL: req.URL, HTML: "<p>x</p>"}, "") != "" {
+		t.Error("Expected no flight key for HTML requests")
+	}
+	if app.flightKey(WithLanguages(context.Background(), []string{"en"}), req, "") == app.flightKey(context.Background(), req, "") {
+		t.Error("Expected output languages to be part of the flight key")
+	}
+}
+
+func TestApp_Summarize_SharesAcrossChannels(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	calls := 0
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			<-release
+			return "Launch announcement", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			return &llm.Response{Text: "Mock summary"}, nil
+		},
+	}
+	store := history.NewStore("")
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetHistory(store)
+	flags, err := feature.New(nil, map[string]map[string]bool{"C3": {feature.Citations: true}})
+	if err != nil {
+		t.Fatalf("feature.New failed: %v", err)
+	}
+	app.SetFeatures(flags)
+
+	req := fetcher.FetchRequest{URL: "https://example.com/launch"}
+	channels := []string{"C1", "C2"}
+	progress := make([][]string, len(channels))
+	results := make([]*Result, len(channels))
+	var done sync.WaitGroup
+	for i, channel := range channels {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			ctx := reqmeta.With(context.Background(), reqmeta.Metadata{Channel: channel, User: "U" + channel})
+			results[i], _ = app.summarizeRequest(ctx, req, "", func(message string) {
+				mu.Lock()
+				defer mu.Unlock()
+				progress[i] = append(progress[i], message)
+			})
+		}()
+	}
+	for {
+		app.flights.mu.Lock()
+		n := 0
+		for _, w := range app.flights.waiting {
+			n = w.n
+		}
+		app.flights.mu.Unlock()
+		if n == len(channels) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	done.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected one LLM call for both channels, got %d", calls)
+	}
+	for i, channel := range channels {
+		if results[i] == nil || results[i].Summary != "Mock summary" {
+			t.Errorf("Expected the summary in %s, got %+v", channel, results[i])
+		}
+		if !slices.ContainsFunc(progress[i], func(m string) bool { return strings.Contains(m, "Generating summary") }) {
+			t.Errorf("Expected the progress of the shared summary in %s, got %q", channel, progress[i])
+		}
+		if entry, err := store.Get(results[i].ID); err != nil || entry.Channel != channel {
+			t.Errorf("Expected a history entry of %s, got %+v (%v)", channel, entry, err)
+		}
+	}
+
+	// Channels whose flags change the summary do not share it
+	ctx := func(channel string) context.Context {
+		return reqmeta.With(context.Background(), reqmeta.Metadata{Channel: channel})
+	}
+	if app.flightKey(ctx("C1"), req, "") != app.flightKey(ctx("C2"), req, "") {
+		t.Error("Expected channels with the same flags to share the flight key")
+	}
+	if app.flightKey(ctx("C1"), req, "") == app.flightKey(ctx("C3"), req, "") {
+		t.Error("Expected a channel overriding a summary flag to get its own flight key")
+	}
+}
+
+func TestApp_Debug(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Line one\nLine two", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			return &llm.Response{Text: "Mock summary", Model: "mock-model", Usage: llm.Usage{TotalTokens: 42}}, nil
+		},
+	}
+	store := history.NewStore("")
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetHistory(store)
+	app.SetPersona(persona.New("Be brief."))
+
+	req := fetcher.FetchRequest{URL: "https://example.com/page", WaitStrategy: fetcher.WaitNetworkIdle}
+	trace, err := app.Debug(context.Background(), req, "")
+	if err != nil {
+		t.Fatalf("Debug failed: %v", err)
+	}
+	if mockFetcher.LastRequest.WaitStrategy != fetcher.WaitNetworkIdle || trace.Route != "default" || trace.Mode != llm.ModeSummary {
+		t.Errorf("Unexpected trace %+v", trace)
+	}
+	if got := trace.ContentStats(); got != "17 bytes, 17 characters, 4 words, 2 lines" {
+		t.Errorf("Unexpected content stats %q", got)
+	}
+	if !strings.HasPrefix(trace.Messages[0].Text(), "Be brief.") {
+		t.Errorf("Expected the prompt preview to include the persona, got %q", trace.Messages[0].Text())
+	}
+	if trace.Response.Model != "mock-model" || len(trace.Stages) != 3 || trace.Stages[1].Name != "fetch" {
+		t.Errorf("Unexpected response or stages %+v %+v", trace.Response, trace.Stages)
+	}
+	if entries, _ := store.Since(time.Time{}); len(entries) != 0 {
+		t.Errorf("Expected traces to stay out of the history, got %d entries", len(entries))
+	}
+
+	mockFetcher.FetchFunc = func(ctx context.Context, url string) (string, error) { return "", errors.New("timeout") }
+	trace, err = app.Debug(context.Background(), req, "")
+	if err == nil || len(trace.Stages) != 2 || trace.Stages[1].Err == nil || trace.Response != nil {
+		t.Errorf("Expected the trace up to the failed fetch, got %+v, %v", trace, err)
+	}
+}
+
+func TestApp_ProcessBrief(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			if url == "https://example.com/down" {
+				return "", errors.New("connection refused")
+			}
+			return "Release notes of v2", nil
+		},
+	}
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			text := userText(messages)
+			if !strings.Contains(text, "Source [1]: https://example.com/v2\nRelease notes of v2") ||
+				!strings.Contains(text, "Source [3]: attached file notes.txt\nMigration notes") {
+				return nil, errors.New("expected numbered sources in the prompt, got " + text)
+			}
+			if strings.Contains(text, "example.com/down") {
+				return nil, errors.New("expected unreadable sources to be left out of the prompt")
+			}
+			return &llm.Response{Text: "v2 needs a migration [1][3]"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	brief, err := app.ProcessBrief(context.Background(), []string{"https://example.com/v2", "https://example.com/down"},
+		[]Document{{Name: "notes.txt", Content: "Migration notes"}}, nil, "Should we upgrade?", nil)
+	if err != nil {
+		t.Fatalf("ProcessBrief failed: %v", err)
+	}
+	want := "v2 needs a migration [1][3]\n\n*Sources*\n[1] https://example.com/v2\n[2] https://example.com/down — :warning: could not be read\n[3] notes.txt"
+	if brief != want {
+		t.Errorf("Expected %q, got %q", want, brief)
+	}
+
+	if _, err := app.ProcessBrief(context.Background(), []string{"https://example.com/down"}, nil, nil, "", nil); err == nil {
+		t.Error("Expected an error when no source can be read")
+	}
+}
+
+func TestApp_ProcessURL_Style(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Mock page content", nil
+		},
+	}
+	var system string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			system = messages[0].Text()
+			return &llm.Response{Text: "Mock summary"}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	// The style wins over the format of the kind of page
+	if _, err := app.ProcessURL(WithStyle(context.Background(), "eli5"), "https://cookpad.com/recipe/123", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if !strings.Contains(system, "patient teacher") {
+		t.Errorf("Expected the ELI5 prompt, got %q", system)
+	}
+	if _, err := app.ProcessURL(WithStyle(context.Background(), "executive"), "https://example.com", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if !strings.Contains(system, "chief of staff") {
+		t.Errorf("Expected the executive prompt, got %q", system)
+	}
+
+	req := fetcher.FetchRequest{URL: "https://example.com"}
+	if app.flightKey(WithStyle(context.Background(), "detailed"), req, "") == app.flightKey(context.Background(), req, "") {
+		t.Error("Expected requests of different styles not to share a summary")
+	}
+	if !ValidStyle("tldr") || ValidStyle("haiku") {
+		t.Error("Expected only the listed styles to be valid")
+	}
+}
+
+func TestApp_ProcessURL_Citations(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			return "Go 1.24 adds generic type aliases.\nThe Swiss table map is faster.", nil
+		},
+	}
+	var calls int
+	var correction string
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			calls++
+			if calls == 1 {
+				return &llm.Response{Text: ":white_check_mark: 要点\n- Generic aliases\n> Go 1.24 adds generic type aliases.\n- Maps are 3x faster\n> Maps are 3x faster."}, nil
+			}
+			correction = userText(messages)
+			return &llm.Response{Text: ":white_check_mark: 要点\n- Generic aliases\n> “Go 1.24 adds generic  type aliases.”\n- Faster maps\n> The Swiss table map is faster.\n- Made up\n> Released in 2019."}, nil
+		},
+	}
+
+	app := NewApp(mockFetcher, mockLLM)
+	flags, err := feature.New(map[string]bool{feature.Citations: true}, nil)
+	if err != nil {
+		t.Fatalf("feature.New failed: %v", err)
+	}
+	app.SetFeatures(flags)
+	summary, err := app.ProcessURL(context.Background(), "https://go.dev/blog/go1.24", "")
+	if err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if calls != 2 || !strings.Contains(correction, "- Maps are 3x faster.") {
+		t.Errorf("Expected one correction naming the missing quote, got %d calls, %q", calls, correction)
+	}
+	if !strings.Contains(summary, "- Faster maps\n> The Swiss table map is faster.") || strings.Contains(summary, "Made up") || strings.Contains(summary, "2019") {
+		t.Errorf("Expected only the claims with quotes in the page, got %q", summary)
+	}
+	if !strings.Contains(summary, ":warning: 1 claim(s) were left out") {
+		t.Errorf("Expected a note about the left out claim, got %q", summary)
+	}
+}
+
+func TestApp_ProcessCrossCheck(t *testing.T) {
+	mockFetcher := &MockFetcher{
+		FetchFunc: func(ctx context.Context, url string) (string, error) {
+			if url == "https://example.com/broken" {
+				return "", errors.New("not found")
+			}
+			return "Text of " + url, nil
+		},
+	}
+	var attempts int
+	mockLLM := &MockLLM{
+		GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+			text := userText(messages)
+			if strings.HasPrefix(text, "Article:") {
+				return &llm.Response{Text: `{"claims": ["The plant opened in 2020.", "It employs 500 people.", "It runs on solar power."]}`}, nil
+			}
+			attempts++
+			if attempts == 1 {
+				if !strings.Contains(text, "1. The plant opened in 2020.\n") || !strings.Contains(text, "Source [3]: https://example.com/b\nText of https://example.com/b") {
+					t.Errorf("Expected the numbered claims and sources, got %q", text)
+				}
+				// Citing the source that could not be read is sent back for a repair
+				return &llm.Response{Text: `{"checks": [{"verdict": "agrees", "sources": [2], "note": ""}, {"verdict": "agrees", "sources": [1], "note": ""}, {"verdict": "unmentioned", "sources": [], "note": ""}]}`}, nil
+			}
+			if !strings.Contains(text, "cites 2, which is not a provided source") {
+				t.Errorf("Expected the repair to name the source, got %q", text)
+			}
+			return &llm.Response{Text: `{"checks": [
+				{"verdict": "agrees", "sources": [1], "note": "Source 1 says 2020."},
+				{"verdict": "contradicts", "sources": [3], "note": "Source 3 says 350 people."},
+				{"verdict": "unmentioned", "sources": [], "note": ""}
+			]}`}, nil
+		},
+	}
+	app := NewApp(mockFetcher, mockLLM)
+	app.SetJSONRepairAttempts(1)
+	urls := []string{"https://example.com/article", "https://example.com/a", "https://example.com/broken", "https://example.com/b"}
+	got, err := app.ProcessCrossCheck(context.Background(), urls, nil)
+	if err != nil {
+		t.Fatalf("ProcessCrossCheck failed: %v", err)
+	}
+	want := "*Cross-check of https://example.com/article*\n" +
+		"\n:x: It employs 500 people.\n      _Source 3 says 350 people._ [3]" +
+		"\n:white_check_mark: The plant opened in 2020.\n      _Source 1 says 2020._ [1]" +
+		"\n:grey_question: It runs on solar power." +
+		"\n\n3 claim(s): 1 contradicted, 1 agreed, 1 not mentioned" +
+		"\n\n*Sources*\n[1] https://example.com/a\n[2] https://example.com/broken — :warning: could not be read\n[3] https://example.com/b"
+	if got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+
+	if _, err := app.ProcessCrossCheck(context.Background(), urls[:1], nil); err == nil {
+		t.Error("Expected an error without other URLs to check against")
+	}
+}
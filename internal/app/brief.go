@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/metrics"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// Document is text the caller already has, such as a file attached to a Slack message.
+type Document struct {
+	Name    string
+	Content string
+}
+
+// briefSource is a source of a brief as listed under it.
+type briefSource struct {
+	name string // URL or file name
+	err  error  // Why the source could not be read, if it could not
+}
+
+// ProcessBrief synthesizes everything given, pages and attachments alike, into one brief citing its
+// sources by number, instead of summarizing each on its own. If userPrompt is provided, it is answered
+// first. Sources that cannot be read are listed as such; the brief fails only if none can be read.
+func (a *App) ProcessBrief(ctx context.Context, urls []string, documents []Document, images []Image, userPrompt string, progressCallback ProgressCallback) (string, error) {
+	if len(urls)+len(documents)+len(images) == 0 {
+		return "", errors.New("nothing to brief")
+	}
+	model, err := a.llmFor(urls...)
+	if err != nil {
+		return "", err
+	}
+
+	var sources []briefSource
+	var content strings.Builder
+	read := 0
+	for i, url := range urls {
+		if progressCallback != nil {
+			progressCallback(fmt.Sprintf(":loading: Fetching source %d/%d: %s", i+1, len(urls), url))
+		}
+		start := time.Now()
+		page, err := a.fetcher.Fetch(ctx, fetcher.FetchRequest{URL: url})
+		a.metrics.Observe(metrics.StageFetch, url, time.Since(start), err)
+		if err == nil && page.Text == "" {
+			err = fetcher.NewPageError(url, page.Diagnostics)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			reqmeta.Logf(ctx, "[App] Leaving %s out of the brief: %v", url, err)
+			sources = append(sources, briefSource{name: url, err: err})
+			continue
+		}
+		sources = append(sources, briefSource{name: url})
+		fmt.Fprintf(&content, "Source [%d]: %s\n%s\n\n", len(sources), url, page.Text)
+		read++
+	}
+	for _, doc := range documents {
+		sources = append(sources, briefSource{name: doc.Name})
+		fmt.Fprintf(&content, "Source [%d]: attached file %s\n%s\n\n", len(sources), doc.Name, doc.Content)
+		read++
+	}
+	if read == 0 && len(images) == 0 {
+		return "", fmt.Errorf("none of the sources could be read: %w", sources[0].err)
+	}
+
+	messages := llm.BuildMessages(llm.ModeBrief, strings.TrimSpace(content.String()), userPrompt)
+	opts := llm.Options{}
+	if len(images) > 0 {
+		// Images are numbered after the text sources and sent with the prompt
+		user := &messages[len(messages)-1]
+		for _, img := range images {
+			sources = append(sources, briefSource{name: img.Name})
+			user.Parts = append(user.Parts, llm.TextPart(fmt.Sprintf("Source [%d]: attached image %s", len(sources), img.Name)), llm.ImagePart(img.Data, img.MIMEType))
+		}
+		opts.Model = a.visionModel
+	}
+
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Writing a brief of %d sources...", len(sources)))
+	}
+	resp, err := a.generateStream(ctx, model, localize(ctx, messages), opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to process sources: %w", err)
+	}
+	return resp.Text + briefSourcesFooter(sources), nil
+}
+
+// briefSourcesFooter lists the sources under their citation numbers.
+func briefSourcesFooter(sources []briefSource) string {
+	var footer strings.Builder
+	footer.WriteString("\n\n*Sources*")
+	for i, s := range sources {
+		if s.err != nil {
+			fmt.Fprintf(&footer, "\n[%d] %s — :warning: could not be read", i+1, s.name)
+			continue
+		}
+		fmt.Fprintf(&footer, "\n[%d] %s", i+1, s.name)
+	}
+	return footer.String()
+}
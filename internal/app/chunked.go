@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// SetChunking makes pages longer than threshold characters summarized in chunks of about size characters:
+// each chunk is turned into notes, and the notes are summarized like a page. Zero threshold disables it.
+func (a *App) SetChunking(threshold, size int) {
+	a.chunkThreshold = threshold
+	a.chunkSize = size
+}
+
+// summarizeChunked summarizes content too long for a single call by taking notes on each chunk first.
+// The usage of the response covers every call.
+func (a *App) summarizeChunked(ctx context.Context, model llm.LLM, url string, content string, userPrompt string, progressCallback ProgressCallback) (*llm.Response, error) {
+	chunks := splitChunks(content, a.chunkSize)
+	reqmeta.Logf(ctx, "[App] Summarizing %s in %d chunks (%d characters)", url, len(chunks), len([]rune(content)))
+
+	var notes strings.Builder
+	var usage llm.Usage
+	for i, chunk := range chunks {
+		if progressCallback != nil {
+			progressCallback(fmt.Sprintf(":loading: Reading part %d/%d of %s...", i+1, len(chunks), url))
+		}
+		// Notes are only read by the model, so they skip the persona and the output languages
+		resp, err := a.call(ctx, model, llm.BuildMessages(llm.ModeChunk, chunk, userPrompt), llm.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to process part %d/%d: %w", i+1, len(chunks), err)
+		}
+		usage = addUsage(usage, resp.Usage)
+		fmt.Fprintf(&notes, "Notes on part %d/%d:\n%s\n\n", i+1, len(chunks), strings.TrimSpace(resp.Text))
+	}
+
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Combining the notes on %d parts of %s...", len(chunks), url))
+	}
+	resp, err := a.summarize(ctx, model, notes.String(), userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	resp.Usage = addUsage(usage, resp.Usage)
+	return resp, nil
+}
+
+// splitChunks splits content into chunks of at most size characters, preferring to break between
+// paragraphs, then between lines, so chunks rarely end mid-sentence.
+func splitChunks(content string, size int) []string {
+	runes := []rune(content)
+	var chunks []string
+	for len(runes) > size {
+		cut := size
+		if i := lastIndex(runes[:size], "\n\n"); i > size/2 {
+			cut = i
+		} else if i := lastIndex(runes[:size], "\n"); i > size/2 {
+			cut = i
+		}
+		if chunk := strings.TrimSpace(string(runes[:cut])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		runes = runes[cut:]
+	}
+	if chunk := strings.TrimSpace(string(runes)); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// truncateBytes returns s cut to at most n bytes, without leaving half of a multi-byte character at the end.
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+// lastIndex returns the index in runes where the last occurrence of sep starts, or -1.
+func lastIndex(runes []rune, sep string) int {
+	s := []rune(sep)
+	for i := len(runes) - len(s); i >= 0; i-- {
+		if string(runes[i:i+len(s)]) == sep {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// summarizeCited summarizes content backing every claim with a verbatim quote, and checks the quotes against
+// content before returning. The model gets one chance to correct quotes that are not in it; claims whose
+// quotes still are not are left out, with a note saying how many.
+func (a *App) summarizeCited(ctx context.Context, model llm.LLM, content string, userPrompt string) (*llm.Response, error) {
+	messages := localize(ctx, llm.BuildMessages(llm.ModeCited, content, userPrompt))
+	resp, err := a.generate(ctx, model, messages, llm.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process content: %w", err)
+	}
+	// Filters may rewrite the response, so the quotes are checked after them
+	resp = a.filterOutput(ctx, model, messages, llm.Options{}, resp)
+
+	source := normalizeQuote(content)
+	if missing := missingQuotes(resp.Text, source); len(missing) > 0 {
+		reqmeta.Logf(ctx, "[Citations] %d quote(s) not found in the page, asking for a correction", len(missing))
+		corrective := append(append([]llm.Message{}, messages...),
+			llm.Message{Role: llm.RoleAssistant, Parts: []llm.Part{llm.TextPart(resp.Text)}},
+			llm.Message{Role: llm.RoleUser, Parts: []llm.Part{llm.TextPart(
+				"These quotes do not appear verbatim in the content:\n- " + strings.Join(missing, "\n- ") +
+					"\n\nRewrite the complete response, copying every quote exactly from the content or leaving out the claims you cannot quote. Reply with the corrected response only.")}},
+		)
+		corrected, err := a.generate(ctx, model, corrective, llm.Options{})
+		if err != nil {
+			log.Printf("[Citations] Dropping the unquoted claims, correction failed: %v", err)
+		} else {
+			corrected.Usage = addUsage(resp.Usage, corrected.Usage)
+			resp = corrected
+		}
+	}
+
+	text, dropped := dropUnquoted(resp.Text, source)
+	if dropped > 0 {
+		reqmeta.Logf(ctx, "[Citations] Left out %d claim(s) whose quotes are not in the page", dropped)
+		text += fmt.Sprintf("\n\n:warning: %d claim(s) were left out because their quotes could not be found in the page.", dropped)
+	}
+	return withText(resp, text), nil
+}
+
+// quoteOf returns the quote of a line of ModeCited output, and whether the line is one.
+func quoteOf(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, strings.TrimSpace(llm.QuotePrefix)) {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, strings.TrimSpace(llm.QuotePrefix))), true
+}
+
+// normalizeQuote collapses whitespace and strips the quotation marks models add around quotes, so line
+// breaks and indentation of the page never make a quote look made up.
+func normalizeQuote(s string) string {
+	return strings.Trim(strings.Join(strings.Fields(s), " "), `"'“”「」`)
+}
+
+// quoted reports whether quote appears in source, which must already be normalized.
+func quoted(quote, source string) bool {
+	quote = normalizeQuote(quote)
+	return quote != "" && strings.Contains(source, quote)
+}
+
+// missingQuotes returns the quotes of text that do not appear in source.
+func missingQuotes(text, source string) []string {
+	var missing []string
+	for _, line := range strings.Split(text, "\n") {
+		if quote, ok := quoteOf(line); ok && !quoted(quote, source) {
+			missing = append(missing, quote)
+		}
+	}
+	return missing
+}
+
+// dropUnquoted removes from text every claim with a quote that does not appear in source, together with all
+// of its quotes, and returns how many claims it removed. A claim is the line its quotes follow.
+func dropUnquoted(text, source string) (string, int) {
+	var out []string
+	claim, dropping, dropped := -1, false, 0
+	for _, line := range strings.Split(text, "\n") {
+		quote, ok := quoteOf(line)
+		switch {
+		case !ok:
+			out = append(out, line)
+			dropping = false
+			if strings.TrimSpace(line) != "" {
+				claim = len(out) - 1
+			}
+		case dropping:
+		case quoted(quote, source):
+			out = append(out, line)
+		default:
+			dropped++
+			dropping = true
+			if claim >= 0 {
+				out = out[:claim]
+				claim = -1
+			}
+		}
+	}
+	return strings.Join(out, "\n"), dropped
+}
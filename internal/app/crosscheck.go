@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+const (
+	// crossCheckMaxClaims caps the claims checked, keeping the answer readable in Slack.
+	crossCheckMaxClaims = 8
+	// crossCheckSourceBytes caps the text of the article and of each reference sent to the model.
+	crossCheckSourceBytes = 40000
+)
+
+// verdictEmoji marks the verdicts of a cross-check, in the order claims are listed: contradictions first.
+var verdictEmoji = []struct{ verdict, emoji, label string }{
+	{"contradicts", ":x:", "contradicted"},
+	{"agrees", ":white_check_mark:", "agreed"},
+	{"unmentioned", ":grey_question:", "not mentioned"},
+}
+
+// claimCheck is the verdict of the reference sources on one claim.
+type claimCheck struct {
+	Verdict string `json:"verdict"`
+	Sources []int  `json:"sources"`
+	Note    string `json:"note"`
+}
+
+// ProcessCrossCheck extracts the key claims of the article at the first URL and checks each against the pages
+// at the other URLs, reporting which ones they agree with, contradict or don't mention. References that
+// cannot be read are listed as such; the check fails if the article or all references cannot be read.
+func (a *App) ProcessCrossCheck(ctx context.Context, urls []string, progressCallback ProgressCallback) (string, error) {
+	if len(urls) < 2 {
+		return "", errors.New("a cross-check needs the article and at least one other URL to check it against")
+	}
+	model, err := a.llmFor(urls...)
+	if err != nil {
+		return "", err
+	}
+
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Fetching the article: %s", urls[0]))
+	}
+	article, err := a.fetchPage(ctx, urls[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the article: %w", err)
+	}
+	if a.enabled(ctx, feature.ContentLanguage) {
+		ctx = matchLanguage(ctx, article, "")
+	}
+
+	if progressCallback != nil {
+		progressCallback(":loading: Extracting the key claims...")
+	}
+	var extracted struct {
+		Claims []string `json:"claims"`
+	}
+	validate := func() error {
+		if len(extracted.Claims) == 0 || len(extracted.Claims) > crossCheckMaxClaims {
+			return fmt.Errorf(`"claims" must have 1 to %d claims, got %d`, crossCheckMaxClaims, len(extracted.Claims))
+		}
+		for i, c := range extracted.Claims {
+			if strings.TrimSpace(c) == "" {
+				return fmt.Errorf("claim %d is empty", i+1)
+			}
+		}
+		return nil
+	}
+	text := truncateBytes(article.Text, crossCheckSourceBytes)
+	messages := localize(ctx, llm.BuildClaimsMessages(text, crossCheckMaxClaims))
+	if _, err := a.callJSON(ctx, model, messages, llm.Options{}, &extracted, validate); err != nil {
+		return "", fmt.Errorf("failed to extract claims: %w", err)
+	}
+	claims := extracted.Claims
+
+	references := urls[1:]
+	var sources []briefSource
+	var content strings.Builder
+	readable := map[int]bool{}
+	for i, url := range references {
+		if progressCallback != nil {
+			progressCallback(fmt.Sprintf(":loading: Fetching source %d/%d: %s", i+1, len(references), url))
+		}
+		page, err := a.fetchPage(ctx, url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			reqmeta.Logf(ctx, "[App] Leaving %s out of the cross-check: %v", url, err)
+			sources = append(sources, briefSource{name: url, err: err})
+			continue
+		}
+		sources = append(sources, briefSource{name: url})
+		readable[len(sources)] = true
+		text := truncateBytes(page.Text, crossCheckSourceBytes)
+		fmt.Fprintf(&content, "Source [%d]: %s\n%s\n\n", len(sources), url, text)
+	}
+	if len(readable) == 0 {
+		return "", fmt.Errorf("none of the sources to check against could be read: %w", sources[0].err)
+	}
+
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Checking %d claim(s) against %d source(s)...", len(claims), len(readable)))
+	}
+	var checked struct {
+		Checks []claimCheck `json:"checks"`
+	}
+	validate = func() error {
+		if len(checked.Checks) != len(claims) {
+			return fmt.Errorf(`"checks" must have one object per claim (%d), got %d`, len(claims), len(checked.Checks))
+		}
+		for i, c := range checked.Checks {
+			if verdictOf(c.Verdict) < 0 {
+				return fmt.Errorf(`"verdict" of check %d must be "agrees", "contradicts" or "unmentioned", got %q`, i+1, c.Verdict)
+			}
+			for _, n := range c.Sources {
+				if !readable[n] {
+					return fmt.Errorf(`"sources" of check %d cites %d, which is not a provided source`, i+1, n)
+				}
+			}
+		}
+		return nil
+	}
+	messages = localize(ctx, llm.BuildCrossCheckMessages(claims, strings.TrimSpace(content.String())))
+	if _, err := a.callJSON(ctx, model, messages, llm.Options{}, &checked, validate); err != nil {
+		return "", fmt.Errorf("failed to cross-check claims: %w", err)
+	}
+	return formatCrossCheck(urls[0], claims, checked.Checks) + briefSourcesFooter(sources), nil
+}
+
+// verdictOf returns the index of verdict in verdictEmoji, or -1 if it is not a verdict.
+func verdictOf(verdict string) int {
+	for i, v := range verdictEmoji {
+		if v.verdict == verdict {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatCrossCheck renders the checks of claims of the article at url in the order of verdictEmoji, with a
+// count of each verdict.
+func formatCrossCheck(url string, claims []string, checks []claimCheck) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Cross-check of %s*\n", url)
+	counts := make([]int, len(verdictEmoji))
+	for order := range verdictEmoji {
+		for i, c := range checks {
+			if verdictOf(c.Verdict) != order {
+				continue
+			}
+			counts[order]++
+			fmt.Fprintf(&b, "\n%s %s", verdictEmoji[order].emoji, claims[i])
+			if c.Note != "" {
+				fmt.Fprintf(&b, "\n      _%s_", c.Note)
+			}
+			for _, n := range c.Sources {
+				fmt.Fprintf(&b, " [%d]", n)
+			}
+		}
+	}
+	parts := make([]string, len(verdictEmoji))
+	for i, v := range verdictEmoji {
+		parts[i] = fmt.Sprintf("%d %s", counts[i], v.label)
+	}
+	fmt.Fprintf(&b, "\n\n%d claim(s): %s", len(claims), strings.Join(parts, ", "))
+	return b.String()
+}
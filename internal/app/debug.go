@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// Trace is a step-by-step account of summarizing one page, for troubleshooting.
+// Fields are filled in as far as the request got before failing.
+type Trace struct {
+	Request  fetcher.FetchRequest
+	Fetcher  string // Concrete type of the fetcher that served the request
+	Route    string // "default", "local" when the domain policy kept the page on the local model, or "short" / "long" when its length picked the model
+	Stages   []TraceStage
+	Page     *fetcher.FetchResult
+	Mode     string        // Prompt mode chosen for the page (see llm.BuildMessages)
+	Messages []llm.Message // Prompt sent for the summary, including the operator prefix
+	Response *llm.Response // Summary as returned by the model, with the model that served it and the token usage
+}
+
+// TraceStage is the outcome of one stage of a traced request.
+type TraceStage struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// ContentStats describes the extracted text of the page.
+func (t *Trace) ContentStats() string {
+	if t.Page == nil {
+		return ""
+	}
+	text := t.Page.Text
+	return fmt.Sprintf("%d bytes, %d characters, %d words, %d lines",
+		len(text), utf8.RuneCountInString(text), len(strings.Fields(text)), strings.Count(text, "\n")+1)
+}
+
+// Debug summarizes req like Summarize and records each step, without touching the history, metrics or hooks.
+// The trace is returned even if a stage fails.
+func (a *App) Debug(ctx context.Context, req fetcher.FetchRequest, userPrompt string) (*Trace, error) {
+	t := &Trace{Request: req, Fetcher: fmt.Sprintf("%T", a.fetcher), Route: "default"}
+	stage := func(name string, start time.Time, err error) error {
+		t.Stages = append(t.Stages, TraceStage{Name: name, Duration: time.Since(start), Err: err})
+		return err
+	}
+
+	start := time.Now()
+	model, err := a.llmFor(req.URL)
+	if err := stage("policy", start, err); err != nil {
+		return t, err
+	}
+	if model != a.llm {
+		t.Route = "local"
+	}
+
+	start = time.Now()
+	t.Page, err = a.fetcher.Fetch(ctx, req)
+	if err := stage("fetch", start, err); err != nil {
+		return t, err
+	}
+	if t.Page.Text == "" {
+		return t, fetcher.NewPageError(req.URL, t.Page.Diagnostics)
+	}
+
+	if a.enabled(ctx, feature.ContentLanguage) {
+		ctx = matchLanguage(ctx, t.Page, userPrompt)
+	}
+	model, route := a.routeByLength(ctx, model, t.Page.Text)
+	if route != "" {
+		t.Route = route
+	}
+	t.Mode = llm.ModeSummary
+	content := t.Page.Text
+	if structuredFrom(ctx) {
+		t.Mode = llm.ModeStructured
+	} else if a.enabled(ctx, feature.Citations) {
+		t.Mode = llm.ModeCited
+	} else if mode := styleMode(ctx); mode != "" {
+		t.Mode = mode
+	} else if t.Page.Video != nil && len(t.Page.Video.Chapters) > 0 {
+		t.Mode = llm.ModeVideo
+		content = videoContent(t.Page.Video, t.Page.Text)
+	} else if t.Page.Meeting != nil {
+		t.Mode = llm.ModeMeeting
+		content = meetingContent(t.Page.Meeting, t.Page.Text)
+	} else if mode := pageModeFor(pageURL(t.Page, req.URL)); mode != "" {
+		t.Mode = mode
+	} else if a.enabled(ctx, feature.StructuredOutput) {
+		t.Mode = llm.ModeStructured
+	}
+	a.mu.RLock()
+	prefix := a.persona.Prefix()
+	a.mu.RUnlock()
+	t.Messages = llm.WithSystemPrefix(localize(ctx, llm.BuildMessages(t.Mode, content, userPrompt)), prefix)
+
+	start = time.Now()
+	switch t.Mode {
+	case llm.ModeVideo:
+		t.Response, err = a.summarizeVideo(ctx, model, req.URL, t.Page.Video, t.Page.Text, userPrompt)
+	case llm.ModeMeeting:
+		t.Response, err = a.summarizeMeeting(ctx, model, t.Page.Meeting, t.Page.Text, userPrompt)
+	case llm.ModeStructured:
+		t.Response, _, err = a.summarizeStructured(ctx, model, t.Page.Text, userPrompt)
+	case llm.ModeCited:
+		t.Response, err = a.summarizeCited(ctx, model, t.Page.Text, userPrompt)
+	case llm.ModeSummary, styleMode(ctx):
+		t.Response, err = a.summarize(ctx, model, t.Page.Text, userPrompt)
+	default:
+		t.Response, err = a.summarizeAs(ctx, model, t.Mode, t.Page.Text, userPrompt)
+	}
+	return t, stage("llm", start, err)
+}
@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/feed"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// DigestFeed is a feed with the episodes going into a digest.
+type DigestFeed struct {
+	Title    string          `json:"title"`
+	Episodes []DigestEpisode `json:"episodes"`
+}
+
+// DigestEpisode is an episode of a digest. RequestID identifies its summary and is empty for
+// episodes without show notes, which are listed without a summary.
+type DigestEpisode struct {
+	Title     string    `json:"title"`
+	Link      string    `json:"link,omitempty"`
+	Published time.Time `json:"published"`
+	RequestID string    `json:"request_id,omitempty"`
+
+	content string // Prompt content, only needed until the summary is requested
+}
+
+// PendingDigest is a podcast digest submitted as a batch, kept (e.g. in a state file) until its results are collected.
+type PendingDigest struct {
+	BatchID string       `json:"batch_id"`
+	Since   time.Time    `json:"since"`
+	Until   time.Time    `json:"until"`
+	Feeds   []DigestFeed `json:"feeds"`
+}
+
+// PodcastDigest summarizes the show notes of episodes published since the given time into a single rollup.
+// It returns an empty string if no feed has new episodes. Episodes that fail to summarize are listed without a summary.
+func (a *App) PodcastDigest(ctx context.Context, feeds []*feed.Feed, since time.Time) (string, error) {
+	digest := a.screenDigest(ctx, digestFeeds(feeds, since))
+	summaries := make(map[string]string)
+	for _, f := range digest {
+		for _, ep := range f.Episodes {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			if ep.RequestID == "" {
+				continue
+			}
+			resp, err := a.generate(ctx, a.llm, llm.BuildMessages(llm.ModeDigest, ep.content, ""), llm.Options{})
+			if err != nil {
+				log.Printf("[App] Failed to summarize episode %q of %s: %v", ep.Title, f.Title, err)
+				continue
+			}
+			summaries[ep.RequestID] = resp.Text
+		}
+	}
+	return renderDigest(digest, since, time.Now(), summaries), nil
+}
+
+// SubmitPodcastDigest submits the summaries of a podcast digest as a batch, for about half the price of
+// PodcastDigest, and returns it for CollectPodcastDigest. It returns nil if no feed has new episodes.
+func (a *App) SubmitPodcastDigest(ctx context.Context, feeds []*feed.Feed, since time.Time) (*PendingDigest, error) {
+	batcher, ok := a.llm.(llm.Batcher)
+	if !ok {
+		return nil, errors.New("the LLM provider does not support batches")
+	}
+	a.mu.RLock()
+	prefix := a.persona.Prefix()
+	a.mu.RUnlock()
+
+	digest := a.screenDigest(ctx, digestFeeds(feeds, since))
+	var requests []llm.BatchRequest
+	for _, f := range digest {
+		for _, ep := range f.Episodes {
+			if ep.RequestID != "" {
+				messages := llm.WithSystemPrefix(llm.BuildMessages(llm.ModeDigest, ep.content, ""), prefix)
+				requests = append(requests, llm.BatchRequest{ID: ep.RequestID, Messages: messages, Options: llm.Options{Params: a.params}})
+			}
+		}
+	}
+	if len(digest) == 0 {
+		return nil, nil
+	}
+	p := &PendingDigest{Since: since, Until: time.Now(), Feeds: digest}
+	if len(requests) == 0 {
+		return p, nil
+	}
+	id, err := batcher.SubmitBatch(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+	a.audit.RecordBatch(ctx, id, requests)
+	p.BatchID = id
+	return p, nil
+}
+
+// CollectPodcastDigest returns the digest of p once its batch has finished, or false while it runs.
+// Episodes whose summary failed are listed without a summary.
+func (a *App) CollectPodcastDigest(ctx context.Context, p *PendingDigest) (string, bool, error) {
+	summaries := make(map[string]string)
+	if p.BatchID != "" {
+		batcher, ok := a.llm.(llm.Batcher)
+		if !ok {
+			return "", false, errors.New("the LLM provider does not support batches")
+		}
+		results, done, err := batcher.BatchResults(ctx, p.BatchID)
+		if err != nil || !done {
+			return "", done, err
+		}
+		a.audit.RecordBatchResults(ctx, p.BatchID, results)
+		for id, r := range results {
+			if r.Err != nil {
+				log.Printf("[App] Failed to summarize episode %s of batch %s: %v", id, p.BatchID, r.Err)
+				continue
+			}
+			summaries[id] = r.Response.Text
+		}
+	}
+	return renderDigest(p.Feeds, p.Since, p.Until, summaries), true, nil
+}
+
+// digestFeeds collects the episodes of feeds published since the given time, leaving out feeds without any.
+func digestFeeds(feeds []*feed.Feed, since time.Time) []DigestFeed {
+	var digest []DigestFeed
+	for i, f := range feeds {
+		items := f.Since(since)
+		if len(items) == 0 {
+			continue
+		}
+		df := DigestFeed{Title: f.Title}
+		for j, it := range items {
+			ep := DigestEpisode{Title: it.Title, Link: it.Link, Published: it.Published}
+			if ep.Link == "" {
+				ep.Link = it.EnclosureURL
+			}
+			if it.Description != "" {
+				ep.RequestID = fmt.Sprintf("feed%d-episode%d", i, j)
+				ep.content = fmt.Sprintf("Podcast: %s\nEpisode: %s\n\nShow notes:\n%s", f.Title, it.Title, it.Description)
+			}
+			df.Episodes = append(df.Episodes, ep)
+		}
+		digest = append(digest, df)
+	}
+	return digest
+}
+
+// screenDigest leaves the episodes whose show notes are not about the screening topics out of digest,
+// and the feeds left without episodes. Episodes without show notes cannot be screened and stay.
+func (a *App) screenDigest(ctx context.Context, digest []DigestFeed) []DigestFeed {
+	if len(a.screeningTopics) == 0 {
+		return digest
+	}
+	var screened []DigestFeed
+	for _, f := range digest {
+		var episodes []DigestEpisode
+		for _, ep := range f.Episodes {
+			if ep.RequestID == "" || a.screen(ctx, "podcast digest", ep.Link, ep.content) {
+				episodes = append(episodes, ep)
+			}
+		}
+		if len(episodes) > 0 {
+			f.Episodes = episodes
+			screened = append(screened, f)
+		}
+	}
+	return screened
+}
+
+// renderDigest formats the digest of the episodes published between since and until, with their summaries
+// by request ID. It returns an empty string if there are no episodes.
+func renderDigest(digest []DigestFeed, since, until time.Time, summaries map[string]string) string {
+	var b strings.Builder
+	episodes := 0
+	for _, f := range digest {
+		fmt.Fprintf(&b, "\n*%s*\n", f.Title)
+		for _, ep := range f.Episodes {
+			episodes++
+			if ep.Link != "" {
+				fmt.Fprintf(&b, "• <%s|%s> (%s)\n", ep.Link, ep.Title, ep.Published.Format("01/02"))
+			} else {
+				fmt.Fprintf(&b, "• %s (%s)\n", ep.Title, ep.Published.Format("01/02"))
+			}
+			for _, line := range strings.Split(summaries[ep.RequestID], "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					fmt.Fprintf(&b, "    %s\n", line)
+				}
+			}
+		}
+	}
+	if episodes == 0 {
+		return ""
+	}
+
+	header := fmt.Sprintf(":studio_microphone: *Podcast digest* (%s 〜 %s, %d episodes)\n",
+		since.Format("2006-01-02"), until.Format("2006-01-02"), episodes)
+	return header + b.String()
+}
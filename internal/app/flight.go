@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"golang.org/x/sync/singleflight"
+)
+
+// flights lets concurrent requests for the same page share one fetch and LLM call, which matters
+// when a page is posted in several channels at once, e.g. on product launch days.
+type flights struct {
+	group singleflight.Group
+
+	mu      sync.Mutex
+	waiting map[string]*flightWaiters
+}
+
+// flightWaiters counts the requests waiting for a shared summary. The summary runs with its own
+// context, cancelled only once every waiting request gave up, so that one user cancelling or timing
+// out doesn't fail the others.
+type flightWaiters struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	n        int
+	next     int                      // ID of the next request joining
+	progress map[int]ProgressCallback // Progress callbacks of the waiting requests, by ID
+}
+
+// summaryFlags are the feature flags that change a page summary or how its progress is shown, so
+// requests from channels that differ in them never share one.
+var summaryFlags = []string{feature.VisionFallback, feature.RelatedLinks, feature.SourceType, feature.NumbersTable, feature.ContentLanguage, feature.StructuredOutput, feature.Citations, feature.Streaming}
+
+// flightKey identifies requests that can share a summary, whichever channel or user they come from:
+// same page, question, output languages, format, style, local-only routing and summary feature flags.
+// Requests with their own HTML, needing a screenshot or streaming to their own sink are never shared.
+func (a *App) flightKey(ctx context.Context, req fetcher.FetchRequest, userPrompt string) string {
+	if req.HTML != "" || req.Screenshot || len(req.Headers) > 0 || streamFrom(ctx) != nil {
+		return ""
+	}
+	languages, _ := ctx.Value(languagesKey{}).([]string)
+	key := strings.Join([]string{req.URL, userPrompt, strings.Join(languages, ",")}, "\x00")
+	if structuredFrom(ctx) {
+		key += "\x00structured"
+	}
+	if mode := styleMode(ctx); mode != "" {
+		key += "\x00" + mode
+	}
+	a.mu.RLock()
+	local := a.policy.RequiresLocal(req.URL)
+	a.mu.RUnlock()
+	if local {
+		key += "\x00local"
+	}
+	for _, name := range summaryFlags {
+		if a.enabled(ctx, name) {
+			key += "\x00" + name
+		}
+	}
+	return key
+}
+
+// sharedSummarize runs fetchAndSummarize for req, or waits for an identical one already running.
+// The fetch and LLM usage is accounted to the request that started it, while each request records its
+// own history entry and runs the summary hooks (see summarizeRequest). Progress is reported to every
+// request still waiting.
+func (a *App) sharedSummarize(ctx context.Context, req fetcher.FetchRequest, userPrompt string, progressCallback ProgressCallback) (*Result, error) {
+	key := a.flightKey(ctx, req, userPrompt)
+	if key == "" {
+		return a.fetchAndSummarize(ctx, req, userPrompt, progressCallback)
+	}
+
+	f := &a.flights
+	f.mu.Lock()
+	if f.waiting == nil {
+		f.waiting = make(map[string]*flightWaiters)
+	}
+	w := f.waiting[key]
+	if w == nil {
+		// Keep the request's values (budget scope, languages, requester) but not its cancellation
+		shared, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		w = &flightWaiters{ctx: shared, cancel: cancel, progress: make(map[int]ProgressCallback)}
+		f.waiting[key] = w
+	}
+	w.n++
+	id := w.next
+	w.next++
+	joined := w.n > 1
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(w.progress, id)
+		w.n--
+		if w.n == 0 {
+			w.cancel()
+			if f.waiting[key] == w {
+				delete(f.waiting, key)
+			}
+		}
+	}()
+
+	if progressCallback != nil {
+		if joined {
+			progressCallback(":loading: This page is already being summarized for another request, sharing the result...")
+		}
+		f.mu.Lock()
+		w.progress[id] = func(message string) {
+			if ctx.Err() == nil {
+				progressCallback(message)
+			}
+		}
+		f.mu.Unlock()
+	}
+	progress := func(message string) {
+		f.mu.Lock()
+		callbacks := make([]ProgressCallback, 0, len(w.progress))
+		for _, cb := range w.progress {
+			callbacks = append(callbacks, cb)
+		}
+		f.mu.Unlock()
+		for _, cb := range callbacks {
+			cb(message)
+		}
+	}
+
+	ch := f.group.DoChan(key, func() (any, error) {
+		return a.fetchAndSummarize(w.ctx, req, userPrompt, progress)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		// Every request gets its own copy, since callers set e.g. the history ID
+		result := *r.Val.(*Result)
+		return &result, nil
+	}
+}
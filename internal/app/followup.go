@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+const (
+	// followUpMaxQuestions caps the suggested follow-up questions.
+	followUpMaxQuestions = 3
+	// followUpMaxRunes caps the length of a question, so it fits on a button.
+	followUpMaxRunes = 75
+	// followUpContentBytes caps the page text sent to the model; the questions only need its gist.
+	followUpContentBytes = 20000
+)
+
+// FollowUpQuestions suggests questions to dig deeper into the page summarized in r, or returns none if the
+// follow-ups feature is off for the request's channel.
+func (a *App) FollowUpQuestions(ctx context.Context, r *Result) ([]string, error) {
+	if !a.enabled(ctx, feature.FollowUps) {
+		return nil, nil
+	}
+	model, err := a.llmFor(r.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggested struct {
+		Questions []string `json:"questions"`
+	}
+	validate := func() error {
+		if len(suggested.Questions) < 2 || len(suggested.Questions) > followUpMaxQuestions {
+			return fmt.Errorf(`"questions" must have 2 to %d questions, got %d`, followUpMaxQuestions, len(suggested.Questions))
+		}
+		for i, q := range suggested.Questions {
+			if n := len([]rune(strings.TrimSpace(q))); n == 0 || n > followUpMaxRunes {
+				return fmt.Errorf("question %d must have 1 to %d characters, got %d", i+1, followUpMaxRunes, n)
+			}
+		}
+		return nil
+	}
+	content := truncateBytes(r.Content, followUpContentBytes)
+	messages := localize(ctx, llm.BuildFollowUpMessages(content, r.Summary, followUpMaxQuestions))
+	if _, err := a.callJSON(ctx, model, messages, llm.Options{}, &suggested, validate); err != nil {
+		return nil, fmt.Errorf("failed to suggest follow-up questions: %w", err)
+	}
+	questions := make([]string, len(suggested.Questions))
+	for i, q := range suggested.Questions {
+		questions[i] = strings.TrimSpace(q)
+	}
+	return questions, nil
+}
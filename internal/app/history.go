@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/cost"
+	"github.com/kznrluk/describe-kun/internal/history"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// SetHistory records every page summary, failed ones included, in s.
+func (a *App) SetHistory(s *history.Store) {
+	a.history = s
+}
+
+// SetHistoryContent also records the extracted page text, so that replays can skip the fetch.
+func (a *App) SetHistoryContent(enabled bool) {
+	a.historyContent = enabled
+}
+
+// recordHistory appends the outcome of a page summary to the history and returns the entry ID, or "" if not recorded.
+func (a *App) recordHistory(ctx context.Context, url, userPrompt string, start time.Time, result *Result, err error) string {
+	if a.history == nil {
+		return ""
+	}
+	meta := reqmeta.From(ctx)
+	entry := history.Entry{
+		Time:       start,
+		RequestID:  meta.RequestID,
+		Source:     meta.Source,
+		Trigger:    meta.Trigger,
+		Workspace:  meta.Workspace,
+		Channel:    meta.Channel,
+		User:       meta.User,
+		URL:        url,
+		Prompt:     userPrompt,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Model = result.Model
+		entry.Tokens = result.Usage.TotalTokens
+		entry.CostUSD = cost.Estimate(result.Model, result.Usage)
+		entry.Summary = result.Summary
+		entry.Provenance = result.Provenance
+		if a.historyContent {
+			entry.Content = result.Content
+		}
+	}
+
+	id, appendErr := a.history.Append(entry)
+	if appendErr != nil {
+		reqmeta.Logf(ctx, "[App] Failed to record history for %s: %v", url, appendErr)
+		return ""
+	}
+	return id
+}
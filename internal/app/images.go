@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// Image is an image to summarize, such as a screenshot attached to a Slack message.
+type Image struct {
+	Name     string
+	Data     []byte
+	MIMEType string
+}
+
+// SetVisionModel sets the model used for images. Empty uses the LLM's default model, which must then support images.
+func (a *App) SetVisionModel(model string) {
+	a.visionModel = model
+}
+
+// ProcessImagesWithProgress summarizes images (screenshots of articles, slides, ...) with a vision-capable model.
+// If userPrompt is provided, it is answered first based on the images.
+func (a *App) ProcessImagesWithProgress(ctx context.Context, images []Image, userPrompt string, progressCallback ProgressCallback) (string, error) {
+	if len(images) == 0 {
+		return "", errors.New("no images to process")
+	}
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Reading %d image(s)...", len(images)))
+	}
+
+	parts := make([]llm.Part, 0, len(images))
+	for _, img := range images {
+		parts = append(parts, llm.ImagePart(img.Data, img.MIMEType))
+	}
+
+	resp, err := a.generate(ctx, a.llm, localize(ctx, llm.BuildImageMessages(userPrompt, parts)), llm.Options{Model: a.visionModel})
+	if err != nil {
+		return "", fmt.Errorf("failed to process images: %w", err)
+	}
+	return resp.Text, nil
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// SetJSONRepairAttempts sets how many times a malformed or invalid JSON reply is sent back to the model
+// for repair before giving up. Zero disables repairs.
+func (a *App) SetJSONRepairAttempts(n int) {
+	a.jsonRepairAttempts = n
+}
+
+// callJSON calls model for a JSON reply and decodes it into v, which must be a pointer; validate, if set,
+// then checks the decoded value against the expected template. A reply that does not decode or validate is
+// sent back with the problem for repair, so callers never see broken JSON. The returned response is the
+// accepted reply, with the usage of every attempt.
+func (a *App) callJSON(ctx context.Context, model llm.LLM, messages []llm.Message, opts llm.Options, v any, validate func() error) (*llm.Response, error) {
+	var usage llm.Usage
+	for attempt := 0; ; attempt++ {
+		resp, err := a.call(ctx, model, messages, opts)
+		if err != nil {
+			return nil, err
+		}
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.CompletionTokens += resp.Usage.CompletionTokens
+		usage.TotalTokens += resp.Usage.TotalTokens
+		err = decodeJSON(resp.Text, v)
+		if err == nil && validate != nil {
+			err = validate()
+		}
+		if err == nil {
+			resp.Usage = usage
+			return resp, nil
+		}
+		if attempt >= a.jsonRepairAttempts {
+			return nil, fmt.Errorf("invalid JSON reply after %d attempt(s): %w", attempt+1, err)
+		}
+		log.Printf("[App] Repairing JSON reply (%d/%d): %v", attempt+1, a.jsonRepairAttempts, err)
+		messages = append(append([]llm.Message{}, messages...),
+			llm.NewTextMessage(llm.RoleAssistant, resp.Text),
+			llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("Your reply is invalid: %v\n\nReply again with only the corrected JSON, in exactly the requested format.", err)),
+		)
+	}
+}
+
+// decodeJSON strictly decodes a JSON reply into v, tolerating a surrounding Markdown code fence.
+// Unknown keys and trailing data are errors, since they mean the model did not follow the template.
+func decodeJSON(text string, v any) error {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(strings.TrimPrefix(text, "```json"), "```")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+
+	// Start from the zero value so fields of a failed attempt do not leak into the next one
+	reflect.ValueOf(v).Elem().SetZero()
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("malformed JSON: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("malformed JSON: unexpected data after the JSON value")
+	}
+	return nil
+}
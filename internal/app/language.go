@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+type languagesKey struct{}
+
+// WithLanguages returns a context whose summaries are written in languages (codes such as "ja", "en").
+// With two or more, each summary has one section per language, generated in a single LLM call to control cost.
+func WithLanguages(ctx context.Context, languages []string) context.Context {
+	return context.WithValue(ctx, languagesKey{}, languages)
+}
+
+// localize applies the output languages requested through ctx to messages.
+func localize(ctx context.Context, messages []llm.Message) []llm.Message {
+	languages, _ := ctx.Value(languagesKey{}).([]string)
+	return llm.WithLanguages(messages, languages)
+}
+
+// matchLanguage returns ctx writing the summary of page in the language of the user's question or, without
+// one, of the page itself. Languages already requested through ctx, such as a channel's, take precedence.
+func matchLanguage(ctx context.Context, page *fetcher.FetchResult, userPrompt string) context.Context {
+	if languages, _ := ctx.Value(languagesKey{}).([]string); len(languages) > 0 {
+		return ctx
+	}
+	lang := detectLanguage(userPrompt)
+	if lang == "" {
+		lang = detectLanguage(page.Text)
+	}
+	if lang == "" && page.Metadata.Language != "" {
+		lang = pageLanguage(page.Metadata.Language, "")
+	}
+	if lang == "" {
+		return ctx
+	}
+	reqmeta.Logf(ctx, "[App] Writing the summary in %q, the language of the content", lang)
+	return WithLanguages(ctx, []string{lang})
+}
+
+// detectSample is how much of a text detectLanguage looks at.
+const detectSample = 5000
+
+// stopwords are frequent function words telling Latin-script languages apart.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with", "are", "this", "was", "on", "what", "how"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "du", "pour", "dans", "pas", "sur", "qui", "au"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "ein", "eine", "den", "zu", "auf", "für", "sich"},
+	"es": {"el", "los", "las", "y", "es", "una", "del", "por", "para", "con", "se", "como", "más", "lo"},
+}
+
+// detectLanguage guesses the language code of text ("ja", "ko", "zh", "en", "fr", "de" or "es") from its
+// scripts and, for Latin script, its function words. It returns "" when text gives too little to go on.
+func detectLanguage(text string) string {
+	if r := []rune(text); len(r) > detectSample {
+		text = string(r[:detectSample])
+	}
+	var letters, latin, han, kana, hangul int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	switch {
+	case letters == 0:
+		return ""
+	case kana*10 >= letters:
+		return "ja"
+	case hangul*10 >= letters:
+		return "ko"
+	case han*3 >= letters:
+		return "zh"
+	case latin*2 < letters:
+		return ""
+	}
+
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for lang, words := range stopwords {
+			for _, w := range words {
+				if word == w {
+					counts[lang]++
+				}
+			}
+		}
+	}
+	best := ""
+	for _, lang := range []string{"en", "fr", "de", "es"} {
+		if counts[lang] >= 2 && counts[lang] > counts[best] {
+			best = lang
+		}
+	}
+	return best
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// meetingContent formats meeting metadata for the prompt, followed by the transcript.
+func meetingContent(m *fetcher.Meeting, transcript string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Meeting: %s\n", m.Topic)
+	if m.StartTime != "" {
+		fmt.Fprintf(&b, "Started: %s\n", m.StartTime)
+	}
+	if m.DurationMinutes > 0 {
+		fmt.Fprintf(&b, "Duration: %d minutes\n", m.DurationMinutes)
+	}
+	fmt.Fprintf(&b, "\nTranscript:\n%s", transcript)
+	return b.String()
+}
+
+// summarizeMeeting turns a meeting transcript into notes with decisions, action items and their owners.
+func (a *App) summarizeMeeting(ctx context.Context, model llm.LLM, m *fetcher.Meeting, transcript string, userPrompt string) (*llm.Response, error) {
+	resp, err := a.generateStream(ctx, model, localize(ctx, llm.BuildMessages(llm.ModeMeeting, meetingContent(m, transcript), userPrompt)), llm.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process content: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+const (
+	// numbersMinFigures is how many figures with a unit or currency a page needs to get a numbers table.
+	numbersMinFigures = 5
+	// numbersMaxRows is how many rows the numbers table has at most.
+	numbersMaxRows = 8
+	// numbersMaxBytes is how much of a page the numbers are extracted from.
+	numbersMaxBytes = 30000
+	// numbersCellWidth is the width cells are cut to so the table fits a Slack message.
+	numbersCellWidth = 28
+)
+
+// figureRegex matches figures that carry a unit or currency, as found on benchmark, pricing and statistics pages.
+var figureRegex = regexp.MustCompile(`[$€£¥]\s?\d|\d(?:[\d,.]*\d)?\s?(?:%|x\b|×|ms\b|µs|ns\b|GB\b|MB\b|KB\b|TB\b|円|ドル|件|倍|USD\b|EUR\b|JPY\b)`)
+
+// numericRow is one key figure of a page.
+type numericRow struct {
+	Metric  string `json:"metric"`
+	Value   string `json:"value"`
+	Context string `json:"context"`
+	Quote   string `json:"quote"`
+}
+
+// looksNumeric reports whether content is full enough of figures to deserve a numbers table.
+func looksNumeric(content string) bool {
+	return len(figureRegex.FindAllStringIndex(content, numbersMinFigures)) >= numbersMinFigures
+}
+
+// numbersTable returns a table of the key figures of content, each backed by a quote from it, since
+// free-form summaries tend to mangle numbers. Rows whose quote is not in content or does not contain
+// the value are dropped. It returns "" for pages without many figures or if extraction fails.
+func (a *App) numbersTable(ctx context.Context, model llm.LLM, content string) string {
+	if !looksNumeric(content) {
+		return ""
+	}
+	content = truncateBytes(content, numbersMaxBytes)
+
+	messages := []llm.Message{
+		llm.NewTextMessage(llm.RoleSystem, fmt.Sprintf(`You extract the key figures (benchmark results, prices, statistics) from a web page. Reply with only a JSON array of at most %d objects with the keys "metric" (what is measured), "value" (the figure with its unit, exactly as written), "context" (conditions such as plan, hardware or date, or "") and "quote" (the exact sentence of the page containing the value, copied verbatim). Reply [] if there are no key figures.`, numbersMaxRows)),
+		llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("Content (may be truncated):\n```\n%s\n```", content)),
+	}
+	var rows []numericRow
+	_, err := a.callJSON(ctx, model, messages, llm.Options{}, &rows, func() error {
+		for i, row := range rows {
+			if row.Metric == "" || row.Value == "" || row.Quote == "" {
+				return fmt.Errorf(`object %d needs non-empty "metric", "value" and "quote"`, i+1)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[App] Extracting numbers failed: %v", err)
+		return ""
+	}
+
+	normalized := strings.Join(strings.Fields(content), " ")
+	var kept []numericRow
+	for _, row := range rows {
+		quote := strings.Join(strings.Fields(row.Quote), " ")
+		if row.Value == "" || quote == "" || !strings.Contains(normalized, quote) || !strings.Contains(quote, row.Value) {
+			continue
+		}
+		row.Quote = quote
+		kept = append(kept, row)
+		if len(kept) == numbersMaxRows {
+			break
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return formatNumbers(kept)
+}
+
+// formatNumbers renders rows as an aligned table in a code block, followed by the quotes backing them.
+func formatNumbers(rows []numericRow) string {
+	cells := [][]string{{"#", "Metric", "Value", "Context"}}
+	for i, row := range rows {
+		cells = append(cells, []string{fmt.Sprint(i + 1), cut(row.Metric), cut(row.Value), cut(row.Context)})
+	}
+	widths := make([]int, len(cells[0]))
+	for _, line := range cells {
+		for i, cell := range line {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n:bar_chart: *Key numbers*\n```\n")
+	for _, line := range cells {
+		for i, cell := range line {
+			if i == len(line)-1 {
+				b.WriteString(cell)
+			} else {
+				b.WriteString(cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("```")
+	for i, row := range rows {
+		fmt.Fprintf(&b, "\n%d. _“%s”_", i+1, row.Quote)
+	}
+	return b.String()
+}
+
+// cut shortens a table cell to numbersCellWidth runes.
+func cut(s string) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= numbersCellWidth {
+		return s
+	}
+	return string([]rune(s)[:numbersCellWidth-1]) + "…"
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/output"
+)
+
+// SetOutputFilters sets the post-processing applied to page summaries. It is safe to call while requests are running.
+func (a *App) SetOutputFilters(c output.Chain) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.outputFilters = c
+}
+
+// filterOutput runs resp through the output filters. If it breaks a rule, the model is asked once to
+// correct it; should the correction break a rule too, the filters' best effort is kept.
+func (a *App) filterOutput(ctx context.Context, model llm.LLM, messages []llm.Message, opts llm.Options, resp *llm.Response) *llm.Response {
+	a.mu.RLock()
+	filters := a.outputFilters
+	a.mu.RUnlock()
+
+	text, violations := filters.Apply(resp.Text)
+	if len(violations) == 0 {
+		return withText(resp, text)
+	}
+
+	problems := make([]string, len(violations))
+	for i, v := range violations {
+		problems[i] = "- " + v.Problem
+	}
+	corrective := append(append([]llm.Message{}, messages...),
+		llm.Message{Role: llm.RoleAssistant, Parts: []llm.Part{llm.TextPart(resp.Text)}},
+		llm.Message{Role: llm.RoleUser, Parts: []llm.Part{llm.TextPart(
+			"Your response does not meet these requirements:\n" + strings.Join(problems, "\n") +
+				"\n\nRewrite the complete response so that it does. Reply with the corrected response only.")}},
+	)
+	corrected, err := a.generate(ctx, model, corrective, opts)
+	if err != nil {
+		log.Printf("[Output] Keeping the filtered response, correction failed: %v", err)
+		return withText(resp, text)
+	}
+	corrected.Usage = addUsage(resp.Usage, corrected.Usage)
+	correctedText, violations := filters.Apply(corrected.Text)
+	for _, v := range violations {
+		log.Printf("[Output] Corrected response still breaks %s", v)
+	}
+	return withText(corrected, correctedText)
+}
+
+// withText returns a copy of resp with its text replaced.
+func withText(resp *llm.Response, text string) *llm.Response {
+	r := *resp
+	r.Text = text
+	return &r
+}
+
+// addUsage sums the tokens of two calls.
+func addUsage(a, b llm.Usage) llm.Usage {
+	return llm.Usage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+	}
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/provenance"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// packContentBytes caps the extracted text of each page in a context pack, so packs of several pages still
+// fit the context of the tools they are pasted into.
+const packContentBytes = 60000
+
+// ContextPack bundles the extracted content and summary of pages into one document to paste into other AI
+// tools.
+type ContextPack struct {
+	Created time.Time  `json:"created"`
+	Pages   []PackPage `json:"pages"`
+}
+
+// PackPage is a page of a context pack, with why it could not be read if it could not.
+type PackPage struct {
+	URL       string `json:"url"`
+	Title     string `json:"title,omitempty"`
+	Summary   string `json:"summary,omitempty"`
+	Content   string `json:"content,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"` // Content was cut at packContentBytes
+	Error     string `json:"error,omitempty"`
+
+	Provenance *provenance.Provenance `json:"provenance,omitempty"` // How Summary was made from the whole page
+}
+
+// ProcessContextPack summarizes every URL and bundles each page's summary with its extracted text. Pages
+// that cannot be read are listed as such; the pack fails only if none can be read.
+func (a *App) ProcessContextPack(ctx context.Context, urls []string, progressCallback ProgressCallback) (*ContextPack, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no pages to pack")
+	}
+	pack := &ContextPack{Created: time.Now()}
+	read := 0
+	for i, url := range urls {
+		if progressCallback != nil {
+			progressCallback(fmt.Sprintf(":loading: Packing page %d/%d: %s", i+1, len(urls), url))
+		}
+		result, err := a.summarizeRequest(ctx, fetcher.FetchRequest{URL: url}, "", nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			reqmeta.Logf(ctx, "[App] Leaving %s out of the context pack: %v", url, err)
+			pack.Pages = append(pack.Pages, PackPage{URL: url, Error: err.Error()})
+			continue
+		}
+		page := PackPage{URL: url, Title: result.Metadata.Title, Summary: result.Summary, Content: result.Content, Provenance: result.Provenance}
+		if len(page.Content) > packContentBytes {
+			page.Content = truncateBytes(page.Content, packContentBytes)
+			page.Truncated = true
+		}
+		pack.Pages = append(pack.Pages, page)
+		read++
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("none of the pages could be read: %s", pack.Pages[0].Error)
+	}
+	return pack, nil
+}
+
+// Markdown renders the pack as a Markdown document: an index of the pages, then each page's summary and
+// extracted text.
+func (p *ContextPack) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Context pack\n\n")
+	fmt.Fprintf(&b, "Extracted text and summaries of %d web page(s), collected %s. Use it as background for the conversation.\n\n", len(p.Pages), p.Created.Format("2006-01-02 15:04 MST"))
+	for i, page := range p.Pages {
+		if page.Error != "" {
+			fmt.Fprintf(&b, "%d. %s (could not be read: %s)\n", i+1, page.URL, page.Error)
+		} else {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, page.Label())
+		}
+	}
+	for i, page := range p.Pages {
+		if page.Error != "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %d. %s\n\nSource: %s\n\n### Summary\n\n%s\n\n### Content\n\n", i+1, page.Label(), page.URL, strings.TrimSpace(page.Summary))
+		b.WriteString(strings.TrimSpace(page.Content))
+		if page.Truncated {
+			b.WriteString("\n\n[The rest of the page was cut to keep the pack short.]")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Label returns the title of the page, or its URL if it has none.
+func (p PackPage) Label() string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return p.URL
+}
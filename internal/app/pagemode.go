@@ -0,0 +1,221 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/persona"
+)
+
+// pageModes pick a specialized summary format for pages of known kinds by their URL, in order.
+// changesFocus, if set, tells watch mode what changes of such pages matter.
+var pageModes = []struct {
+	mode         string
+	match        func(u *url.URL) bool
+	changesFocus string
+}{
+	{llm.ModeProduct, isProductPage, ""},
+	{llm.ModeLegal, isLegalPage, llm.LegalChangesFocus},
+	{llm.ModeJob, isJobPage, ""},
+	{llm.ModeFiling, isFilingPage, ""},
+	{llm.ModeIncident, isIncidentPage, llm.IncidentChangesFocus},
+	{llm.ModeRecipe, isRecipePage, ""},
+}
+
+// recipePaths match the paths of single recipes and guides on recipe and how-to sites, by host.
+var recipePaths = map[string]*regexp.Regexp{
+	"cookpad.com":         regexp.MustCompile(`^(/[a-z]{2})?/recipes?/\d+`),
+	"delishkitchen.tv":    regexp.MustCompile(`^/recipes/\d+`),
+	"kurashiru.com":       regexp.MustCompile(`^/recipes/[0-9a-f-]+`),
+	"orangepage.net":      regexp.MustCompile(`^/recipes/detail_\d+`),
+	"allrecipes.com":      regexp.MustCompile(`^/recipe/\d+`),
+	"cooking.nytimes.com": regexp.MustCompile(`^/recipes/\d+`),
+	"bbcgoodfood.com":     regexp.MustCompile(`^/recipes/[^/]+$`),
+	"seriouseats.com":     regexp.MustCompile(`-recipe(-\d+)?$`),
+	"wikihow.com":         regexp.MustCompile(`^/[^/]+$`),
+	"*.wikihow.com":       regexp.MustCompile(`^/[^/]+$`),
+}
+
+// recipePathRegex matches recipe and how-to sections of other sites, such as food blogs.
+var recipePathRegex = regexp.MustCompile(`(?i)(^|/)(recipes?|how-?to)/[^/]+`)
+
+// statusHosts are status pages not under a "status." host.
+var statusHosts = map[string]bool{
+	"githubstatus.com":       true,
+	"health.aws.amazon.com":  true,
+	"azure.status.microsoft": true,
+}
+
+// incidentPathRegex matches path segments of incident reports and postmortems.
+var incidentPathRegex = regexp.MustCompile(`(?i)(^|/)(incidents?|post-?mortems?|incident[-_]reports?|rca|outages?)([-_./]|$)`)
+
+// filingHosts serve securities filings and timely disclosures only.
+var filingHosts = map[string]bool{
+	"sec.gov":                      true,
+	"disclosure.edinet-fsa.go.jp":  true,
+	"disclosure2.edinet-fsa.go.jp": true,
+	"release.tdnet.info":           true,
+}
+
+// filingPathRegex matches path segments of earnings releases and investor relations documents on other sites.
+var filingPathRegex = regexp.MustCompile(`(?i)(^|/)(ir|investors?|investor[-_]relations|earnings([-_]release)?|(quarterly|financial|annual)[-_]results|10-?[kq]|kessan|tanshin)([-_./]|$)`)
+
+// jobPaths match the paths of single postings on job boards and applicant tracking systems, by host.
+// Hosts starting with "*." match their subdomains.
+var jobPaths = map[string]*regexp.Regexp{
+	"boards.greenhouse.io":     regexp.MustCompile(`^/[^/]+/jobs/\d+`),
+	"job-boards.greenhouse.io": regexp.MustCompile(`^/[^/]+/jobs/\d+`),
+	"jobs.lever.co":            regexp.MustCompile(`^/[^/]+/[0-9a-f-]{36}`),
+	"jobs.ashbyhq.com":         regexp.MustCompile(`^/[^/]+/[0-9a-f-]{36}`),
+	"apply.workable.com":       regexp.MustCompile(`^/[^/]+/j/[0-9A-F]+`),
+	"jobs.smartrecruiters.com": regexp.MustCompile(`^/[^/]+/\d+`),
+	"linkedin.com":             regexp.MustCompile(`^/jobs/view/`),
+	"indeed.com":               regexp.MustCompile(`^/viewjob`),
+	"*.indeed.com":             regexp.MustCompile(`^/viewjob`),
+	"wantedly.com":             regexp.MustCompile(`^/projects/\d+`),
+	"herp.careers":             regexp.MustCompile(`^/v1/[^/]+/[^/]+`),
+	"hrmos.co":                 regexp.MustCompile(`^/pages/[^/]+/jobs/[^/]+`),
+	"open.talentio.com":        regexp.MustCompile(`^/r/1/c/[^/]+/pages/\d+`),
+	"green-japan.com":          regexp.MustCompile(`^/company/\d+/job/\d+`),
+	"*.recruitee.com":          regexp.MustCompile(`^/o/[^/]+`),
+}
+
+// legalPathRegex matches path segments of terms of service, privacy policies and similar documents.
+var legalPathRegex = regexp.MustCompile(`(?i)(^|/)(terms|terms[-_](of[-_](service|use)|and[-_]conditions)|tos|privacy|privacy[-_](policy|notice|statement)|legal|eula|dpa|cookie[-_]policy|kiyaku|policies)(\.[a-z]+)?(/|$)`)
+
+// pageModeFor returns the summary mode for the page at rawURL, or "" for the general summary.
+func pageModeFor(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	for _, m := range pageModes {
+		if m.match(u) {
+			return m.mode
+		}
+	}
+	return ""
+}
+
+// changesFocusFor returns what watch mode should focus on in changes of the page at rawURL, or "".
+func changesFocusFor(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	for _, m := range pageModes {
+		if m.match(u) {
+			return m.changesFocus
+		}
+	}
+	return ""
+}
+
+// SetJobProfile makes job posting summaries score the posting against the profile p; nil disables scoring.
+func (a *App) SetJobProfile(p *persona.Persona) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.jobProfile = p
+}
+
+// pageURL returns the URL page was loaded from after redirects, or the requested one if unknown.
+func pageURL(page *fetcher.FetchResult, requested string) string {
+	if page.FinalURL != "" {
+		return page.FinalURL
+	}
+	return requested
+}
+
+// isProductPage reports whether u is a Product Hunt product or launch, or an App Store or Google Play listing.
+func isProductPage(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	switch host {
+	case "producthunt.com":
+		return strings.HasPrefix(u.Path, "/products/") || strings.HasPrefix(u.Path, "/posts/")
+	case "apps.apple.com":
+		return strings.Contains(u.Path, "/app/")
+	case "play.google.com":
+		return strings.HasPrefix(u.Path, "/store/apps/details")
+	}
+	return false
+}
+
+// isLegalPage reports whether u looks like terms of service, a privacy policy or a similar legal document.
+func isLegalPage(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, prefix := range []string{"legal.", "policies.", "privacy."} {
+		if strings.HasPrefix(host, prefix) {
+			return true
+		}
+	}
+	return legalPathRegex.MatchString(u.Path)
+}
+
+// isJobPage reports whether u is a single posting on a known job board or applicant tracking system.
+func isJobPage(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path, ok := jobPaths[host]
+	if !ok {
+		if _, parent, found := strings.Cut(host, "."); found {
+			path, ok = jobPaths["*."+parent]
+		}
+	}
+	return ok && path.MatchString(u.Path)
+}
+
+// isFilingPage reports whether u is a securities filing, a timely disclosure or an investor relations page.
+func isFilingPage(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if filingHosts[host] {
+		return host != "sec.gov" || strings.HasPrefix(u.Path, "/Archives/edgar/data/") || strings.HasPrefix(u.Path, "/ix")
+	}
+	if strings.HasPrefix(host, "ir.") || strings.HasPrefix(host, "investors.") || strings.HasPrefix(host, "investor.") {
+		return true
+	}
+	return filingPathRegex.MatchString(u.Path)
+}
+
+// isIncidentPage reports whether u is a status page or an incident report or postmortem.
+func isIncidentPage(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if statusHosts[host] || strings.HasPrefix(host, "status.") || strings.HasSuffix(host, ".statuspage.io") || strings.HasSuffix(host, ".status.io") {
+		return true
+	}
+	return incidentPathRegex.MatchString(u.Path)
+}
+
+// isRecipePage reports whether u is a recipe or a how-to guide.
+func isRecipePage(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path, ok := recipePaths[host]
+	if !ok {
+		if _, parent, found := strings.Cut(host, "."); found {
+			path, ok = recipePaths["*."+parent]
+		}
+	}
+	if ok {
+		return path.MatchString(u.Path)
+	}
+	return recipePathRegex.MatchString(u.Path)
+}
+
+// summarizeAs summarizes content in one of the specialized formats of pageModes.
+func (a *App) summarizeAs(ctx context.Context, model llm.LLM, mode string, content string, userPrompt string) (*llm.Response, error) {
+	messages := llm.BuildMessages(mode, content, userPrompt)
+	if mode == llm.ModeJob {
+		a.mu.RLock()
+		profile := a.jobProfile
+		a.mu.RUnlock()
+		messages = llm.WithJobProfile(messages, profile.Prefix())
+	}
+	resp, err := a.generateStream(ctx, model, localize(ctx, messages), llm.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process content: %w", err)
+	}
+	return resp, nil
+}
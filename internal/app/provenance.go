@@ -0,0 +1,19 @@
+package app
+
+import "github.com/kznrluk/describe-kun/internal/provenance"
+
+// SetSigningKey signs the provenance of every summary with key (HMAC-SHA256), so consumers holding the key
+// can verify that a summary was made by this deployment from a given snapshot of its source. Empty leaves
+// provenance unsigned. It must be called before any request is made.
+func (a *App) SetSigningKey(key []byte) {
+	a.signingKey = key
+}
+
+// ProvenanceFor returns the provenance of r for summary, the summary of r as delivered in another format
+// (e.g. rendered as Markdown), signed like r's own.
+func (a *App) ProvenanceFor(r *Result, summary string) *provenance.Provenance {
+	if r.Provenance == nil {
+		return nil
+	}
+	return r.Provenance.For(summary, a.signingKey)
+}
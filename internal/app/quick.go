@@ -0,0 +1,48 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// quickMaxBytes is how much extracted text a quick summary sees; less input means a faster answer.
+const quickMaxBytes = 6000
+
+// SetQuickModel sets the cheap model used by QuickSummary. Empty uses the default model.
+func (a *App) SetQuickModel(model string) {
+	a.quickModel = model
+}
+
+// QuickSummary returns a one-line TL;DR of the page at url, trading detail for latency.
+// If userPrompt is provided, it is answered in one line instead.
+func (a *App) QuickSummary(ctx context.Context, url string, userPrompt string) (string, error) {
+	model, err := a.llmFor(url)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := a.fetcher.Fetch(ctx, fetcher.FetchRequest{URL: url, MaxBytes: quickMaxBytes})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch content: %w", err)
+	}
+	if result.Text == "" {
+		return "", fetcher.NewPageError(url, result.Diagnostics)
+	}
+
+	opts := llm.Options{}
+	if model == a.llm {
+		// The quick model names a model of the default provider, not of the local one
+		opts.Model = a.quickModel
+	}
+	resp, err := a.generate(ctx, model, llm.BuildMessages(llm.ModeTLDR, result.Text, userPrompt), opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to process content: %w", err)
+	}
+	// Models occasionally add a second line despite the instructions
+	line, _, _ := strings.Cut(strings.TrimSpace(resp.Text), "\n")
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/metrics"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+const (
+	// quickstartMaxPages is how many getting started pages are crawled from a docs homepage.
+	quickstartMaxPages = 4
+	// quickstartPageBytes caps the text of each crawled page sent to the model.
+	quickstartPageBytes = 30000
+)
+
+// gettingStartedRegex matches the text or URL of links to getting started, installation and configuration pages.
+var gettingStartedRegex = regexp.MustCompile(`(?i)get(ting)?[-_ ]?started|quick[-_ ]?start|install|set[-_ ]?up|first[-_ ]steps|tutorial|configur|はじめに|入門|導入|インストール|クイックスタート|チュートリアル|設定`)
+
+// gettingStartedLinks returns the links of the docs homepage page that lead to its getting started pages on
+// the same host, in page order.
+func gettingStartedLinks(page *fetcher.FetchResult, homeURL string) []string {
+	home, err := url.Parse(homeURL)
+	if err != nil {
+		return nil
+	}
+	var links []string
+	seen := map[string]bool{}
+	for _, link := range outboundLinks(page.Markdown, homeURL) {
+		u, err := url.Parse(link.URL)
+		if err != nil || !strings.EqualFold(u.Hostname(), home.Hostname()) {
+			continue
+		}
+		u.Fragment = ""
+		if seen[u.String()] || !(gettingStartedRegex.MatchString(link.Text) || gettingStartedRegex.MatchString(u.Path)) {
+			continue
+		}
+		seen[u.String()] = true
+		links = append(links, u.String())
+		if len(links) == quickstartMaxPages {
+			break
+		}
+	}
+	return links
+}
+
+// ProcessQuickstart crawls the getting started pages linked from the docs homepage at docsURL and condenses
+// them into a quickstart: installation, a minimal example and key configuration. If userPrompt is provided,
+// it is answered first. Pages that cannot be read are left out; the homepage must be readable.
+func (a *App) ProcessQuickstart(ctx context.Context, docsURL string, userPrompt string, progressCallback ProgressCallback) (string, error) {
+	if _, err := a.llmFor(docsURL); err != nil {
+		return "", err
+	}
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Fetching the docs at %s...", docsURL))
+	}
+	home, err := a.fetchPage(ctx, docsURL)
+	if err != nil {
+		return "", err
+	}
+	docsURL = pageURL(home, docsURL)
+
+	urls := []string{docsURL}
+	var content strings.Builder
+	writePage := func(url, text string) {
+		fmt.Fprintf(&content, "Page: %s\n%s\n\n", url, truncateBytes(text, quickstartPageBytes))
+	}
+	writePage(docsURL, home.Text)
+
+	actions := a.actions(ctx)
+	var links []string
+	for _, link := range gettingStartedLinks(home, docsURL) {
+		if !actions.AllowsFetch(link) {
+			reqmeta.Logf(ctx, "[App] Channel policy leaves %s out of the quickstart", link)
+			continue
+		}
+		links = append(links, link)
+	}
+	links = links[:actions.Limit(len(links))]
+	for i, link := range links {
+		if _, err := a.llmFor(link); err != nil {
+			reqmeta.Logf(ctx, "[App] Leaving %s out of the quickstart: %v", link, err)
+			continue
+		}
+		if progressCallback != nil {
+			progressCallback(fmt.Sprintf(":loading: Reading getting started page %d/%d: %s", i+1, len(links), link))
+		}
+		page, err := a.fetchPage(ctx, link)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			reqmeta.Logf(ctx, "[App] Leaving %s out of the quickstart: %v", link, err)
+			continue
+		}
+		if !a.screen(ctx, "quickstart", link, page.Text) {
+			continue
+		}
+		urls = append(urls, link)
+		writePage(link, page.Text)
+	}
+	reqmeta.Logf(ctx, "[App] Writing a quickstart from %d page(s) of %s", len(urls), docsURL)
+
+	// Local-only rules apply to every page read
+	model, err := a.llmFor(urls...)
+	if err != nil {
+		return "", err
+	}
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Writing a quickstart from %d page(s)...", len(urls)))
+	}
+	messages := llm.BuildMessages(llm.ModeQuickstart, strings.TrimSpace(content.String()), userPrompt)
+	resp, err := a.generateStream(ctx, model, localize(ctx, messages), llm.Options{})
+	if err != nil {
+		return "", fmt.Errorf("failed to write quickstart: %w", err)
+	}
+
+	var footer strings.Builder
+	footer.WriteString("\n\n*Sources*")
+	for _, u := range urls {
+		fmt.Fprintf(&footer, "\n• %s", u)
+	}
+	return strings.TrimSpace(resp.Text) + footer.String(), nil
+}
+
+// fetchPage fetches url, failing for pages without text.
+func (a *App) fetchPage(ctx context.Context, url string) (*fetcher.FetchResult, error) {
+	start := time.Now()
+	page, err := a.fetcher.Fetch(ctx, fetcher.FetchRequest{URL: url})
+	a.metrics.Observe(metrics.StageFetch, url, time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch content: %w", err)
+	}
+	if page.Text == "" {
+		return nil, fetcher.NewPageError(url, page.Diagnostics)
+	}
+	return page, nil
+}
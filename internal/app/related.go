@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+const (
+	// relatedCandidates is how many of a page's links the model chooses from, in page order.
+	relatedCandidates = 50
+	// relatedMax is how many related links are listed at most.
+	relatedMax = 5
+	// relatedContextBytes is how much of the text around a link is shown to the model.
+	relatedContextBytes = 200
+)
+
+// markdownLinkRegex matches an absolute Markdown link: [text](url).
+var markdownLinkRegex = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+
+// outboundLink is a link found in a page, with the text around it.
+type outboundLink struct {
+	Text    string
+	URL     string
+	Context string
+}
+
+// outboundLinks returns the distinct links of the Markdown rendering of the page at pageURL, in page order.
+func outboundLinks(markdown, pageURL string) []outboundLink {
+	var links []outboundLink
+	seen := map[string]bool{strings.TrimSuffix(pageURL, "/"): true}
+	for _, line := range strings.Split(markdown, "\n") {
+		for _, m := range markdownLinkRegex.FindAllStringSubmatch(line, -1) {
+			url, _, _ := strings.Cut(m[2], "#")
+			text := strings.TrimSpace(m[1])
+			if text == "" || seen[strings.TrimSuffix(url, "/")] {
+				continue
+			}
+			seen[strings.TrimSuffix(url, "/")] = true
+
+			context := strings.Join(strings.Fields(markdownLinkRegex.ReplaceAllString(line, "$1")), " ")
+			context = truncateBytes(context, relatedContextBytes)
+			links = append(links, outboundLink{Text: text, URL: m[2], Context: context})
+			if len(links) == relatedCandidates {
+				return links
+			}
+		}
+	}
+	return links
+}
+
+// relatedLinks returns a "Related links" section with the links of page the model finds most useful for
+// following up on it, judged by their text and context. It returns "" if there are none or choosing fails.
+func (a *App) relatedLinks(ctx context.Context, model llm.LLM, page *fetcher.FetchResult) string {
+	links := outboundLinks(page.Markdown, page.FinalURL)
+	if len(links) == 0 {
+		return ""
+	}
+
+	var candidates strings.Builder
+	for i, link := range links {
+		fmt.Fprintf(&candidates, "[%d] %s (%s) — %s\n", i+1, link.Text, link.URL, link.Context)
+	}
+	messages := []llm.Message{
+		llm.NewTextMessage(llm.RoleSystem, fmt.Sprintf("You pick the links of an article that a reader would most want to follow: referenced specifications, papers, source repositories, official announcements and documentation. Ignore navigation, sharing, login, advertising and unrelated links. Reply with only the numbers of at most %d links, most relevant first, separated by commas, or NONE.", relatedMax)),
+		llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("Article title: %s\n\nLinks with the text around them:\n%s", page.Metadata.Title, candidates.String())),
+	}
+	resp, err := a.call(ctx, model, messages, llm.Options{})
+	if err != nil {
+		log.Printf("[App] Choosing related links failed: %v", err)
+		return ""
+	}
+
+	var section strings.Builder
+	chosen := 0
+	for _, field := range strings.FieldsFunc(resp.Text, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }) {
+		n, err := strconv.Atoi(strings.Trim(field, "[]."))
+		if err != nil || n < 1 || n > len(links) || chosen == relatedMax {
+			continue
+		}
+		if chosen == 0 {
+			section.WriteString("\n\n:paperclip: *Related links*")
+		}
+		fmt.Fprintf(&section, "\n• <%s|%s>", links[n-1].URL, strings.ReplaceAll(links[n-1].Text, "|", "/"))
+		chosen++
+	}
+	return section.String()
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// embedBatch caps the chunks embedded in one call, keeping requests well within the API's token limit.
+const embedBatch = 64
+
+// Embedder turns texts into embedding vectors, whose cosine similarity tells how related the texts are.
+type Embedder interface {
+	// Embed returns the embedding of each of texts, in the same order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// RelevanceFilter narrows pages longer than Threshold characters, when a question is asked about them, to
+// their chunks of ChunkSize characters most similar to the question, up to MaxChars characters in all.
+type RelevanceFilter struct {
+	Threshold int
+	ChunkSize int
+	MaxChars  int
+}
+
+// SetRelevanceFilter makes questions about long pages answered from the parts of the page most relevant to
+// them, found by comparing embeddings made by e, rather than from the whole page. Pages the domain policy
+// keeps on the local model are not filtered, as e runs elsewhere. Zero Threshold disables the filter.
+// It must be called before any request is made.
+func (a *App) SetRelevanceFilter(e Embedder, f RelevanceFilter) {
+	a.embedder = e
+	a.relevance = f
+}
+
+// relevantContent returns the chunks of content most relevant to userPrompt in page order, or content
+// itself if it is short, there is no question, or the chunks cannot be compared.
+func (a *App) relevantContent(ctx context.Context, model llm.LLM, url, content, userPrompt string, progressCallback ProgressCallback) string {
+	f := a.relevance
+	if a.embedder == nil || f.Threshold == 0 || strings.TrimSpace(userPrompt) == "" || model != a.llm || len([]rune(content)) <= f.Threshold {
+		return content
+	}
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":mag: Finding the parts of %s relevant to the question...", url))
+	}
+	chunks := splitChunks(content, f.ChunkSize)
+	vectors, err := a.embed(ctx, append([]string{userPrompt}, chunks...))
+	if err != nil {
+		reqmeta.Logf(ctx, "[App] Reading all of %s: %v", url, err)
+		return content
+	}
+
+	question, scores := vectors[0], make([]float64, len(chunks))
+	order := make([]int, len(chunks))
+	for i := range chunks {
+		scores[i] = cosine(question, vectors[i+1])
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool { return scores[order[x]] > scores[order[y]] })
+	keep := make([]bool, len(chunks))
+	kept, chars := 0, 0
+	for _, i := range order {
+		n := len([]rune(chunks[i]))
+		if kept > 0 && chars+n > f.MaxChars {
+			break
+		}
+		keep[i] = true
+		kept++
+		chars += n
+	}
+
+	var b strings.Builder
+	b.WriteString("[Only the parts of the page most relevant to the question are included; omitted parts are marked with \"[…]\".]\n\n")
+	for i, chunk := range chunks {
+		switch {
+		case keep[i]:
+			b.WriteString(chunk + "\n\n")
+		case i == 0 || keep[i-1]:
+			b.WriteString("[…]\n\n")
+		}
+	}
+	reqmeta.Logf(ctx, "[App] Kept %d of %d chunks of %s relevant to the question (%d characters)", kept, len(chunks), url, chars)
+	return strings.TrimSpace(b.String())
+}
+
+// embed embeds texts in batches of embedBatch.
+func (a *App) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatch {
+		batch := texts[start:min(start+embedBatch, len(texts))]
+		v, err := a.embedder.Embed(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed the page: %w", err)
+		}
+		if len(v) != len(batch) {
+			return nil, fmt.Errorf("failed to embed the page: got %d embeddings for %d texts", len(v), len(batch))
+		}
+		vectors = append(vectors, v...)
+	}
+	return vectors, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if either is zero or their lengths differ.
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/history"
+)
+
+// Replay summarizes a recorded history entry again with the current prompts and models, without
+// recording it or running the summary hooks. With cached set, the entry's stored content is
+// summarized instead of refetching the page; entries recorded without content are refetched.
+func (a *App) Replay(ctx context.Context, e history.Entry, cached bool) (*Result, error) {
+	if !cached || e.Content == "" {
+		return a.fetchAndSummarize(ctx, fetcher.FetchRequest{URL: e.URL}, e.Prompt, nil)
+	}
+
+	model, err := a.llmFor(e.URL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.summarize(ctx, model, e.Content, e.Prompt)
+	if err != nil {
+		return nil, fmt.Errorf("replaying %s: %w", e.ID, err)
+	}
+	return &Result{
+		URL:       e.URL,
+		Prompt:    e.Prompt,
+		Content:   e.Content,
+		Summary:   resp.Text,
+		Model:     resp.Model,
+		Usage:     resp.Usage,
+		CreatedAt: time.Now(),
+	}, nil
+}
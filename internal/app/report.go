@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+const (
+	// reportMaxSections caps the sections of a report; each costs an LLM call over all sources.
+	reportMaxSections = 6
+	// reportSourceBytes caps the text of each source sent to the model.
+	reportSourceBytes = 40000
+)
+
+// Report is a multi-section research report answering a question from several sources.
+type Report struct {
+	Title    string         `json:"title"`
+	Question string         `json:"question"`
+	Sections []Section      `json:"sections"`
+	Sources  []ReportSource `json:"sources"` // In citation order: [1] is the first
+}
+
+// ReportSource is a source of a report, with why it could not be read if it could not.
+type ReportSource struct {
+	URL   string `json:"url"`
+	Error string `json:"error,omitempty"`
+}
+
+// reportOutline is the JSON outline the model plans a report with.
+type reportOutline struct {
+	Title    string `json:"title"`
+	Sections []struct {
+		Heading string `json:"heading"`
+		Focus   string `json:"focus"`
+	} `json:"sections"`
+}
+
+// ProcessReport reads every URL and writes a report answering question from them: the model first plans an
+// outline, then writes each section citing the sources of every claim by number. Sources that cannot be
+// read are listed as such; the report fails only if none can be read.
+func (a *App) ProcessReport(ctx context.Context, urls []string, question string, progressCallback ProgressCallback) (*Report, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no sources to report on")
+	}
+	model, err := a.llmFor(urls...)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Question: question}
+	var content strings.Builder
+	var first *fetcher.FetchResult
+	read := 0
+	for i, url := range urls {
+		if progressCallback != nil {
+			progressCallback(fmt.Sprintf(":loading: Fetching source %d/%d: %s", i+1, len(urls), url))
+		}
+		page, err := a.fetchPage(ctx, url)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			reqmeta.Logf(ctx, "[App] Leaving %s out of the report: %v", url, err)
+			report.Sources = append(report.Sources, ReportSource{URL: url, Error: err.Error()})
+			continue
+		}
+		report.Sources = append(report.Sources, ReportSource{URL: url})
+		if first == nil {
+			first = page
+		}
+		fmt.Fprintf(&content, "Source [%d]: %s\n%s\n\n", len(report.Sources), url, truncateBytes(page.Text, reportSourceBytes))
+		read++
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("none of the sources could be read: %s", report.Sources[0].Error)
+	}
+	sources := strings.TrimSpace(content.String())
+	if a.enabled(ctx, feature.ContentLanguage) {
+		ctx = matchLanguage(ctx, first, question)
+	}
+
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Planning a report from %d source(s)...", read))
+	}
+	var outline reportOutline
+	validate := func() error {
+		if outline.Title == "" {
+			return errors.New(`"title" is empty`)
+		}
+		if len(outline.Sections) == 0 || len(outline.Sections) > reportMaxSections {
+			return fmt.Errorf(`"sections" must have 1 to %d sections, got %d`, reportMaxSections, len(outline.Sections))
+		}
+		for i, s := range outline.Sections {
+			if s.Heading == "" {
+				return fmt.Errorf(`"heading" of section %d is empty`, i+1)
+			}
+		}
+		return nil
+	}
+	messages := localize(ctx, llm.BuildOutlineMessages(sources, question, reportMaxSections))
+	if _, err := a.callJSON(ctx, model, messages, llm.Options{}, &outline, validate); err != nil {
+		return nil, fmt.Errorf("failed to outline report: %w", err)
+	}
+	report.Title = outline.Title
+	headings := make([]string, len(outline.Sections))
+	for i, s := range outline.Sections {
+		headings[i] = s.Heading
+	}
+	reqmeta.Logf(ctx, "[App] Writing report %q with %d section(s)", outline.Title, len(headings))
+
+	for i, s := range outline.Sections {
+		if progressCallback != nil {
+			progressCallback(fmt.Sprintf(":loading: Writing section %d/%d: %s", i+1, len(outline.Sections), s.Heading))
+		}
+		messages := llm.BuildReportSectionMessages(sources, question, outline.Title, headings, s.Heading, s.Focus)
+		resp, err := a.generate(ctx, model, localize(ctx, messages), llm.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write section %q: %w", s.Heading, err)
+		}
+		report.Sections = append(report.Sections, Section{Heading: s.Heading, Body: strings.TrimSpace(resp.Text)})
+	}
+	return report, nil
+}
+
+// Markdown renders the report as a Markdown document ending with its numbered sources.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", r.Title)
+	if r.Question != "" {
+		fmt.Fprintf(&b, "> %s\n\n", r.Question)
+	}
+	for _, s := range r.Sections {
+		fmt.Fprintf(&b, "## %s\n\n%s\n\n", s.Heading, s.Body)
+	}
+	b.WriteString("## Sources\n\n")
+	for i, s := range r.Sources {
+		if s.Error != "" {
+			fmt.Fprintf(&b, "- [%d] %s (could not be read: %s)\n", i+1, s.URL, s.Error)
+		} else {
+			fmt.Fprintf(&b, "- [%d] %s\n", i+1, s.URL)
+		}
+	}
+	return b.String()
+}
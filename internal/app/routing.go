@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// LengthRouting picks the model of a page by the length of its text: pages of at most ShortMaxChars
+// characters go to ShortModel, pages of at least LongMinChars characters to LongModel, and the rest to the
+// provider's default model. An empty model leaves its pages on the default.
+type LengthRouting struct {
+	ShortModel    string
+	ShortMaxChars int
+	LongModel     string
+	LongMinChars  int
+}
+
+// SetLengthRouting routes page summaries to models by the length of the page, e.g. short pages to a cheap,
+// fast model and long ones to a large-context model. Pages kept on the local model are not routed.
+func (a *App) SetLengthRouting(r LengthRouting) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lengthRouting = r
+}
+
+// routeByLength returns model set to summarize content with the model of its length, and the route taken:
+// "short", "long", or "" if content stays on model's default.
+func (a *App) routeByLength(ctx context.Context, model llm.LLM, content string) (llm.LLM, string) {
+	a.mu.RLock()
+	r, local := a.lengthRouting, model != a.llm
+	a.mu.RUnlock()
+	if local {
+		// Model names are those of the default provider, not of the local one
+		return model, ""
+	}
+	chars := len([]rune(content))
+	route, name := "", ""
+	switch {
+	case r.ShortModel != "" && chars <= r.ShortMaxChars:
+		route, name = "short", r.ShortModel
+	case r.LongModel != "" && r.LongMinChars > 0 && chars >= r.LongMinChars:
+		route, name = "long", r.LongModel
+	default:
+		return model, ""
+	}
+	reqmeta.Logf(ctx, "[App] Routing a page of %d characters to the %s-page model %s", chars, route, name)
+	return routedLLM{LLM: model, model: name}, route
+}
+
+// routedLLM generates with its LLM using model, unless a call names a model of its own (vision, quick, ...).
+type routedLLM struct {
+	llm.LLM
+	model string
+}
+
+func (r routedLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	if opts.Model == "" {
+		opts.Model = r.model
+	}
+	return r.LLM.Generate(ctx, messages, opts)
+}
+
+func (r routedLLM) GenerateStream(ctx context.Context, messages []llm.Message, opts llm.Options, onText func(delta string)) (*llm.Response, error) {
+	if opts.Model == "" {
+		opts.Model = r.model
+	}
+	return llm.Stream(ctx, r.LLM, messages, opts, onText)
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// screeningMaxBytes is how much content the screening call sees; the start of a page is usually enough to judge its topic.
+const screeningMaxBytes = 4000
+
+// SetScreening configures relevance pre-screening for automated pipelines: podcast digests, page watches
+// and vendor tracking, and the pages crawled for quickstarts. model should be a cheap model of the default
+// provider; empty topics disable screening. It must be called before any request is made.
+func (a *App) SetScreening(model string, topics []string) {
+	a.screeningModel = model
+	a.screeningTopics = topics
+}
+
+// IsRelevant asks the screening model whether content of url is about any configured topic, so
+// high-volume pipelines (digests, feeds, crawls) can skip pages before paying for a full summary.
+// Local-only rules apply as for summaries. It returns true when screening is disabled. On error it also
+// returns true, so a failing screening call never silently drops content.
+func (a *App) IsRelevant(ctx context.Context, url, content string) (bool, error) {
+	if len(a.screeningTopics) == 0 {
+		return true, nil
+	}
+	model, err := a.llmFor(url)
+	if err != nil {
+		return true, err
+	}
+	opts := llm.Options{}
+	if model == a.llm {
+		// The screening model is one of the default provider
+		opts.Model = a.screeningModel
+	}
+
+	messages := []llm.Message{
+		llm.NewTextMessage(llm.RoleSystem, "You are a strict relevance classifier. Reply with only YES or NO."),
+		llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("Topics: %s\n\nContent (may be truncated):\n```\n%s\n```\n\nIs this content relevant to any of the topics?", strings.Join(a.screeningTopics, ", "), truncateBytes(content, screeningMaxBytes))),
+	}
+	resp, err := a.call(ctx, model, messages, opts)
+	if err != nil {
+		return true, fmt.Errorf("relevance screening failed: %w", err)
+	}
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(resp.Text)), "YES"), nil
+}
+
+// screen reports whether the pipeline named what should go on with content of url, logging pages it skips.
+func (a *App) screen(ctx context.Context, what, url, content string) bool {
+	relevant, err := a.IsRelevant(ctx, url, content)
+	if err != nil {
+		reqmeta.Logf(ctx, "[App] Screening %s for the %s: %v", url, what, err)
+	}
+	if !relevant {
+		reqmeta.Logf(ctx, "[App] Leaving %s out of the %s: not about the screening topics", url, what)
+	}
+	return relevant
+}
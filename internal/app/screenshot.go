@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// visionFallbackChars is the length of extracted text below which a page is read from a screenshot instead,
+// as canvas-rendered and image-heavy pages yield little or no text.
+const visionFallbackChars = 200
+
+// sparse reports whether content is too little text to summarize, so the page is worth reading from a
+// screenshot.
+func sparse(content string) bool {
+	return len([]rune(strings.TrimSpace(content))) < visionFallbackChars
+}
+
+// screenshot returns a screenshot of the page of req, fetching it again with one if page has none, or nil
+// if none can be taken (e.g. with the HTTP fetcher, or for HTML the caller provided).
+func (a *App) screenshot(ctx context.Context, req fetcher.FetchRequest, page *fetcher.FetchResult, progressCallback ProgressCallback) []byte {
+	if len(page.Screenshot) > 0 {
+		return page.Screenshot
+	}
+	if req.HTML != "" {
+		return nil
+	}
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":camera: Little text found on %s, taking a screenshot...", req.URL))
+	}
+	req.Screenshot = true
+	shot, err := a.fetcher.Fetch(ctx, req)
+	if err != nil {
+		reqmeta.Logf(ctx, "[App] Not reading %s from a screenshot: %v", req.URL, err)
+		return nil
+	}
+	if len(shot.Screenshot) == 0 {
+		reqmeta.Logf(ctx, "[App] Not reading %s from a screenshot: the fetcher returned none", req.URL)
+		return nil
+	}
+	reqmeta.Logf(ctx, "[App] Reading %s from a %d-byte screenshot", req.URL, len(shot.Screenshot))
+	return shot.Screenshot
+}
+
+// summarizeScreenshot summarizes a page from its PNG screenshot with the vision model. The vision model is
+// one of the default provider, so pages kept on the local model are read by the local model itself.
+func (a *App) summarizeScreenshot(ctx context.Context, model llm.LLM, png []byte, userPrompt string) (*llm.Response, error) {
+	opts := llm.Options{}
+	if model == a.llm {
+		opts.Model = a.visionModel
+	}
+	resp, err := a.generate(ctx, model, localize(ctx, llm.BuildImageMessages(userPrompt, []llm.Part{llm.ImagePart(png, "image/png")})), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the screenshot: %w", err)
+	}
+	return resp, nil
+}
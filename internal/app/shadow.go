@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/kznrluk/describe-kun/internal/history"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/persona"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// Shadow is a candidate model and/or system prompt prefix tried on live traffic before switching to it.
+// Its summaries are only recorded, never returned to the requester.
+type Shadow struct {
+	Model   string           // Candidate model of the default provider; empty keeps the production model
+	Persona *persona.Persona // Candidate system prompt prefix; nil keeps the production one
+	Sample  float64          // Fraction of page summaries (0-1) also sent to the candidate
+	Store   *history.Store   // Where the candidate's summaries are recorded next to the production ones
+}
+
+// SetShadow enables shadow mode with s, or disables it when s is nil. It is safe to call while requests are running.
+func (a *App) SetShadow(s *Shadow) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.shadow = s
+}
+
+// runShadow summarizes the content of a production result again with the shadow candidate, for a sample of
+// requests. It runs in the background so the requester never waits for the candidate.
+func (a *App) runShadow(ctx context.Context, result *Result) {
+	a.mu.RLock()
+	s := a.shadow
+	a.mu.RUnlock()
+	if s == nil || s.Store == nil || rand.Float64() >= s.Sample {
+		return
+	}
+	model, err := a.llmFor(result.URL)
+	if err != nil || model != a.llm {
+		// The candidate model names a model of the default provider; local-only pages never leave the local one
+		return
+	}
+
+	// Outlive the request, but account the tokens to the deployment rather than the requester's channel
+	ctx = budget.WithScope(context.WithoutCancel(ctx), "")
+	go func() {
+		start := time.Now()
+		prefix := s.Persona.Prefix()
+		if s.Persona == nil {
+			a.mu.RLock()
+			prefix = a.persona.Prefix()
+			a.mu.RUnlock()
+		}
+		messages := llm.WithSystemPrefix(localize(ctx, llm.BuildMessages(llm.ModeSummary, result.Content, result.Prompt)), prefix)
+		resp, err := a.call(ctx, model, messages, llm.Options{Model: s.Model})
+
+		meta := reqmeta.From(ctx)
+		entry := history.Entry{
+			Time:       start,
+			RequestID:  meta.RequestID,
+			Source:     meta.Source,
+			Trigger:    meta.Trigger,
+			Workspace:  meta.Workspace,
+			Channel:    meta.Channel,
+			User:       meta.User,
+			URL:        result.URL,
+			Prompt:     result.Prompt,
+			DurationMS: time.Since(start).Milliseconds(),
+			BaselineID: result.ID,
+			Baseline:   result.Summary,
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.Model = resp.Model
+			entry.Tokens = resp.Usage.TotalTokens
+			entry.Summary = resp.Text
+		}
+		if _, err := s.Store.Append(entry); err != nil {
+			log.Printf("[Shadow] Failed to record the candidate summary of %s: %v", result.URL, err)
+		}
+	}()
+}
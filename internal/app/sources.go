@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// citedSource is one entry of the SOURCES line of a thread answer.
+type citedSource struct {
+	URL     string
+	Section string
+}
+
+// splitSources removes the trailing SOURCES line from a thread answer and returns the cited entries.
+func splitSources(text string) (string, []citedSource) {
+	trimmed := strings.TrimRight(text, " \n")
+	idx := strings.LastIndex(trimmed, "\n")
+	last := strings.TrimSpace(trimmed[idx+1:])
+	if !strings.HasPrefix(last, llm.SourcesPrefix) {
+		return text, nil
+	}
+
+	var cited []citedSource
+	for _, entry := range strings.Split(strings.TrimPrefix(last, llm.SourcesPrefix), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		url, section := entry, ""
+		if open := strings.Index(entry, " ("); open >= 0 && strings.HasSuffix(entry, ")") {
+			url, section = entry[:open], strings.TrimSpace(entry[open+2:len(entry)-1])
+		}
+		url = strings.Trim(strings.TrimSpace(url), "<>")
+		if url != "" {
+			cited = append(cited, citedSource{URL: url, Section: section})
+		}
+	}
+
+	if idx < 0 {
+		return "", cited
+	}
+	return strings.TrimRight(trimmed[:idx], " \n"), cited
+}
+
+// sourcesFooter lists the cited sources that were actually retrieved for the answer, so a model
+// cannot point readers at pages it never saw. It returns "" when nothing qualifies.
+func sourcesFooter(cited []citedSource, retrieved []string) string {
+	known := make(map[string]bool, len(retrieved))
+	for _, url := range retrieved {
+		known[url] = true
+	}
+
+	var footer strings.Builder
+	seen := make(map[string]bool)
+	for _, source := range cited {
+		if !known[source.URL] || seen[source.URL] {
+			continue
+		}
+		seen[source.URL] = true
+		if footer.Len() == 0 {
+			footer.WriteString("\n\n:link: *Sources used*")
+		}
+		if source.Section != "" {
+			fmt.Fprintf(&footer, "\n• %s — %s", source.URL, source.Section)
+		} else {
+			fmt.Fprintf(&footer, "\n• %s", source.URL)
+		}
+	}
+	return footer.String()
+}
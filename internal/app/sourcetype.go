@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// sourceTypeMaxBytes is how much of a page the source type is judged from; the start usually gives it away.
+const sourceTypeMaxBytes = 4000
+
+// sourceTypes are the labels a page can get, in the words shown to readers.
+var sourceTypes = []string{
+	"news article",
+	"vendor blog",
+	"personal blog",
+	"press release",
+	"peer-reviewed paper",
+	"preprint",
+	"documentation",
+	"forum post",
+	"government or official publication",
+	"other",
+}
+
+// sourceType returns a line labelling what kind of source page is and whether its tone is promotional,
+// so readers can weigh the summary. It returns "" if classification fails.
+func (a *App) sourceType(ctx context.Context, model llm.LLM, page *fetcher.FetchResult) string {
+	content := truncateBytes(page.Text, sourceTypeMaxBytes)
+	messages := []llm.Message{
+		llm.NewTextMessage(llm.RoleSystem, fmt.Sprintf("You classify web pages for researchers. Reply with exactly two lines:\nTYPE: one of %s\nPROMOTIONAL: YES if the page mainly promotes a product, company or event, otherwise NO", strings.Join(sourceTypes, ", "))),
+		llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("URL: %s\nTitle: %s\nSite: %s\n\nContent (may be truncated):\n```\n%s\n```", page.FinalURL, page.Metadata.Title, page.Metadata.SiteName, content)),
+	}
+	resp, err := a.call(ctx, model, messages, llm.Options{})
+	if err != nil {
+		log.Printf("[App] Source type classification failed: %v", err)
+		return ""
+	}
+
+	var label string
+	var promotional bool
+	for _, line := range strings.Split(resp.Text, "\n") {
+		key, value, _ := strings.Cut(line, ":")
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch strings.ToUpper(strings.TrimSpace(key)) {
+		case "TYPE":
+			for _, t := range sourceTypes {
+				if value == t {
+					label = t
+				}
+			}
+		case "PROMOTIONAL":
+			promotional = strings.HasPrefix(value, "yes")
+		}
+	}
+	if label == "" {
+		log.Printf("[App] Unexpected source type reply %q", resp.Text)
+		return ""
+	}
+	line := "\n\n:label: *Source:* " + label
+	if promotional {
+		line += " · :loudspeaker: promotional tone"
+	}
+	return line
+}
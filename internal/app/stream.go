@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// streamInterval is the minimum time between progress updates showing a summary being written,
+// which keeps Slack message edits well within their rate limit.
+const streamInterval = time.Second
+
+type streamKey struct{}
+
+// WithStream returns a context whose page and content summaries pass their text to onText as the
+// model writes it, so callers can show it before the summary is done. The text is the model's raw
+// output; the final summary may add to it, e.g. a title header.
+func WithStream(ctx context.Context, onText func(delta string)) context.Context {
+	return context.WithValue(ctx, streamKey{}, onText)
+}
+
+// streamFrom returns the callback set by WithStream, or nil.
+func streamFrom(ctx context.Context) func(delta string) {
+	onText, _ := ctx.Value(streamKey{}).(func(delta string))
+	return onText
+}
+
+// ProcessContentStream is ProcessContent passing the summary to onText as it is written.
+func (a *App) ProcessContentStream(ctx context.Context, content string, userPrompt string, onText func(delta string)) (string, error) {
+	return a.ProcessContent(WithStream(ctx, onText), content, userPrompt)
+}
+
+// generateStream is generate, streaming the text to the context's WithStream callback if there is one.
+func (a *App) generateStream(ctx context.Context, model llm.LLM, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	if onText := streamFrom(ctx); onText != nil {
+		model = streamingLLM{LLM: model, onText: onText}
+	}
+	return a.generate(ctx, model, messages, opts)
+}
+
+// streamingLLM generates with its LLM, streaming the text to onText.
+type streamingLLM struct {
+	llm.LLM
+	onText func(delta string)
+}
+
+func (s streamingLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	return llm.Stream(ctx, s.LLM, messages, opts, s.onText)
+}
+
+// streamProgress returns a stream callback showing the summary of url written so far as progress,
+// at most once per streamInterval.
+func streamProgress(url string, progressCallback ProgressCallback) func(delta string) {
+	var text strings.Builder
+	var last time.Time
+	return func(delta string) {
+		text.WriteString(delta)
+		if time.Since(last) < streamInterval {
+			return
+		}
+		last = time.Now()
+		progressCallback(fmt.Sprintf("%s\n\n:loading: Generating summary for %s...", strings.TrimSpace(text.String()), url))
+	}
+}
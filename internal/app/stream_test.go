@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// mockStreamer writes its answer in two pieces.
+type mockStreamer struct {
+	MockLLM
+}
+
+func (m *mockStreamer) GenerateStream(ctx context.Context, messages []llm.Message, opts llm.Options, onText func(delta string)) (*llm.Response, error) {
+	onText("Mock")
+	onText(" summary")
+	return &llm.Response{Text: "Mock summary"}, nil
+}
+
+func TestApp_ProcessURL_Streaming(t *testing.T) {
+	fetcher := &MockFetcher{FetchFunc: func(ctx context.Context, url string) (string, error) {
+		return "Mock page content", nil
+	}}
+	app := NewApp(fetcher, &mockStreamer{MockLLM{GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+		return &llm.Response{Text: "Mock summary"}, nil
+	}}})
+
+	var progress []string
+	record := func(message string) { progress = append(progress, message) }
+	if _, err := app.ProcessURLWithProgress(context.Background(), "https://example.com", "", record); err != nil {
+		t.Fatalf("ProcessURLWithProgress failed: %v", err)
+	}
+	for _, p := range progress {
+		if strings.HasPrefix(p, "Mock") {
+			t.Errorf("Expected no streamed progress by default, got %q", p)
+		}
+	}
+
+	flags, err := feature.New(map[string]bool{feature.Streaming: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.SetFeatures(flags)
+	progress = nil
+	summary, err := app.ProcessURLWithProgress(context.Background(), "https://example.com/streamed", "", record)
+	if err != nil {
+		t.Fatalf("ProcessURLWithProgress failed: %v", err)
+	}
+	if summary != "Mock summary" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+	// Updates are throttled, so only the first piece is shown this quickly
+	if n := len(progress); n == 0 || !strings.HasPrefix(progress[n-1], "Mock\n\n:loading:") {
+		t.Errorf("Expected the partial summary as progress, got %q", progress)
+	}
+}
+
+func TestApp_ProcessContentStream(t *testing.T) {
+	mockLLM := &MockLLM{GenerateFunc: func(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+		return &llm.Response{Text: "Mock summary"}, nil
+	}}
+	app := NewApp(&MockFetcher{}, mockLLM)
+
+	// LLMs that cannot stream deliver the whole text at once
+	var streamed []string
+	summary, err := app.ProcessContentStream(context.Background(), "Some content", "", func(delta string) {
+		streamed = append(streamed, delta)
+	})
+	if err != nil {
+		t.Fatalf("ProcessContentStream failed: %v", err)
+	}
+	if summary != "Mock summary" || len(streamed) != 1 || streamed[0] != "Mock summary" {
+		t.Errorf("Unexpected result %q, streamed %q", summary, streamed)
+	}
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// Summary is a page summary as data, for callers that render it themselves (Slack blocks, Markdown, JSON)
+// instead of relying on the model to follow a text format.
+type Summary struct {
+	Title            string    `json:"title"`
+	TLDR             []string  `json:"tldr"`
+	Sections         []Section `json:"sections"`
+	AnswerToQuestion string    `json:"answer_to_question"` // Empty without a question
+}
+
+// Section is one key point of a Summary.
+type Section struct {
+	Heading string `json:"heading"`
+	Body    string `json:"body"`
+}
+
+// summarySchema is the JSON schema of Summary for structured outputs.
+var summarySchema = llm.Schema{Name: "summary", Definition: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"title": {"type": "string"},
+		"tldr": {"type": "array", "items": {"type": "string"}},
+		"sections": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {"heading": {"type": "string"}, "body": {"type": "string"}},
+				"required": ["heading", "body"],
+				"additionalProperties": false
+			}
+		},
+		"answer_to_question": {"type": "string"}
+	},
+	"required": ["title", "tldr", "sections", "answer_to_question"],
+	"additionalProperties": false
+}`)}
+
+type structuredKey struct{}
+
+// WithStructured returns a context whose page summaries are generated as a Summary, available as
+// Result.Structured, whatever the kind of page. Result.Summary is then rendered from it.
+func WithStructured(ctx context.Context) context.Context {
+	return context.WithValue(ctx, structuredKey{}, true)
+}
+
+// structuredFrom reports whether WithStructured was applied to ctx.
+func structuredFrom(ctx context.Context) bool {
+	on, _ := ctx.Value(structuredKey{}).(bool)
+	return on
+}
+
+// StructuredContent summarizes content the caller already has as a Summary, bypassing the fetcher.
+func (a *App) StructuredContent(ctx context.Context, content string, userPrompt string) (*Summary, error) {
+	if content == "" {
+		return nil, fmt.Errorf("content is empty")
+	}
+	_, s, err := a.summarizeStructured(ctx, a.llm, content, userPrompt)
+	return s, err
+}
+
+// summarizeStructured summarizes content as a Summary, using the provider's structured outputs where
+// available and repairing invalid replies otherwise. The response's text is the Summary rendered for Slack.
+func (a *App) summarizeStructured(ctx context.Context, model llm.LLM, content string, userPrompt string) (*llm.Response, *Summary, error) {
+	messages := localize(ctx, llm.BuildMessages(llm.ModeStructured, content, userPrompt))
+	var s Summary
+	resp, err := a.callJSON(ctx, model, a.withPersona(messages), llm.Options{Schema: &summarySchema}, &s, func() error {
+		if len(s.TLDR) == 0 {
+			return errors.New(`"tldr" must not be empty`)
+		}
+		if userPrompt != "" && s.AnswerToQuestion == "" {
+			return errors.New(`"answer_to_question" must answer the user's question`)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to process content: %w", err)
+	}
+	if userPrompt == "" {
+		s.AnswerToQuestion = ""
+	}
+	resp.Text = s.Mrkdwn()
+	return resp, &s, nil
+}
+
+// Mrkdwn renders s as Slack text in the format of the regular summaries.
+func (s *Summary) Mrkdwn() string {
+	var b strings.Builder
+	if s.AnswerToQuestion != "" {
+		fmt.Fprintf(&b, "%s\n\n", s.AnswerToQuestion)
+	}
+	b.WriteString(":white_check_mark: 3行要約\n")
+	for _, line := range s.TLDR {
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+	if len(s.Sections) > 0 {
+		b.WriteString("\n:memo: 説明\n")
+		for _, sec := range s.Sections {
+			fmt.Fprintf(&b, "*%s*\n%s\n\n", sec.Heading, sec.Body)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Markdown renders s as a Markdown document.
+func (s *Summary) Markdown() string {
+	var b strings.Builder
+	if s.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", s.Title)
+	}
+	if s.AnswerToQuestion != "" {
+		fmt.Fprintf(&b, "%s\n\n", s.AnswerToQuestion)
+	}
+	b.WriteString("## TL;DR\n\n")
+	for _, line := range s.TLDR {
+		fmt.Fprintf(&b, "- %s\n", line)
+	}
+	for _, sec := range s.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", sec.Heading, sec.Body)
+	}
+	return b.String()
+}
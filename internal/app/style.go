@@ -0,0 +1,38 @@
+package app
+
+import (
+	"context"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// Styles are the summary styles callers can ask for with WithStyle, in the order they are listed to users.
+var Styles = []string{"tldr", "executive", "eli5", "detailed"}
+
+// styleModes maps each style to the prompt mode that writes it.
+var styleModes = map[string]string{
+	"tldr":      llm.ModeTLDR,
+	"executive": llm.ModeExecutive,
+	"eli5":      llm.ModeELI5,
+	"detailed":  llm.ModeDetailed,
+}
+
+// ValidStyle reports whether style is one of Styles.
+func ValidStyle(style string) bool {
+	_, ok := styleModes[style]
+	return ok
+}
+
+type styleKey struct{}
+
+// WithStyle returns a context whose page summaries are written in style, one of Styles, whatever the kind
+// of page. Unknown styles and "" leave the default summary.
+func WithStyle(ctx context.Context, style string) context.Context {
+	return context.WithValue(ctx, styleKey{}, style)
+}
+
+// styleMode returns the prompt mode of the style requested through ctx, or "" if none was.
+func styleMode(ctx context.Context) string {
+	style, _ := ctx.Value(styleKey{}).(string)
+	return styleModes[style]
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+const (
+	// threadTitleMaxRunes caps a suggested thread title; longer ones are cut.
+	threadTitleMaxRunes = 80
+	// threadTitleMessageRunes caps each message shown to the model; the title only needs their gist.
+	threadTitleMessageRunes = 1000
+)
+
+// ThreadTitle suggests a concise title for the thread of threadContext, whose latest message is answer, so
+// long research threads can be found by searching later. It returns "" if the thread-titles feature is off
+// for the request's channel.
+func (a *App) ThreadTitle(ctx context.Context, threadContext *ThreadContext, answer string) (string, error) {
+	if !a.enabled(ctx, feature.ThreadTitles) {
+		return "", nil
+	}
+	model, err := a.llmFor(threadContext.URLs...)
+	if err != nil {
+		return "", err
+	}
+
+	conversation := append(append([]string(nil), threadContext.Messages...), answer)
+	for i, message := range conversation {
+		if r := []rune(message); len(r) > threadTitleMessageRunes {
+			conversation[i] = string(r[:threadTitleMessageRunes]) + "…"
+		}
+	}
+	opts := llm.Options{}
+	if model == a.llm {
+		// A title does not need the summary model
+		opts.Model = a.quickModel
+	}
+	resp, err := a.call(ctx, model, localize(ctx, llm.BuildThreadTitleMessages(conversation)), opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to suggest a thread title: %w", err)
+	}
+	title := cleanThreadTitle(resp.Text)
+	if title == "" {
+		return "", errors.New("failed to suggest a thread title: the model returned none")
+	}
+	return title, nil
+}
+
+// cleanThreadTitle returns the first line of the model's reply without the quotes, labels and markup
+// models add anyway, cut at threadTitleMaxRunes.
+func cleanThreadTitle(text string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	title = strings.TrimSpace(title)
+	if label, rest, ok := strings.Cut(title, ":"); ok && strings.EqualFold(strings.TrimSpace(label), "title") {
+		title = rest
+	}
+	// Periods go both inside and outside the quotes
+	title = strings.TrimRight(strings.TrimSpace(title), "。.")
+	title = strings.TrimRight(strings.Trim(title, "\"'`*_「」“”"), "。.")
+	if r := []rune(title); len(r) > threadTitleMaxRunes {
+		title = strings.TrimSpace(string(r[:threadTitleMaxRunes-1])) + "…"
+	}
+	return title
+}
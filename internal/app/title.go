@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// titleHeader returns a header with the page's title translated into each output language that differs
+// from the page's language, followed by the original title, so readers can still search for the article.
+// It returns "" when no output language is requested, the page has no title, or translating fails.
+func (a *App) titleHeader(ctx context.Context, model llm.LLM, page *fetcher.FetchResult) string {
+	languages, _ := ctx.Value(languagesKey{}).([]string)
+	title := strings.TrimSpace(page.Metadata.Title)
+	if len(languages) == 0 || title == "" {
+		return ""
+	}
+	source := pageLanguage(page.Metadata.Language, title)
+	if source == "" {
+		return ""
+	}
+	var targets []string
+	for _, code := range languages {
+		if !strings.EqualFold(code, source) {
+			targets = append(targets, code)
+		}
+	}
+	if len(targets) == 0 {
+		return ""
+	}
+
+	opts := llm.Options{}
+	if model == a.llm {
+		// A short translation does not need the summary model
+		opts.Model = a.quickModel
+	}
+	resp, err := a.call(ctx, model, llm.BuildTitleTranslation(title, targets), opts)
+	if err != nil {
+		log.Printf("[App] Title translation failed: %v", err)
+		return ""
+	}
+	lines := strings.Split(strings.TrimSpace(resp.Text), "\n")
+	if len(lines) != len(targets) {
+		log.Printf("[App] Title translation returned %d lines for %d languages", len(lines), len(targets))
+		return ""
+	}
+
+	var header strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&header, "*%s*\n", strings.TrimSpace(line))
+	}
+	fmt.Fprintf(&header, "_%s_\n\n", title)
+	return header.String()
+}
+
+// pageLanguage returns the primary language code of a page from its declared language (e.g. "en-US"),
+// or guessed from the script of its title. It returns "" if unknown.
+func pageLanguage(declared, title string) string {
+	if declared != "" {
+		code, _, _ := strings.Cut(strings.ReplaceAll(declared, "_", "-"), "-")
+		return strings.ToLower(code)
+	}
+
+	var latin, han, kana, hangul int
+	for _, r := range title {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	switch {
+	case kana > 0:
+		return "ja"
+	case hangul > 0:
+		return "ko"
+	case han > 0:
+		return "zh"
+	case latin > 0:
+		// Latin script alone cannot tell English from other languages, but it is by far the most common
+		return "en"
+	}
+	return ""
+}
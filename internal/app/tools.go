@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/policy"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// fetchedPage is a page the model read through the fetch tool.
+type fetchedPage struct {
+	URL  string
+	Text string
+}
+
+// toolFetchMaxBytes limits how much of a tool-fetched page is handed back to the model.
+const toolFetchMaxBytes = 20000
+
+// fetchTool lets the model read pages referenced by the content it was given.
+var fetchTool = llm.Tool{
+	Name:        "fetch",
+	Description: "Fetch the text content of a web page. Use this only when the question cannot be answered without reading a page linked or referenced in the provided context.",
+	Parameters: json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"url": {"type": "string", "description": "Absolute http(s) URL of the page to fetch"}
+	},
+	"required": ["url"]
+}`),
+}
+
+// SetToolFetchBudget sets how many extra pages the model may fetch while answering a thread question.
+// Zero disables tool calling, as does turning off the tool-calling feature flag.
+func (a *App) SetToolFetchBudget(n int) {
+	a.toolFetchBudget = n
+}
+
+// generateWithTools runs the conversation, executing fetch calls requested by the model
+// until it produces an answer. Once the budget is spent, tools are no longer offered. Every round of
+// tool calls counts against the budget too, even if none of its calls could be run, so a model that
+// keeps asking for pages it may not fetch still gets to the final call without tools. A final reply with
+// tool calls and no text is asked again once, then fails, instead of giving an empty answer.
+// It also returns the pages the model fetched successfully.
+func (a *App) generateWithTools(ctx context.Context, model llm.LLM, messages []llm.Message, progressCallback ProgressCallback) (string, []fetchedPage, error) {
+	var fetched []fetchedPage
+	budget := a.actions(ctx).Limit(a.toolFetchBudget)
+	if !a.enabled(ctx, feature.ToolCalling) {
+		budget = 0
+	}
+	rounds := budget
+	retried := false
+	for {
+		opts := llm.Options{}
+		if budget > 0 && rounds > 0 {
+			opts.Tools = []llm.Tool{fetchTool}
+			rounds--
+		}
+
+		resp, err := a.generate(ctx, model, messages, opts)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(opts.Tools) == 0 && len(resp.ToolCalls) > 0 && strings.TrimSpace(resp.Text) == "" {
+			// Providers that cannot forbid calling the tools of earlier turns, like Bedrock, may still
+			// reply with calls only; answer them once and ask again rather than post an empty answer
+			if retried {
+				return "", nil, errors.New("the model requested tools instead of answering")
+			}
+			retried = true
+			messages = append(messages, llm.Message{Role: llm.RoleAssistant, ToolCalls: resp.ToolCalls})
+			for _, call := range resp.ToolCalls {
+				messages = append(messages, llm.NewToolResultMessage(call.ID, "No more pages can be fetched. Answer with the information already available."))
+			}
+			continue
+		}
+		if len(resp.ToolCalls) == 0 || len(opts.Tools) == 0 {
+			return resp.Text, fetched, nil
+		}
+
+		messages = append(messages, llm.Message{
+			Role:      llm.RoleAssistant,
+			Parts:     []llm.Part{llm.TextPart(resp.Text)},
+			ToolCalls: resp.ToolCalls,
+		})
+		for _, call := range resp.ToolCalls {
+			result := a.runTool(ctx, model, call, &budget, &fetched, progressCallback)
+			messages = append(messages, llm.NewToolResultMessage(call.ID, result))
+		}
+	}
+}
+
+// runTool executes a single tool call and returns the text handed back to the model.
+// Failures are reported to the model rather than aborting the answer; successful fetches are added to fetched.
+func (a *App) runTool(ctx context.Context, model llm.LLM, call llm.ToolCall, budget *int, fetched *[]fetchedPage, progressCallback ProgressCallback) string {
+	if call.Name != fetchTool.Name {
+		return fmt.Sprintf("Unknown tool %q.", call.Name)
+	}
+	if *budget <= 0 {
+		return "Fetch budget exhausted. Answer with the information already available."
+	}
+
+	var args struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil || args.URL == "" {
+		return "Invalid arguments: a \"url\" string is required."
+	}
+
+	// Never let a tool call pull local-only content into a conversation with a remote model
+	if allowed, err := a.llmFor(args.URL); err != nil || allowed != model {
+		return fmt.Sprintf("Fetching %s is not permitted in this conversation.", args.URL)
+	}
+	if !a.actions(ctx).AllowsFetch(args.URL) {
+		reqmeta.Logf(ctx, "[App] Channel policy does not allow a tool fetch of %s", args.URL)
+		return fmt.Sprintf("Fetching %s is not permitted in this channel. Answer with the information already available.", args.URL)
+	}
+
+	// The model picks the URL, and a fetched page can steer it towards internal services
+	check := a.checkToolURL
+	if check == nil {
+		check = policy.CheckPublicURL
+	}
+	if err := check(ctx, args.URL); err != nil {
+		reqmeta.Logf(ctx, "[App] Refused a tool fetch: %v", err)
+		return fmt.Sprintf("Fetching %s is not permitted: only public addresses can be fetched.", args.URL)
+	}
+
+	*budget--
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":loading: Fetching referenced page %s...", args.URL))
+	}
+	log.Printf("[App] Tool fetch %s (%d fetches left)", args.URL, *budget)
+
+	result, err := a.fetcher.Fetch(ctx, fetcher.FetchRequest{URL: args.URL, MaxBytes: toolFetchMaxBytes})
+	if err != nil {
+		return fmt.Sprintf("Error fetching %s: %v", args.URL, err)
+	}
+	*fetched = append(*fetched, fetchedPage{URL: args.URL, Text: result.Text})
+	return result.Text
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// Transcriber converts the speech in an audio or video file to text.
+type Transcriber interface {
+	// Transcribe returns the speech in audio, a file called name whose extension tells its format.
+	Transcribe(ctx context.Context, name string, audio []byte) (string, error)
+}
+
+// SetTranscriber lets page summaries read links to audio and video files, and the episode audio of podcast
+// pages, as transcripts made by t. Files larger than maxBytes are not downloaded. Audio of pages the domain
+// policy keeps on the local model is not transcribed, as t runs elsewhere.
+// It must be called before any request is made.
+func (a *App) SetTranscriber(t Transcriber, maxBytes int64) {
+	a.transcriber = t
+	a.transcriptionMaxBytes = maxBytes
+}
+
+// fetchWithAudio fetches req, reading a link to an audio or video file as its transcript, and adding the
+// transcript of the episode to the text of a podcast page.
+func (a *App) fetchWithAudio(ctx context.Context, model llm.LLM, req fetcher.FetchRequest, progressCallback ProgressCallback) (*fetcher.FetchResult, error) {
+	if a.transcriber == nil || req.HTML != "" || model != a.llm {
+		return a.fetcher.Fetch(ctx, req)
+	}
+	if name := fetcher.MediaFile(req.URL); name != "" {
+		text, err := a.transcribe(ctx, req.URL, name, progressCallback)
+		if err != nil {
+			return nil, err
+		}
+		return &fetcher.FetchResult{Text: text, FinalURL: req.URL, Metadata: fetcher.Metadata{Title: name}}, nil
+	}
+
+	page, err := a.fetcher.Fetch(ctx, req)
+	if err != nil || page.Metadata.Audio == "" {
+		return page, err
+	}
+	name := fetcher.MediaFile(page.Metadata.Audio)
+	if name == "" {
+		return page, nil
+	}
+	if m, err := a.llmFor(page.Metadata.Audio); err != nil || m != a.llm {
+		reqmeta.Logf(ctx, "[App] Not transcribing %s: the domain policy does not allow it", page.Metadata.Audio)
+		return page, nil
+	}
+	text, err := a.transcribe(ctx, page.Metadata.Audio, name, progressCallback)
+	if err != nil {
+		// The show notes can still be summarized
+		reqmeta.Logf(ctx, "[App] Summarizing %s without its episode: %v", req.URL, err)
+		return page, nil
+	}
+	page.Text = strings.TrimSpace(page.Text + "\n\nEpisode transcript:\n" + text)
+	return page, nil
+}
+
+// transcribe downloads the audio or video file at url, called name, and returns its transcript.
+func (a *App) transcribe(ctx context.Context, url, name string, progressCallback ProgressCallback) (string, error) {
+	if progressCallback != nil {
+		progressCallback(fmt.Sprintf(":studio_microphone: Transcribing the audio of %s...", url))
+	}
+	audio, err := fetcher.Download(ctx, url, a.transcriptionMaxBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to transcribe: %w", err)
+	}
+	text, err := a.transcriber.Transcribe(ctx, name, audio)
+	if err != nil {
+		return "", fmt.Errorf("failed to transcribe %s: %w", url, err)
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("failed to transcribe: no speech was recognized")
+	}
+	reqmeta.Logf(ctx, "[App] Transcribed %d bytes of %s into %d characters", len(audio), name, len([]rune(text)))
+	return text, nil
+}
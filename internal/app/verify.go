@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// verificationMaxBytes is how much of the pages the verification call sees.
+const verificationMaxBytes = 60000
+
+// notFoundPrefix marks thread answers the pages do not support.
+const notFoundPrefix = ":warning: *Not found in the provided pages.* The answer below may not be based on the shared content.\n\n"
+
+// verifyAnswer asks model whether answer is supported by the thread prompt and the pages fetched
+// while answering, and prefixes it with a warning when it is not. The same model that answered is
+// used so local-only content never reaches another provider. A failing check leaves answer as is.
+func (a *App) verifyAnswer(ctx context.Context, model llm.LLM, prompt string, fetched []fetchedPage, question, answer string) string {
+	var evidence strings.Builder
+	evidence.WriteString(prompt)
+	for _, page := range fetched {
+		fmt.Fprintf(&evidence, "\nURL: %s\nContent:\n```\n%s\n```\n", page.URL, page.Text)
+	}
+	content := truncateBytes(evidence.String(), verificationMaxBytes)
+
+	messages := []llm.Message{
+		llm.NewTextMessage(llm.RoleSystem, "You are a strict fact checker. Reply with only YES or NO."),
+		llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("Context (may be truncated):\n```\n%s\n```\n\nQuestion: %s\n\nAnswer:\n```\n%s\n```\n\nIs the answer supported by the URL contents in the context?", content, question, answer)),
+	}
+	resp, err := a.call(ctx, model, messages, llm.Options{})
+	if err != nil {
+		log.Printf("[App] Answer verification failed: %v", err)
+		return answer
+	}
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(resp.Text)), "NO") {
+		return notFoundPrefix + answer
+	}
+	return answer
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// leadingTimestampRegex matches a timestamp at the start of a line, optionally bulleted.
+var leadingTimestampRegex = regexp.MustCompile(`(?m)^([-•*]?\s*)((?:\d{1,2}:)?\d{1,2}:\d{2})\b`)
+
+// videoContent formats video metadata and chapters for the prompt, followed by the page text.
+func videoContent(v *fetcher.Video, text string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Title: %s\n", v.Title)
+	if v.Channel != "" {
+		fmt.Fprintf(&b, "Channel: %s\n", v.Channel)
+	}
+	if v.DurationSeconds > 0 {
+		fmt.Fprintf(&b, "Duration: %s\n", fetcher.FormatTimestamp(v.DurationSeconds))
+	}
+	if v.Description != "" {
+		fmt.Fprintf(&b, "\nDescription:\n%s\n", v.Description)
+	}
+	b.WriteString("\nChapters:\n")
+	for _, c := range v.Chapters {
+		fmt.Fprintf(&b, "%s %s\n", fetcher.FormatTimestamp(c.Start), c.Title)
+	}
+	fmt.Fprintf(&b, "\nPage text:\n%s", text)
+	return b.String()
+}
+
+// summarizeVideo produces a chaptered summary and links each chapter timestamp back to the video.
+func (a *App) summarizeVideo(ctx context.Context, model llm.LLM, url string, v *fetcher.Video, text string, userPrompt string) (*llm.Response, error) {
+	resp, err := a.generateStream(ctx, model, localize(ctx, llm.BuildMessages(llm.ModeVideo, videoContent(v, text), userPrompt)), llm.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to process content: %w", err)
+	}
+	resp.Text = linkTimestamps(resp.Text, url, v.Chapters)
+	return resp, nil
+}
+
+// linkTimestamps turns timestamps leading a line into Slack links to that position of the video.
+// Only timestamps of known chapters are linked so that unrelated numbers stay untouched.
+func linkTimestamps(text string, videoURL string, chapters []fetcher.Chapter) string {
+	starts := make(map[int]bool, len(chapters))
+	for _, c := range chapters {
+		starts[c.Start] = true
+	}
+	return leadingTimestampRegex.ReplaceAllStringFunc(text, func(match string) string {
+		m := leadingTimestampRegex.FindStringSubmatch(match)
+		// Look up by seconds so that "0:00" and "00:00" both match
+		seconds, ok := fetcher.ParseTimestamp(m[2])
+		if !ok || !starts[seconds] {
+			return match
+		}
+		return fmt.Sprintf("%s<%s|%s>", m[1], fetcher.TimestampURL(videoURL, seconds), m[2])
+	})
+}
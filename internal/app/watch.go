@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/snapshot"
+	"github.com/kznrluk/describe-kun/internal/textdiff"
+)
+
+// changesMaxBytes caps the diff sent to the model; the context lines around changes are already trimmed.
+const changesMaxBytes = 16000
+
+// Change is a meaningful change detected on a watched page.
+type Change struct {
+	URL         string
+	Since       time.Time // When the previous snapshot was taken
+	Ratio       float64   // Fraction of changed lines, see textdiff.Ratio
+	Description string    // What changed, as bullet points written by the model
+}
+
+// CheckPage fetches url and compares its text with the latest snapshot in store.
+// It returns nil when this is the first snapshot, when the page is unchanged, when less than
+// minChange of its lines changed, or when the model finds the changes cosmetic. Small changes are not
+// saved, so they add up against the previous snapshot until they pass the threshold.
+func (a *App) CheckPage(ctx context.Context, store *snapshot.Store, url string, minChange float64) (*Change, error) {
+	model, err := a.llmFor(url)
+	if err != nil {
+		return nil, err
+	}
+	page, err := a.fetcher.Fetch(ctx, fetcher.FetchRequest{URL: url})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch content: %w", err)
+	}
+	if page.Text == "" {
+		return nil, fetcher.NewPageError(url, page.Diagnostics)
+	}
+	current := snapshot.Snapshot{Time: time.Now(), Content: page.Text}
+
+	previous, err := store.Latest(url)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		log.Printf("[App] Saved the first snapshot of %s", url)
+		return nil, store.Save(url, current)
+	}
+
+	diff := textdiff.Lines(previous.Content, current.Content)
+	ratio := textdiff.Ratio(diff)
+	if !textdiff.Changed(diff) {
+		return nil, nil
+	}
+	if ratio < minChange {
+		log.Printf("[App] Ignoring a %.1f%% change of %s (threshold %.1f%%)", ratio*100, url, minChange*100)
+		return nil, nil
+	}
+
+	formatted := textdiff.Format(textdiff.Trim(diff, 2))
+	if len(formatted) > changesMaxBytes {
+		formatted = truncateBytes(formatted, changesMaxBytes) + "\n(diff truncated)"
+	}
+	// Changes that are not about the screening topics become the new baseline without being described
+	if !a.screen(ctx, "change reports", url, formatted) {
+		return nil, store.Save(url, current)
+	}
+	// Policies and similar pages get the changes that matter to them pointed out
+	resp, err := a.generate(ctx, model, localize(ctx, llm.BuildMessages(llm.ModeChanges, formatted, changesFocusFor(url))), llm.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe changes: %w", err)
+	}
+	// Cosmetic changes become the new baseline so they are not described again
+	if err := store.Save(url, current); err != nil {
+		return nil, err
+	}
+	if strings.Contains(resp.Text, llm.NoChanges) {
+		log.Printf("[App] Changes of %s are not meaningful", url)
+		return nil, nil
+	}
+	return &Change{URL: url, Since: previous.Time, Ratio: ratio, Description: strings.TrimSpace(resp.Text)}, nil
+}
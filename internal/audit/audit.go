@@ -0,0 +1,250 @@
+// Package audit records every prompt sent to an LLM and its completion, with who asked for it, so compliance
+// can review what content left the network.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+// Record is one LLM call, or one request or result of a batch.
+type Record struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Trigger   string    `json:"trigger,omitempty"`
+	Workspace string    `json:"workspace,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+	User      string    `json:"user,omitempty"`
+
+	Model            string     `json:"model,omitempty"`
+	Messages         []Message  `json:"messages,omitempty"`   // The prompt; empty for batch results
+	Completion       string     `json:"completion,omitempty"` // The reply; empty for batch requests and failed calls
+	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
+	PromptTokens     int        `json:"prompt_tokens,omitempty"`
+	CompletionTokens int        `json:"completion_tokens,omitempty"`
+	TotalTokens      int        `json:"total_tokens,omitempty"`
+	Cached           bool       `json:"cached,omitempty"` // Answered from the LLM cache, so nothing was sent
+	Batch            string     `json:"batch,omitempty"`  // Batch ID and request ID of batch calls, "batch/request"
+	Error            string     `json:"error,omitempty"`
+}
+
+// Message is a message of a prompt. Images are recorded by type and size, not content.
+type Message struct {
+	Role       string     `json:"role"`
+	Text       string     `json:"text,omitempty"`
+	Images     []string   `json:"images,omitempty"` // e.g. "image/png, 48213 bytes"
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a tool the model asked to run.
+type ToolCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Sink stores records. Implementations must be safe for concurrent use.
+type Sink interface {
+	Write(ctx context.Context, r Record) error
+}
+
+// Logger records LLM calls in a Sink. A nil Logger records nothing. Records that cannot be written are
+// logged as errors; the calls themselves go ahead.
+type Logger struct {
+	sink Sink
+}
+
+// New creates a Logger writing to sink.
+func New(sink Sink) *Logger {
+	return &Logger{sink: sink}
+}
+
+// Record records a call of model with messages that returned resp or failed with err.
+func (l *Logger) Record(ctx context.Context, model string, messages []llm.Message, resp *llm.Response, err error) {
+	if l == nil {
+		return
+	}
+	r := newRecord(ctx, model)
+	r.Messages = auditMessages(messages)
+	if err != nil {
+		r.Error = err.Error()
+	} else {
+		r.setResponse(resp)
+	}
+	l.write(ctx, r)
+}
+
+// RecordBatch records the requests submitted as batch.
+func (l *Logger) RecordBatch(ctx context.Context, batch string, requests []llm.BatchRequest) {
+	if l == nil {
+		return
+	}
+	for _, req := range requests {
+		r := newRecord(ctx, req.Options.Model)
+		r.Messages = auditMessages(req.Messages)
+		r.Batch = batch + "/" + req.ID
+		l.write(ctx, r)
+	}
+}
+
+// RecordBatchResults records the results of batch by request ID.
+func (l *Logger) RecordBatchResults(ctx context.Context, batch string, results map[string]llm.BatchResult) {
+	if l == nil {
+		return
+	}
+	for id, result := range results {
+		r := newRecord(ctx, "")
+		r.Batch = batch + "/" + id
+		if result.Err != nil {
+			r.Error = result.Err.Error()
+		} else {
+			r.setResponse(result.Response)
+		}
+		l.write(ctx, r)
+	}
+}
+
+func (l *Logger) write(ctx context.Context, r Record) {
+	if err := l.sink.Write(ctx, r); err != nil {
+		reqmeta.Logf(ctx, "[Audit] Failed to record a call to %s: %v", r.Model, err)
+	}
+}
+
+// newRecord starts the record of a call of model made for the request of ctx.
+func newRecord(ctx context.Context, model string) Record {
+	m := reqmeta.From(ctx)
+	return Record{
+		Time:      time.Now(),
+		RequestID: m.RequestID,
+		Source:    m.Source,
+		Trigger:   m.Trigger,
+		Workspace: m.Workspace,
+		Channel:   m.Channel,
+		User:      m.User,
+		Model:     model,
+	}
+}
+
+// setResponse records the completion and usage of resp.
+func (r *Record) setResponse(resp *llm.Response) {
+	if resp.Model != "" {
+		r.Model = resp.Model
+	}
+	r.Completion = resp.Text
+	r.ToolCalls = auditToolCalls(resp.ToolCalls)
+	r.PromptTokens = resp.Usage.PromptTokens
+	r.CompletionTokens = resp.Usage.CompletionTokens
+	r.TotalTokens = resp.Usage.TotalTokens
+	r.Cached = resp.Cached
+}
+
+func auditMessages(messages []llm.Message) []Message {
+	audited := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		a := Message{Role: string(m.Role), Text: m.Text(), ToolCalls: auditToolCalls(m.ToolCalls), ToolCallID: m.ToolCallID}
+		for _, p := range m.Parts {
+			if p.Type == llm.PartImage {
+				a.Images = append(a.Images, fmt.Sprintf("%s, %d bytes", p.MIMEType, len(p.Data)))
+			}
+		}
+		audited = append(audited, a)
+	}
+	return audited
+}
+
+func auditToolCalls(calls []llm.ToolCall) []ToolCall {
+	var audited []ToolCall
+	for _, c := range calls {
+		audited = append(audited, ToolCall{Name: c.Name, Arguments: c.Arguments})
+	}
+	return audited
+}
+
+// Open returns the sink of dest: an HTTP(S) URL records are POSTed to as JSON, or the path of a JSON Lines
+// file they are appended to.
+func Open(dest string) (Sink, error) {
+	if strings.HasPrefix(dest, "http://") || strings.HasPrefix(dest, "https://") {
+		return Webhook{URL: dest}, nil
+	}
+	f, err := os.OpenFile(dest, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log: %w", err)
+	}
+	f.Close()
+	return NewFile(dest), nil
+}
+
+// File appends records to a JSON Lines file, readable only by its owner as prompts hold the pages read.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFile creates a File appending to path.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Write implements Sink.
+func (f *File) Write(ctx context.Context, r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Webhook POSTs each record as JSON to URL, e.g. the HTTP collector of a SIEM.
+type Webhook struct {
+	URL string
+}
+
+// Write implements Sink.
+func (w Webhook) Write(ctx context.Context, r Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	// The record is written even if the request it belongs to was just canceled
+	ctx = context.WithoutCancel(ctx)
+	return retry.Do(ctx, retry.Current(), "Audit webhook", func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return retry.FromResponse(resp, fmt.Errorf("audit webhook returned %s", resp.Status))
+		}
+		return nil
+	})
+}
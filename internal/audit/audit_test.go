@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+func TestLogger_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	l := New(sink)
+	ctx := reqmeta.With(context.Background(), reqmeta.Metadata{RequestID: "req-1", Channel: "C1", User: "U1"})
+
+	messages := []llm.Message{
+		llm.NewTextMessage(llm.RoleSystem, "Summarize."),
+		{Role: llm.RoleUser, Parts: []llm.Part{llm.TextPart("Page text"), llm.ImagePart([]byte("png"), "image/png")}},
+	}
+	l.Record(ctx, "", messages, &llm.Response{Text: "Summary", Model: "gpt-4o", Usage: llm.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}}, nil)
+	l.Record(ctx, "gpt-4o-mini", messages, nil, errors.New("rate limited"))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the audit log to be readable only by its owner, got %v", info.Mode().Perm())
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("Invalid record %s: %v", scanner.Text(), err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	r := records[0]
+	if r.RequestID != "req-1" || r.Channel != "C1" || r.User != "U1" || r.Model != "gpt-4o" || r.Completion != "Summary" || r.TotalTokens != 12 {
+		t.Errorf("Unexpected record %+v", r)
+	}
+	if len(r.Messages) != 2 || r.Messages[1].Text != "Page text" || len(r.Messages[1].Images) != 1 || r.Messages[1].Images[0] != "image/png, 3 bytes" {
+		t.Errorf("Unexpected messages %+v", r.Messages)
+	}
+	if r := records[1]; r.Model != "gpt-4o-mini" || r.Error != "rate limited" || r.Completion != "" {
+		t.Errorf("Unexpected record of the failed call %+v", r)
+	}
+}
+
+func TestLogger_Webhook(t *testing.T) {
+	var got []Record
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var record Record
+		json.NewDecoder(r.Body).Decode(&record)
+		got = append(got, record)
+	}))
+	defer server.Close()
+
+	sink, err := Open(server.URL)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	l := New(sink)
+	ctx := context.Background()
+	l.RecordBatch(ctx, "batch_1", []llm.BatchRequest{{ID: "ep1", Messages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "Show notes")}}})
+	l.RecordBatchResults(ctx, "batch_1", map[string]llm.BatchResult{"ep1": {Response: &llm.Response{Text: "Summary", Model: "gpt-4o"}}})
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(got))
+	}
+	if got[0].Batch != "batch_1/ep1" || len(got[0].Messages) != 1 || got[0].Completion != "" {
+		t.Errorf("Unexpected record of the batch request %+v", got[0])
+	}
+	if got[1].Batch != "batch_1/ep1" || got[1].Completion != "Summary" || got[1].Model != "gpt-4o" {
+		t.Errorf("Unexpected record of the batch result %+v", got[1])
+	}
+
+	// A nil Logger records nothing
+	var none *Logger
+	none.Record(ctx, "", nil, &llm.Response{}, nil)
+}
@@ -0,0 +1,243 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// WarnRatio is the fraction of a limit at which users are warned.
+const WarnRatio = 0.8
+
+// ErrExhausted is returned (wrapped) when a call would exceed a budget.
+var ErrExhausted = errors.New("LLM budget exhausted")
+
+// Limits are token allowances per period. Zero means unlimited.
+type Limits struct {
+	Daily   int64
+	Monthly int64
+}
+
+// Tracker accounts token usage against global and per-scope (e.g. per-channel) limits.
+type Tracker struct {
+	mu       sync.Mutex
+	global   Limits
+	perScope Limits // Applied to every non-empty scope
+	usage    map[string]int64
+	path     string // Optional file the usage is persisted to
+	now      func() time.Time
+}
+
+// NewTracker creates a Tracker. If statePath is set, usage is loaded from and saved to that file
+// so budgets survive restarts.
+func NewTracker(global, perScope Limits, statePath string) (*Tracker, error) {
+	t := &Tracker{
+		global:   global,
+		perScope: perScope,
+		usage:    make(map[string]int64),
+		path:     statePath,
+		now:      time.Now,
+	}
+	if statePath != "" {
+		data, err := os.ReadFile(statePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read budget state: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &t.usage); err != nil {
+				return nil, fmt.Errorf("failed to parse budget state %s: %w", statePath, err)
+			}
+		}
+	}
+	return t, nil
+}
+
+// Enabled reports whether any limit is configured.
+func (t *Tracker) Enabled() bool {
+	return t.global != (Limits{}) || t.perScope != (Limits{})
+}
+
+// check is a limit evaluated for a scope in the current periods.
+type check struct {
+	label string // Human-readable description used in messages
+	key   string
+	limit int64
+}
+
+// checks lists the limits that apply to scope right now.
+func (t *Tracker) checks(scope string) []check {
+	now := t.now()
+	day, month := "d:"+now.Format("2006-01-02"), "m:"+now.Format("2006-01")
+	cs := []check{
+		{"daily global", "|" + day, t.global.Daily},
+		{"monthly global", "|" + month, t.global.Monthly},
+	}
+	if scope != "" {
+		cs = append(cs,
+			check{"daily for " + scope, scope + "|" + day, t.perScope.Daily},
+			check{"monthly for " + scope, scope + "|" + month, t.perScope.Monthly},
+		)
+	}
+	return cs
+}
+
+// Check returns an error wrapping ErrExhausted if any limit for scope is already used up.
+func (t *Tracker) Check(scope string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.checks(scope) {
+		if c.limit > 0 && t.usage[c.key] >= c.limit {
+			return fmt.Errorf("%w: %s limit of %d tokens reached", ErrExhausted, c.label, c.limit)
+		}
+	}
+	return nil
+}
+
+// Record adds tokens used by scope to the current periods.
+func (t *Tracker) Record(scope string, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := make(map[string]bool)
+	for _, c := range t.checks(scope) {
+		t.usage[c.key] += int64(tokens)
+		current[periodOf(c.key)] = true
+	}
+	// Drop counters of past periods so the state doesn't grow forever
+	for k := range t.usage {
+		if !current[periodOf(k)] {
+			delete(t.usage, k)
+		}
+	}
+	t.save()
+}
+
+// Warning returns a message if any limit for scope is at least WarnRatio used, or "" otherwise.
+func (t *Tracker) Warning(scope string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.checks(scope) {
+		if c.limit > 0 && float64(t.usage[c.key]) >= WarnRatio*float64(c.limit) {
+			return fmt.Sprintf(":warning: %d%% of the %s LLM budget (%d tokens) has been used.", t.usage[c.key]*100/c.limit, c.label, c.limit)
+		}
+	}
+	return ""
+}
+
+// Period is the global usage in the current day or month.
+type Period struct {
+	Name    string  // "daily" or "monthly"
+	Used    int64   // Tokens used so far
+	Limit   int64   // Zero means unlimited
+	Elapsed float64 // Fraction of the period that has passed (0-1)
+}
+
+// BurnRate is the fraction of the limit used divided by the fraction of the period passed: above 1,
+// the budget runs out before the period ends. It is 0 without a limit.
+func (p Period) BurnRate() float64 {
+	if p.Limit <= 0 || p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Used) / float64(p.Limit) / p.Elapsed
+}
+
+// Periods returns the global usage of the current day and month.
+func (t *Tracker) Periods() []Period {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	cs := t.checks("")
+	return []Period{
+		{Name: "daily", Used: t.usage[cs[0].key], Limit: cs[0].limit, Elapsed: elapsed(now, day, day.AddDate(0, 0, 1))},
+		{Name: "monthly", Used: t.usage[cs[1].key], Limit: cs[1].limit, Elapsed: elapsed(now, month, month.AddDate(0, 1, 0))},
+	}
+}
+
+// elapsed returns the fraction of the period from start to end that has passed at now.
+func elapsed(now, start, end time.Time) float64 {
+	return float64(now.Sub(start)) / float64(end.Sub(start))
+}
+
+// save writes the usage to the state file. Must be called with mu held.
+func (t *Tracker) save() {
+	if t.path == "" {
+		return
+	}
+	data, err := json.Marshal(t.usage)
+	if err == nil {
+		err = os.WriteFile(t.path, data, 0o644)
+	}
+	if err != nil {
+		log.Printf("[Budget] Failed to save budget state: %v", err)
+	}
+}
+
+// periodOf returns the period part ("d:2006-01-02" or "m:2006-01") of a usage key.
+func periodOf(key string) string {
+	return key[strings.LastIndex(key, "|")+1:]
+}
+
+type scopeKey struct{}
+
+// WithScope returns a context whose LLM calls are accounted to scope (e.g. a Slack channel ID).
+func WithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFrom returns the scope set by WithScope, or "".
+func ScopeFrom(ctx context.Context) string {
+	scope, _ := ctx.Value(scopeKey{}).(string)
+	return scope
+}
+
+// Guard wraps an LLM, refusing calls once a budget is exhausted and recording usage of the rest.
+type Guard struct {
+	llm     llm.LLM
+	tracker *Tracker
+}
+
+// NewGuard creates a Guard around l.
+func NewGuard(l llm.LLM, t *Tracker) *Guard {
+	return &Guard{llm: l, tracker: t}
+}
+
+// Generate checks the budget of the context's scope before dispatching to the wrapped LLM.
+func (g *Guard) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	scope := ScopeFrom(ctx)
+	if err := g.tracker.Check(scope); err != nil {
+		return nil, err
+	}
+	resp, err := g.llm.Generate(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	g.tracker.Record(scope, resp.Usage.TotalTokens)
+	return resp, nil
+}
+
+// GenerateStream is Generate for streamed responses, see llm.Streamer.
+func (g *Guard) GenerateStream(ctx context.Context, messages []llm.Message, opts llm.Options, onText func(delta string)) (*llm.Response, error) {
+	scope := ScopeFrom(ctx)
+	if err := g.tracker.Check(scope); err != nil {
+		return nil, err
+	}
+	resp, err := llm.Stream(ctx, g.llm, messages, opts, onText)
+	if err != nil {
+		return nil, err
+	}
+	g.tracker.Record(scope, resp.Usage.TotalTokens)
+	return resp, nil
+}
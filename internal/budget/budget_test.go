@@ -0,0 +1,109 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+type fixedLLM struct {
+	calls int
+}
+
+func (f *fixedLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	f.calls++
+	return &llm.Response{Text: "ok", Usage: llm.Usage{TotalTokens: 50}}, nil
+}
+
+func TestGuard_PerScopeLimit(t *testing.T) {
+	tracker, _ := NewTracker(Limits{}, Limits{Daily: 100}, "")
+	inner := &fixedLLM{}
+	guard := NewGuard(inner, tracker)
+	ctx := WithScope(context.Background(), "C1")
+
+	for i := 0; i < 2; i++ {
+		if _, err := guard.Generate(ctx, nil, llm.Options{}); err != nil {
+			t.Fatalf("Call %d failed: %v", i+1, err)
+		}
+	}
+	if w := tracker.Warning("C1"); !strings.Contains(w, "100%") {
+		t.Errorf("Expected a 100%% warning, got %q", w)
+	}
+
+	_, err := guard.Generate(ctx, nil, llm.Options{})
+	if !errors.Is(err, ErrExhausted) {
+		t.Fatalf("Expected ErrExhausted, got %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("Exhausted budget must not dispatch the call, got %d calls", inner.calls)
+	}
+
+	// Other channels have their own allowance
+	if _, err := guard.Generate(WithScope(context.Background(), "C2"), nil, llm.Options{}); err != nil {
+		t.Errorf("Expected another channel to be unaffected, got %v", err)
+	}
+}
+
+func TestTracker_WarningThreshold(t *testing.T) {
+	tracker, _ := NewTracker(Limits{Monthly: 1000}, Limits{}, "")
+	tracker.Record("", 700)
+	if w := tracker.Warning(""); w != "" {
+		t.Errorf("Expected no warning at 70%%, got %q", w)
+	}
+	tracker.Record("", 100)
+	if w := tracker.Warning(""); !strings.Contains(w, "monthly global") {
+		t.Errorf("Expected a monthly warning at 80%%, got %q", w)
+	}
+}
+
+func TestTracker_PeriodRollover(t *testing.T) {
+	tracker, _ := NewTracker(Limits{Daily: 100}, Limits{}, "")
+	day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return day }
+	tracker.Record("", 100)
+	if err := tracker.Check(""); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("Expected ErrExhausted, got %v", err)
+	}
+
+	day = day.Add(24 * time.Hour)
+	if err := tracker.Check(""); err != nil {
+		t.Errorf("Expected a fresh daily budget, got %v", err)
+	}
+}
+
+func TestTracker_Periods(t *testing.T) {
+	tracker, _ := NewTracker(Limits{Daily: 100}, Limits{}, "")
+	tracker.now = func() time.Time { return time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC) }
+	tracker.Record("", 50)
+
+	periods := tracker.Periods()
+	if len(periods) != 2 || periods[0].Name != "daily" || periods[0].Used != 50 || periods[0].Elapsed != 0.25 {
+		t.Fatalf("Unexpected periods %+v", periods)
+	}
+	// Half the budget after a quarter of the day runs out by noon
+	if rate := periods[0].BurnRate(); rate != 2 {
+		t.Errorf("Expected a daily burn rate of 2, got %v", rate)
+	}
+	if rate := periods[1].BurnRate(); rate != 0 {
+		t.Errorf("Expected no burn rate without a monthly limit, got %v", rate)
+	}
+}
+
+func TestTracker_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budget.json")
+	tracker, _ := NewTracker(Limits{Daily: 100}, Limits{}, path)
+	tracker.Record("", 100)
+
+	reloaded, err := NewTracker(Limits{Daily: 100}, Limits{}, path)
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	if err := reloaded.Check(""); !errors.Is(err, ErrExhausted) {
+		t.Errorf("Expected usage to survive a restart, got %v", err)
+	}
+}
@@ -0,0 +1,805 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds runtime settings read from the environment.
+type Config struct {
+	// Fetcher selects how pages are fetched: "chrome" (headless browser) or "http" (no browser needed).
+	// Empty uses the binary's default, which is "http" for builds with the nochrome tag.
+	Fetcher string
+
+	// ToolFetchBudget is how many extra pages the LLM may fetch while answering a thread question.
+	// Zero disables tool calling.
+	ToolFetchBudget int
+	// ChannelFetchLimits caps, per channel, the additional pages one request may fetch through tool calls and
+	// crawling.
+	ChannelFetchLimits map[string]int
+	// ChannelFetchDomains restricts, per channel, the domains those additional pages may be on.
+	ChannelFetchDomains map[string][]string
+
+	// VisionModel is the model used to summarize images; empty uses the default model.
+	VisionModel string
+
+	// LLMProvider selects the LLM API: "openai" (default), "anthropic", "gemini", "ollama", "bedrock" or "mock".
+	LLMProvider string
+
+	// FallbackLLMProviders are tried in order when LLMProvider fails its health check or a request.
+	FallbackLLMProviders []string
+
+	// QuickModel is the cheap model used for one-line quick summaries; empty uses the provider's default.
+	QuickModel string
+
+	// ScreeningTopics are the topics pages of automated pipelines must be about to be summarized; empty
+	// disables screening.
+	ScreeningTopics []string
+	// ScreeningModel is the cheap model that screens pages; empty uses the provider's quick model.
+	ScreeningModel string
+
+	// SystemPromptPrefix is an operator instruction (tone, disclaimers, ...) placed before every mode's system prompt.
+	SystemPromptPrefix string
+
+	// SystemPromptPrefixFile holds the prefix instead; edits take effect without a restart.
+	SystemPromptPrefixFile string
+
+	// Footer is a note (disclaimer, policy link, ...) appended to every Slack response.
+	Footer string
+
+	// FooterFile holds translated and per-channel footers as JSON instead, see footer.Load.
+	FooterFile string
+
+	// JobProfile is what job postings are scored against, such as a candidate's skills and wishes; empty disables scoring.
+	JobProfile string
+
+	// JobProfileFile holds the profile instead; edits take effect without a restart.
+	JobProfileFile string
+
+	// JSONRepairAttempts is how many times a malformed JSON reply from the LLM is sent back for repair.
+	JSONRepairAttempts int
+
+	// ChunkThreshold is the length in characters above which a page is summarized in chunks of ChunkSize
+	// characters, whose notes are then summarized. Zero disables chunking.
+	ChunkThreshold int
+	ChunkSize      int
+
+	// Routing picks the model of page summaries by the length of the page.
+	Routing Routing
+
+	// Transcription reads links to audio and video files, and podcast episodes, as transcripts.
+	Transcription Transcription
+
+	// Relevance narrows long pages asked about to the parts relevant to the question.
+	Relevance Relevance
+
+	// ProvenanceSigningKey signs the provenance of summaries with HMAC-SHA256; empty leaves it unsigned.
+	ProvenanceSigningKey string
+
+	// LLMPrices overrides or adds model prices for cost estimates, as "model=input/output" in USD per million tokens.
+	LLMPrices []string
+
+	// HealthCheckInterval is how often the LLM providers and the browser are probed; zero disables the probes.
+	HealthCheckInterval time.Duration
+
+	// Workers is the number of requests processed concurrently by the Slack server.
+	Workers int
+
+	// SlackRateLimit is the number of Slack API calls per minute allowed for each API method; zero disables the limit.
+	SlackRateLimit int
+
+	Timeouts Timeouts
+
+	Retry Retry
+
+	Sampling Sampling
+
+	Cache Cache
+
+	Budget Budget
+
+	Podcast Podcast
+
+	Watch Watch
+
+	Vendors Vendors
+
+	Politeness Politeness
+
+	Thread Thread
+
+	Newsletter Newsletter
+
+	Meetings Meetings
+
+	Alerts Alerts
+
+	Shadow Shadow
+
+	Output Output
+
+	SLO SLO
+
+	// ChannelLanguages lists the output languages (codes such as "ja", "en") of channels that want
+	// something other than the model's default, e.g. both Japanese and English for bilingual teams.
+	ChannelLanguages map[string][]string
+
+	// Features turns experimental capabilities on or off for the whole deployment, by flag name.
+	Features map[string]bool
+
+	// ChannelFeatures overrides Features per channel, so a capability can be tried in one channel first.
+	ChannelFeatures map[string]map[string]bool
+
+	// HistoryFile is where every page summary is recorded as JSON Lines; empty disables the history.
+	HistoryFile string
+	// HistoryContent also records the extracted page text in the history, for `describe-kun replay -cached`.
+	HistoryContent bool
+
+	// AuditLog is where every LLM prompt and completion is recorded: a JSON Lines file, or an HTTP(S) URL
+	// each record is POSTed to. Empty disables the audit log.
+	AuditLog string
+
+	// ReportChannel receives the weekly analytics report; empty disables it.
+	ReportChannel string
+
+	// TriggerPrefix lets messages starting with it (e.g. "!describe") trigger the bot without a mention.
+	// It needs the message events of the channels subscribed; empty disables it.
+	TriggerPrefix string
+
+	// EditDetection offers a new summary when a summarized message is edited to link elsewhere.
+	// It needs the message events of the channels subscribed.
+	EditDetection bool
+
+	// StatusReactions marks mentions with reactions as they are received, processed and answered.
+	// It needs the reactions:write scope.
+	StatusReactions bool
+
+	// SubscriptionsFile is where users' topic subscriptions are persisted; empty keeps them in memory.
+	SubscriptionsFile string
+
+	// ReadingListFile is where the links users saved with a :bookmark: reaction are persisted; empty keeps
+	// them in memory.
+	ReadingListFile string
+
+	// PIDFile is where the Slack server records its process ID; empty writes none.
+	PIDFile string
+
+	// APIToken enables the HTTP API (/api/summarize) for bearer requests carrying it; empty disables the API.
+	// It also guards /statusz, /admin and /metrics, which are only served to loopback clients without it.
+	APIToken string
+}
+
+// Alerts configures keyword alerts.
+type Alerts struct {
+	Channel  string   // Slack channel alerts are cross-posted to; empty disables alerts
+	Keywords []string // Keywords to watch for in summarized content
+}
+
+// SLO configures alerts on the error rate and latency of page summaries. Zero disables a threshold.
+type SLO struct {
+	ErrorRate           float64       // Fraction of failed summaries (0-1)
+	P95Latency          time.Duration // 95th percentile latency of summaries
+	Window              time.Duration // Period the thresholds are evaluated over
+	MinRequests         int           // Summaries needed in the window before alerting
+	SlackWebhookURL     string        // Slack incoming webhook alerts are posted to
+	PagerDutyRoutingKey string        // PagerDuty Events API v2 integration key incidents are opened with
+}
+
+// Output configures the post-processing of page summaries.
+type Output struct {
+	Filters          []string // Filter names, see the output package; empty disables post-processing
+	MaxLength        int      // Characters allowed by the max-length filter
+	RequiredSections []string // Headers checked by the require-sections filter
+}
+
+// Shadow configures shadow mode, where a candidate model or system prompt prefix summarizes a sample
+// of real requests alongside production and only has its output recorded for offline comparison.
+type Shadow struct {
+	Model              string  // Candidate model; empty keeps the production model
+	SystemPromptPrefix string  // Candidate system prompt prefix; empty (without a file) keeps the production one
+	SystemPromptFile   string  // Holds the candidate prefix instead
+	Sample             float64 // Fraction of page summaries (0-1) also sent to the candidate
+	File               string  // Where candidate summaries are recorded as JSON Lines; empty disables shadow mode
+}
+
+// Newsletter configures inbound email summarization.
+type Newsletter struct {
+	Channel string // Slack channel summaries are posted to; empty disables inbound email
+	Token   string // Shared secret inbound email requests must carry
+}
+
+// Meetings configures reading meeting transcripts through the Zoom and Google APIs.
+type Meetings struct {
+	ZoomAccountID    string // Server-to-Server OAuth app of Zoom; empty disables Zoom
+	ZoomClientID     string
+	ZoomClientSecret string
+	GoogleMeet       bool // Read Google Meet transcripts with the application default credentials
+}
+
+// Podcast configures the podcast digest.
+type Podcast struct {
+	Feeds     []string // RSS feed URLs
+	Channel   string   // Slack channel the digest is posted to; empty prints it instead
+	StateFile string   // Where feed validators and polling schedules are kept between runs; empty keeps none
+
+	BatchStateFile string // Where digests submitted as batches are kept until delivered
+}
+
+// Watch configures page change monitoring.
+type Watch struct {
+	URLs        []string // Pages to monitor
+	Channel     string   // Slack channel change reports are posted to; empty prints them instead
+	SnapshotDir string   // Where extracted-content snapshots are kept between runs
+	MinChange   float64  // Fraction of changed lines (0-1) below which a change is ignored as trivial
+}
+
+// Vendors configures the vendor tracker, which posts the changes of vendors' changelog and status pages to Slack.
+type Vendors struct {
+	File        string        // JSON registry of the tracked vendors and their channels; empty disables the tracker
+	Channel     string        // Channel changes of vendors without their own channel are posted to
+	Interval    time.Duration // How often each vendor is checked
+	StateFile   string        // Where the time each vendor was last checked is kept across restarts
+	SnapshotDir string        // Where snapshots of the pages are kept; defaults to WATCH_SNAPSHOT_DIR
+}
+
+// Politeness limits how hard batch commands (eval, watch) fetch from a single site.
+type Politeness struct {
+	PerHost int           // Concurrent fetches per host
+	Delay   time.Duration // Minimum time between fetches from the same host
+}
+
+// Thread limits how much of a long thread is read as context for a thread question.
+type Thread struct {
+	MaxMessages int           // Most recent messages kept besides the parent; zero keeps all
+	MaxAge      time.Duration // Messages older than this are left out, except the parent; zero keeps all
+}
+
+// Budget holds LLM token allowances. Zero means unlimited.
+type Budget struct {
+	DailyTokens          int    // Whole deployment, per day
+	MonthlyTokens        int    // Whole deployment, per month
+	ChannelDailyTokens   int    // Each channel, per day
+	ChannelMonthlyTokens int    // Each channel, per month
+	StateFile            string // Where usage is persisted across restarts; empty keeps it in memory
+}
+
+// Timeouts holds the per-stage limits. Zero disables a limit.
+type Timeouts struct {
+	Navigation time.Duration // Loading a page in the browser
+	Extraction time.Duration // Extracting content from a loaded page
+	LLM        time.Duration // A single LLM call
+	SlackPost  time.Duration // A single Slack post or message update
+	Request    time.Duration // A whole Slack request, from mention to final reply
+}
+
+// Routing sends pages of at most ShortMaxChars characters to ShortModel and pages of at least LongMinChars
+// characters to LongModel. An empty model leaves those pages on the provider's default model.
+type Routing struct {
+	ShortModel    string
+	ShortMaxChars int
+	LongModel     string
+	LongMinChars  int
+}
+
+// Transcription configures transcribing audio with the OpenAI audio API before summarizing it.
+type Transcription struct {
+	Enabled  bool
+	Model    string // Empty uses whisper-1
+	MaxBytes int64  // Largest audio file downloaded
+}
+
+// Relevance configures picking the chunks of long pages relevant to a question by their OpenAI embeddings.
+type Relevance struct {
+	Enabled   bool
+	Model     string // Empty uses text-embedding-3-small
+	Threshold int    // Length in characters above which a page is narrowed
+	ChunkSize int
+	MaxChars  int // Length of the chunks kept
+}
+
+// Sampling configures the sampling parameters of every LLM call. Nil and zero use the provider's defaults.
+type Sampling struct {
+	Temperature     *float64 // From 0 to 2
+	TopP            *float64 // From 0 to 1
+	PresencePenalty *float64 // From -2 to 2
+	MaxTokens       int      // Upper bound of generated tokens
+}
+
+// Cache configures the cache of LLM responses. It is disabled unless Dir or Size is set.
+type Cache struct {
+	Dir  string        // Directory keeping responses across restarts; takes precedence over Size
+	Size int           // Responses kept in memory
+	TTL  time.Duration // How long a response is reused; 0 means until evicted
+}
+
+// Retry configures how failed calls to web pages, LLM providers, Slack and alert webhooks are retried.
+type Retry struct {
+	MaxAttempts int           // Attempts per call, the first included; 1 disables retries
+	MaxElapsed  time.Duration // No retry starts later than this after the first attempt; 0 means no limit
+}
+
+// Load reads the configuration from environment variables, applying defaults for unset values.
+// If CONFIG_FILE is set, variables the environment does not define are read from that file first;
+// calling Load again re-reads it.
+func Load() (*Config, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := applyFile(path); err != nil {
+			return nil, err
+		}
+	}
+	cfg := &Config{}
+
+	var err error
+	if cfg.ToolFetchBudget, err = envInt("TOOL_FETCH_BUDGET", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ChannelFetchLimits, err = envChannelLimits("CHANNEL_FETCH_LIMITS"); err != nil {
+		return nil, err
+	}
+	if cfg.ChannelFetchDomains, err = envChannelDomains("CHANNEL_FETCH_DOMAINS"); err != nil {
+		return nil, err
+	}
+	if cfg.Workers, err = envInt("WORKERS", 4); err != nil {
+		return nil, err
+	}
+	if cfg.Workers == 0 {
+		return nil, fmt.Errorf("WORKERS must be at least 1")
+	}
+	if cfg.SlackRateLimit, err = envInt("SLACK_RATE_LIMIT", 50); err != nil {
+		return nil, err
+	}
+	if cfg.Retry.MaxAttempts, err = envInt("RETRY_MAX_ATTEMPTS", 3); err != nil {
+		return nil, err
+	}
+	if cfg.Retry.MaxAttempts == 0 {
+		return nil, fmt.Errorf("RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.Politeness.PerHost, err = envInt("FETCH_HOST_CONCURRENCY", 1); err != nil {
+		return nil, err
+	}
+	if cfg.Politeness.PerHost == 0 {
+		return nil, fmt.Errorf("FETCH_HOST_CONCURRENCY must be at least 1")
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{"BUDGET_DAILY_TOKENS", &cfg.Budget.DailyTokens},
+		{"BUDGET_MONTHLY_TOKENS", &cfg.Budget.MonthlyTokens},
+		{"BUDGET_CHANNEL_DAILY_TOKENS", &cfg.Budget.ChannelDailyTokens},
+		{"BUDGET_CHANNEL_MONTHLY_TOKENS", &cfg.Budget.ChannelMonthlyTokens},
+	}
+	for _, i := range ints {
+		if *i.dst, err = envInt(i.name, 0); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Thread.MaxMessages, err = envInt("THREAD_MAX_MESSAGES", 200); err != nil {
+		return nil, err
+	}
+	cfg.Budget.StateFile = os.Getenv("BUDGET_STATE_FILE")
+	cfg.Fetcher = os.Getenv("FETCHER")
+	cfg.VisionModel = os.Getenv("VISION_MODEL")
+	cfg.LLMProvider = os.Getenv("LLM_PROVIDER")
+	if cfg.LLMProvider == "" {
+		cfg.LLMProvider = "openai"
+	}
+	if !validProvider(cfg.LLMProvider) {
+		return nil, fmt.Errorf("LLM_PROVIDER must be openai, anthropic, gemini, ollama, bedrock or mock, got %q", cfg.LLMProvider)
+	}
+	cfg.FallbackLLMProviders = envList("FALLBACK_LLM_PROVIDER")
+	for i, name := range cfg.FallbackLLMProviders {
+		if !validProvider(name) {
+			return nil, fmt.Errorf("FALLBACK_LLM_PROVIDER must list openai, anthropic, gemini, ollama, bedrock or mock, got %q", name)
+		}
+		if name == cfg.LLMProvider || slices.Contains(cfg.FallbackLLMProviders[:i], name) {
+			return nil, fmt.Errorf("FALLBACK_LLM_PROVIDER lists %s more than once or as the primary provider", name)
+		}
+	}
+	cfg.QuickModel = os.Getenv("QUICK_MODEL")
+	cfg.ScreeningTopics = envList("SCREENING_TOPICS")
+	cfg.ScreeningModel = os.Getenv("SCREENING_MODEL")
+	cfg.SystemPromptPrefix = os.Getenv("SYSTEM_PROMPT_PREFIX")
+	cfg.SystemPromptPrefixFile = os.Getenv("SYSTEM_PROMPT_PREFIX_FILE")
+	if cfg.SystemPromptPrefix != "" && cfg.SystemPromptPrefixFile != "" {
+		return nil, fmt.Errorf("SYSTEM_PROMPT_PREFIX and SYSTEM_PROMPT_PREFIX_FILE cannot both be set")
+	}
+	cfg.Footer = os.Getenv("FOOTER")
+	cfg.FooterFile = os.Getenv("FOOTER_FILE")
+	if cfg.Footer != "" && cfg.FooterFile != "" {
+		return nil, fmt.Errorf("FOOTER and FOOTER_FILE cannot both be set")
+	}
+	cfg.JobProfile = os.Getenv("JOB_PROFILE")
+	cfg.JobProfileFile = os.Getenv("JOB_PROFILE_FILE")
+	if cfg.JobProfile != "" && cfg.JobProfileFile != "" {
+		return nil, fmt.Errorf("JOB_PROFILE and JOB_PROFILE_FILE cannot both be set")
+	}
+	cfg.Podcast.Feeds = envList("PODCAST_FEEDS")
+	cfg.Podcast.Channel = os.Getenv("PODCAST_DIGEST_CHANNEL")
+	cfg.Podcast.StateFile = os.Getenv("PODCAST_FEED_STATE_FILE")
+	cfg.Podcast.BatchStateFile = os.Getenv("PODCAST_BATCH_STATE_FILE")
+	cfg.Watch.URLs = envList("WATCH_URLS")
+	cfg.Watch.Channel = os.Getenv("WATCH_CHANNEL")
+	cfg.Watch.SnapshotDir = os.Getenv("WATCH_SNAPSHOT_DIR")
+	if cfg.Watch.MinChange, err = envFraction("WATCH_MIN_CHANGE", 0.01); err != nil {
+		return nil, err
+	}
+	cfg.Vendors.File = os.Getenv("VENDORS_FILE")
+	cfg.Vendors.Channel = os.Getenv("VENDOR_CHANNEL")
+	cfg.Vendors.StateFile = os.Getenv("VENDOR_STATE_FILE")
+	cfg.Vendors.SnapshotDir = os.Getenv("VENDOR_SNAPSHOT_DIR")
+	if cfg.Vendors.SnapshotDir == "" {
+		cfg.Vendors.SnapshotDir = cfg.Watch.SnapshotDir
+	}
+	if cfg.Vendors.File != "" && cfg.Vendors.SnapshotDir == "" {
+		return nil, fmt.Errorf("VENDOR_SNAPSHOT_DIR or WATCH_SNAPSHOT_DIR must be set when VENDORS_FILE is set")
+	}
+	cfg.APIToken = os.Getenv("API_TOKEN")
+	cfg.PIDFile = os.Getenv("PID_FILE")
+	cfg.Alerts.Channel = os.Getenv("ALERT_CHANNEL")
+	cfg.Alerts.Keywords = envList("ALERT_KEYWORDS")
+	cfg.SubscriptionsFile = os.Getenv("SUBSCRIPTIONS_FILE")
+	cfg.ReadingListFile = os.Getenv("READING_LIST_FILE")
+	if cfg.ChannelLanguages, err = envChannelLanguages("CHANNEL_LANGUAGES"); err != nil {
+		return nil, err
+	}
+	if cfg.Features, err = envFeatures("FEATURES"); err != nil {
+		return nil, err
+	}
+	if cfg.ChannelFeatures, err = envChannelFeatures("CHANNEL_FEATURES"); err != nil {
+		return nil, err
+	}
+	cfg.HistoryFile = os.Getenv("HISTORY_FILE")
+	if cfg.HistoryContent, err = envBool("HISTORY_CONTENT"); err != nil {
+		return nil, err
+	}
+	cfg.AuditLog = os.Getenv("AUDIT_LOG")
+	cfg.ReportChannel = os.Getenv("REPORT_CHANNEL")
+	cfg.TriggerPrefix = os.Getenv("TRIGGER_PREFIX")
+	if cfg.StatusReactions, err = envBool("STATUS_REACTIONS"); err != nil {
+		return nil, err
+	}
+	if cfg.EditDetection, err = envBool("EDIT_DETECTION"); err != nil {
+		return nil, err
+	}
+	if cfg.ReportChannel != "" && cfg.HistoryFile == "" {
+		return nil, fmt.Errorf("HISTORY_FILE must be set when REPORT_CHANNEL is set")
+	}
+	if cfg.Sampling.Temperature, err = envFloat("LLM_TEMPERATURE", 0, 2); err != nil {
+		return nil, err
+	}
+	if cfg.Sampling.TopP, err = envFloat("LLM_TOP_P", 0, 1); err != nil {
+		return nil, err
+	}
+	if cfg.Sampling.PresencePenalty, err = envFloat("LLM_PRESENCE_PENALTY", -2, 2); err != nil {
+		return nil, err
+	}
+	if cfg.Sampling.MaxTokens, err = envInt("LLM_MAX_TOKENS", 0); err != nil {
+		return nil, err
+	}
+	cfg.Cache.Dir = os.Getenv("LLM_CACHE_DIR")
+	if cfg.Cache.Size, err = envInt("LLM_CACHE_SIZE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.JSONRepairAttempts, err = envInt("JSON_REPAIR_ATTEMPTS", 2); err != nil {
+		return nil, err
+	}
+	if cfg.ChunkThreshold, err = envInt("CHUNK_THRESHOLD", 120000); err != nil {
+		return nil, err
+	}
+	if cfg.ChunkSize, err = envInt("CHUNK_SIZE", 40000); err != nil {
+		return nil, err
+	}
+	if cfg.ChunkThreshold > 0 && cfg.ChunkSize == 0 {
+		return nil, fmt.Errorf("CHUNK_SIZE must be at least 1 when CHUNK_THRESHOLD is set")
+	}
+	cfg.Routing.ShortModel = os.Getenv("SHORT_PAGE_MODEL")
+	cfg.Routing.LongModel = os.Getenv("LONG_PAGE_MODEL")
+	if cfg.Routing.ShortMaxChars, err = envInt("SHORT_PAGE_MAX_CHARS", 4000); err != nil {
+		return nil, err
+	}
+	if cfg.Routing.LongMinChars, err = envInt("LONG_PAGE_MIN_CHARS", 60000); err != nil {
+		return nil, err
+	}
+	if cfg.Routing.ShortModel != "" && cfg.Routing.LongModel != "" && cfg.Routing.ShortMaxChars >= cfg.Routing.LongMinChars {
+		return nil, fmt.Errorf("SHORT_PAGE_MAX_CHARS (%d) must be below LONG_PAGE_MIN_CHARS (%d)", cfg.Routing.ShortMaxChars, cfg.Routing.LongMinChars)
+	}
+	if cfg.Transcription.Enabled, err = envBool("AUDIO_TRANSCRIPTION"); err != nil {
+		return nil, err
+	}
+	cfg.Transcription.Model = os.Getenv("TRANSCRIPTION_MODEL")
+	maxMB, err := envInt("TRANSCRIPTION_MAX_MB", 25)
+	if err != nil {
+		return nil, err
+	}
+	if maxMB == 0 {
+		return nil, fmt.Errorf("TRANSCRIPTION_MAX_MB must be at least 1")
+	}
+	cfg.Transcription.MaxBytes = int64(maxMB) << 20
+	if cfg.Relevance.Enabled, err = envBool("RELEVANCE_FILTER"); err != nil {
+		return nil, err
+	}
+	cfg.Relevance.Model = os.Getenv("EMBEDDING_MODEL")
+	if cfg.Relevance.Threshold, err = envInt("RELEVANCE_THRESHOLD", 40000); err != nil {
+		return nil, err
+	}
+	if cfg.Relevance.ChunkSize, err = envInt("RELEVANCE_CHUNK_SIZE", 2000); err != nil {
+		return nil, err
+	}
+	if cfg.Relevance.MaxChars, err = envInt("RELEVANCE_MAX_CHARS", 20000); err != nil {
+		return nil, err
+	}
+	if cfg.Relevance.Enabled && (cfg.Relevance.ChunkSize == 0 || cfg.Relevance.MaxChars < cfg.Relevance.ChunkSize || cfg.Relevance.MaxChars >= cfg.Relevance.Threshold) {
+		return nil, fmt.Errorf("RELEVANCE_MAX_CHARS (%d) must be between RELEVANCE_CHUNK_SIZE (%d) and RELEVANCE_THRESHOLD (%d)", cfg.Relevance.MaxChars, cfg.Relevance.ChunkSize, cfg.Relevance.Threshold)
+	}
+	cfg.ProvenanceSigningKey = os.Getenv("PROVENANCE_SIGNING_KEY")
+	cfg.LLMPrices = envList("LLM_PRICES")
+	cfg.Output.Filters = envList("OUTPUT_FILTERS")
+	if cfg.Output.MaxLength, err = envInt("OUTPUT_MAX_LENGTH", 3000); err != nil {
+		return nil, err
+	}
+	cfg.Output.RequiredSections = envList("OUTPUT_REQUIRED_SECTIONS")
+	if len(cfg.Output.RequiredSections) == 0 {
+		cfg.Output.RequiredSections = []string{":white_check_mark:", ":memo:"}
+	}
+	cfg.Shadow.Model = os.Getenv("SHADOW_MODEL")
+	cfg.Shadow.SystemPromptPrefix = os.Getenv("SHADOW_SYSTEM_PROMPT_PREFIX")
+	cfg.Shadow.SystemPromptFile = os.Getenv("SHADOW_SYSTEM_PROMPT_PREFIX_FILE")
+	cfg.Shadow.File = os.Getenv("SHADOW_FILE")
+	if cfg.Shadow.Sample, err = envFraction("SHADOW_SAMPLE", 0.1); err != nil {
+		return nil, err
+	}
+	if cfg.Shadow.SystemPromptPrefix != "" && cfg.Shadow.SystemPromptFile != "" {
+		return nil, fmt.Errorf("SHADOW_SYSTEM_PROMPT_PREFIX and SHADOW_SYSTEM_PROMPT_PREFIX_FILE cannot both be set")
+	}
+	if (cfg.Shadow.Model != "" || cfg.Shadow.SystemPromptPrefix != "" || cfg.Shadow.SystemPromptFile != "") && cfg.Shadow.File == "" {
+		return nil, fmt.Errorf("SHADOW_FILE must be set when a shadow model or system prompt prefix is set")
+	}
+	if cfg.SLO.ErrorRate, err = envFraction("SLO_ERROR_RATE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.SLO.MinRequests, err = envInt("SLO_MIN_REQUESTS", 10); err != nil {
+		return nil, err
+	}
+	cfg.SLO.SlackWebhookURL = os.Getenv("SLO_SLACK_WEBHOOK_URL")
+	cfg.SLO.PagerDutyRoutingKey = os.Getenv("SLO_PAGERDUTY_ROUTING_KEY")
+	cfg.Newsletter.Channel = os.Getenv("NEWSLETTER_CHANNEL")
+	cfg.Newsletter.Token = os.Getenv("NEWSLETTER_INBOUND_TOKEN")
+	if cfg.Newsletter.Channel != "" && cfg.Newsletter.Token == "" {
+		return nil, fmt.Errorf("NEWSLETTER_INBOUND_TOKEN must be set when NEWSLETTER_CHANNEL is set")
+	}
+	cfg.Meetings.ZoomAccountID = os.Getenv("ZOOM_ACCOUNT_ID")
+	cfg.Meetings.ZoomClientID = os.Getenv("ZOOM_CLIENT_ID")
+	cfg.Meetings.ZoomClientSecret = os.Getenv("ZOOM_CLIENT_SECRET")
+	if cfg.Meetings.ZoomAccountID != "" && (cfg.Meetings.ZoomClientID == "" || cfg.Meetings.ZoomClientSecret == "") {
+		return nil, fmt.Errorf("ZOOM_CLIENT_ID and ZOOM_CLIENT_SECRET must be set when ZOOM_ACCOUNT_ID is set")
+	}
+	if cfg.Meetings.GoogleMeet, err = envBool("GOOGLE_MEET_TRANSCRIPTS"); err != nil {
+		return nil, err
+	}
+
+	durations := []struct {
+		name string
+		def  time.Duration
+		dst  *time.Duration
+	}{
+		{"NAVIGATION_TIMEOUT", 30 * time.Second, &cfg.Timeouts.Navigation},
+		{"EXTRACTION_TIMEOUT", 20 * time.Second, &cfg.Timeouts.Extraction},
+		{"LLM_TIMEOUT", 2 * time.Minute, &cfg.Timeouts.LLM},
+		{"LLM_CACHE_TTL", 24 * time.Hour, &cfg.Cache.TTL},
+		{"SLACK_POST_TIMEOUT", 10 * time.Second, &cfg.Timeouts.SlackPost},
+		{"REQUEST_TIMEOUT", 5 * time.Minute, &cfg.Timeouts.Request},
+		{"RETRY_MAX_ELAPSED", 30 * time.Second, &cfg.Retry.MaxElapsed},
+		{"FETCH_HOST_DELAY", 2 * time.Second, &cfg.Politeness.Delay},
+		{"THREAD_MAX_AGE", 0, &cfg.Thread.MaxAge},
+		{"VENDOR_INTERVAL", 6 * time.Hour, &cfg.Vendors.Interval},
+		{"HEALTH_CHECK_INTERVAL", time.Minute, &cfg.HealthCheckInterval},
+		{"SLO_P95_LATENCY", 0, &cfg.SLO.P95Latency},
+		{"SLO_WINDOW", 5 * time.Minute, &cfg.SLO.Window},
+	}
+	for _, d := range durations {
+		if *d.dst, err = envDuration(d.name, d.def); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Vendors.Interval == 0 {
+		return nil, fmt.Errorf("VENDOR_INTERVAL must be positive")
+	}
+
+	return cfg, nil
+}
+
+// envInt reads a non-negative integer environment variable, returning def when unset.
+func envInt(name string, def int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", name, v)
+	}
+	return n, nil
+}
+
+// envDuration reads a non-negative duration environment variable (e.g. "30s"), returning def when unset.
+func envDuration(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration such as \"30s\", got %q", name, v)
+	}
+	return d, nil
+}
+
+// envFraction reads a number between 0 and 1 from the environment, returning def when unset.
+func envFraction(name string, def float64) (float64, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f > 1 {
+		return 0, fmt.Errorf("%s must be a number between 0 and 1, got %q", name, v)
+	}
+	return f, nil
+}
+
+// envFloat reads a number between min and max from the environment, returning nil when unset.
+func envFloat(name string, min, max float64) (*float64, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < min || f > max {
+		return nil, fmt.Errorf("%s must be a number between %g and %g, got %q", name, min, max, v)
+	}
+	return &f, nil
+}
+
+// envBool reads a boolean environment variable such as "true" or "1", defaulting to false.
+func envBool(name string) (bool, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", name, v)
+	}
+	return b, nil
+}
+
+// validProvider reports whether name is a supported LLM provider.
+func validProvider(name string) bool {
+	switch name {
+	case "openai", "anthropic", "gemini", "ollama", "bedrock", "mock":
+		return true
+	}
+	return false
+}
+
+// envList reads a comma-separated environment variable, dropping empty entries.
+func envList(name string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// envChannelLanguages reads a list of channel language settings such as "C123=ja+en,C456=en".
+func envChannelLanguages(name string) (map[string][]string, error) {
+	languages := make(map[string][]string)
+	for _, entry := range envList(name) {
+		channel, codes, ok := strings.Cut(entry, "=")
+		channel = strings.TrimSpace(channel)
+		if !ok || channel == "" {
+			return nil, fmt.Errorf("%s entries must look like \"C123=ja+en\", got %q", name, entry)
+		}
+		for _, code := range strings.Split(codes, "+") {
+			if code = strings.TrimSpace(code); code != "" {
+				languages[channel] = append(languages[channel], code)
+			}
+		}
+		if len(languages[channel]) == 0 {
+			return nil, fmt.Errorf("%s entry %q lists no languages", name, entry)
+		}
+	}
+	return languages, nil
+}
+
+// envChannelLimits reads a list of per-channel limits such as "C123=3,C456=0".
+func envChannelLimits(name string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range envList(name) {
+		channel, limit, ok := strings.Cut(entry, "=")
+		channel = strings.TrimSpace(channel)
+		if !ok || channel == "" {
+			return nil, fmt.Errorf("%s entries must look like \"C123=3\", got %q", name, entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s entry %q must set a non-negative integer", name, entry)
+		}
+		limits[channel] = n
+	}
+	return limits, nil
+}
+
+// envChannelDomains reads a list of per-channel domains such as "C123=docs.example.com+github.com,C456=example.com".
+func envChannelDomains(name string) (map[string][]string, error) {
+	domains := make(map[string][]string)
+	for _, entry := range envList(name) {
+		channel, list, ok := strings.Cut(entry, "=")
+		channel = strings.TrimSpace(channel)
+		if !ok || channel == "" {
+			return nil, fmt.Errorf("%s entries must look like \"C123=docs.example.com+github.com\", got %q", name, entry)
+		}
+		for _, domain := range strings.Split(list, "+") {
+			if domain = strings.TrimSpace(domain); domain != "" {
+				domains[channel] = append(domains[channel], domain)
+			}
+		}
+		if len(domains[channel]) == 0 {
+			return nil, fmt.Errorf("%s entry %q lists no domains", name, entry)
+		}
+	}
+	return domains, nil
+}
+
+// envFeatures reads a list of feature flags such as "streaming,-tool-calling", where a leading "-" turns a flag off.
+func envFeatures(name string) (map[string]bool, error) {
+	features := make(map[string]bool)
+	for _, entry := range envList(name) {
+		if err := setFeature(features, entry); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return features, nil
+}
+
+// envChannelFeatures reads per-channel feature flags such as "C123=streaming+-tool-calling,C456=streaming".
+func envChannelFeatures(name string) (map[string]map[string]bool, error) {
+	channels := make(map[string]map[string]bool)
+	for _, entry := range envList(name) {
+		channel, flags, ok := strings.Cut(entry, "=")
+		channel = strings.TrimSpace(channel)
+		if !ok || channel == "" {
+			return nil, fmt.Errorf("%s entries must look like \"C123=streaming+-tool-calling\", got %q", name, entry)
+		}
+		if channels[channel] == nil {
+			channels[channel] = make(map[string]bool)
+		}
+		for _, flag := range strings.Split(flags, "+") {
+			if flag = strings.TrimSpace(flag); flag == "" {
+				continue
+			}
+			if err := setFeature(channels[channel], flag); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return channels, nil
+}
+
+// setFeature records a "name" or "-name" flag setting.
+func setFeature(features map[string]bool, flag string) error {
+	name, off := strings.CutPrefix(flag, "-")
+	if name = strings.TrimSpace(name); name == "" {
+		return fmt.Errorf("empty feature flag in %q", flag)
+	}
+	features[name] = !off
+	return nil
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ToolFetchBudget != 0 {
+		t.Errorf("Expected tool calling disabled by default, got budget %d", cfg.ToolFetchBudget)
+	}
+	if cfg.Timeouts.Navigation != 30*time.Second || cfg.Timeouts.LLM != 2*time.Minute {
+		t.Errorf("Unexpected default timeouts: %+v", cfg.Timeouts)
+	}
+	if cfg.Politeness.PerHost != 1 || cfg.Politeness.Delay != 2*time.Second {
+		t.Errorf("Unexpected default politeness: %+v", cfg.Politeness)
+	}
+	if cfg.ChunkThreshold != 120000 || cfg.ChunkSize != 40000 {
+		t.Errorf("Unexpected default chunking: %d, %d", cfg.ChunkThreshold, cfg.ChunkSize)
+	}
+}
+
+func TestLoad_FromEnv(t *testing.T) {
+	t.Setenv("TOOL_FETCH_BUDGET", "3")
+	t.Setenv("LLM_TIMEOUT", "45s")
+	t.Setenv("SLACK_POST_TIMEOUT", "0")
+	t.Setenv("PODCAST_FEEDS", "https://a.example/feed.xml, ,https://b.example/rss")
+	t.Setenv("HISTORY_CONTENT", "true")
+	t.Setenv("WATCH_MIN_CHANGE", "0.05")
+	t.Setenv("STATUS_REACTIONS", "1")
+	t.Setenv("THREAD_MAX_AGE", "168h")
+	t.Setenv("LLM_TEMPERATURE", "0")
+	t.Setenv("LLM_MAX_TOKENS", "800")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ToolFetchBudget != 3 {
+		t.Errorf("Expected budget 3, got %d", cfg.ToolFetchBudget)
+	}
+	if cfg.Timeouts.LLM != 45*time.Second {
+		t.Errorf("Expected LLM timeout 45s, got %s", cfg.Timeouts.LLM)
+	}
+	if cfg.Timeouts.SlackPost != 0 {
+		t.Errorf("Expected Slack post timeout disabled, got %s", cfg.Timeouts.SlackPost)
+	}
+	if len(cfg.Podcast.Feeds) != 2 || cfg.Podcast.Feeds[1] != "https://b.example/rss" {
+		t.Errorf("Unexpected podcast feeds %q", cfg.Podcast.Feeds)
+	}
+	if !cfg.HistoryContent {
+		t.Error("Expected history content to be enabled")
+	}
+	if !cfg.StatusReactions {
+		t.Error("Expected status reactions to be enabled")
+	}
+	if cfg.Thread.MaxMessages != 200 || cfg.Thread.MaxAge != 168*time.Hour {
+		t.Errorf("Unexpected thread limits: %+v", cfg.Thread)
+	}
+	if s := cfg.Sampling; s.Temperature == nil || *s.Temperature != 0 || s.TopP != nil || s.MaxTokens != 800 {
+		t.Errorf("Unexpected sampling %+v", s)
+	}
+	if cfg.Watch.MinChange != 0.05 {
+		t.Errorf("Expected a minimum change of 0.05, got %v", cfg.Watch.MinChange)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	t.Setenv("NAVIGATION_TIMEOUT", "soon")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for an invalid duration")
+	}
+	t.Setenv("NAVIGATION_TIMEOUT", "")
+	t.Setenv("HISTORY_CONTENT", "sometimes")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for an invalid boolean")
+	}
+	t.Setenv("HISTORY_CONTENT", "")
+	t.Setenv("WATCH_MIN_CHANGE", "5")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a fraction above 1")
+	}
+	t.Setenv("WATCH_MIN_CHANGE", "")
+	t.Setenv("LLM_PRESENCE_PENALTY", "-3")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a presence penalty below -2")
+	}
+}
+
+func TestLoad_NewsletterRequiresToken(t *testing.T) {
+	t.Setenv("NEWSLETTER_CHANNEL", "C123")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error when NEWSLETTER_CHANNEL is set without a token")
+	}
+}
+
+func TestLoad_RoutingThresholds(t *testing.T) {
+	t.Setenv("SHORT_PAGE_MODEL", "gpt-4o-mini")
+	t.Setenv("LONG_PAGE_MODEL", "gpt-4.1")
+	t.Setenv("SHORT_PAGE_MAX_CHARS", "80000")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error when short pages overlap long ones")
+	}
+}
+
+func TestLoad_Transcription(t *testing.T) {
+	t.Setenv("AUDIO_TRANSCRIPTION", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.Transcription.Enabled || cfg.Transcription.MaxBytes != 25<<20 {
+		t.Errorf("Expected transcription of files up to 25 MB, got %+v", cfg.Transcription)
+	}
+	t.Setenv("TRANSCRIPTION_MAX_MB", "0")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a zero file size limit")
+	}
+}
+
+func TestLoad_Relevance(t *testing.T) {
+	t.Setenv("RELEVANCE_FILTER", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Relevance.Threshold != 40000 || cfg.Relevance.ChunkSize != 2000 || cfg.Relevance.MaxChars != 20000 {
+		t.Errorf("Unexpected defaults %+v", cfg.Relevance)
+	}
+	t.Setenv("RELEVANCE_MAX_CHARS", "50000")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error when the kept chunks are longer than the pages narrowed")
+	}
+}
+
+func TestLoad_FallbackProviders(t *testing.T) {
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("FALLBACK_LLM_PROVIDER", "anthropic, bedrock")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := cfg.FallbackLLMProviders; len(got) != 2 || got[0] != "anthropic" || got[1] != "bedrock" {
+		t.Errorf("Unexpected fallback providers %v", got)
+	}
+
+	for _, v := range []string{"anthropic,claude", "openai", "anthropic,anthropic"} {
+		t.Setenv("FALLBACK_LLM_PROVIDER", v)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected an error for FALLBACK_LLM_PROVIDER=%s", v)
+		}
+	}
+}
+
+func TestLoad_ChannelFetchPolicies(t *testing.T) {
+	t.Setenv("CHANNEL_FETCH_LIMITS", "C123=3, C456=0")
+	t.Setenv("CHANNEL_FETCH_DOMAINS", "C123=docs.example.com+github.com")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.ChannelFetchLimits["C123"] != 3 || cfg.ChannelFetchLimits["C456"] != 0 || len(cfg.ChannelFetchLimits) != 2 {
+		t.Errorf("Unexpected limits %v", cfg.ChannelFetchLimits)
+	}
+	if got := cfg.ChannelFetchDomains["C123"]; len(got) != 2 || got[0] != "docs.example.com" || got[1] != "github.com" {
+		t.Errorf("Unexpected domains %v", cfg.ChannelFetchDomains)
+	}
+
+	t.Setenv("CHANNEL_FETCH_LIMITS", "C123=-1")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a negative limit")
+	}
+	t.Setenv("CHANNEL_FETCH_LIMITS", "")
+	t.Setenv("CHANNEL_FETCH_DOMAINS", "C123=")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a channel without domains")
+	}
+}
+
+func TestLoad_SystemPromptPrefixConflict(t *testing.T) {
+	t.Setenv("SYSTEM_PROMPT_PREFIX", "Be polite.")
+	t.Setenv("SYSTEM_PROMPT_PREFIX_FILE", "/etc/describe-kun/persona.txt")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error when both prefix settings are set")
+	}
+}
+
+func TestLoad_ShadowRequiresFile(t *testing.T) {
+	t.Setenv("SHADOW_MODEL", "gpt-4.1")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error when SHADOW_MODEL is set without SHADOW_FILE")
+	}
+	t.Setenv("SHADOW_FILE", "/var/lib/describe-kun/shadow.jsonl")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Shadow.Sample != 0.1 {
+		t.Errorf("Expected the default sample of 0.1, got %v", cfg.Shadow.Sample)
+	}
+}
+
+func TestLoad_VendorsRequireSnapshots(t *testing.T) {
+	t.Setenv("VENDORS_FILE", "/etc/describe-kun/vendors.json")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error when VENDORS_FILE is set without a snapshot directory")
+	}
+	t.Setenv("WATCH_SNAPSHOT_DIR", "/var/lib/describe-kun/snapshots")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Vendors.SnapshotDir != "/var/lib/describe-kun/snapshots" || cfg.Vendors.Interval != 6*time.Hour {
+		t.Errorf("Expected the watch snapshots and a 6h interval by default, got %+v", cfg.Vendors)
+	}
+	t.Setenv("VENDOR_INTERVAL", "0")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
+
+func TestLoad_ChannelLanguages(t *testing.T) {
+	t.Setenv("CHANNEL_LANGUAGES", "C123=ja+en, C456 = en")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := cfg.ChannelLanguages["C123"]; len(got) != 2 || got[0] != "ja" || got[1] != "en" {
+		t.Errorf("Unexpected languages for C123: %q", got)
+	}
+	if got := cfg.ChannelLanguages["C456"]; len(got) != 1 || got[0] != "en" {
+		t.Errorf("Unexpected languages for C456: %q", got)
+	}
+
+	t.Setenv("CHANNEL_LANGUAGES", "C123")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for an entry without languages")
+	}
+}
+
+func TestLoad_Features(t *testing.T) {
+	t.Setenv("FEATURES", "streaming, -tool-calling")
+	t.Setenv("CHANNEL_FEATURES", "C123=tool-calling+-streaming, C456=vision-fallback")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !cfg.Features["streaming"] || cfg.Features["tool-calling"] || len(cfg.Features) != 2 {
+		t.Errorf("Unexpected features %v", cfg.Features)
+	}
+	if c := cfg.ChannelFeatures["C123"]; !c["tool-calling"] || c["streaming"] || len(c) != 2 {
+		t.Errorf("Unexpected overrides for C123: %v", c)
+	}
+	if !cfg.ChannelFeatures["C456"]["vision-fallback"] {
+		t.Errorf("Unexpected overrides for C456: %v", cfg.ChannelFeatures["C456"])
+	}
+
+	t.Setenv("CHANNEL_FEATURES", "streaming")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for an entry without a channel")
+	}
+}
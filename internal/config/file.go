@@ -0,0 +1,73 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	fileMu   sync.Mutex
+	fileKeys = make(map[string]bool) // Variables currently set from the config file
+)
+
+// applyFile sets the variables defined in the config file at path (KEY=VALUE lines, "#" comments)
+// that the process environment does not already define, so the environment always wins.
+// Variables set by a previous call are updated or cleared, so calling it again picks up edits.
+func applyFile(path string) error {
+	vars, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	for key := range fileKeys {
+		if _, ok := vars[key]; !ok {
+			os.Unsetenv(key)
+			delete(fileKeys, key)
+		}
+	}
+	for key, value := range vars {
+		if _, set := os.LookupEnv(key); set && !fileKeys[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		fileKeys[key] = true
+	}
+	return nil
+}
+
+// readFile parses a KEY=VALUE config file. Values may be wrapped in single or double quotes.
+func readFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE, got %q", path, n, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	return vars, nil
+}
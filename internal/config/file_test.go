@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "describe-kun.env")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("# Deployment settings\nexport ALERT_KEYWORDS=\"describe-kun, competitor\"\nALERT_CHANNEL=CFILE\nTOOL_FETCH_BUDGET=2\n")
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("ALERT_CHANNEL", "CENV")
+	// Variables the file sets are cleaned up after the test
+	t.Setenv("ALERT_KEYWORDS", "")
+	os.Unsetenv("ALERT_KEYWORDS")
+	t.Setenv("TOOL_FETCH_BUDGET", "")
+	os.Unsetenv("TOOL_FETCH_BUDGET")
+	t.Cleanup(func() { fileKeys = make(map[string]bool) })
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Alerts.Channel != "CENV" {
+		t.Errorf("Expected the environment to win, got %q", cfg.Alerts.Channel)
+	}
+	if len(cfg.Alerts.Keywords) != 2 || cfg.Alerts.Keywords[1] != "competitor" || cfg.ToolFetchBudget != 2 {
+		t.Errorf("Expected values from the file, got %q and %d", cfg.Alerts.Keywords, cfg.ToolFetchBudget)
+	}
+
+	// Loading again picks up edits and removals
+	write("ALERT_KEYWORDS=other\n")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(cfg.Alerts.Keywords) != 1 || cfg.Alerts.Keywords[0] != "other" || cfg.ToolFetchBudget != 0 {
+		t.Errorf("Expected the edited file to apply, got %q and %d", cfg.Alerts.Keywords, cfg.ToolFetchBudget)
+	}
+
+	write("not a setting\n")
+	if _, err := Load(); err == nil {
+		t.Error("Expected an error for a malformed line")
+	}
+}
@@ -0,0 +1,304 @@
+// Package cost estimates what LLM calls cost and totals the usage per day, channel, user and model.
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// retainDays is how many days of totals a Meter keeps.
+const retainDays = 31
+
+// Price is what a model charges in USD per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// defaultPrices lists the list prices of known models by name prefix, as compared after llm.BaseModel.
+// More specific prefixes come first. Models not listed, such as local ones, are estimated at zero.
+var defaultPrices = []struct {
+	prefix string
+	price  Price
+}{
+	{"gpt-4.1-nano", Price{0.10, 0.40}},
+	{"gpt-4.1-mini", Price{0.40, 1.60}},
+	{"gpt-4.1", Price{2.00, 8.00}},
+	{"gpt-4o-mini", Price{0.15, 0.60}},
+	{"gpt-4o", Price{2.50, 10.00}},
+	{"chatgpt-4o", Price{5.00, 15.00}},
+	{"gpt-4-turbo", Price{10.00, 30.00}},
+	{"gpt-3.5-turbo", Price{0.50, 1.50}},
+	{"gpt-5-nano", Price{0.05, 0.40}},
+	{"gpt-5-mini", Price{0.25, 2.00}},
+	{"gpt-5", Price{1.25, 10.00}},
+	{"o1-mini", Price{1.10, 4.40}},
+	{"o1", Price{15.00, 60.00}},
+	{"o3-mini", Price{1.10, 4.40}},
+	{"o3", Price{2.00, 8.00}},
+	{"o4-mini", Price{1.10, 4.40}},
+	{"claude-opus-4-5", Price{5.00, 25.00}},
+	{"claude-opus-4", Price{15.00, 75.00}},
+	{"claude-sonnet-4", Price{3.00, 15.00}},
+	{"claude-3-7-sonnet", Price{3.00, 15.00}},
+	{"claude-3-5-sonnet", Price{3.00, 15.00}},
+	{"claude-haiku-4-5", Price{1.00, 5.00}},
+	{"claude-3-5-haiku", Price{0.80, 4.00}},
+	{"claude-3-haiku", Price{0.25, 1.25}},
+	{"gemini-2.5-pro", Price{1.25, 10.00}},
+	{"gemini-2.5-flash-lite", Price{0.10, 0.40}},
+	{"gemini-2.5-flash", Price{0.30, 2.50}},
+	{"gemini-2.0-flash-lite", Price{0.075, 0.30}},
+	{"gemini-2.0-flash", Price{0.10, 0.40}},
+	{"amazon.nova-micro", Price{0.035, 0.14}},
+	{"amazon.nova-lite", Price{0.06, 0.24}},
+	{"amazon.nova-pro", Price{0.80, 3.20}},
+}
+
+var (
+	mu        sync.RWMutex
+	overrides map[string]Price // By lower-case model name, from SetPrices
+)
+
+// ParsePrices parses prices given as "model=input/output" in USD per million tokens, e.g. "gpt-4o=2.5/10".
+func ParsePrices(specs []string) (map[string]Price, error) {
+	prices := make(map[string]Price, len(specs))
+	for _, spec := range specs {
+		model, rates, ok := strings.Cut(spec, "=")
+		input, output, ok2 := strings.Cut(rates, "/")
+		if !ok || !ok2 || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("invalid price %q, want model=input/output", spec)
+		}
+		var p Price
+		var err error
+		if p.Input, err = strconv.ParseFloat(strings.TrimSpace(input), 64); err != nil || p.Input < 0 {
+			return nil, fmt.Errorf("invalid input price in %q", spec)
+		}
+		if p.Output, err = strconv.ParseFloat(strings.TrimSpace(output), 64); err != nil || p.Output < 0 {
+			return nil, fmt.Errorf("invalid output price in %q", spec)
+		}
+		prices[llm.BaseModel(strings.TrimSpace(model))] = p
+	}
+	return prices, nil
+}
+
+// SetPrices makes Estimate use prices, by model name prefix, before the built-in list; see ParsePrices.
+// It is safe to call while calls are being estimated.
+func SetPrices(prices map[string]Price) {
+	mu.Lock()
+	defer mu.Unlock()
+	overrides = prices
+}
+
+// PriceOf returns the price of model and whether it is known.
+func PriceOf(model string) (Price, bool) {
+	name := llm.BaseModel(model)
+	mu.RLock()
+	// The longest matching override wins, as in the built-in list
+	best, found := "", false
+	for prefix := range overrides {
+		if strings.HasPrefix(name, prefix) && len(prefix) >= len(best) {
+			best, found = prefix, true
+		}
+	}
+	p := overrides[best]
+	mu.RUnlock()
+	if found {
+		return p, true
+	}
+	for _, d := range defaultPrices {
+		if strings.HasPrefix(name, d.prefix) {
+			return d.price, true
+		}
+	}
+	return Price{}, false
+}
+
+// Estimate returns what usage of model costs in USD, 0 for models of unknown price.
+func Estimate(model string, usage llm.Usage) float64 {
+	p, _ := PriceOf(model)
+	return (float64(usage.PromptTokens)*p.Input + float64(usage.CompletionTokens)*p.Output) / 1e6
+}
+
+// Key identifies a group of calls a Meter totals.
+type Key struct {
+	Day     string `json:"day"` // "2006-01-02", local time
+	Channel string `json:"channel,omitempty"`
+	User    string `json:"user,omitempty"`
+	Model   string `json:"model,omitempty"`
+}
+
+// Totals is the usage and estimated cost of a group of calls.
+type Totals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// add adds one call to t.
+func (t *Totals) add(usage llm.Usage, cost float64) {
+	t.Calls++
+	t.PromptTokens += int64(usage.PromptTokens)
+	t.CompletionTokens += int64(usage.CompletionTokens)
+	t.CostUSD += cost
+}
+
+// Row is the totals of one group.
+type Row struct {
+	Key
+	Totals
+}
+
+// Meter logs every LLM call with its estimated cost and totals them per day, channel, user and model,
+// as well as per model since start for counters. A nil Meter records nothing.
+type Meter struct {
+	mu      sync.Mutex
+	daily   map[Key]*Totals
+	byModel map[string]*Totals // Since start, for Prometheus counters
+	now     func() time.Time
+}
+
+// NewMeter creates an empty Meter.
+func NewMeter() *Meter {
+	return &Meter{daily: make(map[Key]*Totals), byModel: make(map[string]*Totals), now: time.Now}
+}
+
+// Record accounts a call of model with usage to the channel and user of ctx, logs it and returns its estimated cost.
+func (m *Meter) Record(ctx context.Context, model string, usage llm.Usage) float64 {
+	if m == nil {
+		return 0
+	}
+	if model == "" {
+		model = "unknown"
+	}
+	cost := Estimate(model, usage)
+	reqmeta.Logf(ctx, "[Cost] %s: %d prompt + %d completion tokens, $%.4f", model, usage.PromptTokens, usage.CompletionTokens, cost)
+
+	meta := reqmeta.From(ctx)
+	now := m.now()
+	k := Key{Day: now.Format("2006-01-02"), Channel: meta.Channel, User: meta.User, Model: model}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.daily[k] == nil {
+		m.daily[k] = &Totals{}
+		// Days before the retention period are dropped when a new group starts
+		oldest := now.AddDate(0, 0, -retainDays).Format("2006-01-02")
+		for old := range m.daily {
+			if old.Day < oldest {
+				delete(m.daily, old)
+			}
+		}
+	}
+	m.daily[k].add(usage, cost)
+	if m.byModel[model] == nil {
+		m.byModel[model] = &Totals{}
+	}
+	m.byModel[model].add(usage, cost)
+	return cost
+}
+
+// Rows returns the totals of day ("2006-01-02"), grouped by the fields of by ("channel", "user",
+// "model"; none totals the day) and sorted by cost, highest first.
+func (m *Meter) Rows(day string, by []string) []Row {
+	group := make(map[string]bool, len(by))
+	for _, f := range by {
+		group[f] = true
+	}
+	m.mu.Lock()
+	grouped := make(map[Key]*Totals)
+	for k, t := range m.daily {
+		if k.Day != day {
+			continue
+		}
+		g := Key{Day: day}
+		if group["channel"] {
+			g.Channel = k.Channel
+		}
+		if group["user"] {
+			g.User = k.User
+		}
+		if group["model"] {
+			g.Model = k.Model
+		}
+		if grouped[g] == nil {
+			grouped[g] = &Totals{}
+		}
+		grouped[g].Calls += t.Calls
+		grouped[g].PromptTokens += t.PromptTokens
+		grouped[g].CompletionTokens += t.CompletionTokens
+		grouped[g].CostUSD += t.CostUSD
+	}
+	m.mu.Unlock()
+
+	rows := make([]Row, 0, len(grouped))
+	for k, t := range grouped {
+		rows = append(rows, Row{Key: k, Totals: *t})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].CostUSD != rows[j].CostUSD {
+			return rows[i].CostUSD > rows[j].CostUSD
+		}
+		a, b := rows[i].Key, rows[j].Key
+		return a.Channel+"|"+a.User+"|"+a.Model < b.Channel+"|"+b.User+"|"+b.Model
+	})
+	return rows
+}
+
+// Handler serves the totals of a day as JSON: "?day=2006-01-02" (default today) and
+// "?by=channel,user,model" (default all three).
+func (m *Meter) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		day := r.URL.Query().Get("day")
+		if day == "" {
+			day = m.now().Format("2006-01-02")
+		} else if _, err := time.Parse("2006-01-02", day); err != nil {
+			http.Error(w, "day must be formatted as 2006-01-02", http.StatusBadRequest)
+			return
+		}
+		by := []string{"channel", "user", "model"}
+		if s := r.URL.Query().Get("by"); s != "" {
+			by = strings.Split(s, ",")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(m.Rows(day, by)); err != nil {
+			log.Printf("[Cost] Error writing usage: %v", err)
+		}
+	}
+}
+
+// Write writes the token and cost counters per model since start in the Prometheus text exposition format.
+func (m *Meter) Write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	models := make([]string, 0, len(m.byModel))
+	for model := range m.byModel {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	fmt.Fprintln(w, "# HELP describe_kun_llm_tokens_total LLM tokens used, by model and type.")
+	fmt.Fprintln(w, "# TYPE describe_kun_llm_tokens_total counter")
+	for _, model := range models {
+		t := m.byModel[model]
+		fmt.Fprintf(w, "describe_kun_llm_tokens_total{model=%q,type=\"prompt\"} %d\n", model, t.PromptTokens)
+		fmt.Fprintf(w, "describe_kun_llm_tokens_total{model=%q,type=\"completion\"} %d\n", model, t.CompletionTokens)
+	}
+	fmt.Fprintln(w, "# HELP describe_kun_llm_cost_usd_total Estimated LLM cost in USD, by model.")
+	fmt.Fprintln(w, "# TYPE describe_kun_llm_cost_usd_total counter")
+	for _, model := range models {
+		fmt.Fprintf(w, "describe_kun_llm_cost_usd_total{model=%q} %g\n", model, m.byModel[model].CostUSD)
+	}
+}
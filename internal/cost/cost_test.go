@@ -0,0 +1,90 @@
+package cost
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+func TestEstimate(t *testing.T) {
+	usage := llm.Usage{PromptTokens: 1_000_000, CompletionTokens: 100_000}
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"gpt-4o-2024-08-06", 2.5 + 1.0},
+		{"gpt-4o-mini", 0.15 + 0.06},
+		{"global.anthropic.claude-sonnet-4-5-20250929-v1:0", 3 + 1.5},
+		{"llama3.1", 0},
+	}
+	for _, tt := range tests {
+		if got := Estimate(tt.model, usage); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Estimate(%q) = %g, want %g", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestSetPrices(t *testing.T) {
+	defer SetPrices(nil)
+	if _, err := ParsePrices([]string{"gpt-4o=cheap"}); err == nil {
+		t.Error("Expected an error for a malformed price")
+	}
+	prices, err := ParsePrices([]string{"llama3=0.1/0.2", "GPT-4o=1/2"})
+	if err != nil {
+		t.Fatalf("ParsePrices failed: %v", err)
+	}
+	SetPrices(prices)
+	if p, ok := PriceOf("llama3.1:8b"); !ok || p != (Price{0.1, 0.2}) {
+		t.Errorf("Expected the added price, got %+v, %v", p, ok)
+	}
+	if p, _ := PriceOf("gpt-4o-mini"); p != (Price{1, 2}) {
+		t.Errorf("Expected the override to win over the built-in price, got %+v", p)
+	}
+}
+
+func TestMeter(t *testing.T) {
+	m := NewMeter()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	c1 := reqmeta.With(context.Background(), reqmeta.Metadata{Channel: "C1", User: "U1"})
+	c2 := reqmeta.With(context.Background(), reqmeta.Metadata{Channel: "C2", User: "U1"})
+	m.Record(c1, "gpt-4o", llm.Usage{PromptTokens: 1000, CompletionTokens: 100})
+	m.Record(c1, "gpt-4o", llm.Usage{PromptTokens: 1000, CompletionTokens: 100})
+	m.Record(c2, "gpt-4o-mini", llm.Usage{PromptTokens: 1000, CompletionTokens: 100})
+	now = now.AddDate(0, 0, 1)
+	m.Record(c2, "gpt-4o", llm.Usage{PromptTokens: 1000})
+
+	rows := m.Rows("2026-10-16", []string{"channel"})
+	if len(rows) != 2 || rows[0].Channel != "C1" || rows[0].Calls != 2 || rows[0].PromptTokens != 2000 {
+		t.Fatalf("Expected C1 first with two calls, got %+v", rows)
+	}
+	if math.Abs(rows[0].CostUSD-0.007) > 1e-9 {
+		t.Errorf("Expected $0.007 for C1, got %g", rows[0].CostUSD)
+	}
+	if rows := m.Rows("2026-10-16", nil); len(rows) != 1 || rows[0].Calls != 3 {
+		t.Errorf("Expected one total of the day, got %+v", rows)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler()(rec, httptest.NewRequest(http.MethodGet, "/?by=user", nil))
+	if !strings.Contains(rec.Body.String(), `"day":"2026-10-17","user":"U1"`) {
+		t.Errorf("Expected today's usage by user, got %s", rec.Body)
+	}
+
+	var b bytes.Buffer
+	m.Write(&b)
+	for _, want := range []string{`describe_kun_llm_tokens_total{model="gpt-4o",type="prompt"} 3000`, `describe_kun_llm_cost_usd_total{model="gpt-4o-mini"}`} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/health"
+	"github.com/kznrluk/describe-kun/internal/queue"
+)
+
+func TestPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "describe-kun.pid")
+	if _, err := ReadPIDFile(path); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning without a file, got %v", err)
+	}
+
+	remove, err := WritePIDFile(path)
+	if err != nil {
+		t.Fatalf("WritePIDFile failed: %v", err)
+	}
+	if pid, err := ReadPIDFile(path); err != nil || pid != os.Getpid() {
+		t.Errorf("Expected our pid, got %d, %v", pid, err)
+	}
+	if _, err := WritePIDFile(path); err == nil {
+		t.Error("Expected a second instance to be refused")
+	}
+	remove()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the pid file to be removed")
+	}
+
+	// A stale file from a crashed process is taken over
+	os.WriteFile(path, []byte("999999999\n"), 0o644)
+	if _, err := ReadPIDFile(path); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected a stale pid file to report ErrNotRunning, got %v", err)
+	}
+	if _, err := WritePIDFile(path); err != nil {
+		t.Errorf("Expected a stale pid file to be replaced, got %v", err)
+	}
+	os.WriteFile(path, []byte("garbage"), 0o644)
+	if _, err := ReadPIDFile(path); err == nil || errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected a malformed pid file error, got %v", err)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	if err := Notify(Ready); err != nil {
+		t.Errorf("Expected no-op without NOTIFY_SOCKET, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	if err := Notify(Ready, StatusNotification("Serving on port %d", 8080)); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Reading notification failed: %v", err)
+	}
+	if got := string(buf[:n]); got != "READY=1\nSTATUS=Serving on port 8080" {
+		t.Errorf("Unexpected notification %q", got)
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	jobs := queue.New(3)
+	defer jobs.Close()
+	started := time.Now().Add(-time.Hour)
+	monitor := health.NewMonitor(time.Second, health.Probe{Name: "openai", Check: func(ctx context.Context) error { return errors.New("status 503") }})
+	monitor.Check(context.Background())
+	h := StatusHandler(started, jobs, func(ctx context.Context) error { return errors.New("browser is not responding") }, monitor)
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+	s, err := FetchStatus(context.Background(), server.URL+"/status")
+	if err != nil {
+		t.Fatalf("FetchStatus failed: %v", err)
+	}
+	if s.PID != os.Getpid() || s.Queue.Workers != 3 || s.Healthy() {
+		t.Errorf("Unexpected status %+v", s)
+	}
+	out := s.Format(started.Add(90 * time.Minute))
+	for _, want := range []string{"pid " + strconv.Itoa(os.Getpid()), "up 1h30m0s", "0 waiting, 0 running (3 workers)", "Browser: browser is not responding", "openai: failing since"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in %q", want, out)
+		}
+	}
+
+	// Remote clients are not answered
+	r := httptest.NewRequest(http.MethodGet, "/status", nil)
+	r.RemoteAddr = "203.0.113.5:1234"
+	w := httptest.NewRecorder()
+	h(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected remote clients to get 404, got %d", w.Code)
+	}
+}
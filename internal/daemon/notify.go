@@ -0,0 +1,42 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Notification states understood by systemd (see sd_notify(3)).
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+)
+
+// Notify sends state to the service manager over the NOTIFY_SOCKET datagram socket, so that
+// systemd units with Type=notify know when the server is ready or stopping. Without
+// NOTIFY_SOCKET (not started by systemd) it does nothing.
+func Notify(state ...string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		// Abstract socket namespace
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("connecting to the service manager: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(strings.Join(state, "\n"))); err != nil {
+		return fmt.Errorf("notifying the service manager: %w", err)
+	}
+	return nil
+}
+
+// StatusNotification returns a STATUS= notification, a one-line description shown by systemctl status.
+func StatusNotification(format string, args ...any) string {
+	return "STATUS=" + strings.ReplaceAll(fmt.Sprintf(format, args...), "\n", " ")
+}
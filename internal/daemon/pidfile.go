@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNotRunning is returned by ReadPIDFile when no live process owns the PID file.
+var ErrNotRunning = errors.New("not running")
+
+// WritePIDFile records the current process ID in path and returns a function removing it again.
+// It refuses to overwrite the PID file of another process that is still alive.
+func WritePIDFile(path string) (func(), error) {
+	if pid, err := ReadPIDFile(path); err == nil {
+		return nil, fmt.Errorf("already running with pid %d (%s)", pid, path)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("writing pid file: %w", err)
+	}
+	return func() {
+		// Only remove the file if it still names this process
+		if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) == strconv.Itoa(os.Getpid()) {
+			os.Remove(path)
+		}
+	}, nil
+}
+
+// ReadPIDFile returns the process ID recorded in path. It returns ErrNotRunning when the file
+// is missing or names a process that no longer exists (a stale file left by a crash).
+func ReadPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNotRunning
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading pid file: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("pid file %s is malformed: %q", path, strings.TrimSpace(string(data)))
+	}
+	if !processAlive(pid) {
+		return pid, ErrNotRunning
+	}
+	return pid, nil
+}
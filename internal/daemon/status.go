@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/health"
+	"github.com/kznrluk/describe-kun/internal/queue"
+)
+
+// browserCheckTimeout bounds the browser health check made for each status request.
+const browserCheckTimeout = 5 * time.Second
+
+// Status is the state of a running server, served on /status and printed by -status.
+type Status struct {
+	PID       int         `json:"pid"`
+	StartedAt time.Time   `json:"started_at"`
+	Queue     queue.Stats `json:"queue"`
+	Browser   string      `json:"browser"` // "ok", or why the fetcher cannot serve requests
+
+	Dependencies []health.Result `json:"dependencies,omitempty"` // Latest periodic checks of the LLM providers and browser
+}
+
+// Format renders s for humans.
+func (s Status) Format(now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "describe-kun-slack is running (pid %d, up %s)\n", s.PID, now.Sub(s.StartedAt).Round(time.Second))
+	fmt.Fprintf(&b, "Queue: %d waiting, %d running (%d workers)\n", s.Queue.Pending, s.Queue.Running, s.Queue.Workers)
+	fmt.Fprintf(&b, "Browser: %s\n", s.Browser)
+	for _, d := range s.Dependencies {
+		if d.Healthy {
+			fmt.Fprintf(&b, "%s: ok (%dms)\n", d.Name, d.LatencyMS)
+		} else {
+			fmt.Fprintf(&b, "%s: failing since %s: %s\n", d.Name, d.Since.Format(time.RFC3339), d.Error)
+		}
+	}
+	return b.String()
+}
+
+// Healthy reports whether the server can serve requests with all of its dependencies.
+func (s Status) Healthy() bool {
+	for _, d := range s.Dependencies {
+		if !d.Healthy {
+			return false
+		}
+	}
+	return s.Browser == "ok"
+}
+
+// StatusHandler serves the server's status as JSON. check reports the fetcher's health and monitor,
+// which may be nil, the latest dependency checks. Only loopback clients are answered, since the status reveals internals.
+func StatusHandler(startedAt time.Time, jobs *queue.Queue, check func(ctx context.Context) error, monitor *health.Monitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			http.NotFound(w, r)
+			return
+		}
+
+		s := Status{PID: os.Getpid(), StartedAt: startedAt, Queue: jobs.Stats(), Browser: "ok", Dependencies: monitor.Results()}
+		ctx, cancel := context.WithTimeout(r.Context(), browserCheckTimeout)
+		defer cancel()
+		if err := check(ctx); err != nil {
+			s.Browser = err.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s); err != nil {
+			log.Printf("[Daemon] Error writing status: %v", err)
+		}
+	}
+}
+
+// FetchStatus requests the status of the server listening at url (e.g. "http://127.0.0.1:8080/status").
+func FetchStatus(ctx context.Context, url string) (*Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting status: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requesting status: server returned %s", resp.Status)
+	}
+	var s Status
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return nil, fmt.Errorf("decoding status: %w", err)
+	}
+	return &s, nil
+}
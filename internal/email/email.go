@@ -0,0 +1,213 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Message is the readable part of an email, such as a newsletter.
+type Message struct {
+	From    string
+	Subject string
+	Date    time.Time
+	Text    string // text/plain body, if any
+	HTML    string // text/html body, if any
+}
+
+// maxDepth bounds nested multiparts and forwarded messages.
+const maxDepth = 10
+
+// wordDecoder decodes RFC 2047 headers in any charset known to x/text (e.g. ISO-2022-JP).
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse reads a raw RFC 5322 message.
+// If the message forwards another one as an attachment (message/rfc822), the forwarded message is returned instead,
+// so that the newsletter's own sender and subject are reported.
+func Parse(r io.Reader) (*Message, error) {
+	return parse(r, 0)
+}
+
+func parse(r io.Reader, depth int) (*Message, error) {
+	raw, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading message: %w", err)
+	}
+
+	m := &Message{
+		From:    decodeHeader(raw.Header.Get("From")),
+		Subject: decodeHeader(raw.Header.Get("Subject")),
+	}
+	m.Date, _ = raw.Header.Date()
+
+	forwarded, err := m.walk(raw.Header, raw.Body, depth)
+	if err != nil {
+		return nil, err
+	}
+	if forwarded != nil {
+		return forwarded, nil
+	}
+	return m, nil
+}
+
+// header is satisfied by both mail.Header and the MIME headers of parts.
+type header interface {
+	Get(key string) string
+}
+
+// walk collects text bodies from a (possibly multipart) entity into m.
+// It returns a forwarded message if one is found.
+func (m *Message) walk(h header, body io.Reader, depth int) (*Message, error) {
+	if depth > maxDepth {
+		return nil, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		// RFC 2045 default
+		mediaType, params = "text/plain", map[string]string{"charset": "us-ascii"}
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading multipart: %w", err)
+			}
+			forwarded, err := m.walk(part.Header, part, depth+1)
+			if forwarded != nil || err != nil {
+				return forwarded, err
+			}
+		}
+
+	case mediaType == "message/rfc822":
+		forwarded, err := parse(decodeTransfer(h, body), depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("reading forwarded message: %w", err)
+		}
+		return forwarded, nil
+
+	case mediaType == "text/plain" || mediaType == "text/html":
+		if disposition, _, _ := mime.ParseMediaType(h.Get("Content-Disposition")); disposition == "attachment" {
+			return nil, nil
+		}
+		data, err := io.ReadAll(decodeTransfer(h, body))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s part: %w", mediaType, err)
+		}
+		text := decodeCharset(data, params["charset"])
+		// Keep the first part of each type; later ones are usually signatures or footers
+		if mediaType == "text/plain" && m.Text == "" {
+			m.Text = strings.TrimSpace(text)
+		} else if mediaType == "text/html" && m.HTML == "" {
+			m.HTML = text
+		}
+	}
+	return nil, nil
+}
+
+// decodeTransfer undoes the Content-Transfer-Encoding of a part.
+func decodeTransfer(h header, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(h.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, newlineStripper{body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// newlineStripper drops CR and LF so that line-wrapped base64 decodes.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n newlineStripper) Read(p []byte) (int, error) {
+	for {
+		c, err := n.r.Read(p)
+		out := p[:0]
+		for _, b := range p[:c] {
+			if b != '\r' && b != '\n' {
+				out = append(out, b)
+			}
+		}
+		if len(out) > 0 || err != nil {
+			return len(out), err
+		}
+	}
+}
+
+// decodeCharset converts data to UTF-8, leaving it unchanged for unknown charsets.
+func decodeCharset(data []byte, charset string) string {
+	r, err := charsetReader(charset, bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// charsetReader returns a reader converting from charset to UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "us-ascii":
+		return input, nil
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q: %w", charset, err)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// decodeHeader decodes RFC 2047 encoded words, falling back to the raw value.
+func decodeHeader(v string) string {
+	decoded, err := wordDecoder.DecodeHeader(v)
+	if err != nil {
+		return v
+	}
+	return decoded
+}
+
+var (
+	invisibleRegex  = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	blockRegex      = regexp.MustCompile(`(?i)<(br|/p|/div|/tr|/h[1-6]|/li)\b[^>]*>`)
+	tagRegex        = regexp.MustCompile(`<[^>]*>`)
+	blankLinesRegex = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// Content returns the body as plain text, preferring the text/plain part and otherwise stripping the HTML.
+func (m *Message) Content() string {
+	if m.Text != "" {
+		return m.Text
+	}
+	s := invisibleRegex.ReplaceAllString(m.HTML, "")
+	s = blockRegex.ReplaceAllString(s, "\n")
+	s = tagRegex.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesRegex.ReplaceAllString(s, "\n\n"))
+}
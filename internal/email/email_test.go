@@ -0,0 +1,75 @@
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_MultipartAlternative(t *testing.T) {
+	raw := "From: =?UTF-8?B?44OL44Ol44O844K5?= <news@example.com>\r\n" +
+		"Subject: =?ISO-2022-JP?B?GyRCJUslZSE8JTkbKEI=?=\r\n" +
+		"Date: Mon, 12 Oct 2026 09:00:00 +0900\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Hello =E4=B8=96=E7=95=8C\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<p>Hello</p>\r\n" +
+		"--b1--\r\n"
+
+	m, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if m.Subject != "ニュース" {
+		t.Errorf("Expected the ISO-2022-JP subject to be decoded, got %q", m.Subject)
+	}
+	if !strings.HasPrefix(m.From, "ニュース") {
+		t.Errorf("Unexpected From %q", m.From)
+	}
+	if m.Content() != "Hello 世界" {
+		t.Errorf("Expected the text part to be preferred, got %q", m.Content())
+	}
+	if m.Date.IsZero() {
+		t.Error("Expected the date to be parsed")
+	}
+}
+
+func TestParse_ForwardedHTML(t *testing.T) {
+	raw := "From: me@example.com\r\n" +
+		"Subject: Fwd: Weekly\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+		"\r\n" +
+		"--outer\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"FYI\r\n" +
+		"--outer\r\n" +
+		"Content-Type: message/rfc822\r\n" +
+		"\r\n" +
+		"From: Weekly <weekly@example.com>\r\n" +
+		"Subject: Weekly issue 42\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"PGh0bWw+PGhlYWQ+PHN0eWxlPnB7fTwvc3R5bGU+PC9oZWFkPjxib2R5PjxoMT5Jc3N1ZSA0Mjwv\r\n" +
+		"aDE+PHA+R28gJmFtcDsgUnVzdDwvcD48L2JvZHk+PC9odG1sPg==\r\n" +
+		"--outer--\r\n"
+
+	m, err := Parse(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if m.Subject != "Weekly issue 42" || m.From != "Weekly <weekly@example.com>" {
+		t.Errorf("Expected the forwarded message, got %q from %q", m.Subject, m.From)
+	}
+	if got := m.Content(); got != "Issue 42\nGo & Rust" {
+		t.Errorf("Unexpected content %q", got)
+	}
+}
@@ -0,0 +1,215 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// Case is a single eval input together with the facts a good summary must convey.
+type Case struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url,omitempty"`
+	Fixture string   `json:"fixture,omitempty"` // Text file used instead of fetching URL, relative to the set file
+	Prompt  string   `json:"prompt,omitempty"`  // Optional user question
+	Expect  []string `json:"expect"`            // Key facts the summary must contain
+}
+
+// Set is a collection of eval cases.
+type Set struct {
+	Cases []Case `json:"cases"`
+}
+
+// LoadSet reads an eval set from a JSON file and resolves fixture paths relative to it.
+func LoadSet(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read eval set: %w", err)
+	}
+	var set Set
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse eval set %s: %w", path, err)
+	}
+	for i, c := range set.Cases {
+		if c.Name == "" {
+			set.Cases[i].Name = fmt.Sprintf("case-%d", i+1)
+		}
+		if (c.URL == "") == (c.Fixture == "") {
+			return nil, fmt.Errorf("eval case %q must set exactly one of url or fixture", set.Cases[i].Name)
+		}
+		if len(c.Expect) == 0 {
+			return nil, fmt.Errorf("eval case %q has no expected facts", set.Cases[i].Name)
+		}
+		if c.Fixture != "" && !filepath.IsAbs(c.Fixture) {
+			set.Cases[i].Fixture = filepath.Join(filepath.Dir(path), c.Fixture)
+		}
+	}
+	return &set, nil
+}
+
+// Summarizer produces the summaries under evaluation.
+type Summarizer interface {
+	ProcessURL(ctx context.Context, url string, userPrompt string) (string, error)
+	ProcessContent(ctx context.Context, content string, userPrompt string) (string, error)
+}
+
+// FactResult records whether one expected fact was found in the summary.
+type FactResult struct {
+	Fact  string
+	Found bool
+}
+
+// CaseResult is the outcome of a single case.
+type CaseResult struct {
+	Name    string
+	Summary string
+	Facts   []FactResult
+	Err     error // Set when the case could not be summarized or judged
+}
+
+// Passed reports whether the summary conveyed every expected fact.
+func (r CaseResult) Passed() bool {
+	if r.Err != nil {
+		return false
+	}
+	for _, f := range r.Facts {
+		if !f.Found {
+			return false
+		}
+	}
+	return true
+}
+
+// Report is the outcome of running a set.
+type Report struct {
+	Cases []CaseResult
+}
+
+// PassRate returns the fraction of cases that passed.
+func (r *Report) PassRate() float64 {
+	if len(r.Cases) == 0 {
+		return 0
+	}
+	passed := 0
+	for _, c := range r.Cases {
+		if c.Passed() {
+			passed++
+		}
+	}
+	return float64(passed) / float64(len(r.Cases))
+}
+
+// FactRate returns the fraction of expected facts found across all cases.
+// Facts of failed cases count as missing.
+func (r *Report) FactRate() float64 {
+	total, found := 0, 0
+	for _, c := range r.Cases {
+		for _, f := range c.Facts {
+			total++
+			if f.Found {
+				found++
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(found) / float64(total)
+}
+
+// Runner runs eval sets, using an LLM as the judge of whether facts are present.
+// A judge is used instead of string matching because summaries are often in a different language than the facts.
+type Runner struct {
+	summarizer Summarizer
+	judge      llm.LLM
+}
+
+// NewRunner creates a Runner.
+func NewRunner(s Summarizer, judge llm.LLM) *Runner {
+	return &Runner{summarizer: s, judge: judge}
+}
+
+// Run evaluates every case in the set.
+func (r *Runner) Run(ctx context.Context, set *Set) *Report {
+	report := &Report{}
+	for _, c := range set.Cases {
+		report.Cases = append(report.Cases, r.runCase(ctx, c))
+	}
+	return report
+}
+
+func (r *Runner) runCase(ctx context.Context, c Case) CaseResult {
+	result := CaseResult{Name: c.Name}
+	for _, fact := range c.Expect {
+		result.Facts = append(result.Facts, FactResult{Fact: fact})
+	}
+
+	var summary string
+	var err error
+	if c.Fixture != "" {
+		var data []byte
+		data, err = os.ReadFile(c.Fixture)
+		if err == nil {
+			summary, err = r.summarizer.ProcessContent(ctx, string(data), c.Prompt)
+		}
+	} else {
+		summary, err = r.summarizer.ProcessURL(ctx, c.URL, c.Prompt)
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Summary = summary
+
+	found, err := r.judgeFacts(ctx, summary, c.Expect)
+	if err != nil {
+		result.Err = fmt.Errorf("judge failed: %w", err)
+		return result
+	}
+	for i := range result.Facts {
+		result.Facts[i].Found = found[i]
+	}
+	return result
+}
+
+// judgeFacts asks the judge model which facts the summary conveys.
+func (r *Runner) judgeFacts(ctx context.Context, summary string, facts []string) ([]bool, error) {
+	var list strings.Builder
+	for i, f := range facts {
+		fmt.Fprintf(&list, "%d. %s\n", i+1, f)
+	}
+	messages := []llm.Message{
+		llm.NewTextMessage(llm.RoleSystem, `You grade summaries. For each numbered fact, decide whether the summary conveys it (in any language or wording). Respond with JSON only, in the form {"found": [true, false, ...]} with one boolean per fact in order.`),
+		llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("Summary:\n```\n%s\n```\n\nFacts:\n%s", summary, list.String())),
+	}
+
+	resp, err := r.judge.Generate(ctx, messages, llm.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return parseVerdict(resp.Text, len(facts))
+}
+
+// parseVerdict extracts the judge's JSON verdict, tolerating surrounding prose or code fences.
+func parseVerdict(text string, n int) ([]bool, error) {
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("judge response contains no JSON object")
+	}
+	var verdict struct {
+		Found []bool `json:"found"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &verdict); err != nil {
+		return nil, fmt.Errorf("failed to parse judge response: %w", err)
+	}
+	if len(verdict.Found) != n {
+		return nil, fmt.Errorf("judge returned %d verdicts for %d facts", len(verdict.Found), n)
+	}
+	return verdict.Found, nil
+}
@@ -0,0 +1,91 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+type fakeSummarizer struct{}
+
+func (fakeSummarizer) ProcessURL(ctx context.Context, url string, userPrompt string) (string, error) {
+	return "", errors.New("fetch failed")
+}
+
+func (fakeSummarizer) ProcessContent(ctx context.Context, content string, userPrompt string) (string, error) {
+	return "summary of " + content, nil
+}
+
+type fakeJudge struct {
+	text string
+}
+
+func (j fakeJudge) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	return &llm.Response{Text: j.text}, nil
+}
+
+func TestLoadSet(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "set.json")
+	os.WriteFile(path, []byte(`{"cases": [{"fixture": "page.txt", "expect": ["a"]}]}`), 0o644)
+
+	set, err := LoadSet(path)
+	if err != nil {
+		t.Fatalf("LoadSet failed: %v", err)
+	}
+	if set.Cases[0].Name != "case-1" {
+		t.Errorf("Expected default name 'case-1', got '%s'", set.Cases[0].Name)
+	}
+	if set.Cases[0].Fixture != filepath.Join(dir, "page.txt") {
+		t.Errorf("Expected fixture resolved relative to set file, got '%s'", set.Cases[0].Fixture)
+	}
+
+	os.WriteFile(path, []byte(`{"cases": [{"url": "https://example.com", "fixture": "page.txt", "expect": ["a"]}]}`), 0o644)
+	if _, err := LoadSet(path); err == nil {
+		t.Error("Expected an error when both url and fixture are set")
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "page.txt")
+	os.WriteFile(fixture, []byte("Go"), 0o644)
+
+	set := &Set{Cases: []Case{
+		{Name: "fixture", Fixture: fixture, Expect: []string{"Go is fast", "Go has generics"}},
+		{Name: "url", URL: "https://example.com", Expect: []string{"anything"}},
+	}}
+
+	runner := NewRunner(fakeSummarizer{}, fakeJudge{text: "```json\n{\"found\": [true, false]}\n```"})
+	report := runner.Run(context.Background(), set)
+
+	if len(report.Cases) != 2 {
+		t.Fatalf("Expected 2 case results, got %d", len(report.Cases))
+	}
+	if report.Cases[0].Summary != "summary of Go" || report.Cases[0].Passed() {
+		t.Errorf("Unexpected fixture case result: %+v", report.Cases[0])
+	}
+	if report.Cases[1].Err == nil {
+		t.Error("Expected the url case to record the fetch error")
+	}
+	if report.PassRate() != 0 {
+		t.Errorf("Expected pass rate 0, got %v", report.PassRate())
+	}
+	if got := report.FactRate(); got < 0.33 || got > 0.34 {
+		t.Errorf("Expected fact rate 1/3, got %v", got)
+	}
+}
+
+func TestParseVerdict(t *testing.T) {
+	if _, err := parseVerdict(`{"found": [true]}`, 2); err == nil || !strings.Contains(err.Error(), "1 verdicts for 2 facts") {
+		t.Errorf("Expected a count mismatch error, got %v", err)
+	}
+	if _, err := parseVerdict("no json here", 1); err == nil {
+		t.Error("Expected an error for a response without JSON")
+	}
+}
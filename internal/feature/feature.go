@@ -0,0 +1,86 @@
+package feature
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Experimental capabilities that can be rolled out gradually.
+const (
+	ToolCalling      = "tool-calling"      // The model may fetch extra pages while answering thread questions
+	Streaming        = "streaming"         // Replies are updated as the model writes them
+	VisionFallback   = "vision-fallback"   // Pages with little text are summarized from a screenshot
+	Verification     = "verification"      // Thread answers are checked against the fetched pages
+	RelatedLinks     = "related-links"     // Summaries list the page's most relevant outbound links
+	SourceType       = "source-type"       // Summaries label the kind of source and flag promotional tone
+	NumbersTable     = "numbers-table"     // Summaries of pages full of figures get a table of the key numbers
+	StructuredOutput = "structured-output" // Summaries are generated as JSON and rendered by the app, not formatted by the model
+	ContentLanguage  = "content-language"  // Summaries are written in the language of the question or page, not the prompts'
+	Citations        = "citations"         // Summaries back every claim with a quote of the page, and claims whose quotes are not in it are dropped
+	FollowUps        = "follow-ups"        // Summaries in Slack come with buttons asking suggested follow-up questions
+	ThreadTitles     = "thread-titles"     // Long Slack threads get a suggested title so they can be found later
+)
+
+// defaults holds each known flag's state when nothing overrides it.
+var defaults = map[string]bool{
+	ToolCalling:      true,
+	Streaming:        false,
+	VisionFallback:   false,
+	Verification:     false,
+	RelatedLinks:     false,
+	SourceType:       false,
+	NumbersTable:     false,
+	ContentLanguage:  true,
+	StructuredOutput: false,
+	Citations:        false,
+	FollowUps:        false,
+	ThreadTitles:     false,
+}
+
+// Flags holds the deployment's feature settings and per-channel overrides.
+type Flags struct {
+	global   map[string]bool
+	channels map[string]map[string]bool
+}
+
+// New creates Flags from global settings and per-channel overrides, rejecting unknown flag names.
+func New(global map[string]bool, channels map[string]map[string]bool) (*Flags, error) {
+	for name := range global {
+		if _, ok := defaults[name]; !ok {
+			return nil, unknown(name)
+		}
+	}
+	for _, overrides := range channels {
+		for name := range overrides {
+			if _, ok := defaults[name]; !ok {
+				return nil, unknown(name)
+			}
+		}
+	}
+	return &Flags{global: global, channels: channels}, nil
+}
+
+// unknown returns the error for an unknown flag name.
+func unknown(name string) error {
+	known := make([]string, 0, len(defaults))
+	for n := range defaults {
+		known = append(known, n)
+	}
+	sort.Strings(known)
+	return fmt.Errorf("unknown feature flag %q (known: %s)", name, strings.Join(known, ", "))
+}
+
+// Enabled reports whether the flag is on in channel. A channel override wins over the global setting,
+// which wins over the default. A nil Flags uses the defaults; channel may be empty outside Slack.
+func (f *Flags) Enabled(name, channel string) bool {
+	if f != nil {
+		if on, ok := f.channels[channel][name]; ok && channel != "" {
+			return on
+		}
+		if on, ok := f.global[name]; ok {
+			return on
+		}
+	}
+	return defaults[name]
+}
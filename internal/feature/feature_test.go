@@ -0,0 +1,40 @@
+package feature
+
+import (
+	"testing"
+)
+
+func TestFlags(t *testing.T) {
+	var none *Flags
+	if !none.Enabled(ToolCalling, "C1") || none.Enabled(Streaming, "C1") {
+		t.Error("Expected the defaults for nil Flags")
+	}
+
+	flags, err := New(
+		map[string]bool{ToolCalling: false},
+		map[string]map[string]bool{"C1": {ToolCalling: true, Streaming: true}},
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	tests := []struct {
+		name, channel string
+		want          bool
+	}{
+		{ToolCalling, "C1", true},  // Channel override
+		{ToolCalling, "C2", false}, // Global setting
+		{ToolCalling, "", false},   // Outside Slack
+		{Streaming, "C1", true},    // Channel override of a default
+		{Streaming, "C2", false},   // Default
+		{VisionFallback, "C1", false},
+	}
+	for _, tt := range tests {
+		if got := flags.Enabled(tt.name, tt.channel); got != tt.want {
+			t.Errorf("Enabled(%q, %q) = %v, want %v", tt.name, tt.channel, got, tt.want)
+		}
+	}
+
+	if _, err := New(nil, map[string]map[string]bool{"C1": {"telepathy": true}}); err == nil {
+		t.Error("Expected an error for an unknown flag")
+	}
+}
@@ -0,0 +1,129 @@
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Feed is a parsed RSS feed.
+type Feed struct {
+	Title string
+	Link  string
+	Items []Item
+}
+
+// Item is a single feed entry, such as a podcast episode.
+type Item struct {
+	Title        string
+	Link         string
+	Description  string // Show notes; HTML is kept as-is
+	Published    time.Time
+	EnclosureURL string // Media file (e.g. the episode audio), if any
+}
+
+// rss mirrors the parts of RSS 2.0 (with iTunes extensions) that we use.
+type rss struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			Encoded     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+			Summary     string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+			PubDate     string `xml:"pubDate"`
+			Enclosure   struct {
+				URL string `xml:"url,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// dateLayouts are the pubDate formats seen in the wild, RFC 1123 variants first.
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+}
+
+// Parse reads an RSS 2.0 document.
+func Parse(r io.Reader) (*Feed, error) {
+	var doc rss
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding feed: %w", err)
+	}
+
+	f := &Feed{Title: strings.TrimSpace(doc.Channel.Title), Link: strings.TrimSpace(doc.Channel.Link)}
+	for _, it := range doc.Channel.Items {
+		// Prefer the richest show notes available
+		description := it.Encoded
+		if description == "" {
+			description = it.Description
+		}
+		if description == "" {
+			description = it.Summary
+		}
+		f.Items = append(f.Items, Item{
+			Title:        strings.TrimSpace(it.Title),
+			Link:         strings.TrimSpace(it.Link),
+			Description:  strings.TrimSpace(description),
+			Published:    parseDate(it.PubDate),
+			EnclosureURL: it.Enclosure.URL,
+		})
+	}
+	return f, nil
+}
+
+// parseDate parses a pubDate, returning the zero time if no layout matches.
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Fetch downloads and parses the feed at url.
+func Fetch(ctx context.Context, client *http.Client, url string) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for %s: %w", url, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching feed %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching feed %s: status %d", url, resp.StatusCode)
+	}
+
+	f, err := Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing feed %s: %w", url, err)
+	}
+	return f, nil
+}
+
+// Since returns the items published at or after t, newest first as in the feed.
+// Items without a parsable date are skipped.
+func (f *Feed) Since(t time.Time) []Item {
+	var items []Item
+	for _, it := range f.Items {
+		if !it.Published.IsZero() && !it.Published.Before(t) {
+			items = append(items, it)
+		}
+	}
+	return items
+}
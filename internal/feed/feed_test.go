@@ -0,0 +1,83 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const podcastXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+	<title>Tech Talk</title>
+	<link>https://example.com/podcast</link>
+	<item>
+		<title>Episode 2</title>
+		<link>https://example.com/ep2</link>
+		<description>Short notes</description>
+		<content:encoded><![CDATA[<p>Full show notes</p>]]></content:encoded>
+		<pubDate>Mon, 12 Oct 2026 09:00:00 +0900</pubDate>
+		<enclosure url="https://example.com/ep2.mp3" type="audio/mpeg" length="1"/>
+	</item>
+	<item>
+		<title>Episode 1</title>
+		<itunes:summary>iTunes summary</itunes:summary>
+		<pubDate>Mon, 5 Oct 2026 09:00:00 GMT</pubDate>
+	</item>
+</channel>
+</rss>`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(podcastXML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.Title != "Tech Talk" || len(f.Items) != 2 {
+		t.Fatalf("Unexpected feed: %+v", f)
+	}
+
+	ep2 := f.Items[0]
+	if ep2.Description != "<p>Full show notes</p>" {
+		t.Errorf("Expected content:encoded to be preferred, got %q", ep2.Description)
+	}
+	if ep2.EnclosureURL != "https://example.com/ep2.mp3" {
+		t.Errorf("Unexpected enclosure %q", ep2.EnclosureURL)
+	}
+	if want := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC); !ep2.Published.Equal(want) {
+		t.Errorf("Published = %v, want %v", ep2.Published, want)
+	}
+	if f.Items[1].Description != "iTunes summary" {
+		t.Errorf("Expected the iTunes summary as fallback, got %q", f.Items[1].Description)
+	}
+
+	recent := f.Since(time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC))
+	if len(recent) != 1 || recent[0].Title != "Episode 2" {
+		t.Errorf("Since returned %+v", recent)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(podcastXML))
+	}))
+	defer srv.Close()
+
+	f, err := Fetch(context.Background(), srv.Client(), srv.URL+"/feed.xml")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(f.Items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(f.Items))
+	}
+
+	if _, err := Fetch(context.Background(), srv.Client(), srv.URL+"/missing"); err == nil {
+		t.Error("Expected an error for a non-200 response")
+	}
+}
@@ -0,0 +1,180 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Polling intervals. A feed is polled at MinInterval after it changed, and the interval doubles every
+// time it is found unchanged, up to MaxInterval.
+const (
+	MinInterval = 15 * time.Minute
+	MaxInterval = 24 * time.Hour
+)
+
+// Poller fetches feeds cheaply across many polls: it sends conditional requests, honors
+// Cache-Control max-age, and polls feeds that rarely change less often. Between polls, and when
+// the server answers 304 Not Modified, the feed from the last download is returned.
+type Poller struct {
+	client *http.Client
+	path   string // Optional file the state is persisted to
+	now    func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*pollState
+}
+
+// pollState is what the Poller remembers about one feed.
+type pollState struct {
+	ETag         string        `json:"etag,omitempty"`
+	LastModified string        `json:"last_modified,omitempty"`
+	Hash         string        `json:"hash,omitempty"` // Of the last downloaded body, to spot servers without validators
+	Interval     time.Duration `json:"interval"`
+	NextPoll     time.Time     `json:"next_poll"`
+	Feed         *Feed         `json:"feed,omitempty"`
+}
+
+// NewPoller creates a Poller. If statePath is set, validators, schedules and the last downloaded
+// feeds are loaded from and saved to that file, so they carry over between runs.
+func NewPoller(client *http.Client, statePath string) (*Poller, error) {
+	p := &Poller{client: client, path: statePath, now: time.Now, states: make(map[string]*pollState)}
+	if statePath == "" {
+		return p, nil
+	}
+	data, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading feed state: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &p.states); err != nil {
+			return nil, fmt.Errorf("parsing feed state %s: %w", statePath, err)
+		}
+	}
+	return p, nil
+}
+
+// Poll returns the feed at url and whether it changed since the previous poll. A feed polled for the
+// first time counts as changed.
+func (p *Poller) Poll(ctx context.Context, url string) (*Feed, bool, error) {
+	p.mu.Lock()
+	state := p.states[url]
+	if state == nil {
+		state = &pollState{}
+		p.states[url] = state
+	}
+	if state.Feed != nil && p.now().Before(state.NextPoll) {
+		f := state.Feed
+		p.mu.Unlock()
+		return f, false, nil
+	}
+	etag, lastModified := state.ETag, state.LastModified
+	if state.Feed == nil {
+		// Without a stored copy a 304 would leave nothing to return
+		etag, lastModified = "", ""
+	}
+	p.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("creating request for %s: %w", url, err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching feed %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.save()
+
+	if resp.StatusCode == http.StatusNotModified && state.Feed != nil {
+		p.schedule(state, resp, false)
+		return state.Feed, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("fetching feed %s: status %d", url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading feed %s: %w", url, err)
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	changed := state.Feed == nil || hash != state.Hash
+	if changed {
+		f, err := Parse(bytes.NewReader(body))
+		if err != nil {
+			return nil, false, fmt.Errorf("parsing feed %s: %w", url, err)
+		}
+		state.Feed = f
+		state.Hash = hash
+	}
+	state.ETag = resp.Header.Get("ETag")
+	state.LastModified = resp.Header.Get("Last-Modified")
+	p.schedule(state, resp, changed)
+	return state.Feed, changed, nil
+}
+
+// schedule sets the next poll of a feed after a response. Must be called with mu held.
+func (p *Poller) schedule(state *pollState, resp *http.Response, changed bool) {
+	switch {
+	case changed || state.Interval == 0:
+		state.Interval = MinInterval
+	default:
+		state.Interval = min(state.Interval*2, MaxInterval)
+	}
+	wait := state.Interval
+	// Never poll before the server says the feed may change; no-store and no-cache set no floor
+	if maxAge := cacheMaxAge(resp.Header.Get("Cache-Control")); maxAge > wait {
+		wait = min(maxAge, MaxInterval)
+	}
+	state.NextPoll = p.now().Add(wait)
+}
+
+// cacheMaxAge returns the max-age of a Cache-Control header, or 0.
+func cacheMaxAge(header string) time.Duration {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
+
+// save writes the state file. Must be called with mu held.
+func (p *Poller) save() {
+	if p.path == "" {
+		return
+	}
+	data, err := json.Marshal(p.states)
+	if err == nil {
+		err = os.WriteFile(p.path, data, 0o644)
+	}
+	if err != nil {
+		log.Printf("[Feed] Failed to save feed state: %v", err)
+	}
+}
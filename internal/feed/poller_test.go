@@ -0,0 +1,81 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPoller(t *testing.T) {
+	requests, conditional := 0, 0
+	body := podcastXML
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` && body == podcastXML {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	statePath := filepath.Join(t.TempDir(), "feeds.json")
+	p, err := NewPoller(srv.Client(), statePath)
+	if err != nil {
+		t.Fatalf("NewPoller failed: %v", err)
+	}
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	url := srv.URL + "/feed.xml"
+
+	f, changed, err := p.Poll(context.Background(), url)
+	if err != nil || !changed || len(f.Items) != 2 {
+		t.Fatalf("Expected the first poll to download the feed, got %v, %v", changed, err)
+	}
+
+	// Before the next poll is due, the stored feed is returned without a request
+	if f, changed, _ := p.Poll(context.Background(), url); changed || f == nil || requests != 1 {
+		t.Errorf("Expected no request before the next poll, got %d requests", requests)
+	}
+
+	// A due poll is conditional; unchanged feeds are polled less and less often
+	now = now.Add(MinInterval)
+	if f, changed, err := p.Poll(context.Background(), url); err != nil || changed || len(f.Items) != 2 || conditional != 1 {
+		t.Fatalf("Expected a 304 answered from the stored feed, got %v, %v, %d conditional", changed, err, conditional)
+	}
+	now = now.Add(MinInterval)
+	p.Poll(context.Background(), url)
+	if requests != 2 {
+		t.Errorf("Expected the interval to double after an unchanged poll, got %d requests", requests)
+	}
+
+	// State carries over to a new Poller, and a changed feed resets the interval
+	p, err = NewPoller(srv.Client(), statePath)
+	if err != nil {
+		t.Fatalf("NewPoller failed: %v", err)
+	}
+	p.now = func() time.Time { return now }
+	now = now.Add(MinInterval)
+	body = podcastXML[:len(podcastXML)-len("</channel>\n</rss>")] + "<item><title>Episode 3</title></item></channel></rss>"
+	if f, changed, err := p.Poll(context.Background(), url); err != nil || !changed || len(f.Items) != 3 {
+		t.Fatalf("Expected the changed feed, got %v, %v", changed, err)
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	for header, want := range map[string]time.Duration{
+		"public, max-age=3600": time.Hour,
+		"no-cache":             0,
+		"max-age=abc":          0,
+		`MAX-AGE="60"`:         time.Minute,
+	} {
+		if got := cacheMaxAge(header); got != want {
+			t.Errorf("cacheMaxAge(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
@@ -1,21 +1,42 @@
+//go:build !nochrome
+
 package fetcher
 
 import (
 	"context"
+	"encoding/json"
 	"errors" // Added import
 	"fmt"    // Added import
+	"html"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	// Added import
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
+
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/kznrluk/describe-kun/internal/retry"
+	"github.com/kznrluk/describe-kun/internal/timeout"
 )
 
 // ChromeDPFetcher implements the Fetcher interface using ChromeDP.
 type ChromeDPFetcher struct {
 	allocatorCancel context.CancelFunc
 	browserCtx      context.Context
+
+	navigationTimeout time.Duration // Limit for loading the page, 0 means none
+	extractionTimeout time.Duration // Limit for extracting content from the loaded page, 0 means none
+}
+
+// SetTimeouts sets the per-stage limits applied to every Fetch. Zero disables a limit.
+func (f *ChromeDPFetcher) SetTimeouts(navigation, extraction time.Duration) {
+	f.navigationTimeout = navigation
+	f.extractionTimeout = extraction
 }
 
 // NewChromeDPFetcher creates a new ChromeDP fetcher instance.
@@ -47,15 +68,16 @@ func NewChromeDPFetcher() (*ChromeDPFetcher, error) {
 	}, nil
 }
 
-// Fetch retrieves the main textual content from the given URL using ChromeDP.
-func (f *ChromeDPFetcher) Fetch(ctx context.Context, url string) (string, error) {
-	var content string
+// Fetch retrieves the page described by req using ChromeDP.
+// Each call runs in its own browser tab so per-request headers don't leak between fetches.
+func (f *ChromeDPFetcher) Fetch(ctx context.Context, req FetchRequest) (*FetchResult, error) {
+	url := req.URL
+	result := &FetchResult{}
 	var statusCode int64
 
-	// Use the browser context created in NewChromeDPFetcher
-	// Combine the passed context with the browser context for timeout/cancellation
-	runCtx, cancel := context.WithCancel(f.browserCtx)
-	defer cancel() // Ensure task context is cancelled
+	// Open a new tab in the browser created in NewChromeDPFetcher
+	runCtx, cancel := chromedp.NewContext(f.browserCtx)
+	defer cancel() // Ensure the tab is closed
 
 	// Link the parent context (passed to Fetch) for cancellation signals
 	go func() {
@@ -67,25 +89,92 @@ func (f *ChromeDPFetcher) Fetch(ctx context.Context, url string) (string, error)
 		}
 	}()
 
-	log.Printf("[Fetcher] Starting actions for %s", url)
+	// Allocate the tab with the long-lived context: chromedp ties the tab's event loop to the
+	// context of the first Run, so it must not be one of the stage contexts below
+	if err := chromedp.Run(runCtx); err != nil {
+		return nil, fmt.Errorf("failed to open browser tab for %s: %w", url, err)
+	}
+
+	// Record script errors and failed requests, which explain pages that come out empty
+	collector := newDiagnosticsCollector()
+	chromedp.ListenTarget(runCtx, collector.listen)
+
+	reqmeta.Logf(ctx, "[Fetcher] Starting actions for %s", url)
 	start := time.Now()
 
-	actions := []chromedp.Action{
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			log.Printf("[Fetcher] Navigating to %s...", url)
-			return nil
-		}),
-		chromedp.Navigate(url),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			log.Printf("[Fetcher] Navigation finished or timed out (%s)", time.Since(start))
-			return nil
-		}),
+	// Navigation stage: load the page
+	actions := []chromedp.Action{}
+	if len(req.Headers) > 0 {
+		headers := network.Headers{}
+		for k, v := range req.Headers {
+			headers[k] = v
+		}
+		actions = append(actions, network.Enable(), network.SetExtraHTTPHeaders(headers))
+	}
+	if req.HTML != "" {
+		// Load the caller's document into a blank page so the extraction stage below is shared
+		actions = append(actions,
+			chromedp.Navigate("about:blank"),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				log.Printf("[Fetcher] Loading provided HTML (%d bytes)...", len(req.HTML))
+				tree, err := page.GetFrameTree().Do(ctx)
+				if err != nil {
+					return err
+				}
+				return page.SetDocumentContent(tree.Frame.ID, documentWithBase(req.HTML, url)).Do(ctx)
+			}),
+		)
+	} else {
+		actions = append(actions,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				log.Printf("[Fetcher] Navigating to %s...", url)
+				return nil
+			}),
+			chromedp.Navigate(url),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				log.Printf("[Fetcher] Navigation finished or timed out (%s)", time.Since(start))
+				return nil
+			}),
+		)
+	}
+	if req.WaitStrategy == WaitNetworkIdle {
+		actions = append(actions,
+			chromedp.Evaluate(networkIdleScript, nil, awaitPromise),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				log.Printf("[Fetcher] Network idle (%s)", time.Since(start))
+				return nil
+			}),
+		)
+	}
+	err := retry.Do(runCtx, retry.Current(), "Loading "+url, func(ctx context.Context) error {
+		return timeout.Run(ctx, timeout.Navigation, f.navigationTimeout, func(ctx context.Context) error {
+			return transientNavigation(chromedp.Run(ctx, actions...))
+		})
+	})
+
+	// Extraction stage: read metadata and content from the loaded page
+	actions = []chromedp.Action{
+		chromedp.Location(&result.FinalURL),
 		// Check status code after navigation (best effort, might run before full load sometimes)
 		chromedp.Evaluate(`window.performance.getEntriesByType('navigation')[0]?.responseStatus`, &statusCode),
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			log.Printf("[Fetcher] Status code evaluated (%s)", time.Since(start))
 			return nil
 		}),
+		chromedp.Evaluate(metadataScript, &result.Metadata),
+		chromedp.Evaluate(videoScript, &result.Video),
+	}
+	if req.Screenshot {
+		// Capture before cleanup so the screenshot shows the page as a reader would see it
+		actions = append(actions,
+			chromedp.FullScreenshot(&result.Screenshot, 100),
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				log.Printf("[Fetcher] Screenshot captured (%s)", time.Since(start))
+				return nil
+			}),
+		)
+	}
+	actions = append(actions,
 		// Remove common non-content elements via JavaScript before extracting text
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			log.Printf("[Fetcher] Running cleanup script...")
@@ -102,38 +191,207 @@ func (f *ChromeDPFetcher) Fetch(ctx context.Context, url string) (string, error)
 			return nil
 		}),
 		// Use Evaluate to get innerText instead of Text with NodeVisible
-		chromedp.Evaluate(`document.body.innerText`, &content),
+		chromedp.Evaluate(`document.body.innerText`, &result.Text),
+		chromedp.Evaluate(`document.body.innerHTML`, &result.HTML),
+		chromedp.Evaluate(markdownScript, &result.Markdown),
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			log.Printf("[Fetcher] innerText extracted (%s)", time.Since(start))
 			return nil
 		}),
-	}
+	)
 
-	err := chromedp.Run(runCtx, actions...)
+	if err == nil {
+		err = timeout.Run(runCtx, timeout.Extraction, f.extractionTimeout, func(ctx context.Context) error {
+			return chromedp.Run(ctx, actions...)
+		})
+	}
 
 	log.Printf("[Fetcher] chromedp.Run finished for %s after %s", url, time.Since(start))
 
 	if err != nil {
+		// Report which stage timeout fired, if any
+		var te *timeout.Error
+		if errors.As(err, &te) {
+			return nil, fmt.Errorf("fetching %s: %w", url, err)
+		}
 		// Check if the error is due to context cancellation (timeout or external cancel)
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-			return "", fmt.Errorf("chromedp context cancelled or timed out for %s: %w", url, err)
+			return nil, fmt.Errorf("chromedp context cancelled or timed out for %s: %w", url, err)
 		}
-		return "", fmt.Errorf("failed to fetch content from %s: %w", url, err)
+		return nil, fmt.Errorf("failed to fetch content from %s: %w", url, err)
+	}
+
+	result.StatusCode = int(statusCode)
+	result.Diagnostics = collector.diagnostics()
+	if !result.Diagnostics.Empty() {
+		d := result.Diagnostics
+		log.Printf("[Fetcher] %s: %d exception(s), %d console error(s), %d failed request(s)",
+			url, len(d.Exceptions), len(d.ConsoleErrors), len(d.FailedRequests))
+	}
+	if req.HTML != "" {
+		// The page itself is about:blank; report the URL the caller attributed the HTML to
+		result.FinalURL = url
 	}
 
 	// Check HTTP status code after successful run
 	if statusCode != 0 && (statusCode < 200 || statusCode >= 300) {
-		return "", fmt.Errorf("received non-2xx status code %d for %s", statusCode, url)
+		return nil, fmt.Errorf("received non-2xx status code %d for %s", statusCode, url)
 	}
-	if statusCode == 0 && content == "" {
+	if statusCode == 0 && result.Text == "" {
 		// Sometimes status code might not be captured, but empty content is a good indicator of failure
-		return "", fmt.Errorf("failed to retrieve content or status code for %s", url)
+		return nil, NewPageError(url, result.Diagnostics)
+	}
+
+	// Chapters come from timestamp lines in the description, falling back to the page body (e.g. Vimeo)
+	if result.Video != nil {
+		result.Video.Chapters = ParseChapters(result.Video.Description)
+		if result.Video.Chapters == nil {
+			result.Video.Chapters = ParseChapters(result.Markdown)
+		}
 	}
 
 	// Basic cleanup - replace multiple newlines/spaces
-	content = strings.Join(strings.Fields(content), " ")
+	result.Text = strings.Join(strings.Fields(result.Text), " ")
+
+	result.Text = truncate(result.Text, req.MaxBytes)
+	result.HTML = truncate(result.HTML, req.MaxBytes)
+	result.Markdown = truncate(result.Markdown, req.MaxBytes)
+
+	return result, nil
+}
+
+// diagnosticsCollector records the problems of a page from browser events, which arrive on another goroutine.
+type diagnosticsCollector struct {
+	mu       sync.Mutex
+	d        Diagnostics
+	requests map[network.RequestID]*network.Request // In flight, to name the URL of failed ones
+}
+
+func newDiagnosticsCollector() *diagnosticsCollector {
+	return &diagnosticsCollector{requests: make(map[network.RequestID]*network.Request)}
+}
+
+// listen handles a browser event; it is registered with chromedp.ListenTarget.
+func (c *diagnosticsCollector) listen(ev any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch ev := ev.(type) {
+	case *runtime.EventExceptionThrown:
+		if len(c.d.Exceptions) < maxDiagnostics {
+			c.d.Exceptions = append(c.d.Exceptions, exceptionText(ev.ExceptionDetails))
+		}
+	case *runtime.EventConsoleAPICalled:
+		if ev.Type == runtime.APITypeError && len(c.d.ConsoleErrors) < maxDiagnostics {
+			var args []string
+			for _, arg := range ev.Args {
+				args = append(args, remoteObjectText(arg))
+			}
+			c.d.ConsoleErrors = append(c.d.ConsoleErrors, shorten(strings.Join(args, " ")))
+		}
+	case *network.EventRequestWillBeSent:
+		c.requests[ev.RequestID] = ev.Request
+	case *network.EventResponseReceived:
+		delete(c.requests, ev.RequestID)
+		if ev.Response.Status >= 400 {
+			c.addFailed(FailedRequest{URL: ev.Response.URL, Type: string(ev.Type), Status: int(ev.Response.Status)})
+		}
+	case *network.EventLoadingFailed:
+		req := c.requests[ev.RequestID]
+		delete(c.requests, ev.RequestID)
+		// Canceled requests are usually the page's own doing, such as aborted prefetches
+		if req != nil && !ev.Canceled {
+			c.addFailed(FailedRequest{URL: req.URL, Type: string(ev.Type), Reason: ev.ErrorText})
+		}
+	}
+}
+
+// addFailed records a failed request. Must be called with mu held.
+func (c *diagnosticsCollector) addFailed(r FailedRequest) {
+	if len(c.d.FailedRequests) < maxDiagnostics {
+		r.URL = shorten(r.URL)
+		c.d.FailedRequests = append(c.d.FailedRequests, r)
+	}
+}
+
+// diagnostics returns a copy of what was recorded, or nil if nothing went wrong.
+func (c *diagnosticsCollector) diagnostics() *Diagnostics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.d.Empty() {
+		return nil
+	}
+	d := Diagnostics{
+		Exceptions:     append([]string(nil), c.d.Exceptions...),
+		ConsoleErrors:  append([]string(nil), c.d.ConsoleErrors...),
+		FailedRequests: append([]FailedRequest(nil), c.d.FailedRequests...),
+	}
+	return &d
+}
+
+// exceptionText describes an uncaught exception, preferring the error's own message and location.
+func exceptionText(e *runtime.ExceptionDetails) string {
+	text := e.Text
+	if e.Exception != nil && e.Exception.Description != "" {
+		// The description is the stack trace; its first line is the message
+		text, _, _ = strings.Cut(e.Exception.Description, "\n")
+	}
+	if e.URL != "" {
+		text += fmt.Sprintf(" at %s:%d", e.URL, e.LineNumber+1)
+	}
+	return shorten(text)
+}
 
-	return content, nil
+// remoteObjectText renders a console argument as the console would.
+func remoteObjectText(o *runtime.RemoteObject) string {
+	if o.Description != "" {
+		return o.Description
+	}
+	var s string
+	if json.Unmarshal(o.Value, &s) == nil {
+		return s
+	}
+	if len(o.Value) > 0 {
+		return string(o.Value)
+	}
+	return string(o.UnserializableValue)
+}
+
+// shorten limits diagnostic text, which can contain whole stack traces or data URLs.
+func shorten(s string) string {
+	if runes := []rune(s); len(runes) > 300 {
+		return string(runes[:300]) + "…"
+	}
+	return s
+}
+
+// documentWithBase prefixes doc with a <base> element so relative links resolve against baseURL.
+func documentWithBase(doc, baseURL string) string {
+	if baseURL == "" {
+		return doc
+	}
+	return `<base href="` + html.EscapeString(baseURL) + `">` + doc
+}
+
+// awaitPromise makes chromedp.Evaluate wait for a returned promise to settle.
+func awaitPromise(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+	return p.WithAwaitPromise(true)
+}
+
+// Check opens and closes a blank tab to verify the browser still responds.
+func (f *ChromeDPFetcher) Check(ctx context.Context) error {
+	tabCtx, cancel := chromedp.NewContext(f.browserCtx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- chromedp.Run(tabCtx, chromedp.Navigate("about:blank")) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("browser is not responding: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("browser is not responding: %w", ctx.Err())
+	}
 }
 
 // Close terminates the browser instance and releases resources.
@@ -144,3 +402,27 @@ func (f *ChromeDPFetcher) Close() {
 	// but cancelling the allocator context is usually sufficient.
 	// chromedp.Cancel(f.browserCtx) // This might be redundant
 }
+
+// transientNavigationErrors are Chrome network errors of a page load that another attempt may not hit.
+var transientNavigationErrors = []string{
+	"net::ERR_CONNECTION_RESET",
+	"net::ERR_CONNECTION_CLOSED",
+	"net::ERR_CONNECTION_REFUSED",
+	"net::ERR_CONNECTION_TIMED_OUT",
+	"net::ERR_EMPTY_RESPONSE",
+	"net::ERR_NETWORK_CHANGED",
+	"net::ERR_HTTP2_PROTOCOL_ERROR",
+}
+
+// transientNavigation marks page load errors caused by a flaky connection as worth retrying.
+func transientNavigation(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, e := range transientNavigationErrors {
+		if strings.Contains(err.Error(), e) {
+			return retry.Transient(err)
+		}
+	}
+	return err
+}
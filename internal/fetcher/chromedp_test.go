@@ -1,3 +1,5 @@
+//go:build !nochrome
+
 package fetcher
 
 import (
@@ -13,7 +15,10 @@ import (
 const testHTML = `
 <!DOCTYPE html>
 <html>
-<head><title>Test Page</title></head>
+<head>
+    <title>Test Page</title>
+    <meta name="description" content="A page used in tests">
+</head>
 <body>
     <h1>Main Title</h1>
     <article>
@@ -49,11 +54,12 @@ func TestChromeDPFetcher_Fetch(t *testing.T) {
 	defer cancel()
 
 	testURL := server.URL + "/test"
-	content, err := fetcher.Fetch(ctx, testURL)
+	result, err := fetcher.Fetch(ctx, FetchRequest{URL: testURL})
 
 	if err != nil {
 		t.Fatalf("Fetch failed for URL %s: %v", testURL, err)
 	}
+	content := result.Text
 
 	if content == "" {
 		t.Fatal("Expected content, but got empty string")
@@ -85,9 +91,99 @@ func TestChromeDPFetcher_Fetch(t *testing.T) {
 		}
 	}
 
+	if result.Metadata.Title != "Test Page" {
+		t.Errorf("Expected title 'Test Page', got '%s'", result.Metadata.Title)
+	}
+	if result.Metadata.Description != "A page used in tests" {
+		t.Errorf("Expected description 'A page used in tests', got '%s'", result.Metadata.Description)
+	}
+	if result.FinalURL != testURL {
+		t.Errorf("Expected final URL '%s', got '%s'", testURL, result.FinalURL)
+	}
+	if !strings.Contains(result.Markdown, "# Main Title") {
+		t.Errorf("Expected markdown to contain a heading, got:\n%s", result.Markdown)
+	}
+
 	t.Logf("Fetched content:\n%s", content) // Log for manual inspection
 }
 
+func TestChromeDPFetcher_Fetch_MaxBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, testHTML)
+	}))
+	defer server.Close()
+
+	fetcher, err := NewChromeDPFetcher()
+	if err != nil {
+		t.Skipf("Skipping test: Failed to create ChromeDPFetcher: %v", err)
+		return
+	}
+	defer fetcher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := fetcher.Fetch(ctx, FetchRequest{URL: server.URL, MaxBytes: 10})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(result.Text) > 10 || len(result.HTML) > 10 || len(result.Markdown) > 10 {
+		t.Errorf("Expected content truncated to 10 bytes, got text=%d html=%d markdown=%d", len(result.Text), len(result.HTML), len(result.Markdown))
+	}
+}
+
+func TestChromeDPFetcher_Fetch_HTML(t *testing.T) {
+	fetcher, err := NewChromeDPFetcher()
+	if err != nil {
+		t.Skipf("Skipping test: Failed to create ChromeDPFetcher: %v", err)
+		return
+	}
+	defer fetcher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := fetcher.Fetch(ctx, FetchRequest{URL: "https://example.com/article", HTML: testHTML})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if !strings.Contains(result.Text, "main content paragraph 1") || strings.Contains(result.Text, "Footer content") {
+		t.Errorf("Expected the provided HTML to be extracted and cleaned, got %q", result.Text)
+	}
+	if result.Metadata.Title != "Test Page" || result.FinalURL != "https://example.com/article" {
+		t.Errorf("Unexpected metadata %+v or final URL %q", result.Metadata, result.FinalURL)
+	}
+}
+
+func TestDocumentWithBase(t *testing.T) {
+	if got := documentWithBase("<p>x</p>", ""); got != "<p>x</p>" {
+		t.Errorf("Expected the document unchanged without a base URL, got %q", got)
+	}
+	if got := documentWithBase("<p>x</p>", `https://example.com/?a=1&b="2"`); got != `<base href="https://example.com/?a=1&amp;b=&#34;2&#34;"><p>x</p>` {
+		t.Errorf("Unexpected document %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"hello", 0, "hello"},
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"日本語", 4, "日"}, // Each rune is 3 bytes; never split one
+		{"日本語", 6, "日本"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.in, tt.max); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
 func TestChromeDPFetcher_Fetch_NotFound(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -105,7 +201,7 @@ func TestChromeDPFetcher_Fetch_NotFound(t *testing.T) {
 	defer cancel()
 
 	testURL := server.URL + "/nonexistent"
-	_, err = fetcher.Fetch(ctx, testURL)
+	_, err = fetcher.Fetch(ctx, FetchRequest{URL: testURL})
 
 	if err == nil {
 		t.Fatalf("Expected an error for a 404 URL (%s), but got nil", testURL)
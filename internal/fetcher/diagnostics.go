@@ -0,0 +1,95 @@
+package fetcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiagnostics bounds the entries of each kind kept per page, so noisy pages don't bloat results.
+const maxDiagnostics = 20
+
+// Diagnostics are the problems the browser saw while loading a page, which often explain why
+// the extracted content is empty or incomplete.
+type Diagnostics struct {
+	Exceptions     []string        `json:"exceptions,omitempty"`      // Uncaught JavaScript exceptions
+	ConsoleErrors  []string        `json:"console_errors,omitempty"`  // console.error calls
+	FailedRequests []FailedRequest `json:"failed_requests,omitempty"` // Requests that failed or got an error status
+}
+
+// FailedRequest is a network request of the page that did not succeed.
+type FailedRequest struct {
+	URL    string `json:"url"`
+	Type   string `json:"type,omitempty"`   // Resource type, e.g. "Document", "Script" or "XHR"
+	Status int    `json:"status,omitempty"` // HTTP status, 0 if the request failed without a response
+	Reason string `json:"reason,omitempty"` // Network error, e.g. "net::ERR_NAME_NOT_RESOLVED"
+}
+
+func (r FailedRequest) String() string {
+	if r.Status != 0 {
+		return fmt.Sprintf("%s %s: status %d", r.Type, r.URL, r.Status)
+	}
+	return fmt.Sprintf("%s %s: %s", r.Type, r.URL, r.Reason)
+}
+
+// Empty reports whether nothing went wrong. A nil Diagnostics is empty.
+func (d *Diagnostics) Empty() bool {
+	return d == nil || len(d.Exceptions)+len(d.ConsoleErrors)+len(d.FailedRequests) == 0
+}
+
+// String lists the problems, one per line.
+func (d *Diagnostics) String() string {
+	if d.Empty() {
+		return ""
+	}
+	var lines []string
+	for _, e := range d.Exceptions {
+		lines = append(lines, "exception: "+e)
+	}
+	for _, e := range d.ConsoleErrors {
+		lines = append(lines, "console error: "+e)
+	}
+	for _, r := range d.FailedRequests {
+		lines = append(lines, "failed request: "+r.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Reasons a page yields no content, as reported by PageError.
+const (
+	ReasonJSCrashed      = "page JS crashed"                  // Scripts threw before rendering the content
+	ReasonRequestsFailed = "page resources failed to load"    // Scripts or data the content depends on failed
+	ReasonEmpty          = "page rendered no visible content" // Nothing went visibly wrong
+)
+
+// PageError is returned when a page loads but yields no content, with the browser's diagnosis.
+type PageError struct {
+	URL         string
+	Reason      string // One of the Reason constants
+	Diagnostics *Diagnostics
+}
+
+// NewPageError diagnoses why the page at url has no content from what the browser saw while loading it.
+func NewPageError(url string, d *Diagnostics) *PageError {
+	reason := ReasonEmpty
+	switch {
+	case d == nil:
+	case len(d.Exceptions) > 0:
+		reason = ReasonJSCrashed
+	case len(d.FailedRequests) > 0:
+		reason = ReasonRequestsFailed
+	}
+	return &PageError{URL: url, Reason: reason, Diagnostics: d}
+}
+
+func (e *PageError) Error() string {
+	msg := fmt.Sprintf("no content from %s: %s", e.URL, e.Reason)
+	if d := e.Diagnostics; !d.Empty() {
+		switch {
+		case len(d.Exceptions) > 0:
+			msg += " (" + d.Exceptions[0] + ")"
+		case len(d.FailedRequests) > 0:
+			msg += " (" + d.FailedRequests[0].String() + ")"
+		}
+	}
+	return msg
+}
@@ -0,0 +1,50 @@
+package fetcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewPageError(t *testing.T) {
+	failed := FailedRequest{URL: "https://example.com/app.js", Type: "Script", Reason: "net::ERR_NAME_NOT_RESOLVED"}
+	tests := []struct {
+		name   string
+		diag   *Diagnostics
+		reason string
+		detail string
+	}{
+		{"no diagnostics", nil, ReasonEmpty, ""},
+		{"console errors only", &Diagnostics{ConsoleErrors: []string{"deprecated API"}}, ReasonEmpty, ""},
+		{"failed requests", &Diagnostics{FailedRequests: []FailedRequest{failed}}, ReasonRequestsFailed, "net::ERR_NAME_NOT_RESOLVED"},
+		{"exceptions", &Diagnostics{Exceptions: []string{"TypeError: x is undefined"}, FailedRequests: []FailedRequest{failed}}, ReasonJSCrashed, "TypeError: x is undefined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewPageError("https://example.com", tt.diag)
+			if err.Reason != tt.reason {
+				t.Errorf("Expected reason %q, got %q", tt.reason, err.Reason)
+			}
+			msg := err.Error()
+			if !strings.HasPrefix(msg, "no content from https://example.com: "+tt.reason) || !strings.Contains(msg, tt.detail) {
+				t.Errorf("Unexpected message %q", msg)
+			}
+		})
+	}
+}
+
+func TestDiagnostics_String(t *testing.T) {
+	var d *Diagnostics
+	if !d.Empty() || d.String() != "" {
+		t.Error("Expected nil diagnostics to be empty")
+	}
+
+	d = &Diagnostics{
+		Exceptions:     []string{"boom"},
+		FailedRequests: []FailedRequest{{URL: "https://example.com/api", Type: "XHR", Status: 503}},
+	}
+	want := "exception: boom\nfailed request: XHR https://example.com/api: status 503"
+	if d.Empty() || d.String() != want {
+		t.Errorf("Expected %q, got %q", want, d.String())
+	}
+}
@@ -1,10 +1,89 @@
 package fetcher
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// WaitStrategy controls when a fetched page is considered ready for extraction.
+type WaitStrategy string
+
+const (
+	// WaitLoad waits for the page load event (default).
+	WaitLoad WaitStrategy = "load"
+	// WaitNetworkIdle additionally waits until no new resources have been requested for a short period,
+	// which helps with client-side rendered pages.
+	WaitNetworkIdle WaitStrategy = "networkidle"
+)
+
+// FetchRequest describes what to fetch and how.
+type FetchRequest struct {
+	URL          string            // Page to load; with HTML set, only used as the base for relative links and as FinalURL
+	HTML         string            // Document to extract from instead of navigating to URL
+	WaitStrategy WaitStrategy      // Empty means WaitLoad
+	Headers      map[string]string // Extra HTTP headers sent with the request
+	Screenshot   bool              // Capture a full-page PNG screenshot
+	MaxBytes     int               // Truncate extracted Text, HTML and Markdown to this size; 0 means unlimited
+}
+
+// Metadata holds descriptive information about a fetched page.
+type Metadata struct {
+	Title         string `json:"title,omitempty"`
+	Description   string `json:"description,omitempty"`
+	SiteName      string `json:"site_name,omitempty"`
+	Author        string `json:"author,omitempty"`
+	PublishedTime string `json:"published_time,omitempty"`
+	Language      string `json:"language,omitempty"`
+	CanonicalURL  string `json:"canonical_url,omitempty"`
+	Audio         string `json:"audio,omitempty"` // Audio of the page's episode (og:audio), e.g. on podcast pages
+}
+
+// FetchResult is the content extracted from a fetched page.
+type FetchResult struct {
+	Text       string   // Main textual content with whitespace collapsed
+	HTML       string   // Cleaned HTML of the page body
+	Markdown   string   // Markdown rendering of the cleaned body
+	Metadata   Metadata // Page metadata
+	StatusCode int      // HTTP status code, 0 if unknown
+	FinalURL   string   // URL after redirects
+	Screenshot []byte   // PNG screenshot, only set when requested
+	Video      *Video   // Video metadata and chapters, nil unless the page is a video
+	Meeting    *Meeting // Meeting metadata, nil unless Text is the transcript of a meeting
+
+	Diagnostics *Diagnostics // Problems the browser saw while loading; nil if none or without a browser
+}
 
 // Fetcher defines the interface for retrieving content from a URL.
 type Fetcher interface {
-	// Fetch retrieves the main textual content from the given URL.
+	// Fetch retrieves the main content described by req.
 	// It should prioritize fetching content in reader mode if possible.
-	Fetch(ctx context.Context, url string) (content string, err error)
+	Fetch(ctx context.Context, req FetchRequest) (*FetchResult, error)
+}
+
+// Engine is a Fetcher together with the settings and lifecycle the commands manage.
+type Engine interface {
+	Fetcher
+	SetTimeouts(navigation, extraction time.Duration)
+	// Check reports whether the fetcher can currently serve requests (e.g. the browser responds).
+	Check(ctx context.Context) error
+	Close()
+}
+
+// Fetcher profiles accepted by New.
+const (
+	ProfileChrome = "chrome" // Headless Chrome via ChromeDP; runs JavaScript and takes screenshots
+	ProfileHTTP   = "http"   // Plain HTTP with readability-style extraction; needs no browser
+)
+
+// truncate shortens s to at most maxBytes without splitting a UTF-8 sequence.
+func truncate(s string, maxBytes int) string {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	// Back off to the start of a rune (continuation bytes are 10xxxxxx)
+	for cut > 0 && s[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return s[:cut]
 }
@@ -0,0 +1,162 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/kznrluk/describe-kun/internal/retry"
+	"github.com/kznrluk/describe-kun/internal/timeout"
+)
+
+// httpMaxBodyBytes limits how much of a response the HTTP fetcher reads.
+const httpMaxBodyBytes = 10 << 20
+
+// httpUserAgent identifies the HTTP fetcher; some sites refuse requests without a browser-like agent.
+const httpUserAgent = "Mozilla/5.0 (compatible; describe-kun/1.0; +https://github.com/kznrluk/describe-kun)"
+
+// HTTPFetcher implements the Fetcher interface with plain HTTP requests and a readability-style
+// extractor. It needs no browser, but cannot run JavaScript (client-side rendered pages come back
+// mostly empty) or take screenshots.
+type HTTPFetcher struct {
+	client *http.Client
+
+	navigationTimeout time.Duration // Limit for downloading the page, 0 means none
+}
+
+// NewHTTPFetcher creates a new HTTP fetcher.
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{client: &http.Client{}}
+}
+
+// SetTimeouts sets the limit for downloading a page. Extraction runs in-process without
+// network access, so the extraction limit is not used.
+func (f *HTTPFetcher) SetTimeouts(navigation, extraction time.Duration) {
+	f.navigationTimeout = navigation
+}
+
+// Check always succeeds: the HTTP fetcher has no browser that could be down.
+func (f *HTTPFetcher) Check(ctx context.Context) error {
+	return nil
+}
+
+// Close releases resources; the HTTP fetcher holds none beyond idle connections.
+func (f *HTTPFetcher) Close() {
+	f.client.CloseIdleConnections()
+}
+
+// Fetch downloads (or, with req.HTML set, parses) the page described by req and extracts its main content.
+func (f *HTTPFetcher) Fetch(ctx context.Context, req FetchRequest) (*FetchResult, error) {
+	url := req.URL
+	if req.Screenshot {
+		return nil, fmt.Errorf("screenshots of %s require the browser fetcher", url)
+	}
+
+	if req.HTML != "" {
+		log.Printf("[Fetcher] Parsing provided HTML (%d bytes)...", len(req.HTML))
+		doc, err := html.Parse(strings.NewReader(req.HTML))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse HTML for %s: %w", url, err)
+		}
+		result := extractDocument(doc, url)
+		result.FinalURL = url
+		return finish(result, req.MaxBytes), nil
+	}
+
+	reqmeta.Logf(ctx, "[Fetcher] Downloading %s...", url)
+	start := time.Now()
+	var result *FetchResult
+	err := retry.Do(ctx, retry.Current(), "Fetching "+url, func(ctx context.Context) error {
+		return timeout.Run(ctx, timeout.Navigation, f.navigationTimeout, func(ctx context.Context) error {
+			var err error
+			result, err = f.download(ctx, req)
+			if err == nil && retry.RetryableStatus(result.StatusCode) {
+				return &retry.StatusError{Code: result.StatusCode, Err: fmt.Errorf("received status code %d", result.StatusCode)}
+			}
+			return err
+		})
+	})
+	var se *retry.StatusError
+	if err != nil && !errors.As(err, &se) {
+		// A status that is still retryable after the last attempt is reported below like any other
+		var te *timeout.Error
+		if errors.As(err, &te) {
+			return nil, fmt.Errorf("fetching %s: %w", url, err)
+		}
+		return nil, fmt.Errorf("failed to fetch content from %s: %w", url, err)
+	}
+	log.Printf("[Fetcher] Downloaded and extracted %s after %s", url, time.Since(start))
+
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		return nil, fmt.Errorf("received non-2xx status code %d for %s", result.StatusCode, url)
+	}
+	return finish(result, req.MaxBytes), nil
+}
+
+// download performs the request and extracts the response body.
+func (f *HTTPFetcher) download(ctx context.Context, req FetchRequest) (*FetchResult, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("User-Agent", httpUserAgent)
+	httpReq.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.8")
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := f.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	finalURL := resp.Request.URL.String()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &FetchResult{StatusCode: resp.StatusCode, FinalURL: finalURL}, nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	body, err := charset.NewReader(io.LimitReader(resp.Body, httpMaxBodyBytes), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	var result *FetchResult
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "text/plain" {
+		text, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("reading response: %w", err)
+		}
+		result = &FetchResult{Text: string(text), Markdown: string(text)}
+	} else if mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		doc, err := html.Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("parsing HTML: %w", err)
+		}
+		result = extractDocument(doc, finalURL)
+	} else {
+		return nil, fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	result.StatusCode = resp.StatusCode
+	result.FinalURL = finalURL
+	return result, nil
+}
+
+// finish applies the whitespace cleanup and size limit shared with the browser fetcher.
+func finish(result *FetchResult, maxBytes int) *FetchResult {
+	result.Text = strings.Join(strings.Fields(result.Text), " ")
+	result.Text = truncate(result.Text, maxBytes)
+	result.HTML = truncate(result.HTML, maxBytes)
+	result.Markdown = truncate(result.Markdown, maxBytes)
+	return result
+}
@@ -0,0 +1,180 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const articleHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <title>Fallback Title</title>
+    <meta property="og:title" content="Release notes">
+    <meta name="description" content="What changed in 2.0">
+    <meta property="og:site_name" content="Example Blog">
+    <meta name="author" content="Alice">
+    <link rel="canonical" href="/posts/2.0">
+    <meta property="og:audio" content="/media/2.0.mp3">
+    <script>var tracking = "ignore me";</script>
+</head>
+<body>
+    <nav><a href="/">Home</a> <a href="/about">About</a></nav>
+    <div class="sidebar"><p>Subscribe to our newsletter!</p></div>
+    <div class="post">
+        <h2>Highlights</h2>
+        <p>Version 2.0 rewrites the scheduler so that jobs start <strong>twice as fast</strong> on large clusters.</p>
+        <p>The configuration format is unchanged, but see the <a href="/docs/migrate">migration guide</a> for deprecated flags.</p>
+        <ul><li>Faster scheduling</li><li>Smaller memory footprint</li></ul>
+        <p>Upgrading from 1.x requires restarting every worker once, after which rolling upgrades work as before.</p>
+    </div>
+    <footer>Copyright Example</footer>
+</body>
+</html>`
+
+func TestHTTPFetcher_Fetch(t *testing.T) {
+	var gotHeader string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/posts/2.0", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/posts/2.0", func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Test")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articleHTML))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f := NewHTTPFetcher()
+	defer f.Close()
+	result, err := f.Fetch(context.Background(), FetchRequest{URL: server.URL + "/old", Headers: map[string]string{"X-Test": "yes"}})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if gotHeader != "yes" {
+		t.Error("Expected the extra header to be sent")
+	}
+	if result.FinalURL != server.URL+"/posts/2.0" || result.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected final URL %q or status %d", result.FinalURL, result.StatusCode)
+	}
+	want := Metadata{
+		Title:        "Release notes",
+		Description:  "What changed in 2.0",
+		SiteName:     "Example Blog",
+		Author:       "Alice",
+		Language:     "en",
+		CanonicalURL: server.URL + "/posts/2.0",
+		Audio:        server.URL + "/media/2.0.mp3",
+	}
+	if result.Metadata != want {
+		t.Errorf("Unexpected metadata %+v", result.Metadata)
+	}
+	if !strings.Contains(result.Text, "jobs start twice as fast on large clusters") || !strings.Contains(result.Text, "Smaller memory footprint") {
+		t.Errorf("Expected the article text, got %q", result.Text)
+	}
+	for _, boilerplate := range []string{"Subscribe", "Home", "Copyright", "tracking"} {
+		if strings.Contains(result.Text, boilerplate) {
+			t.Errorf("Expected %q to be left out, got %q", boilerplate, result.Text)
+		}
+	}
+	for _, md := range []string{"## Highlights", "**twice as fast**", "[migration guide](" + server.URL + "/docs/migrate)", "- Faster scheduling\n- Smaller memory footprint"} {
+		if !strings.Contains(result.Markdown, md) {
+			t.Errorf("Expected %q in Markdown, got %q", md, result.Markdown)
+		}
+	}
+	if result.Video != nil {
+		t.Errorf("Expected no video, got %+v", result.Video)
+	}
+}
+
+func TestHTTPFetcher_Fetch_Charset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+		// "こんにちは" in Shift_JIS
+		w.Write([]byte("<html><body><p>\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd</p></body></html>"))
+	}))
+	defer server.Close()
+
+	result, err := NewHTTPFetcher().Fetch(context.Background(), FetchRequest{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if result.Text != "こんにちは" {
+		t.Errorf("Expected decoded text, got %q", result.Text)
+	}
+}
+
+func TestHTTPFetcher_Fetch_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/file.zip":
+			w.Header().Set("Content-Type", "application/zip")
+		}
+	}))
+	defer server.Close()
+
+	f := NewHTTPFetcher()
+	f.SetTimeouts(50*time.Millisecond, 0)
+	if _, err := f.Fetch(context.Background(), FetchRequest{URL: server.URL + "/missing"}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected a status code error, got %v", err)
+	}
+	if _, err := f.Fetch(context.Background(), FetchRequest{URL: server.URL + "/slow"}); err == nil || !strings.Contains(err.Error(), "navigation timeout") {
+		t.Errorf("Expected a navigation timeout, got %v", err)
+	}
+	if _, err := f.Fetch(context.Background(), FetchRequest{URL: server.URL + "/file.zip"}); err == nil {
+		t.Error("Expected an error for unsupported content")
+	}
+	if _, err := f.Fetch(context.Background(), FetchRequest{URL: server.URL, Screenshot: true}); err == nil {
+		t.Error("Expected an error for screenshots")
+	}
+}
+
+func TestHTTPFetcher_Fetch_Retry(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(articleHTML))
+	}))
+	defer server.Close()
+
+	result, err := NewHTTPFetcher().Fetch(context.Background(), FetchRequest{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if attempts != 2 || !strings.Contains(result.Text, "twice as fast") {
+		t.Errorf("Expected the page after one retry, got %d attempts", attempts)
+	}
+}
+
+func TestHTTPFetcher_Fetch_HTML(t *testing.T) {
+	doc := `<html><head><meta property="og:type" content="video.other"><meta property="og:site_name" content="Vimeo">
+<meta property="og:description" content="0:00 Intro
+1:30 Demo"></head><body><p>Watch the <a href="demo">demo</a></p></body></html>`
+
+	result, err := NewHTTPFetcher().Fetch(context.Background(), FetchRequest{URL: "https://example.com/videos/", HTML: doc, MaxBytes: 9})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if result.FinalURL != "https://example.com/videos/" {
+		t.Errorf("Expected the base URL as final URL, got %q", result.FinalURL)
+	}
+	if result.Text != "Watch the" {
+		t.Errorf("Expected truncated text, got %q", result.Text)
+	}
+	if result.Video == nil || result.Video.Platform != "vimeo" || len(result.Video.Chapters) != 2 {
+		t.Errorf("Expected a video with two chapters, got %+v", result.Video)
+	}
+}
@@ -0,0 +1,64 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+// mediaExtensions are the audio and video file formats the transcription API accepts.
+var mediaExtensions = map[string]bool{
+	".flac": true, ".m4a": true, ".mp3": true, ".mp4": true, ".mpeg": true,
+	".mpga": true, ".oga": true, ".ogg": true, ".wav": true, ".webm": true,
+}
+
+// MediaFile returns the file name of the audio or video file rawURL links to, e.g. "episode-42.mp3", or ""
+// if it links to something else.
+func MediaFile(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if !mediaExtensions[strings.ToLower(path.Ext(name))] {
+		return ""
+	}
+	return name
+}
+
+// Download reads the file at rawURL, failing if it is larger than maxBytes.
+func Download(ctx context.Context, rawURL string, maxBytes int64) ([]byte, error) {
+	var body []byte
+	err := retry.Do(ctx, retry.Current(), "Downloading "+rawURL, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return retry.FromResponse(resp, fmt.Errorf("%s", resp.Status))
+		}
+		if resp.ContentLength > maxBytes {
+			return retry.Permanent(fmt.Errorf("the file is %d MB, more than the %d MB allowed", resp.ContentLength>>20, maxBytes>>20))
+		}
+		body, err = io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+		if err == nil && int64(len(body)) > maxBytes {
+			return retry.Permanent(fmt.Errorf("the file is more than the %d MB allowed", maxBytes>>20))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+	return body, nil
+}
@@ -0,0 +1,322 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/auth/credentials"
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+// Meeting holds metadata of a meeting recording whose transcript was fetched.
+type Meeting struct {
+	Platform        string `json:"platform"` // "zoom" or "google-meet"
+	Topic           string `json:"topic"`
+	StartTime       string `json:"start_time,omitempty"` // As reported by the platform, e.g. RFC 3339
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+}
+
+// TokenFunc returns an OAuth access token for an API.
+type TokenFunc func(ctx context.Context) (string, error)
+
+// Meetings wraps a Fetcher so that links to Zoom recordings and Google Meet transcripts are read
+// through the platforms' APIs, which serve the transcript that the pages themselves only show
+// after a login. Other URLs, and meeting links of a platform without a token, are passed through.
+type Meetings struct {
+	fetcher Fetcher
+	zoom    TokenFunc // nil disables Zoom
+	google  TokenFunc // nil disables Google Meet
+
+	client   *http.Client
+	zoomAPI  string // Base URL of the Zoom API, replaced in tests
+	driveAPI string // Base URL of the Google Drive API, replaced in tests
+}
+
+// NewMeetings wraps f, reading Zoom recordings with tokens from zoom and Google Meet transcripts
+// with tokens from google. Either may be nil.
+func NewMeetings(f Fetcher, zoom, google TokenFunc) *Meetings {
+	return &Meetings{
+		fetcher:  f,
+		zoom:     zoom,
+		google:   google,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		zoomAPI:  "https://api.zoom.us/v2",
+		driveAPI: "https://www.googleapis.com/drive/v3",
+	}
+}
+
+var (
+	// zoomJoinRegex matches the meeting ID of a Zoom join link, e.g. https://example.zoom.us/j/85012345678.
+	zoomJoinRegex = regexp.MustCompile(`^/(?:j|w|my)/(\d{9,12})$`)
+	// googleDocRegex matches the document ID of a Google Docs link, where Meet saves transcripts.
+	googleDocRegex = regexp.MustCompile(`^/document/d/([a-zA-Z0-9_-]+)`)
+	// transcriptTitleRegex matches the titles Meet gives the documents it saves: transcripts and notes
+	// taken by Gemini, in English and Japanese.
+	transcriptTitleRegex = regexp.MustCompile(`(?i)transcript|notes by gemini|文字起こし|Gemini によるメモ`)
+)
+
+// Fetch reads the transcript of a meeting link, or passes req to the wrapped fetcher.
+func (m *Meetings) Fetch(ctx context.Context, req FetchRequest) (*FetchResult, error) {
+	if req.HTML == "" {
+		if id := zoomMeetingID(req.URL); id != "" && m.zoom != nil {
+			return m.fetchZoom(ctx, req, id)
+		}
+		if id := googleDocID(req.URL); id != "" && m.google != nil {
+			result, err := m.fetchGoogle(ctx, req, id)
+			if result != nil || err != nil {
+				return result, err
+			}
+		}
+	}
+	return m.fetcher.Fetch(ctx, req)
+}
+
+// zoomMeetingID returns the meeting ID or UUID in a Zoom join or recording link, or "".
+// Share links (/rec/share/...) don't reveal the meeting and are not supported.
+func zoomMeetingID(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Hostname() != "zoom.us" && !strings.HasSuffix(u.Hostname(), ".zoom.us")) {
+		return ""
+	}
+	if m := zoomJoinRegex.FindStringSubmatch(u.Path); m != nil {
+		return m[1]
+	}
+	if strings.HasPrefix(u.Path, "/recording/detail") {
+		return u.Query().Get("meeting_id")
+	}
+	return ""
+}
+
+// googleDocID returns the ID of the Google Docs document rawURL links to, or "".
+func googleDocID(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() != "docs.google.com" {
+		return ""
+	}
+	if m := googleDocRegex.FindStringSubmatch(u.Path); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// fetchZoom reads the transcript of the cloud recording of meeting id.
+func (m *Meetings) fetchZoom(ctx context.Context, req FetchRequest, id string) (*FetchResult, error) {
+	// UUIDs starting with "/" or containing "//" must be encoded twice
+	escaped := url.PathEscape(id)
+	if strings.HasPrefix(id, "/") || strings.Contains(id, "//") {
+		escaped = url.PathEscape(escaped)
+	}
+	var recording struct {
+		Topic          string `json:"topic"`
+		StartTime      string `json:"start_time"`
+		Duration       int    `json:"duration"`
+		RecordingFiles []struct {
+			FileType    string `json:"file_type"`
+			DownloadURL string `json:"download_url"`
+		} `json:"recording_files"`
+	}
+	body, err := m.get(ctx, m.zoom, m.zoomAPI+"/meetings/"+escaped+"/recordings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the Zoom recording of %s: %w", req.URL, err)
+	}
+	if err := json.Unmarshal(body, &recording); err != nil {
+		return nil, fmt.Errorf("failed to parse the Zoom recording of %s: %w", req.URL, err)
+	}
+
+	var transcriptURL string
+	for _, f := range recording.RecordingFiles {
+		if f.FileType == "TRANSCRIPT" {
+			transcriptURL = f.DownloadURL
+			break
+		}
+	}
+	if transcriptURL == "" {
+		return nil, fmt.Errorf("the Zoom recording of %s has no transcript; enable audio transcripts in the Zoom recording settings", req.URL)
+	}
+	vtt, err := m.get(ctx, m.zoom, transcriptURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the Zoom transcript of %s: %w", req.URL, err)
+	}
+
+	return m.result(req, ParseVTT(string(vtt)), &Meeting{
+		Platform:        "zoom",
+		Topic:           recording.Topic,
+		StartTime:       recording.StartTime,
+		DurationMinutes: recording.Duration,
+	}), nil
+}
+
+// fetchGoogle reads the Google Docs document id if Meet saved it as a transcript or notes.
+// It returns nil without an error for other documents, which are left to the wrapped fetcher.
+func (m *Meetings) fetchGoogle(ctx context.Context, req FetchRequest, id string) (*FetchResult, error) {
+	var file struct {
+		Name        string `json:"name"`
+		CreatedTime string `json:"createdTime"`
+	}
+	body, err := m.get(ctx, m.google, m.driveAPI+"/files/"+url.PathEscape(id)+"?fields=name,createdTime&supportsAllDrives=true")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Google document %s: %w", req.URL, err)
+	}
+	if err := json.Unmarshal(body, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse Google document %s: %w", req.URL, err)
+	}
+	if !transcriptTitleRegex.MatchString(file.Name) {
+		return nil, nil
+	}
+	text, err := m.get(ctx, m.google, m.driveAPI+"/files/"+url.PathEscape(id)+"/export?mimeType=text/plain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export Google document %s: %w", req.URL, err)
+	}
+
+	return m.result(req, strings.TrimSpace(strings.TrimPrefix(string(text), "\ufeff")), &Meeting{
+		Platform:  "google-meet",
+		Topic:     file.Name,
+		StartTime: file.CreatedTime,
+	}), nil
+}
+
+// result builds the fetch result of a transcript.
+func (m *Meetings) result(req FetchRequest, transcript string, meeting *Meeting) *FetchResult {
+	text := truncate(transcript, req.MaxBytes)
+	return &FetchResult{
+		Text:       text,
+		Markdown:   text,
+		Metadata:   Metadata{Title: meeting.Topic, PublishedTime: meeting.StartTime},
+		StatusCode: http.StatusOK,
+		FinalURL:   req.URL,
+		Meeting:    meeting,
+	}
+}
+
+// get fetches url with a bearer token from token, retrying transient failures.
+func (m *Meetings) get(ctx context.Context, token TokenFunc, url string) ([]byte, error) {
+	var body []byte
+	err := retry.Do(ctx, retry.Current(), "Fetching "+url, func(ctx context.Context) error {
+		t, err := token(ctx)
+		if err != nil {
+			return retry.Permanent(fmt.Errorf("failed to get an access token: %w", err))
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+t)
+		resp, err := m.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return retry.FromResponse(resp, fmt.Errorf("%s", resp.Status))
+		}
+		body, err = io.ReadAll(resp.Body)
+		return err
+	})
+	return body, err
+}
+
+// vttCueRegex matches the timing line of a WebVTT cue, e.g. "00:01:02.345 --> 00:01:05.000".
+var vttCueRegex = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2})\.\d{3} --> `)
+
+// ParseVTT turns a WebVTT transcript, as Zoom produces, into "[hh:mm:ss] Speaker: text" lines.
+// Consecutive cues of the same speaker are joined into one line.
+func ParseVTT(vtt string) string {
+	var lines []string
+	var speaker string
+	var start string
+	for _, block := range strings.Split(strings.ReplaceAll(vtt, "\r\n", "\n"), "\n\n") {
+		var ts string
+		var text []string
+		for _, line := range strings.Split(strings.TrimSpace(block), "\n") {
+			if m := vttCueRegex.FindStringSubmatch(line); m != nil {
+				ts = m[1]
+			} else if ts != "" && line != "" {
+				text = append(text, line)
+			}
+		}
+		if ts == "" || len(text) == 0 {
+			continue // Header, note or cue number only
+		}
+		s, t := "", strings.Join(text, " ")
+		if i := strings.Index(t, ": "); i > 0 && i < 60 {
+			s, t = t[:i], t[i+2:]
+		}
+		if len(lines) > 0 && s == speaker {
+			lines[len(lines)-1] += " " + t
+			continue
+		}
+		speaker, start = s, ts
+		if s == "" {
+			lines = append(lines, fmt.Sprintf("[%s] %s", start, t))
+		} else {
+			lines = append(lines, fmt.Sprintf("[%s] %s: %s", start, s, t))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ZoomToken returns a TokenFunc getting tokens of a Zoom Server-to-Server OAuth app, which needs
+// the cloud_recording:read:list_recording_files:admin scope (or recording:read:admin). Tokens are
+// cached until shortly before they expire.
+func ZoomToken(accountID, clientID, clientSecret string) TokenFunc {
+	var mu sync.Mutex
+	var token string
+	var expiry time.Time
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if token != "" && time.Now().Before(expiry) {
+			return token, nil
+		}
+		endpoint := "https://zoom.us/oauth/token?grant_type=account_credentials&account_id=" + url.QueryEscape(accountID)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+		if err != nil {
+			return "", err
+		}
+		req.SetBasicAuth(clientID, clientSecret)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("zoom token endpoint returned %s", resp.Status)
+		}
+		var body struct {
+			AccessToken string `json:"access_token"`
+			ExpiresIn   int    `json:"expires_in"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", err
+		}
+		token, expiry = body.AccessToken, time.Now().Add(time.Duration(body.ExpiresIn)*time.Second-time.Minute)
+		return token, nil
+	}
+}
+
+// GoogleToken returns a TokenFunc getting tokens of the application default credentials (e.g. a
+// service account in GOOGLE_APPLICATION_CREDENTIALS) with read access to Google Drive. Transcripts
+// must be shared with the account.
+func GoogleToken() (TokenFunc, error) {
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes: []string{"https://www.googleapis.com/auth/drive.readonly"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) (string, error) {
+		t, err := creds.Token(ctx)
+		if err != nil {
+			return "", err
+		}
+		return t.Value, nil
+	}, nil
+}
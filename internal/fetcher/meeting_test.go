@@ -0,0 +1,109 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseVTT(t *testing.T) {
+	vtt := "WEBVTT\n\n1\n00:00:01.000 --> 00:00:04.000\nAlice: Let's ship on Friday.\n\n2\n00:00:04.500 --> 00:00:06.000\nAlice: Bob, can you write the notes?\n\n3\n00:00:07.000 --> 00:00:09.000\nBob: Sure.\n"
+	want := "[00:00:01] Alice: Let's ship on Friday. Bob, can you write the notes?\n[00:00:07] Bob: Sure."
+	if got := ParseVTT(vtt); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestMeetingIDs(t *testing.T) {
+	tests := []struct {
+		url  string
+		zoom string
+		doc  string
+	}{
+		{"https://example.zoom.us/j/85012345678?pwd=abc", "85012345678", ""},
+		{"https://zoom.us/recording/detail?meeting_id=abc%2F%2Fdef%3D%3D", "abc//def==", ""},
+		{"https://zoom.us/rec/share/xyz", "", ""},
+		{"https://zoom.us.example.com/j/85012345678", "", ""},
+		{"https://docs.google.com/document/d/1AbC_d-E/edit", "", "1AbC_d-E"},
+		{"https://docs.google.com/spreadsheets/d/1AbC/edit", "", ""},
+	}
+	for _, tt := range tests {
+		if got := zoomMeetingID(tt.url); got != tt.zoom {
+			t.Errorf("zoomMeetingID(%q) = %q, want %q", tt.url, got, tt.zoom)
+		}
+		if got := googleDocID(tt.url); got != tt.doc {
+			t.Errorf("googleDocID(%q) = %q, want %q", tt.url, got, tt.doc)
+		}
+	}
+}
+
+func TestMeetings(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/zoom/meetings/85012345678/recordings":
+			w.Write([]byte(`{"topic":"Weekly sync","start_time":"2026-01-05T10:00:00Z","duration":30,"recording_files":[
+				{"file_type":"MP4","download_url":"` + srv.URL + `/video"},
+				{"file_type":"TRANSCRIPT","download_url":"` + srv.URL + `/transcript"}]}`))
+		case "/transcript":
+			w.Write([]byte("WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\nAlice: We ship Friday.\n"))
+		case "/drive/files/transcript-doc":
+			w.Write([]byte(`{"name":"Weekly sync - 2026/01/05 - Transcript","createdTime":"2026-01-05T10:30:00Z"}`))
+		case "/drive/files/transcript-doc/export":
+			w.Write([]byte("\ufeffAlice: We ship Friday.\r\n"))
+		case "/drive/files/other-doc":
+			w.Write([]byte(`{"name":"Design doc"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	token := func(ctx context.Context) (string, error) { return "token", nil }
+	m := NewMeetings(stubFetcher{text: "page"}, token, token)
+	m.zoomAPI, m.driveAPI = srv.URL+"/zoom", srv.URL+"/drive"
+
+	result, err := m.Fetch(context.Background(), FetchRequest{URL: "https://example.zoom.us/j/85012345678"})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if result.Meeting == nil || result.Meeting.Topic != "Weekly sync" || result.Meeting.DurationMinutes != 30 || result.Text != "[00:00:01] Alice: We ship Friday." {
+		t.Errorf("Expected the Zoom transcript, got %+v", result)
+	}
+
+	result, err = m.Fetch(context.Background(), FetchRequest{URL: "https://docs.google.com/document/d/transcript-doc/edit"})
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if result.Meeting == nil || result.Meeting.Platform != "google-meet" || result.Text != "Alice: We ship Friday." {
+		t.Errorf("Expected the Meet transcript, got %+v", result)
+	}
+
+	// Other documents and links are fetched as pages
+	for _, url := range []string{"https://docs.google.com/document/d/other-doc/edit", "https://example.com/"} {
+		result, err = m.Fetch(context.Background(), FetchRequest{URL: url})
+		if err != nil || result.Meeting != nil || result.Text != "page" {
+			t.Errorf("Expected %s to be passed through, got %+v, %v", url, result, err)
+		}
+	}
+
+	_, err = m.Fetch(context.Background(), FetchRequest{URL: "https://example.zoom.us/j/99912345678"})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
+
+// stubFetcher returns text for every request.
+type stubFetcher struct {
+	text string
+}
+
+func (f stubFetcher) Fetch(ctx context.Context, req FetchRequest) (*FetchResult, error) {
+	return &FetchResult{Text: f.text, FinalURL: req.URL}, nil
+}
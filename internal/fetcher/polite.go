@@ -0,0 +1,92 @@
+package fetcher
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Polite wraps a Fetcher so that no single site gets more than a few concurrent requests, with a
+// pause between requests to the same host, for batch work that would otherwise hit one site from
+// every worker at once and risk getting banned.
+type Polite struct {
+	fetcher Fetcher
+	perHost int           // Concurrent fetches allowed per host
+	delay   time.Duration // Minimum time between the starts of two fetches from one host
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+// hostLimit is the politeness state of one host.
+type hostLimit struct {
+	slots chan struct{} // Holds a token per running fetch
+	next  time.Time     // Earliest start of the next fetch; guarded by Polite.mu
+}
+
+// NewPolite wraps f, allowing perHost concurrent fetches per host (at least 1) started at least delay apart.
+func NewPolite(f Fetcher, perHost int, delay time.Duration) *Polite {
+	if perHost < 1 {
+		perHost = 1
+	}
+	return &Polite{fetcher: f, perHost: perHost, delay: delay, hosts: make(map[string]*hostLimit)}
+}
+
+// Fetch waits for the host of req.URL to be free, then fetches it. Requests with their own HTML
+// don't touch the network and are passed through.
+func (p *Polite) Fetch(ctx context.Context, req FetchRequest) (*FetchResult, error) {
+	host := hostOf(req.URL)
+	if req.HTML != "" || host == "" {
+		return p.fetcher.Fetch(ctx, req)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	h := p.hosts[host]
+	if h == nil {
+		h = &hostLimit{slots: make(chan struct{}, p.perHost)}
+		p.hosts[host] = h
+	}
+	p.mu.Unlock()
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-h.slots }()
+
+	// Reserve the next start time, so concurrent fetches are spread out too
+	p.mu.Lock()
+	now := time.Now()
+	start := h.next
+	if start.Before(now) {
+		start = now
+	}
+	h.next = start.Add(p.delay)
+	p.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return p.fetcher.Fetch(ctx, req)
+}
+
+// hostOf returns the lowercased host name of rawURL, or "" if it has none.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
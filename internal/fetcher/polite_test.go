@@ -0,0 +1,71 @@
+package fetcher
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingFetcher records how many fetches run at once and when each started.
+type countingFetcher struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+	starts  []time.Time
+}
+
+func (f *countingFetcher) Fetch(ctx context.Context, req FetchRequest) (*FetchResult, error) {
+	f.mu.Lock()
+	f.running++
+	f.peak = max(f.peak, f.running)
+	f.starts = append(f.starts, time.Now())
+	f.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	f.mu.Lock()
+	f.running--
+	f.mu.Unlock()
+	return &FetchResult{Text: "ok", FinalURL: req.URL}, nil
+}
+
+func TestPolite(t *testing.T) {
+	inner := &countingFetcher{}
+	p := NewPolite(inner, 2, 30*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for _, u := range []string{"https://a.example/1", "https://A.example/2", "https://a.example/3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Fetch(context.Background(), FetchRequest{URL: u}); err != nil {
+				t.Errorf("Fetch failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if inner.peak > 2 {
+		t.Errorf("Expected at most 2 concurrent fetches per host, got %d", inner.peak)
+	}
+	first, last := inner.starts[0], inner.starts[len(inner.starts)-1]
+	if gap := last.Sub(first); gap < 55*time.Millisecond {
+		t.Errorf("Expected fetches to be spread at least 30ms apart, got %s for three", gap)
+	}
+
+	// Other hosts are not held back by the delay
+	slow := NewPolite(inner, 1, 200*time.Millisecond)
+	slow.Fetch(context.Background(), FetchRequest{URL: "https://a.example/"})
+	start := time.Now()
+	slow.Fetch(context.Background(), FetchRequest{URL: "https://b.example/"})
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected another host to be fetched right away, took %s", elapsed)
+	}
+
+	// A cancelled request stops waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.Fetch(context.Background(), FetchRequest{URL: "https://c.example/"})
+	if _, err := p.Fetch(ctx, FetchRequest{URL: "https://c.example/"}); err == nil {
+		t.Error("Expected an error for a cancelled request")
+	}
+}
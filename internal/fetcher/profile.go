@@ -0,0 +1,20 @@
+//go:build !nochrome
+
+package fetcher
+
+import "fmt"
+
+// New creates the fetcher for profile: ProfileChrome (the default when empty) or ProfileHTTP.
+func New(profile string) (Engine, error) {
+	switch profile {
+	case "", ProfileChrome:
+		f, err := NewChromeDPFetcher()
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case ProfileHTTP:
+		return NewHTTPFetcher(), nil
+	}
+	return nil, fmt.Errorf("unknown fetcher profile %q (use %q or %q)", profile, ProfileChrome, ProfileHTTP)
+}
@@ -0,0 +1,17 @@
+//go:build nochrome
+
+package fetcher
+
+import "fmt"
+
+// New creates the fetcher for profile. Binaries built with the nochrome tag only include
+// ProfileHTTP, which is also the default.
+func New(profile string) (Engine, error) {
+	switch profile {
+	case "", ProfileHTTP:
+		return NewHTTPFetcher(), nil
+	case ProfileChrome:
+		return nil, fmt.Errorf("fetcher profile %q is not available: this binary was built with the nochrome tag", profile)
+	}
+	return nil, fmt.Errorf("unknown fetcher profile %q (only %q is available)", profile, ProfileHTTP)
+}
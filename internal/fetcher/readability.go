@@ -0,0 +1,387 @@
+package fetcher
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// minContentChars is the amount of paragraph text a candidate needs to be picked over the whole body.
+const minContentChars = 200
+
+// removedAtoms are elements that never hold main content, mirroring the browser fetcher's cleanup.
+var removedAtoms = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Nav:      true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+}
+
+// extractDocument reads metadata, video details and the main content from a parsed document.
+// baseURL resolves relative links in the Markdown rendering.
+func extractDocument(doc *html.Node, baseURL string) *FetchResult {
+	result := &FetchResult{
+		Metadata: documentMetadata(doc, baseURL),
+		Video:    documentVideo(doc),
+	}
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+	clean(body)
+	root := mainContent(body)
+
+	result.Text = strings.Join(strings.Fields(textOf(root)), " ")
+	var b strings.Builder
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&b, c)
+	}
+	result.HTML = b.String()
+	result.Markdown = renderMarkdown(root, baseURL)
+	return result
+}
+
+// documentMetadata collects page metadata the same way the browser fetcher's metadataScript does.
+func documentMetadata(doc *html.Node, baseURL string) Metadata {
+	meta := metaTags(doc)
+	md := Metadata{
+		Title:         firstNonEmpty(meta["og:title"], documentTitle(doc)),
+		Description:   firstNonEmpty(meta["description"], meta["og:description"]),
+		SiteName:      meta["og:site_name"],
+		Author:        meta["author"],
+		PublishedTime: meta["article:published_time"],
+	}
+	if audio := firstNonEmpty(meta["og:audio"], meta["og:audio:url"]); audio != "" {
+		md.Audio = resolveURL(baseURL, audio)
+	}
+	if h := findFirst(doc, atom.Html); h != nil {
+		md.Language = attr(h, "lang")
+	}
+	walk(doc, func(n *html.Node) bool {
+		if n.DataAtom == atom.Link && hasToken(attr(n, "rel"), "canonical") && md.CanonicalURL == "" {
+			md.CanonicalURL = resolveURL(baseURL, attr(n, "href"))
+		}
+		return true
+	})
+	return md
+}
+
+// documentVideo returns video details for pages declaring og:type video, like the browser fetcher's
+// fallback for non-YouTube platforms. YouTube's player data needs JavaScript and is not available here.
+func documentVideo(doc *html.Node) *Video {
+	meta := metaTags(doc)
+	if !strings.HasPrefix(meta["og:type"], "video") {
+		return nil
+	}
+	duration, _ := strconv.Atoi(meta["video:duration"])
+	v := &Video{
+		Platform:        strings.ToLower(meta["og:site_name"]),
+		Title:           firstNonEmpty(meta["og:title"], documentTitle(doc)),
+		Channel:         meta["author"],
+		DurationSeconds: duration,
+		Description:     firstNonEmpty(meta["og:description"], meta["description"]),
+	}
+	v.Chapters = ParseChapters(v.Description)
+	return v
+}
+
+// metaTags maps meta names and properties to their trimmed content, keeping the first occurrence.
+func metaTags(doc *html.Node) map[string]string {
+	tags := make(map[string]string)
+	walk(doc, func(n *html.Node) bool {
+		if n.DataAtom != atom.Meta {
+			return true
+		}
+		key := firstNonEmpty(attr(n, "property"), attr(n, "name"))
+		if _, seen := tags[key]; key != "" && !seen {
+			tags[key] = strings.TrimSpace(attr(n, "content"))
+		}
+		return true
+	})
+	return tags
+}
+
+// documentTitle returns the trimmed <title> text.
+func documentTitle(doc *html.Node) string {
+	if t := findFirst(doc, atom.Title); t != nil {
+		return strings.TrimSpace(textOf(t))
+	}
+	return ""
+}
+
+// clean removes non-content elements below n.
+func clean(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isBoilerplate(c)) {
+			n.RemoveChild(c)
+		} else {
+			clean(c)
+		}
+		c = next
+	}
+}
+
+// isBoilerplate reports whether an element is navigation, decoration or hidden.
+func isBoilerplate(n *html.Node) bool {
+	if removedAtoms[n.DataAtom] {
+		return true
+	}
+	role := attr(n, "role")
+	return role == "navigation" || role == "complementary" || attr(n, "aria-hidden") == "true" || hasAttr(n, "hidden")
+}
+
+// mainContent picks the element holding the page's main text: an <article> or <main> if present,
+// otherwise the element whose direct paragraphs contain the most text. Short pages use the whole body.
+func mainContent(body *html.Node) *html.Node {
+	for _, a := range []atom.Atom{atom.Article, atom.Main} {
+		if n := findFirst(body, a); n != nil && len(strings.TrimSpace(textOf(n))) >= minContentChars {
+			return n
+		}
+	}
+
+	scores := make(map[*html.Node]int)
+	var best *html.Node
+	walk(body, func(n *html.Node) bool {
+		if n.DataAtom != atom.P || n.Parent == nil {
+			return true
+		}
+		scores[n.Parent] += len(strings.TrimSpace(textOf(n)))
+		if best == nil || scores[n.Parent] > scores[best] {
+			best = n.Parent
+		}
+		return false
+	})
+	if best == nil || scores[best] < minContentChars {
+		return body
+	}
+	return best
+}
+
+// blockAtoms separate their text from the surrounding text.
+var blockAtoms = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true, atom.Td: true, atom.Th: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Pre: true, atom.Blockquote: true, atom.Section: true, atom.Article: true, atom.Header: true,
+}
+
+// textOf returns the text below n, with block elements separated by newlines.
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case n.Type == html.ElementNode && blockAtoms[n.DataAtom]:
+			b.WriteString("\n")
+			defer b.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+var spaces = regexp.MustCompile(`\s+`)
+
+// renderMarkdown renders the content below root as Markdown, covering the same elements as markdownScript.
+func renderMarkdown(root *html.Node, baseURL string) string {
+	var out []string
+	var inline func(*html.Node) string
+	inline = func(n *html.Node) string {
+		var s strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				s.WriteString(spaces.ReplaceAllString(c.Data, " "))
+				continue
+			}
+			if c.Type != html.ElementNode {
+				continue
+			}
+			inner := strings.TrimSpace(inline(c))
+			switch c.DataAtom {
+			case atom.A:
+				if href := resolveURL(baseURL, attr(c, "href")); inner != "" && href != "" {
+					s.WriteString("[" + inner + "](" + href + ")")
+				} else {
+					s.WriteString(inner)
+				}
+			case atom.Strong, atom.B:
+				if inner != "" {
+					s.WriteString("**" + inner + "**")
+				}
+			case atom.Em, atom.I:
+				if inner != "" {
+					s.WriteString("*" + inner + "*")
+				}
+			case atom.Code:
+				s.WriteString("`" + textOf(c) + "`")
+			case atom.Br:
+				s.WriteString("\n")
+			case atom.Img:
+				s.WriteString(attr(c, "alt"))
+			default:
+				s.WriteString(inline(c))
+			}
+		}
+		return s.String()
+	}
+
+	var block func(*html.Node)
+	block = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				if t := strings.TrimSpace(spaces.ReplaceAllString(c.Data, " ")); t != "" {
+					out = append(out, t)
+				}
+				continue
+			}
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				if t := strings.TrimSpace(inline(c)); t != "" {
+					level := int(c.Data[1] - '0')
+					out = append(out, strings.Repeat("#", level)+" "+t)
+				}
+			case atom.P:
+				if t := strings.TrimSpace(inline(c)); t != "" {
+					out = append(out, t)
+				}
+			case atom.Pre:
+				out = append(out, "```\n"+strings.TrimSuffix(textOf(c), "\n")+"\n```")
+			case atom.Blockquote:
+				if t := strings.TrimSpace(inline(c)); t != "" {
+					lines := strings.Split(t, "\n")
+					for i, l := range lines {
+						lines[i] = "> " + l
+					}
+					out = append(out, strings.Join(lines, "\n"))
+				}
+			case atom.Ul, atom.Ol:
+				var items []string
+				for li := c.FirstChild; li != nil; li = li.NextSibling {
+					if li.DataAtom != atom.Li {
+						continue
+					}
+					if t := strings.TrimSpace(inline(li)); t != "" {
+						marker := "- "
+						if c.DataAtom == atom.Ol {
+							marker = strconv.Itoa(len(items)+1) + ". "
+						}
+						items = append(items, marker+t)
+					}
+				}
+				if len(items) > 0 {
+					out = append(out, strings.Join(items, "\n"))
+				}
+			case atom.Hr:
+				out = append(out, "---")
+			default:
+				block(c)
+			}
+		}
+	}
+	block(root)
+	return strings.Join(out, "\n\n")
+}
+
+// walk calls fn for n and its descendants in document order; fn returns false to skip a node's children.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+// findFirst returns the first element of type a below n, or nil.
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// attr returns the value of n's attribute key, or "".
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether n has the attribute key.
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// hasToken reports whether the space-separated list contains token, ignoring case.
+func hasToken(list, token string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveURL resolves ref against base, returning ref unchanged when either cannot be parsed.
+// Script and empty links resolve to "".
+func resolveURL(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(strings.ToLower(ref), "javascript:") {
+		return ""
+	}
+	b, err := url.Parse(base)
+	if err != nil || base == "" {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+// firstNonEmpty returns the first non-empty value.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
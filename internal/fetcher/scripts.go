@@ -0,0 +1,154 @@
+package fetcher
+
+// metadataScript collects page metadata into an object matching the JSON tags of Metadata.
+const metadataScript = `(() => {
+	const meta = (sel) => (document.querySelector(sel)?.getAttribute('content') || '').trim();
+	return {
+		title: meta('meta[property="og:title"]') || document.title.trim(),
+		description: meta('meta[name="description"]') || meta('meta[property="og:description"]'),
+		site_name: meta('meta[property="og:site_name"]'),
+		author: meta('meta[name="author"]'),
+		published_time: meta('meta[property="article:published_time"]'),
+		language: document.documentElement.lang || '',
+		canonical_url: document.querySelector('link[rel="canonical"]')?.href || '',
+		audio: (() => {
+			const audio = meta('meta[property="og:audio"]') || meta('meta[property="og:audio:url"]');
+			return audio ? new URL(audio, document.baseURI).href : '';
+		})(),
+	};
+})()`
+
+// videoScript collects video metadata into an object matching the JSON tags of Video, or null for non-video pages.
+// YouTube exposes its player response globally; other platforms are detected through og:type.
+const videoScript = `(() => {
+	const yt = window.ytInitialPlayerResponse?.videoDetails;
+	if (yt) {
+		return {
+			platform: 'youtube',
+			title: yt.title || '',
+			channel: yt.author || '',
+			duration_seconds: Number(yt.lengthSeconds) || 0,
+			description: yt.shortDescription || '',
+		};
+	}
+	const meta = (sel) => (document.querySelector(sel)?.getAttribute('content') || '').trim();
+	if (!meta('meta[property="og:type"]').startsWith('video')) {
+		return null;
+	}
+	return {
+		platform: meta('meta[property="og:site_name"]').toLowerCase(),
+		title: meta('meta[property="og:title"]') || document.title.trim(),
+		channel: meta('meta[name="author"]'),
+		duration_seconds: Number(meta('meta[property="video:duration"]')) || 0,
+		description: meta('meta[property="og:description"]') || meta('meta[name="description"]'),
+	};
+})()`
+
+// networkIdleScript resolves once no new resources have been requested for 500ms, or after 10s at the latest.
+const networkIdleScript = `new Promise((resolve) => {
+	let last = performance.getEntriesByType('resource').length;
+	let stable = 0;
+	const timer = setInterval(() => {
+		const n = performance.getEntriesByType('resource').length;
+		if (n !== last) {
+			last = n;
+			stable = 0;
+			return;
+		}
+		if (++stable >= 5) {
+			clearInterval(timer);
+			resolve(true);
+		}
+	}, 100);
+	setTimeout(() => { clearInterval(timer); resolve(false); }, 10000);
+})`
+
+// markdownScript renders the (already cleaned) document body as Markdown.
+// It covers the common block and inline elements; anything else falls back to its text.
+const markdownScript = `(() => {
+	const out = [];
+	const inline = (node) => {
+		let s = '';
+		node.childNodes.forEach((c) => {
+			if (c.nodeType === Node.TEXT_NODE) {
+				s += c.textContent.replace(/\s+/g, ' ');
+				return;
+			}
+			if (c.nodeType !== Node.ELEMENT_NODE) return;
+			const tag = c.tagName.toLowerCase();
+			const inner = inline(c).trim();
+			switch (tag) {
+			case 'a':
+				s += inner && c.href ? '[' + inner + '](' + c.href + ')' : inner;
+				break;
+			case 'strong': case 'b':
+				s += inner ? '**' + inner + '**' : '';
+				break;
+			case 'em': case 'i':
+				s += inner ? '*' + inner + '*' : '';
+				break;
+			case 'code':
+				s += '` + "`" + `' + c.textContent + '` + "`" + `';
+				break;
+			case 'br':
+				s += '\n';
+				break;
+			case 'img':
+				s += c.getAttribute('alt') || '';
+				break;
+			default:
+				s += inline(c);
+			}
+		});
+		return s;
+	};
+	const block = (node) => {
+		node.childNodes.forEach((c) => {
+			if (c.nodeType === Node.TEXT_NODE) {
+				const t = c.textContent.replace(/\s+/g, ' ').trim();
+				if (t) out.push(t);
+				return;
+			}
+			if (c.nodeType !== Node.ELEMENT_NODE) return;
+			const tag = c.tagName.toLowerCase();
+			if (/^h[1-6]$/.test(tag)) {
+				const t = inline(c).trim();
+				if (t) out.push('#'.repeat(Number(tag[1])) + ' ' + t);
+				return;
+			}
+			switch (tag) {
+			case 'p': {
+				const t = inline(c).trim();
+				if (t) out.push(t);
+				return;
+			}
+			case 'pre':
+				out.push('` + "```" + `\n' + c.textContent.replace(/\n$/, '') + '\n` + "```" + `');
+				return;
+			case 'blockquote': {
+				const t = inline(c).trim();
+				if (t) out.push(t.split('\n').map((l) => '> ' + l).join('\n'));
+				return;
+			}
+			case 'ul': case 'ol': {
+				const items = [];
+				let n = 1;
+				for (const li of c.children) {
+					if (li.tagName.toLowerCase() !== 'li') continue;
+					const t = inline(li).trim();
+					if (t) items.push((tag === 'ol' ? (n++) + '. ' : '- ') + t);
+				}
+				if (items.length) out.push(items.join('\n'));
+				return;
+			}
+			case 'hr':
+				out.push('---');
+				return;
+			default:
+				block(c);
+			}
+		});
+	};
+	block(document.body);
+	return out.join('\n\n');
+})()`
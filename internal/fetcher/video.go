@@ -0,0 +1,88 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Video holds metadata of a video page.
+type Video struct {
+	Platform        string    `json:"platform"` // e.g. "youtube", "vimeo"
+	Title           string    `json:"title"`
+	Channel         string    `json:"channel"`
+	DurationSeconds int       `json:"duration_seconds"`
+	Description     string    `json:"description"`
+	Chapters        []Chapter `json:"chapters,omitempty"`
+}
+
+// Chapter is a titled section of a video.
+type Chapter struct {
+	Start int    `json:"start"` // Offset in seconds
+	Title string `json:"title"`
+}
+
+// chapterLineRegex matches description lines such as "00:00 Intro", "1:02:03 - Q&A" or "(12:30) Benchmarks".
+var chapterLineRegex = regexp.MustCompile(`^\s*\(?((?:\d{1,2}:)?\d{1,2}:\d{2})\)?\s*[-–—:|]?\s*(.+?)\s*$`)
+
+// ParseChapters extracts chapters from timestamp lines in text, as used by YouTube descriptions.
+// At least two timestamps in ascending order are required; otherwise nil is returned.
+func ParseChapters(text string) []Chapter {
+	var chapters []Chapter
+	for _, line := range strings.Split(text, "\n") {
+		m := chapterLineRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, ok := ParseTimestamp(m[1])
+		if !ok || (len(chapters) > 0 && start <= chapters[len(chapters)-1].Start) {
+			continue
+		}
+		chapters = append(chapters, Chapter{Start: start, Title: m[2]})
+	}
+	if len(chapters) < 2 {
+		return nil
+	}
+	return chapters
+}
+
+// ParseTimestamp converts "m:ss" or "h:mm:ss" to seconds.
+func ParseTimestamp(ts string) (int, bool) {
+	seconds := 0
+	for _, part := range strings.Split(ts, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, true
+}
+
+// FormatTimestamp renders seconds as "mm:ss", or "h:mm:ss" for an hour or more.
+func FormatTimestamp(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// TimestampURL returns a link to videoURL starting at the given offset.
+func TimestampURL(videoURL string, seconds int) string {
+	u, err := url.Parse(videoURL)
+	if err != nil {
+		return videoURL
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	if host == "youtube.com" || host == "m.youtube.com" || host == "youtu.be" {
+		q := u.Query()
+		q.Set("t", strconv.Itoa(seconds)+"s")
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	// Media fragment, understood by Vimeo and most HTML5 players
+	u.Fragment = "t=" + strconv.Itoa(seconds) + "s"
+	return u.String()
+}
@@ -0,0 +1,57 @@
+package fetcher
+
+import "testing"
+
+func TestParseChapters(t *testing.T) {
+	description := `Deep dive into the new release.
+
+0:00 Intro
+02:15 - Architecture
+(12:30) Benchmarks
+1:02:03 Q&A
+Follow us at https://example.com`
+
+	chapters := ParseChapters(description)
+	want := []Chapter{
+		{0, "Intro"},
+		{135, "Architecture"},
+		{750, "Benchmarks"},
+		{3723, "Q&A"},
+	}
+	if len(chapters) != len(want) {
+		t.Fatalf("Expected %d chapters, got %+v", len(want), chapters)
+	}
+	for i := range want {
+		if chapters[i] != want[i] {
+			t.Errorf("Chapter %d = %+v, want %+v", i, chapters[i], want[i])
+		}
+	}
+
+	if got := ParseChapters("Released at 10:30 today"); got != nil {
+		t.Errorf("Expected no chapters from a single timestamp, got %+v", got)
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	for seconds, want := range map[int]string{0: "00:00", 750: "12:30", 3723: "1:02:03"} {
+		if got := FormatTimestamp(seconds); got != want {
+			t.Errorf("FormatTimestamp(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
+
+func TestTimestampURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.youtube.com/watch?v=abc", "https://www.youtube.com/watch?t=750s&v=abc"},
+		{"https://youtu.be/abc", "https://youtu.be/abc?t=750s"},
+		{"https://vimeo.com/12345", "https://vimeo.com/12345#t=750s"},
+	}
+	for _, tt := range tests {
+		if got := TimestampURL(tt.url, 750); got != tt.want {
+			t.Errorf("TimestampURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package footer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Fallback is the language key of the text used when none of a channel's languages has a translation.
+const Fallback = "*"
+
+// Texts holds one footer in several languages, by language code such as "ja" or "en", or Fallback.
+type Texts map[string]string
+
+// Footers are operator-provided notes (disclaimers, policy links, ...) appended to every bot response.
+type Footers struct {
+	Default  Texts            `json:"default"`  // Used in channels without an override
+	Channels map[string]Texts `json:"channels"` // Per-channel overrides; an empty one disables the footer
+}
+
+// New returns Footers with one untranslated footer for every channel.
+func New(text string) *Footers {
+	return &Footers{Default: Texts{Fallback: strings.TrimSpace(text)}}
+}
+
+// Open returns the Footers configured by a footer file or, if path is empty, an inline footer.
+// It returns nil when neither is set.
+func Open(text, path string) (*Footers, error) {
+	if path != "" {
+		return Load(path)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return New(text), nil
+}
+
+// Load reads Footers from a JSON file such as
+//
+//	{"default": {"en": "AI-generated, verify before acting.", "ja": "AIによる要約です。"},
+//	 "channels": {"C0123456": {"*": "<https://intra.example.com/ai-policy|AI policy>"}, "C0456789": {}}}
+func Load(path string) (*Footers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading footer file: %w", err)
+	}
+	var f Footers
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing footer file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// For returns the footer of channel in its output languages, one line per language that has a
+// translation, or the fallback text if none has. It returns "" for a nil Footers or a disabled channel.
+func (f *Footers) For(channel string, languages []string) string {
+	if f == nil {
+		return ""
+	}
+	texts, ok := f.Channels[channel]
+	if !ok {
+		texts = f.Default
+	}
+
+	var lines []string
+	for _, code := range languages {
+		if text := strings.TrimSpace(texts[strings.ToLower(code)]); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	if len(lines) == 0 {
+		if text := strings.TrimSpace(texts[Fallback]); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
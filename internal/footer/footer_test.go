@@ -0,0 +1,54 @@
+package footer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFooters(t *testing.T) {
+	var none *Footers
+	if got := none.For("C1", nil); got != "" {
+		t.Errorf("Expected no footer for nil Footers, got %q", got)
+	}
+	if got := New(" Verify before acting. ").For("C1", []string{"ja"}); got != "Verify before acting." {
+		t.Errorf("Unexpected inline footer %q", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "footer.json")
+	data := `{
+		"default": {"*": "AI-generated summary.", "ja": "AIによる要約です。", "en": "AI-generated summary, verify before acting."},
+		"channels": {"C2": {"*": "Research use only."}, "C3": {}}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		channel   string
+		languages []string
+		want      string
+	}{
+		{"C1", nil, "AI-generated summary."},
+		{"C1", []string{"ja"}, "AIによる要約です。"},
+		{"C1", []string{"ja", "en"}, "AIによる要約です。\nAI-generated summary, verify before acting."},
+		{"C1", []string{"pt"}, "AI-generated summary."},
+		{"C2", []string{"ja"}, "Research use only."},
+		{"C3", nil, ""},
+	} {
+		if got := f.For(tc.channel, tc.languages); got != tc.want {
+			t.Errorf("For(%s, %v) = %q, want %q", tc.channel, tc.languages, got, tc.want)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
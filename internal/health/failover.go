@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"log"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// Provider is an LLM together with the name of the probe checking it.
+type Provider struct {
+	Name string
+	LLM  llm.LLM
+}
+
+// ProviderProbe returns a probe pinging p, or nil if its LLM cannot be pinged.
+func ProviderProbe(p Provider) *Probe {
+	pinger, ok := p.LLM.(llm.Pinger)
+	if !ok {
+		return nil
+	}
+	return &Probe{Name: p.Name, Check: pinger.Ping}
+}
+
+// Failover is an LLM sending each request to the first provider passing its health check, so that
+// an outage is routed around before requests fail. A request failing on a provider is sent to the next
+// healthy one. If every provider fails its check, the first one is tried anyway.
+type Failover struct {
+	monitor   *Monitor
+	providers []Provider
+}
+
+// NewFailover creates a Failover over providers, in order of preference.
+func NewFailover(monitor *Monitor, providers ...Provider) *Failover {
+	return &Failover{monitor: monitor, providers: providers}
+}
+
+// Generate implements llm.LLM.
+func (f *Failover) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	var resp *llm.Response
+	var err error
+	candidates := f.candidates()
+	for n, i := range candidates {
+		resp, err = f.providers[i].LLM.Generate(ctx, messages, f.options(i, opts))
+		if err == nil || n == len(candidates)-1 || !f.next(ctx, i, err) {
+			break
+		}
+	}
+	return resp, err
+}
+
+// GenerateStream implements llm.Streamer, streaming if the chosen provider can. Once text was passed on,
+// a failure is returned rather than repeated by the next provider.
+func (f *Failover) GenerateStream(ctx context.Context, messages []llm.Message, opts llm.Options, onText func(delta string)) (*llm.Response, error) {
+	var resp *llm.Response
+	var err error
+	candidates := f.candidates()
+	for n, i := range candidates {
+		streamed := false
+		resp, err = llm.Stream(ctx, f.providers[i].LLM, messages, f.options(i, opts), func(delta string) {
+			streamed = true
+			onText(delta)
+		})
+		if err == nil || streamed || n == len(candidates)-1 || !f.next(ctx, i, err) {
+			break
+		}
+	}
+	return resp, err
+}
+
+// candidates returns the indexes of the providers to try, in order: the healthy ones, or the first one
+// if none is.
+func (f *Failover) candidates() []int {
+	var healthy []int
+	for i, p := range f.providers {
+		if f.monitor.Healthy(p.Name) {
+			healthy = append(healthy, i)
+		}
+	}
+	if len(healthy) == 0 {
+		return []int{0}
+	}
+	return healthy
+}
+
+// options returns opts adjusted for provider i.
+func (f *Failover) options(i int, opts llm.Options) llm.Options {
+	if i > 0 {
+		// Model overrides name models of the preferred provider
+		opts.Model = ""
+	}
+	return opts
+}
+
+// next reports whether a request that failed on provider i with err should go on to the next provider,
+// logging it if so. Cancelled requests do not.
+func (f *Failover) next(ctx context.Context, i int, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	log.Printf("[Health] %s failed a request, trying the next provider: %v", f.providers[i].Name, err)
+	return true
+}
@@ -0,0 +1,188 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Probe checks one dependency, such as an LLM provider or the browser.
+type Probe struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Result is the outcome of the latest check of a probe.
+type Result struct {
+	Name      string    `json:"name"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+	Since     time.Time `json:"since"` // When the probe last turned healthy or unhealthy
+}
+
+// Monitor checks its probes periodically and keeps their latest results, so that requests can avoid
+// failing dependencies instead of discovering the failure themselves.
+type Monitor struct {
+	probes  []Probe
+	timeout time.Duration
+
+	mu      sync.RWMutex
+	results map[string]Result
+}
+
+// NewMonitor creates a Monitor for probes; each check is bounded by timeout.
+func NewMonitor(timeout time.Duration, probes ...Probe) *Monitor {
+	return &Monitor{probes: probes, timeout: timeout, results: make(map[string]Result)}
+}
+
+// Run checks every probe immediately and then every interval, until ctx is done.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check runs every probe concurrently and records the results.
+func (m *Monitor) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range m.probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.check(ctx, p)
+		}()
+	}
+	wg.Wait()
+}
+
+// check runs p and records the result, logging when its health changes.
+func (m *Monitor) check(ctx context.Context, p Probe) {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	start := time.Now()
+	err := p.Check(ctx)
+	r := Result{Name: p.Name, Healthy: err == nil, LatencyMS: time.Since(start).Milliseconds(), CheckedAt: time.Now()}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev, seen := m.results[p.Name]
+	r.Since = r.CheckedAt
+	if seen && prev.Healthy == r.Healthy {
+		r.Since = prev.Since
+	}
+	m.results[p.Name] = r
+	switch {
+	case !r.Healthy && (!seen || prev.Healthy):
+		log.Printf("[Health] %s is failing: %s", p.Name, r.Error)
+	case r.Healthy && seen && !prev.Healthy:
+		log.Printf("[Health] %s recovered", p.Name)
+	}
+}
+
+// Healthy reports whether the named probe passed its latest check. Probes not checked yet, unknown
+// names and a nil Monitor count as healthy, so that requests are never refused before the first check.
+func (m *Monitor) Healthy(name string) bool {
+	if m == nil {
+		return true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.results[name]
+	return !ok || r.Healthy
+}
+
+// Results returns the latest results in probe order, leaving out probes not checked yet. A nil Monitor has none.
+func (m *Monitor) Results() []Result {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var results []Result
+	for _, p := range m.probes {
+		if r, ok := m.results[p.Name]; ok {
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// Handler serves the results as text for monitoring systems: one line per probe, with status 503 if any fails.
+func (m *Monitor) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		status := http.StatusOK
+		now := time.Now()
+		for _, res := range m.Results() {
+			if res.Healthy {
+				fmt.Fprintf(&b, "ok       %s (%dms, checked %s ago)\n", res.Name, res.LatencyMS, now.Sub(res.CheckedAt).Round(time.Second))
+				continue
+			}
+			status = http.StatusServiceUnavailable
+			fmt.Fprintf(&b, "FAILING  %s since %s: %s\n", res.Name, res.Since.Format(time.RFC3339), res.Error)
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write([]byte(b.String()))
+	}
+}
+
+// dashboardPage renders the results for operators; it reloads itself every 30 seconds.
+var dashboardPage = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"ago": func(t time.Time) time.Duration { return time.Since(t).Round(time.Second) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>describe-kun status</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+td, th { padding: 0.3em 1em; text-align: left; }
+.ok { color: #1a7f37; }
+.failing { color: #cf222e; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>describe-kun status</h1>
+{{if .}}<table>
+<tr><th>Dependency</th><th>Status</th><th>Since</th><th>Latency</th><th>Checked</th><th>Error</th></tr>
+{{range .}}<tr>
+<td>{{.Name}}</td>
+{{if .Healthy}}<td class="ok">ok</td>{{else}}<td class="failing">FAILING</td>{{end}}
+<td>{{.Since.Format "2006-01-02 15:04:05 MST"}}</td>
+<td>{{.LatencyMS}}ms</td>
+<td>{{ago .CheckedAt}} ago</td>
+<td>{{.Error}}</td>
+</tr>
+{{end}}</table>{{else}}<p>No checks have run yet.</p>{{end}}
+</body>
+</html>
+`))
+
+// Dashboard serves the results as an HTML page for operators.
+func (m *Monitor) Dashboard() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboardPage.Execute(w, m.Results()); err != nil {
+			log.Printf("[Health] Error rendering the dashboard: %v", err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// stubLLM answers with its name and records the model it was asked for.
+type stubLLM struct {
+	name    string
+	pingErr error
+	genErr  error
+	model   string
+	calls   int
+}
+
+func (s *stubLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	s.model = opts.Model
+	s.calls++
+	if s.genErr != nil {
+		return nil, s.genErr
+	}
+	return &llm.Response{Text: s.name}, nil
+}
+
+func (s *stubLLM) Ping(ctx context.Context) error {
+	return s.pingErr
+}
+
+func TestMonitor(t *testing.T) {
+	failing := errors.New("connection refused")
+	var browserErr error
+	m := NewMonitor(time.Second,
+		Probe{Name: "openai", Check: func(ctx context.Context) error { return nil }},
+		Probe{Name: "browser", Check: func(ctx context.Context) error { return browserErr }},
+	)
+	if !m.Healthy("browser") || len(m.Results()) != 0 {
+		t.Fatal("Expected probes to count as healthy before the first check")
+	}
+
+	browserErr = failing
+	m.Check(context.Background())
+	first := m.Results()
+	if len(first) != 2 || first[0].Name != "openai" || !first[0].Healthy || first[1].Healthy || first[1].Error != "connection refused" {
+		t.Fatalf("Unexpected results %+v", first)
+	}
+	if m.Healthy("browser") || !m.Healthy("openai") {
+		t.Error("Expected only the browser to be unhealthy")
+	}
+
+	m.Check(context.Background())
+	if again := m.Results()[1]; !again.Since.Equal(first[1].Since) || !again.CheckedAt.After(first[1].CheckedAt) {
+		t.Errorf("Expected the failure to keep its start time, got %+v", again)
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler()(w, httptest.NewRequest(http.MethodGet, "/statusz", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "FAILING  browser since") || !strings.Contains(w.Body.String(), "ok       openai") {
+		t.Errorf("Unexpected /statusz response %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	m.Dashboard()(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, "<td>browser</td>") || !strings.Contains(body, "FAILING") || !strings.Contains(body, "connection refused") {
+		t.Errorf("Unexpected dashboard %d %q", w.Code, body)
+	}
+
+	browserErr = nil
+	m.Check(context.Background())
+	w = httptest.NewRecorder()
+	m.Handler()(w, httptest.NewRequest(http.MethodGet, "/statusz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 once recovered, got %d", w.Code)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	primary := &stubLLM{name: "primary", pingErr: errors.New("status 503")}
+	secondary := &stubLLM{name: "secondary"}
+	providers := []Provider{{Name: "primary", LLM: primary}, {Name: "secondary", LLM: secondary}}
+	var probes []Probe
+	for _, p := range providers {
+		probes = append(probes, *ProviderProbe(p))
+	}
+	m := NewMonitor(time.Second, probes...)
+	f := NewFailover(m, providers...)
+
+	resp, _ := f.Generate(context.Background(), nil, llm.Options{Model: "primary-mini"})
+	if resp.Text != "primary" || primary.model != "primary-mini" {
+		t.Errorf("Expected the primary before any check, got %q", resp.Text)
+	}
+
+	m.Check(context.Background())
+	resp, _ = f.Generate(context.Background(), nil, llm.Options{Model: "primary-mini"})
+	if resp.Text != "secondary" || secondary.model != "" {
+		t.Errorf("Expected the healthy secondary with its default model, got %q (model %q)", resp.Text, secondary.model)
+	}
+
+	secondary.pingErr = errors.New("timeout")
+	m.Check(context.Background())
+	if resp, _ := f.Generate(context.Background(), nil, llm.Options{}); resp.Text != "primary" {
+		t.Errorf("Expected the primary when every provider fails, got %q", resp.Text)
+	}
+
+	if ProviderProbe(Provider{Name: "plain", LLM: struct{ llm.LLM }{}}) != nil {
+		t.Error("Expected no probe for LLMs that cannot be pinged")
+	}
+}
+
+func TestFailover_FailedRequest(t *testing.T) {
+	// The outage is not noticed by a health check yet, only by a request
+	primary := &stubLLM{name: "primary", genErr: errors.New("status 503")}
+	secondary := &stubLLM{name: "secondary"}
+	f := NewFailover(NewMonitor(time.Second), Provider{Name: "primary", LLM: primary}, Provider{Name: "secondary", LLM: secondary})
+
+	resp, err := f.Generate(context.Background(), nil, llm.Options{Model: "primary-mini"})
+	if err != nil || resp.Text != "secondary" || secondary.model != "" {
+		t.Errorf("Expected the request to fall through to the secondary with its default model, got %v, %v", resp, err)
+	}
+	var streamed string
+	resp, err = f.GenerateStream(context.Background(), nil, llm.Options{}, func(delta string) { streamed += delta })
+	if err != nil || resp.Text != "secondary" || streamed != "secondary" {
+		t.Errorf("Expected the stream to fall through to the secondary, got %v, %v", resp, err)
+	}
+
+	secondary.genErr = errors.New("timeout")
+	if _, err := f.Generate(context.Background(), nil, llm.Options{}); err == nil || err.Error() != "timeout" {
+		t.Errorf("Expected the error of the last provider, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	primary.calls, secondary.calls = 0, 0
+	if _, err := f.Generate(ctx, nil, llm.Options{}); err == nil || primary.calls != 1 || secondary.calls != 0 {
+		t.Errorf("Expected a cancelled request not to fall through, got %v after %d and %d calls", err, primary.calls, secondary.calls)
+	}
+}
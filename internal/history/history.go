@@ -0,0 +1,164 @@
+package history
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/provenance"
+)
+
+// Entry records one summary request.
+type Entry struct {
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Source     string    `json:"source,omitempty"`
+	Trigger    string    `json:"trigger,omitempty"`
+	Workspace  string    `json:"workspace,omitempty"`
+	Channel    string    `json:"channel,omitempty"`
+	User       string    `json:"user,omitempty"`
+	URL        string    `json:"url"`
+	Prompt     string    `json:"prompt,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	Tokens     int       `json:"tokens,omitempty"`
+	CostUSD    float64   `json:"cost_usd,omitempty"` // Estimated from the model's list price
+	DurationMS int64     `json:"duration_ms"`
+	Summary    string    `json:"summary,omitempty"`
+	Content    string    `json:"content,omitempty"` // Extracted page text, only kept when enabled
+	Error      string    `json:"error,omitempty"`
+	BaselineID string    `json:"baseline_id,omitempty"` // Shadow entries: the production entry compared against
+	Baseline   string    `json:"baseline,omitempty"`    // Shadow entries: the production summary
+
+	Provenance *provenance.Provenance `json:"provenance,omitempty"` // How Summary was made
+}
+
+// ErrNotFound is returned by Get for unknown IDs.
+var ErrNotFound = errors.New("history entry not found")
+
+// Store is an append-only log of entries, kept in a JSON Lines file or, without one, in memory.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	entries []Entry // Only used without a file
+}
+
+// NewStore creates a Store writing to path; empty keeps the history in memory.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Append adds e, assigning an ID and time if unset, and returns the ID.
+func (s *Store) Append(e Entry) (string, error) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.ID == "" {
+		e.ID = newID(e.Time)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" {
+		s.entries = append(s.entries, e)
+		return e.ID, nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("encoding history entry: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("opening history %s: %w", s.path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return "", fmt.Errorf("writing history %s: %w", s.path, err)
+	}
+	return e.ID, nil
+}
+
+// Since returns the entries recorded at or after t, oldest first.
+func (s *Store) Since(t time.Time) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []Entry
+	keep := func(e Entry) {
+		if !e.Time.Before(t) {
+			entries = append(entries, e)
+		}
+	}
+	if s.path == "" {
+		for _, e := range s.entries {
+			keep(e)
+		}
+		return entries, nil
+	}
+
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening history %s: %w", s.path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20) // Summaries can make long lines
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip lines damaged by e.g. a crash mid-write rather than losing the whole history
+			continue
+		}
+		keep(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading history %s: %w", s.path, err)
+	}
+	return entries, nil
+}
+
+// Get returns the entry with the given ID.
+func (s *Store) Get(id string) (Entry, error) {
+	entries, err := s.Since(time.Time{})
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// LatestSummary returns the summary of the most recent successful entry for url, or "" if there is none.
+func (s *Store) LatestSummary(url string) (string, error) {
+	entries, err := s.Since(time.Time{})
+	if err != nil {
+		return "", err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := entries[i]; e.URL == url && e.Error == "" && e.Summary != "" && e.BaselineID == "" {
+			return e.Summary, nil
+		}
+	}
+	return "", nil
+}
+
+// newID returns a sortable, unique job ID such as "20261016T093000-1a2b3c".
+func newID(t time.Time) string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return t.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,86 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	for _, s := range []*Store{NewStore(""), NewStore(path)} {
+		old := time.Now().Add(-48 * time.Hour)
+		if _, err := s.Append(Entry{Time: old, URL: "https://example.com/old"}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		id, err := s.Append(Entry{URL: "https://example.com/new", Tokens: 10})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		if id == "" {
+			t.Error("Expected an ID to be assigned")
+		}
+
+		entries, err := s.Since(time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("Since failed: %v", err)
+		}
+		if len(entries) != 1 || entries[0].ID != id || entries[0].Tokens != 10 {
+			t.Errorf("Since returned %+v", entries)
+		}
+		if e, err := s.Get(id); err != nil || e.URL != "https://example.com/new" {
+			t.Errorf("Get returned %+v, %v", e, err)
+		}
+		if _, err := s.Get("missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	}
+
+	// A damaged line doesn't hide the rest of the history
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("{broken\n")
+	f.Close()
+	entries, err := NewStore(path).Since(time.Time{})
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Expected 2 entries despite a broken line, got %d, %v", len(entries), err)
+	}
+
+	if entries, err := NewStore(filepath.Join(t.TempDir(), "missing.jsonl")).Since(time.Time{}); err != nil || entries != nil {
+		t.Errorf("Expected an empty history for a missing file, got %v, %v", entries, err)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	from := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	entries := []Entry{
+		{Time: from.Add(time.Hour), URL: "https://www.example.com/a", User: "U1", Channel: "C1", Tokens: 100, CostUSD: 0.5},
+		{Time: from.Add(25 * time.Hour), URL: "https://example.com/b", User: "U1", Channel: "C1", Tokens: 300, CostUSD: 0.75},
+		{Time: from.Add(26 * time.Hour), URL: "https://slow.example.org/", User: "U2", Channel: "C2", Error: "timeout"},
+		{Time: to.Add(time.Hour), URL: "https://example.com/next-week", Tokens: 1000},
+	}
+
+	r := BuildReport(entries, from, to)
+	if r.Total != 3 || r.Failed != 1 || r.Tokens != 400 {
+		t.Errorf("Unexpected totals %d/%d/%d", r.Total, r.Failed, r.Tokens)
+	}
+	if r.Domains[0] != (Count{"example.com", 2}) {
+		t.Errorf("Unexpected top domain %+v", r.Domains[0])
+	}
+	if len(r.FailingDomains) != 1 || r.FailingDomains[0].Key != "slow.example.org" {
+		t.Errorf("Unexpected failure hotspots %+v", r.FailingDomains)
+	}
+	if len(r.DailyTokens) != 7 || r.DailyTokens[1] != (Count{"2026-10-06", 300}) {
+		t.Errorf("Unexpected daily tokens %+v", r.DailyTokens)
+	}
+
+	text := r.Format()
+	for _, want := range []string{"(2026-10-05 〜 2026-10-11)", "Summaries: 3 (failed: 1)  Tokens: 400  Estimated cost: $1.25", "• <@U1>: 2", "• <#C2>: 1", "`10-06` ████████████████████ 300"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in report:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package history
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportTopN is how many entries each ranking in a report shows.
+const reportTopN = 5
+
+// Count is a ranked key with its number of occurrences.
+type Count struct {
+	Key   string
+	Count int
+}
+
+// Report aggregates the history over a period.
+type Report struct {
+	From, To       time.Time
+	Total          int
+	Failed         int
+	Tokens         int
+	CostUSD        float64 // Estimated cost of the summaries
+	Domains        []Count // Most summarized domains
+	Users          []Count // Most active Slack users
+	Channels       []Count // Most active Slack channels
+	FailingDomains []Count // Domains with the most failures
+	DailyTokens    []Count // Tokens per day ("2006-01-02"), oldest first
+}
+
+// BuildReport aggregates the entries recorded in [from, to).
+func BuildReport(entries []Entry, from, to time.Time) *Report {
+	r := &Report{From: from, To: to}
+	domains, users, channels, failing := map[string]int{}, map[string]int{}, map[string]int{}, map[string]int{}
+	daily := map[string]int{}
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		daily[d.Format("2006-01-02")] = 0
+	}
+
+	for _, e := range entries {
+		if e.Time.Before(from) || !e.Time.Before(to) {
+			continue
+		}
+		r.Total++
+		r.Tokens += e.Tokens
+		r.CostUSD += e.CostUSD
+		daily[e.Time.In(from.Location()).Format("2006-01-02")] += e.Tokens
+
+		domain := domainOf(e.URL)
+		domains[domain]++
+		if e.Error != "" {
+			r.Failed++
+			failing[domain]++
+		}
+		if e.User != "" {
+			users[e.User]++
+		}
+		if e.Channel != "" {
+			channels[e.Channel]++
+		}
+	}
+
+	r.Domains = top(domains, reportTopN)
+	r.Users = top(users, reportTopN)
+	r.Channels = top(channels, reportTopN)
+	r.FailingDomains = top(failing, reportTopN)
+	for day, tokens := range daily {
+		r.DailyTokens = append(r.DailyTokens, Count{Key: day, Count: tokens})
+	}
+	sort.Slice(r.DailyTokens, func(i, j int) bool { return r.DailyTokens[i].Key < r.DailyTokens[j].Key })
+	return r
+}
+
+// Format renders the report as a Slack message.
+func (r *Report) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ":bar_chart: *Weekly report* (%s 〜 %s)\n", r.From.Format("2006-01-02"), r.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	fmt.Fprintf(&b, "Summaries: %d (failed: %d)  Tokens: %d", r.Total, r.Failed, r.Tokens)
+	if r.CostUSD > 0 {
+		fmt.Fprintf(&b, "  Estimated cost: $%.2f", r.CostUSD)
+	}
+	b.WriteString("\n")
+	if r.Total == 0 {
+		return b.String()
+	}
+
+	section := func(title string, counts []Count, format func(string) string) {
+		if len(counts) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n*%s*\n", title)
+		for _, c := range counts {
+			fmt.Fprintf(&b, "• %s: %d\n", format(c.Key), c.Count)
+		}
+	}
+	plain := func(s string) string { return s }
+	section("Most summarized domains", r.Domains, plain)
+	section("Most active users", r.Users, func(s string) string { return "<@" + s + ">" })
+	section("Most active channels", r.Channels, func(s string) string { return "<#" + s + ">" })
+	section("Failure hotspots", r.FailingDomains, plain)
+
+	fmt.Fprintf(&b, "\n*Token spend per day*\n")
+	peak := 0
+	for _, d := range r.DailyTokens {
+		if d.Count > peak {
+			peak = d.Count
+		}
+	}
+	for _, d := range r.DailyTokens {
+		bar := ""
+		if peak > 0 {
+			bar = strings.Repeat("█", d.Count*20/peak)
+		}
+		fmt.Fprintf(&b, "`%s` %s %d\n", d.Key[5:], bar, d.Count)
+	}
+	return b.String()
+}
+
+// domainOf returns the host of rawURL without "www.", or the URL itself if it has none.
+func domainOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		if rawURL == "" {
+			return "(no URL)"
+		}
+		return rawURL
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// top returns the n largest counts, ties broken by key.
+func top(counts map[string]int, n int) []Count {
+	list := make([]Count, 0, len(counts))
+	for k, c := range counts {
+		list = append(list, Count{Key: k, Count: c})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Key < list[j].Key
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
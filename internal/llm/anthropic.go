@@ -0,0 +1,396 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+const (
+	anthropicBaseURL   = "https://api.anthropic.com"
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 4096 // The Messages API requires a limit; summaries stay well below it
+)
+
+// AnthropicClient implements the LLM interface using the Anthropic Messages API.
+type AnthropicClient struct {
+	apiKey        string
+	model         string
+	contextWindow int // Overrides the known context window of the model; 0 uses it
+	baseURL       string
+	http          *http.Client
+}
+
+// NewAnthropicClient creates a new Anthropic client.
+// It requires the ANTHROPIC_API_KEY environment variable to be set.
+// ANTHROPIC_MODEL overrides the default model and LLM_CONTEXT_WINDOW its context window, which prompts
+// are trimmed to.
+func NewAnthropicClient() (*AnthropicClient, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return nil, errors.New("ANTHROPIC_API_KEY environment variable not set")
+	}
+
+	model := "claude-sonnet-4-5"
+	if os.Getenv("ANTHROPIC_MODEL") != "" {
+		model = os.Getenv("ANTHROPIC_MODEL")
+	}
+
+	window, err := envContextWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AnthropicClient{apiKey: apiKey, model: model, contextWindow: window, baseURL: anthropicBaseURL, http: http.DefaultClient}, nil
+}
+
+// anthropicBlock is a content block of the Messages API.
+type anthropicBlock struct {
+	Type string `json:"type"`
+
+	Text string `json:"text,omitempty"` // "text"
+
+	Source *anthropicImageSource `json:"source,omitempty"` // "image"
+
+	ID    string          `json:"id,omitempty"`    // "tool_use"
+	Name  string          `json:"name,omitempty"`  // "tool_use"
+	Input json.RawMessage `json:"input,omitempty"` // "tool_use"
+
+	ToolUseID string `json:"tool_use_id,omitempty"` // "tool_result"
+	Content   string `json:"content,omitempty"`     // "tool_result"
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	TopP        *float64           `json:"top_p,omitempty"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  *anthropicChoice   `json:"tool_choice,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+// anthropicChoice controls whether the model may call the tools of a request.
+type anthropicChoice struct {
+	Type string `json:"type"` // "auto", "any", "tool" or "none"
+}
+
+type anthropicResponse struct {
+	Model   string           `json:"model"`
+	Content []anthropicBlock `json:"content"`
+	Usage   struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicStreamEvent is a server-sent event of a streamed response. Fields are set by event type.
+type anthropicStreamEvent struct {
+	Type         string            `json:"type"`
+	Message      anthropicResponse `json:"message"`       // "message_start"
+	Index        int               `json:"index"`         // "content_block_start", "content_block_delta"
+	ContentBlock anthropicBlock    `json:"content_block"` // "content_block_start"
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`         // "text_delta"
+		PartialJSON string `json:"partial_json"` // "input_json_delta"
+	} `json:"delta"` // "content_block_delta"
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"` // "message_delta"
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"` // "error"
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Ping checks that the API answers and knows the configured model.
+func (c *AnthropicClient) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/v1/models/"+url.PathEscape(c.model), nil, nil)
+}
+
+// Model returns the model used when Options.Model is empty.
+func (c *AnthropicClient) Model() string {
+	return c.model
+}
+
+// Generate sends the conversation to the Anthropic Messages API.
+func (c *AnthropicClient) Generate(ctx context.Context, messages []Message, opts Options) (*Response, error) {
+	var resp anthropicResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/messages", c.request(messages, opts), &resp); err != nil {
+		return nil, err
+	}
+	return fromAnthropicResponse(resp)
+}
+
+// GenerateStream sends the conversation to the Anthropic Messages API, passing the text to onText as
+// it arrives in server-sent events.
+func (c *AnthropicClient) GenerateStream(ctx context.Context, messages []Message, opts Options, onText func(delta string)) (*Response, error) {
+	req := c.request(messages, opts)
+	req.Stream = true
+	httpResp, err := c.send(ctx, http.MethodPost, "/v1/messages", req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	// The events are put back together into a regular response
+	var resp anthropicResponse
+	var inputs []string // Partial JSON input of the tool_use blocks, by block index
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("anthropic: decoding stream event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			resp.Model = event.Message.Model
+			resp.Usage.InputTokens = event.Message.Usage.InputTokens
+		case "content_block_start":
+			resp.Content = append(resp.Content, event.ContentBlock)
+			inputs = append(inputs, "")
+		case "content_block_delta":
+			if event.Index >= len(resp.Content) {
+				continue
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				resp.Content[event.Index].Text += event.Delta.Text
+				onText(event.Delta.Text)
+			case "input_json_delta":
+				inputs[event.Index] += event.Delta.PartialJSON
+			}
+		case "message_delta":
+			resp.Usage.OutputTokens = event.Usage.OutputTokens
+		case "error":
+			return nil, fmt.Errorf("anthropic request failed: %s (%s)", event.Error.Message, event.Error.Type)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("anthropic: reading stream: %w", err)
+	}
+	for i, input := range inputs {
+		if input != "" {
+			resp.Content[i].Input = json.RawMessage(input)
+		}
+	}
+	return fromAnthropicResponse(resp)
+}
+
+// request builds the Messages API request for a conversation, trimmed to the context window.
+func (c *AnthropicClient) request(messages []Message, opts Options) anthropicRequest {
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	system, converted := toAnthropicMessages(fitContext(model, c.contextWindow, messages))
+	req := anthropicRequest{Model: model, MaxTokens: anthropicMaxTokens, System: system, Messages: converted, Temperature: opts.Temperature, TopP: opts.TopP}
+	if opts.MaxTokens > 0 {
+		req.MaxTokens = opts.MaxTokens
+	}
+	for _, t := range opts.Tools {
+		req.Tools = append(req.Tools, anthropicTool{Name: t.Name, Description: t.Description, InputSchema: t.Parameters})
+	}
+	if len(req.Tools) == 0 {
+		// The API rejects tool_use and tool_result blocks without the definitions of their tools, so the
+		// tools of earlier turns are declared, but may not be called again
+		for _, name := range historyTools(messages) {
+			req.Tools = append(req.Tools, anthropicTool{Name: name, InputSchema: json.RawMessage(`{"type":"object"}`)})
+		}
+		if len(req.Tools) > 0 {
+			req.ToolChoice = &anthropicChoice{Type: "none"}
+		}
+	}
+	return req
+}
+
+// fromAnthropicResponse converts a Messages API response.
+func fromAnthropicResponse(resp anthropicResponse) (*Response, error) {
+	var text []string
+	var toolCalls []ToolCall
+	for _, b := range resp.Content {
+		switch b.Type {
+		case "text":
+			text = append(text, b.Text)
+		case "tool_use":
+			toolCalls = append(toolCalls, ToolCall{ID: b.ID, Name: b.Name, Arguments: string(b.Input)})
+		}
+	}
+	joined := strings.TrimSpace(strings.Join(text, ""))
+	if joined == "" && len(toolCalls) == 0 {
+		return nil, errors.New("anthropic returned an empty response")
+	}
+
+	return &Response{
+		Text:      joined,
+		ToolCalls: toolCalls,
+		Model:     resp.Model,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// do sends a request to the API and decodes the JSON response into out, if set.
+func (c *AnthropicClient) do(ctx context.Context, method, path string, in, out any) error {
+	resp, err := c.send(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("anthropic: reading response: %w", err)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("anthropic: decoding response: %w", err)
+	}
+	return nil
+}
+
+// send sends a request to the API, retrying transient failures, and returns the response if it
+// succeeded. The caller closes its body.
+func (c *AnthropicClient) send(ctx context.Context, method, path string, in any) (*http.Response, error) {
+	var data []byte
+	if in != nil {
+		var err error
+		if data, err = json.Marshal(in); err != nil {
+			return nil, fmt.Errorf("anthropic: encoding request: %w", err)
+		}
+	}
+
+	var resp *http.Response
+	err := retry.Do(ctx, retry.Current(), "Anthropic request", func(ctx context.Context) error {
+		var body io.Reader
+		if data != nil {
+			body = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+		if err != nil {
+			return fmt.Errorf("anthropic: %w", err)
+		}
+		req.Header.Set("x-api-key", c.apiKey)
+		req.Header.Set("anthropic-version", anthropicVersion)
+		req.Header.Set("content-type", "application/json")
+
+		resp, err = c.http.Do(req)
+		if err != nil {
+			return fmt.Errorf("anthropic request failed: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		defer resp.Body.Close()
+		errBody, _ := io.ReadAll(resp.Body)
+		var apiErr anthropicError
+		if json.Unmarshal(errBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return retry.FromResponse(resp, fmt.Errorf("anthropic request failed: %s: %s (%s)", resp.Status, apiErr.Error.Message, apiErr.Error.Type))
+		}
+		return retry.FromResponse(resp, fmt.Errorf("anthropic request failed: %s", resp.Status))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// toAnthropicMessages converts messages to the Anthropic wire format. System messages become the
+// separate system prompt, and tool results become user messages, merged with the results of the
+// same turn since the API expects them together.
+func toAnthropicMessages(messages []Message) (string, []anthropicMessage) {
+	var system []string
+	var out []anthropicMessage
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			system = append(system, m.Text())
+			continue
+		case RoleTool:
+			block := anthropicBlock{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Text()}
+			if n := len(out); n > 0 && out[n-1].Role == string(RoleUser) && out[n-1].Content[0].Type == "tool_result" {
+				out[n-1].Content = append(out[n-1].Content, block)
+			} else {
+				out = append(out, anthropicMessage{Role: string(RoleUser), Content: []anthropicBlock{block}})
+			}
+			continue
+		}
+
+		msg := anthropicMessage{Role: string(m.Role)}
+		for _, p := range m.Parts {
+			switch p.Type {
+			case PartText:
+				if p.Text != "" {
+					msg.Content = append(msg.Content, anthropicBlock{Type: "text", Text: p.Text})
+				}
+			case PartImage:
+				msg.Content = append(msg.Content, anthropicBlock{Type: "image", Source: &anthropicImageSource{
+					Type:      "base64",
+					MediaType: p.MIMEType,
+					Data:      base64.StdEncoding.EncodeToString(p.Data),
+				}})
+			}
+		}
+		for _, tc := range m.ToolCalls {
+			input := json.RawMessage(tc.Arguments)
+			if !json.Valid(input) {
+				input = json.RawMessage("{}")
+			}
+			msg.Content = append(msg.Content, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: input})
+		}
+		if len(msg.Content) == 0 {
+			// The API rejects empty messages
+			msg.Content = []anthropicBlock{{Type: "text", Text: "(empty)"}}
+		}
+		out = append(out, msg)
+	}
+	return strings.Join(system, "\n\n"), out
+}
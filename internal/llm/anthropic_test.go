@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+func TestNewAnthropicClient_MissingAPIKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := NewAnthropicClient(); err == nil {
+		t.Fatal("Expected an error when ANTHROPIC_API_KEY is not set, but got nil")
+	}
+}
+
+func TestAnthropicClient_Generate(t *testing.T) {
+	var got anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("Unexpected request %s %s %v", r.Method, r.URL.Path, r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"model": "claude-test", "content": [
+			{"type": "text", "text": " Let me read it. "},
+			{"type": "tool_use", "id": "call_2", "name": "fetch", "input": {"url": "https://example.com/b"}}
+		], "usage": {"input_tokens": 100, "output_tokens": 20}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_MODEL", "claude-default")
+	c, err := NewAnthropicClient()
+	if err != nil {
+		t.Fatalf("NewAnthropicClient failed: %v", err)
+	}
+	c.baseURL = server.URL
+
+	messages := append(BuildMessages(ModeThread, "Context", "What changed?"),
+		Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "fetch", Arguments: `{"url":"https://example.com/a"}`}}},
+		NewToolResultMessage("call_1", "Page A"),
+	)
+	temperature := 0.2
+	resp, err := c.Generate(context.Background(), messages, Options{Model: "claude-override", Tools: []Tool{{Name: "fetch", Parameters: json.RawMessage(`{"type":"object"}`)}}, Params: Params{Temperature: &temperature, MaxTokens: 500}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if got.Model != "claude-override" || got.MaxTokens != 500 || got.Temperature == nil || *got.Temperature != 0.2 || got.TopP != nil || !strings.Contains(got.System, "conversation thread") || len(got.Tools) != 1 {
+		t.Errorf("Unexpected request %+v", got)
+	}
+	if len(got.Messages) != 3 || got.Messages[0].Role != "user" || got.Messages[1].Content[0].Type != "tool_use" {
+		t.Fatalf("Unexpected messages %+v", got.Messages)
+	}
+	if result := got.Messages[2]; result.Role != "user" || result.Content[0].ToolUseID != "call_1" || result.Content[0].Content != "Page A" {
+		t.Errorf("Expected the tool result as a user message, got %+v", result)
+	}
+
+	if resp.Text != "Let me read it." || resp.Model != "claude-test" || resp.Usage.TotalTokens != 120 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_2" || resp.ToolCalls[0].Arguments != `{"url": "https://example.com/b"}` {
+		t.Errorf("Unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
+
+func TestAnthropicClient_RequestToolHistory(t *testing.T) {
+	c := &AnthropicClient{model: "claude-default"}
+	messages := append(BuildMessages(ModeThread, "Context", "What changed?"),
+		Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "fetch", Arguments: `{"url":"https://example.com/a"}`}}},
+		NewToolResultMessage("call_1", "Page A"),
+	)
+
+	// The last round of a tool loop offers no tools, but its history still holds the tool blocks
+	req := c.request(messages, Options{})
+	if len(req.Tools) != 1 || req.Tools[0].Name != "fetch" || req.ToolChoice == nil || req.ToolChoice.Type != "none" {
+		t.Errorf("Expected the tool of the history declared but not callable, got %+v / %+v", req.Tools, req.ToolChoice)
+	}
+
+	if req := c.request(BuildMessages(ModeThread, "Context", "What changed?"), Options{}); len(req.Tools) != 0 || req.ToolChoice != nil {
+		t.Errorf("Expected no tools without tool history, got %+v / %+v", req.Tools, req.ToolChoice)
+	}
+	if req := c.request(messages, Options{Tools: []Tool{{Name: "fetch", Parameters: json.RawMessage(`{"type":"object"}`)}}}); len(req.Tools) != 1 || req.ToolChoice != nil {
+		t.Errorf("Expected the offered tools left as they are, got %+v / %+v", req.Tools, req.ToolChoice)
+	}
+}
+
+func TestAnthropicClient_GenerateStream(t *testing.T) {
+	var got anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`event: message_start
+data: {"type": "message_start", "message": {"model": "claude-test", "usage": {"input_tokens": 100}}}
+
+event: content_block_start
+data: {"type": "content_block_start", "index": 0, "content_block": {"type": "text", "text": ""}}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "Hello"}}
+
+event: ping
+data: {"type": "ping"}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": " world"}}
+
+event: content_block_start
+data: {"type": "content_block_start", "index": 1, "content_block": {"type": "tool_use", "id": "call_1", "name": "fetch", "input": {}}}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": "{\"url\": "}}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": "\"https://example.com\"}"}}
+
+event: message_delta
+data: {"type": "message_delta", "delta": {"stop_reason": "tool_use"}, "usage": {"output_tokens": 20}}
+
+event: message_stop
+data: {"type": "message_stop"}
+`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	c, err := NewAnthropicClient()
+	if err != nil {
+		t.Fatalf("NewAnthropicClient failed: %v", err)
+	}
+	c.baseURL = server.URL
+
+	var deltas []string
+	resp, err := c.GenerateStream(context.Background(), BuildMessages(ModeSummary, "Content", ""), Options{}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream failed: %v", err)
+	}
+
+	if !got.Stream {
+		t.Error("Expected a streaming request")
+	}
+	if strings.Join(deltas, "|") != "Hello| world" {
+		t.Errorf("Unexpected deltas %q", deltas)
+	}
+	if resp.Text != "Hello world" || resp.Model != "claude-test" || resp.Usage.TotalTokens != 120 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_1" || resp.ToolCalls[0].Arguments != `{"url": "https://example.com"}` {
+		t.Errorf("Unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
+
+func TestAnthropicClient_Errors(t *testing.T) {
+	retry.SetLimits(2, 0)
+	t.Cleanup(func() { retry.SetLimits(retry.Default.MaxAttempts, retry.Default.MaxElapsed) })
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/v1/models/claude-default" {
+			w.Write([]byte(`{"id": "claude-default"}`))
+			return
+		}
+		attempts++
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"type": "error", "error": {"type": "rate_limit_error", "message": "Number of requests has exceeded your rate limit"}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_MODEL", "claude-default")
+	c, _ := NewAnthropicClient()
+	c.baseURL = server.URL
+
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+	_, err := c.Generate(context.Background(), BuildMessages(ModeSummary, "Content", ""), Options{})
+	if err == nil || !strings.Contains(err.Error(), "429") || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("Expected the API error to be reported, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected the rate-limited request to be retried once, got %d attempts", attempts)
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	if l, err := New(ProviderAnthropic); err != nil {
+		t.Errorf("New failed: %v", err)
+	} else if _, ok := l.(*AnthropicClient); !ok {
+		t.Errorf("Expected an Anthropic client, got %T", l)
+	}
+	if _, err := New("palm"); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
@@ -0,0 +1,267 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/document"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+// BedrockClient implements the LLM interface using the Amazon Bedrock Converse API, which serves
+// Claude, Titan and the other Bedrock models with the same request format.
+type BedrockClient struct {
+	client        *bedrockruntime.Client
+	credentials   aws.CredentialsProvider
+	model         string
+	contextWindow int // Overrides the known context window of the model; 0 uses it
+}
+
+// NewBedrockClient creates a new Bedrock client.
+// Credentials are resolved like the AWS CLI does: environment variables, the shared config files
+// (AWS_PROFILE), or the IAM role of the ECS task or EC2 instance, so no API key is needed inside AWS.
+// It requires AWS_REGION (or a region in the profile). BEDROCK_MODEL overrides the default model and
+// takes a model ID or an inference profile ID; LLM_CONTEXT_WINDOW overrides its context window, which
+// prompts are trimmed to.
+func NewBedrockClient() (*BedrockClient, error) {
+	// Failed calls are retried like those of the other providers, see package retry
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRetryer(func() aws.Retryer { return aws.NopRetryer{} }))
+	if err != nil {
+		return nil, fmt.Errorf("bedrock: loading AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("AWS_REGION environment variable not set")
+	}
+
+	model := "global.anthropic.claude-sonnet-4-5-20250929-v1:0"
+	if os.Getenv("BEDROCK_MODEL") != "" {
+		model = os.Getenv("BEDROCK_MODEL")
+	}
+
+	window, err := envContextWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	// The SDK also reads AWS_ENDPOINT_URL_BEDROCK_RUNTIME, e.g. for a VPC endpoint without private DNS
+	return &BedrockClient{client: bedrockruntime.NewFromConfig(cfg), credentials: cfg.Credentials, model: model, contextWindow: window}, nil
+}
+
+// Ping checks that AWS credentials can be resolved. The runtime API has no call that checks a model
+// without invoking it.
+func (c *BedrockClient) Ping(ctx context.Context) error {
+	if c.credentials == nil {
+		return errors.New("bedrock: no AWS credentials found")
+	}
+	if _, err := c.credentials.Retrieve(ctx); err != nil {
+		return fmt.Errorf("bedrock: %w", err)
+	}
+	return nil
+}
+
+// Model returns the model used when Options.Model is empty.
+func (c *BedrockClient) Model() string {
+	return c.model
+}
+
+// Generate sends the conversation to the Bedrock Converse API.
+func (c *BedrockClient) Generate(ctx context.Context, messages []Message, opts Options) (*Response, error) {
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	system, converse := toBedrockMessages(fitContext(model, c.contextWindow, messages), supportsSystemPrompt(model))
+	input := &bedrockruntime.ConverseInput{ModelId: aws.String(model), System: system, Messages: converse}
+	if opts.Temperature != nil || opts.TopP != nil || opts.MaxTokens > 0 {
+		input.InferenceConfig = &types.InferenceConfiguration{Temperature: float32Ptr(opts.Temperature), TopP: float32Ptr(opts.TopP)}
+		if opts.MaxTokens > 0 {
+			input.InferenceConfig.MaxTokens = aws.Int32(int32(opts.MaxTokens))
+		}
+	}
+	tools := opts.Tools
+	if len(tools) == 0 {
+		// Converse rejects toolUse and toolResult blocks without a toolConfig, and has no choice that
+		// forbids calling the tools, so the tools of earlier turns are declared as no longer available
+		for _, name := range historyTools(messages) {
+			tools = append(tools, Tool{Name: name, Description: "No longer available; answer with what it returned."})
+		}
+	}
+	if len(tools) > 0 {
+		toolConfig := &types.ToolConfiguration{}
+		for _, t := range tools {
+			var params any = map[string]any{"type": "object"}
+			if len(t.Parameters) > 0 {
+				if err := json.Unmarshal(t.Parameters, &params); err != nil {
+					return nil, fmt.Errorf("bedrock: parameters of tool %s: %w", t.Name, err)
+				}
+			}
+			toolConfig.Tools = append(toolConfig.Tools, &types.ToolMemberToolSpec{Value: types.ToolSpecification{
+				Name:        aws.String(t.Name),
+				Description: aws.String(t.Description),
+				InputSchema: &types.ToolInputSchemaMemberJson{Value: document.NewLazyDocument(params)},
+			}})
+		}
+		input.ToolConfig = toolConfig
+	}
+
+	var out *bedrockruntime.ConverseOutput
+	err := retry.Do(ctx, retry.Current(), "Bedrock converse", func(ctx context.Context) error {
+		var err error
+		out, err = c.client.Converse(ctx, input)
+		var respErr interface{ HTTPStatusCode() int }
+		if errors.As(err, &respErr) {
+			return &retry.StatusError{Code: respErr.HTTPStatusCode(), Err: err}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bedrock converse failed: %w", err)
+	}
+	return fromBedrockOutput(out, model)
+}
+
+// supportsSystemPrompt reports whether the model accepts system prompts. Titan text models reject them.
+func supportsSystemPrompt(model string) bool {
+	return !strings.Contains(model, "amazon.titan")
+}
+
+// fromBedrockOutput converts a Converse response.
+func fromBedrockOutput(out *bedrockruntime.ConverseOutput, model string) (*Response, error) {
+	msg, ok := out.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return nil, errors.New("bedrock returned no message")
+	}
+
+	var text []string
+	var toolCalls []ToolCall
+	for _, block := range msg.Value.Content {
+		switch b := block.(type) {
+		case *types.ContentBlockMemberText:
+			text = append(text, b.Value)
+		case *types.ContentBlockMemberToolUse:
+			args := []byte("{}")
+			if b.Value.Input != nil {
+				data, err := b.Value.Input.MarshalSmithyDocument()
+				if err != nil {
+					return nil, fmt.Errorf("bedrock: arguments of %s: %w", aws.ToString(b.Value.Name), err)
+				}
+				args = data
+			}
+			toolCalls = append(toolCalls, ToolCall{ID: aws.ToString(b.Value.ToolUseId), Name: aws.ToString(b.Value.Name), Arguments: string(args)})
+		}
+	}
+	joined := strings.TrimSpace(strings.Join(text, ""))
+	if joined == "" && len(toolCalls) == 0 {
+		return nil, fmt.Errorf("bedrock returned an empty response (stop reason %s)", out.StopReason)
+	}
+
+	resp := &Response{Text: joined, ToolCalls: toolCalls, Model: model}
+	if u := out.Usage; u != nil {
+		resp.Usage = Usage{
+			PromptTokens:     int(aws.ToInt32(u.InputTokens)),
+			CompletionTokens: int(aws.ToInt32(u.OutputTokens)),
+			TotalTokens:      int(aws.ToInt32(u.TotalTokens)),
+		}
+	}
+	return resp, nil
+}
+
+// toBedrockMessages converts messages to Converse messages. System messages become the separate system
+// prompt, or lead the first user turn for models without one. Tool results are sent as user content,
+// and consecutive messages of the same role are merged, since Bedrock expects the turns to alternate.
+func toBedrockMessages(messages []Message, systemPrompt bool) ([]types.SystemContentBlock, []types.Message) {
+	var system []types.SystemContentBlock
+	var systemText []string
+	var out []types.Message
+	for _, m := range messages {
+		role := types.ConversationRoleUser
+		var content []types.ContentBlock
+		switch m.Role {
+		case RoleSystem:
+			if text := m.Text(); text != "" {
+				system = append(system, &types.SystemContentBlockMemberText{Value: text})
+				systemText = append(systemText, text)
+			}
+			continue
+		case RoleTool:
+			content = append(content, &types.ContentBlockMemberToolResult{Value: types.ToolResultBlock{
+				ToolUseId: aws.String(m.ToolCallID),
+				Content:   []types.ToolResultContentBlock{&types.ToolResultContentBlockMemberText{Value: m.Text()}},
+			}})
+		default:
+			if m.Role == RoleAssistant {
+				role = types.ConversationRoleAssistant
+			}
+			for _, p := range m.Parts {
+				switch p.Type {
+				case PartText:
+					if p.Text != "" {
+						content = append(content, &types.ContentBlockMemberText{Value: p.Text})
+					}
+				case PartImage:
+					content = append(content, &types.ContentBlockMemberImage{Value: types.ImageBlock{
+						Format: types.ImageFormat(strings.TrimPrefix(strings.TrimPrefix(p.MIMEType, "image/"), "x-")),
+						Source: &types.ImageSourceMemberBytes{Value: p.Data},
+					}})
+				}
+			}
+			for _, tc := range m.ToolCalls {
+				var args any
+				if err := json.Unmarshal([]byte(tc.Arguments), &args); err != nil {
+					args = map[string]any{}
+				}
+				content = append(content, &types.ContentBlockMemberToolUse{Value: types.ToolUseBlock{
+					ToolUseId: aws.String(tc.ID),
+					Name:      aws.String(tc.Name),
+					Input:     document.NewLazyDocument(args),
+				}})
+			}
+		}
+		if len(content) == 0 {
+			// The API rejects messages without content
+			content = []types.ContentBlock{&types.ContentBlockMemberText{Value: "(empty)"}}
+		}
+
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, content...)
+		} else {
+			out = append(out, types.Message{Role: role, Content: content})
+		}
+	}
+
+	if len(system) == 0 {
+		return nil, out
+	}
+	if len(out) == 0 {
+		// A conversation needs at least one message, so a lone system prompt is sent as the user turn
+		return nil, []types.Message{{Role: types.ConversationRoleUser, Content: toTextBlocks(systemText)}}
+	}
+	if systemPrompt {
+		return system, out
+	}
+	lead := toTextBlocks(systemText)
+	if out[0].Role == types.ConversationRoleUser {
+		out[0].Content = append(lead, out[0].Content...)
+		return nil, out
+	}
+	return nil, append([]types.Message{{Role: types.ConversationRoleUser, Content: lead}}, out...)
+}
+
+// toTextBlocks converts texts to content blocks.
+func toTextBlocks(texts []string) []types.ContentBlock {
+	blocks := make([]types.ContentBlock, 0, len(texts))
+	for _, t := range texts {
+		blocks = append(blocks, &types.ContentBlockMemberText{Value: t})
+	}
+	return blocks
+}
@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// bedrockMessage mirrors the wire format of a Converse message, as received by the test server.
+type bedrockMessage struct {
+	Role    string `json:"role"`
+	Content []struct {
+		Text    string `json:"text"`
+		ToolUse *struct {
+			ToolUseID string         `json:"toolUseId"`
+			Name      string         `json:"name"`
+			Input     map[string]any `json:"input"`
+		} `json:"toolUse"`
+		ToolResult *struct {
+			ToolUseID string `json:"toolUseId"`
+			Content   []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"toolResult"`
+	} `json:"content"`
+}
+
+// setBedrockEnv points the AWS SDK at server with static credentials, away from any local AWS setup.
+func setBedrockEnv(t *testing.T, server string) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_ENDPOINT_URL_BEDROCK_RUNTIME", server)
+}
+
+func TestBedrockClient_Generate(t *testing.T) {
+	var path string
+	var got struct {
+		Messages []bedrockMessage `json:"messages"`
+		System   []struct {
+			Text string `json:"text"`
+		} `json:"system"`
+		ToolConfig struct {
+			Tools []struct {
+				ToolSpec struct {
+					Name        string `json:"name"`
+					InputSchema struct {
+						JSON map[string]any `json:"json"`
+					} `json:"inputSchema"`
+				} `json:"toolSpec"`
+			} `json:"tools"`
+		} `json:"toolConfig"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") {
+			t.Errorf("Expected a signed request, got %v", r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"output": {"message": {"role": "assistant", "content": [
+			{"text": " Let me read it. "},
+			{"toolUse": {"toolUseId": "tooluse_2", "name": "fetch", "input": {"url": "https://example.com/b"}}}
+		]}}, "stopReason": "tool_use", "usage": {"inputTokens": 100, "outputTokens": 20, "totalTokens": 120}}`))
+	}))
+	defer server.Close()
+
+	setBedrockEnv(t, server.URL)
+	t.Setenv("BEDROCK_MODEL", "anthropic.claude-test")
+	c, err := NewBedrockClient()
+	if err != nil {
+		t.Fatalf("NewBedrockClient failed: %v", err)
+	}
+
+	messages := append(BuildMessages(ModeThread, "Context", "What changed?"),
+		Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "tooluse_1", Name: "fetch", Arguments: `{"url":"https://example.com/a"}`}}},
+		NewToolResultMessage("tooluse_1", "Page A"),
+	)
+	resp, err := c.Generate(context.Background(), messages, Options{Tools: []Tool{{Name: "fetch", Parameters: json.RawMessage(`{"type":"object"}`)}}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if path != "/model/anthropic.claude-test/converse" {
+		t.Errorf("Unexpected path %s", path)
+	}
+	if len(got.System) != 1 || len(got.ToolConfig.Tools) != 1 || got.ToolConfig.Tools[0].ToolSpec.InputSchema.JSON["type"] != "object" {
+		t.Errorf("Unexpected request %+v", got)
+	}
+	if n := len(got.Messages); n != 3 || got.Messages[1].Content[0].ToolUse.Input["url"] != "https://example.com/a" ||
+		got.Messages[2].Role != "user" || got.Messages[2].Content[0].ToolResult.Content[0].Text != "Page A" {
+		t.Errorf("Unexpected messages %+v", got.Messages)
+	}
+	if resp.Text != "Let me read it." || resp.Model != "anthropic.claude-test" || resp.Usage.TotalTokens != 120 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "tooluse_2" || resp.ToolCalls[0].Arguments != `{"url":"https://example.com/b"}` {
+		t.Errorf("Unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
+
+func TestBedrockClient_GenerateToolHistory(t *testing.T) {
+	var got struct {
+		ToolConfig *struct {
+			Tools []struct {
+				ToolSpec struct {
+					Name string `json:"name"`
+				} `json:"toolSpec"`
+			} `json:"tools"`
+		} `json:"toolConfig"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.ToolConfig = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"output": {"message": {"role": "assistant", "content": [{"text": "Answer"}]}}, "stopReason": "end_turn", "usage": {}}`))
+	}))
+	defer server.Close()
+
+	setBedrockEnv(t, server.URL)
+	c, err := NewBedrockClient()
+	if err != nil {
+		t.Fatalf("NewBedrockClient failed: %v", err)
+	}
+
+	// The last round of a tool loop offers no tools, but its history still holds the tool blocks
+	messages := append(BuildMessages(ModeThread, "Context", "What changed?"),
+		Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "tooluse_1", Name: "fetch", Arguments: `{"url":"https://example.com/a"}`}}},
+		NewToolResultMessage("tooluse_1", "Page A"),
+	)
+	if _, err := c.Generate(context.Background(), messages, Options{}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if got.ToolConfig == nil || len(got.ToolConfig.Tools) != 1 || got.ToolConfig.Tools[0].ToolSpec.Name != "fetch" {
+		t.Errorf("Expected the tool of the history declared, got %+v", got.ToolConfig)
+	}
+
+	if _, err := c.Generate(context.Background(), BuildMessages(ModeThread, "Context", "What changed?"), Options{}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if got.ToolConfig != nil {
+		t.Errorf("Expected no toolConfig without tool history, got %+v", got.ToolConfig)
+	}
+}
+
+func TestToBedrockMessages_NoSystemPrompt(t *testing.T) {
+	messages := BuildMessages(ModeSummary, "Content", "")
+	system, converse := toBedrockMessages(messages, false)
+	if system != nil {
+		t.Errorf("Expected no system prompt, got %+v", system)
+	}
+	if len(converse) != 1 || len(converse[0].Content) != 2 {
+		t.Fatalf("Expected the system prompt to lead the user turn, got %+v", converse)
+	}
+}
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+// DefaultEmbeddingModel is the OpenAI model that embeds text unless another is configured.
+const DefaultEmbeddingModel = string(openai.SmallEmbedding3)
+
+// OpenAIEmbedder turns text into embedding vectors with the OpenAI embeddings API, whichever provider
+// summarizes.
+type OpenAIEmbedder struct {
+	client *openai.Client
+	model  string
+}
+
+// NewOpenAIEmbedder creates an embedder using model (e.g. "text-embedding-3-small"), with the same
+// OPENAI_API_KEY, OPENAI_BASE_URL and OPENAI_EXTRA_HEADERS settings as NewOpenAIClient. An empty model uses
+// DefaultEmbeddingModel.
+func NewOpenAIEmbedder(model string) (*OpenAIEmbedder, error) {
+	client, err := newOpenAIAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	return &OpenAIEmbedder{client: client, model: model}, nil
+}
+
+// Embed returns the embedding of each of texts, in the same order.
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp openai.EmbeddingResponse
+	err := retry.Do(ctx, retry.Current(), "OpenAI embeddings", func(ctx context.Context) error {
+		var err error
+		resp, err = e.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{Input: texts, Model: openai.EmbeddingModel(e.model)})
+		return openAIError(err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai embeddings failed: %w", err)
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("openai embeddings returned index %d for %d texts", d.Index, len(texts))
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("openai embeddings returned no embedding for text %d", i+1)
+		}
+	}
+	return vectors, nil
+}
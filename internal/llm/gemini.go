@@ -0,0 +1,231 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+// GeminiClient implements the LLM interface using the Google GenAI SDK, against either the
+// Gemini API or Vertex AI.
+type GeminiClient struct {
+	client        *genai.Client
+	model         string
+	contextWindow int // Overrides the known context window of the model; 0 uses it
+}
+
+// NewGeminiClient creates a new Gemini client.
+// It requires GEMINI_API_KEY (or GOOGLE_API_KEY) to be set, or GOOGLE_GENAI_USE_VERTEXAI together with
+// GOOGLE_CLOUD_PROJECT and GOOGLE_CLOUD_LOCATION to use Vertex AI with the application default credentials.
+// GEMINI_MODEL overrides the default model and LLM_CONTEXT_WINDOW its context window, which prompts are
+// trimmed to.
+func NewGeminiClient() (*GeminiClient, error) {
+	vertex := strings.EqualFold(os.Getenv("GOOGLE_GENAI_USE_VERTEXAI"), "true") || os.Getenv("GOOGLE_GENAI_USE_VERTEXAI") == "1"
+	if !vertex && os.Getenv("GEMINI_API_KEY") == "" && os.Getenv("GOOGLE_API_KEY") == "" {
+		return nil, errors.New("GEMINI_API_KEY environment variable not set")
+	}
+	// The SDK reads the key, Vertex AI settings and GOOGLE_GEMINI_BASE_URL from the environment
+	client, err := genai.NewClient(context.Background(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("gemini: %w", err)
+	}
+
+	model := "gemini-2.5-pro"
+	if os.Getenv("GEMINI_MODEL") != "" {
+		model = os.Getenv("GEMINI_MODEL")
+	}
+
+	window, err := envContextWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeminiClient{client: client, model: model, contextWindow: window}, nil
+}
+
+// Ping checks that the API answers and knows the configured model.
+func (c *GeminiClient) Ping(ctx context.Context) error {
+	if _, err := c.client.Models.Get(ctx, c.model, nil); err != nil {
+		return fmt.Errorf("gemini: %w", err)
+	}
+	return nil
+}
+
+// Model returns the model used when Options.Model is empty.
+func (c *GeminiClient) Model() string {
+	return c.model
+}
+
+// Generate sends the conversation to the Gemini generateContent API.
+func (c *GeminiClient) Generate(ctx context.Context, messages []Message, opts Options) (*Response, error) {
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	system, contents := toGeminiContents(fitContext(model, c.contextWindow, messages))
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: system,
+		Temperature:       float32Ptr(opts.Temperature),
+		TopP:              float32Ptr(opts.TopP),
+		PresencePenalty:   float32Ptr(opts.PresencePenalty),
+		MaxOutputTokens:   int32(opts.MaxTokens),
+	}
+	if len(opts.Tools) > 0 {
+		tool := &genai.Tool{}
+		for _, t := range opts.Tools {
+			var params any
+			if len(t.Parameters) > 0 {
+				if err := json.Unmarshal(t.Parameters, &params); err != nil {
+					return nil, fmt.Errorf("gemini: parameters of tool %s: %w", t.Name, err)
+				}
+			}
+			tool.FunctionDeclarations = append(tool.FunctionDeclarations, &genai.FunctionDeclaration{
+				Name:                 t.Name,
+				Description:          t.Description,
+				ParametersJsonSchema: params,
+			})
+		}
+		config.Tools = []*genai.Tool{tool}
+	}
+
+	var resp *genai.GenerateContentResponse
+	err := retry.Do(ctx, retry.Current(), "Gemini generate content", func(ctx context.Context) error {
+		var err error
+		resp, err = c.client.Models.GenerateContent(ctx, model, contents, config)
+		var apiErr genai.APIError
+		if errors.As(err, &apiErr) {
+			return &retry.StatusError{Code: apiErr.Code, Err: err}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gemini generate content failed: %w", err)
+	}
+	return fromGeminiResponse(resp, model)
+}
+
+// fromGeminiResponse converts a generateContent response. Gemini does not always identify function
+// calls, so calls without an ID are numbered to let tool results refer to them.
+func fromGeminiResponse(resp *genai.GenerateContentResponse, model string) (*Response, error) {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+			return nil, fmt.Errorf("gemini blocked the prompt: %s", resp.PromptFeedback.BlockReason)
+		}
+		return nil, errors.New("gemini returned no candidates")
+	}
+
+	var text []string
+	var toolCalls []ToolCall
+	for _, p := range resp.Candidates[0].Content.Parts {
+		switch {
+		case p.Thought:
+		case p.FunctionCall != nil:
+			args, err := json.Marshal(p.FunctionCall.Args)
+			if err != nil {
+				return nil, fmt.Errorf("gemini: arguments of %s: %w", p.FunctionCall.Name, err)
+			}
+			id := p.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d", len(toolCalls)+1)
+			}
+			toolCalls = append(toolCalls, ToolCall{ID: id, Name: p.FunctionCall.Name, Arguments: string(args)})
+		case p.Text != "":
+			text = append(text, p.Text)
+		}
+	}
+	joined := strings.TrimSpace(strings.Join(text, ""))
+	if joined == "" && len(toolCalls) == 0 {
+		return nil, fmt.Errorf("gemini returned an empty response (finish reason %s)", resp.Candidates[0].FinishReason)
+	}
+
+	out := &Response{Text: joined, ToolCalls: toolCalls, Model: resp.ModelVersion}
+	if out.Model == "" {
+		out.Model = model
+	}
+	if u := resp.UsageMetadata; u != nil {
+		out.Usage = Usage{
+			PromptTokens:     int(u.PromptTokenCount),
+			CompletionTokens: int(u.CandidatesTokenCount + u.ThoughtsTokenCount),
+			TotalTokens:      int(u.TotalTokenCount),
+		}
+	}
+	return out, nil
+}
+
+// toGeminiContents converts messages to Gemini contents. Gemini has no system role: system messages,
+// wherever they appear, become the separate system instruction. Assistant messages take the "model"
+// role, and tool results become function responses, which Gemini matches to calls by function name.
+// Consecutive contents of the same role are merged, since Gemini expects the turns to alternate.
+func toGeminiContents(messages []Message) (*genai.Content, []*genai.Content) {
+	var system []*genai.Part
+	var contents []*genai.Content
+	callNames := make(map[string]string) // Function name by tool call ID
+	for _, m := range messages {
+		var role genai.Role
+		var parts []*genai.Part
+		switch m.Role {
+		case RoleSystem:
+			if text := m.Text(); text != "" {
+				system = append(system, genai.NewPartFromText(text))
+			}
+			continue
+		case RoleTool:
+			role = genai.RoleUser
+			resp := genai.NewPartFromFunctionResponse(callNames[m.ToolCallID], map[string]any{"output": m.Text()})
+			resp.FunctionResponse.ID = m.ToolCallID
+			parts = append(parts, resp)
+		default:
+			role = genai.RoleUser
+			if m.Role == RoleAssistant {
+				role = genai.RoleModel
+			}
+			for _, p := range m.Parts {
+				switch p.Type {
+				case PartText:
+					if p.Text != "" {
+						parts = append(parts, genai.NewPartFromText(p.Text))
+					}
+				case PartImage:
+					parts = append(parts, genai.NewPartFromBytes(p.Data, p.MIMEType))
+				}
+			}
+			for _, tc := range m.ToolCalls {
+				var args map[string]any
+				if err := json.Unmarshal([]byte(tc.Arguments), &args); err != nil {
+					args = map[string]any{}
+				}
+				call := genai.NewPartFromFunctionCall(tc.Name, args)
+				call.FunctionCall.ID = tc.ID
+				parts = append(parts, call)
+				callNames[tc.ID] = tc.Name
+			}
+		}
+		if len(parts) == 0 {
+			// The API rejects contents without parts
+			parts = []*genai.Part{genai.NewPartFromText("(empty)")}
+		}
+
+		if n := len(contents); n > 0 && contents[n-1].Role == string(role) {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+		} else {
+			contents = append(contents, genai.NewContentFromParts(parts, role))
+		}
+	}
+
+	if len(system) == 0 {
+		return nil, contents
+	}
+	if len(contents) == 0 {
+		// A conversation needs at least one content, so a lone system prompt is sent as the user turn
+		return nil, []*genai.Content{genai.NewContentFromParts(system, genai.RoleUser)}
+	}
+	return &genai.Content{Parts: system}, contents
+}
@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewGeminiClient_MissingAPIKey(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "")
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+	if _, err := NewGeminiClient(); err == nil {
+		t.Fatal("Expected an error when GEMINI_API_KEY is not set, but got nil")
+	}
+}
+
+// geminiContent mirrors the wire format of a Gemini content, as received by the test server.
+type geminiContent struct {
+	Role  string `json:"role"`
+	Parts []struct {
+		Text         string `json:"text"`
+		FunctionCall *struct {
+			ID   string         `json:"id"`
+			Name string         `json:"name"`
+			Args map[string]any `json:"args"`
+		} `json:"functionCall"`
+		FunctionResponse *struct {
+			ID       string         `json:"id"`
+			Name     string         `json:"name"`
+			Response map[string]any `json:"response"`
+		} `json:"functionResponse"`
+	} `json:"parts"`
+}
+
+func TestGeminiClient_Generate(t *testing.T) {
+	var path string
+	var got struct {
+		Contents          []geminiContent `json:"contents"`
+		SystemInstruction *geminiContent  `json:"systemInstruction"`
+		Tools             []struct {
+			FunctionDeclarations []struct {
+				Name                 string         `json:"name"`
+				ParametersJsonSchema map[string]any `json:"parametersJsonSchema"`
+			} `json:"functionDeclarations"`
+		} `json:"tools"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if r.Header.Get("x-goog-api-key") != "test-key" {
+			t.Errorf("Unexpected headers %v", r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"modelVersion": "gemini-test", "candidates": [{"content": {"role": "model", "parts": [
+			{"text": " Let me read it. "},
+			{"functionCall": {"name": "fetch", "args": {"url": "https://example.com/b"}}}
+		]}, "finishReason": "STOP"}], "usageMetadata": {"promptTokenCount": 100, "candidatesTokenCount": 20, "totalTokenCount": 120}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_API_KEY", "")
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", server.URL)
+	t.Setenv("GEMINI_MODEL", "gemini-default")
+	c, err := NewGeminiClient()
+	if err != nil {
+		t.Fatalf("NewGeminiClient failed: %v", err)
+	}
+
+	messages := append(BuildMessages(ModeThread, "Context", "What changed?"),
+		Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "fetch", Arguments: `{"url":"https://example.com/a"}`}}},
+		NewToolResultMessage("call_1", "Page A"),
+	)
+	resp, err := c.Generate(context.Background(), messages, Options{Model: "gemini-override", Tools: []Tool{{Name: "fetch", Parameters: json.RawMessage(`{"type":"object"}`)}}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if !strings.HasSuffix(path, "/models/gemini-override:generateContent") {
+		t.Errorf("Expected the overriding model to be requested, got %s", path)
+	}
+	if got.SystemInstruction == nil || !strings.Contains(got.SystemInstruction.Parts[0].Text, "conversation thread") {
+		t.Errorf("Expected the system prompt as the system instruction, got %+v", got.SystemInstruction)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].FunctionDeclarations[0].Name != "fetch" || got.Tools[0].FunctionDeclarations[0].ParametersJsonSchema["type"] != "object" {
+		t.Errorf("Unexpected tools %+v", got.Tools)
+	}
+	if len(got.Contents) != 3 || got.Contents[0].Role != "user" || got.Contents[1].Role != "model" || got.Contents[1].Parts[0].FunctionCall == nil {
+		t.Fatalf("Unexpected contents %+v", got.Contents)
+	}
+	if result := got.Contents[2]; result.Role != "user" || result.Parts[0].FunctionResponse == nil ||
+		result.Parts[0].FunctionResponse.Name != "fetch" || result.Parts[0].FunctionResponse.Response["output"] != "Page A" {
+		t.Errorf("Expected the tool result as a named function response, got %+v", result)
+	}
+
+	if resp.Text != "Let me read it." || resp.Model != "gemini-test" || resp.Usage.TotalTokens != 120 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID == "" || resp.ToolCalls[0].Arguments != `{"url":"https://example.com/b"}` {
+		t.Errorf("Unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
+
+func TestToGeminiContents_SystemOnly(t *testing.T) {
+	system, contents := toGeminiContents([]Message{NewTextMessage(RoleSystem, "Summarize: hello")})
+	if system != nil || len(contents) != 1 || contents[0].Role != "user" || contents[0].Parts[0].Text != "Summarize: hello" {
+		t.Errorf("Expected a lone system prompt to be sent as the user turn, got %+v %+v", system, contents)
+	}
+}
@@ -1,11 +1,223 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Role identifies the author of a message.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool" // Result of a tool call
+)
+
+// PartType identifies the kind of content held by a Part.
+type PartType string
+
+const (
+	PartText  PartType = "text"
+	PartImage PartType = "image"
+)
+
+// Part is a single piece of message content.
+type Part struct {
+	Type     PartType
+	Text     string // Set for PartText
+	Data     []byte // Raw image bytes, set for PartImage
+	MIMEType string // MIME type of Data, e.g. "image/png"
+}
+
+// TextPart creates a text content part.
+func TextPart(text string) Part {
+	return Part{Type: PartText, Text: text}
+}
+
+// ImagePart creates an image content part from raw bytes.
+func ImagePart(data []byte, mimeType string) Part {
+	return Part{Type: PartImage, Data: data, MIMEType: mimeType}
+}
+
+// Message is one turn of a conversation.
+type Message struct {
+	Role       Role
+	Parts      []Part
+	ToolCalls  []ToolCall // Tool invocations requested by an assistant message
+	ToolCallID string     // For RoleTool messages, the call this message answers
+}
+
+// Tool describes a function the model may call.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage // JSON schema of the arguments object
+}
+
+// ToolCall is a tool invocation requested by the model.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string // JSON-encoded arguments
+}
+
+// historyTools returns the names of the tools called in messages, for providers that need the tools of
+// earlier turns declared even in calls that offer none.
+func historyTools(messages []Message) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range messages {
+		for _, tc := range m.ToolCalls {
+			if !seen[tc.Name] {
+				seen[tc.Name] = true
+				names = append(names, tc.Name)
+			}
+		}
+	}
+	return names
+}
+
+// NewToolResultMessage creates the message answering a tool call.
+func NewToolResultMessage(callID string, result string) Message {
+	return Message{Role: RoleTool, Parts: []Part{TextPart(result)}, ToolCallID: callID}
+}
+
+// NewTextMessage creates a message consisting of a single text part.
+func NewTextMessage(role Role, text string) Message {
+	return Message{Role: role, Parts: []Part{TextPart(text)}}
+}
+
+// Text returns the concatenated text parts of the message.
+func (m Message) Text() string {
+	var texts []string
+	for _, p := range m.Parts {
+		if p.Type == PartText {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Options holds per-call settings. Zero values mean provider defaults.
+type Options struct {
+	Model  string  // Overrides the provider's default model
+	Tools  []Tool  // Tools the model may call; empty disables tool calling
+	Schema *Schema // Constrains the reply to JSON of this schema where the provider supports structured outputs
+	Params
+}
+
+// Params are the sampling parameters of a call. Nil and zero values mean provider defaults; providers
+// ignore parameters their API does not have.
+type Params struct {
+	Temperature     *float64 // Randomness, from 0 (deterministic) to 2
+	TopP            *float64 // Nucleus sampling probability mass, from 0 to 1
+	PresencePenalty *float64 // From -2 to 2; positive values discourage repeating topics
+	MaxTokens       int      // Upper bound of generated tokens
+}
+
+// Or returns p with the parameters it leaves unset taken from defaults.
+func (p Params) Or(defaults Params) Params {
+	if p.Temperature == nil {
+		p.Temperature = defaults.Temperature
+	}
+	if p.TopP == nil {
+		p.TopP = defaults.TopP
+	}
+	if p.PresencePenalty == nil {
+		p.PresencePenalty = defaults.PresencePenalty
+	}
+	if p.MaxTokens == 0 {
+		p.MaxTokens = defaults.MaxTokens
+	}
+	return p
+}
+
+// float32Ptr converts an optional parameter for APIs taking float32.
+func float32Ptr(f *float64) *float32 {
+	if f == nil {
+		return nil
+	}
+	v := float32(*f)
+	return &v
+}
+
+// Schema is the JSON schema a structured reply must follow. Providers without structured outputs ignore it,
+// so the prompt must still describe the expected JSON.
+type Schema struct {
+	Name       string          // Identifies the schema to the provider, e.g. "summary"
+	Definition json.RawMessage // JSON schema of the reply; objects need every property required and no additional ones
+}
+
+// Usage reports token consumption of a call.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// Response is the result of a generation call.
+type Response struct {
+	Text      string
+	ToolCalls []ToolCall // Set when the model wants tools run before answering
+	Model     string     // Model that actually served the request
+	Usage     Usage
+	Cached    bool // Served from a cache instead of the model, see llmcache
+}
 
 // LLM defines the interface for interacting with a Large Language Model.
 type LLM interface {
-	// ProcessContent takes content and an optional user prompt, returning a processed response.
-	ProcessContent(ctx context.Context, content string, userPrompt string) (string, error)
-	// ProcessContentWithMode allows specifying the processing mode (summary/thread)
-	ProcessContentWithMode(ctx context.Context, content string, userPrompt string, mode string) (string, error)
+	// Generate produces the next assistant message for the given conversation.
+	Generate(ctx context.Context, messages []Message, opts Options) (*Response, error)
+}
+
+// Pinger is implemented by LLMs that can check their provider is reachable without generating text.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// BatchRequest is one generation of a batch, identified by ID in the results.
+type BatchRequest struct {
+	ID       string
+	Messages []Message
+	Options  Options
+}
+
+// BatchResult is the outcome of one BatchRequest: a response or why it failed.
+type BatchResult struct {
+	Response *Response
+	Err      error
+}
+
+// Batcher is implemented by LLMs that can run many generations asynchronously at a lower price,
+// for workloads where nobody waits for the answer.
+type Batcher interface {
+	// SubmitBatch starts a batch and returns its ID.
+	SubmitBatch(ctx context.Context, requests []BatchRequest) (string, error)
+	// BatchResults returns the results by request ID once the batch has finished, or false while it runs.
+	// Requests missing from the results were not processed, e.g. because the batch expired. An error with
+	// true means the batch itself failed and will not produce results.
+	BatchResults(ctx context.Context, batchID string) (map[string]BatchResult, bool, error)
+}
+
+// Streamer is implemented by LLMs that can deliver the text of a response while it is being generated.
+type Streamer interface {
+	// GenerateStream works like Generate, calling onText with each piece of text as the model writes it.
+	// The returned Response holds the complete text, tool calls and usage.
+	GenerateStream(ctx context.Context, messages []Message, opts Options, onText func(delta string)) (*Response, error)
+}
+
+// Stream generates with l, passing the text to onText as it is written if l is a Streamer. Otherwise
+// onText gets the whole text at once when it is done.
+func Stream(ctx context.Context, l LLM, messages []Message, opts Options, onText func(delta string)) (*Response, error) {
+	if s, ok := l.(Streamer); ok {
+		return s.GenerateStream(ctx, messages, opts, onText)
+	}
+	resp, err := l.Generate(ctx, messages, opts)
+	if err == nil && resp.Text != "" {
+		onText(resp.Text)
+	}
+	return resp, err
 }
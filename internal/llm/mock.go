@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// mockModel is the model name the mock client reports.
+const mockModel = "mock"
+
+// MockClient implements the LLM interface without calling any model, so the CLI and the Slack bot can be
+// run end to end without API keys. It replies with a canned summary describing the request, the same one
+// for the same request; requests with a schema get JSON following it.
+type MockClient struct{}
+
+// NewMockClient creates a mock client.
+func NewMockClient() *MockClient {
+	return &MockClient{}
+}
+
+// Generate replies to messages without a model.
+func (c *MockClient) Generate(ctx context.Context, messages []Message, opts Options) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var input strings.Builder
+	for _, m := range messages {
+		input.WriteString(m.Text())
+	}
+	text := mockSummary(messages, input.Len())
+	if opts.Schema != nil {
+		var schema map[string]any
+		if err := json.Unmarshal(opts.Schema.Definition, &schema); err != nil {
+			return nil, fmt.Errorf("invalid schema %s: %w", opts.Schema.Name, err)
+		}
+		data, err := json.Marshal(mockValue("value", schema))
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	prompt, completion := CountTokens(mockModel, input.String()), CountTokens(mockModel, text)
+	return &Response{
+		Text:  text,
+		Model: mockModel,
+		Usage: Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+	}, nil
+}
+
+// mockSummary describes the request of messages, with chars characters of text, as a summary.
+func mockSummary(messages []Message, chars int) string {
+	var last string
+	for _, m := range messages {
+		if m.Role == RoleUser {
+			last = m.Text()
+		}
+	}
+	excerpt := []rune(strings.Join(strings.Fields(last), " "))
+	if len(excerpt) > 80 {
+		excerpt = append(excerpt[:80], '…')
+	}
+	return fmt.Sprintf("*Mock summary*\n• The request had %d message(s) and %d characters of text.\n• The last message starts with: %q\n• No model was called; set LLM_PROVIDER to a real provider for actual summaries.",
+		len(messages), chars, string(excerpt))
+}
+
+// mockValue returns a value of the JSON schema, named name in its parent: the first of an enum, one item
+// of arrays, and text naming the property for strings.
+func mockValue(name string, schema map[string]any) any {
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	switch schema["type"] {
+	case "object":
+		properties, _ := schema["properties"].(map[string]any)
+		object := make(map[string]any, len(properties))
+		for key, p := range properties {
+			property, _ := p.(map[string]any)
+			object[key] = mockValue(key, property)
+		}
+		return object
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		return []any{mockValue(name, items)}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	default:
+		return "Mock " + strings.ReplaceAll(name, "_", " ")
+	}
+}
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMockClient_Generate(t *testing.T) {
+	c, err := New(ProviderMock)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	messages := BuildMessages(ModeSummary, "Release notes of version 2", "")
+
+	resp, err := c.Generate(context.Background(), messages, Options{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !strings.HasPrefix(resp.Text, "*Mock summary*") || resp.Model != "mock" || resp.Usage.TotalTokens == 0 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	again, _ := c.Generate(context.Background(), messages, Options{})
+	if again.Text != resp.Text {
+		t.Errorf("Expected the same reply to the same request, got %q and %q", resp.Text, again.Text)
+	}
+
+	schema := &Schema{Name: "summary", Definition: json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"title": {"type": "string"},
+			"tldr": {"type": "array", "items": {"type": "string"}},
+			"kind": {"type": "string", "enum": ["news", "docs"]},
+			"score": {"type": "integer"}
+		}
+	}`)}
+	resp, err = c.Generate(context.Background(), messages, Options{Schema: schema})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	var got struct {
+		Title string   `json:"title"`
+		TLDR  []string `json:"tldr"`
+		Kind  string   `json:"kind"`
+		Score int      `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(resp.Text), &got); err != nil {
+		t.Fatalf("Expected JSON of the schema, got %q: %v", resp.Text, err)
+	}
+	if got.Title == "" || len(got.TLDR) != 1 || got.Kind != "news" {
+		t.Errorf("Unexpected reply %+v", got)
+	}
+}
@@ -0,0 +1,353 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+const (
+	ollamaDefaultHost = "http://localhost:11434"
+	// Ollama defaults to a 2048-token context and silently drops the start of longer prompts
+	ollamaDefaultNumCtx = 8192
+	// About 3 characters per token leaves room for the answer within the default context
+	ollamaDefaultMaxInputChars = 16000
+)
+
+// trimmedMarker is appended to content cut to fit the context window.
+const trimmedMarker = "\n\n[... content truncated to fit the model's context window]"
+
+// OllamaClient implements the LLM interface using an Ollama server, so content never leaves the premises.
+type OllamaClient struct {
+	host          string
+	model         string
+	numCtx        int // Context window requested from the server
+	maxInputChars int // Characters of prompt kept; longer content is trimmed
+	http          *http.Client
+}
+
+// NewOllamaClient creates a new Ollama client.
+// OLLAMA_HOST is the server (default http://localhost:11434) and OLLAMA_MODEL overrides the default model.
+// OLLAMA_NUM_CTX sets the context window in tokens and OLLAMA_MAX_INPUT_CHARS how much prompt is kept.
+func NewOllamaClient() (*OllamaClient, error) {
+	host := os.Getenv("OLLAMA_HOST")
+	if host == "" {
+		host = ollamaDefaultHost
+	}
+	if !strings.Contains(host, "://") {
+		// Ollama's own CLI accepts bare host:port
+		host = "http://" + host
+	}
+
+	model := "llama3.1"
+	if os.Getenv("OLLAMA_MODEL") != "" {
+		model = os.Getenv("OLLAMA_MODEL")
+	}
+
+	c := &OllamaClient{
+		host:          strings.TrimRight(host, "/"),
+		model:         model,
+		numCtx:        ollamaDefaultNumCtx,
+		maxInputChars: ollamaDefaultMaxInputChars,
+		http:          http.DefaultClient,
+	}
+	for _, v := range []struct {
+		name string
+		dst  *int
+	}{{"OLLAMA_NUM_CTX", &c.numCtx}, {"OLLAMA_MAX_INPUT_CHARS", &c.maxInputChars}} {
+		if s := os.Getenv(v.name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%s must be a positive integer, got %q", v.name, s)
+			}
+			*v.dst = n
+		}
+	}
+	return c, nil
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Format   json.RawMessage `json:"format,omitempty"` // JSON schema of a structured reply
+	Stream   bool            `json:"stream"`
+	Options  struct {
+		NumCtx          int      `json:"num_ctx"`
+		NumPredict      int      `json:"num_predict,omitempty"`
+		Temperature     *float64 `json:"temperature,omitempty"`
+		TopP            *float64 `json:"top_p,omitempty"`
+		PresencePenalty *float64 `json:"presence_penalty,omitempty"`
+	} `json:"options"`
+}
+
+type ollamaResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Done            bool          `json:"done"`            // Last line of a streamed response
+	Error           string        `json:"error,omitempty"` // Failure in the middle of a stream
+}
+
+// Ping checks that the server answers and has the configured model.
+func (c *OllamaClient) Ping(ctx context.Context) error {
+	return c.do(ctx, "/api/show", map[string]string{"model": c.model}, nil)
+}
+
+// Model returns the model used when Options.Model is empty.
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+// Generate sends the conversation to the Ollama chat API, trimming it to the context window first.
+func (c *OllamaClient) Generate(ctx context.Context, messages []Message, opts Options) (*Response, error) {
+	var resp ollamaResponse
+	if err := c.do(ctx, "/api/chat", c.request(messages, opts), &resp); err != nil {
+		return nil, err
+	}
+	return fromOllamaResponse(resp)
+}
+
+// GenerateStream works like Generate, passing the text to onText as the server streams it.
+func (c *OllamaClient) GenerateStream(ctx context.Context, messages []Message, opts Options, onText func(delta string)) (*Response, error) {
+	req := c.request(messages, opts)
+	req.Stream = true
+	httpResp, err := c.send(ctx, "/api/chat", req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	// Every line is a response with the next piece of the message; the last one has the counts
+	var resp ollamaResponse
+	var content strings.Builder
+	decoder := json.NewDecoder(httpResp.Body)
+	for {
+		var chunk ollamaResponse
+		if err := decoder.Decode(&chunk); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("ollama: decoding stream: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("ollama request failed: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onText(chunk.Message.Content)
+		}
+		resp.Model = chunk.Model
+		resp.Message.ToolCalls = append(resp.Message.ToolCalls, chunk.Message.ToolCalls...)
+		if chunk.Done {
+			resp.PromptEvalCount, resp.EvalCount = chunk.PromptEvalCount, chunk.EvalCount
+		}
+	}
+	resp.Message.Content = content.String()
+	return fromOllamaResponse(resp)
+}
+
+// request builds the chat request for a conversation, trimmed to the context window.
+func (c *OllamaClient) request(messages []Message, opts Options) ollamaRequest {
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	req := ollamaRequest{Model: model, Messages: toOllamaMessages(fitContext(model, c.numCtx, trimMessages(messages, c.maxInputChars)))}
+	req.Options.NumCtx = c.numCtx
+	req.Options.NumPredict = opts.MaxTokens
+	req.Options.Temperature, req.Options.TopP, req.Options.PresencePenalty = opts.Temperature, opts.TopP, opts.PresencePenalty
+	if opts.Schema != nil {
+		req.Format = opts.Schema.Definition
+	}
+	for _, t := range opts.Tools {
+		tool := ollamaTool{Type: "function"}
+		tool.Function.Name = t.Name
+		tool.Function.Description = t.Description
+		tool.Function.Parameters = t.Parameters
+		req.Tools = append(req.Tools, tool)
+	}
+	return req
+}
+
+// fromOllamaResponse converts a chat response.
+func fromOllamaResponse(resp ollamaResponse) (*Response, error) {
+	var toolCalls []ToolCall
+	for i, tc := range resp.Message.ToolCalls {
+		// Ollama does not identify tool calls, so they are numbered for the results to refer to
+		toolCalls = append(toolCalls, ToolCall{ID: fmt.Sprintf("call_%d", i+1), Name: tc.Function.Name, Arguments: string(tc.Function.Arguments)})
+	}
+	text := strings.TrimSpace(resp.Message.Content)
+	if text == "" && len(toolCalls) == 0 {
+		return nil, errors.New("ollama returned an empty response")
+	}
+
+	return &Response{
+		Text:      text,
+		ToolCalls: toolCalls,
+		Model:     resp.Model,
+		Usage: Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+	}, nil
+}
+
+// do posts in to the server and decodes the JSON response into out, if set.
+func (c *OllamaClient) do(ctx context.Context, path string, in, out any) error {
+	resp, err := c.send(ctx, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("ollama: reading response: %w", err)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("ollama: decoding response: %w", err)
+	}
+	return nil
+}
+
+// send posts in to the server, retrying transient failures, and returns the response if it succeeded.
+// The caller closes its body.
+func (c *OllamaClient) send(ctx context.Context, path string, in any) (*http.Response, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: encoding request: %w", err)
+	}
+
+	var resp *http.Response
+	err = retry.Do(ctx, retry.Current(), "Ollama request", func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host+path, bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("ollama: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err = c.http.Do(req)
+		if err != nil {
+			return fmt.Errorf("ollama request failed: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return retry.FromResponse(resp, fmt.Errorf("ollama request failed: %s: %s", resp.Status, apiErr.Error))
+		}
+		return retry.FromResponse(resp, fmt.Errorf("ollama request failed: %s", resp.Status))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// toOllamaMessages converts messages to the Ollama wire format.
+func toOllamaMessages(messages []Message) []ollamaMessage {
+	out := make([]ollamaMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := ollamaMessage{Role: string(m.Role), Content: m.Text()}
+		for _, p := range m.Parts {
+			if p.Type == PartImage {
+				msg.Images = append(msg.Images, base64.StdEncoding.EncodeToString(p.Data))
+			}
+		}
+		for _, tc := range m.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = json.RawMessage(tc.Arguments)
+			if !json.Valid(call.Function.Arguments) {
+				call.Function.Arguments = json.RawMessage("{}")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// trimMessages shortens the longest text parts of messages, typically the page content, until their
+// text fits in maxChars characters. Cut content ends with a note telling the model it is incomplete.
+// The messages are not modified; zero maxChars keeps them as they are.
+func trimMessages(messages []Message, maxChars int) []Message {
+	total := 0
+	for _, m := range messages {
+		for _, p := range m.Parts {
+			total += len([]rune(p.Text))
+		}
+	}
+	if maxChars <= 0 || total <= maxChars {
+		return messages
+	}
+
+	trimmed := make([]Message, len(messages))
+	for i, m := range messages {
+		m.Parts = append([]Part(nil), m.Parts...)
+		trimmed[i] = m
+	}
+	marker := len([]rune(trimmedMarker))
+	for total > maxChars {
+		// Cut the longest part, which leaves instructions and questions alone as long as possible
+		longest, part, length := -1, -1, 0
+		for i, m := range trimmed {
+			for j, p := range m.Parts {
+				if n := len([]rune(p.Text)); p.Type == PartText && n > length && !strings.HasSuffix(p.Text, trimmedMarker) {
+					longest, part, length = i, j, n
+				}
+			}
+		}
+		if longest < 0 || length <= marker {
+			break
+		}
+		keep := max(length-(total-maxChars)-marker, 0)
+		p := &trimmed[longest].Parts[part]
+		p.Text = string([]rune(p.Text)[:keep]) + trimmedMarker
+		total -= length - len([]rune(p.Text))
+	}
+	return trimmed
+}
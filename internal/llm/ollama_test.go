@@ -0,0 +1,124 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaClient_Generate(t *testing.T) {
+	var got ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"model": "llama-test", "message": {"role": "assistant", "content": " Let me read it. ",
+			"tool_calls": [{"function": {"name": "fetch", "arguments": {"url": "https://example.com/b"}}}]},
+			"done": true, "prompt_eval_count": 100, "eval_count": 20}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	t.Setenv("OLLAMA_MODEL", "llama-default")
+	t.Setenv("OLLAMA_NUM_CTX", "4096")
+	t.Setenv("OLLAMA_MAX_INPUT_CHARS", "")
+	c, err := NewOllamaClient()
+	if err != nil {
+		t.Fatalf("NewOllamaClient failed: %v", err)
+	}
+
+	messages := append(BuildMessages(ModeThread, "Context", "What changed?"),
+		Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "fetch", Arguments: `{"url":"https://example.com/a"}`}}},
+		NewToolResultMessage("call_1", "Page A"),
+	)
+	resp, err := c.Generate(context.Background(), messages, Options{Tools: []Tool{{Name: "fetch", Parameters: json.RawMessage(`{"type":"object"}`)}}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if got.Model != "llama-default" || got.Stream || got.Options.NumCtx != 4096 || len(got.Tools) != 1 || got.Tools[0].Function.Name != "fetch" {
+		t.Errorf("Unexpected request %+v", got)
+	}
+	if n := len(got.Messages); n != 4 || got.Messages[2].ToolCalls[0].Function.Name != "fetch" || got.Messages[3].Role != "tool" || got.Messages[3].Content != "Page A" {
+		t.Errorf("Unexpected messages %+v", got.Messages)
+	}
+	if resp.Text != "Let me read it." || resp.Model != "llama-test" || resp.Usage.TotalTokens != 120 {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID == "" || resp.ToolCalls[0].Arguments != `{"url": "https://example.com/b"}` {
+		t.Errorf("Unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
+
+func TestOllamaClient_GenerateStream(t *testing.T) {
+	var got ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"model": "llama-test", "message": {"role": "assistant", "content": "Hello"}, "done": false}
+{"model": "llama-test", "message": {"role": "assistant", "content": " world"}, "done": false}
+{"model": "llama-test", "message": {"role": "assistant", "content": ""}, "done": true, "prompt_eval_count": 100, "eval_count": 20}
+`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_HOST", server.URL)
+	c, err := NewOllamaClient()
+	if err != nil {
+		t.Fatalf("NewOllamaClient failed: %v", err)
+	}
+
+	var streamed string
+	resp, err := Stream(context.Background(), c, BuildMessages(ModeSummary, "Content", ""), Options{}, func(delta string) {
+		streamed += delta
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if !got.Stream {
+		t.Error("Expected a streaming request")
+	}
+	if streamed != "Hello world" || resp.Text != "Hello world" || resp.Usage.TotalTokens != 120 {
+		t.Errorf("Unexpected result %q, %+v", streamed, resp)
+	}
+}
+
+func TestTrimMessages(t *testing.T) {
+	content := strings.Repeat("あ", 1000)
+	messages := []Message{
+		NewTextMessage(RoleSystem, "Summarize the page."),
+		NewTextMessage(RoleUser, content),
+	}
+
+	trimmed := trimMessages(messages, 500)
+	total := 0
+	for _, m := range trimmed {
+		total += len([]rune(m.Text()))
+	}
+	if total != 500 {
+		t.Errorf("Expected the messages trimmed to 500 characters, got %d", total)
+	}
+	if trimmed[0].Text() != "Summarize the page." || !strings.HasSuffix(trimmed[1].Text(), trimmedMarker) {
+		t.Errorf("Expected only the content to be cut, got %+v", trimmed)
+	}
+	if messages[1].Text() != content {
+		t.Error("Expected the original messages to be left alone")
+	}
+	if kept := trimMessages(messages, 2000); kept[1].Text() != content {
+		t.Error("Expected messages within the limit to be kept")
+	}
+}
+
+func TestNewLocal(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "")
+	if l, err := NewLocal(); l != nil || err != nil {
+		t.Errorf("Expected no local model without OLLAMA_HOST, got %v, %v", l, err)
+	}
+	t.Setenv("OLLAMA_HOST", "localhost:11434")
+	if l, err := NewLocal(); err != nil || l == nil {
+		t.Errorf("Expected an Ollama client, got %v, %v", l, err)
+	}
+}
@@ -2,113 +2,394 @@ package llm
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
 	"os"
 	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
 )
 
 // OpenAIClient implements the LLM interface using the OpenAI API.
 type OpenAIClient struct {
-	client *openai.Client
+	client        *openai.Client
+	model         string
+	contextWindow int // Overrides the known context window of the model; 0 uses it
 }
 
 // NewOpenAIClient creates a new OpenAI client.
-// It requires the OPENAI_API_KEY environment variable to be set.
+// It requires the OPENAI_API_KEY environment variable to be set, unless OPENAI_BASE_URL points it at
+// another server that speaks the OpenAI API (LiteLLM, OpenRouter, vLLM, a corporate gateway, ...).
+// OPENAI_EXTRA_HEADERS adds headers to every request, as comma separated "Name=value" pairs.
+// OPENAI_MODEL overrides the default model and LLM_CONTEXT_WINDOW its context window, which prompts are
+// trimmed to.
 func NewOpenAIClient() (*OpenAIClient, error) {
+	client, err := newOpenAIAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	model := "chatgpt-4o-latest"
+	if os.Getenv("OPENAI_MODEL") != "" {
+		model = os.Getenv("OPENAI_MODEL")
+	}
+
+	window, err := envContextWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenAIClient{client: client, model: model, contextWindow: window}, nil
+}
+
+// newOpenAIAPIClient creates a client of the OpenAI API configured by OPENAI_API_KEY, OPENAI_BASE_URL and
+// OPENAI_EXTRA_HEADERS.
+func newOpenAIAPIClient() (*openai.Client, error) {
 	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if apiKey == "" && baseURL == "" {
 		return nil, errors.New("OPENAI_API_KEY environment variable not set")
 	}
-	client := openai.NewClient(apiKey)
-	return &OpenAIClient{client: client}, nil
+	config := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		config.BaseURL = strings.TrimRight(baseURL, "/")
+	}
+	headers, err := parseHeaders(os.Getenv("OPENAI_EXTRA_HEADERS"))
+	if err != nil {
+		return nil, fmt.Errorf("OPENAI_EXTRA_HEADERS: %w", err)
+	}
+	if len(headers) > 0 {
+		config.HTTPClient = &http.Client{Transport: &headerTransport{headers: headers, base: http.DefaultTransport}}
+	}
+	return openai.NewClientWithConfig(config), nil
 }
 
-// ProcessContent uses the OpenAI API to process the given content.
-// If userPrompt is provided, it attempts to answer the prompt based on the content first.
-func (c *OpenAIClient) ProcessContent(ctx context.Context, content string, userPrompt string) (string, error) {
-	return c.ProcessContentWithMode(ctx, content, userPrompt, "summary")
+// parseHeaders parses comma separated "Name=value" pairs.
+func parseHeaders(s string) (http.Header, error) {
+	headers := make(http.Header)
+	for _, entry := range strings.Split(s, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q must be Name=value", strings.TrimSpace(entry))
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
 }
 
-// ProcessContentWithMode allows specifying the processing mode
-func (c *OpenAIClient) ProcessContentWithMode(ctx context.Context, content string, userPrompt string, mode string) (string, error) {
-	var systemPrompt string
-	var instructions string
+// headerTransport adds fixed headers to every request, e.g. the attribution headers of OpenRouter or
+// the credentials a gateway expects besides the API key.
+type headerTransport struct {
+	headers http.Header
+	base    http.RoundTripper
+}
 
-	switch mode {
-	case "thread":
-		// Simple Q&A format for thread responses
-		systemPrompt = `You are an AI assistant helping with a conversation thread. Analyze the provided context and respond naturally to the user's question. Provide clear, helpful answers based on the information available.`
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header[name] = values
+	}
+	return t.base.RoundTrip(req)
+}
 
-		if userPrompt != "" {
-			instructions = fmt.Sprintf("Based on the provided context, please answer the following question: %s\n\nIf the context doesn't contain enough information to answer the question, please state that clearly.", userPrompt)
-		} else {
-			instructions = "Please provide a helpful response based on the provided context."
-		}
+// Ping checks that the API answers and knows the configured model.
+func (c *OpenAIClient) Ping(ctx context.Context) error {
+	if _, err := c.client.GetModel(ctx, c.model); err != nil {
+		return fmt.Errorf("openai: %w", err)
+	}
+	return nil
+}
 
-	default: // "summary" mode
-		// Original format for initial mentions
-		systemPrompt = `You are an expert summarizer. Analyze the provided web page content and generate a concise summary based on the user's request.
+// Model returns the model used when Options.Model is empty.
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
 
-Output Format:
-(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+// Generate sends the conversation to the OpenAI chat completion API.
+func (c *OpenAIClient) Generate(ctx context.Context, messages []Message, opts Options) (*Response, error) {
+	req := c.chatRequest(messages, opts)
+	var resp openai.ChatCompletionResponse
+	err := retry.Do(ctx, retry.Current(), "OpenAI chat completion", func(ctx context.Context) error {
+		var err error
+		resp, err = c.client.CreateChatCompletion(ctx, req)
+		return openAIError(err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai chat completion failed: %w", err)
+	}
+	return fromOpenAIResponse(resp)
+}
 
-:white_check_mark: 3行要約
-- Bullet point 1
-- Bullet point 2
-- Bullet point 3
+// GenerateStream sends the conversation to the OpenAI chat completion API, passing the text to onText
+// as it arrives.
+func (c *OpenAIClient) GenerateStream(ctx context.Context, messages []Message, opts Options, onText func(delta string)) (*Response, error) {
+	req := c.chatRequest(messages, opts)
+	req.Stream = true
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	// Only opening the stream is retried; once text was passed on, a retry would repeat it
+	var stream *openai.ChatCompletionStream
+	err := retry.Do(ctx, retry.Current(), "OpenAI chat completion", func(ctx context.Context) error {
+		var err error
+		stream, err = c.client.CreateChatCompletionStream(ctx, req)
+		return openAIError(err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openai chat completion failed: %w", err)
+	}
+	defer stream.Close()
 
-:memo: 説明
-*Key points header 1*
-Explanation of the main points of the article
+	// The chunks are put back together into a regular response
+	resp := openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}}}}
+	var content strings.Builder
+	msg := &resp.Choices[0].Message
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("openai chat completion failed: %w", err)
+		}
+		resp.Model = chunk.Model
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			content.WriteString(delta.Content)
+			onText(delta.Content)
+		}
+		for _, tc := range delta.ToolCalls {
+			// Tool calls arrive in pieces, the first of which has the ID and name. Pieces without an index
+			// start a new call when they carry an ID, and continue the last one otherwise.
+			i := len(msg.ToolCalls) - 1
+			switch {
+			case tc.Index != nil:
+				i = *tc.Index
+			case tc.ID != "" || i < 0:
+				i = len(msg.ToolCalls)
+			}
+			for len(msg.ToolCalls) <= i {
+				msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{Type: openai.ToolTypeFunction})
+			}
+			call := &msg.ToolCalls[i]
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			call.Function.Name += tc.Function.Name
+			call.Function.Arguments += tc.Function.Arguments
+		}
+	}
+	msg.Content = content.String()
+	return fromOpenAIResponse(resp)
+}
 
-*Key points header 2*
-Explanation of the main points of the article
+// openAIError exposes the HTTP status of a failed API call to retry.
+func openAIError(err error) error {
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.HTTPStatusCode > 0:
+		return &retry.StatusError{Code: apiErr.HTTPStatusCode, Err: err}
+	case errors.As(err, &reqErr) && reqErr.HTTPStatusCode > 0:
+		return &retry.StatusError{Code: reqErr.HTTPStatusCode, Err: err}
+	}
+	return err
+}
 
-(Key points can be increased arbitrarily)
-`
+// openAIModelCapabilities lists the models that differ from the chat models in what they accept, by
+// name prefix; the first match applies. Models without a match accept everything.
+var openAIModelCapabilities = []struct {
+	prefix    string
+	system    bool // Accepts system messages
+	reasoning bool // Reasoning model: fixed sampling parameters, and max_completion_tokens instead of max_tokens
+}{
+	{"gpt-5-chat", true, false},
+	{"gpt-5", true, true},
+	{"o1", false, true},
+	{"o3", false, true},
+	{"o4", false, true},
+}
 
-		if userPrompt != "" {
-			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided content. If the content doesn't contain the answer, state 'この記事にはその情報が含まれていません。'. Then, provide the 3-line summary and the detailed explanation as described in the system prompt.", userPrompt)
-		} else {
-			instructions = "Instructions: Provide the 3-line summary and the detailed explanation as described in the system prompt."
+// openAICapabilities returns whether model accepts system messages, and whether it is a reasoning model.
+func openAICapabilities(model string) (system, reasoning bool) {
+	name := BaseModel(model)
+	for _, c := range openAIModelCapabilities {
+		if strings.HasPrefix(name, c.prefix) {
+			return c.system, c.reasoning
 		}
 	}
+	return true, false
+}
 
-	prompt := fmt.Sprintf("Content:\n```\n%s\n```\n\n%s", content, instructions)
-
-	model := "chatgpt-4o-latest"
-	if os.Getenv("OPENAI_MODEL") != "" {
-		model = os.Getenv("OPENAI_MODEL")
+// chatRequest builds the chat completion request for a conversation, trimmed to the context window.
+// Requests to models that reject system messages or sampling parameters are adapted to them.
+func (c *OpenAIClient) chatRequest(messages []Message, opts Options) openai.ChatCompletionRequest {
+	model := c.model
+	if opts.Model != "" {
+		model = opts.Model
 	}
+	system, reasoning := openAICapabilities(model)
 
-	resp, err := c.client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: systemPrompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
+	messages = fitContext(model, c.contextWindow, messages)
+	if !system {
+		messages = mergeSystemMessages(messages)
+	}
+	req := openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: toOpenAIMessages(messages),
+	}
+	if reasoning {
+		// The limit also covers the hidden reasoning tokens; sampling parameters are fixed by the API
+		req.MaxCompletionTokens = opts.MaxTokens
+		opts.Params = Params{}
+	} else {
+		req.MaxTokens = opts.MaxTokens
+	}
+	if opts.Temperature != nil {
+		// The client omits zero, which the API would read as the default of 1
+		req.Temperature = max(float32(*opts.Temperature), math.SmallestNonzeroFloat32)
+	}
+	if opts.TopP != nil {
+		req.TopP = max(float32(*opts.TopP), math.SmallestNonzeroFloat32)
+	}
+	if opts.PresencePenalty != nil {
+		req.PresencePenalty = float32(*opts.PresencePenalty)
+	}
+	if opts.Schema != nil {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   opts.Schema.Name,
+				Schema: opts.Schema.Definition,
+				Strict: true,
 			},
+		}
+	}
+	for _, t := range opts.Tools {
+		req.Tools = append(req.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	return req
+}
+
+// fromOpenAIResponse converts a chat completion response.
+func fromOpenAIResponse(resp openai.ChatCompletionResponse) (*Response, error) {
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("openai returned an empty response")
+	}
+	choice := resp.Choices[0].Message
+
+	var toolCalls []ToolCall
+	for _, tc := range choice.ToolCalls {
+		toolCalls = append(toolCalls, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	if choice.Content == "" && len(toolCalls) == 0 {
+		return nil, errors.New("openai returned an empty response")
+	}
+
+	return &Response{
+		// Trim potential leading/trailing whitespace
+		Text:      strings.TrimSpace(choice.Content),
+		ToolCalls: toolCalls,
+		Model:     resp.Model,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
 		},
-	)
+	}, nil
+}
 
-	if err != nil {
-		return "", fmt.Errorf("openai chat completion failed: %w", err)
+// mergeSystemMessages moves the text of the system messages to the start of the first user message, for
+// models that only take user and assistant messages.
+func mergeSystemMessages(messages []Message) []Message {
+	var system []string
+	out := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		if m.Role == RoleSystem {
+			system = append(system, m.Text())
+			continue
+		}
+		out = append(out, m)
+	}
+	if len(system) == 0 {
+		return messages
 	}
+	instructions := TextPart(strings.Join(system, "\n\n") + "\n\n---\n")
+	for i, m := range out {
+		if m.Role == RoleUser {
+			out[i].Parts = append([]Part{instructions}, m.Parts...)
+			return out
+		}
+	}
+	return append([]Message{{Role: RoleUser, Parts: []Part{instructions}}}, out...)
+}
 
-	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
-		return "", errors.New("openai returned an empty response")
+// toOpenAIMessages converts messages to the OpenAI wire format.
+// Text-only messages use plain content; messages with images use multi-part content.
+func toOpenAIMessages(messages []Message) []openai.ChatCompletionMessage {
+	out := make([]openai.ChatCompletionMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := openai.ChatCompletionMessage{Role: string(m.Role), ToolCallID: m.ToolCallID}
+		for _, tc := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:       tc.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: tc.Name, Arguments: tc.Arguments},
+			})
+		}
+		if !hasImage(m) {
+			msg.Content = m.Text()
+			out = append(out, msg)
+			continue
+		}
+		for _, p := range m.Parts {
+			switch p.Type {
+			case PartText:
+				msg.MultiContent = append(msg.MultiContent, openai.ChatMessagePart{
+					Type: openai.ChatMessagePartTypeText,
+					Text: p.Text,
+				})
+			case PartImage:
+				msg.MultiContent = append(msg.MultiContent, openai.ChatMessagePart{
+					Type: openai.ChatMessagePartTypeImageURL,
+					ImageURL: &openai.ChatMessageImageURL{
+						URL: "data:" + p.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(p.Data),
+					},
+				})
+			}
+		}
+		out = append(out, msg)
 	}
+	return out
+}
 
-	// Trim potential leading/trailing whitespace
-	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+// hasImage reports whether the message contains an image part.
+func hasImage(m Message) bool {
+	for _, p := range m.Parts {
+		if p.Type == PartImage {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// openAIBatchLine is one line of a batch output or error file.
+type openAIBatchLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int                           `json:"status_code"`
+		Body       openai.ChatCompletionResponse `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// SubmitBatch uploads the requests and starts a batch with the 24 hour completion window of the Batch API.
+func (c *OpenAIClient) SubmitBatch(ctx context.Context, requests []BatchRequest) (string, error) {
+	if len(requests) == 0 {
+		return "", errors.New("openai batch: no requests")
+	}
+	upload := openai.UploadBatchFileRequest{FileName: "describe-kun-batch.jsonl"}
+	for _, r := range requests {
+		upload.AddChatCompletion(r.ID, c.chatRequest(r.Messages, r.Options))
+	}
+	file, err := c.client.UploadBatchFile(ctx, upload)
+	if err != nil {
+		return "", fmt.Errorf("openai batch upload failed: %w", err)
+	}
+	batch, err := c.client.CreateBatch(ctx, openai.CreateBatchRequest{
+		InputFileID: file.ID,
+		Endpoint:    openai.BatchEndpointChatCompletions,
+	})
+	if err != nil {
+		return "", fmt.Errorf("openai batch creation failed: %w", err)
+	}
+	return batch.ID, nil
+}
+
+// BatchResults reads the output and error files of a finished batch.
+func (c *OpenAIClient) BatchResults(ctx context.Context, batchID string) (map[string]BatchResult, bool, error) {
+	batch, err := c.client.RetrieveBatch(ctx, batchID)
+	if err != nil {
+		return nil, false, fmt.Errorf("openai batch %s: %w", batchID, err)
+	}
+	switch batch.Status {
+	case "completed", "expired", "cancelled":
+	case "failed":
+		reason := "no reason given"
+		if batch.Errors != nil && len(batch.Errors.Data) > 0 {
+			reason = batch.Errors.Data[0].Message
+		}
+		return nil, true, fmt.Errorf("openai batch %s failed: %s", batchID, reason)
+	default:
+		return nil, false, nil
+	}
+
+	results := make(map[string]BatchResult)
+	for _, fileID := range []*string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == nil || *fileID == "" {
+			continue
+		}
+		if err := c.readBatchFile(ctx, *fileID, results); err != nil {
+			return nil, false, fmt.Errorf("openai batch %s: %w", batchID, err)
+		}
+	}
+	return results, true, nil
+}
+
+// readBatchFile adds the results in a batch output or error file to results.
+func (c *OpenAIClient) readBatchFile(ctx context.Context, fileID string, results map[string]BatchResult) error {
+	content, err := c.client.GetFileContent(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("reading file %s: %w", fileID, err)
+	}
+	defer content.Close()
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var line openAIBatchLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("decoding file %s: %w", fileID, err)
+		}
+		switch {
+		case line.Error != nil:
+			results[line.CustomID] = BatchResult{Err: fmt.Errorf("openai batch request failed: %s (%s)", line.Error.Message, line.Error.Code)}
+		case line.Response == nil:
+			results[line.CustomID] = BatchResult{Err: errors.New("openai batch request has no response")}
+		case line.Response.StatusCode != 200:
+			results[line.CustomID] = BatchResult{Err: fmt.Errorf("openai batch request failed with status %d", line.Response.StatusCode)}
+		default:
+			resp, err := fromOpenAIResponse(line.Response.Body)
+			results[line.CustomID] = BatchResult{Response: resp, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading file %s: %w", fileID, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestOpenAIClient_Batch(t *testing.T) {
+	var uploaded string
+	status := "in_progress"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("Expected a file upload: %v", err)
+				return
+			}
+			data, _ := io.ReadAll(f)
+			uploaded = string(data)
+			w.Write([]byte(`{"id": "file-in", "purpose": "batch"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/batches":
+			w.Write([]byte(`{"id": "batch_1", "status": "validating"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/batches/batch_1":
+			json.NewEncoder(w).Encode(map[string]any{"id": "batch_1", "status": status, "output_file_id": "file-out", "error_file_id": "file-err"})
+		case r.URL.Path == "/v1/files/file-out/content":
+			w.Write([]byte(`{"custom_id": "ep1", "response": {"status_code": 200, "body": {"model": "gpt-test", "choices": [{"message": {"role": "assistant", "content": " - Topic "}}], "usage": {"total_tokens": 12}}}}` + "\n"))
+		case r.URL.Path == "/v1/files/file-err/content":
+			w.Write([]byte(`{"custom_id": "ep2", "response": {"status_code": 429, "body": {}}}` + "\n"))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := openai.DefaultConfig("test-key")
+	config.BaseURL = server.URL + "/v1"
+	c := &OpenAIClient{client: openai.NewClientWithConfig(config), model: "gpt-default"}
+
+	id, err := c.SubmitBatch(context.Background(), []BatchRequest{
+		{ID: "ep1", Messages: BuildMessages(ModeDigest, "Notes 1", "")},
+		{ID: "ep2", Messages: BuildMessages(ModeDigest, "Notes 2", ""), Options: Options{Model: "gpt-mini"}},
+	})
+	if err != nil || id != "batch_1" {
+		t.Fatalf("SubmitBatch returned %q, %v", id, err)
+	}
+	lines := strings.Split(uploaded, "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"custom_id":"ep1"`) || !strings.Contains(lines[0], `"model":"gpt-default"`) || !strings.Contains(lines[1], `"model":"gpt-mini"`) {
+		t.Errorf("Unexpected batch input:\n%s", uploaded)
+	}
+
+	if _, done, err := c.BatchResults(context.Background(), id); done || err != nil {
+		t.Fatalf("Expected the batch to be running, got %v, %v", done, err)
+	}
+	status = "completed"
+	results, done, err := c.BatchResults(context.Background(), id)
+	if !done || err != nil {
+		t.Fatalf("Expected the finished batch, got %v, %v", done, err)
+	}
+	if r := results["ep1"]; r.Err != nil || r.Response.Text != "- Topic" || r.Response.Usage.TotalTokens != 12 {
+		t.Errorf("Unexpected result %+v", r)
+	}
+	if r := results["ep2"]; r.Err == nil || !strings.Contains(r.Err.Error(), "429") {
+		t.Errorf("Expected the failed request to be reported, got %+v", r)
+	}
+}
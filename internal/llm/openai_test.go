@@ -2,11 +2,17 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 )
 
 func TestNewOpenAIClient_MissingAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_BASE_URL", "")
 	// Unset the API key temporarily
 	originalKey, keyExists := os.LookupEnv("OPENAI_API_KEY")
 	if keyExists {
@@ -20,10 +26,215 @@ func TestNewOpenAIClient_MissingAPIKey(t *testing.T) {
 	}
 }
 
-// TestProcessContent requires a valid OPENAI_API_KEY to be set in the environment.
+func TestOpenAIClient_BaseURL(t *testing.T) {
+	var path string
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, header = r.URL.Path, r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model": "gateway-model", "choices": [{"message": {"role": "assistant", "content": "Summary"}}],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", server.URL+"/v1/")
+	t.Setenv("OPENAI_EXTRA_HEADERS", "X-Title=describe-kun, HTTP-Referer=https://example.com/")
+	c, err := NewOpenAIClient()
+	if err != nil {
+		t.Fatalf("NewOpenAIClient failed: %v", err)
+	}
+	resp, err := c.Generate(context.Background(), BuildMessages(ModeSummary, "Content", ""), Options{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if path != "/v1/chat/completions" {
+		t.Errorf("Unexpected path %s", path)
+	}
+	if header.Get("X-Title") != "describe-kun" || header.Get("HTTP-Referer") != "https://example.com/" {
+		t.Errorf("Expected the extra headers, got %v", header)
+	}
+	if resp.Text != "Summary" || resp.Model != "gateway-model" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if strings.Contains(string(body), "response_format") {
+		t.Errorf("Expected no response format without a schema, got %s", body)
+	}
+
+	schema := &Schema{Name: "summary", Definition: json.RawMessage(`{"type":"object"}`)}
+	if _, err := c.Generate(context.Background(), BuildMessages(ModeStructured, "Content", ""), Options{Schema: schema}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !strings.Contains(string(body), `"response_format":{"type":"json_schema","json_schema":{"name":"summary","schema":{"type":"object"},"strict":true}}`) {
+		t.Errorf("Expected the schema as the response format, got %s", body)
+	}
+
+	zero, penalty := 0.0, 0.5
+	if _, err := c.Generate(context.Background(), BuildMessages(ModeSummary, "Content", ""), Options{Params: Params{Temperature: &zero, PresencePenalty: &penalty, MaxTokens: 300}}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	for _, param := range []string{`"temperature":1e-45`, `"presence_penalty":0.5`, `"max_tokens":300`} {
+		if !strings.Contains(string(body), param) {
+			t.Errorf("Expected %s in the request, got %s", param, body)
+		}
+	}
+	if strings.Contains(string(body), "top_p") {
+		t.Errorf("Expected the default top_p, got %s", body)
+	}
+
+	t.Setenv("OPENAI_EXTRA_HEADERS", "X-Title")
+	if _, err := NewOpenAIClient(); err == nil {
+		t.Error("Expected an error for a header without a value")
+	}
+}
+
+func TestOpenAIClient_GenerateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"model": "gpt-test", "choices": [{"index": 0, "delta": {"role": "assistant", "content": "Hello"}}]}
+
+data: {"model": "gpt-test", "choices": [{"index": 0, "delta": {"content": " world"}}]}
+
+data: {"model": "gpt-test", "choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "id": "call_1", "type": "function", "function": {"name": "fetch", "arguments": "{\"url\":"}}]}}]}
+
+data: {"model": "gpt-test", "choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "function": {"arguments": "\"https://example.com\"}"}}]}}]}
+
+data: {"model": "gpt-test", "choices": [], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+	t.Setenv("OPENAI_EXTRA_HEADERS", "")
+	c, err := NewOpenAIClient()
+	if err != nil {
+		t.Fatalf("NewOpenAIClient failed: %v", err)
+	}
+	var streamed string
+	resp, err := c.GenerateStream(context.Background(), BuildMessages(ModeSummary, "Content", ""), Options{}, func(delta string) {
+		streamed += delta
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream failed: %v", err)
+	}
+
+	if streamed != "Hello world" || resp.Text != "Hello world" || resp.Model != "gpt-test" || resp.Usage.TotalTokens != 15 {
+		t.Errorf("Unexpected result %q, %+v", streamed, resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "call_1" || resp.ToolCalls[0].Arguments != `{"url":"https://example.com"}` {
+		t.Errorf("Unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
+
+func TestOpenAIClient_GenerateStreamWithoutIndex(t *testing.T) {
+	// Some OpenAI-compatible servers leave out the index of tool call deltas
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"model": "gpt-test", "choices": [{"index": 0, "delta": {"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "fetch", "arguments": "{\"url\":"}}]}}]}
+
+data: {"model": "gpt-test", "choices": [{"index": 0, "delta": {"tool_calls": [{"function": {"arguments": "\"https://example.com/a\"}"}}]}}]}
+
+data: {"model": "gpt-test", "choices": [{"index": 0, "delta": {"tool_calls": [{"id": "call_2", "type": "function", "function": {"name": "fetch", "arguments": "{\"url\":\"https://example.com/b\"}"}}]}}]}
+
+data: [DONE]
+
+`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+	t.Setenv("OPENAI_EXTRA_HEADERS", "")
+	c, err := NewOpenAIClient()
+	if err != nil {
+		t.Fatalf("NewOpenAIClient failed: %v", err)
+	}
+	resp, err := c.GenerateStream(context.Background(), BuildMessages(ModeSummary, "Content", ""), Options{}, func(string) {})
+	if err != nil {
+		t.Fatalf("GenerateStream failed: %v", err)
+	}
+
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].ID != "call_1" || resp.ToolCalls[0].Arguments != `{"url":"https://example.com/a"}` ||
+		resp.ToolCalls[1].ID != "call_2" || resp.ToolCalls[1].Arguments != `{"url":"https://example.com/b"}` {
+		t.Errorf("Unexpected tool calls %+v", resp.ToolCalls)
+	}
+}
+
+func TestOpenAITranscriber(t *testing.T) {
+	var path, model, name, audio string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		model = r.FormValue("model")
+		if f, h, err := r.FormFile("file"); err == nil {
+			data, _ := io.ReadAll(f)
+			name, audio = h.Filename, string(data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text": "Welcome to the show."}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+	t.Setenv("OPENAI_EXTRA_HEADERS", "")
+	tr, err := NewOpenAITranscriber("")
+	if err != nil {
+		t.Fatalf("NewOpenAITranscriber failed: %v", err)
+	}
+	text, err := tr.Transcribe(context.Background(), "episode.mp3", []byte("audio"))
+	if err != nil {
+		t.Fatalf("Transcribe failed: %v", err)
+	}
+	if text != "Welcome to the show." {
+		t.Errorf("Unexpected transcript %q", text)
+	}
+	if path != "/audio/transcriptions" || model != DefaultTranscriptionModel || name != "episode.mp3" || audio != "audio" {
+		t.Errorf("Unexpected request to %s with model %q and file %q (%q)", path, model, name, audio)
+	}
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	var req struct {
+		Input []string `json:"input"`
+		Model string   `json:"model"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": [{"index": 1, "embedding": [0, 1]}, {"index": 0, "embedding": [1, 0]}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+	t.Setenv("OPENAI_EXTRA_HEADERS", "")
+	e, err := NewOpenAIEmbedder("")
+	if err != nil {
+		t.Fatalf("NewOpenAIEmbedder failed: %v", err)
+	}
+	vectors, err := e.Embed(context.Background(), []string{"question", "chunk"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if req.Model != DefaultEmbeddingModel || len(req.Input) != 2 {
+		t.Errorf("Unexpected request %+v", req)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("Expected the embeddings in the order of the texts, got %v", vectors)
+	}
+}
+
+// TestGenerate_Integration requires a valid OPENAI_API_KEY to be set in the environment.
 // It also makes a real API call, which might incur costs.
 // Consider using mocks for more robust testing in a real-world scenario.
-func TestProcessContent_Integration(t *testing.T) {
+func TestGenerate_Integration(t *testing.T) {
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
 		t.Skip("Skipping integration test: OPENAI_API_KEY not set")
@@ -39,22 +250,75 @@ func TestProcessContent_Integration(t *testing.T) {
 	userPrompt := "What are the key features of Go?"
 
 	// Test with user prompt
-	summaryWithPrompt, err := client.ProcessContent(ctx, content, userPrompt)
+	respWithPrompt, err := client.Generate(ctx, BuildMessages(ModeSummary, content, userPrompt), Options{})
 	if err != nil {
-		t.Fatalf("ProcessContent with prompt failed: %v", err)
+		t.Fatalf("Generate with prompt failed: %v", err)
 	}
-	if summaryWithPrompt == "" {
+	if respWithPrompt.Text == "" {
 		t.Error("Expected a summary with prompt, but got empty string")
 	}
-	t.Logf("Summary with prompt:\n%s", summaryWithPrompt) // Log for manual inspection
+	t.Logf("Summary with prompt:\n%s", respWithPrompt.Text) // Log for manual inspection
 
 	// Test without user prompt (just summary)
-	summaryOnly, err := client.ProcessContent(ctx, content, "")
+	respOnly, err := client.Generate(ctx, BuildMessages(ModeSummary, content, ""), Options{})
 	if err != nil {
-		t.Fatalf("ProcessContent without prompt failed: %v", err)
+		t.Fatalf("Generate without prompt failed: %v", err)
 	}
-	if summaryOnly == "" {
+	if respOnly.Text == "" {
 		t.Error("Expected a summary only, but got empty string")
 	}
-	t.Logf("Summary only:\n%s", summaryOnly) // Log for manual inspection
+	t.Logf("Summary only:\n%s", respOnly.Text) // Log for manual inspection
+}
+
+func TestToOpenAIMessages(t *testing.T) {
+	messages := []Message{
+		NewTextMessage(RoleSystem, "system prompt"),
+		{Role: RoleUser, Parts: []Part{TextPart("what is this?"), ImagePart([]byte("png"), "image/png")}},
+	}
+
+	out := toOpenAIMessages(messages)
+	if len(out) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(out))
+	}
+	if out[0].Role != "system" || out[0].Content != "system prompt" || out[0].MultiContent != nil {
+		t.Errorf("Unexpected text-only message: %+v", out[0])
+	}
+	if out[1].Content != "" || len(out[1].MultiContent) != 2 {
+		t.Fatalf("Expected multi-part content for image message, got %+v", out[1])
+	}
+	if got := out[1].MultiContent[1].ImageURL.URL; got != "data:image/png;base64,cG5n" {
+		t.Errorf("Unexpected image data URL: %s", got)
+	}
+}
+
+func TestOpenAIClient_ReasoningModels(t *testing.T) {
+	c := &OpenAIClient{model: "chatgpt-4o-latest"}
+	temperature := 0.2
+	opts := Options{Params: Params{Temperature: &temperature, MaxTokens: 500}}
+	messages := BuildMessages(ModeSummary, "Content", "")
+
+	req := c.chatRequest(messages, opts)
+	if req.Messages[0].Role != "system" || req.Temperature == 0 || req.MaxTokens != 500 {
+		t.Errorf("Expected a chat model request unchanged, got %+v", req)
+	}
+
+	for _, model := range []string{"o1-mini", "o3", "openai/o4-mini"} {
+		opts.Model = model
+		req := c.chatRequest(messages, opts)
+		if len(req.Messages) != 1 || req.Messages[0].Role != "user" {
+			t.Fatalf("%s: expected the system prompt merged into the user message, got %+v", model, req.Messages)
+		}
+		if !strings.HasPrefix(req.Messages[0].Content, messages[0].Text()+"\n\n---\n") || !strings.HasSuffix(req.Messages[0].Content, messages[1].Text()) {
+			t.Errorf("%s: unexpected user message %q", model, req.Messages[0].Content)
+		}
+		if req.Temperature != 0 || req.MaxTokens != 0 || req.MaxCompletionTokens != 500 {
+			t.Errorf("%s: expected no sampling parameters and max_completion_tokens, got %+v", model, req)
+		}
+	}
+
+	// GPT-5 takes system messages but not sampling parameters
+	opts.Model = "gpt-5-mini"
+	if req := c.chatRequest(messages, opts); req.Messages[0].Role != "system" || req.Temperature != 0 || req.MaxCompletionTokens != 500 {
+		t.Errorf("Unexpected gpt-5 request %+v", req)
+	}
 }
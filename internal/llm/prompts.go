@@ -0,0 +1,783 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PromptVersion identifies the revision of the prompts in this file, recorded in the provenance of summaries.
+// Change it whenever a prompt changes what the summaries say or look like.
+const PromptVersion = "2026-10-17"
+
+// Processing modes understood by BuildMessages.
+const (
+	ModeSummary    = "summary"    // Initial mentions: 3-line summary plus explanation
+	ModeThread     = "thread"     // Follow-up Q&A inside a thread
+	ModeVideo      = "video"      // Video pages with chapters: summary plus per-chapter outline
+	ModeDigest     = "digest"     // One entry of a rollup digest: a few short bullet points
+	ModeTLDR       = "tldr"       // A single-line TL;DR for launchers and shell aliases
+	ModeChanges    = "changes"    // What changed between two snapshots of a page, given their line diff
+	ModeChunk      = "chunk"      // Notes on one part of a document too long to summarize at once
+	ModeBrief      = "brief"      // One brief synthesizing several numbered sources, with citations
+	ModeMeeting    = "meeting"    // Meeting notes from a transcript: decisions, action items and owners
+	ModeProduct    = "product"    // Product and app store pages: features, pricing, platforms and review sentiment
+	ModeLegal      = "legal"      // Terms of service and privacy policies: data collection, retention and liability clauses
+	ModeJob        = "job"        // Job postings: role, seniority, stack, location and salary, optionally matched against a profile
+	ModeFiling     = "filing"     // Earnings releases and filings: results, guidance and notable risks
+	ModeIncident   = "incident"   // Status pages and postmortems: impact, root cause, timeline and follow-up actions
+	ModeRecipe     = "recipe"     // Recipes and how-to guides: just the ingredients or materials and numbered steps
+	ModeQuickstart = "quickstart" // Getting started pages of a library's docs: installation, a minimal example and key configuration
+	ModeStructured = "structured" // The summary as JSON with a title, TL;DR, sections and the answer to the question
+	ModeCited      = "cited"      // Summary backing every claim with a verbatim quote of the content, checked by the app
+	ModeExecutive  = "executive"  // Summary style for decision makers: bottom line, implications and recommended actions
+	ModeELI5       = "eli5"       // Summary style explaining the page in plain words to someone new to the topic
+	ModeDetailed   = "detailed"   // Summary style covering every section of the page in depth
+)
+
+// NoChanges is the ModeChanges reply for diffs without meaningful changes.
+const NoChanges = "NO_CHANGES"
+
+// SourcesPrefix starts the last line of a ModeThread reply, which lists the URLs that informed the answer.
+const SourcesPrefix = "SOURCES:"
+
+// summarySystemPrompt defines the output format of summaries.
+const summarySystemPrompt = `You are an expert summarizer. Analyze the provided web page content and generate a concise summary based on the user's request.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:white_check_mark: 3行要約
+- Bullet point 1
+- Bullet point 2
+- Bullet point 3
+
+:memo: 説明
+*Key points header 1*
+Explanation of the main points of the article
+
+*Key points header 2*
+Explanation of the main points of the article
+
+(Key points can be increased arbitrarily)
+`
+
+// QuotePrefix starts the lines of ModeCited output quoting the content verbatim.
+const QuotePrefix = "> "
+
+// citedSystemPrompt defines the summary whose quotes are checked against the content before posting.
+const citedSystemPrompt = `You are an expert summarizer writing for readers who must be able to trust every statement. Analyze the provided web page content and summarize it so that every claim is backed by a verbatim quote of the content.
+
+Rules:
+- Follow each claim with a line starting with "` + QuotePrefix + `" that quotes the content exactly, character for character: no paraphrasing, translation, ellipses or added words, and no quotation marks around it. Quote in the language of the content even when you write in another.
+- Keep each quote to the one sentence or phrase that supports the claim.
+- Leave out anything you cannot support with a quote.
+
+Output Format:
+(If the user asked a question, answer it here as claims with quotes. If the content doesn't contain the answer, state that clearly without a quote. If no question was asked, omit this section.)
+
+:white_check_mark: 要点
+- Claim
+` + QuotePrefix + `Quote
+
+:memo: 詳細
+- Claim
+` + QuotePrefix + `Quote
+`
+
+// executiveSystemPrompt defines the summary style for decision makers.
+const executiveSystemPrompt = `You are a chief of staff briefing a busy executive. Analyze the provided web page content and write a briefing they can act on in one minute. Lead with the conclusion, keep only what affects decisions, and leave out technical detail and background.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:dart: 結論
+The bottom line in one or two sentences
+
+:chart_with_upwards_trend: ビジネスへの影響
+- Impact on cost, revenue, risk, customers or competitors, with the figures the page gives
+
+:white_check_mark: 推奨アクション
+- Concrete next steps worth considering, only where the page supports them
+`
+
+// eli5SystemPrompt defines the summary style for readers new to the topic.
+const eli5SystemPrompt = `You are a patient teacher. Analyze the provided web page content and explain it so that someone with no background in the topic understands it. Use short sentences and everyday words, explain any term you can't avoid, and use one familiar analogy if it helps. Stay faithful to the content; simplify, but never say anything it doesn't.
+
+Output Format:
+(If the user asked a question, answer it here in the same plain words, based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:child: ざっくり言うと
+What the page is about, in two or three plain sentences
+
+:thinking_face: なぜ大事？
+Why it matters to ordinary people, in one or two sentences
+
+:books: ことば
+- Term: a one-line plain explanation, for the few terms a newcomer needs; omit this section if there are none
+`
+
+// detailedSystemPrompt defines the summary style covering the whole page.
+const detailedSystemPrompt = `You are an expert analyst. Analyze the provided web page content and write a thorough summary for a reader who will not read the original. Cover every section of the page in order, keep the figures, names, dates, conditions and caveats, and note the author's evidence for the main claims. Don't add anything the content doesn't say.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:white_check_mark: 要約
+A paragraph summarizing the whole page
+
+:memo: 詳細
+*Section heading of the page*
+A detailed explanation of the section, with bullet points for lists of facts
+
+(One heading per section of the page, in order)
+
+:warning: 注意点・限界
+- Caveats, limitations and open questions stated or evident in the content; omit this section if there are none
+`
+
+// videoSystemPrompt extends the summary format with a chapter outline for videos.
+const videoSystemPrompt = `You are an expert summarizer. Analyze the provided video page (metadata, description, chapter list and any page text such as a transcript) and generate a concise summary based on the user's request.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:white_check_mark: 3行要約
+- Bullet point 1
+- Bullet point 2
+- Bullet point 3
+
+:clapper: チャプター
+00:00 Chapter title - One-sentence summary of the chapter
+12:30 Chapter title - One-sentence summary of the chapter
+
+(List every chapter in order, one per line, starting with its timestamp exactly as given in the chapter list)
+`
+
+// meetingSystemPrompt defines the output format of meeting notes.
+const meetingSystemPrompt = `You are an expert note taker. The content is the transcript of a meeting, with its metadata, where lines usually start with a timestamp and the speaker. Write meeting notes based *only* on the transcript.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the transcript. If the transcript doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:white_check_mark: 概要
+- Bullet point 1
+- Bullet point 2
+- Bullet point 3
+
+:scales: 決定事項
+- Decision, with who made or agreed to it when the transcript says so
+
+:pushpin: アクションアイテム
+- Task — Owner (due date)
+
+(Name an owner only if the transcript assigns the task to someone or someone volunteers; otherwise write "担当未定". Add the due date only if one was mentioned. Write "なし" under a section with nothing to list.)
+
+:question: 未解決の論点
+- Open question or topic deferred to a later meeting
+`
+
+// productSystemPrompt defines a fixed format for product pages, so that summaries of competing products line up.
+const productSystemPrompt = `You are an expert product analyst. The content is a product page, such as a Product Hunt launch or an App Store or Google Play listing. Summarize the product in exactly the format below, keeping every section in this order so that summaries of different products can be compared side by side. Use only the provided content; write "不明" for anything the page doesn't state.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:package: 概要
+One sentence on what the product is and who it is for
+
+:sparkles: 主な機能
+- Feature 1
+- Feature 2
+- Feature 3
+
+:moneybag: 価格
+Free / freemium / paid, with plan names, prices and in-app purchases as stated
+
+:iphone: 対応プラットフォーム
+e.g. iOS 17+, Android, Web, macOS, Windows, browser extension
+
+:speech_balloon: レビューの評判
+Overall rating and number of ratings if shown, then the sentiment of recent reviews or comments: what users praise and what they complain about
+`
+
+// legalSystemPrompt defines the output format of terms of service and privacy policy summaries.
+const legalSystemPrompt = `You are an expert in terms of service and privacy policies, summarizing them for people who need to know what they agree to. The content is a legal document such as terms of service, a privacy policy, a data processing agreement or a EULA. Use only the provided content; write "記載なし" for anything the document doesn't cover. Quote the exact wording of important clauses where it matters, and mark clauses that are unusual or unfavorable to users with :rotating_light:.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:scroll: 概要
+The kind of document, the company it is with and its effective or last updated date, if stated
+
+:mag: データの収集と利用
+- What data is collected, for what purposes, and whether it is shared with or sold to third parties or used to train AI models
+
+:file_cabinet: データの保存と削除
+- How long data is kept, and how users can export or delete it
+
+:warning: 責任と免責
+- Limitations of liability, disclaimers of warranties and indemnification duties
+
+:pushpin: その他の注意点
+- Other notable clauses, such as arbitration or class action waivers, automatic renewal, termination, changes to the terms without notice and governing law
+`
+
+// LegalChangesFocus steers ModeChanges toward the clauses of legal documents that matter to their users.
+const LegalChangesFocus = "changes to data collection, use and sharing, data retention and deletion, liability, warranties and indemnification, and arbitration or termination clauses. Mark changes unfavorable to users with :rotating_light:."
+
+// jobSystemPrompt defines the output format of job posting summaries.
+const jobSystemPrompt = `You are an expert recruiter. The content is a job posting. Summarize it in exactly the format below so that postings can be compared at a glance. Use only the provided content; write "記載なし" for anything the posting doesn't state, and never guess a salary.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:briefcase: 職種
+The job title, the company and the team, and one sentence on what the role does
+
+:chart_with_upwards_trend: レベル
+Seniority (e.g. junior, mid, senior, staff, lead, manager) and the required years of experience, if stated
+
+:hammer_and_wrench: 技術スタック
+- Languages, frameworks, infrastructure and tools, required ones first
+
+:round_pushpin: 勤務地・リモート
+Locations, the remote or hybrid policy and any time zone or visa requirements
+
+:moneybag: 給与
+The salary range, currency and period, plus equity or bonuses, exactly as stated
+
+:clipboard: 応募条件
+- Must-have requirements
+- Nice-to-have requirements, marked (歓迎)
+`
+
+// filingSystemPrompt defines the output format of earnings release and filing summaries.
+const filingSystemPrompt = `You are an expert equity analyst. The content is an earnings release, a securities filing (such as a 10-K, 10-Q, 8-K, 有価証券報告書 or 決算短信) or another investor relations document. Summarize it in exactly the format below so that companies and quarters can be compared side by side. Use only the provided content: copy figures exactly as stated, with their units, currencies and periods, never compute figures the document doesn't state, and write "記載なし" for anything it doesn't cover.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:office: 概要
+The company (and ticker, if stated), the kind of document and the period it covers, and one sentence on the headline of the period
+
+:bar_chart: 業績
+- 売上高: value (change from the same period of the previous year)
+- 営業利益: value (change)
+- 純利益: value (change)
+- EPS: value (change)
+- Other key metrics the document highlights, such as segment results or KPIs
+
+:crystal_ball: ガイダンス
+The outlook for the next period or the full year, and whether it was raised, lowered or kept compared with the previous guidance
+
+:warning: 注目すべきリスク
+- Risks, one-off items and uncertainties the document points out, most significant first
+
+:pushpin: その他のトピック
+- Dividends, share buybacks, acquisitions, management changes and other notable announcements
+`
+
+// incidentSystemPrompt defines the output format of incident and postmortem summaries.
+const incidentSystemPrompt = `You are an experienced site reliability engineer. The content is an incident on a status page or a postmortem write-up. Summarize it in exactly the format below for engineers who need to know whether and how they were affected. Use only the provided content; write "記載なし" for anything it doesn't state, and never guess a root cause. Give times exactly as stated, with their time zones.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:rotating_light: 影響
+The affected services, features and regions, what users experienced (errors, latency, data loss), how long it lasted and the current status (investigating, identified, monitoring, resolved)
+
+:mag: 根本原因
+The root cause and the trigger as stated, and the contributing factors
+
+:clock3: タイムライン
+- time: event, from detection to resolution
+
+:hammer_and_wrench: 再発防止策
+- Follow-up actions and fixes, and whether they are done or planned
+
+:bulb: 利用者への推奨事項
+Anything users need to do, such as retrying failed requests or rotating credentials; "なし" if nothing is stated
+`
+
+// IncidentChangesFocus steers ModeChanges toward what matters on status pages.
+const IncidentChangesFocus = "new incidents, status updates of ongoing incidents (investigating, identified, monitoring, resolved), the affected services and regions, and newly stated root causes. Ignore past incidents that did not change."
+
+// recipeSystemPrompt condenses recipes and how-to guides to what is needed to follow them.
+const recipeSystemPrompt = `You are a practical cook and DIY enthusiast. The content is a recipe or a how-to guide, often buried under life stories, anecdotes and ads. Skip all of that and output only what someone needs to follow it, in exactly the format below. Use only the provided content; keep every quantity, temperature, time and size exactly as stated, and never invent steps or amounts.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided text. If the text doesn't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:clipboard: 概要
+What is made or done, in one line, with the servings or yield, total time and difficulty if stated
+
+:shopping_trolley: 材料・道具
+- item: quantity, grouped under the headings the page uses (e.g. for the sauce)
+
+:1234: 手順
+1. One action per step, in order, with the times, temperatures and visual cues to watch for
+
+:bulb: コツ
+- Tips, substitutions and warnings from the page that change the result; omit this section if there are none
+`
+
+// quickstartSystemPrompt turns the getting started pages of a library's documentation into a quickstart snippet.
+const quickstartSystemPrompt = `You are a senior software engineer onboarding onto a library. The content is the homepage and the getting started, installation and configuration pages of its documentation, each starting with "Page:" and its URL. Condense them into a quickstart in exactly the format below. Use only the provided pages: copy commands, package names, versions and code exactly as they appear, adapting examples only to combine them, and never invent APIs or options. Write "記載なし" for a section the pages don't cover.
+
+Output Format:
+(If the user asked a question, answer it here based *only* on the provided pages. If the pages don't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:package: インストール
+The install commands in a code block, for each package manager or platform the pages give, with the prerequisites (language or runtime versions) in one line
+
+:rocket: 最小の例
+The smallest complete example that works, in a code block with its language, followed by one line on what it does and how to run it
+
+:gear: 主な設定
+- option: what it does and its default, for the few settings most users need first, including required environment variables or API keys
+
+:books: 次に読むページ
+- The URLs of the pages to read next, with what each covers
+`
+
+// structuredSystemPrompt asks for the summary as JSON, for callers that render it themselves.
+const structuredSystemPrompt = `You are an expert summarizer. Analyze the provided web page content and summarize it as a JSON object, which is rendered by a program, so reply with only the JSON object and no Markdown or emoji in its values.
+
+The JSON object has these keys:
+- "title": a short title of the page in the output language
+- "tldr": exactly 3 strings, the 3-line summary
+- "sections": an array of objects with the keys "heading" (a key point) and "body" (a paragraph explaining it); add as many as the content needs
+- "answer_to_question": the answer to the user's question based *only* on the provided text, stating clearly if the text doesn't contain it; "" if no question was asked
+`
+
+// WithJobProfile asks the model to also score the job posting in messages against profile, such as the skills
+// and wishes of a candidate or the kind of role a team hires for. An empty profile leaves messages unchanged.
+func WithJobProfile(messages []Message, profile string) []Message {
+	if profile == "" {
+		return messages
+	}
+	instruction := fmt.Sprintf("Profile:\n%s\n\nAfter the summary, add a section `:dart: マッチ度` scoring how well the posting matches the profile from 1 (poor) to 5 (excellent) as `n/5`, followed by bullet points on what matches and what doesn't. Judge only by what the posting states.", profile)
+	out := append([]Message(nil), messages...)
+	for i := len(out) - 1; i >= 0; i-- {
+		if out[i].Role == RoleUser {
+			parts := append([]Part(nil), out[i].Parts...)
+			out[i].Parts = append(parts, TextPart(instruction))
+			break
+		}
+	}
+	return out
+}
+
+// BuildMessages builds the conversation for processing content in the given mode.
+// If userPrompt is provided, the model is asked to answer it based on the content first.
+func BuildMessages(mode string, content string, userPrompt string) []Message {
+	var systemPrompt string
+	var instructions string
+
+	switch mode {
+	case ModeThread:
+		// Simple Q&A format for thread responses
+		systemPrompt = `You are an AI assistant helping with a conversation thread. Analyze the provided context and respond naturally to the user's question. Provide clear, helpful answers based on the information available.
+
+If the content of any provided or fetched URL informed your answer, end with one last line "` + SourcesPrefix + ` <url> (<section>); <url> (<section>)" listing only the URLs you actually used, each followed by the heading of the section you relied on when there is one. Omit this line if your answer did not rely on any URL content.`
+
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("Based on the provided context, please answer the following question: %s\n\nIf the context doesn't contain enough information to answer the question, please state that clearly.", userPrompt)
+		} else {
+			instructions = "Please provide a helpful response based on the provided context."
+		}
+
+	case ModeVideo:
+		systemPrompt = videoSystemPrompt
+
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided content. If the content doesn't contain the answer, state 'この動画にはその情報が含まれていません。'. Then, provide the 3-line summary and the chapter outline as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Provide the 3-line summary and the chapter outline as described in the system prompt."
+		}
+
+	case ModeDigest:
+		systemPrompt = `You are an expert summarizer writing one entry of a digest that covers many items. Be brief: output 2 or 3 short bullet points ("- ...") capturing the main topics, and nothing else.`
+		instructions = "Instructions: Summarize the content as bullet points as described in the system prompt."
+
+	case ModeTLDR:
+		systemPrompt = `You write one-line TL;DRs. Output a single line of at most 120 characters with no preface, no markdown and no line breaks.`
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("Answer in one line, based *only* on the content: %s", userPrompt)
+		} else {
+			instructions = "Write the one-line TL;DR of the content."
+		}
+
+	case ModeChanges:
+		systemPrompt = `You monitor web pages for meaningful changes. The content is a line diff between two versions of a page: lines starting with "-" were removed, lines starting with "+" were added, and other lines are unchanged context.
+
+Describe what changed in meaning as short bullet points ("- ..."), stating old and new values where there are any (e.g. "- The Pro plan price changed from $10 to $12 per month"). Ignore changes to formatting, whitespace, dates of page generation, counters, ads and other boilerplate.
+If nothing meaningful changed, reply with exactly ` + NoChanges + ` and nothing else.`
+		instructions = "Instructions: Describe the meaningful changes as described in the system prompt."
+		if userPrompt != "" {
+			instructions += fmt.Sprintf(" Focus on: %s", userPrompt)
+		}
+
+	case ModeMeeting:
+		systemPrompt = meetingSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the transcript. Then, write the meeting notes as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Write the meeting notes as described in the system prompt."
+		}
+
+	case ModeProduct:
+		systemPrompt = productSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided content. Then, summarize the product as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Summarize the product as described in the system prompt."
+		}
+
+	case ModeLegal:
+		systemPrompt = legalSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided document. Then, summarize the document as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Summarize the document as described in the system prompt."
+		}
+
+	case ModeJob:
+		systemPrompt = jobSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided posting. Then, summarize the posting as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Summarize the job posting as described in the system prompt."
+		}
+
+	case ModeFiling:
+		systemPrompt = filingSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided document. Then, summarize the document as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Summarize the document as described in the system prompt."
+		}
+
+	case ModeIncident:
+		systemPrompt = incidentSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided content. Then, summarize the incident as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Summarize the incident as described in the system prompt."
+		}
+
+	case ModeRecipe:
+		systemPrompt = recipeSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided page. Then, condense the recipe or guide as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Condense the recipe or guide as described in the system prompt."
+		}
+
+	case ModeQuickstart:
+		systemPrompt = quickstartSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided pages. Then, write the quickstart as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Write the quickstart as described in the system prompt."
+		}
+
+	case ModeCited:
+		systemPrompt = citedSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided text, quoting it. Then, summarize the content with quotes as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Summarize the content with quotes as described in the system prompt."
+		}
+
+	case ModeExecutive:
+		systemPrompt = executiveSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided text. Then, write the briefing as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Write the briefing as described in the system prompt."
+		}
+
+	case ModeELI5:
+		systemPrompt = eli5SystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question in plain words based *only* on the provided text. Then, explain the content as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Explain the content as described in the system prompt."
+		}
+
+	case ModeDetailed:
+		systemPrompt = detailedSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided text. Then, write the detailed summary as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Write the detailed summary as described in the system prompt."
+		}
+
+	case ModeStructured:
+		systemPrompt = structuredSystemPrompt
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: Summarize the content and answer the user's question as the JSON object described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Summarize the content as the JSON object described in the system prompt."
+		}
+
+	case ModeBrief:
+		systemPrompt = `You write briefs that synthesize several sources into one text. The content consists of numbered sources ("Source [1]: ..."). Organize the brief by topic rather than by source, point out where sources agree, add to or contradict each other, and cite the sources every statement is based on with their numbers, e.g. "[1]" or "[2][3]". Use only the provided sources.
+
+Output Format:
+(If the user asked a question, answer it here with citations. If the sources don't contain the answer, state that clearly. If no question was asked, omit this section.)
+
+:white_check_mark: 要点
+- Bullet point with citations
+- Bullet point with citations
+- Bullet point with citations
+
+:memo: 詳細
+*Topic header 1*
+Explanation combining what the sources say about the topic, with citations
+
+(Topics can be increased arbitrarily)
+`
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the sources. Then, write the brief as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Write the brief as described in the system prompt."
+		}
+
+	case ModeChunk:
+		systemPrompt = `You take notes on one part of a long document, which is summarized from the notes on all its parts afterwards. Output bullet points ("- ...") with the main points, facts, names and numbers of this part, and nothing else. Do not summarize what is not in this part.`
+		instructions = "Instructions: Take notes on this part as described in the system prompt."
+		if userPrompt != "" {
+			instructions += fmt.Sprintf(" Keep everything relevant to this question, which is answered from the notes later: %s", userPrompt)
+		}
+
+	default: // "summary" mode
+		// Original format for initial mentions
+		systemPrompt = summarySystemPrompt
+
+		if userPrompt != "" {
+			instructions = fmt.Sprintf("User Question: %s\n\nInstructions: First, answer the user's question based *only* on the provided content. If the content doesn't contain the answer, state 'この記事にはその情報が含まれていません。'. Then, provide the 3-line summary and the detailed explanation as described in the system prompt.", userPrompt)
+		} else {
+			instructions = "Instructions: Provide the 3-line summary and the detailed explanation as described in the system prompt."
+		}
+	}
+
+	prompt := fmt.Sprintf("Content:\n```\n%s\n```\n\n%s", content, instructions)
+
+	return []Message{
+		NewTextMessage(RoleSystem, systemPrompt),
+		NewTextMessage(RoleUser, prompt),
+	}
+}
+
+// BuildImageMessages builds the conversation for summarizing images such as screenshots of articles or slides,
+// using the same output format as the summary mode.
+func BuildImageMessages(userPrompt string, images []Part) []Message {
+	instructions := "The content is provided as the attached images (for example screenshots of articles or slides). Read all text in them carefully, then provide the 3-line summary and the detailed explanation as described in the system prompt."
+	if userPrompt != "" {
+		instructions = fmt.Sprintf("User Question: %s\n\n%s Answer the user's question first, based *only* on the images. If the images don't contain the answer, state 'この画像にはその情報が含まれていません。'.", userPrompt, instructions)
+	}
+
+	parts := append([]Part{TextPart(instructions)}, images...)
+	return []Message{
+		NewTextMessage(RoleSystem, summarySystemPrompt),
+		{Role: RoleUser, Parts: parts},
+	}
+}
+
+// languageNames maps language codes to the names used in prompts and section headers.
+var languageNames = map[string]struct{ name, header string }{
+	"ja": {"Japanese", ":flag-jp: 日本語"},
+	"en": {"English", ":flag-us: English"},
+	"zh": {"Simplified Chinese", ":flag-cn: 中文"},
+	"ko": {"Korean", ":flag-kr: 한국어"},
+	"fr": {"French", ":flag-fr: Français"},
+	"de": {"German", ":flag-de: Deutsch"},
+	"es": {"Spanish", ":flag-es: Español"},
+}
+
+// WithLanguages instructs the model to write its output in the given languages (codes such as "ja", "en").
+// With several languages, the complete output is repeated once per language under a header, in a single response.
+// The instruction is appended to the last user message; no languages leaves messages unchanged.
+func WithLanguages(messages []Message, languages []string) []Message {
+	if len(languages) == 0 {
+		return messages
+	}
+
+	var instruction string
+	if len(languages) == 1 {
+		instruction = fmt.Sprintf("Write the entire output in %s, keeping the emoji section headers as they are.", languageName(languages[0]))
+	} else {
+		var sections []string
+		for _, code := range languages {
+			sections = append(sections, fmt.Sprintf("a line `*%s*` followed by the complete output in %s", languageHeader(code), languageName(code)))
+		}
+		instruction = "Write the complete output once per language, in this order: " + strings.Join(sections, "; then ") +
+			". Each language section must contain the full content in the format described above, keeping the emoji section headers as they are. Separate the sections with a blank line."
+	}
+
+	out := append([]Message(nil), messages...)
+	for i := len(out) - 1; i >= 0; i-- {
+		if out[i].Role == RoleUser {
+			parts := append([]Part(nil), out[i].Parts...)
+			out[i].Parts = append(parts, TextPart(instruction))
+			break
+		}
+	}
+	return out
+}
+
+// outlineSystemPrompt plans a research report from numbered sources.
+const outlineSystemPrompt = `You are a research analyst planning a report that answers a question from the numbered sources provided. Plan the sections a decision maker needs, in reading order: usually a short overview first, then the main findings grouped by theme, where the sources disagree, and a conclusion answering the question. Plan only sections the sources can support.
+
+Reply with only a JSON object with these keys:
+- "title": the title of the report, in the output language
+- "sections": an array of 3 to %d objects with the keys "heading" (the section heading, in the output language) and "focus" (one sentence on what the section covers and which sources inform it)
+`
+
+// reportSectionSystemPrompt writes one section of a research report.
+const reportSectionSystemPrompt = `You are a research analyst writing one section of a report that answers a question from the numbered sources provided. Write only the body of the requested section, in Markdown paragraphs and bullet points, without its heading. Stay within the section's focus so sections don't repeat each other.
+
+Base every statement *only* on the sources and cite them after each claim with their numbers in square brackets, e.g. "... [2]" or "... [1][3]". When sources disagree, say so and cite each side. Don't make claims no source supports, and don't add a list of sources.
+`
+
+// BuildOutlineMessages returns the messages asking for the outline of a report answering question from sources,
+// as JSON with at most maxSections sections.
+func BuildOutlineMessages(sources string, question string, maxSections int) []Message {
+	question = reportQuestion(question)
+	return []Message{
+		NewTextMessage(RoleSystem, fmt.Sprintf(outlineSystemPrompt, maxSections)),
+		NewTextMessage(RoleUser, fmt.Sprintf("Question: %s\n\nSources:\n---\n%s\n---", question, sources)),
+	}
+}
+
+// BuildReportSectionMessages returns the messages asking for the body of the section heading, covering focus,
+// of the report titled title. outline lists the headings of every section, so the section fits among them.
+func BuildReportSectionMessages(sources string, question string, title string, outline []string, heading string, focus string) []Message {
+	question = reportQuestion(question)
+	return []Message{
+		NewTextMessage(RoleSystem, reportSectionSystemPrompt),
+		NewTextMessage(RoleUser, fmt.Sprintf("Question: %s\n\nSources:\n---\n%s\n---\n\nReport: %s\nSections:\n- %s\n\nWrite the section \"%s\": %s", question, sources, title, strings.Join(outline, "\n- "), heading, focus)),
+	}
+}
+
+// reportQuestion returns the question of a report, or what to report on without one.
+func reportQuestion(question string) string {
+	if question == "" {
+		return "(none; report what the sources say about their common topic)"
+	}
+	return question
+}
+
+// claimsSystemPrompt extracts the claims of an article to check against other sources.
+const claimsSystemPrompt = `You are a fact checker. Extract the key factual claims of the article provided: the statements of fact its conclusions rest on, such as figures, dates, events, attributions and causal claims. Skip opinions, predictions and background everyone agrees on. State each claim as one self-contained sentence in the output language, precise enough to be checked on its own.
+
+Reply with only a JSON object with the key "claims": an array of 1 to %d strings, the most important first.
+`
+
+// crossCheckSystemPrompt checks claims against numbered reference sources.
+const crossCheckSystemPrompt = `You are a fact checker. Check each numbered claim against the numbered reference sources provided, using *only* those sources, not your own knowledge. A claim is "agrees" when a source states the same, "contradicts" when a source states something incompatible with it (a different figure, date, cause or outcome), and "unmentioned" when no source addresses it. When sources disagree with each other, the claim "contradicts".
+
+Reply with only a JSON object with the key "checks": an array with one object per claim, in the same order, with the keys "verdict" ("agrees", "contradicts" or "unmentioned"), "sources" (the numbers of the sources the verdict rests on, [] when unmentioned) and "note" (one sentence in the output language on what the sources say, e.g. the differing figure, or "" when unmentioned).
+`
+
+// BuildClaimsMessages returns the messages asking for at most maxClaims key claims of article as JSON.
+func BuildClaimsMessages(article string, maxClaims int) []Message {
+	return []Message{
+		NewTextMessage(RoleSystem, fmt.Sprintf(claimsSystemPrompt, maxClaims)),
+		NewTextMessage(RoleUser, fmt.Sprintf("Article:\n---\n%s\n---", article)),
+	}
+}
+
+// BuildCrossCheckMessages returns the messages asking whether the numbered reference sources agree with each
+// of claims, as JSON.
+func BuildCrossCheckMessages(claims []string, sources string) []Message {
+	var b strings.Builder
+	for i, claim := range claims {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, claim)
+	}
+	return []Message{
+		NewTextMessage(RoleSystem, crossCheckSystemPrompt),
+		NewTextMessage(RoleUser, fmt.Sprintf("Claims:\n%s\nSources:\n---\n%s\n---", b.String(), sources)),
+	}
+}
+
+// followUpSystemPrompt suggests questions a reader of a summary may want to ask next.
+const followUpSystemPrompt = `You help readers dig deeper into a web page they just read a summary of. Suggest the questions a curious reader without expert knowledge would most likely ask next: about details the summary glosses over, what a term or figure means, or what it means for them. Each question must be answerable from the page, short (at most 12 words), in the output language, and different from the others.
+
+Reply with only a JSON object with the key "questions": an array of 2 to %d strings.
+`
+
+// BuildFollowUpMessages returns the messages asking for at most maxQuestions follow-up questions to the
+// summary of content, as JSON.
+func BuildFollowUpMessages(content, summary string, maxQuestions int) []Message {
+	return []Message{
+		NewTextMessage(RoleSystem, fmt.Sprintf(followUpSystemPrompt, maxQuestions)),
+		NewTextMessage(RoleUser, fmt.Sprintf("Page:\n---\n%s\n---\n\nSummary:\n---\n%s\n---", content, summary)),
+	}
+}
+
+// threadTitleSystemPrompt names a conversation so it can be found by searching later.
+const threadTitleSystemPrompt = `You name Slack threads so people can find them later by searching. Read the conversation and write a concise title of what it is about: its subject and, if it reached one, its conclusion. Use the names and key terms people would search for, at most 10 words, in the output language.
+
+Reply with only the title on one line, without quotes, labels or a trailing period.`
+
+// BuildThreadTitleMessages returns the messages asking for a title of the conversation, given as its
+// messages in order.
+func BuildThreadTitleMessages(conversation []string) []Message {
+	var b strings.Builder
+	for i, message := range conversation {
+		fmt.Fprintf(&b, "Message %d: %s\n", i+1, message)
+	}
+	return []Message{
+		NewTextMessage(RoleSystem, threadTitleSystemPrompt),
+		NewTextMessage(RoleUser, b.String()),
+	}
+}
+
+// BuildTitleTranslation returns the messages asking for title translated into each of languages,
+// one line per language in the same order.
+func BuildTitleTranslation(title string, languages []string) []Message {
+	names := make([]string, len(languages))
+	for i, code := range languages {
+		names[i] = languageName(code)
+	}
+	return []Message{
+		NewTextMessage(RoleSystem, "You translate web page titles. Reply with exactly one line per requested language, in the requested order, each containing only the translated title without quotes or labels."),
+		NewTextMessage(RoleUser, fmt.Sprintf("Title: %s\nLanguages: %s", title, strings.Join(names, ", "))),
+	}
+}
+
+// WithSystemPrefix returns messages with prefix placed before the system prompt, so operator instructions
+// (tone, disclaimers, ...) apply on top of every mode. A system message is added if there is none.
+// The input slice is not modified.
+func WithSystemPrefix(messages []Message, prefix string) []Message {
+	if prefix == "" {
+		return messages
+	}
+	out := append([]Message(nil), messages...)
+	for i := range out {
+		if out[i].Role == RoleSystem {
+			out[i].Parts = append([]Part{TextPart(prefix + "\n")}, out[i].Parts...)
+			return out
+		}
+	}
+	return append([]Message{NewTextMessage(RoleSystem, prefix)}, out...)
+}
+
+// languageName returns the English name of a language code, or the code itself if unknown.
+func languageName(code string) string {
+	if l, ok := languageNames[strings.ToLower(code)]; ok {
+		return l.name
+	}
+	return code
+}
+
+// languageHeader returns the section header for a language code.
+func languageHeader(code string) string {
+	if l, ok := languageNames[strings.ToLower(code)]; ok {
+		return l.header
+	}
+	return code
+}
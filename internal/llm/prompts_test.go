@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildMessages(t *testing.T) {
+	messages := BuildMessages(ModeSummary, "page body", "what is it?")
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Role != RoleSystem || !strings.Contains(messages[0].Text(), "3行要約") {
+		t.Errorf("Expected summary system prompt, got %q", messages[0].Text())
+	}
+	user := messages[1].Text()
+	if !strings.Contains(user, "page body") || !strings.Contains(user, "User Question: what is it?") {
+		t.Errorf("Expected content and question in user message, got %q", user)
+	}
+
+	thread := BuildMessages(ModeThread, "thread body", "")
+	if strings.Contains(thread[0].Text(), "3行要約") {
+		t.Error("Thread mode should not use the summary format")
+	}
+
+	video := BuildMessages(ModeVideo, "video body", "")
+	if !strings.Contains(video[0].Text(), ":clapper: チャプター") {
+		t.Errorf("Expected chapter outline in video system prompt, got %q", video[0].Text())
+	}
+
+	filing := BuildMessages(ModeFiling, "filing body", "")
+	if !strings.Contains(filing[0].Text(), ":crystal_ball: ガイダンス") || !strings.Contains(filing[1].Text(), "filing body") {
+		t.Errorf("Expected the filing template, got %q", filing[0].Text())
+	}
+}
+
+func TestWithLanguages(t *testing.T) {
+	messages := BuildMessages(ModeSummary, "page body", "")
+	if got := WithLanguages(messages, nil); len(got[1].Parts) != len(messages[1].Parts) {
+		t.Error("Expected no change without languages")
+	}
+
+	bilingual := WithLanguages(messages, []string{"ja", "en"})
+	text := bilingual[1].Text()
+	if !strings.Contains(text, "*:flag-jp: 日本語*` followed by the complete output in Japanese; then a line `*:flag-us: English*") {
+		t.Errorf("Expected both language sections in order, got %q", text)
+	}
+	if strings.Contains(messages[1].Text(), "Japanese") {
+		t.Error("WithLanguages must not modify the original messages")
+	}
+
+	single := WithLanguages(messages, []string{"pt"})
+	if !strings.Contains(single[1].Text(), "entire output in pt") {
+		t.Errorf("Expected unknown codes to be passed through, got %q", single[1].Text())
+	}
+}
+
+func TestWithSystemPrefix(t *testing.T) {
+	messages := BuildMessages(ModeSummary, "page body", "")
+	if got := WithSystemPrefix(messages, ""); len(got[0].Parts) != len(messages[0].Parts) {
+		t.Error("Expected no change without a prefix")
+	}
+
+	got := WithSystemPrefix(messages, "Never give legal advice.")
+	if text := got[0].Text(); !strings.HasPrefix(text, "Never give legal advice.\n") || !strings.Contains(text, "3行要約") {
+		t.Errorf("Expected the prefix before the mode prompt, got %q", text)
+	}
+	if strings.Contains(messages[0].Text(), "legal") {
+		t.Error("WithSystemPrefix must not modify the original messages")
+	}
+
+	noSystem := []Message{NewTextMessage(RoleUser, "hi")}
+	got = WithSystemPrefix(noSystem, "Be polite.")
+	if len(got) != 2 || got[0].Role != RoleSystem || got[0].Text() != "Be polite." {
+		t.Errorf("Expected a system message to be added, got %+v", got)
+	}
+}
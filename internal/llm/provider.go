@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+)
+
+// Providers accepted by New.
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderGemini    = "gemini"
+	ProviderOllama    = "ollama"
+	ProviderBedrock   = "bedrock"
+	ProviderMock      = "mock" // Canned replies for development without API keys
+)
+
+// New creates the client of the named provider from its environment variables. Empty selects OpenAI.
+func New(provider string) (LLM, error) {
+	switch provider {
+	case "", ProviderOpenAI:
+		return NewOpenAIClient()
+	case ProviderAnthropic:
+		return NewAnthropicClient()
+	case ProviderGemini:
+		return NewGeminiClient()
+	case ProviderOllama:
+		return NewOllamaClient()
+	case ProviderBedrock:
+		return NewBedrockClient()
+	case ProviderMock:
+		return NewMockClient(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", provider)
+	}
+}
+
+// NewLocal creates the on-prem model that content of local-only domains is sent to, or returns nil
+// if OLLAMA_HOST is not set.
+func NewLocal() (LLM, error) {
+	if os.Getenv("OLLAMA_HOST") == "" {
+		return nil, nil
+	}
+	return NewOllamaClient()
+}
+
+// DefaultQuickModel returns the cheap model of the provider used for quick summaries when none is configured.
+// Empty means the provider's default model, for providers without a cheaper one.
+func DefaultQuickModel(provider string) string {
+	switch provider {
+	case ProviderAnthropic:
+		return "claude-haiku-4-5"
+	case ProviderGemini:
+		return "gemini-2.5-flash"
+	case ProviderOllama, ProviderMock:
+		return ""
+	case ProviderBedrock:
+		return "global.anthropic.claude-haiku-4-5-20251001-v1:0"
+	default:
+		return "gpt-4o-mini"
+	}
+}
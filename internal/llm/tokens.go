@@ -0,0 +1,211 @@
+package llm
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+const (
+	// outputReserve is the part of the context window left for the answer.
+	outputReserve = 4096
+	// trimTailTokens is how much of the end of a cut part is kept, since instructions and the user's
+	// question follow the content in the same message.
+	trimTailTokens = 300
+	// messageOverheadTokens approximates the tokens each message costs besides its text.
+	messageOverheadTokens = 4
+	// defaultContextWindow is assumed for models not in contextWindows, e.g. behind an OpenAI-compatible gateway.
+	defaultContextWindow = 32768
+	// fallbackEncoding counts tokens of models tiktoken does not know. Other vendors' tokenizers differ,
+	// but are close enough for leaving room in the window.
+	fallbackEncoding = tiktoken.MODEL_O200K_BASE
+)
+
+// contextWindows lists the context windows of known models in tokens by name prefix. More specific
+// prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"chatgpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"gpt-5", 400000},
+	{"o1-mini", 128000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini", 1048576},
+	{"amazon.nova-micro", 128000},
+	{"amazon.nova", 300000},
+	{"llama3", 128000},
+	{"mistral-large", 128000},
+}
+
+// vendorPrefixes are stripped from model IDs by BaseModel, e.g. the inference
+// profile and vendor of "global.anthropic.claude-sonnet-4-5-20250929-v1:0" on Bedrock.
+var vendorPrefixes = []string{"global.", "us.", "eu.", "apac.", "anthropic.", "meta.", "mistral.", "models/"}
+
+func init() {
+	// The BPE files are embedded, so counting works without downloading them at runtime
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// encodings caches tokenizers by encoding name; building one parses the whole vocabulary.
+var encodings sync.Map
+
+// encodingFor returns the tokenizer of model, or nil if none can be loaded.
+func encodingFor(model string) *tiktoken.Tiktoken {
+	name, ok := tiktoken.MODEL_TO_ENCODING[model]
+	if !ok {
+		name = fallbackEncoding
+		for prefix, encoding := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+			if strings.HasPrefix(model, prefix) {
+				name = encoding
+				break
+			}
+		}
+	}
+	if enc, ok := encodings.Load(name); ok {
+		return enc.(*tiktoken.Tiktoken)
+	}
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		log.Printf("[LLM] Cannot load tokenizer %s: %v", name, err)
+		return nil
+	}
+	actual, _ := encodings.LoadOrStore(name, enc)
+	return actual.(*tiktoken.Tiktoken)
+}
+
+// CountTokens returns how many tokens text takes for model.
+func CountTokens(model, text string) int {
+	if enc := encodingFor(model); enc != nil {
+		return len(enc.EncodeOrdinary(text))
+	}
+	// One token per character overestimates, which errs on the side of fitting
+	return len([]rune(text))
+}
+
+// BaseModel returns model in lower case without gateway and vendor prefixes, e.g. "claude-sonnet-4-5-20250929-v1:0"
+// for "global.anthropic.claude-sonnet-4-5-20250929-v1:0", for looking it up in tables by name prefix.
+func BaseModel(model string) string {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 && !strings.HasPrefix(name, "models/") {
+		// OpenRouter and LiteLLM style "openai/gpt-4o"
+		name = name[i+1:]
+	}
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, p := range vendorPrefixes {
+			if strings.HasPrefix(name, p) {
+				name, trimmed = name[len(p):], true
+			}
+		}
+	}
+	return name
+}
+
+// ContextWindow returns the context window of model in tokens.
+func ContextWindow(model string) int {
+	name := BaseModel(model)
+	for _, w := range contextWindows {
+		if strings.HasPrefix(name, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// envContextWindow reads LLM_CONTEXT_WINDOW, which overrides the context window of every model of a
+// provider, e.g. for a gateway model not in contextWindows. Zero means unset.
+func envContextWindow() (int, error) {
+	s := os.Getenv("LLM_CONTEXT_WINDOW")
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("LLM_CONTEXT_WINDOW must be a positive integer, got %q", s)
+	}
+	return n, nil
+}
+
+// fitContext trims messages so they fit the context window of model, leaving room for the answer.
+// window overrides the model's known window unless zero. Like trimMessages, it cuts the longest text
+// parts, typically the page content, but keeps their last tokens. The messages are not modified.
+func fitContext(model string, window int, messages []Message) []Message {
+	if window <= 0 {
+		window = ContextWindow(model)
+	}
+	limit := window - outputReserve
+	if limit < window/2 {
+		limit = window / 2
+	}
+
+	enc := encodingFor(model)
+	if enc == nil {
+		return messages
+	}
+	tokens := make([][][]int, len(messages))
+	total := 0
+	for i, m := range messages {
+		tokens[i] = make([][]int, len(m.Parts))
+		total += messageOverheadTokens
+		for j, p := range m.Parts {
+			if p.Type == PartText {
+				tokens[i][j] = enc.EncodeOrdinary(p.Text)
+				total += len(tokens[i][j])
+			}
+		}
+	}
+	if total <= limit {
+		return messages
+	}
+
+	trimmed := make([]Message, len(messages))
+	for i, m := range messages {
+		m.Parts = append([]Part(nil), m.Parts...)
+		trimmed[i] = m
+	}
+	before := total
+	separator := trimmedMarker + "\n\n"
+	marker := len(enc.EncodeOrdinary(separator))
+	cut := make(map[[2]int]bool)
+	for total > limit {
+		// Cut the longest part, which leaves instructions and questions alone as long as possible
+		longest, part, length := -1, -1, 0
+		for i := range tokens {
+			for j, t := range tokens[i] {
+				if len(t) > length && !cut[[2]int{i, j}] {
+					longest, part, length = i, j, len(t)
+				}
+			}
+		}
+		if longest < 0 || length <= marker+trimTailTokens {
+			break
+		}
+		t := tokens[longest][part]
+		tail := min(trimTailTokens, length/4)
+		head := max(length-(total-limit)-marker-tail, 0)
+		// Token boundaries can split a character, whose remains are dropped
+		text := strings.ToValidUTF8(enc.Decode(t[:head]), "") + separator + strings.ToValidUTF8(enc.Decode(t[length-tail:]), "")
+		trimmed[longest].Parts[part].Text = text
+		cut[[2]int{longest, part}] = true
+		total -= length - (head + marker + tail)
+	}
+	log.Printf("[LLM] Trimmed the prompt from %d to about %d tokens to fit the %d-token context window of %s", before, total, window, model)
+	return trimmed
+}
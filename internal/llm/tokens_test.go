@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountTokens(t *testing.T) {
+	if n := CountTokens("gpt-4o", "hello world"); n != 2 {
+		t.Errorf("Expected 2 tokens, got %d", n)
+	}
+	// Unknown models are counted with the fallback encoding
+	if n := CountTokens("claude-sonnet-4-5", "hello world"); n != 2 {
+		t.Errorf("Expected 2 tokens, got %d", n)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4o-mini", 128000},
+		{"chatgpt-4o-latest", 128000},
+		{"gpt-4", 8192},
+		{"gpt-4.1-2025-04-14", 1047576},
+		{"openai/gpt-4o", 128000},
+		{"claude-haiku-4-5", 200000},
+		{"global.anthropic.claude-sonnet-4-5-20250929-v1:0", 200000},
+		{"models/gemini-2.5-pro", 1048576},
+		{"some-gateway-model", defaultContextWindow},
+	}
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestFitContext(t *testing.T) {
+	content := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 2000) + "Last user question: what does the fox do?"
+	messages := []Message{
+		NewTextMessage(RoleSystem, "Summarize the page."),
+		NewTextMessage(RoleUser, content),
+	}
+
+	trimmed := fitContext("gpt-4o", 8192, messages)
+	total := 0
+	for _, m := range trimmed {
+		total += CountTokens("gpt-4o", m.Text()) + messageOverheadTokens
+	}
+	if total > 8192-outputReserve {
+		t.Errorf("Expected the messages to fit %d tokens, got %d", 8192-outputReserve, total)
+	}
+	text := trimmed[1].Text()
+	if trimmed[0].Text() != "Summarize the page." || !strings.Contains(text, trimmedMarker) {
+		t.Errorf("Expected only the content to be cut, got %q", trimmed[0].Text())
+	}
+	if !strings.HasSuffix(text, "what does the fox do?") {
+		t.Error("Expected the end of the content, holding the question, to be kept")
+	}
+	if messages[1].Text() != content {
+		t.Error("Expected the original messages to be left alone")
+	}
+	if kept := fitContext("gpt-4o", 0, messages); kept[1].Text() != content {
+		t.Error("Expected messages within the model's window to be kept")
+	}
+}
+
+func TestEnvContextWindow(t *testing.T) {
+	t.Setenv("LLM_CONTEXT_WINDOW", "16000")
+	if n, err := envContextWindow(); n != 16000 || err != nil {
+		t.Errorf("Expected 16000, got %d, %v", n, err)
+	}
+	t.Setenv("LLM_CONTEXT_WINDOW", "lots")
+	if _, err := envContextWindow(); err == nil {
+		t.Error("Expected an error for a non-numeric window")
+	}
+}
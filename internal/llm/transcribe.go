@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+// DefaultTranscriptionModel is the OpenAI model that transcribes audio unless another is configured.
+const DefaultTranscriptionModel = openai.Whisper1
+
+// OpenAITranscriber converts speech to text with the OpenAI audio API, whichever provider summarizes.
+type OpenAITranscriber struct {
+	client *openai.Client
+	model  string
+}
+
+// NewOpenAITranscriber creates a transcriber using model (e.g. "whisper-1" or "gpt-4o-transcribe"), with
+// the same OPENAI_API_KEY, OPENAI_BASE_URL and OPENAI_EXTRA_HEADERS settings as NewOpenAIClient. An empty
+// model uses DefaultTranscriptionModel.
+func NewOpenAITranscriber(model string) (*OpenAITranscriber, error) {
+	client, err := newOpenAIAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	if model == "" {
+		model = DefaultTranscriptionModel
+	}
+	return &OpenAITranscriber{client: client, model: model}, nil
+}
+
+// Transcribe returns the speech in audio as text. name is the file name of audio, whose extension tells
+// the API its format (e.g. "episode.mp3").
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, name string, audio []byte) (string, error) {
+	var resp openai.AudioResponse
+	err := retry.Do(ctx, retry.Current(), "OpenAI transcription", func(ctx context.Context) error {
+		var err error
+		resp, err = t.client.CreateTranscription(ctx, openai.AudioRequest{
+			Model:    t.model,
+			FilePath: name,
+			Reader:   bytes.NewReader(audio),
+		})
+		return openAIError(err)
+	})
+	if err != nil {
+		return "", fmt.Errorf("openai transcription failed: %w", err)
+	}
+	return resp.Text, nil
+}
@@ -0,0 +1,133 @@
+// Package llmcache answers repeated LLM calls from a cache, so summarizing an unchanged page again with the
+// same prompt and model costs nothing.
+package llmcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// Entry is a cached response with the time it was generated.
+type Entry struct {
+	Time     time.Time     `json:"time"`
+	Response *llm.Response `json:"response"`
+}
+
+// Store keeps cache entries by key. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry of key, or nil if there is none.
+	Get(key string) (*Entry, error)
+	// Put stores e under key, replacing any previous entry.
+	Put(key string, e Entry) error
+	// Delete removes the entry of key if there is one.
+	Delete(key string) error
+}
+
+// Modeler is implemented by LLMs that can tell which model serves calls without Options.Model.
+type Modeler interface {
+	Model() string
+}
+
+// Cache is an LLM answering calls it has seen before from a Store instead of the wrapped LLM. Calls are
+// the same when their model, messages (so the page content and the prompt), tools, schema and sampling
+// parameters are. Responses served from the cache report no usage, as nothing was paid for them.
+type Cache struct {
+	llm   llm.LLM
+	store Store
+	ttl   time.Duration
+}
+
+// New creates a Cache around l keeping responses in store for ttl; zero keeps them until the store drops them.
+// l should be a single provider, so that its default model is part of the key.
+func New(l llm.LLM, store Store, ttl time.Duration) *Cache {
+	return &Cache{llm: l, store: store, ttl: ttl}
+}
+
+// Generate implements llm.LLM.
+func (c *Cache) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	key := c.key(messages, opts)
+	if resp := c.get(ctx, key); resp != nil {
+		return resp, nil
+	}
+	resp, err := c.llm.Generate(ctx, messages, opts)
+	if err == nil {
+		c.put(key, resp)
+	}
+	return resp, err
+}
+
+// GenerateStream implements llm.Streamer. A cached response is passed to onText at once.
+func (c *Cache) GenerateStream(ctx context.Context, messages []llm.Message, opts llm.Options, onText func(delta string)) (*llm.Response, error) {
+	key := c.key(messages, opts)
+	if resp := c.get(ctx, key); resp != nil {
+		if resp.Text != "" {
+			onText(resp.Text)
+		}
+		return resp, nil
+	}
+	resp, err := llm.Stream(ctx, c.llm, messages, opts, onText)
+	if err == nil {
+		c.put(key, resp)
+	}
+	return resp, err
+}
+
+// Ping implements llm.Pinger for LLMs that can be pinged.
+func (c *Cache) Ping(ctx context.Context) error {
+	if p, ok := c.llm.(llm.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// key identifies a call by the hash of everything that shapes its response.
+func (c *Cache) key(messages []llm.Message, opts llm.Options) string {
+	if opts.Model == "" {
+		if m, ok := c.llm.(Modeler); ok {
+			opts.Model = m.Model()
+		}
+	}
+	data, _ := json.Marshal(struct {
+		Messages []llm.Message
+		Options  llm.Options
+	}{messages, opts})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the cached response of key, or nil if there is none or it expired.
+func (c *Cache) get(ctx context.Context, key string) *llm.Response {
+	e, err := c.store.Get(key)
+	if err != nil {
+		log.Printf("[Cache] Failed to read a cached response: %v", err)
+		return nil
+	}
+	if e == nil || e.Response == nil {
+		return nil
+	}
+	if c.ttl > 0 && time.Since(e.Time) > c.ttl {
+		if err := c.store.Delete(key); err != nil {
+			log.Printf("[Cache] Failed to drop an expired response: %v", err)
+		}
+		return nil
+	}
+	reqmeta.Logf(ctx, "[Cache] Answered a call to %s from the cache of %s", e.Response.Model, e.Time.Format(time.RFC3339))
+	resp := *e.Response
+	resp.Usage = llm.Usage{}
+	resp.Cached = true
+	return &resp
+}
+
+// put caches resp under key; failures only cost a cache miss later.
+func (c *Cache) put(key string, resp *llm.Response) {
+	if err := c.store.Put(key, Entry{Time: time.Now(), Response: resp}); err != nil {
+		log.Printf("[Cache] Failed to cache a response: %v", err)
+	}
+}
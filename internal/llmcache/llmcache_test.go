@@ -0,0 +1,88 @@
+package llmcache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/llm"
+)
+
+// countingLLM answers with the number of calls it has received.
+type countingLLM struct {
+	calls int
+	model string
+}
+
+func (c *countingLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	c.calls++
+	return &llm.Response{Text: fmt.Sprintf("answer %d", c.calls), Model: c.model, Usage: llm.Usage{TotalTokens: 100}}, nil
+}
+
+func (c *countingLLM) Model() string { return c.model }
+
+func TestCache(t *testing.T) {
+	dirStore, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore failed: %v", err)
+	}
+	for name, store := range map[string]Store{"memory": NewMemoryStore(10), "dir": dirStore} {
+		t.Run(name, func(t *testing.T) {
+			inner := &countingLLM{model: "model-a"}
+			c := New(inner, store, time.Hour)
+			ctx := context.Background()
+			page := llm.BuildMessages(llm.ModeSummary, "Page content", "")
+
+			first, _ := c.Generate(ctx, page, llm.Options{})
+			again, _ := c.Generate(ctx, page, llm.Options{})
+			if inner.calls != 1 || again.Text != first.Text || !again.Cached || again.Usage.TotalTokens != 0 || first.Cached {
+				t.Errorf("Expected the second call from the cache without usage, got %d calls, %+v", inner.calls, again)
+			}
+
+			var streamed string
+			if resp, _ := c.GenerateStream(ctx, page, llm.Options{}, func(delta string) { streamed += delta }); !resp.Cached || streamed != first.Text {
+				t.Errorf("Expected the cached text streamed at once, got %q", streamed)
+			}
+
+			// A different prompt, model or page content is a different call
+			c.Generate(ctx, llm.BuildMessages(llm.ModeSummary, "Page content", "Why?"), llm.Options{})
+			c.Generate(ctx, page, llm.Options{Model: "model-b"})
+			c.Generate(ctx, llm.BuildMessages(llm.ModeSummary, "Changed content", ""), llm.Options{})
+			if inner.calls != 4 {
+				t.Errorf("Expected 4 calls, got %d", inner.calls)
+			}
+			// Naming the default model is the same call
+			if c.Generate(ctx, page, llm.Options{Model: "model-a"}); inner.calls != 4 {
+				t.Errorf("Expected the default model to share the cache, got %d calls", inner.calls)
+			}
+		})
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	inner := &countingLLM{}
+	store := NewMemoryStore(10)
+	c := New(inner, store, time.Hour)
+	messages := llm.BuildMessages(llm.ModeSummary, "Page content", "")
+	key := c.key(messages, llm.Options{})
+	store.Put(key, Entry{Time: time.Now().Add(-2 * time.Hour), Response: &llm.Response{Text: "stale"}})
+
+	if resp, _ := c.Generate(context.Background(), messages, llm.Options{}); resp.Text == "stale" || inner.calls != 1 {
+		t.Errorf("Expected an expired response to be generated again, got %+v", resp)
+	}
+}
+
+func TestMemoryStore_Evicts(t *testing.T) {
+	s := NewMemoryStore(2)
+	s.Put("a", Entry{})
+	s.Put("b", Entry{})
+	s.Get("a")
+	s.Put("c", Entry{})
+	if e, _ := s.Get("b"); e != nil {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	if e, _ := s.Get("a"); e == nil {
+		t.Error("Expected a recently used entry to be kept")
+	}
+}
@@ -0,0 +1,152 @@
+package llmcache
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Open returns the store configured by dir and size: a DirStore in dir if set, otherwise a MemoryStore of size
+// entries. It returns nil when both are unset, which disables caching.
+func Open(dir string, size int) (Store, error) {
+	switch {
+	case dir != "":
+		s, err := NewDirStore(dir)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case size > 0:
+		return NewMemoryStore(size), nil
+	}
+	return nil, nil
+}
+
+// MemoryStore keeps the most recently used entries in memory; they are lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Keys, most recently used first
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key   string
+	entry Entry
+}
+
+// NewMemoryStore creates a MemoryStore holding up to size entries.
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	s.order.MoveToFront(el)
+	e := el.Value.(*memoryEntry).entry
+	return &e, nil
+}
+
+// Put implements Store, dropping the least recently used entry when full.
+func (s *MemoryStore) Put(key string, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		el.Value.(*memoryEntry).entry = e
+		s.order.MoveToFront(el)
+		return nil
+	}
+	s.entries[key] = s.order.PushFront(&memoryEntry{key: key, entry: e})
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		s.order.Remove(el)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// DirStore keeps entries in a directory, one JSON file per key, so they survive restarts and can be
+// shared by processes on the same disk. Expired entries are deleted when read; nothing else is.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates a DirStore in dir, creating the directory if needed.
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+// Get implements Store.
+func (s *DirStore) Get(key string) (*Entry, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", s.path(key), err)
+	}
+	return &e, nil
+}
+
+// Put implements Store. The file is replaced atomically so concurrent readers never see half of it.
+func (s *DirStore) Put(key string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Delete implements Store.
+func (s *DirStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *DirStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
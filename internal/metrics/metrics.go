@@ -0,0 +1,235 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/kznrluk/describe-kun/internal/cost"
+)
+
+// Stages of a page summary.
+const (
+	StageFetch = "fetch" // Loading and extracting the page
+	StageLLM   = "llm"   // Generating the summary
+	StageTotal = "total" // The whole request, as seen by the user; SLOs are evaluated on it
+)
+
+// buckets are the upper bounds, in seconds, of the latency histograms.
+var buckets = []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+const (
+	maxDomains = 200   // Distinct domains labelled before the rest are counted as "other"
+	maxRecent  = 10000 // Observations kept for SLO evaluation
+)
+
+// histogram counts the observations of a stage for a domain.
+type histogram struct {
+	counts []int64 // Per bucket, not cumulative; the last one counts observations above every bound
+	sum    float64
+	count  int64
+	errors int64
+}
+
+type key struct {
+	stage  string
+	domain string
+}
+
+// observation is a recent request kept for SLO evaluation.
+type observation struct {
+	at       time.Time
+	stage    string
+	duration time.Duration
+	failed   bool
+}
+
+// Registry collects latency histograms per stage and domain. A nil Registry discards observations.
+type Registry struct {
+	mu         sync.Mutex
+	histograms map[key]*histogram
+	domains    map[string]bool
+	recent     []observation // Ring buffer of the latest observations
+	next       int
+	budget     *budget.Tracker
+	costs      *cost.Meter
+	now        func() time.Time
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{histograms: make(map[key]*histogram), domains: make(map[string]bool), now: time.Now}
+}
+
+// SetBudget adds the token usage and burn rate of t to the exported metrics.
+func (r *Registry) SetBudget(t *budget.Tracker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.budget = t
+}
+
+// SetCost adds the LLM token and cost counters of m to the exported metrics.
+func (r *Registry) SetCost(m *cost.Meter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.costs = m
+}
+
+// Observe records that stage took d for a page of rawURL, and whether it failed.
+func (r *Registry) Observe(stage, rawURL string, d time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := key{stage: stage, domain: r.domain(rawURL)}
+	h := r.histograms[k]
+	if h == nil {
+		h = &histogram{counts: make([]int64, len(buckets)+1)}
+		r.histograms[k] = h
+	}
+	h.counts[sort.SearchFloat64s(buckets, d.Seconds())]++
+	h.sum += d.Seconds()
+	h.count++
+	if err != nil {
+		h.errors++
+	}
+
+	o := observation{at: r.now(), stage: stage, duration: d, failed: err != nil}
+	if len(r.recent) < maxRecent {
+		r.recent = append(r.recent, o)
+	} else {
+		r.recent[r.next] = o
+		r.next = (r.next + 1) % maxRecent
+	}
+}
+
+// domain returns the label of rawURL's host, bounding the number of distinct labels. Must be called with mu held.
+func (r *Registry) domain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return "none"
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if !r.domains[host] {
+		if len(r.domains) >= maxDomains {
+			return "other"
+		}
+		r.domains[host] = true
+	}
+	return host
+}
+
+// Window summarizes the observations of a stage over a period of time.
+type Window struct {
+	Requests int
+	Errors   int
+	P95      time.Duration
+}
+
+// ErrorRate returns the fraction of failed requests, 0 without requests.
+func (w Window) ErrorRate() float64 {
+	if w.Requests == 0 {
+		return 0
+	}
+	return float64(w.Errors) / float64(w.Requests)
+}
+
+// Window returns the observations of stage over the last d, across domains.
+func (r *Registry) Window(stage string, d time.Duration) Window {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	since := r.now().Add(-d)
+	var w Window
+	var durations []time.Duration
+	for _, o := range r.recent {
+		if o.stage != stage || o.at.Before(since) {
+			continue
+		}
+		w.Requests++
+		if o.failed {
+			w.Errors++
+		}
+		durations = append(durations, o.duration)
+	}
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		w.P95 = durations[(len(durations)*95+99)/100-1]
+	}
+	return w
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	}
+}
+
+// Write writes the metrics in the Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	keys := make([]key, 0, len(r.histograms))
+	for k := range r.histograms {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].stage != keys[j].stage {
+			return keys[i].stage < keys[j].stage
+		}
+		return keys[i].domain < keys[j].domain
+	})
+
+	fmt.Fprintln(w, "# HELP describe_kun_stage_duration_seconds Time spent in each stage of a page summary, by domain.")
+	fmt.Fprintln(w, "# TYPE describe_kun_stage_duration_seconds histogram")
+	for _, k := range keys {
+		h := r.histograms[k]
+		labels := fmt.Sprintf("stage=%q,domain=%q", k.stage, k.domain)
+		var cumulative int64
+		for i, le := range buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "describe_kun_stage_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels, le, cumulative)
+		}
+		fmt.Fprintf(w, "describe_kun_stage_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "describe_kun_stage_duration_seconds_sum{%s} %g\n", labels, h.sum)
+		fmt.Fprintf(w, "describe_kun_stage_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+	fmt.Fprintln(w, "# HELP describe_kun_stage_errors_total Failed stages of page summaries, by domain.")
+	fmt.Fprintln(w, "# TYPE describe_kun_stage_errors_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "describe_kun_stage_errors_total{stage=%q,domain=%q} %d\n", k.stage, k.domain, r.histograms[k].errors)
+	}
+	tracker, costs := r.budget, r.costs
+	r.mu.Unlock()
+
+	if costs != nil {
+		costs.Write(w)
+	}
+	if tracker == nil {
+		return
+	}
+	periods := tracker.Periods()
+	fmt.Fprintln(w, "# HELP describe_kun_budget_tokens_used LLM tokens used in the current period.")
+	fmt.Fprintln(w, "# TYPE describe_kun_budget_tokens_used gauge")
+	for _, p := range periods {
+		fmt.Fprintf(w, "describe_kun_budget_tokens_used{period=%q} %d\n", p.Name, p.Used)
+	}
+	fmt.Fprintln(w, "# HELP describe_kun_budget_tokens_limit LLM token limit of the period, 0 if unlimited.")
+	fmt.Fprintln(w, "# TYPE describe_kun_budget_tokens_limit gauge")
+	for _, p := range periods {
+		fmt.Fprintf(w, "describe_kun_budget_tokens_limit{period=%q} %d\n", p.Name, p.Limit)
+	}
+	fmt.Fprintln(w, "# HELP describe_kun_budget_burn_rate Share of the limit used over share of the period passed; above 1 the budget runs out early.")
+	fmt.Fprintln(w, "# TYPE describe_kun_budget_burn_rate gauge")
+	for _, p := range periods {
+		fmt.Fprintf(w, "describe_kun_budget_burn_rate{period=%q} %g\n", p.Name, p.BurnRate())
+	}
+}
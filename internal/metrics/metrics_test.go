@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/budget"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	r.Observe(StageFetch, "https://www.example.com/a", 300*time.Millisecond, nil)
+	r.Observe(StageFetch, "https://example.com/b", 3*time.Second, errors.New("timeout"))
+	r.Observe(StageLLM, "https://example.org/", 10*time.Minute, nil)
+	tracker, err := budget.NewTracker(budget.Limits{Daily: 1000}, budget.Limits{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.Record("", 500)
+	r.SetBudget(tracker)
+
+	var b strings.Builder
+	r.Write(&b)
+	out := b.String()
+	for _, want := range []string{
+		`describe_kun_stage_duration_seconds_bucket{stage="fetch",domain="example.com",le="0.25"} 0`,
+		`describe_kun_stage_duration_seconds_bucket{stage="fetch",domain="example.com",le="0.5"} 1`,
+		`describe_kun_stage_duration_seconds_bucket{stage="fetch",domain="example.com",le="5"} 2`,
+		`describe_kun_stage_duration_seconds_count{stage="fetch",domain="example.com"} 2`,
+		`describe_kun_stage_duration_seconds_bucket{stage="llm",domain="example.org",le="300"} 0`,
+		`describe_kun_stage_duration_seconds_bucket{stage="llm",domain="example.org",le="+Inf"} 1`,
+		`describe_kun_stage_errors_total{stage="fetch",domain="example.com"} 1`,
+		`describe_kun_budget_tokens_used{period="daily"} 500`,
+		`describe_kun_budget_tokens_limit{period="daily"} 1000`,
+		`describe_kun_budget_burn_rate{period="monthly"} 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the metrics, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRegistry_Window(t *testing.T) {
+	r := NewRegistry()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now.Add(-time.Hour) }
+	r.Observe(StageTotal, "https://example.com/", time.Minute, errors.New("old"))
+	r.now = func() time.Time { return now }
+	for i := 1; i <= 20; i++ {
+		var err error
+		if i%5 == 0 {
+			err = errors.New("failed")
+		}
+		r.Observe(StageTotal, "https://example.com/", time.Duration(i)*time.Second, err)
+	}
+	r.Observe(StageFetch, "https://example.com/", time.Hour, nil)
+
+	w := r.Window(StageTotal, 5*time.Minute)
+	if w.Requests != 20 || w.Errors != 4 || w.ErrorRate() != 0.2 || w.P95 != 19*time.Second {
+		t.Errorf("Unexpected window %+v", w)
+	}
+}
+
+type recordingNotifier struct {
+	alerts []Alert
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, a Alert) error {
+	n.alerts = append(n.alerts, a)
+	return nil
+}
+
+func TestAlerter(t *testing.T) {
+	r := NewRegistry()
+	n := &recordingNotifier{}
+	a := NewAlerter(r, SLO{ErrorRate: 0.1, P95: 10 * time.Second, Window: 5 * time.Minute, MinRequests: 3}, n)
+
+	r.Observe(StageTotal, "https://example.com/", time.Second, errors.New("failed"))
+	a.Check(context.Background())
+	if len(n.alerts) != 0 {
+		t.Fatalf("Expected no alert below the minimum number of requests, got %+v", n.alerts)
+	}
+
+	r.Observe(StageTotal, "https://example.com/", time.Second, nil)
+	r.Observe(StageTotal, "https://example.com/", time.Second, nil)
+	a.Check(context.Background())
+	a.Check(context.Background())
+	if len(n.alerts) != 1 || n.alerts[0].Name != "error-rate" || !n.alerts[0].Firing {
+		t.Fatalf("Expected one error rate alert, got %+v", n.alerts)
+	}
+
+	for range 30 {
+		r.Observe(StageTotal, "https://example.com/", time.Second, nil)
+	}
+	a.Check(context.Background())
+	if len(n.alerts) != 2 || n.alerts[1].Name != "error-rate" || n.alerts[1].Firing {
+		t.Errorf("Expected the error rate alert to resolve, got %+v", n.alerts)
+	}
+}
+
+func TestPagerDuty_Notify(t *testing.T) {
+	var events []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event map[string]any
+		json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	p := PagerDuty{RoutingKey: "key", URL: server.URL}
+	if err := p.Notify(context.Background(), Alert{Name: "p95-latency", Firing: true, Summary: "slow"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := p.Notify(context.Background(), Alert{Name: "p95-latency", Summary: "fast again"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(events) != 2 || events[0]["event_action"] != "trigger" || events[1]["event_action"] != "resolve" ||
+		events[0]["dedup_key"] != events[1]["dedup_key"] || events[0]["routing_key"] != "key" {
+		t.Errorf("Unexpected events %+v", events)
+	}
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+// SLO holds the thresholds of page summaries that trigger alerts. Zero disables a threshold.
+type SLO struct {
+	ErrorRate   float64       // Fraction of failed requests (0-1)
+	P95         time.Duration // 95th percentile latency
+	Window      time.Duration // Period the thresholds are evaluated over
+	MinRequests int           // Requests needed in the window before alerting, so a single failure does not page
+}
+
+// Alert is a threshold that started or stopped being exceeded.
+type Alert struct {
+	Name    string // "error-rate" or "p95-latency"
+	Firing  bool   // False when the alert resolves
+	Summary string
+}
+
+// Notifier delivers alerts, e.g. to a chat or paging service.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// Alerter evaluates the SLO periodically and notifies when a threshold starts or stops being exceeded.
+type Alerter struct {
+	registry  *Registry
+	slo       SLO
+	notifiers []Notifier
+	firing    map[string]bool
+}
+
+// NewAlerter creates an Alerter for the requests recorded in r.
+func NewAlerter(r *Registry, slo SLO, notifiers ...Notifier) *Alerter {
+	return &Alerter{registry: r, slo: slo, notifiers: notifiers, firing: make(map[string]bool)}
+}
+
+// Run evaluates the SLO every interval until ctx is done.
+func (a *Alerter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Check(ctx)
+		}
+	}
+}
+
+// Check evaluates the SLO over the window once and sends the alerts that changed.
+func (a *Alerter) Check(ctx context.Context) {
+	w := a.registry.Window(StageTotal, a.slo.Window)
+	enough := w.Requests > 0 && w.Requests >= a.slo.MinRequests
+	window := a.slo.Window.String()
+
+	if a.slo.ErrorRate > 0 {
+		a.update(ctx, "error-rate", enough && w.ErrorRate() > a.slo.ErrorRate,
+			fmt.Sprintf("%.0f%% of %d summaries failed in the last %s (threshold %.0f%%)", w.ErrorRate()*100, w.Requests, window, a.slo.ErrorRate*100))
+	}
+	if a.slo.P95 > 0 {
+		a.update(ctx, "p95-latency", enough && w.P95 > a.slo.P95,
+			fmt.Sprintf("p95 latency of %d summaries was %s in the last %s (threshold %s)", w.Requests, w.P95.Round(time.Millisecond), window, a.slo.P95))
+	}
+}
+
+// update notifies if the alert's state changed.
+func (a *Alerter) update(ctx context.Context, name string, firing bool, summary string) {
+	if a.firing[name] == firing {
+		return
+	}
+	a.firing[name] = firing
+	alert := Alert{Name: name, Firing: firing, Summary: summary}
+	if firing {
+		log.Printf("[Metrics] SLO alert %s: %s", name, summary)
+	} else {
+		log.Printf("[Metrics] SLO alert %s resolved: %s", name, summary)
+	}
+	for _, n := range a.notifiers {
+		if err := n.Notify(ctx, alert); err != nil {
+			log.Printf("[Metrics] Failed to send SLO alert %s: %v", name, err)
+		}
+	}
+}
+
+// SlackWebhook posts alerts to a Slack incoming webhook.
+type SlackWebhook struct {
+	URL string
+}
+
+// Notify implements Notifier.
+func (s SlackWebhook) Notify(ctx context.Context, a Alert) error {
+	text := ":rotating_light: *describe-kun SLO alert* (" + a.Name + "): " + a.Summary
+	if !a.Firing {
+		text = ":white_check_mark: *describe-kun SLO alert resolved* (" + a.Name + "): " + a.Summary
+	}
+	return postJSON(ctx, s.URL, map[string]string{"text": text})
+}
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty triggers and resolves incidents through the PagerDuty Events API v2.
+type PagerDuty struct {
+	RoutingKey string
+	URL        string // Overrides the Events API endpoint, for tests
+}
+
+// Notify implements Notifier. Alerts of the same name share an incident, which resolves with them.
+func (p PagerDuty) Notify(ctx context.Context, a Alert) error {
+	event := map[string]any{
+		"routing_key":  p.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    "describe-kun-" + a.Name,
+		"payload": map[string]string{
+			"summary":  "describe-kun: " + a.Summary,
+			"source":   "describe-kun",
+			"severity": "error",
+		},
+	}
+	if !a.Firing {
+		event["event_action"] = "resolve"
+		delete(event, "payload")
+	}
+	endpoint := p.URL
+	if endpoint == "" {
+		endpoint = pagerDutyEventsURL
+	}
+	return postJSON(ctx, endpoint, event)
+}
+
+// postJSON posts v as JSON to url, failing on any non-2xx status. Transient failures are retried;
+// alert receivers deduplicate, so a repeated delivery does no harm.
+func postJSON(ctx context.Context, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return retry.Do(ctx, retry.Current(), "Alert webhook", func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return retry.FromResponse(resp, fmt.Errorf("webhook returned %s", resp.Status))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,141 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter names accepted by New.
+const (
+	StripImages      = "strip-images"      // Remove markdown images, which Slack shows as raw links
+	StripBoilerplate = "strip-boilerplate" // Remove apologies and assistant chatter around the answer
+	MaxLength        = "max-length"        // Limit the length of the response
+	RequireSections  = "require-sections"  // Check that the required section headers are present
+)
+
+// Filter checks or rewrites generated text. A violation asks for the response to be generated again;
+// the returned text is then the filter's best effort, used if the corrected response breaks the rule too.
+type Filter func(text string) (string, *Violation)
+
+// Violation is a rule a response breaks, phrased as an instruction for the model.
+type Violation struct {
+	Filter  string
+	Problem string
+}
+
+func (v *Violation) String() string {
+	return v.Filter + ": " + v.Problem
+}
+
+// Chain applies filters in order.
+type Chain []Filter
+
+// New builds the chain of the named filters, in the given order.
+func New(names []string, maxLength int, sections []string) (Chain, error) {
+	var c Chain
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case StripImages:
+			c = append(c, stripImages)
+		case StripBoilerplate:
+			c = append(c, stripBoilerplate)
+		case MaxLength:
+			if maxLength <= 0 {
+				return nil, fmt.Errorf("filter %s needs a positive maximum length", MaxLength)
+			}
+			c = append(c, maxLengthFilter(maxLength))
+		case RequireSections:
+			if len(sections) == 0 {
+				return nil, fmt.Errorf("filter %s needs at least one section", RequireSections)
+			}
+			c = append(c, requireSections(sections))
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown output filter %q", name)
+		}
+	}
+	return c, nil
+}
+
+// Apply runs text through every filter and returns the result with the rules it breaks.
+func (c Chain) Apply(text string) (string, []*Violation) {
+	var violations []*Violation
+	for _, f := range c {
+		var v *Violation
+		if text, v = f(text); v != nil {
+			violations = append(violations, v)
+		}
+	}
+	return text, violations
+}
+
+var (
+	imageRegex = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// stripImages removes markdown images.
+func stripImages(text string) (string, *Violation) {
+	text = imageRegex.ReplaceAllString(text, "")
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n")), nil
+}
+
+var (
+	// Lines that only introduce the answer, such as "Sure! Here is the summary:"
+	openerLines = regexp.MustCompile(`(?i)^(sure|certainly|of course|here is|here's)\b.*[:!]$`)
+	// Lines that only close it, such as "I hope this helps!"
+	closerLines = regexp.MustCompile(`(?i)^(i hope this helps|let me know if|feel free to)\b`)
+	// Apologies and disclaimers that carry no content, such as "I apologize for the confusion."
+	boilerplatePhrases = regexp.MustCompile(`(?i)\b(i'm sorry|i apologize)( for [^,.!]*)?[.!]\s*|\bas an ai (language )?model,?\s*`)
+)
+
+// stripBoilerplate removes assistant chatter before and after the answer, and apologies within it.
+func stripBoilerplate(text string) (string, *Violation) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for len(lines) > 1 && openerLines.MatchString(strings.TrimSpace(lines[0])) {
+		lines = lines[1:]
+	}
+	for len(lines) > 1 && closerLines.MatchString(strings.TrimSpace(lines[len(lines)-1])) {
+		lines = lines[:len(lines)-1]
+	}
+	text = boilerplatePhrases.ReplaceAllString(strings.Join(lines, "\n"), "")
+	return strings.TrimSpace(text), nil
+}
+
+// maxLengthFilter reports responses longer than max characters; its best effort cuts them at a line break.
+func maxLengthFilter(max int) Filter {
+	return func(text string) (string, *Violation) {
+		runes := []rune(text)
+		if len(runes) <= max {
+			return text, nil
+		}
+		cut := string(runes[:max-1])
+		if i := strings.LastIndex(cut, "\n"); i > len(cut)/2 {
+			cut = cut[:i]
+		}
+		return strings.TrimSpace(cut) + "…", &Violation{
+			Filter:  MaxLength,
+			Problem: fmt.Sprintf("The response is %d characters long; keep it under %d characters.", len(runes), max),
+		}
+	}
+}
+
+// requireSections reports responses missing any of the section headers.
+func requireSections(sections []string) Filter {
+	return func(text string) (string, *Violation) {
+		var missing []string
+		for _, s := range sections {
+			if !strings.Contains(text, s) {
+				missing = append(missing, s)
+			}
+		}
+		if len(missing) == 0 {
+			return text, nil
+		}
+		return text, &Violation{
+			Filter:  RequireSections,
+			Problem: fmt.Sprintf("The response is missing the required section(s) %s; follow the output format.", strings.Join(missing, ", ")),
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChain_Rewrites(t *testing.T) {
+	c, err := New([]string{StripImages, StripBoilerplate}, 0, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	text := "Sure! Here is the summary:\n\n![chart](https://example.com/chart.png)\n\n\n\nI apologize for the confusion. As an AI language model, the page has no date. Go 1.24 adds generic type aliases.\n\nI hope this helps!"
+	got, violations := c.Apply(text)
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+	want := "the page has no date. Go 1.24 adds generic type aliases."
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if got, _ := c.Apply("Here is what changed in 1.24: generic type aliases."); got != "Here is what changed in 1.24: generic type aliases." {
+		t.Errorf("Expected a one-line answer to be kept, got %q", got)
+	}
+}
+
+func TestChain_Violations(t *testing.T) {
+	c, err := New([]string{MaxLength, RequireSections}, 40, []string{":white_check_mark:", ":memo:"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	got, violations := c.Apply(":white_check_mark: first line\nsecond line that is long")
+	if len(violations) != 2 {
+		t.Fatalf("Expected 2 violations, got %v", violations)
+	}
+	if got != ":white_check_mark: first line…" {
+		t.Errorf("Expected the text cut at a line break, got %q", got)
+	}
+	if !strings.Contains(violations[0].Problem, "under 40 characters") || !strings.Contains(violations[1].Problem, ":memo:") {
+		t.Errorf("Unexpected problems %v", violations)
+	}
+
+	if _, violations := c.Apply(":white_check_mark: :memo:"); len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, names := range [][]string{{"shout"}, {MaxLength}, {RequireSections}} {
+		if _, err := New(names, 0, nil); err == nil {
+			t.Errorf("Expected an error for %v", names)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package persona
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Persona is an operator-provided system prompt prefix (tone, compliance disclaimers, ...)
+// layered on top of the mode prompts. When read from a file, edits take effect without a restart.
+type Persona struct {
+	mu      sync.Mutex
+	path    string
+	text    string
+	modTime time.Time
+	size    int64
+}
+
+// New returns a Persona with a fixed prefix.
+func New(text string) *Persona {
+	return &Persona{text: strings.TrimSpace(text)}
+}
+
+// Open returns the Persona configured by a prefix file or, if path is empty, an inline prefix.
+// It returns nil when neither is set.
+func Open(text, path string) (*Persona, error) {
+	if path != "" {
+		return Load(path)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return New(text), nil
+}
+
+// Load returns a Persona whose prefix is the content of the file at path, reloaded whenever the file changes.
+func Load(path string) (*Persona, error) {
+	p := &Persona{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading system prompt prefix: %w", err)
+	}
+	if err := p.read(info); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Prefix returns the current prefix, or "" for a nil Persona.
+// If the file can no longer be read, the last good prefix is kept.
+func (p *Persona) Prefix() string {
+	if p == nil {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.path != "" {
+		info, err := os.Stat(p.path)
+		if err != nil {
+			log.Printf("[Persona] Keeping the previous prefix, cannot stat %s: %v", p.path, err)
+		} else if !info.ModTime().Equal(p.modTime) || info.Size() != p.size {
+			if err := p.read(info); err != nil {
+				log.Printf("[Persona] Keeping the previous prefix: %v", err)
+			} else {
+				log.Printf("[Persona] Reloaded system prompt prefix from %s", p.path)
+			}
+		}
+	}
+	return p.text
+}
+
+// read loads the file described by info. Must be called with mu held (or before p is shared).
+func (p *Persona) read(info os.FileInfo) error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("reading system prompt prefix: %w", err)
+	}
+	p.text = strings.TrimSpace(string(data))
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	return nil
+}
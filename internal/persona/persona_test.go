@@ -0,0 +1,56 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPersona(t *testing.T) {
+	var none *Persona
+	if none.Prefix() != "" {
+		t.Error("Expected no prefix for a nil Persona")
+	}
+	if got := New("  Be polite.\n").Prefix(); got != "Be polite." {
+		t.Errorf("Unexpected fixed prefix %q", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "persona.txt")
+	if err := os.WriteFile(path, []byte("Never give legal advice.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := p.Prefix(); got != "Never give legal advice." {
+		t.Errorf("Unexpected prefix %q", got)
+	}
+
+	// Edits are picked up without reloading explicitly
+	if err := os.WriteFile(path, []byte("Answer formally."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	if got := p.Prefix(); got != "Answer formally." {
+		t.Errorf("Expected the edited prefix, got %q", got)
+	}
+
+	// A removed file keeps the last good prefix
+	os.Remove(path)
+	if got := p.Prefix(); got != "Answer formally." {
+		t.Errorf("Expected the previous prefix to be kept, got %q", got)
+	}
+
+	if p, err := Open("  ", ""); p != nil || err != nil {
+		t.Errorf("Expected no persona without settings, got %v, %v", p, err)
+	}
+	if p, _ := Open("Be brief.", ""); p.Prefix() != "Be brief." {
+		t.Errorf("Expected the inline prefix, got %q", p.Prefix())
+	}
+
+	if _, err := Open("", filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
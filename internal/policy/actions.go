@@ -0,0 +1,53 @@
+package policy
+
+// Actions limits what a request may do beyond reading the pages it was asked about: how many more pages it
+// may fetch, through tool calls or crawling, and on which domains. It bounds the cost of agentic features
+// and what they can reach.
+type Actions struct {
+	MaxFetches int      // Additional pages one request may fetch; negative leaves the limit of each feature
+	Domains    []string // Domain patterns additional pages must be on; empty allows any domain
+}
+
+// ChannelActions returns the Actions of each channel given a limit of additional fetches per request in
+// limits or domain patterns in domains. Channels in only one of them are not limited by the other.
+func ChannelActions(limits map[string]int, domains map[string][]string) map[string]Actions {
+	actions := make(map[string]Actions)
+	for channel, n := range limits {
+		actions[channel] = Actions{MaxFetches: n}
+	}
+	for channel, patterns := range domains {
+		a, ok := actions[channel]
+		if !ok {
+			a.MaxFetches = -1
+		}
+		for _, d := range patterns {
+			if d = normalizeDomain(d); d != "" {
+				a.Domains = append(a.Domains, d)
+			}
+		}
+		actions[channel] = a
+	}
+	return actions
+}
+
+// Limit returns n capped at the additional fetches a allows.
+func (a Actions) Limit(n int) int {
+	if a.MaxFetches >= 0 && a.MaxFetches < n {
+		return a.MaxFetches
+	}
+	return n
+}
+
+// AllowsFetch reports whether a allows fetching rawURL as an additional page.
+func (a Actions) AllowsFetch(rawURL string) bool {
+	if len(a.Domains) == 0 {
+		return true
+	}
+	host := hostOf(rawURL)
+	for _, d := range a.Domains {
+		if host != "" && matchDomain(host, normalizeDomain(d)) {
+			return true
+		}
+	}
+	return false
+}
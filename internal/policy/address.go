@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// lookupIP resolves host names for CheckPublicURL; tests replace it.
+var lookupIP = net.DefaultResolver.LookupIPAddr
+
+// CheckPublicURL returns an error unless every address the host of rawURL resolves to is public, so that
+// URLs chosen by a model (and possibly injected by a fetched page) cannot reach loopback, private,
+// link-local or unspecified addresses, e.g. the cloud metadata service at 169.254.169.254.
+func CheckPublicURL(ctx context.Context, rawURL string) error {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("invalid URL %s: no host", rawURL)
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := lookupIP(ctx, host)
+		if err != nil {
+			return fmt.Errorf("resolving %s: %w", host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return fmt.Errorf("resolving %s: no addresses", host)
+	}
+	for _, ip := range ips {
+		if !isPublic(ip) {
+			return fmt.Errorf("%s is not allowed: %s resolves to the internal address %s", rawURL, host, ip)
+		}
+	}
+	return nil
+}
+
+// isPublic reports whether ip is a routable address outside of the local machine and network.
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast()
+}
@@ -0,0 +1,179 @@
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Policy holds per-domain routing rules for processed URLs.
+type Policy struct {
+	localOnly   []string         // Domain patterns whose content must stay on local models
+	schemes     []string         // URL schemes that may be fetched
+	ports       []int            // Ports that may be fetched on any host
+	domainPorts map[string][]int // Extra ports allowed by domain pattern
+}
+
+// New creates a Policy with the given local-only domain patterns, which allows http and https URLs on
+// ports 80 and 443.
+// A pattern matches the host itself and any of its subdomains; a leading "*." is accepted for readability.
+func New(localOnlyDomains []string) *Policy {
+	p := &Policy{schemes: []string{"http", "https"}, ports: []int{80, 443}}
+	for _, d := range localOnlyDomains {
+		if d = normalizeDomain(d); d != "" {
+			p.localOnly = append(p.localOnly, d)
+		}
+	}
+	return p
+}
+
+// NewFromEnv creates a Policy from the LOCAL_ONLY_DOMAINS, ALLOWED_URL_SCHEMES, ALLOWED_URL_PORTS and
+// TRUSTED_DOMAIN_PORTS environment variables (comma separated). TRUSTED_DOMAIN_PORTS entries are
+// "domain:port", e.g. "consul.internal.example.com:8500".
+func NewFromEnv() (*Policy, error) {
+	p := New(strings.Split(os.Getenv("LOCAL_ONLY_DOMAINS"), ","))
+	if v := os.Getenv("ALLOWED_URL_SCHEMES"); v != "" {
+		p.schemes = nil
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+				p.schemes = append(p.schemes, s)
+			}
+		}
+	}
+	if v := os.Getenv("ALLOWED_URL_PORTS"); v != "" {
+		p.ports = nil
+		for _, s := range strings.Split(v, ",") {
+			port, err := parsePort(s)
+			if err != nil {
+				return nil, fmt.Errorf("ALLOWED_URL_PORTS: %w", err)
+			}
+			p.ports = append(p.ports, port)
+		}
+	}
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_DOMAIN_PORTS"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("TRUSTED_DOMAIN_PORTS: %q must be domain:port", entry)
+		}
+		port, err := parsePort(entry[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_DOMAIN_PORTS: %w", err)
+		}
+		p.AllowPort(entry[:i], port)
+	}
+	return p, nil
+}
+
+// AllowPort allows URLs on port for hosts matching the domain pattern, e.g. an internal service
+// that does not listen on 80 or 443.
+func (p *Policy) AllowPort(domain string, port int) {
+	if domain = normalizeDomain(domain); domain == "" {
+		return
+	}
+	if p.domainPorts == nil {
+		p.domainPorts = make(map[string][]int)
+	}
+	p.domainPorts[domain] = append(p.domainPorts[domain], port)
+}
+
+// CheckURL returns an error if rawURL may not be fetched because of its scheme or port.
+// Without an explicit port, the default port of the scheme is checked. A nil Policy allows every URL.
+func (p *Policy) CheckURL(rawURL string) error {
+	if p == nil {
+		return nil
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if !slices.Contains(p.schemes, scheme) {
+		return fmt.Errorf("%s is not allowed: scheme %s is not one of %s", rawURL, scheme, strings.Join(p.schemes, ", "))
+	}
+
+	port := 0
+	switch {
+	case u.Port() != "":
+		if port, err = strconv.Atoi(u.Port()); err != nil {
+			return fmt.Errorf("invalid URL %s: bad port %q", rawURL, u.Port())
+		}
+	case scheme == "http":
+		port = 80
+	case scheme == "https":
+		port = 443
+	default:
+		// Schemes without a default port are only restricted by the scheme list
+		return nil
+	}
+	if slices.Contains(p.ports, port) {
+		return nil
+	}
+	host := strings.TrimRight(strings.ToLower(u.Hostname()), ".")
+	for domain, ports := range p.domainPorts {
+		if matchDomain(host, domain) && slices.Contains(ports, port) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not allowed: port %d is not allowed for %s", rawURL, port, host)
+}
+
+// RequiresLocal reports whether content from rawURL must only be processed by a local model.
+// URLs without a host that can be parsed are treated as sensitive, since their domain cannot be checked;
+// content without any URL is not restricted.
+func (p *Policy) RequiresLocal(rawURL string) bool {
+	if p == nil || len(p.localOnly) == 0 || strings.TrimSpace(rawURL) == "" {
+		return false
+	}
+	host := hostOf(rawURL)
+	if host == "" {
+		return true
+	}
+	for _, d := range p.localOnly {
+		if matchDomain(host, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOf extracts the lower-cased host name from rawURL without trailing dots, accepting scheme-less "www." links.
+func hostOf(rawURL string) string {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	// A fully-qualified "wiki.example.com." is the same host as "wiki.example.com"
+	return strings.TrimRight(strings.ToLower(u.Hostname()), ".")
+}
+
+// normalizeDomain lower-cases a domain pattern and drops a leading "*." and trailing dots.
+func normalizeDomain(d string) string {
+	return strings.TrimRight(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "*."), ".")
+}
+
+// parsePort parses a TCP port number.
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", strings.TrimSpace(s))
+	}
+	return port, nil
+}
+
+// matchDomain reports whether host equals domain or is a subdomain of it.
+func matchDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,168 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestPolicy_RequiresLocal(t *testing.T) {
+	p := New([]string{"wiki.example.com", "*.hr.example.com", "Intranet.Example.org.", " "})
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://wiki.example.com/page/1", true},
+		{"https://WIKI.example.com/", true},
+		{"https://team.wiki.example.com/x", true},
+		{"https://payroll.hr.example.com/", true},
+		{"https://hr.example.com/", true},
+		{"www.wiki.example.com/page", true},
+		{"https://example.com/", false},
+		{"https://notwiki.example.com/", false},
+		{"https://wiki.example.com.evil.test/", false},
+		{"https://wiki.example.com./page", true},
+		{"https://Team.Wiki.Example.COM./x", true},
+		{"wiki.example.com./page", true},
+		{"https://intranet.example.org/", true},
+		{"https://docs.INTRANET.example.org./", true},
+		{"https://wiki.example.com../", true},
+		{"https://[wiki.example.com/", true},
+		{"https:///page", true},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := p.RequiresLocal(tt.url); got != tt.want {
+			t.Errorf("RequiresLocal(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy_NilAndEmpty(t *testing.T) {
+	var p *Policy
+	if p.RequiresLocal("https://wiki.example.com/") {
+		t.Error("nil policy should not require local processing")
+	}
+	if New(nil).RequiresLocal("https://wiki.example.com/") {
+		t.Error("empty policy should not require local processing")
+	}
+}
+
+func TestPolicy_CheckURL(t *testing.T) {
+	p := New(nil)
+	p.AllowPort("*.internal.example.com", 8500)
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://example.com/", true},
+		{"http://example.com/", true},
+		{"https://example.com:443/", true},
+		{"www.example.com/page", true},
+		{"http://internal-service:8500/v1/kv", false},
+		{"https://example.com:8443/", false},
+		{"ftp://example.com/file", false},
+		{"https://consul.internal.example.com:8500/ui", true},
+		{"https://consul.internal.example.com:8501/ui", false},
+		{"https://internal.example.com.evil.test:8500/", false},
+	}
+	for _, tt := range tests {
+		if err := p.CheckURL(tt.url); (err == nil) != tt.allowed {
+			t.Errorf("CheckURL(%q) = %v, want allowed %v", tt.url, err, tt.allowed)
+		}
+	}
+
+	var nilPolicy *Policy
+	if err := nilPolicy.CheckURL("http://internal-service:8500/"); err != nil {
+		t.Errorf("nil policy should allow every URL, got %v", err)
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("LOCAL_ONLY_DOMAINS", "")
+	t.Setenv("ALLOWED_URL_SCHEMES", "https")
+	t.Setenv("ALLOWED_URL_PORTS", "443, 8443")
+	t.Setenv("TRUSTED_DOMAIN_PORTS", "grafana.example.com:3000")
+	p, err := NewFromEnv()
+	if err != nil {
+		t.Fatalf("NewFromEnv failed: %v", err)
+	}
+	for url, allowed := range map[string]bool{
+		"https://example.com:8443/":        true,
+		"http://example.com/":              false,
+		"https://grafana.example.com:3000": true,
+		"https://example.com:3000/":        false,
+	} {
+		if err := p.CheckURL(url); (err == nil) != allowed {
+			t.Errorf("CheckURL(%q) = %v, want allowed %v", url, err, allowed)
+		}
+	}
+
+	t.Setenv("TRUSTED_DOMAIN_PORTS", "grafana.example.com")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("Expected an error for an entry without a port")
+	}
+}
+
+func TestChannelActions(t *testing.T) {
+	actions := ChannelActions(
+		map[string]int{"C1": 2, "C2": 0},
+		map[string][]string{"C1": {"*.Example.com", " "}, "C3": {"docs.example.com"}},
+	)
+
+	c1 := actions["C1"]
+	if c1.Limit(5) != 2 || c1.Limit(1) != 1 {
+		t.Errorf("C1 limits 5 to %d and 1 to %d", c1.Limit(5), c1.Limit(1))
+	}
+	if !c1.AllowsFetch("https://api.example.com/x") || !c1.AllowsFetch("https://example.com/") || c1.AllowsFetch("https://example.com.evil.test/") {
+		t.Errorf("Unexpected domains of C1 %v", c1.Domains)
+	}
+	if c2 := actions["C2"]; c2.Limit(5) != 0 || !c2.AllowsFetch("https://anything.test/") {
+		t.Errorf("Unexpected actions of C2 %+v", c2)
+	}
+	if c3 := actions["C3"]; c3.Limit(5) != 5 || c3.AllowsFetch("https://example.com/") || !c3.AllowsFetch("https://docs.example.com/guide") {
+		t.Errorf("Unexpected actions of C3 %+v", c3)
+	}
+}
+
+func TestCheckPublicURL(t *testing.T) {
+	lookup := lookupIP
+	defer func() { lookupIP = lookup }()
+	lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "public.test":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+		case "rebind.test":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.1.2.3")}}, nil
+		case "metadata.test":
+			return []net.IPAddr{{IP: net.ParseIP("169.254.169.254")}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	for url, allowed := range map[string]bool{
+		"https://public.test/page":                true,
+		"https://93.184.216.34/":                  true,
+		"public.test/page":                        true,
+		"https://rebind.test/":                    false,
+		"https://metadata.test/":                  false,
+		"http://169.254.169.254/latest/meta-data": false,
+		"http://127.0.0.1:8080/":                  false,
+		"http://10.0.0.1/":                        false,
+		"http://172.16.5.4/":                      false,
+		"http://192.168.0.1/":                     false,
+		"http://[::1]/":                           false,
+		"http://[fe80::1]/":                       false,
+		"http://[fd00::1]/":                       false,
+		"http://0.0.0.0/":                         false,
+		"https://unknown.test/":                   false,
+		"https:///page":                           false,
+	} {
+		if err := CheckPublicURL(context.Background(), url); (err == nil) != allowed {
+			t.Errorf("CheckPublicURL(%q) = %v, want allowed %v", url, err, allowed)
+		}
+	}
+}
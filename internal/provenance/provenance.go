@@ -0,0 +1,88 @@
+// Package provenance records where a summary came from: the text it was generated from, the model and the
+// prompts. Records can be signed with a shared key, so consumers can check that a summary corresponds to a
+// specific snapshot of its source and was not changed on the way.
+package provenance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// scheme names the format of the signed message, so it can change without old signatures verifying.
+const scheme = "describe-kun provenance v1"
+
+// Provenance describes how a summary was made.
+type Provenance struct {
+	URL           string    `json:"url,omitempty"`
+	ContentSHA256 string    `json:"content_sha256"`           // Hash of the extracted text the summary was generated from
+	SummarySHA256 string    `json:"summary_sha256,omitempty"` // Hash of the summary as delivered
+	Model         string    `json:"model,omitempty"`
+	PromptVersion string    `json:"prompt_version"`
+	Created       time.Time `json:"created"`
+	Signature     string    `json:"signature,omitempty"` // HMAC-SHA256 of the other fields; empty if unsigned
+}
+
+// New describes summary, generated by model with the prompts of promptVersion from content, the text of url.
+// It is signed with key, unless key is empty.
+func New(url, content, summary, model, promptVersion string, created time.Time, key []byte) *Provenance {
+	p := &Provenance{
+		URL:           url,
+		ContentSHA256: Hash(content),
+		SummarySHA256: Hash(summary),
+		Model:         model,
+		PromptVersion: promptVersion,
+		Created:       created.UTC(),
+	}
+	p.sign(key)
+	return p
+}
+
+// For returns a copy of p describing summary instead, e.g. the same summary rendered in another format,
+// signed with key unless key is empty.
+func (p *Provenance) For(summary string, key []byte) *Provenance {
+	q := *p
+	q.SummarySHA256 = Hash(summary)
+	q.sign(key)
+	return &q
+}
+
+// sign sets the signature of p with key, or clears it if key is empty.
+func (p *Provenance) sign(key []byte) {
+	p.Signature = ""
+	if len(key) > 0 {
+		p.Signature = hex.EncodeToString(p.mac(key))
+	}
+}
+
+// Verify reports whether summary is the one p describes and p was signed with key.
+func (p *Provenance) Verify(summary string, key []byte) bool {
+	if p.Signature == "" || Hash(summary) != p.SummarySHA256 {
+		return false
+	}
+	signature, err := hex.DecodeString(p.Signature)
+	return err == nil && hmac.Equal(signature, p.mac(key))
+}
+
+// Hash returns the hex-encoded SHA-256 of text.
+func Hash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// mac returns the HMAC-SHA256 with key of the fields of p, one per line in a fixed order.
+func (p *Provenance) mac(key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(strings.Join([]string{
+		scheme,
+		p.URL,
+		p.ContentSHA256,
+		p.SummarySHA256,
+		p.Model,
+		p.PromptVersion,
+		p.Created.UTC().Format(time.RFC3339Nano),
+	}, "\n")))
+	return h.Sum(nil)
+}
@@ -0,0 +1,63 @@
+package provenance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestProvenance_Verify(t *testing.T) {
+	key := []byte("secret")
+	created := time.Date(2026, 10, 17, 9, 30, 0, 123, time.FixedZone("JST", 9*60*60))
+	p := New("https://example.com", "Page text", "Summary", "gpt-4o-mini", "2026-10-17", created, key)
+
+	if p.ContentSHA256 != Hash("Page text") || p.SummarySHA256 != Hash("Summary") {
+		t.Errorf("hashes = %s, %s", p.ContentSHA256, p.SummarySHA256)
+	}
+	if !p.Verify("Summary", key) {
+		t.Error("Verify() = false for the signed summary")
+	}
+	if p.Verify("Summary, edited", key) {
+		t.Error("Verify() = true for another summary")
+	}
+	if p.Verify("Summary", []byte("other")) {
+		t.Error("Verify() = true with another key")
+	}
+
+	tampered := *p
+	tampered.Model = "gpt-4o"
+	if tampered.Verify("Summary", key) {
+		t.Error("Verify() = true after the model was changed")
+	}
+
+	// Consumers verify what they decoded from JSON
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Provenance
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Verify("Summary", key) {
+		t.Errorf("Verify() = false after a JSON round trip of %s", data)
+	}
+
+	markdown := p.For("*Summary*", key)
+	if !markdown.Verify("*Summary*", key) || markdown.ContentSHA256 != p.ContentSHA256 {
+		t.Errorf("For() = %+v", markdown)
+	}
+	if !p.Verify("Summary", key) {
+		t.Error("For() changed the original")
+	}
+}
+
+func TestProvenance_Unsigned(t *testing.T) {
+	p := New("https://example.com", "Page text", "Summary", "", "v1", time.Now(), nil)
+	if p.Signature != "" {
+		t.Errorf("Signature = %q without a key", p.Signature)
+	}
+	if p.Verify("Summary", nil) {
+		t.Error("Verify() = true for an unsigned provenance")
+	}
+}
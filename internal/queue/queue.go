@@ -0,0 +1,295 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// recentJobs is how many recent job durations ETA averages over.
+const recentJobs = 20
+
+// Priority orders jobs in the queue; higher values run first.
+type Priority int
+
+const (
+	// Background is for automated work such as digests, feed watchers and crawls.
+	Background Priority = iota
+	// Interactive is for requests a user is waiting on, such as mentions and slash commands.
+	Interactive
+)
+
+func (p Priority) String() string {
+	if p == Interactive {
+		return "interactive"
+	}
+	return "background"
+}
+
+// ErrClosed is returned when submitting to a closed queue.
+var ErrClosed = errors.New("queue is closed")
+
+// Job is a unit of work run by the queue.
+type Job struct {
+	Priority Priority
+	Name     string // Used in logs
+	Run      func(ctx context.Context)
+
+	// Ctx cancels the job: a job cancelled while queued is skipped, and a running one sees
+	// the cancellation through the context passed to Run. Nil means the job cannot be cancelled.
+	Ctx context.Context
+}
+
+// Queue runs jobs on a fixed pool of workers, always picking the highest priority job first.
+// When there is more than one worker, one is reserved for interactive jobs so that
+// long-running background work can never make a user wait for a free worker.
+type Queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending jobHeap
+	seq     uint64 // Keeps FIFO order within a priority
+	closed  bool
+
+	workers           int
+	maxBackground     int // Workers background jobs may occupy at once
+	runningBackground int
+	running           int             // Jobs currently running, of any priority
+	durations         []time.Duration // Run times of the most recent jobs, for ETA
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a queue and starts its workers. workers below 1 is treated as 1.
+func New(workers int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		workers:       workers,
+		maxBackground: workers,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	if workers > 1 {
+		q.maxBackground = workers - 1
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	return q
+}
+
+// Submit enqueues a job.
+func (q *Queue) Submit(job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	q.seq++
+	heap.Push(&q.pending, &item{job: job, seq: q.seq})
+	q.cond.Broadcast()
+	return nil
+}
+
+// Len returns the number of jobs waiting for a worker.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending.Len()
+}
+
+// Stats is a snapshot of the queue's load.
+type Stats struct {
+	Pending int `json:"pending"` // Jobs waiting for a worker
+	Running int `json:"running"` // Jobs being run
+	Workers int `json:"workers"` // Size of the worker pool
+}
+
+// Stats returns the current load of the queue.
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return Stats{Pending: q.pending.Len(), Running: q.running, Workers: q.workers}
+}
+
+// Position returns the 1-based place of the job named name among the jobs waiting for a worker,
+// in the order they will start, or 0 if no such job is waiting.
+func (q *Queue) Position(name string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var target *item
+	for _, it := range q.pending {
+		if it.job.Name == name {
+			target = it
+			break
+		}
+	}
+	if target == nil {
+		return 0
+	}
+	position := 1
+	for _, it := range q.pending {
+		if it != target && q.pending.before(it, target) {
+			position++
+		}
+	}
+	return position
+}
+
+// ETA roughly estimates how long the job at position waits before it finishes, from the average
+// run time of recent jobs. It returns 0 until a job has finished.
+func (q *Queue) ETA(position int) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.durations) == 0 || position < 1 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range q.durations {
+		total += d
+	}
+	average := total / time.Duration(len(q.durations))
+	// Jobs ahead start as workers free up, then the job itself runs
+	rounds := (position + q.workers - 1) / q.workers
+	return average * time.Duration(rounds+1)
+}
+
+// Wait blocks until no jobs are queued or running. Serverless runtimes use it to finish
+// background work after responding, before the environment is frozen.
+func (q *Queue) Wait() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.pending.Len() > 0 || q.running > 0 {
+		q.cond.Wait()
+	}
+}
+
+// Close stops accepting jobs, waits for queued and running jobs to finish, then stops the workers.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	q.wg.Wait()
+	q.cancel()
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		var it *item
+		for {
+			it = q.next()
+			if it != nil || (q.closed && q.pending.Len() == 0) {
+				break
+			}
+			q.cond.Wait()
+		}
+		if it == nil {
+			q.mu.Unlock()
+			return
+		}
+		if it.job.Priority == Background {
+			q.runningBackground++
+		}
+		q.running++
+		q.mu.Unlock()
+
+		start := time.Now()
+		ran := q.run(it.job)
+
+		q.mu.Lock()
+		if ran {
+			q.durations = append(q.durations, time.Since(start))
+			if len(q.durations) > recentJobs {
+				q.durations = q.durations[1:]
+			}
+		}
+		if it.job.Priority == Background {
+			q.runningBackground--
+		}
+		q.running--
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// next pops the job to run now, or returns nil if nothing may run. Must be called with mu held.
+func (q *Queue) next() *item {
+	if q.pending.Len() == 0 {
+		return nil
+	}
+	top := q.pending[0]
+	if top.job.Priority == Background && q.runningBackground >= q.maxBackground {
+		// Only background work is waiting and its share of workers is in use
+		return nil
+	}
+	return heap.Pop(&q.pending).(*item)
+}
+
+// run executes a job, keeping a panicking job from taking down its worker.
+// It returns false if the job was skipped because it was cancelled while queued.
+func (q *Queue) run(job Job) bool {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Queue] Job %q panicked: %v", job.Name, r)
+		}
+	}()
+	ctx := q.ctx
+	if job.Ctx != nil {
+		if err := job.Ctx.Err(); err != nil {
+			log.Printf("[Queue] Skipping job %q: %v", job.Name, context.Cause(job.Ctx))
+			return false
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(job.Ctx)
+		defer cancel()
+		stop := context.AfterFunc(q.ctx, cancel)
+		defer stop()
+	}
+	job.Run(ctx)
+	return true
+}
+
+type item struct {
+	job Job
+	seq uint64
+}
+
+// jobHeap implements heap.Interface ordered by priority, then submission order.
+type jobHeap []*item
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool { return h.before(h[i], h[j]) }
+
+// before reports whether a runs before b.
+func (jobHeap) before(a, b *item) bool {
+	if a.job.Priority != b.job.Priority {
+		return a.job.Priority > b.job.Priority
+	}
+	return a.seq < b.seq
+}
+
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *jobHeap) Push(x any) { *h = append(*h, x.(*item)) }
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	*h = old[:n-1]
+	return it
+}
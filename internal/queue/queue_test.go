@@ -0,0 +1,174 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueue_PriorityOrder(t *testing.T) {
+	q := New(1)
+
+	// Block the only worker so the following jobs queue up
+	release := make(chan struct{})
+	started := make(chan struct{})
+	q.Submit(Job{Priority: Interactive, Name: "blocker", Run: func(ctx context.Context) {
+		close(started)
+		<-release
+	}})
+	<-started
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(context.Context) {
+		return func(ctx context.Context) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	}
+	q.Submit(Job{Priority: Background, Name: "crawl", Run: record("crawl")})
+	q.Submit(Job{Priority: Interactive, Name: "mention-1", Run: record("mention-1")})
+	q.Submit(Job{Priority: Interactive, Name: "mention-2", Run: record("mention-2")})
+
+	close(release)
+	q.Close()
+
+	want := []string{"mention-1", "mention-2", "crawl"}
+	if len(order) != len(want) {
+		t.Fatalf("Expected order %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Expected order %v, got %v", want, order)
+		}
+	}
+}
+
+func TestQueue_ReservesWorkerForInteractive(t *testing.T) {
+	q := New(2)
+	defer q.Close()
+
+	// Two long background jobs: only one may run, the other must wait
+	release := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		q.Submit(Job{Priority: Background, Run: func(ctx context.Context) { <-release }})
+	}
+
+	done := make(chan struct{})
+	q.Submit(Job{Priority: Interactive, Run: func(ctx context.Context) { close(done) }})
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Interactive job did not run while background jobs held the pool")
+	}
+	if n := q.Len(); n != 1 {
+		t.Errorf("Expected one background job still queued, got %d", n)
+	}
+	close(release)
+}
+
+func TestQueue_SubmitAfterClose(t *testing.T) {
+	q := New(1)
+	q.Close()
+	if err := q.Submit(Job{Run: func(ctx context.Context) {}}); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestQueue_Wait(t *testing.T) {
+	q := New(2)
+	defer q.Close()
+	q.Wait() // Returns immediately when idle
+
+	var mu sync.Mutex
+	done := 0
+	for i := 0; i < 3; i++ {
+		q.Submit(Job{Priority: Background, Name: "job", Run: func(ctx context.Context) {
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			done++
+			mu.Unlock()
+		}})
+	}
+	if stats := q.Stats(); stats.Workers != 2 || stats.Pending+stats.Running == 0 {
+		t.Errorf("Expected queued work in %+v", stats)
+	}
+	q.Wait()
+	if stats := q.Stats(); stats.Pending != 0 || stats.Running != 0 {
+		t.Errorf("Expected an idle queue, got %+v", stats)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if done != 3 {
+		t.Errorf("Expected all jobs to finish before Wait returns, got %d", done)
+	}
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	q := New(1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	runningCtx, cancelRunning := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	q.Submit(Job{Priority: Interactive, Name: "running", Ctx: runningCtx, Run: func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		<-release
+	}})
+	<-started
+
+	queuedCtx, cancelQueued := context.WithCancel(context.Background())
+	ran := false
+	q.Submit(Job{Priority: Interactive, Name: "queued", Ctx: queuedCtx, Run: func(ctx context.Context) { ran = true }})
+
+	cancelQueued()
+	cancelRunning()
+	if err := <-cancelled; err != context.Canceled {
+		t.Errorf("Expected the running job to see the cancellation, got %v", err)
+	}
+	close(release)
+	q.Close()
+	if ran {
+		t.Error("Expected the job cancelled while queued to be skipped")
+	}
+}
+
+func TestQueue_PositionAndETA(t *testing.T) {
+	q := New(1)
+	if eta := q.ETA(1); eta != 0 {
+		t.Errorf("Expected no ETA before any job finished, got %s", eta)
+	}
+	q.Submit(Job{Priority: Background, Name: "warm-up", Run: func(ctx context.Context) { time.Sleep(20 * time.Millisecond) }})
+	q.Wait()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	q.Submit(Job{Priority: Interactive, Name: "blocker", Run: func(ctx context.Context) {
+		close(started)
+		<-release
+	}})
+	<-started
+	noop := func(ctx context.Context) {}
+	q.Submit(Job{Priority: Background, Name: "crawl", Run: noop})
+	q.Submit(Job{Priority: Interactive, Name: "mention-1", Run: noop})
+	q.Submit(Job{Priority: Interactive, Name: "mention-2", Run: noop})
+
+	for name, want := range map[string]int{"mention-1": 1, "mention-2": 2, "crawl": 3, "blocker": 0, "unknown": 0} {
+		if got := q.Position(name); got != want {
+			t.Errorf("Position(%q) = %d, want %d", name, got, want)
+		}
+	}
+	// The running job and the first mention finish before the second mention runs: three warm-up lengths
+	if eta := q.ETA(2); eta < 60*time.Millisecond || eta > time.Second {
+		t.Errorf("Unexpected ETA %s", eta)
+	}
+
+	close(release)
+	q.Close()
+}
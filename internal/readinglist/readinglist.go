@@ -0,0 +1,102 @@
+// Package readinglist keeps the links each user saved to read later, with their summaries.
+package readinglist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// MaxItems is how many links a reading list keeps; saving more drops the oldest.
+const MaxItems = 100
+
+// Item is a saved link.
+type Item struct {
+	URL     string    `json:"url"`
+	Summary string    `json:"summary,omitempty"` // The latest summary of the page when it was saved, if any
+	Channel string    `json:"channel,omitempty"` // Where it was saved from
+	Saved   time.Time `json:"saved"`
+}
+
+// Store keeps the reading list of each user.
+type Store struct {
+	mu    sync.Mutex
+	items map[string][]Item // User ID -> items, most recently saved first
+	path  string            // Optional file the reading lists are persisted to
+}
+
+// NewStore creates a Store. If statePath is set, reading lists are loaded from and saved to that file.
+func NewStore(statePath string) (*Store, error) {
+	s := &Store{items: make(map[string][]Item), path: statePath}
+	if statePath != "" {
+		data, err := os.ReadFile(statePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read reading lists: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &s.items); err != nil {
+				return nil, fmt.Errorf("failed to parse reading lists %s: %w", statePath, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Save adds item to the top of user's reading list, replacing an item with the same URL.
+func (s *Store) Save(user string, item Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if item.Saved.IsZero() {
+		item.Saved = time.Now()
+	}
+	items := []Item{item}
+	for _, it := range s.items[user] {
+		if it.URL != item.URL && len(items) < MaxItems {
+			items = append(items, it)
+		}
+	}
+	s.items[user] = items
+	s.save()
+}
+
+// Remove removes url from user's reading list and reports whether it was there.
+func (s *Store) Remove(user, url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, it := range s.items[user] {
+		if it.URL == url {
+			s.items[user] = append(s.items[user][:i], s.items[user][i+1:]...)
+			if len(s.items[user]) == 0 {
+				delete(s.items, user)
+			}
+			s.save()
+			return true
+		}
+	}
+	return false
+}
+
+// List returns user's reading list, most recently saved first.
+func (s *Store) List(user string) []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Item(nil), s.items[user]...)
+}
+
+// save writes the reading lists to the state file. Must be called with mu held.
+func (s *Store) save() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.Marshal(s.items)
+	if err == nil {
+		err = os.WriteFile(s.path, data, 0o644)
+	}
+	if err != nil {
+		log.Printf("[ReadingList] Failed to save reading lists: %v", err)
+	}
+}
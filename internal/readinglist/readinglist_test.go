@@ -0,0 +1,67 @@
+package readinglist
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func urls(items []Item) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.URL)
+	}
+	return out
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reading-list.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	s.Save("U1", Item{URL: "https://example.com/a"})
+	s.Save("U1", Item{URL: "https://example.com/b", Summary: "B"})
+	s.Save("U2", Item{URL: "https://example.com/c"})
+	// Saving a link again moves it to the top with its new summary
+	s.Save("U1", Item{URL: "https://example.com/a", Summary: "A"})
+
+	items := s.List("U1")
+	if got := urls(items); len(got) != 2 || got[0] != "https://example.com/a" || got[1] != "https://example.com/b" {
+		t.Fatalf("List = %q", got)
+	}
+	if items[0].Summary != "A" || items[0].Saved.IsZero() {
+		t.Errorf("Unexpected item %+v", items[0])
+	}
+
+	if !s.Remove("U1", "https://example.com/b") || s.Remove("U1", "https://example.com/b") {
+		t.Error("Expected the link to be removed once")
+	}
+
+	// Reading lists survive a restart
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if got := urls(reloaded.List("U1")); len(got) != 1 || got[0] != "https://example.com/a" {
+		t.Errorf("Reloaded list = %q", got)
+	}
+	if got := urls(reloaded.List("U2")); len(got) != 1 {
+		t.Errorf("Reloaded list = %q", got)
+	}
+}
+
+func TestStore_MaxItems(t *testing.T) {
+	s, err := NewStore("")
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	for i := 0; i <= MaxItems; i++ {
+		s.Save("U1", Item{URL: fmt.Sprintf("https://example.com/%d", i)})
+	}
+	items := s.List("U1")
+	if len(items) != MaxItems || items[len(items)-1].URL != "https://example.com/1" {
+		t.Errorf("Expected the oldest link dropped, got %d items ending with %s", len(items), items[len(items)-1].URL)
+	}
+}
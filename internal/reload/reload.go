@@ -0,0 +1,69 @@
+package reload
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Watch calls fn whenever the process receives SIGHUP or one of paths changes on disk, until ctx is done.
+// Files are polled every interval; a zero interval only reacts to SIGHUP. Missing files are not an error,
+// so a file that appears later triggers a reload too.
+func Watch(ctx context.Context, paths []string, interval time.Duration, fn func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 && len(paths) > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last := stamps(paths)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			last = stamps(paths)
+			fn()
+		case <-tick:
+			current := stamps(paths)
+			if changed(last, current) {
+				last = current
+				fn()
+			}
+		}
+	}
+}
+
+// stamp identifies a version of a file.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// stamps returns the current stamp of each path; missing files get the zero stamp.
+func stamps(paths []string) []stamp {
+	out := make([]stamp, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			out[i] = stamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return out
+}
+
+// changed reports whether any stamp differs.
+func changed(a, b []stamp) bool {
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return true
+		}
+	}
+	return false
+}
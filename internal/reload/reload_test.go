@@ -0,0 +1,57 @@
+//go:build !windows
+
+package reload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "describe-kun.env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reloads := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		Watch(ctx, []string{path}, 10*time.Millisecond, func() { reloads <- struct{}{} })
+		close(done)
+	}()
+
+	expectReload := func(what string) {
+		t.Helper()
+		select {
+		case <-reloads:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected a reload after %s", what)
+		}
+	}
+
+	// Give Watch time to take the initial stamps and install the signal handler
+	time.Sleep(50 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("A=22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectReload("a file change")
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	expectReload("SIGHUP")
+
+	select {
+	case <-reloads:
+		t.Error("Expected no reload without changes")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,92 @@
+// Package reqmeta carries who asked for a request, and how, through its context.
+package reqmeta
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Sources of requests.
+const (
+	SourceSlack = "slack"
+	SourceAPI   = "api"
+)
+
+// Ways a request was triggered.
+const (
+	TriggerMention     = "mention"     // The bot was mentioned
+	TriggerPrefix      = "prefix"      // A message started with the trigger prefix
+	TriggerResummarize = "resummarize" // A thread reply asked to summarize an edited message again
+	TriggerFollowUp    = "follow-up"   // A suggested follow-up question was clicked
+	TriggerEmail       = "email"       // An inbound newsletter
+	TriggerHTTP        = "http"        // A call to the summarize API
+)
+
+// Metadata identifies a request and who made it. It travels with the request's context, so logs,
+// policy decisions and the history all see the same requester.
+type Metadata struct {
+	RequestID string // Correlates the log lines of one request, e.g. the X-Request-Id it arrived with
+	Source    string // SourceSlack, SourceAPI, or empty for the CLI
+	Trigger   string // How the request was triggered, one of the Trigger constants
+	Workspace string // Slack team ID
+	Channel   string // Slack channel the request came from
+	User      string // Slack user who asked
+}
+
+type metadataKey struct{}
+
+// With returns a context carrying m.
+func With(ctx context.Context, m Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, m)
+}
+
+// From returns the metadata set by With, or the zero Metadata.
+func From(ctx context.Context) Metadata {
+	m, _ := ctx.Value(metadataKey{}).(Metadata)
+	return m
+}
+
+// Update returns a context carrying the metadata of ctx as changed by fn.
+func Update(ctx context.Context, fn func(m *Metadata)) context.Context {
+	m := From(ctx)
+	fn(&m)
+	return With(ctx, m)
+}
+
+// NewID returns a random hex request ID.
+func NewID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// String formats the set fields for logs, e.g. "request=1a2b source=slack channel=C123 user=U456".
+func (m Metadata) String() string {
+	var parts []string
+	for _, f := range []struct{ key, value string }{
+		{"request", m.RequestID},
+		{"source", m.Source},
+		{"trigger", m.Trigger},
+		{"workspace", m.Workspace},
+		{"channel", m.Channel},
+		{"user", m.User},
+	} {
+		if f.value != "" {
+			parts = append(parts, f.key+"="+f.value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Logf logs like log.Printf, followed by the metadata of ctx if it carries any.
+func Logf(ctx context.Context, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if m := From(ctx).String(); m != "" {
+		msg += " (" + m + ")"
+	}
+	log.Print(msg)
+}
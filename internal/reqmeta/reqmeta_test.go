@@ -0,0 +1,31 @@
+package reqmeta
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	if m := From(context.Background()); m != (Metadata{}) {
+		t.Errorf("Expected no metadata, got %+v", m)
+	}
+	ctx := With(context.Background(), Metadata{Source: SourceSlack, Channel: "C1", User: "U1"})
+	if m := From(ctx); m.Channel != "C1" || m.User != "U1" {
+		t.Errorf("Unexpected metadata %+v", m)
+	}
+
+	ctx = Update(ctx, func(m *Metadata) { m.RequestID = "r1" })
+	if m := From(ctx); m.RequestID != "r1" || m.Channel != "C1" {
+		t.Errorf("Expected the update to keep the other fields, got %+v", m)
+	}
+}
+
+func TestString(t *testing.T) {
+	m := Metadata{RequestID: "r1", Source: SourceSlack, Trigger: TriggerPrefix, Channel: "C1"}
+	if got, want := m.String(), "request=r1 source=slack trigger=prefix channel=C1"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got := (Metadata{}).String(); got != "" {
+		t.Errorf("Expected an empty string, got %q", got)
+	}
+}
@@ -0,0 +1,203 @@
+// Package retry retries operations that failed for transient reasons, with exponential backoff and jitter.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// Policy describes how often and how long an operation is retried.
+type Policy struct {
+	MaxAttempts int           // Attempts in total, the first included; 1 or less disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled for each further one
+	MaxDelay    time.Duration // Upper bound of a single delay; 0 means none
+	MaxElapsed  time.Duration // No retry starts later than this after the first attempt; 0 means no limit
+	Jitter      float64       // Fraction of each delay that is randomized, from 0 to 1
+}
+
+// Default is the policy used unless SetLimits changes it.
+var Default = Policy{MaxAttempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second, MaxElapsed: 30 * time.Second, Jitter: 0.2}
+
+var (
+	mu      sync.RWMutex
+	current = Default
+)
+
+// SetLimits changes the attempts and elapsed time of the policy returned by Current, keeping the
+// delays of Default. It is safe to call while operations are running.
+func SetLimits(maxAttempts int, maxElapsed time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = Default
+	current.MaxAttempts = maxAttempts
+	current.MaxElapsed = maxElapsed
+}
+
+// DefaultPolicy returns the policy shared by the fetcher, the LLM clients, the Slack client and the
+// alert webhooks.
+func Current() Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Do calls fn until it succeeds, fails with an error that is not Retryable, runs out of attempts or
+// elapsed time, or ctx is done. It returns the last error of fn. name identifies the operation in logs.
+func Do(ctx context.Context, p Policy, name string, fn func(ctx context.Context) error) error {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !Retryable(err) || attempt >= p.MaxAttempts {
+			return err
+		}
+		delay := p.delay(attempt)
+		var se *StatusError
+		if errors.As(err, &se) && se.RetryAfter > delay {
+			delay = se.RetryAfter
+		}
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			// The retry could not finish anyway; the operation's own error says more than a timeout
+			return err
+		}
+		reqmeta.Logf(ctx, "[Retry] %s failed (attempt %d/%d), retrying in %s: %v", name, attempt, p.MaxAttempts, delay.Round(time.Millisecond), err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// delay returns the backoff before the retry following attempt.
+func (p Policy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		// Spread retries of many clients failing at once, so they do not hit the server together again
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// StatusError is a failed response of an HTTP API, classified by its status code.
+type StatusError struct {
+	Code       int
+	RetryAfter time.Duration // Delay the server asked for; 0 if it did not say
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// FromResponse returns a StatusError for resp, described by err, with the delay of its Retry-After header.
+func FromResponse(resp *http.Response, err error) *StatusError {
+	se := &StatusError{Code: resp.StatusCode, Err: err}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+		se.RetryAfter = time.Duration(s) * time.Second
+	}
+	return se
+}
+
+// RetryableStatus reports whether a request answered with code may succeed when sent again: the
+// server was overloaded, rate limited the client or failed temporarily.
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	// Cloudflare and some LLM providers use non-standard 5xx codes for overload, e.g. 520-529
+	return code >= 520 && code <= 529
+}
+
+type transientError struct{ err error }
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// Transient marks err as worth retrying, for failures Retryable cannot recognize by itself.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err}
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, e.g. a network error after a request that must not be
+// sent twice.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// Retryable reports whether the operation failing with err may succeed when tried again: connection
+// failures, retryable HTTP statuses and errors marked Transient. Cancellation and deadlines are final,
+// as are all other errors (invalid certificates, unknown hosts, bad requests), which would only fail again.
+func Retryable(err error) bool {
+	var permanent *permanentError
+	var transient *transientError
+	var se *StatusError
+	var urlErr *url.Error
+	switch {
+	case err == nil, errors.As(err, &permanent):
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &transient):
+		return true
+	case errors.As(err, &se):
+		return RetryableStatus(se.Code)
+	case errors.As(err, &urlErr):
+		// Errors of http.Client; the cause tells whether the connection failed
+		err = urlErr.Err
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// The server closed the connection, typically a reused idle one
+			return true
+		}
+	}
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return !dnsErr.IsNotFound
+	case errors.As(err, &opErr):
+		// Refused, reset or unreachable
+		return true
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var fast = Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestDo(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), fast, "test", func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return &StatusError{Code: 503, Err: errors.New("unavailable")}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected success on the third attempt, got %v after %d", err, attempts)
+	}
+
+	attempts = 0
+	err = Do(context.Background(), fast, "test", func(ctx context.Context) error {
+		attempts++
+		return &StatusError{Code: 503, Err: errors.New("unavailable")}
+	})
+	if err == nil || attempts != 3 {
+		t.Errorf("Expected the last error after 3 attempts, got %v after %d", err, attempts)
+	}
+
+	attempts = 0
+	err = Do(context.Background(), fast, "test", func(ctx context.Context) error {
+		attempts++
+		return &StatusError{Code: 400, Err: errors.New("bad request")}
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected no retry of a bad request, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestDo_Budgets(t *testing.T) {
+	slow := Policy{MaxAttempts: 5, BaseDelay: time.Hour, MaxElapsed: time.Minute}
+	attempts := 0
+	err := Do(context.Background(), slow, "test", func(ctx context.Context) error {
+		attempts++
+		return Transient(errors.New("flaky"))
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected no retry beyond the elapsed budget, got %v after %d attempts", err, attempts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	attempts = 0
+	start := time.Now()
+	Do(ctx, Policy{MaxAttempts: 5, BaseDelay: time.Minute}, "test", func(ctx context.Context) error {
+		attempts++
+		return Transient(errors.New("flaky"))
+	})
+	if attempts != 1 || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("Expected to give up at once when the retry cannot start before the deadline, %d attempts", attempts)
+	}
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := p.delay(attempt); got != want {
+			t.Errorf("delay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.delay(1); d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("Expected a jittered delay between 0.5s and 1s, got %s", d)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	connErr := &url.Error{Op: "Post", URL: "https://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &StatusError{Code: 429}, true},
+		{"overloaded", fmt.Errorf("wrapped: %w", &StatusError{Code: 529}), true},
+		{"not found", &StatusError{Code: 404}, false},
+		{"connection refused", connErr, true},
+		{"connection closed", &url.Error{Op: "Post", URL: "https://example.com", Err: io.EOF}, true},
+		{"unknown host", &url.Error{Op: "Get", URL: "https://nx.example", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, false},
+		{"certificate", &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("x509: certificate has expired")}, false},
+		{"deadline", &url.Error{Op: "Get", URL: "https://example.com", Err: context.DeadlineExceeded}, false},
+		{"permanent", Permanent(connErr), false},
+		{"transient", Transient(errors.New("net::ERR_CONNECTION_RESET")), true},
+		{"other", errors.New("unsupported content type"), false},
+	} {
+		if got := Retryable(tc.err); got != tc.want {
+			t.Errorf("Retryable(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+)
+
+// requestIDHeader carries the request ID, both from a proxy that assigned one and back to the caller.
+const requestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds request IDs taken from incoming headers, which end up in logs.
+const maxRequestIDLength = 64
+
+// RequestIDFrom returns the ID assigned to the request by the RequestID middleware, or "" if there is none.
+func RequestIDFrom(ctx context.Context) string {
+	return reqmeta.From(ctx).RequestID
+}
+
+// RequestID identifies every request, keeping the ID a load balancer or proxy set in X-Request-Id
+// and generating one otherwise. The ID is echoed in the response and available from RequestIDFrom.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = reqmeta.NewID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := reqmeta.Update(r.Context(), func(m *reqmeta.Metadata) { m.RequestID = id })
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Recover turns a panicking handler into a 500 response and a logged stack trace, instead of a dropped
+// connection.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				// Deliberate abort, which the server handles quietly
+				panic(v)
+			}
+			log.Printf("[HTTP] Panic serving %s %s (request %s): %v\n%s", r.Method, r.URL.Path, RequestIDFrom(r.Context()), v, debug.Stack())
+			w.WriteHeader(http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Log logs every request with its status, size and duration.
+func Log(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("[HTTP] %s %s %d %dB %s (request %s)", r.Method, r.URL.Path, rec.status, rec.bytes, time.Since(start).Round(time.Millisecond), RequestIDFrom(r.Context()))
+	})
+}
+
+// statusRecorder remembers the status and size of a response for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// BufferBody reads the request body, up to maxBytes, into memory ahead of the handler, so middleware
+// such as signature verification and the handler can both read it. Larger bodies get 413.
+func BufferBody(maxBytes int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+			r.Body.Close()
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					return
+				}
+				log.Printf("[HTTP] Error reading request body of %s: %v", r.URL.Path, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Body returns the body buffered by BufferBody, leaving it in place for the next reader.
+func Body(r *http.Request) ([]byte, error) {
+	if r.GetBody == nil {
+		return nil, errors.New("request body was not buffered")
+	}
+	rc, err := r.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// RequireToken lets through only requests carrying token, either as a bearer token or, for browsers,
+// as the password of basic authentication. Without a token, only loopback clients are let through.
+func RequireToken(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				host, _, err := net.SplitHostPort(r.RemoteAddr)
+				if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+					http.NotFound(w, r)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				_, got, _ = r.BasicAuth()
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="describe-kun"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
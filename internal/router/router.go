@@ -0,0 +1,43 @@
+// Package router serves HTTP endpoints behind shared middleware.
+package router
+
+import "net/http"
+
+// Middleware wraps a handler with behavior shared by several endpoints, such as authentication or logging.
+type Middleware func(http.Handler) http.Handler
+
+// Router dispatches requests by path, like http.ServeMux, running its middleware before every request
+// and each route's own middleware before that route.
+type Router struct {
+	mux     *http.ServeMux
+	handler http.Handler // mux wrapped in the router's middleware
+}
+
+// New creates a Router whose middleware runs for every request, unknown paths included.
+// Middleware runs in the order given, so the first one sees the request first.
+func New(middleware ...Middleware) *Router {
+	mux := http.NewServeMux()
+	return &Router{mux: mux, handler: Chain(mux, middleware...)}
+}
+
+// Handle registers h for pattern (see http.ServeMux), behind the given middleware.
+func (r *Router) Handle(pattern string, h http.Handler, middleware ...Middleware) {
+	r.mux.Handle(pattern, Chain(h, middleware...))
+}
+
+// HandleFunc registers f for pattern (see http.ServeMux), behind the given middleware.
+func (r *Router) HandleFunc(pattern string, f http.HandlerFunc, middleware ...Middleware) {
+	r.Handle(pattern, f, middleware...)
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.handler.ServeHTTP(w, req)
+}
+
+// Chain wraps h in middleware, the first of which sees the request first.
+func Chain(h http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,131 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouter_MiddlewareOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	r := New(mark("global"))
+	r.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}, mark("first"), mark("second"))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+	if got := strings.Join(order, ","); got != "global,first,second,handler" {
+		t.Errorf("Unexpected order %s", got)
+	}
+
+	order = nil
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	if w.Code != http.StatusNotFound || strings.Join(order, ",") != "global" {
+		t.Errorf("Expected global middleware before the 404, got %d %v", w.Code, order)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if seen == "" || w.Header().Get("X-Request-Id") != seen {
+		t.Errorf("Expected a generated ID in the context and the response, got %q and %q", seen, w.Header().Get("X-Request-Id"))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-Id", "from-proxy")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if seen != "from-proxy" {
+		t.Errorf("Expected the proxy's ID to be kept, got %q", seen)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 after a panic, got %d", w.Code)
+	}
+}
+
+func TestBufferBody(t *testing.T) {
+	var fromMiddleware, fromHandler string
+	peek := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := Body(r)
+			if err != nil {
+				t.Fatalf("Body failed: %v", err)
+			}
+			fromMiddleware = string(body)
+			next.ServeHTTP(w, r)
+		})
+	}
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fromHandler = string(body)
+	}), BufferBody(10), peek)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload")))
+	if fromMiddleware != "payload" || fromHandler != "payload" {
+		t.Errorf("Expected both to read the body, got %q and %q", fromMiddleware, fromHandler)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload too large")))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a large body, got %d", w.Code)
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	serve := func(token string, prepare func(r *http.Request)) int {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		r.RemoteAddr = "203.0.113.5:4000"
+		prepare(r)
+		w := httptest.NewRecorder()
+		RequireToken(token)(ok).ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := serve("secret", func(r *http.Request) {}); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", code)
+	}
+	if code := serve("secret", func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", code)
+	}
+	if code := serve("secret", func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }); code != http.StatusOK {
+		t.Errorf("Expected 200 for the bearer token, got %d", code)
+	}
+	if code := serve("secret", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }); code != http.StatusOK {
+		t.Errorf("Expected 200 for the token as a basic auth password, got %d", code)
+	}
+
+	// Without a token, only loopback clients are served
+	if code := serve("", func(r *http.Request) { r.Header.Set("Authorization", "Bearer ") }); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for remote clients without a token, got %d", code)
+	}
+	if code := serve("", func(r *http.Request) { r.RemoteAddr = "127.0.0.1:4000" }); code != http.StatusOK {
+		t.Errorf("Expected 200 for loopback clients without a token, got %d", code)
+	}
+}
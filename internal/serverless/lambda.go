@@ -0,0 +1,193 @@
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// runtimeVersion is the AWS Lambda runtime API version path.
+const runtimeVersion = "2018-06-01"
+
+// httpEvent is the Lambda payload of HTTP APIs and function URLs (payload format version 2.0).
+type httpEvent struct {
+	RawPath         string            `json:"rawPath"`
+	RawQueryString  string            `json:"rawQueryString"`
+	Cookies         []string          `json:"cookies"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	RequestContext  struct {
+		DomainName string `json:"domainName"`
+		HTTP       struct {
+			Method   string `json:"method"`
+			SourceIP string `json:"sourceIp"`
+		} `json:"http"`
+	} `json:"requestContext"`
+}
+
+// httpResponse is the Lambda response for HTTP APIs and function URLs.
+type httpResponse struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Cookies         []string          `json:"cookies,omitempty"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// ServeLambda runs h as an AWS Lambda function using the runtime API at runtimeAPI
+// (the AWS_LAMBDA_RUNTIME_API environment variable), for deployments behind a function URL
+// or an HTTP API. It only returns on a runtime API error.
+//
+// afterResponse, if not nil, is called after each response has been delivered and before the next
+// invocation is requested. Lambda freezes the environment only once the next invocation is
+// requested, so work such as Slack mentions acknowledged with an immediate 200 can finish there.
+func ServeLambda(runtimeAPI string, h http.Handler, afterResponse func()) error {
+	client := &http.Client{}
+	base := "http://" + runtimeAPI + "/" + runtimeVersion + "/runtime/invocation/"
+	for {
+		resp, err := client.Get(base + "next")
+		if err != nil {
+			return fmt.Errorf("requesting the next invocation: %w", err)
+		}
+		payload, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("reading the next invocation: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("requesting the next invocation: runtime API returned status %d", resp.StatusCode)
+		}
+		id := resp.Header.Get("Lambda-Runtime-Aws-Request-Id")
+		deadline := time.Now().Add(15 * time.Minute)
+		if ms, err := strconv.ParseInt(resp.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+			deadline = time.UnixMilli(ms)
+		}
+
+		out, err := invoke(h, payload, deadline)
+		if err != nil {
+			log.Printf("[Lambda] Invocation %s failed: %v", id, err)
+			out, _ = json.Marshal(map[string]string{"errorMessage": err.Error(), "errorType": "InvalidEvent"})
+			err = post(client, base+id+"/error", out)
+		} else {
+			err = post(client, base+id+"/response", out)
+		}
+		if err != nil {
+			return err
+		}
+
+		if afterResponse != nil {
+			afterResponse()
+		}
+	}
+}
+
+// invoke converts an HTTP event into a request for h and returns the encoded response.
+func invoke(h http.Handler, payload []byte, deadline time.Time) ([]byte, error) {
+	var event httpEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("decoding event: %w", err)
+	}
+	if event.RequestContext.HTTP.Method == "" {
+		return nil, fmt.Errorf("unsupported event: only function URL and HTTP API (payload version 2.0) events are handled")
+	}
+
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return nil, fmt.Errorf("decoding body: %w", err)
+		}
+	}
+	target := event.RawPath
+	if target == "" {
+		target = "/"
+	}
+	if event.RawQueryString != "" {
+		target += "?" + event.RawQueryString
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, event.RequestContext.HTTP.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("building request: %w", err)
+	}
+	for k, v := range event.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(event.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	req.Host = event.RequestContext.DomainName
+	req.RemoteAddr = event.RequestContext.HTTP.SourceIP
+	req.ContentLength = int64(len(body))
+
+	w := &responseWriter{header: http.Header{}}
+	h.ServeHTTP(w, req)
+	return json.Marshal(w.response())
+}
+
+// post sends a result to the runtime API.
+func post(client *http.Client, url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting to the runtime API: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("runtime API rejected %s with status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// responseWriter buffers a handler's response for the Lambda result.
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header { return w.header }
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// response returns the buffered response; non-UTF-8 bodies are base64 encoded.
+func (w *responseWriter) response() httpResponse {
+	resp := httpResponse{StatusCode: w.status, Headers: map[string]string{}}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+	for k, v := range w.header {
+		if k == "Set-Cookie" {
+			resp.Cookies = v
+			continue
+		}
+		resp.Headers[k] = strings.Join(v, ", ")
+	}
+	if utf8.Valid(w.body.Bytes()) {
+		resp.Body = w.body.String()
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(w.body.Bytes())
+		resp.IsBase64Encoded = true
+	}
+	return resp
+}
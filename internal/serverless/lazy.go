@@ -0,0 +1,18 @@
+package serverless
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Lazy returns a handler that builds the real handler with init on the first request, so a cold
+// start only pays for what the first request needs (browser, API clients, stores) after the
+// runtime has already reported the instance as started.
+func Lazy(init func() http.Handler) http.Handler {
+	var once sync.Once
+	var h http.Handler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { h = init() })
+		h.ServeHTTP(w, r)
+	})
+}
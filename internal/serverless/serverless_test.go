@@ -0,0 +1,107 @@
+package serverless
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	inits := 0
+	h := Lazy(func() http.Handler {
+		inits++
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ready"))
+		})
+	})
+	if inits != 0 {
+		t.Fatal("Expected no initialization before the first request")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Body.String() != "ready" {
+				t.Errorf("Unexpected response %q", w.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+	if inits != 1 {
+		t.Errorf("Expected one initialization, got %d", inits)
+	}
+}
+
+func TestServeLambda(t *testing.T) {
+	events := []string{
+		`{"version":"2.0","rawPath":"/api/summarize","rawQueryString":"dry=1","headers":{"content-type":"application/json","x-token":"t"},
+		  "body":"eyJ1cmwiOiJodHRwczovL2V4YW1wbGUuY29tIn0=","isBase64Encoded":true,
+		  "requestContext":{"domainName":"abc.lambda-url.ap-northeast-1.on.aws","http":{"method":"POST","sourceIp":"203.0.113.1"}}}`,
+		`{"detail-type":"Scheduled Event"}`,
+	}
+	var mu sync.Mutex
+	results := map[string]string{}
+	runtime := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/next") {
+			if len(events) == 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Lambda-Runtime-Aws-Request-Id", string(rune('a'+len(events))))
+			w.Write([]byte(events[0]))
+			events = events[1:]
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		results[strings.TrimPrefix(r.URL.Path, "/2018-06-01/runtime/invocation/")] = string(body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer runtime.Close()
+
+	var got *http.Request
+	var gotBody string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"summary":"ok"}`))
+	})
+	afterResponse := 0
+	err := ServeLambda(strings.TrimPrefix(runtime.URL, "http://"), h, func() { afterResponse++ })
+	if err == nil {
+		t.Fatal("Expected an error once the runtime API fails")
+	}
+
+	if got == nil || got.Method != http.MethodPost || got.URL.Path != "/api/summarize" || got.URL.Query().Get("dry") != "1" {
+		t.Fatalf("Unexpected request %+v", got)
+	}
+	if got.Header.Get("X-Token") != "t" || got.RemoteAddr != "203.0.113.1" || gotBody != `{"url":"https://example.com"}` {
+		t.Errorf("Unexpected request details: header %q, remote %q, body %q", got.Header.Get("X-Token"), got.RemoteAddr, gotBody)
+	}
+
+	var resp httpResponse
+	if err := json.Unmarshal([]byte(results["c/response"]), &resp); err != nil {
+		t.Fatalf("Expected a response for the first invocation, got %v: %v", results, err)
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Body != `{"summary":"ok"}` || resp.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if !strings.Contains(results["b/error"], "unsupported event") {
+		t.Errorf("Expected an error for the unsupported event, got %v", results)
+	}
+	if afterResponse != 2 {
+		t.Errorf("Expected afterResponse after each invocation, got %d", afterResponse)
+	}
+}
@@ -0,0 +1,176 @@
+// Package slackapi creates Slack Web API clients that share a rate limiter and retry rate-limited calls.
+package slackapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/kznrluk/describe-kun/internal/retry"
+)
+
+const (
+	// DefaultPerMinute is the default rate of calls per API method, matching Slack's Tier 3 methods
+	// (chat.update, reactions.add, conversations.replies, ...).
+	DefaultPerMinute = 50
+	// burst is how many calls to one method may go out back to back before the rate applies.
+	burst = 5
+	// defaultRetryAfter is the wait after a 429 without a usable Retry-After header.
+	defaultRetryAfter = time.Second
+)
+
+// shared is the transport of every client created by New: Slack's limits apply to the app as a whole,
+// so posts, progress updates and file uploads of all jobs have to share them.
+var shared = NewTransport(http.DefaultTransport, DefaultPerMinute)
+
+// New creates a Slack client whose calls go through the shared rate limiter.
+func New(token string, options ...slack.Option) *slack.Client {
+	options = append([]slack.Option{slack.OptionHTTPClient(&http.Client{Transport: shared})}, options...)
+	return slack.New(token, options...)
+}
+
+// SetRateLimit sets the calls per minute allowed for each API method of clients created by New.
+// Zero disables limiting; rate-limited calls are still retried.
+func SetRateLimit(perMinute int) {
+	shared.SetRate(perMinute)
+}
+
+// Transport spaces out Slack API calls per method and retries calls answered with 429 Too Many Requests
+// or 503 Service Unavailable according to retry.Current, waiting at least the Retry-After delay and
+// holding back other calls to the same method meanwhile.
+type Transport struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	interval time.Duration        // Time between calls to one method; 0 disables limiting
+	tat      map[string]time.Time // Theoretical arrival time of the next call, by method (GCRA)
+	held     map[string]time.Time // No calls to the method before this time, after a 429
+}
+
+// NewTransport creates a Transport over base allowing perMinute calls per method.
+func NewTransport(base http.RoundTripper, perMinute int) *Transport {
+	t := &Transport{base: base, tat: make(map[string]time.Time), held: make(map[string]time.Time)}
+	t.SetRate(perMinute)
+	return t
+}
+
+// SetRate sets the calls per minute allowed for each method. Zero disables limiting.
+func (t *Transport) SetRate(perMinute int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interval = 0
+	if perMinute > 0 {
+		t.interval = time.Minute / time.Duration(perMinute)
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := methodOf(req)
+	var resp *http.Response
+	err := retry.Do(req.Context(), retry.Current(), "Slack call "+key, func(ctx context.Context) error {
+		if resp != nil {
+			// Retrying: drop the previous response and send the body again
+			resp.Body.Close()
+			resp = nil
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return retry.Permanent(err)
+				}
+				req = req.Clone(ctx)
+				req.Body = body
+			}
+		}
+		if err := wait(ctx, t.reserve(key)); err != nil {
+			return err
+		}
+		var err error
+		if resp, err = t.base.RoundTrip(req); err != nil {
+			// The call may have reached Slack, and posting a message twice is worse than failing
+			return retry.Permanent(err)
+		}
+		// Other server errors may have been processed too
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+			return nil
+		}
+		// Bodies that cannot be replayed (streamed uploads) are left to the caller
+		if req.Body != nil && req.GetBody == nil {
+			return nil
+		}
+		se := retry.FromResponse(resp, fmt.Errorf("%s returned %s", key, resp.Status))
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if se.RetryAfter == 0 {
+				se.RetryAfter = defaultRetryAfter
+			}
+			t.hold(key, se.RetryAfter)
+		}
+		return se
+	})
+	if resp != nil {
+		// Still rate limited after the last attempt, the caller gets Slack's answer
+		return resp, nil
+	}
+	return nil, err
+}
+
+// reserve takes the next slot of the method and returns when it starts. Up to burst calls may start
+// at once; after that, calls start interval apart.
+func (t *Transport) reserve(key string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	start := now
+	if t.interval > 0 {
+		tat := t.tat[key]
+		if tat.Before(now) {
+			tat = now
+		}
+		t.tat[key] = tat.Add(t.interval)
+		if s := tat.Add(-time.Duration(burst-1) * t.interval); s.After(start) {
+			start = s
+		}
+	}
+	if held := t.held[key]; held.After(start) {
+		start = held
+	}
+	return start
+}
+
+// hold keeps all calls to the method back for d, as Slack asked.
+func (t *Transport) hold(key string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); t.held[key].Before(until) {
+		t.held[key] = until
+	}
+}
+
+// wait sleeps until start or until ctx is done.
+func wait(ctx context.Context, start time.Time) error {
+	d := time.Until(start)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// methodOf names the rate limit bucket of req: the API method for Web API calls (Slack limits each
+// method separately), otherwise the host, e.g. for file uploads to their upload URL.
+func methodOf(req *http.Request) string {
+	if method, ok := strings.CutPrefix(req.URL.Path, "/api/"); ok {
+		return method
+	}
+	return req.URL.Host
+}
@@ -0,0 +1,73 @@
+package slackapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransport_RetriesRateLimitedCalls(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(http.DefaultTransport, 0)}
+	start := time.Now()
+	resp, err := client.Post(server.URL+"/api/chat.update", "application/x-www-form-urlencoded", strings.NewReader("text=hello"))
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || len(bodies) != 2 || bodies[1] != "text=hello" {
+		t.Errorf("Expected one retry with the same body, got %d after %q", resp.StatusCode, bodies)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected the retry to wait for Retry-After, waited %s", elapsed)
+	}
+}
+
+func TestTransport_Reserve(t *testing.T) {
+	tr := NewTransport(http.DefaultTransport, 60) // One call per second after the burst
+	now := time.Now()
+	for i := 0; i < burst; i++ {
+		if start := tr.reserve("chat.update"); start.Sub(now) > 100*time.Millisecond {
+			t.Fatalf("Expected call %d of the burst to start right away, starts in %s", i+1, start.Sub(now))
+		}
+	}
+	if start := tr.reserve("chat.update"); start.Sub(now) < 900*time.Millisecond {
+		t.Errorf("Expected the call after the burst to wait a second, starts in %s", start.Sub(now))
+	}
+	if start := tr.reserve("reactions.add"); start.Sub(now) > 100*time.Millisecond {
+		t.Errorf("Expected other methods not to be held back, starts in %s", start.Sub(now))
+	}
+
+	tr.hold("reactions.add", 5*time.Second)
+	if start := tr.reserve("reactions.add"); start.Sub(now) < 4*time.Second {
+		t.Errorf("Expected calls to wait out a 429, starts in %s", start.Sub(now))
+	}
+}
+
+func TestMethodOf(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://slack.com/api/chat.postMessage":   "chat.postMessage",
+		"https://files.slack.com/upload/v1/abc123": "files.slack.com",
+	} {
+		req, _ := http.NewRequest(http.MethodPost, url, nil)
+		if got := methodOf(req); got != want {
+			t.Errorf("methodOf(%s) = %q, want %q", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// historyScopes are the scopes needed to read a thread, depending on the kind of conversation.
+const historyScopes = "`channels:history` (public channels), `groups:history` (private channels), `im:history` or `mpim:history` (DMs)"
+
+// accessGuidance explains how to fix err if it means the bot cannot access channel, and returns ""
+// for any other error. scopes names the scopes the failed call needs, for missing_scope errors.
+func accessGuidance(err error, channel, scopes string) string {
+	var slackErr slack.SlackErrorResponse
+	if !errors.As(err, &slackErr) {
+		return ""
+	}
+	switch slackErr.Err {
+	case "not_in_channel", "channel_not_found":
+		// Private channels and Slack Connect channels hide themselves from apps that were not invited
+		return fmt.Sprintf("I'm not a member of <#%s>. Invite me with `/invite @describe-kun` and try again; private and Slack Connect channels need an explicit invite.", channel)
+	case "missing_scope":
+		return fmt.Sprintf("The app is missing a Slack permission needed here: %s. Ask a workspace admin to add it under \"OAuth & Permissions\" and reinstall the app.", scopes)
+	case "restricted_action", "team_access_not_granted", "access_denied", "ekm_access_denied":
+		return fmt.Sprintf("<#%s> does not allow me to do this. If it is a Slack Connect channel, an admin of the organization hosting it must allow the app there.", channel)
+	case "is_archived":
+		return fmt.Sprintf("<#%s> is archived, so I cannot reply there.", channel)
+	}
+	return ""
+}
+
+// reportAccessProblem tells the user who mentioned the bot why it cannot reply in the channel, by DM
+// since the channel itself is out of reach. Errors unrelated to access are only logged by the caller.
+func (h *SlackHandler) reportAccessProblem(ctx context.Context, event *slackevents.AppMentionEvent, err error) {
+	guidance := accessGuidance(err, event.Channel, "`chat:write`")
+	if guidance == "" {
+		return
+	}
+	if _, postErr := h.postMessage(ctx, event.User, slack.MsgOptionText(":warning: I couldn't reply to your mention. "+guidance, false)); postErr != nil {
+		log.Printf("Error sending access guidance to user %s: %v", event.User, postErr)
+	}
+}
@@ -0,0 +1,65 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestAccessGuidance(t *testing.T) {
+	for code, want := range map[string]string{
+		"not_in_channel":    "/invite",
+		"missing_scope":     "`groups:history`",
+		"restricted_action": "Slack Connect",
+		"is_archived":       "archived",
+	} {
+		err := fmt.Errorf("failed to get conversation replies: %w", slack.SlackErrorResponse{Err: code})
+		if got := accessGuidance(err, "C1", historyScopes); !strings.Contains(got, want) {
+			t.Errorf("%s: expected guidance mentioning %q, got %q", code, want, got)
+		}
+	}
+	if got := accessGuidance(errors.New("timeout"), "C1", historyScopes); got != "" {
+		t.Errorf("Expected no guidance for other errors, got %q", got)
+	}
+}
+
+func TestHandleThreadMention_HistoryUnreadable(t *testing.T) {
+	var mu sync.Mutex
+	var updates []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path == "/conversations.replies" {
+			w.Write([]byte(`{"ok":false,"error":"missing_scope"}`))
+			return
+		}
+		mu.Lock()
+		updates = append(updates, r.Form.Get("text"))
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"channel":"C1","ts":"9.0"}`))
+	}))
+	defer srv.Close()
+	h := &SlackHandler{
+		SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/")),
+		AppCore:     app.NewApp(stubFetcher{text: "page"}, stubLLM{}),
+	}
+
+	event := &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "2.0", ThreadTimeStamp: "1.0", Text: "<@B1> what is this?"}
+	if !h.handleThreadMention(context.Background(), event) {
+		t.Fatal("Expected the mention to be answered without the thread history")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	final := updates[len(updates)-1]
+	if !strings.Contains(final, "couldn't read the earlier messages") || !strings.Contains(final, "Newsletter summary") {
+		t.Errorf("Expected an answer with a note about the history, got %q", final)
+	}
+}
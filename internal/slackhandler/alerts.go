@@ -0,0 +1,90 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/alert"
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// mentionKey is the context key for the Slack message a request came from.
+type mentionKey struct{}
+
+// mentionRef identifies a Slack message and its author.
+type mentionRef struct {
+	Channel   string
+	Timestamp string
+	User      string
+}
+
+// withMention records the message a request came from, so summary hooks can link back to it.
+func withMention(ctx context.Context, event *slackevents.AppMentionEvent) context.Context {
+	return context.WithValue(ctx, mentionKey{}, mentionRef{Channel: event.Channel, Timestamp: event.TimeStamp, User: event.User})
+}
+
+// mentionFrom returns the message recorded by withMention, if any.
+func mentionFrom(ctx context.Context) (mentionRef, bool) {
+	ref, ok := ctx.Value(mentionKey{}).(mentionRef)
+	return ref, ok
+}
+
+// SetAlerts cross-posts a note to channel whenever summarized content mentions any of keywords,
+// e.g. the product's name or a competitor's. It applies to every page summary the App makes.
+// Calling it again replaces the settings; an empty channel or keyword list disables alerts.
+func (h *SlackHandler) SetAlerts(channel string, keywords []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.alertChannel = channel
+	h.alertMatcher = alert.NewMatcher(keywords)
+	if channel == "" || h.alertMatcher.Empty() || h.alertHook {
+		return
+	}
+	h.alertHook = true
+	h.AppCore.AddSummaryHook(func(ctx context.Context, r *app.Result) {
+		h.mu.RLock()
+		channel, matcher := h.alertChannel, h.alertMatcher
+		h.mu.RUnlock()
+		if channel != "" && !matcher.Empty() {
+			h.postAlert(ctx, channel, matcher, r)
+		}
+	})
+}
+
+// postAlert posts an alert for r to channel if it matches.
+func (h *SlackHandler) postAlert(ctx context.Context, channel string, matcher *alert.Matcher, r *app.Result) {
+	found := matcher.Match(r.Metadata.Title, r.Content, r.Summary)
+	if len(found) == 0 {
+		return
+	}
+
+	title := r.Metadata.Title
+	if title == "" {
+		title = r.URL
+	}
+	source := "via API"
+	if ref, ok := mentionFrom(ctx); ok {
+		if ref.Channel == channel {
+			// The summary is already visible in the alerts channel
+			return
+		}
+		source = fmt.Sprintf("in <#%s>", ref.Channel)
+		permalink, err := h.SlackClient.GetPermalinkContext(ctx, &slack.PermalinkParameters{Channel: ref.Channel, Ts: ref.Timestamp})
+		if err != nil {
+			log.Printf("[Alerts] Error getting permalink for %s/%s: %v", ref.Channel, ref.Timestamp, err)
+		} else {
+			source = fmt.Sprintf("in <#%s> (<%s|message>)", ref.Channel, permalink)
+		}
+	}
+
+	text := fmt.Sprintf(":rotating_light: Keyword alert: %s\n<%s|%s> was summarized %s", strings.Join(found, ", "), r.URL, title, source)
+	if _, err := h.postMessage(ctx, channel, slack.MsgOptionText(text, false)); err != nil {
+		log.Printf("[Alerts] Error posting alert for %s: %v", r.URL, err)
+		return
+	}
+	log.Printf("[Alerts] Posted alert for %s (keywords: %v)", r.URL, found)
+}
@@ -0,0 +1,84 @@
+package slackhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// stubFetcher returns fixed page text.
+type stubFetcher struct {
+	text string
+}
+
+func (f stubFetcher) Fetch(ctx context.Context, req fetcher.FetchRequest) (*fetcher.FetchResult, error) {
+	return &fetcher.FetchResult{Text: f.text, FinalURL: req.URL, Metadata: fetcher.Metadata{Title: "Market report"}}, nil
+}
+
+func TestSetAlerts(t *testing.T) {
+	var posts []string
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/chat.getPermalink":
+			w.Write([]byte(`{"ok":true,"permalink":"https://example.slack.com/archives/C1/p1"}`))
+		default:
+			posts = append(posts, r.Form.Get("channel")+" "+r.Form.Get("text"))
+			w.Write([]byte(`{"ok":true,"channel":"C9","ts":"1.0"}`))
+		}
+	}))
+	defer slackAPI.Close()
+
+	h := &SlackHandler{
+		SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(slackAPI.URL+"/")),
+		AppCore:     app.NewApp(stubFetcher{text: "Competitor X launched a new product"}, stubLLM{}),
+	}
+	h.SetAlerts("C9", []string{"competitor x", "unrelated"})
+
+	ctx := withMention(context.Background(), &slackevents.AppMentionEvent{Channel: "C1", TimeStamp: "1.0", User: "U1"})
+	if _, err := h.AppCore.ProcessURL(ctx, "https://example.com/report", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("Expected one alert, got %q", posts)
+	}
+	for _, want := range []string{"C9 ", "Keyword alert: competitor x\n", "<https://example.com/report|Market report>", "<#C1>", "https://example.slack.com/archives/C1/p1"} {
+		if !strings.Contains(posts[0], want) {
+			t.Errorf("Expected %q in alert, got %q", want, posts[0])
+		}
+	}
+
+	// Summaries made in the alerts channel itself are not cross-posted
+	posts = nil
+	if _, err := h.AppCore.ProcessURL(withMention(context.Background(), &slackevents.AppMentionEvent{Channel: "C9", TimeStamp: "2.0", User: "U1"}), "https://example.com/report", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("Expected no alert for the alerts channel, got %q", posts)
+	}
+
+	// Reloaded settings replace the previous ones without registering the hook twice
+	posts = nil
+	h.SetAlerts("C8", []string{"competitor x"})
+	if _, err := h.AppCore.ProcessURL(ctx, "https://example.com/report", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if len(posts) != 1 || !strings.HasPrefix(posts[0], "C8 ") {
+		t.Errorf("Expected one alert in the new channel, got %q", posts)
+	}
+	posts = nil
+	h.SetAlerts("", nil)
+	if _, err := h.AppCore.ProcessURL(ctx, "https://example.com/report", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("Expected alerts to be disabled, got %q", posts)
+	}
+}
@@ -0,0 +1,70 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// batchMinURLs is how many URLs a mention needs before each one gets its own TL;DR reply
+// instead of a single combined message.
+const batchMinURLs = 4
+
+// summarizeBatch posts a one-line TL;DR of each URL as its own thread reply and returns the index
+// linking to them, which replaces the loading message, and how many URLs were summarized. Long link dumps stay readable this way: the
+// index fits on one screen and each reply can be read or followed up on its own.
+func (h *SlackHandler) summarizeBatch(ctx context.Context, event *slackevents.AppMentionEvent, urls []string, progress *ProgressUpdater) (string, int) {
+	index := []string{fmt.Sprintf(":bookmark_tabs: %d links, one reply each:", len(urls))}
+	summarized := 0
+	for i, url := range urls {
+		progress.UpdateProgress(fmt.Sprintf(":loading: Processing URL %d/%d: %s", i+1, len(urls), url))
+
+		tldr, err := h.AppCore.QuickSummary(ctx, url, "")
+		if cancelledBy(ctx) != "" {
+			// Replies posted before the cancellation stay listed
+			index = append(index, cancelledMessage(ctx))
+			break
+		}
+		if errors.Is(err, budget.ErrExhausted) {
+			log.Printf("Budget exhausted while processing URL %s: %v", url, err)
+			index = append(index, budgetExhaustedMessage(err))
+			break
+		}
+		if err != nil {
+			log.Printf("Error processing URL %s: %v", url, err)
+			index = append(index, fmt.Sprintf("%d. %s — :x: %v", i+1, url, err))
+			continue
+		}
+		summarized++
+
+		ts, err := h.postMessage(
+			ctx,
+			event.Channel,
+			slack.MsgOptionText(fmt.Sprintf("*%d/%d* %s\n> %s", i+1, len(urls), url, tldr), false),
+			slack.MsgOptionTS(event.TimeStamp),
+			// A preview per reply would bury the TL;DRs
+			slack.MsgOptionDisableLinkUnfurl(),
+		)
+		if err != nil {
+			log.Printf("Error posting TL;DR of %s to Slack: %v", url, err)
+			index = append(index, fmt.Sprintf("%d. %s — %s", i+1, url, tldr))
+			continue
+		}
+
+		entry := fmt.Sprintf("%d. %s", i+1, url)
+		permalink, err := h.SlackClient.GetPermalinkContext(ctx, &slack.PermalinkParameters{Channel: event.Channel, Ts: ts})
+		if err != nil {
+			log.Printf("Error getting permalink for %s/%s: %v", event.Channel, ts, err)
+		} else if permalink != "" {
+			entry += fmt.Sprintf(" (<%s|TL;DR>)", permalink)
+		}
+		index = append(index, entry)
+	}
+	return strings.Join(index, "\n"), summarized
+}
@@ -0,0 +1,36 @@
+package slackhandler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestHandleNewMention_Batch(t *testing.T) {
+	client, posts := recordingSlack(t)
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+
+	event := &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> <https://a.example> <https://b.example> <https://c.example> <https://d.example>"}
+	h.handleAppMention(context.Background(), event, nil)
+
+	var replies, updates []string
+	for _, post := range posts() {
+		switch {
+		case strings.HasPrefix(post, "/chat.postMessage "):
+			replies = append(replies, post)
+		case strings.HasPrefix(post, "/chat.update "):
+			updates = append(updates, post)
+		}
+	}
+	// The loading message, then one reply per URL
+	if len(replies) != 5 || !strings.Contains(replies[1], "*1/4* https://a.example\n> Newsletter summary") {
+		t.Fatalf("Expected one TL;DR reply per URL, got %q", replies)
+	}
+	index := updates[len(updates)-1]
+	if !strings.Contains(index, "4 links, one reply each") || !strings.Contains(index, "4. https://d.example") {
+		t.Errorf("Expected the loading message to become the index, got %q", index)
+	}
+}
@@ -0,0 +1,89 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/timeout"
+	"github.com/slack-go/slack"
+)
+
+// Slack limits on blocks.
+const (
+	maxHeaderChars  = 150 // Text of a header block
+	maxSectionChars = 3000
+	maxBlocks       = 50 // Blocks of one message
+)
+
+// summaryBlocks lays out the structured summary of r as Slack blocks. rest is text to show after the
+// summary, such as the footer; the title header and additions (related links, numbers table, ...) of
+// r.Summary are kept as they are.
+func summaryBlocks(r *app.Result, rest string) []slack.Block {
+	s := r.Structured
+	header, additions := r.Summary, ""
+	if i := strings.Index(r.Summary, s.Mrkdwn()); i >= 0 {
+		header, additions = r.Summary[:i], r.Summary[i+len(s.Mrkdwn()):]
+	}
+
+	title := s.Title
+	if title == "" {
+		title = r.URL
+	}
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, truncateRunes(title, maxHeaderChars), true, false)),
+		slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, r.URL, false, false)),
+	}
+	section := func(text string) {
+		if text = strings.TrimSpace(text); text != "" {
+			blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, truncateRunes(text, maxSectionChars), false, false), nil, nil))
+		}
+	}
+	section(header)
+	section(s.AnswerToQuestion)
+	var tldr strings.Builder
+	tldr.WriteString(":white_check_mark: *3行要約*\n")
+	for _, line := range s.TLDR {
+		fmt.Fprintf(&tldr, "• %s\n", line)
+	}
+	section(tldr.String())
+	if len(s.Sections) > 0 {
+		blocks = append(blocks, slack.NewDividerBlock())
+	}
+	for _, sec := range s.Sections {
+		section(fmt.Sprintf("*%s*\n%s", sec.Heading, sec.Body))
+	}
+	section(additions)
+	if strings.TrimSpace(rest) != "" {
+		blocks = append(blocks, slack.NewDividerBlock())
+		section(rest)
+	}
+	if len(blocks) > maxBlocks {
+		blocks = blocks[:maxBlocks]
+	}
+	return blocks
+}
+
+// truncateRunes cuts s to at most n characters, marking the cut with an ellipsis.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// UpdateBlocks replaces the Slack message with blocks; text is shown in notifications and clients
+// without block support.
+func (p *ProgressUpdater) UpdateBlocks(text string, blocks []slack.Block) {
+	ctx := context.WithoutCancel(p.ctx)
+	err := timeout.Run(ctx, timeout.SlackPost, p.postTimeout, func(ctx context.Context) error {
+		_, _, _, err := p.client.UpdateMessageContext(ctx, p.channel, p.timestamp, slack.MsgOptionText(text, false), slack.MsgOptionBlocks(blocks...))
+		return err
+	})
+	if err != nil {
+		log.Printf("Error updating message with blocks: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package slackhandler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/slack-go/slack"
+)
+
+func TestSummaryBlocks(t *testing.T) {
+	s := &app.Summary{
+		Title:    "Release",
+		TLDR:     []string{"One", "Two", "Three"},
+		Sections: []app.Section{{Heading: "Details", Body: "More"}},
+	}
+	r := &app.Result{URL: "https://example.com", Summary: "*Release*\n\n" + s.Mrkdwn() + "\n\n:link: Related links", Structured: s}
+	blocks := summaryBlocks(r, "\n\n_footer_")
+
+	var types []string
+	var text strings.Builder
+	for _, b := range blocks {
+		types = append(types, string(b.BlockType()))
+		if sec, ok := b.(*slack.SectionBlock); ok {
+			text.WriteString(sec.Text.Text + "\n")
+		}
+	}
+	want := "header context section section divider section section divider section"
+	if got := strings.Join(types, " "); got != want {
+		t.Errorf("Expected blocks %q, got %q", want, got)
+	}
+	for _, part := range []string{"*Release*", "• One\n", "*Details*\nMore", ":link: Related links", "_footer_"} {
+		if !strings.Contains(text.String(), part) {
+			t.Errorf("Expected %q in the blocks, got %q", part, text.String())
+		}
+	}
+	if strings.Contains(text.String(), ":memo:") {
+		t.Errorf("Expected the plain summary to be replaced, got %q", text.String())
+	}
+}
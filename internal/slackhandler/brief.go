@@ -0,0 +1,96 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// maxDocumentBytes limits the size of an attached text file downloaded for a brief.
+const maxDocumentBytes = 1 << 20
+
+// briefCommandRegex matches a mention asking for one brief of everything in it, e.g. "@bot brief: what changed?".
+var briefCommandRegex = regexp.MustCompile(`(?i)^(brief|まとめて)\b\s*:?\s*(.*)$`)
+
+// textFiles returns the plain text files among files, such as notes, logs, CSV and JSON.
+func textFiles(files []slack.File) []slack.File {
+	var texts []slack.File
+	for _, f := range files {
+		if strings.HasPrefix(f.Mimetype, "text/") || f.Mimetype == "application/json" {
+			texts = append(texts, f)
+		}
+	}
+	return texts
+}
+
+// handleBriefCommand answers a brief command in event with one brief of all its URLs and attached files,
+// and reports whether the mention was a brief command. posted tells whether a brief was posted.
+func (h *SlackHandler) handleBriefCommand(ctx context.Context, event *slackevents.AppMentionEvent, files []slack.File) (handled, posted bool) {
+	m := briefCommandRegex.FindStringSubmatch(mentionQuestion(event.Text))
+	if m == nil {
+		return false, false
+	}
+	urls := extractURLs(event.Text)
+	texts, images := textFiles(files), imageFiles(files)
+	if len(urls)+len(texts)+len(images) == 0 {
+		// "brief" alone is just a question
+		return false, false
+	}
+	log.Printf("Briefing %d URL(s), %d text file(s) and %d image(s) for user %s", len(urls), len(texts), len(images), event.User)
+
+	loadingTS, postErr := h.postLoading(ctx, event.Channel, event.TimeStamp)
+	if postErr != nil {
+		log.Printf("Error posting loading message to Slack: %v", postErr)
+		h.reportAccessProblem(ctx, event, postErr)
+		return true, false
+	}
+	progressUpdater := h.newProgressUpdater(ctx, event.Channel, loadingTS)
+
+	if len(texts)+len(images) > 0 {
+		progressUpdater.UpdateProgress(fmt.Sprintf(":loading: Downloading %d attached file(s)...", len(texts)+len(images)))
+	}
+	var documents []app.Document
+	for _, f := range texts {
+		data, err := h.downloadFile(ctx, f, maxDocumentBytes)
+		if err != nil {
+			progressUpdater.UpdateProgress(fmt.Sprintf("Error reading the attached files: %v", err))
+			return true, false
+		}
+		documents = append(documents, app.Document{Name: f.Name, Content: string(data)})
+	}
+	var imgs []app.Image
+	for _, f := range images {
+		data, err := h.downloadFile(ctx, f, maxImageBytes)
+		if err != nil {
+			progressUpdater.UpdateProgress(fmt.Sprintf("Error reading the attached files: %v", err))
+			return true, false
+		}
+		imgs = append(imgs, app.Image{Name: f.Name, Data: data, MIMEType: f.Mimetype})
+	}
+
+	brief, err := h.AppCore.ProcessBrief(ctx, urls, documents, imgs, m[2], progressUpdater.UpdateProgress)
+	switch {
+	case cancelledBy(ctx) != "":
+		progressUpdater.UpdateProgress(cancelledMessage(ctx))
+		return true, false
+	case errors.Is(err, budget.ErrExhausted):
+		log.Printf("Budget exhausted while writing a brief: %v", err)
+		progressUpdater.UpdateProgress(budgetExhaustedMessage(err))
+		return true, false
+	case err != nil:
+		log.Printf("Error writing a brief: %v", err)
+		progressUpdater.UpdateProgress(fmt.Sprintf("Error writing a brief: %v", err))
+		return true, false
+	}
+	progressUpdater.UpdateProgress(h.withFooter(event.Channel, h.withBudgetWarning(event.Channel, brief)))
+	log.Printf("Successfully posted a brief to channel %s", event.Channel)
+	return true, true
+}
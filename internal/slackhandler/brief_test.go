@@ -0,0 +1,34 @@
+package slackhandler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestBriefCommand(t *testing.T) {
+	client, posts := recordingSlack(t)
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+
+	h.handleAppMention(context.Background(), &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0",
+		Text: "<@B1> brief: which should we use? <https://example.com/a> <https://example.com/b>"}, nil)
+	all := posts()
+	want := "Newsletter summary\n\n*Sources*\n[1] https://example.com/a\n[2] https://example.com/b"
+	if final := all[len(all)-1]; !strings.HasSuffix(final, want) {
+		t.Errorf("Expected one brief citing both pages, got %q", final)
+	}
+	if strings.Contains(strings.Join(all, "\n"), "---") {
+		t.Error("Expected no per-URL summaries")
+	}
+}
+
+func TestTextFiles(t *testing.T) {
+	files := []slack.File{{Name: "notes.md", Mimetype: "text/markdown"}, {Name: "data.json", Mimetype: "application/json"}, {Name: "shot.png", Mimetype: "image/png"}}
+	if texts := textFiles(files); len(texts) != 2 || texts[1].Name != "data.json" {
+		t.Errorf("Expected the Markdown and JSON files, got %+v", texts)
+	}
+}
@@ -0,0 +1,118 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// cancelCommandRegex matches a thread reply asking the bot to stop, e.g. "@bot cancel".
+var cancelCommandRegex = regexp.MustCompile(`(?i)^(cancel|stop|キャンセル|中止)$`)
+
+// threadJob is a mention being handled in a thread.
+type threadJob struct {
+	cancel context.CancelCauseFunc
+}
+
+// cancelledError is the cancellation cause of jobs stopped with a cancel command.
+type cancelledError struct {
+	user string
+}
+
+func (e *cancelledError) Error() string {
+	return fmt.Sprintf("cancelled by %s", e.user)
+}
+
+// threadOf returns the timestamp of the thread replies to event are posted in.
+func threadOf(event *slackevents.AppMentionEvent) string {
+	if event.ThreadTimeStamp != "" {
+		return event.ThreadTimeStamp
+	}
+	return event.TimeStamp
+}
+
+// isCancelCommand reports whether event is a cancel command. Only replies in a thread can cancel,
+// since a new mention has nothing running in its thread yet.
+func isCancelCommand(event *slackevents.AppMentionEvent) bool {
+	return event.ThreadTimeStamp != "" && cancelCommandRegex.MatchString(mentionQuestion(event.Text))
+}
+
+// trackJob registers a mention handled in the thread threadTS of channel and returns the context it
+// must run with, which a cancel command in the same thread cancels. done must be called once it finishes.
+func (h *SlackHandler) trackJob(channel, threadTS string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	job := &threadJob{cancel: cancel}
+	key := channel + "/" + threadTS
+
+	h.jobsMu.Lock()
+	if h.jobs == nil {
+		h.jobs = make(map[string][]*threadJob)
+	}
+	h.jobs[key] = append(h.jobs[key], job)
+	h.jobsMu.Unlock()
+
+	return ctx, func() {
+		h.jobsMu.Lock()
+		defer h.jobsMu.Unlock()
+		for i, j := range h.jobs[key] {
+			if j == job {
+				h.jobs[key] = append(h.jobs[key][:i], h.jobs[key][i+1:]...)
+				break
+			}
+		}
+		if len(h.jobs[key]) == 0 {
+			delete(h.jobs, key)
+		}
+		cancel(nil)
+	}
+}
+
+// cancelThread cancels the mentions queued or running in the thread threadTS of channel on behalf of
+// user and returns how many there were.
+func (h *SlackHandler) cancelThread(channel, threadTS, user string) int {
+	key := channel + "/" + threadTS
+	h.jobsMu.Lock()
+	jobs := h.jobs[key]
+	delete(h.jobs, key)
+	h.jobsMu.Unlock()
+
+	for _, job := range jobs {
+		job.cancel(&cancelledError{user: user})
+	}
+	return len(jobs)
+}
+
+// handleCancelCommand cancels the jobs in the thread of event and replies with the outcome.
+func (h *SlackHandler) handleCancelCommand(event *slackevents.AppMentionEvent) {
+	n := h.cancelThread(event.Channel, event.ThreadTimeStamp, event.User)
+	log.Printf("User %s cancelled %d job(s) in thread %s of channel %s", event.User, n, event.ThreadTimeStamp, event.Channel)
+
+	text := "There is nothing running in this thread to cancel."
+	if n > 0 {
+		text = fmt.Sprintf(":octagonal_sign: Cancelled %d request(s) in this thread.", n)
+	}
+	ctx, cancel := h.requestContext(context.Background(), event.Channel)
+	defer cancel()
+	if _, err := h.postMessage(ctx, event.Channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(event.ThreadTimeStamp)); err != nil {
+		log.Printf("Error replying to cancel command: %v", err)
+	}
+}
+
+// cancelledBy returns the user who cancelled the job running with ctx, or "" if it was not cancelled.
+func cancelledBy(ctx context.Context) string {
+	var cancelled *cancelledError
+	if errors.As(context.Cause(ctx), &cancelled) {
+		return cancelled.user
+	}
+	return ""
+}
+
+// cancelledMessage is the reply replacing the progress message of a cancelled job.
+func cancelledMessage(ctx context.Context) string {
+	return fmt.Sprintf(":octagonal_sign: Cancelled by <@%s>.", cancelledBy(ctx))
+}
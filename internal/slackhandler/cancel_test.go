@@ -0,0 +1,145 @@
+package slackhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/queue"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// blockingFetcher blocks until the request is cancelled.
+type blockingFetcher struct {
+	started chan struct{}
+}
+
+func (f blockingFetcher) Fetch(ctx context.Context, req fetcher.FetchRequest) (*fetcher.FetchResult, error) {
+	close(f.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// recordingSlack starts a Slack API stub recording the text of every post and update, and the name of every reaction.
+func recordingSlack(t *testing.T) (*slack.Client, func() []string) {
+	var mu sync.Mutex
+	var posts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		text := r.Form.Get("text")
+		if strings.HasPrefix(r.URL.Path, "/reactions.") {
+			text = r.Form.Get("name")
+		}
+		mu.Lock()
+		posts = append(posts, r.URL.Path+" "+text)
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"channel":"C1","ts":"9.0"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/")), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), posts...)
+	}
+}
+
+func TestCancelCommand_Queued(t *testing.T) {
+	defer func(d time.Duration) { queuedReplyDelay = d }(queuedReplyDelay)
+	queuedReplyDelay = 0
+	client, posts := recordingSlack(t)
+	jobs := queue.New(1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	jobs.Submit(queue.Job{Priority: queue.Interactive, Name: "blocker", Run: func(ctx context.Context) {
+		close(started)
+		<-release
+	}})
+	<-started
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+	h.SetQueue(jobs)
+
+	h.dispatch(reqmeta.Metadata{}, &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "2.0", ThreadTimeStamp: "1.0", Text: "<@B1> what about https://example.com"}, nil)
+	if jobs.Len() != 1 {
+		t.Fatalf("Expected the mention to be queued, got %d", jobs.Len())
+	}
+	waitForPosts(t, posts, 1) // The queue position reply
+	h.handleCancelCommand(&slackevents.AppMentionEvent{User: "U2", Channel: "C1", TimeStamp: "3.0", ThreadTimeStamp: "1.0", Text: "<@B1> cancel"})
+	waitForPosts(t, posts, 3) // The cancel reply, and the queue position reply replaced
+
+	close(release)
+	jobs.Close()
+	got := strings.Join(posts(), "\n")
+	if !strings.Contains(got, "Cancelled 1 request(s)") || !strings.Contains(got, "/chat.update :octagonal_sign: Cancelled by <@U2>") {
+		t.Errorf("Expected the cancellation to be reported, got %q", got)
+	}
+	if strings.Contains(got, ":loading:") {
+		t.Errorf("Expected the cancelled mention not to run, got %q", got)
+	}
+
+	// Nothing is left to cancel
+	h.handleCancelCommand(&slackevents.AppMentionEvent{User: "U2", Channel: "C1", TimeStamp: "4.0", ThreadTimeStamp: "1.0", Text: "<@B1> cancel"})
+	if got := posts(); !strings.Contains(got[len(got)-1], "nothing running") {
+		t.Errorf("Expected a nothing-to-cancel reply, got %q", got)
+	}
+}
+
+// waitForPosts waits until the Slack stub received at least n requests.
+func waitForPosts(t *testing.T, posts func() []string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(posts()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d Slack requests, got %q", n, posts())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCancelCommand_Running(t *testing.T) {
+	client, posts := recordingSlack(t)
+	f := blockingFetcher{started: make(chan struct{})}
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(f, stubLLM{})}
+
+	event := &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> https://example.com"}
+	ctx, done := h.trackJob(event.Channel, threadOf(event))
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		defer done()
+		h.handleAppMention(ctx, event, nil)
+	}()
+
+	<-f.started
+	if n := h.cancelThread("C1", "1.0", "U2"); n != 1 {
+		t.Errorf("Expected one job to be cancelled, got %d", n)
+	}
+	<-finished
+	got := posts()
+	if last := got[len(got)-1]; !strings.HasPrefix(last, "/chat.update") || !strings.Contains(last, "Cancelled by <@U2>") {
+		t.Errorf("Expected the progress message to report the cancellation, got %q", got)
+	}
+}
+
+func TestIsCancelCommand(t *testing.T) {
+	for text, want := range map[string]bool{
+		"<@B1> cancel":           true,
+		"<@B1>  Stop ":           true,
+		"<@B1> キャンセル":            true,
+		"<@B1> cancel the plan?": false,
+	} {
+		if got := isCancelCommand(&slackevents.AppMentionEvent{ThreadTimeStamp: "1.0", Text: text}); got != want {
+			t.Errorf("isCancelCommand(%q) = %v, want %v", text, got, want)
+		}
+	}
+	if isCancelCommand(&slackevents.AppMentionEvent{Text: "<@B1> cancel"}) {
+		t.Error("Expected cancel outside a thread not to be a command")
+	}
+}
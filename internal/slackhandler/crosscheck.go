@@ -0,0 +1,54 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// crossCheckCommandRegex matches a mention asking to check an article against other pages,
+// e.g. "@bot crosscheck <article> <url> <url>".
+var crossCheckCommandRegex = regexp.MustCompile(`(?i)^(cross-?check|fact-?check|ファクトチェック|裏取り)\b\s*:?\s*(.*)$`)
+
+// handleCrossCheckCommand answers a cross-check command in event by checking the claims of the article at its
+// first URL against the other URLs, and reports whether the mention was a cross-check command. posted tells
+// whether a cross-check was posted.
+func (h *SlackHandler) handleCrossCheckCommand(ctx context.Context, event *slackevents.AppMentionEvent) (handled, posted bool) {
+	m := crossCheckCommandRegex.FindStringSubmatch(mentionQuestion(event.Text))
+	urls := extractURLs(event.Text)
+	if m == nil || len(urls) == 0 {
+		return false, false
+	}
+	log.Printf("Cross-checking %s against %d URL(s) for user %s", urls[0], len(urls)-1, event.User)
+
+	loadingTS, postErr := h.postLoading(ctx, event.Channel, event.TimeStamp)
+	if postErr != nil {
+		log.Printf("Error posting loading message to Slack: %v", postErr)
+		h.reportAccessProblem(ctx, event, postErr)
+		return true, false
+	}
+	progressUpdater := h.newProgressUpdater(ctx, event.Channel, loadingTS)
+
+	check, err := h.AppCore.ProcessCrossCheck(ctx, urls, progressUpdater.UpdateProgress)
+	switch {
+	case cancelledBy(ctx) != "":
+		progressUpdater.UpdateProgress(cancelledMessage(ctx))
+		return true, false
+	case errors.Is(err, budget.ErrExhausted):
+		log.Printf("Budget exhausted while cross-checking: %v", err)
+		progressUpdater.UpdateProgress(budgetExhaustedMessage(err))
+		return true, false
+	case err != nil:
+		log.Printf("Error cross-checking: %v", err)
+		progressUpdater.UpdateProgress(fmt.Sprintf("Error cross-checking: %v", err))
+		return true, false
+	}
+	progressUpdater.UpdateProgress(h.withFooter(event.Channel, h.withBudgetWarning(event.Channel, check)))
+	log.Printf("Successfully posted a cross-check to channel %s", event.Channel)
+	return true, true
+}
@@ -0,0 +1,139 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// summarizedMessagesSize is how many summarized messages are remembered for edit detection.
+const summarizedMessagesSize = 1000
+
+// resummarizeCommandRegex matches a thread reply asking to summarize the edited parent message again.
+var resummarizeCommandRegex = regexp.MustCompile(`(?i)^(resummarize|再要約)$`)
+
+// summarizedMessage is a mention whose URLs were summarized.
+type summarizedMessage struct {
+	urls   []string
+	edited string // Text of the message after an edit changed its URLs; empty until then
+}
+
+// summarizedMessages remembers recently summarized mentions by channel/ts, oldest evicted first.
+type summarizedMessages struct {
+	mu       sync.Mutex
+	messages map[string]*summarizedMessage
+	order    []string
+}
+
+// record remembers that the URLs of the message at key were summarized.
+func (s *summarizedMessages) record(key string, urls []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.messages == nil {
+		s.messages = make(map[string]*summarizedMessage)
+	}
+	if _, ok := s.messages[key]; !ok {
+		s.order = append(s.order, key)
+		if len(s.order) > summarizedMessagesSize {
+			delete(s.messages, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+	s.messages[key] = &summarizedMessage{urls: urls}
+}
+
+// edit records text as the new text of the message at key and returns the URLs summarized before,
+// if the edit changed them.
+func (s *summarizedMessages) edit(key, text string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message := s.messages[key]
+	if message == nil || sameURLs(message.urls, extractURLs(text)) {
+		return nil, false
+	}
+	message.edited = text
+	return message.urls, true
+}
+
+// takeEdited returns the edited text of the message at key and forgets the edit.
+func (s *summarizedMessages) takeEdited(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message := s.messages[key]
+	if message == nil || message.edited == "" {
+		return "", false
+	}
+	text := message.edited
+	message.edited = ""
+	return text, true
+}
+
+// sameURLs reports whether a and b hold the same URLs, in any order.
+func sameURLs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, url := range a {
+		seen[url] = true
+	}
+	for _, url := range b {
+		if !seen[url] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetEditDetection enables offering a new summary when a summarized message is edited to link
+// elsewhere. It requires subscribing to the message events of the channels the bot is in.
+func (h *SlackHandler) SetEditDetection(enabled bool) {
+	h.editDetection = enabled
+}
+
+// handleMessageChanged offers to summarize an edited message again when the edit changed the URLs
+// that were summarized, so the summary is not left describing a link that is gone.
+func (h *SlackHandler) handleMessageChanged(ev *slackevents.MessageEvent) {
+	if !h.editDetection || ev.SubType != "message_changed" || ev.Message == nil {
+		return
+	}
+	// Unfurls also arrive as message_changed, but leave the URLs as they are
+	before, changed := h.summarized.edit(ev.Channel+"/"+ev.Message.TimeStamp, ev.Message.Text)
+	if !changed {
+		return
+	}
+	log.Printf("Summarized message %s in channel %s was edited to link elsewhere", ev.Message.TimeStamp, ev.Channel)
+
+	text := fmt.Sprintf(":pencil2: This message was edited, so the summary above may describe a link that is no longer there (%s). Mention me with `resummarize` in this thread to summarize the new version.", strings.Join(before, ", "))
+	ctx, cancel := h.requestContext(context.Background(), ev.Channel)
+	defer cancel()
+	if _, err := h.postMessage(ctx, ev.Channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(ev.Message.TimeStamp), slack.MsgOptionDisableLinkUnfurl()); err != nil {
+		log.Printf("Error offering to summarize edited message: %v", err)
+	}
+}
+
+// resummarizeEvent returns the mention to handle instead of event if it asks to summarize the edited
+// parent message of its thread again.
+func (h *SlackHandler) resummarizeEvent(event *slackevents.AppMentionEvent) (*slackevents.AppMentionEvent, bool) {
+	if event.ThreadTimeStamp == "" || !resummarizeCommandRegex.MatchString(mentionQuestion(event.Text)) {
+		return nil, false
+	}
+	text, ok := h.summarized.takeEdited(event.Channel + "/" + event.ThreadTimeStamp)
+	if !ok {
+		return nil, false
+	}
+	// Handled as if the parent message had just been posted, so the new summary lands in the same thread
+	return &slackevents.AppMentionEvent{
+		Type:      event.Type,
+		User:      event.User,
+		Text:      text,
+		TimeStamp: event.ThreadTimeStamp,
+		Channel:   event.Channel,
+	}, true
+}
@@ -0,0 +1,51 @@
+package slackhandler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestEditDetection(t *testing.T) {
+	client, posts := recordingSlack(t)
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+	h.SetEditDetection(true)
+
+	mention := &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> <https://old.example>"}
+	if !h.handleAppMention(context.Background(), mention, nil) {
+		t.Fatal("Expected the mention to be summarized")
+	}
+	summarized := len(posts())
+
+	// An unfurl keeps the URL, so nothing is offered
+	h.handleMessageChanged(&slackevents.MessageEvent{Channel: "C1", SubType: "message_changed", Message: &slackevents.MessageEvent{TimeStamp: "1.0", Text: "<@B1> <https://old.example>"}})
+	h.handleMessageChanged(&slackevents.MessageEvent{Channel: "C1", SubType: "message_changed", Message: &slackevents.MessageEvent{TimeStamp: "1.0", Text: "<@B1> <https://new.example>"}})
+	got := posts()[summarized:]
+	if len(got) != 1 || !strings.Contains(got[0], "This message was edited") || !strings.Contains(got[0], "https://old.example") {
+		t.Fatalf("Expected one offer to summarize again, got %q", got)
+	}
+
+	command := &slackevents.AppMentionEvent{User: "U2", Channel: "C1", TimeStamp: "3.0", ThreadTimeStamp: "1.0", Text: "<@B1> resummarize"}
+	edited, ok := h.resummarizeEvent(command)
+	if !ok || edited.Text != "<@B1> <https://new.example>" || edited.TimeStamp != "1.0" || edited.ThreadTimeStamp != "" {
+		t.Fatalf("Expected the edited parent to be summarized, got %+v", edited)
+	}
+	if _, ok := h.resummarizeEvent(command); ok {
+		t.Error("Expected the edit to be summarized only once")
+	}
+}
+
+func TestEditDetection_Disabled(t *testing.T) {
+	client, posts := recordingSlack(t)
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+
+	h.handleAppMention(context.Background(), &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> <https://old.example>"}, nil)
+	before := len(posts())
+	h.handleMessageChanged(&slackevents.MessageEvent{Channel: "C1", SubType: "message_changed", Message: &slackevents.MessageEvent{TimeStamp: "1.0", Text: "<@B1> <https://new.example>"}})
+	if len(posts()) != before {
+		t.Errorf("Expected edits to be ignored by default, got %q", posts()[before:])
+	}
+}
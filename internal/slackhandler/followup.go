@@ -0,0 +1,108 @@
+package slackhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// followUpActionPrefix starts the action IDs of follow-up question buttons, which must be unique in a block.
+const followUpActionPrefix = "follow_up_"
+
+// postFollowUps suggests questions to dig deeper into the summary r posted for event, as buttons in its
+// thread. Clicking one asks the question in the thread (see HandleInteraction). Nothing is posted if the
+// follow-ups feature is off or no questions could be suggested.
+func (h *SlackHandler) postFollowUps(ctx context.Context, event *slackevents.AppMentionEvent, r *app.Result) {
+	questions, err := h.AppCore.FollowUpQuestions(ctx, r)
+	if err != nil {
+		reqmeta.Logf(ctx, "[FollowUps] Not suggesting follow-up questions for %s: %v", r.URL, err)
+		return
+	}
+	if len(questions) == 0 {
+		return
+	}
+	if _, err := h.postMessage(ctx, event.Channel,
+		slack.MsgOptionText("Suggested follow-up questions: "+strings.Join(questions, " / "), false),
+		slack.MsgOptionBlocks(followUpBlocks(questions)...),
+		slack.MsgOptionTS(event.TimeStamp),
+	); err != nil {
+		reqmeta.Logf(ctx, "[FollowUps] Error posting follow-up questions: %v", err)
+	}
+}
+
+// followUpBlocks lays out questions as a row of buttons whose values are the questions.
+func followUpBlocks(questions []string) []slack.Block {
+	buttons := make([]slack.BlockElement, len(questions))
+	for i, q := range questions {
+		buttons[i] = slack.NewButtonBlockElement(fmt.Sprintf("%s%d", followUpActionPrefix, i), q,
+			slack.NewTextBlockObject(slack.PlainTextType, truncateRunes(q, 75), false, false))
+	}
+	return []slack.Block{
+		slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, ":mag: Dig deeper:", false, false)),
+		slack.NewActionBlock("follow_ups", buttons...),
+	}
+}
+
+// HandleInteraction handles clicks on the app's interactive components, sent by Slack to the interactivity
+// Request URL. A click on a follow-up question asks that question in the thread, as if the user had
+// mentioned the app with it. Like HandleEvent, it must be mounted behind VerifySignature.
+func (h *SlackHandler) HandleInteraction(w http.ResponseWriter, r *http.Request) {
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(r.FormValue("payload")), &callback); err != nil {
+		log.Printf("Error parsing interaction payload: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	// Slack expects an answer within 3 seconds, so the question is answered in the background
+	w.WriteHeader(http.StatusOK)
+	if callback.Type != slack.InteractionTypeBlockActions {
+		return
+	}
+	for _, action := range callback.ActionCallback.BlockActions {
+		if !strings.HasPrefix(action.ActionID, followUpActionPrefix) || action.Value == "" {
+			continue
+		}
+		threadTS := callback.Message.ThreadTimestamp
+		if threadTS == "" {
+			threadTS = callback.Container.ThreadTs
+		}
+		meta := reqmeta.Metadata{
+			RequestID: reqmeta.From(r.Context()).RequestID,
+			Source:    reqmeta.SourceSlack,
+			Workspace: callback.Team.ID,
+			Trigger:   reqmeta.TriggerFollowUp,
+		}
+		go h.askFollowUp(meta, callback.User.ID, callback.Channel.ID, threadTS, action.Value)
+	}
+}
+
+// askFollowUp posts question in the thread threadTS on behalf of user, so the thread shows what was asked,
+// and answers it like a mention in the thread.
+func (h *SlackHandler) askFollowUp(meta reqmeta.Metadata, user, channel, threadTS, question string) {
+	ctx, cancel := h.requestContext(context.Background(), channel)
+	defer cancel()
+	ts, err := h.postMessage(ctx, channel,
+		slack.MsgOptionText(fmt.Sprintf(":speech_balloon: <@%s> asked: %s", user, question), false),
+		slack.MsgOptionTS(threadTS),
+	)
+	if err != nil {
+		log.Printf("[FollowUps] Error posting the question of %s: %v", user, err)
+		return
+	}
+	log.Printf("[FollowUps] %s asked %q in %s/%s", user, question, channel, threadTS)
+	h.dispatch(meta, &slackevents.AppMentionEvent{
+		User:            user,
+		Channel:         channel,
+		TimeStamp:       ts,
+		ThreadTimeStamp: threadTS,
+		Text:            question,
+	}, nil)
+}
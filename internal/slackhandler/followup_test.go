@@ -0,0 +1,72 @@
+package slackhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// followUpLLM suggests follow-up questions and answers everything else with "Answer".
+type followUpLLM struct{}
+
+func (followUpLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	if strings.Contains(messages[0].Text(), "dig deeper") {
+		return &llm.Response{Text: `{"questions": ["What does it cost?", "Who is it for?"]}`}, nil
+	}
+	return &llm.Response{Text: "Answer"}, nil
+}
+
+func TestFollowUps(t *testing.T) {
+	client, posts := recordingSlack(t)
+	core := app.NewApp(stubFetcher{text: "page"}, followUpLLM{})
+	flags, err := feature.New(map[string]bool{feature.FollowUps: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.SetFeatures(flags)
+	h := &SlackHandler{SlackClient: client, AppCore: core}
+
+	// The questions are posted in the thread after the summary
+	h.handleAppMention(context.Background(), &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> <https://example.com>"}, nil)
+	all := posts()
+	if last := all[len(all)-1]; last != "/chat.postMessage Suggested follow-up questions: What does it cost? / Who is it for?" {
+		t.Fatalf("Expected the follow-up questions last, got %q", all)
+	}
+
+	// Clicking one asks it in the thread
+	payload := `{"type": "block_actions", "user": {"id": "U2"}, "channel": {"id": "C1"}, "team": {"id": "T1"},
+		"message": {"ts": "2.0", "thread_ts": "1.0"},
+		"actions": [{"block_id": "follow_ups", "action_id": "follow_up_1", "type": "button", "value": "Who is it for?"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(url.Values{"payload": {payload}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.HandleInteraction(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the click to be acknowledged, got %d", w.Code)
+	}
+
+	var asked []string
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		asked = posts()[len(all):]
+		if len(asked) > 0 && strings.HasSuffix(asked[len(asked)-1], " Answer") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the question to be answered, got %q", asked)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if asked[0] != "/chat.postMessage :speech_balloon: <@U2> asked: Who is it for?" {
+		t.Errorf("Expected the question posted in the thread first, got %q", asked)
+	}
+}
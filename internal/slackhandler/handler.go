@@ -1,9 +1,9 @@
 package slackhandler
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,8 +11,21 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/kznrluk/describe-kun/internal/alert"
 	"github.com/kznrluk/describe-kun/internal/app" // Assuming app provides the core processing logic
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/footer"
+	"github.com/kznrluk/describe-kun/internal/history"
+	"github.com/kznrluk/describe-kun/internal/queue"
+	"github.com/kznrluk/describe-kun/internal/readinglist"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/kznrluk/describe-kun/internal/slackapi"
+	"github.com/kznrluk/describe-kun/internal/subscription"
+	"github.com/kznrluk/describe-kun/internal/timeout"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 )
@@ -22,6 +35,44 @@ type SlackHandler struct {
 	SlackClient   *slack.Client
 	SigningSecret string
 	AppCore       *app.App // Reference to the core application logic
+
+	postTimeout    time.Duration // Limit for a single post or update, 0 means none
+	requestTimeout time.Duration // Limit for handling one mention end to end, 0 means none
+
+	queue  *queue.Queue    // Worker pool for mentions; nil runs each mention in its own goroutine
+	budget *budget.Tracker // Optional LLM budget, used for warnings in replies
+
+	statusReactions bool // Whether mentions get lifecycle reactions; see statusReaction
+
+	threadMaxMessages int           // Latest replies read as thread context besides the parent; 0 reads all
+	threadMaxAge      time.Duration // Replies older than this are not read as thread context; 0 reads all
+
+	threadCache threadCache // Recently read threads, see getThreadContext
+
+	editDetection bool               // Whether edits changing a summarized message's URLs are noticed
+	summarized    summarizedMessages // Recently summarized mentions, for edit detection
+
+	triggerPrefix string    // Message prefix triggering the bot like a mention; empty disables it
+	botUserOnce   sync.Once // Guards botUser
+	botUser       string    // The bot's own user ID, see botUserID
+
+	newsletterChannel string // Channel receiving newsletter summaries; empty disables inbound email
+	newsletterToken   string // Shared secret required on inbound email requests
+
+	subscriptions *subscription.Store // Users' topic subscriptions; nil disables subscription commands
+
+	readingList    *readinglist.Store // Users' saved links; nil disables bookmarks
+	readingHistory *history.Store     // Where the summaries of saved links are looked up; nil saves links alone
+
+	jobsMu sync.Mutex
+	jobs   map[string][]*threadJob // Mentions queued or running, by thread; see trackJob
+
+	mu               sync.RWMutex        // Guards the settings below, which may be reloaded while requests run
+	channelLanguages map[string][]string // Output languages per channel; channels not listed use the model's default
+	alertChannel     string              // Channel keyword alerts are cross-posted to; empty disables alerts
+	alertMatcher     *alert.Matcher      // Keywords alerts are raised for
+	alertHook        bool                // Whether the alert summary hook is registered
+	footers          *footer.Footers     // Notes appended to every response; nil appends none
 }
 
 // NewSlackHandler creates a new SlackHandler
@@ -32,7 +83,7 @@ func NewSlackHandler(appCore *app.App) (*SlackHandler, error) {
 		log.Fatal("Error: SLACK_BOT_TOKEN and SLACK_SIGNING_SECRET environment variables must be set")
 	}
 
-	client := slack.New(botToken)
+	client := slackapi.New(botToken)
 
 	return &SlackHandler{
 		SlackClient:   client,
@@ -41,15 +92,114 @@ func NewSlackHandler(appCore *app.App) (*SlackHandler, error) {
 	}, nil
 }
 
-// HandleEvent handles incoming HTTP requests from Slack
-func (h *SlackHandler) HandleEvent(w http.ResponseWriter, r *http.Request) {
-	verifier, err := slack.NewSecretsVerifier(r.Header, h.SigningSecret)
-	if err != nil {
-		log.Printf("Error creating secrets verifier: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+// SetTimeouts sets the limits for a single Slack post and for a whole request. Zero disables a limit.
+func (h *SlackHandler) SetTimeouts(post, request time.Duration) {
+	h.postTimeout = post
+	h.requestTimeout = request
+}
+
+// SetStatusReactions enables reactions on mentions showing whether they were received, are being
+// processed, or were answered or failed.
+func (h *SlackHandler) SetStatusReactions(enabled bool) {
+	h.statusReactions = enabled
+}
+
+// SetQueue makes mentions run on q as interactive jobs.
+func (h *SlackHandler) SetQueue(q *queue.Queue) {
+	h.queue = q
+}
+
+// SetBudget sets the tracker whose warnings are appended to replies.
+// Enforcement itself happens in the budget.Guard wrapping the LLM.
+func (h *SlackHandler) SetBudget(t *budget.Tracker) {
+	h.budget = t
+}
+
+// SetChannelLanguages sets the output languages per channel, e.g. {"C123": {"ja", "en"}} for a bilingual team.
+// It is safe to call while requests are running.
+func (h *SlackHandler) SetChannelLanguages(languages map[string][]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.channelLanguages = languages
+}
+
+// SetFooters sets the notes appended to every response. It is safe to call while requests are running.
+func (h *SlackHandler) SetFooters(f *footer.Footers) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.footers = f
+}
+
+// withFooter appends channel's footer, in its output languages, to text.
+func (h *SlackHandler) withFooter(channel, text string) string {
+	h.mu.RLock()
+	note := h.footers.For(channel, h.channelLanguages[channel])
+	h.mu.RUnlock()
+	if note == "" {
+		return text
 	}
+	return text + "\n\n" + note
+}
+
+// requestContext returns the context bounding the handling of one mention in channel.
+// LLM usage made with it is accounted to the channel's budget, output uses the channel's languages,
+// and the channel's feature flag overrides apply.
+func (h *SlackHandler) requestContext(parent context.Context, channel string) (context.Context, context.CancelFunc) {
+	ctx := budget.WithScope(parent, channel)
+	ctx = reqmeta.Update(ctx, func(m *reqmeta.Metadata) { m.Channel = channel })
+	h.mu.RLock()
+	languages := h.channelLanguages[channel]
+	h.mu.RUnlock()
+	if len(languages) > 0 {
+		ctx = app.WithLanguages(ctx, languages)
+	}
+	if h.requestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, h.requestTimeout)
+}
+
+// withBudgetWarning appends a budget warning for channel to text, if one applies.
+func (h *SlackHandler) withBudgetWarning(channel, text string) string {
+	if h.budget == nil {
+		return text
+	}
+	if warning := h.budget.Warning(channel); warning != "" {
+		return text + "\n\n" + warning
+	}
+	return text
+}
 
+// budgetExhaustedMessage explains a request refused by the budget.
+func budgetExhaustedMessage(err error) string {
+	return fmt.Sprintf(":no_entry: The LLM budget is exhausted, so this request was not processed (%v). Please try again later or contact the bot administrator.", err)
+}
+
+// postMessage posts a message bounded by the Slack post timeout and returns its timestamp.
+func (h *SlackHandler) postMessage(ctx context.Context, channel string, options ...slack.MsgOption) (string, error) {
+	var ts string
+	err := timeout.Run(ctx, timeout.SlackPost, h.postTimeout, func(ctx context.Context) error {
+		var err error
+		_, ts, err = h.SlackClient.PostMessageContext(ctx, channel, options...)
+		return err
+	})
+	return ts, err
+}
+
+// newProgressUpdater creates a ProgressUpdater editing the message at ts.
+func (h *SlackHandler) newProgressUpdater(ctx context.Context, channel, ts string) *ProgressUpdater {
+	return &ProgressUpdater{
+		ctx:         ctx,
+		client:      h.SlackClient,
+		channel:     channel,
+		timestamp:   ts,
+		postTimeout: h.postTimeout,
+	}
+}
+
+// HandleEvent handles incoming HTTP requests from Slack.
+// It trusts the request, so it must be mounted behind VerifySignature (see Register).
+func (h *SlackHandler) HandleEvent(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("Error reading request body: %v", err)
@@ -58,15 +208,11 @@ func (h *SlackHandler) HandleEvent(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	// Verify the request signature
-	if _, err := verifier.Write(body); err != nil {
-		log.Printf("Error writing body to verifier: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-	if err := verifier.Ensure(); err != nil {
-		log.Printf("Error verifying request signature: %v", err)
-		w.WriteHeader(http.StatusUnauthorized)
+	// Slack redelivers events it got no answer for within 3 seconds, e.g. during a serverless cold start.
+	// The first delivery is still being processed, so acknowledge such retries without handling them again.
+	if r.Header.Get("X-Slack-Retry-Num") != "" && r.Header.Get("X-Slack-Retry-Reason") == "http_timeout" {
+		log.Printf("Ignoring Slack retry %s of a slow delivery", r.Header.Get("X-Slack-Retry-Num"))
+		w.WriteHeader(http.StatusOK)
 		return
 	}
 
@@ -95,15 +241,56 @@ func (h *SlackHandler) HandleEvent(w http.ResponseWriter, r *http.Request) {
 
 	// Handle Callback Events (like app_mention)
 	if eventsAPIEvent.Type == slackevents.CallbackEvent {
+		// Background work keeps the ID of the delivery it came from, so its logs can be matched up
+		meta := reqmeta.Metadata{
+			RequestID: reqmeta.From(r.Context()).RequestID,
+			Source:    reqmeta.SourceSlack,
+			Workspace: eventsAPIEvent.TeamID,
+		}
 		innerEvent := eventsAPIEvent.InnerEvent
 		switch ev := innerEvent.Data.(type) {
 		case *slackevents.AppMentionEvent:
 			log.Printf("Received AppMention event: User %s in channel %s said %s", ev.User, ev.Channel, ev.Text)
 			// Acknowledge the event immediately to prevent Slack retries
 			w.WriteHeader(http.StatusOK)
-			// Process the mention in a separate goroutine to avoid blocking
-			go h.handleAppMention(ev)
-			return // Important: Return after starting goroutine
+			// Process the mention in the background to avoid blocking
+			meta.Trigger = reqmeta.TriggerMention
+			h.dispatch(meta, ev, eventFiles(body))
+			return // Important: Return after dispatching
+		case *slackevents.MessageEvent:
+			w.WriteHeader(http.StatusOK)
+			if ev.SubType == "message_changed" {
+				go h.handleMessageChanged(ev)
+				return
+			}
+			if m := h.readingListCommand(ev); m != nil {
+				go h.handleReadingListCommand(ev, m)
+				return
+			}
+			if mention, ok := h.triggerMention(r.Context(), ev); ok {
+				log.Printf("Received trigger prefix message: User %s in channel %s said %s", ev.User, ev.Channel, ev.Text)
+				meta.Trigger = reqmeta.TriggerPrefix
+				h.dispatch(meta, mention, eventFiles(body))
+			}
+			return
+		case *slackevents.ReactionAddedEvent:
+			w.WriteHeader(http.StatusOK)
+			if ev.Reaction == bookmarkReaction {
+				go h.handleBookmark(ev.User, ev.Item, true)
+			}
+			return
+		case *slackevents.ReactionRemovedEvent:
+			w.WriteHeader(http.StatusOK)
+			if ev.Reaction == bookmarkReaction {
+				go h.handleBookmark(ev.User, ev.Item, false)
+			}
+			return
+		case *slackevents.AppHomeOpenedEvent:
+			w.WriteHeader(http.StatusOK)
+			if ev.Tab == "home" {
+				go h.publishHome(ev.User)
+			}
+			return
 		default:
 			log.Printf("Received unhandled event type: %T", ev)
 		}
@@ -113,63 +300,203 @@ func (h *SlackHandler) HandleEvent(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// handleAppMention processes the AppMention event
-func (h *SlackHandler) handleAppMention(event *slackevents.AppMentionEvent) {
+// dispatch hands the mention to the worker pool, or to a new goroutine when no pool is configured.
+// Mentions are interactive, so they run ahead of any queued background work. Cancel commands are
+// handled right away instead, since they must not wait behind the jobs they cancel.
+// meta describes the delivery; the mention's channel and user are added to it.
+func (h *SlackHandler) dispatch(meta reqmeta.Metadata, event *slackevents.AppMentionEvent, files []slack.File) {
+	if isCancelCommand(event) {
+		go h.handleCancelCommand(event)
+		return
+	}
+
+	ctx, done := h.trackJob(event.Channel, threadOf(event))
+	if meta.RequestID == "" {
+		meta.RequestID = reqmeta.NewID()
+	}
+	meta.Channel = event.Channel
+	meta.User = event.User
+	ctx = reqmeta.With(ctx, meta)
+	reply := &queuedReply{}
+	ctx = context.WithValue(ctx, queuedReplyKey{}, reply)
+
+	var status *statusReaction
+	if h.statusReactions {
+		status = h.newStatusReaction(event)
+		go status.set(stageReceived, reactionReceived)
+		// A mention cancelled while queued never runs, so its reaction is cleared here
+		context.AfterFunc(ctx, func() {
+			if cancelledBy(ctx) != "" {
+				status.finish(ctx, false)
+			}
+		})
+	}
+
+	name := fmt.Sprintf("mention %s/%s", event.Channel, event.TimeStamp)
+	err := h.enqueue(ctx, queue.Interactive, name, func(ctx context.Context) {
+		defer done()
+		if status == nil {
+			h.handleAppMention(ctx, event, files)
+			return
+		}
+		status.set(stageProcessing, reactionProcessing)
+		status.finish(ctx, h.handleAppMention(ctx, event, files))
+	})
+	if err != nil {
+		done()
+		reqmeta.Logf(ctx, "Error queueing mention from user %s: %v", event.User, err)
+		if status != nil {
+			status.set(stageFinished, reactionFailed)
+		}
+		return
+	}
+	if h.queue != nil {
+		// A silent wait makes people mention again, which only makes the backlog longer
+		go h.reportQueued(ctx, reply, event, name, queuedReplyDelay)
+	}
+}
+
+// enqueue runs fn on the worker pool with the given priority, or in a new goroutine when no pool is configured.
+// Cancelling ctx skips fn if it has not started yet.
+func (h *SlackHandler) enqueue(ctx context.Context, priority queue.Priority, name string, fn func(ctx context.Context)) error {
+	if h.queue == nil {
+		go fn(ctx)
+		return nil
+	}
+	return h.queue.Submit(queue.Job{
+		Priority: priority,
+		Name:     name,
+		Run:      fn,
+		Ctx:      ctx,
+	})
+}
+
+// handleAppMention processes the AppMention event and reports whether it was answered
+func (h *SlackHandler) handleAppMention(ctx context.Context, event *slackevents.AppMentionEvent, files []slack.File) bool {
+	if h.handleSubscriptionCommand(event) {
+		h.deleteQueuedReply(ctx, event.Channel)
+		return true
+	}
+
+	if edited, ok := h.resummarizeEvent(event); ok {
+		ctx = reqmeta.Update(ctx, func(m *reqmeta.Metadata) { m.Trigger = reqmeta.TriggerResummarize })
+		return h.handleNewMention(ctx, edited, nil)
+	}
+
 	// Check if this is a thread mention or a new mention
 	if event.ThreadTimeStamp != "" {
 		// This is a mention within a thread
-		h.handleThreadMention(event)
-	} else {
-		// This is a new mention (not in a thread)
-		h.handleNewMention(event)
+		return h.handleThreadMention(ctx, event)
 	}
+	// This is a new mention (not in a thread)
+	return h.handleNewMention(ctx, event, files)
 }
 
-// handleNewMention handles mentions that are not part of a thread (original behavior)
-func (h *SlackHandler) handleNewMention(event *slackevents.AppMentionEvent) {
+// handleNewMention handles mentions that are not part of a thread (original behavior).
+// It reports whether at least one summary was posted.
+func (h *SlackHandler) handleNewMention(ctx context.Context, event *slackevents.AppMentionEvent, files []slack.File) bool {
+	ctx, cancel := h.requestContext(ctx, event.Channel)
+	defer cancel()
+	ctx = withMention(ctx, event)
+	if style := mentionStyle(event.Text); style != "" {
+		ctx = app.WithStyle(ctx, style)
+	}
+	if handled, posted := h.handleBriefCommand(ctx, event, files); handled {
+		return posted
+	}
+	if handled, posted := h.handleQuickstartCommand(ctx, event); handled {
+		return posted
+	}
+	if handled, posted := h.handleReportCommand(ctx, event); handled {
+		return posted
+	}
+	if handled, posted := h.handleCrossCheckCommand(ctx, event); handled {
+		return posted
+	}
+	if handled, posted := h.handleContextPackCommand(ctx, event); handled {
+		return posted
+	}
+
 	urls := extractURLs(event.Text)
-	if len(urls) == 0 {
-		log.Printf("No URLs found in mention from user %s in channel %s", event.User, event.Channel)
+	images := imageFiles(files)
+	if len(urls) == 0 && len(images) == 0 {
+		log.Printf("No URLs or images found in mention from user %s in channel %s", event.User, event.Channel)
+		h.deleteQueuedReply(ctx, event.Channel)
 		// Post a message indicating no URLs were found
-		_, _, postErr := h.SlackClient.PostMessage(
+		_, postErr := h.postMessage(
+			ctx,
 			event.Channel,
-			slack.MsgOptionText("No URLs found in your message. Please include a URL for me to summarize.", false),
+			slack.MsgOptionText("No URLs or images found in your message. Please include a URL or an image for me to summarize.", false),
 			slack.MsgOptionTS(event.TimeStamp),
 		)
 		if postErr != nil {
 			log.Printf("Error posting no URLs message to Slack: %v", postErr)
 		}
-		return
+		return false
 	}
 
-	log.Printf("Found URLs: %v in mention from user %s", urls, event.User)
+	log.Printf("Found URLs: %v and %d image(s) in mention from user %s", urls, len(images), event.User)
 
 	// Post initial loading message
-	_, loadingTS, postErr := h.SlackClient.PostMessage(
-		event.Channel,
-		slack.MsgOptionText(":loading:", false),
-		slack.MsgOptionTS(event.TimeStamp),
-	)
+	loadingTS, postErr := h.postLoading(ctx, event.Channel, event.TimeStamp)
 	if postErr != nil {
 		log.Printf("Error posting loading message to Slack: %v", postErr)
-		return
+		h.reportAccessProblem(ctx, event, postErr)
+		return false
 	}
 
 	// Create progress updater
-	progressUpdater := &ProgressUpdater{
-		client:    h.SlackClient,
-		channel:   event.Channel,
-		timestamp: loadingTS,
+	progressUpdater := h.newProgressUpdater(ctx, event.Channel, loadingTS)
+
+	var allSummaries []string
+	var structured *app.Result // Set when a single page was summarized as data
+	var single *app.Result     // Set when a single page was summarized, to suggest follow-up questions on
+	summarized := 0
+
+	// Summarize attached images (screenshots, slides) with the vision model
+	if len(images) > 0 {
+		summary, err := h.summarizeImages(ctx, images, mentionQuestion(event.Text), progressUpdater.UpdateProgress)
+		switch {
+		case cancelledBy(ctx) != "":
+			progressUpdater.UpdateProgress(cancelledMessage(ctx))
+			return false
+		case errors.Is(err, budget.ErrExhausted):
+			log.Printf("Budget exhausted while processing images: %v", err)
+			progressUpdater.UpdateProgress(budgetExhaustedMessage(err))
+			return false
+		case err != nil:
+			log.Printf("Error processing images: %v", err)
+			progressUpdater.UpdateProgress(fmt.Sprintf("Error summarizing attached images: %v", err))
+		default:
+			allSummaries = append(allSummaries, fmt.Sprintf("Summary of attached image(s):\n%s", summary))
+			summarized++
+		}
+	}
+
+	if len(urls) >= batchMinURLs {
+		index, n := h.summarizeBatch(ctx, event, urls, progressUpdater)
+		allSummaries = append(allSummaries, index)
+		summarized += n
+		urls = nil
 	}
 
 	// Process URLs with progress updates
-	var allSummaries []string
 	for i, url := range urls {
 		// Update progress
 		progressMsg := fmt.Sprintf(":loading: Processing URL %d/%d: %s", i+1, len(urls), url)
 		progressUpdater.UpdateProgress(progressMsg)
 
-		summary, err := h.AppCore.ProcessURLWithProgress(context.Background(), url, "", progressUpdater.UpdateProgress)
+		result, err := h.AppCore.SummarizeWithProgress(ctx, fetcher.FetchRequest{URL: url}, "", progressUpdater.UpdateProgress)
+		if cancelledBy(ctx) != "" {
+			// Summaries finished before the cancellation are still worth keeping
+			allSummaries = append(allSummaries, cancelledMessage(ctx))
+			break
+		}
+		if errors.Is(err, budget.ErrExhausted) {
+			log.Printf("Budget exhausted while processing URL %s: %v", url, err)
+			allSummaries = append(allSummaries, budgetExhaustedMessage(err))
+			break
+		}
 		if err != nil {
 			log.Printf("Error processing URL %s: %v", url, err)
 			errorMsg := fmt.Sprintf("Error summarizing %s: %v", url, err)
@@ -177,51 +504,78 @@ func (h *SlackHandler) handleNewMention(event *slackevents.AppMentionEvent) {
 			continue
 		}
 
-		allSummaries = append(allSummaries, fmt.Sprintf("Summary for %s:\n%s", url, summary))
+		allSummaries = append(allSummaries, fmt.Sprintf("Summary for %s:\n%s", url, result.Summary))
+		summarized++
+		if len(urls) == 1 && len(images) == 0 {
+			single = result
+			if result.Structured != nil {
+				structured = result
+			}
+		}
 	}
 
 	// Post final result by updating the loading message
 	if len(allSummaries) > 0 {
 		finalResponse := strings.Join(allSummaries, "\n\n---\n\n")
-		progressUpdater.UpdateProgress(finalResponse)
+		text := h.withFooter(event.Channel, h.withBudgetWarning(event.Channel, finalResponse))
+		if structured != nil && len(allSummaries) == 1 {
+			// A summary generated as data is laid out as blocks, with the text as the notification fallback
+			progressUpdater.UpdateBlocks(text, summaryBlocks(structured, strings.TrimPrefix(text, allSummaries[0])))
+		} else {
+			progressUpdater.UpdateProgress(text)
+		}
 		log.Printf("Successfully posted summaries to channel %s", event.Channel)
+		if single != nil && len(allSummaries) == 1 {
+			h.postFollowUps(ctx, event, single)
+		}
 	} else {
 		progressUpdater.UpdateProgress("No summaries could be generated.")
 	}
+	if summarized > 0 && h.editDetection {
+		h.summarized.record(event.Channel+"/"+event.TimeStamp, extractURLs(event.Text))
+	}
+	return summarized > 0
 }
 
-// handleThreadMention handles mentions within a thread
-func (h *SlackHandler) handleThreadMention(event *slackevents.AppMentionEvent) {
+// handleThreadMention handles mentions within a thread and reports whether it was answered
+func (h *SlackHandler) handleThreadMention(ctx context.Context, event *slackevents.AppMentionEvent) bool {
 	log.Printf("Handling thread mention from user %s in channel %s, thread %s", event.User, event.Channel, event.ThreadTimeStamp)
 
+	ctx, cancel := h.requestContext(ctx, event.Channel)
+	defer cancel()
+
 	// Post initial loading message
-	_, loadingTS, postErr := h.SlackClient.PostMessage(
-		event.Channel,
-		slack.MsgOptionText(":loading:", false),
-		slack.MsgOptionTS(event.ThreadTimeStamp),
-	)
+	loadingTS, postErr := h.postLoading(ctx, event.Channel, event.ThreadTimeStamp)
 	if postErr != nil {
 		log.Printf("Error posting loading message to Slack: %v", postErr)
-		return
+		h.reportAccessProblem(ctx, event, postErr)
+		return false
 	}
 
 	// Create progress updater
-	progressUpdater := &ProgressUpdater{
-		client:    h.SlackClient,
-		channel:   event.Channel,
-		timestamp: loadingTS,
-	}
+	progressUpdater := h.newProgressUpdater(ctx, event.Channel, loadingTS)
 
 	// Update progress: Getting thread context
 	progressUpdater.UpdateProgress(":loading: Getting thread context...")
 
 	// Get thread context
-	threadContext, err := h.getThreadContext(event.Channel, event.ThreadTimeStamp)
+	threadContext, err := h.getThreadContext(ctx, event.Channel, event.ThreadTimeStamp)
+	if cancelledBy(ctx) != "" {
+		progressUpdater.UpdateProgress(cancelledMessage(ctx))
+		return false
+	}
+	// Without access to the history, the question can still be answered from the mention itself
+	var historyNote string
+	if guidance := accessGuidance(err, event.Channel, historyScopes); guidance != "" {
+		log.Printf("Cannot read thread %s in channel %s, answering from the mention only: %v", event.ThreadTimeStamp, event.Channel, err)
+		historyNote = fmt.Sprintf(":warning: I couldn't read the earlier messages of this thread, so this answer is based on your message only. %s\n\n", guidance)
+		threadContext, err = &app.ThreadContext{URLContents: map[string]string{}}, nil
+	}
 	if err != nil {
 		log.Printf("Error getting thread context: %v", err)
 		errorMsg := fmt.Sprintf("Error getting thread context: %v", err)
 		progressUpdater.UpdateProgress(errorMsg)
-		return
+		return false
 	}
 
 	// Extract URLs from the latest mention
@@ -232,35 +586,55 @@ func (h *SlackHandler) handleThreadMention(event *slackevents.AppMentionEvent) {
 
 	// Process the thread mention
 	response, err := h.AppCore.ProcessThreadMentionWithProgress(
-		context.Background(),
+		ctx,
 		threadContext,
 		event.Text,
 		latestMentionURLs,
 		progressUpdater.UpdateProgress,
 	)
+	if cancelledBy(ctx) != "" {
+		progressUpdater.UpdateProgress(cancelledMessage(ctx))
+		return false
+	}
+	if errors.Is(err, budget.ErrExhausted) {
+		log.Printf("Budget exhausted while processing thread mention: %v", err)
+		progressUpdater.UpdateProgress(budgetExhaustedMessage(err))
+		return false
+	}
 	if err != nil {
 		log.Printf("Error processing thread mention: %v", err)
 		errorMsg := fmt.Sprintf("Error processing thread mention: %v", err)
 		progressUpdater.UpdateProgress(errorMsg)
-		return
+		return false
 	}
 
 	// Post the final response by updating the loading message
-	progressUpdater.UpdateProgress(response)
+	progressUpdater.UpdateProgress(h.withFooter(event.Channel, h.withBudgetWarning(event.Channel, historyNote+response)))
 	log.Printf("Successfully posted thread response to channel %s", event.Channel)
+	h.suggestThreadTitle(ctx, event, threadContext, response)
+	return true
 }
 
-// getThreadContext retrieves all messages and URLs from a thread
-func (h *SlackHandler) getThreadContext(channel, threadTS string) (*app.ThreadContext, error) {
-	// Get conversation replies (thread messages)
-	replies, _, _, err := h.SlackClient.GetConversationReplies(&slack.GetConversationRepliesParameters{
-		ChannelID: channel,
-		Timestamp: threadTS,
-		Inclusive: true, // Include the parent message
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get conversation replies: %w", err)
+// getThreadContext retrieves all messages and URLs from a thread. Threads read in the last few minutes
+// are cached, so a follow-up question only reads the new messages and fetches the URLs they add.
+func (h *SlackHandler) getThreadContext(ctx context.Context, channel, threadTS string) (*app.ThreadContext, error) {
+	// Get conversation replies (thread messages), only the new ones if the thread was read recently
+	key := channel + "/" + threadTS
+	cached := h.threadCache.get(key)
+	var replies []slack.Message
+	if cached != nil {
+		newer, err := h.threadMessages(ctx, channel, threadTS, cached.latest())
+		if err != nil {
+			return nil, err
+		}
+		replies = append(append([]slack.Message{}, cached.messages...), newer...)
+	} else {
+		var err error
+		if replies, err = h.threadMessages(ctx, channel, threadTS, ""); err != nil {
+			return nil, err
+		}
 	}
+	replies = h.limitThread(replies)
 
 	threadContext := &app.ThreadContext{
 		Messages:    make([]string, 0),
@@ -277,26 +651,45 @@ func (h *SlackHandler) getThreadContext(channel, threadTS string) (*app.ThreadCo
 		// Extract URLs from this message
 		urls := extractURLs(message.Text)
 		for _, url := range urls {
-			if !allURLs[url] {
-				allURLs[url] = true
-				threadContext.URLs = append(threadContext.URLs, url)
+			if allURLs[url] {
+				continue
+			}
+			allURLs[url] = true
+			if err := h.AppCore.CheckURL(url); err != nil {
+				// Links to internal services are left out rather than failing the whole thread
+				log.Printf("Skipping URL in thread context: %v", err)
+				continue
 			}
+			threadContext.URLs = append(threadContext.URLs, url)
 		}
 	}
 
-	// Fetch raw content for all URLs found in the thread
-	fetcher := h.AppCore.GetFetcher()
+	// Fetch raw content for all URLs found in the thread, reusing what the last question fetched
+	entry := &cachedThread{messages: replies, contents: make(map[string]string), created: time.Now()}
+	if cached != nil {
+		entry.created = cached.created
+	}
+	f := h.AppCore.GetFetcher()
 	for _, url := range threadContext.URLs {
-		content, err := fetcher.Fetch(context.Background(), url)
+		if content, ok := cached.content(url); ok {
+			threadContext.URLContents[url] = content
+			entry.contents[url] = content
+			continue
+		}
+		result, err := f.Fetch(ctx, fetcher.FetchRequest{URL: url})
 		if err != nil {
 			log.Printf("Warning: failed to fetch content for URL %s in thread context: %v", url, err)
 			// Continue with other URLs even if one fails
 			threadContext.URLContents[url] = fmt.Sprintf("Error fetching content: %v", err)
 		} else {
 			// Store the raw content
-			threadContext.URLContents[url] = content
+			threadContext.URLContents[url] = result.Text
+			entry.contents[url] = result.Text
 		}
 	}
+	if len(replies) > 0 {
+		h.threadCache.put(key, entry)
+	}
 
 	return threadContext, nil
 }
@@ -311,31 +704,27 @@ func extractURLs(text string) []string {
 
 // ProgressUpdater handles updating Slack messages with progress information
 type ProgressUpdater struct {
-	client    *slack.Client
-	channel   string
-	timestamp string
+	ctx         context.Context
+	client      *slack.Client
+	channel     string
+	timestamp   string
+	postTimeout time.Duration
 }
 
 // UpdateProgress updates the Slack message with new progress information
 func (p *ProgressUpdater) UpdateProgress(message string) {
-	_, _, _, err := p.client.UpdateMessage(
-		p.channel,
-		p.timestamp,
-		slack.MsgOptionText(message, false),
-	)
+	// Final results and errors must still be posted after the request deadline passed
+	ctx := context.WithoutCancel(p.ctx)
+	err := timeout.Run(ctx, timeout.SlackPost, p.postTimeout, func(ctx context.Context) error {
+		_, _, _, err := p.client.UpdateMessageContext(
+			ctx,
+			p.channel,
+			p.timestamp,
+			slack.MsgOptionText(message, false),
+		)
+		return err
+	})
 	if err != nil {
 		log.Printf("Error updating progress message: %v", err)
 	}
 }
-
-// Helper function to replace the request body after reading it once
-// Needed because the request body can only be read once, but we need it for verification and parsing
-func drainAndReplaceBody(r *http.Request) ([]byte, error) {
-	bodyBytes, err := io.ReadAll(r.Body)
-	if err != nil {
-		return nil, err
-	}
-	r.Body.Close()                                    // Close the original body
-	r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // Replace with a new reader
-	return bodyBytes, nil
-}
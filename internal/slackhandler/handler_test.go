@@ -0,0 +1,82 @@
+package slackhandler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/footer"
+	"github.com/kznrluk/describe-kun/internal/queue"
+	"github.com/kznrluk/describe-kun/internal/router"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// signedRequest builds a Slack event request signed with secret.
+func signedRequest(secret, body string) *http.Request {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+	r := httptest.NewRequest(http.MethodPost, "/slack/events", strings.NewReader(body))
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func TestHandleEvent_IgnoresTimeoutRetries(t *testing.T) {
+	// Block the only worker so a dispatched mention would stay queued
+	jobs := queue.New(1)
+	release := make(chan struct{})
+	defer jobs.Close()
+	defer close(release)
+	started := make(chan struct{})
+	jobs.Submit(queue.Job{Priority: queue.Interactive, Name: "blocker", Run: func(ctx context.Context) {
+		close(started)
+		<-release
+	}})
+	<-started
+	h := &SlackHandler{SigningSecret: "secret"}
+	h.SetQueue(jobs)
+	routes := router.New()
+	h.Register(routes)
+
+	body := `{"type":"event_callback","event":{"type":"app_mention","user":"U1","channel":"C1","ts":"1.0","text":"<@B1> https://example.com"}}`
+	r := signedRequest("secret", body)
+	r.Header.Set("X-Slack-Retry-Num", "1")
+	r.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the retry to be acknowledged, got %d", w.Code)
+	}
+	if jobs.Len() != 0 {
+		t.Error("Expected the retry not to be queued")
+	}
+
+	w = httptest.NewRecorder()
+	routes.ServeHTTP(w, signedRequest("wrong", body))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unsigned requests to be rejected, got %d", w.Code)
+	}
+}
+
+func TestHandleNewMention_Footer(t *testing.T) {
+	client, posts := recordingSlack(t)
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+	h.SetChannelLanguages(map[string][]string{"C1": {"ja"}})
+	h.SetFooters(&footer.Footers{Default: footer.Texts{"*": "AI-generated.", "ja": "AIによる要約です。"}})
+
+	h.handleAppMention(context.Background(), &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> <https://example.com>"}, nil)
+	all := posts()
+	if final := all[len(all)-1]; !strings.HasSuffix(final, "Newsletter summary\n\nAIによる要約です。") {
+		t.Errorf("Expected the localized footer after the summary, got %q", final)
+	}
+}
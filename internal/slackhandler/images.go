@@ -0,0 +1,85 @@
+package slackhandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/slack-go/slack"
+)
+
+// maxImageBytes limits the size of an attached image downloaded for summarization.
+const maxImageBytes = 20 << 20
+
+// eventFiles extracts the files attached to the message behind an event callback body.
+// slackevents.AppMentionEvent doesn't carry them, so they are read from the raw payload.
+func eventFiles(body []byte) []slack.File {
+	var payload struct {
+		Event struct {
+			Files []slack.File `json:"files"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil
+	}
+	return payload.Event.Files
+}
+
+// imageFiles returns the image files among files.
+func imageFiles(files []slack.File) []slack.File {
+	var images []slack.File
+	for _, f := range files {
+		if strings.HasPrefix(f.Mimetype, "image/") {
+			images = append(images, f)
+		}
+	}
+	return images
+}
+
+// summarizeImages downloads the attached images (requires the files:read scope) and summarizes them.
+func (h *SlackHandler) summarizeImages(ctx context.Context, files []slack.File, question string, progress app.ProgressCallback) (string, error) {
+	progress(fmt.Sprintf(":loading: Downloading %d image(s)...", len(files)))
+
+	images := make([]app.Image, 0, len(files))
+	for _, f := range files {
+		data, err := h.downloadFile(ctx, f, maxImageBytes)
+		if err != nil {
+			return "", err
+		}
+		images = append(images, app.Image{Name: f.Name, Data: data, MIMEType: f.Mimetype})
+	}
+
+	return h.AppCore.ProcessImagesWithProgress(ctx, images, question, progress)
+}
+
+// downloadFile downloads an attached file (requires the files:read scope) of at most limit bytes.
+func (h *SlackHandler) downloadFile(ctx context.Context, f slack.File, limit int) ([]byte, error) {
+	if f.Size > limit {
+		return nil, fmt.Errorf("%s is too large (%d bytes, limit %d)", f.Name, f.Size, limit)
+	}
+	url := f.URLPrivateDownload
+	if url == "" {
+		url = f.URLPrivate
+	}
+	var buf bytes.Buffer
+	if err := h.SlackClient.GetFileContext(ctx, url, &buf); err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", f.Name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// slackMarkupRegex matches Slack markup such as user mentions (<@U123>) and links (<https://...>).
+var slackMarkupRegex = regexp.MustCompile(`<[^>]*>`)
+
+// mentionQuestion returns what the user asked in a mention, without mentions and URLs.
+func mentionQuestion(text string) string {
+	text = slackMarkupRegex.ReplaceAllString(text, " ")
+	for _, url := range extractURLs(text) {
+		text = strings.Replace(text, url, " ", 1)
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,38 @@
+package slackhandler
+
+import "testing"
+
+func TestEventFiles(t *testing.T) {
+	body := []byte(`{"type":"event_callback","event":{"type":"app_mention","text":"<@U1> what is this?","files":[
+		{"id":"F1","name":"shot.png","mimetype":"image/png","url_private_download":"https://files.slack.com/shot.png"},
+		{"id":"F2","name":"notes.pdf","mimetype":"application/pdf"}
+	]}}`)
+
+	files := eventFiles(body)
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
+	}
+	images := imageFiles(files)
+	if len(images) != 1 || images[0].URLPrivateDownload != "https://files.slack.com/shot.png" {
+		t.Errorf("Expected only the PNG to be treated as an image, got %+v", images)
+	}
+	if eventFiles([]byte("not json")) != nil {
+		t.Error("Expected no files for an invalid body")
+	}
+}
+
+func TestMentionQuestion(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"<@U123> what does this screenshot say?", "what does this screenshot say?"},
+		{"<@U123>   <https://example.com|example.com> summarize  please", "summarize please"},
+		{"<@U123> https://example.com/a", ""},
+	}
+	for _, tt := range tests {
+		if got := mentionQuestion(tt.text); got != tt.want {
+			t.Errorf("mentionQuestion(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
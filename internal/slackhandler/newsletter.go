@@ -0,0 +1,97 @@
+package slackhandler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/kznrluk/describe-kun/internal/email"
+	"github.com/kznrluk/describe-kun/internal/queue"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/slack-go/slack"
+)
+
+// maxEmailBytes bounds the size of an inbound email, attachments included.
+const maxEmailBytes = 25 << 20
+
+// SetNewsletter enables inbound email: newsletters posted to HandleInboundEmail with token are summarized into channel.
+func (h *SlackHandler) SetNewsletter(channel, token string) {
+	h.newsletterChannel = channel
+	h.newsletterToken = token
+}
+
+// HandleInboundEmail accepts a raw RFC 5322 message as the request body, as delivered by mail pipes
+// or inbound mail services, and queues it for summarization.
+// The shared token is read from the X-Inbound-Token header or the token query parameter.
+func (h *SlackHandler) HandleInboundEmail(w http.ResponseWriter, r *http.Request) {
+	if h.newsletterChannel == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	token := r.Header.Get("X-Inbound-Token")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.newsletterToken)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	msg, err := email.Parse(http.MaxBytesReader(w, r.Body, maxEmailBytes))
+	if err != nil {
+		log.Printf("[Newsletter] Error parsing inbound email: %v", err)
+		http.Error(w, "invalid email", http.StatusBadRequest)
+		return
+	}
+	if msg.Content() == "" {
+		log.Printf("[Newsletter] Inbound email %q has no readable body", msg.Subject)
+		http.Error(w, "email has no readable body", http.StatusUnprocessableEntity)
+		return
+	}
+
+	log.Printf("[Newsletter] Received %q from %s", msg.Subject, msg.From)
+	ctx := reqmeta.With(context.Background(), reqmeta.Metadata{
+		RequestID: reqmeta.From(r.Context()).RequestID,
+		Source:    reqmeta.SourceSlack,
+		Trigger:   reqmeta.TriggerEmail,
+	})
+	// Newsletters are not time-critical, so mentions run first
+	if err := h.enqueue(ctx, queue.Background, "newsletter "+msg.Subject, func(ctx context.Context) { h.summarizeNewsletter(ctx, msg) }); err != nil {
+		log.Printf("[Newsletter] Error queueing %q: %v", msg.Subject, err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// summarizeNewsletter posts a summary of msg to the newsletter channel.
+func (h *SlackHandler) summarizeNewsletter(ctx context.Context, msg *email.Message) {
+	ctx, cancel := h.requestContext(ctx, h.newsletterChannel)
+	defer cancel()
+
+	summary, err := h.AppCore.ProcessContent(ctx, msg.Content(), "")
+	if errors.Is(err, budget.ErrExhausted) {
+		// Posting would only add noise to the channel; the newsletter is simply dropped
+		log.Printf("[Newsletter] Budget exhausted, skipping %q: %v", msg.Subject, err)
+		return
+	}
+	if err != nil {
+		log.Printf("[Newsletter] Error summarizing %q: %v", msg.Subject, err)
+		return
+	}
+
+	text := fmt.Sprintf(":email: *%s*\nFrom: %s\n\n%s", msg.Subject, msg.From, summary)
+	if _, err := h.postMessage(ctx, h.newsletterChannel, slack.MsgOptionText(h.withFooter(h.newsletterChannel, h.withBudgetWarning(h.newsletterChannel, text)), false)); err != nil {
+		log.Printf("[Newsletter] Error posting summary of %q: %v", msg.Subject, err)
+		return
+	}
+	log.Printf("[Newsletter] Posted summary of %q to %s", msg.Subject, h.newsletterChannel)
+}
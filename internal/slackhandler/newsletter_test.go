@@ -0,0 +1,77 @@
+package slackhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/kznrluk/describe-kun/internal/queue"
+	"github.com/slack-go/slack"
+)
+
+// stubLLM returns a fixed summary.
+type stubLLM struct{}
+
+func (stubLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	return &llm.Response{Text: "Newsletter summary"}, nil
+}
+
+func TestHandleInboundEmail(t *testing.T) {
+	var mu sync.Mutex
+	var posted []string
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		posted = append(posted, r.URL.Path+" "+r.Form.Get("channel")+" "+r.Form.Get("text"))
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"channel":"C1","ts":"1.0"}`))
+	}))
+	defer slackAPI.Close()
+
+	jobs := queue.New(1)
+	h := &SlackHandler{
+		SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(slackAPI.URL+"/")),
+		AppCore:     app.NewApp(nil, stubLLM{}),
+	}
+	h.SetQueue(jobs)
+
+	raw := "From: Weekly <weekly@example.com>\r\nSubject: Issue 42\r\n\r\nThis week in Go.\r\n"
+	send := func(token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/email/inbound?token="+token, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.HandleInboundEmail(rec, req)
+		return rec.Code
+	}
+
+	if code := send("secret", raw); code != http.StatusNotFound {
+		t.Errorf("Expected 404 while disabled, got %d", code)
+	}
+
+	h.SetNewsletter("C1", "secret")
+	if code := send("wrong", raw); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", code)
+	}
+	if code := send("secret", "From: a@example.com\r\n\r\n"); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for an empty body, got %d", code)
+	}
+	if code := send("secret", raw); code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", code)
+	}
+
+	jobs.Close() // Waits for the queued summary
+	mu.Lock()
+	defer mu.Unlock()
+	if len(posted) != 1 {
+		t.Fatalf("Expected one Slack post, got %q", posted)
+	}
+	for _, want := range []string{"/chat.postMessage C1", "*Issue 42*", "Newsletter summary"} {
+		if !strings.Contains(posted[0], want) {
+			t.Errorf("Expected %q in post, got %q", want, posted[0])
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/kznrluk/describe-kun/internal/timeout"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// packCommandRegex matches a mention asking for a context pack of its URLs, e.g. "@bot pack <url> <url>".
+var packCommandRegex = regexp.MustCompile(`(?i)^(pack|context-?pack|コンテキスト)(\s|:|$)`)
+
+// handleContextPackCommand answers a pack command in event with a Markdown file bundling the extracted text
+// and summary of its URLs, to paste into other AI tools, and reports whether the mention was a pack command.
+// posted tells whether a pack was posted.
+func (h *SlackHandler) handleContextPackCommand(ctx context.Context, event *slackevents.AppMentionEvent) (handled, posted bool) {
+	urls := extractURLs(event.Text)
+	if !packCommandRegex.MatchString(mentionQuestion(event.Text)) || len(urls) == 0 {
+		return false, false
+	}
+	log.Printf("Packing %d URL(s) for user %s", len(urls), event.User)
+
+	loadingTS, postErr := h.postLoading(ctx, event.Channel, event.TimeStamp)
+	if postErr != nil {
+		log.Printf("Error posting loading message to Slack: %v", postErr)
+		h.reportAccessProblem(ctx, event, postErr)
+		return true, false
+	}
+	progressUpdater := h.newProgressUpdater(ctx, event.Channel, loadingTS)
+
+	pack, err := h.AppCore.ProcessContextPack(ctx, urls, progressUpdater.UpdateProgress)
+	switch {
+	case cancelledBy(ctx) != "":
+		progressUpdater.UpdateProgress(cancelledMessage(ctx))
+		return true, false
+	case errors.Is(err, budget.ErrExhausted):
+		log.Printf("Budget exhausted while packing pages: %v", err)
+		progressUpdater.UpdateProgress(budgetExhaustedMessage(err))
+		return true, false
+	case err != nil:
+		log.Printf("Error packing pages: %v", err)
+		progressUpdater.UpdateProgress(fmt.Sprintf("Error creating a context pack: %v", err))
+		return true, false
+	}
+
+	progressUpdater.UpdateProgress(":loading: Uploading the context pack...")
+	content := pack.Markdown()
+	err = timeout.Run(ctx, timeout.SlackPost, h.postTimeout, func(ctx context.Context) error {
+		_, err := h.SlackClient.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+			Channel:         event.Channel,
+			ThreadTimestamp: event.TimeStamp,
+			Filename:        "context-pack.md",
+			Title:           "Context pack",
+			Content:         content,
+			FileSize:        len(content),
+		})
+		return err
+	})
+	if err != nil {
+		log.Printf("Error uploading a context pack: %v", err)
+		progressUpdater.UpdateProgress(fmt.Sprintf("Error uploading the context pack (the files:write scope is needed): %v", err))
+		return true, false
+	}
+	progressUpdater.UpdateProgress(h.withFooter(event.Channel, h.withBudgetWarning(event.Channel, packMessage(pack))))
+	log.Printf("Successfully posted a context pack to channel %s", event.Channel)
+	return true, true
+}
+
+// packMessage introduces the attached context pack with the pages it holds.
+func packMessage(p *app.ContextPack) string {
+	var b strings.Builder
+	b.WriteString(":package: *Context pack*\n")
+	for _, page := range p.Pages {
+		if page.Error != "" {
+			fmt.Fprintf(&b, "• :warning: %s (could not be read)\n", page.URL)
+		} else {
+			fmt.Fprintf(&b, "• <%s|%s>\n", page.URL, page.Label())
+		}
+	}
+	b.WriteString("\nThe attached file holds the extracted text and summary of each page; paste it into any AI tool as context.")
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// queuedReplyDelay is how long a mention may wait for a worker before its queue position is posted,
+// so that short waits don't add noise to the thread.
+var queuedReplyDelay = 2 * time.Second
+
+// queuedUpdateInterval is how often the queue position in a waiting mention's reply is refreshed.
+const queuedUpdateInterval = 15 * time.Second
+
+// queuedReply is the reply telling a user their mention waits for a worker. Once the job starts,
+// the same message becomes its loading message, so the thread gets a single reply either way.
+type queuedReply struct {
+	mu      sync.Mutex
+	ts      string // Timestamp of the posted reply; empty if none was posted
+	started bool
+}
+
+type queuedReplyKey struct{}
+
+// start marks the job as started and returns the reply to reuse, or "" if none was posted.
+func (q *queuedReply) start() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.started = true
+	return q.ts
+}
+
+// reportQueued posts a reply with the queue position of the job name and an ETA if the job is still
+// waiting after delay, and keeps it up to date until the job starts or ctx is cancelled.
+func (h *SlackHandler) reportQueued(ctx context.Context, reply *queuedReply, event *slackevents.AppMentionEvent, name string, delay time.Duration) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(delay):
+	}
+
+	reply.mu.Lock()
+	position := h.queue.Position(name)
+	if reply.started || position == 0 {
+		reply.mu.Unlock()
+		return
+	}
+	ts, err := h.postMessage(ctx, event.Channel, slack.MsgOptionText(h.queuedMessage(position), false), slack.MsgOptionTS(threadOf(event)))
+	if err != nil {
+		log.Printf("Error posting queue position: %v", err)
+	}
+	reply.ts = ts
+	reply.mu.Unlock()
+	if ts == "" {
+		return
+	}
+
+	updater := h.newProgressUpdater(ctx, event.Channel, ts)
+	ticker := time.NewTicker(queuedUpdateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			reply.mu.Lock()
+			if !reply.started && cancelledBy(ctx) != "" {
+				// The job will be skipped, so nothing else replaces this message
+				updater.UpdateProgress(cancelledMessage(ctx))
+			}
+			reply.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+		reply.mu.Lock()
+		if reply.started {
+			reply.mu.Unlock()
+			return
+		}
+		p := h.queue.Position(name)
+		if p > 0 && p != position {
+			position = p
+			updater.UpdateProgress(h.queuedMessage(position))
+		}
+		reply.mu.Unlock()
+		if p == 0 {
+			// The job is about to start and will reuse the message
+			return
+		}
+	}
+}
+
+// queuedMessage tells a user where their mention is in the queue.
+func (h *SlackHandler) queuedMessage(position int) string {
+	text := fmt.Sprintf(":hourglass_flowing_sand: Waiting in the queue (position %d", position)
+	if eta := h.queue.ETA(position); eta > 0 {
+		text += fmt.Sprintf(", done in about %d min", int(math.Ceil(eta.Minutes())))
+	}
+	return text + "). This message will be updated, no need to mention me again."
+}
+
+// postLoading posts the loading message for a mention in thread threadTS, reusing the queue
+// position reply if one was posted, and returns its timestamp.
+func (h *SlackHandler) postLoading(ctx context.Context, channel, threadTS string) (string, error) {
+	if reply, ok := ctx.Value(queuedReplyKey{}).(*queuedReply); ok {
+		if ts := reply.start(); ts != "" {
+			h.newProgressUpdater(ctx, channel, ts).UpdateProgress(":loading:")
+			return ts, nil
+		}
+	}
+	return h.postMessage(ctx, channel, slack.MsgOptionText(":loading:", false), slack.MsgOptionTS(threadTS))
+}
+
+// deleteQueuedReply removes the queue position reply of a job that posts no loading message.
+func (h *SlackHandler) deleteQueuedReply(ctx context.Context, channel string) {
+	reply, ok := ctx.Value(queuedReplyKey{}).(*queuedReply)
+	if !ok {
+		return
+	}
+	if ts := reply.start(); ts != "" {
+		if _, _, err := h.SlackClient.DeleteMessageContext(ctx, channel, ts); err != nil {
+			log.Printf("Error deleting queue position reply: %v", err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package slackhandler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/queue"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestDispatch_ReportsQueuePosition(t *testing.T) {
+	defer func(d time.Duration) { queuedReplyDelay = d }(queuedReplyDelay)
+	queuedReplyDelay = 0
+	client, posts := recordingSlack(t)
+	jobs := queue.New(1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	jobs.Submit(queue.Job{Priority: queue.Interactive, Name: "blocker", Run: func(ctx context.Context) {
+		close(started)
+		<-release
+	}})
+	<-started
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+	h.SetQueue(jobs)
+
+	h.dispatch(reqmeta.Metadata{}, &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> https://example.com"}, nil)
+	waitForPosts(t, posts, 1)
+	if got := posts()[0]; !strings.HasPrefix(got, "/chat.postMessage") || !strings.Contains(got, "position 1") {
+		t.Errorf("Expected a queue position reply, got %q", got)
+	}
+
+	close(release)
+	jobs.Close()
+	got := posts()
+	for _, p := range got[1:] {
+		if strings.HasPrefix(p, "/chat.postMessage") {
+			t.Errorf("Expected the queue position reply to become the loading message, got another post %q", got)
+		}
+	}
+	if !strings.Contains(strings.Join(got, "\n"), "/chat.update :loading:") {
+		t.Errorf("Expected the reply to be updated to the loading message, got %q", got)
+	}
+}
+
+func TestDispatch_NoQueuePositionWhenIdle(t *testing.T) {
+	client, posts := recordingSlack(t)
+	jobs := queue.New(1)
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+	h.SetQueue(jobs)
+
+	h.dispatch(reqmeta.Metadata{}, &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> https://example.com"}, nil)
+	jobs.Close()
+	for _, p := range posts() {
+		if strings.Contains(p, "Waiting in the queue") {
+			t.Errorf("Expected no queue position reply for an idle pool, got %q", posts())
+		}
+	}
+}
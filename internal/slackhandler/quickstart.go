@@ -0,0 +1,52 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// quickstartCommandRegex matches a mention asking for a quickstart of a library's docs, e.g. "@bot quickstart <url>".
+var quickstartCommandRegex = regexp.MustCompile(`(?i)^(quickstart|クイックスタート)\b\s*:?\s*(.*)$`)
+
+// handleQuickstartCommand answers a quickstart command in event with a quickstart of the docs at its first URL,
+// and reports whether the mention was a quickstart command. posted tells whether a quickstart was posted.
+func (h *SlackHandler) handleQuickstartCommand(ctx context.Context, event *slackevents.AppMentionEvent) (handled, posted bool) {
+	m := quickstartCommandRegex.FindStringSubmatch(mentionQuestion(event.Text))
+	urls := extractURLs(event.Text)
+	if m == nil || len(urls) == 0 {
+		return false, false
+	}
+	log.Printf("Writing a quickstart of %s for user %s", urls[0], event.User)
+
+	loadingTS, postErr := h.postLoading(ctx, event.Channel, event.TimeStamp)
+	if postErr != nil {
+		log.Printf("Error posting loading message to Slack: %v", postErr)
+		h.reportAccessProblem(ctx, event, postErr)
+		return true, false
+	}
+	progressUpdater := h.newProgressUpdater(ctx, event.Channel, loadingTS)
+
+	quickstart, err := h.AppCore.ProcessQuickstart(ctx, urls[0], m[2], progressUpdater.UpdateProgress)
+	switch {
+	case cancelledBy(ctx) != "":
+		progressUpdater.UpdateProgress(cancelledMessage(ctx))
+		return true, false
+	case errors.Is(err, budget.ErrExhausted):
+		log.Printf("Budget exhausted while writing a quickstart: %v", err)
+		progressUpdater.UpdateProgress(budgetExhaustedMessage(err))
+		return true, false
+	case err != nil:
+		log.Printf("Error writing a quickstart: %v", err)
+		progressUpdater.UpdateProgress(fmt.Sprintf("Error writing a quickstart: %v", err))
+		return true, false
+	}
+	progressUpdater.UpdateProgress(h.withFooter(event.Channel, h.withBudgetWarning(event.Channel, quickstart)))
+	log.Printf("Successfully posted a quickstart to channel %s", event.Channel)
+	return true, true
+}
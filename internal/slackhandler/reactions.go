@@ -0,0 +1,85 @@
+package slackhandler
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/kznrluk/describe-kun/internal/timeout"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// Reactions showing where a mention is in its lifecycle, visible even when the thread is collapsed.
+const (
+	reactionReceived   = "eyes"
+	reactionProcessing = "hourglass_flowing_sand"
+	reactionDone       = "white_check_mark"
+	reactionFailed     = "x"
+)
+
+// Lifecycle stages, in order. A stage never replaces a later one, so reactions set from different
+// goroutines cannot end up out of order.
+const (
+	stageReceived = iota + 1
+	stageProcessing
+	stageFinished
+)
+
+// statusReaction keeps a single lifecycle reaction on the message that triggered a mention.
+type statusReaction struct {
+	h       *SlackHandler
+	item    slack.ItemRef
+	mu      sync.Mutex
+	stage   int
+	current string
+}
+
+// newStatusReaction returns the status reaction of event's message.
+func (h *SlackHandler) newStatusReaction(event *slackevents.AppMentionEvent) *statusReaction {
+	return &statusReaction{h: h, item: slack.NewRefToMessage(event.Channel, event.TimeStamp)}
+}
+
+// set replaces the current reaction with name, or just removes it if name is empty, unless the
+// mention already reached stage or a later one. Failures are logged; status is best effort.
+func (s *statusReaction) set(stage int, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stage <= s.stage {
+		return
+	}
+	s.stage = stage
+
+	// The request context may already be cancelled, and the reaction must still be updated
+	err := timeout.Run(context.Background(), timeout.SlackPost, s.h.postTimeout, func(ctx context.Context) error {
+		if s.current != "" {
+			if err := s.h.SlackClient.RemoveReactionContext(ctx, s.current, s.item); err != nil {
+				log.Printf("Error removing reaction %s from %s/%s: %v", s.current, s.item.Channel, s.item.Timestamp, err)
+			}
+			s.current = ""
+		}
+		if name == "" {
+			return nil
+		}
+		if err := s.h.SlackClient.AddReactionContext(ctx, name, s.item); err != nil {
+			return err
+		}
+		s.current = name
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error adding reaction %s to %s/%s: %v", name, s.item.Channel, s.item.Timestamp, err)
+	}
+}
+
+// finish sets the final reaction of a mention: done, failed, or none if it was cancelled.
+func (s *statusReaction) finish(ctx context.Context, answered bool) {
+	switch {
+	case cancelledBy(ctx) != "":
+		s.set(stageFinished, "")
+	case answered:
+		s.set(stageFinished, reactionDone)
+	default:
+		s.set(stageFinished, reactionFailed)
+	}
+}
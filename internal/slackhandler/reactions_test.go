@@ -0,0 +1,66 @@
+package slackhandler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// waitForReaction waits until the last reaction request adds name, and returns all reaction requests.
+func waitForReaction(t *testing.T, posts func() []string, name string) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var reactions []string
+		for _, post := range posts() {
+			if strings.HasPrefix(post, "/reactions.") {
+				reactions = append(reactions, post)
+			}
+		}
+		if len(reactions) > 0 && reactions[len(reactions)-1] == "/reactions.add "+name {
+			return reactions
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the final reaction %s, got %q", name, reactions)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStatusReactions(t *testing.T) {
+	for _, tc := range []struct {
+		text  string
+		final string
+	}{
+		{"<@B1> <https://example.com>", reactionDone},
+		{"<@B1> no link here", reactionFailed},
+	} {
+		client, posts := recordingSlack(t)
+		h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+		h.SetStatusReactions(true)
+
+		h.dispatch(reqmeta.Metadata{}, &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: tc.text}, nil)
+		reactions := strings.Join(waitForReaction(t, posts, tc.final), "\n")
+		if !strings.Contains(reactions, "/reactions.remove "+reactionProcessing) {
+			t.Errorf("%q: expected the processing reaction to be replaced, got %q", tc.text, reactions)
+		}
+	}
+}
+
+func TestStatusReactions_Disabled(t *testing.T) {
+	client, posts := recordingSlack(t)
+	h := &SlackHandler{SlackClient: client, AppCore: app.NewApp(stubFetcher{text: "page"}, stubLLM{})}
+
+	h.dispatch(reqmeta.Metadata{}, &slackevents.AppMentionEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "<@B1> <https://example.com>"}, nil)
+	waitForPosts(t, posts, 2)
+	time.Sleep(50 * time.Millisecond)
+	for _, post := range posts() {
+		if strings.HasPrefix(post, "/reactions.") {
+			t.Errorf("Expected no reactions by default, got %q", post)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/history"
+	"github.com/kznrluk/describe-kun/internal/readinglist"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	// bookmarkReaction is the reaction that saves the links of a message to the reacting user's reading list.
+	bookmarkReaction = "bookmark"
+	// maxBookmarkURLs caps the links saved from one message.
+	maxBookmarkURLs = 5
+	// maxHomeItems caps the items listed on the Home tab, keeping it within Slack's block limit.
+	maxHomeItems = 45
+	// previewRunes caps the summary line shown with each saved link.
+	previewRunes = 150
+)
+
+// readingListCommandRegex matches "saved", "reading list" and "remove 2" / "remove <url>" in a DM with the app.
+var readingListCommandRegex = regexp.MustCompile(`(?i)^(saved|reading ?list|bookmarks|あとで読む|remove|unsave|削除)(\s|:|$)\s*(.*)$`)
+
+// SetReadingList lets users save links to read later by reacting to a message with :bookmark:. The
+// summary of each link is looked up in hist, which may be nil. Users see their list by DMing the app
+// "saved" or on its Home tab, and remove links with "remove 2" or "remove <url>".
+func (h *SlackHandler) SetReadingList(store *readinglist.Store, hist *history.Store) {
+	h.readingList = store
+	h.readingHistory = hist
+}
+
+// readingListCommand returns the submatches of a reading list command DMed to the app in ev, or nil if
+// ev is not one.
+func (h *SlackHandler) readingListCommand(ev *slackevents.MessageEvent) []string {
+	if h.readingList == nil || ev.ChannelType != "im" || ev.BotID != "" || ev.SubType != "" {
+		return nil
+	}
+	return readingListCommandRegex.FindStringSubmatch(strings.TrimSpace(ev.Text))
+}
+
+// handleReadingListCommand replies in the DM of ev to the reading list command m.
+func (h *SlackHandler) handleReadingListCommand(ev *slackevents.MessageEvent, m []string) {
+	ctx, cancel := h.requestContext(context.Background(), ev.Channel)
+	defer cancel()
+
+	var text string
+	switch strings.ToLower(m[1]) {
+	case "remove", "unsave", "削除":
+		text = h.removeSaved(ev.User, strings.TrimSpace(m[3]))
+	default:
+		text = readingListText(h.readingList.List(ev.User))
+	}
+	if _, err := h.postMessage(ctx, ev.Channel, slack.MsgOptionText(text, false)); err != nil {
+		log.Printf("[ReadingList] Error replying to %s: %v", ev.User, err)
+	}
+}
+
+// removeSaved removes the item given by its number in the list or its URL from user's reading list and
+// returns the reply.
+func (h *SlackHandler) removeSaved(user, arg string) string {
+	url := strings.Trim(arg, "<>")
+	if i := strings.Index(url, "|"); i >= 0 {
+		url = url[:i]
+	}
+	if n, err := strconv.Atoi(arg); err == nil {
+		items := h.readingList.List(user)
+		if n < 1 || n > len(items) {
+			return fmt.Sprintf("There is no item %d in your reading list.", n)
+		}
+		url = items[n-1].URL
+	}
+	if url == "" {
+		return "Say which link to remove, e.g. `remove 2` or `remove https://example.com`."
+	}
+	if !h.readingList.Remove(user, url) {
+		return fmt.Sprintf("%s is not in your reading list.", url)
+	}
+	log.Printf("[ReadingList] %s removed %s", user, url)
+	return fmt.Sprintf(":wastebasket: Removed %s from your reading list.", url)
+}
+
+// handleBookmark saves the links of the message item to user's reading list when added, or removes them.
+func (h *SlackHandler) handleBookmark(user string, item slackevents.Item, added bool) {
+	if h.readingList == nil || item.Type != "message" {
+		return
+	}
+	ctx, cancel := h.requestContext(context.Background(), item.Channel)
+	defer cancel()
+
+	message, err := h.messageAt(ctx, item.Channel, item.Timestamp)
+	if err != nil {
+		log.Printf("[ReadingList] Error reading the message %s/%s: %v", item.Channel, item.Timestamp, err)
+		return
+	}
+	urls, summary := bookmarkedLinks(message)
+	if len(urls) == 0 && message.ThreadTimestamp != "" && message.ThreadTimestamp != message.Timestamp {
+		// A summary reply without links saves the links the thread was started with
+		if parent, err := h.messageAt(ctx, item.Channel, message.ThreadTimestamp); err == nil {
+			urls, _ = bookmarkedLinks(parent)
+		}
+		if message.BotID != "" && len(urls) == 1 {
+			summary = message.Text
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+
+	if !added {
+		for _, url := range urls {
+			h.readingList.Remove(user, url)
+		}
+		log.Printf("[ReadingList] %s unsaved %v", user, urls)
+		return
+	}
+	// Saved last to first, so the links are listed in the order of the message
+	for i := len(urls) - 1; i >= 0; i-- {
+		url := urls[i]
+		saved := readinglist.Item{URL: url, Channel: item.Channel}
+		if h.readingHistory != nil {
+			if s, err := h.readingHistory.LatestSummary(url); err != nil {
+				log.Printf("[ReadingList] Error looking up the summary of %s: %v", url, err)
+			} else {
+				saved.Summary = s
+			}
+		}
+		if saved.Summary == "" {
+			saved.Summary = summary
+		}
+		h.readingList.Save(user, saved)
+	}
+	log.Printf("[ReadingList] %s saved %v", user, urls)
+
+	text := fmt.Sprintf(":bookmark: Saved %d link(s) to your reading list. DM me `saved` or open my Home tab to see it.", len(urls))
+	options := []slack.MsgOption{slack.MsgOptionText(text, false)}
+	if message.ThreadTimestamp != "" {
+		options = append(options, slack.MsgOptionTS(message.ThreadTimestamp))
+	}
+	if _, err := h.SlackClient.PostEphemeralContext(ctx, item.Channel, user, options...); err != nil {
+		log.Printf("[ReadingList] Error confirming the bookmark to %s: %v", user, err)
+	}
+}
+
+// messageAt returns the message at ts in channel, which may be a thread reply.
+func (h *SlackHandler) messageAt(ctx context.Context, channel, ts string) (*slack.Message, error) {
+	messages, _, _, err := h.SlackClient.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+		ChannelID: channel,
+		Timestamp: ts,
+		Oldest:    ts,
+		Latest:    ts,
+		Inclusive: true,
+		Limit:     1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the message: %w", err)
+	}
+	for i := range messages {
+		if messages[i].Timestamp == ts {
+			return &messages[i], nil
+		}
+	}
+	return nil, fmt.Errorf("message %s not found", ts)
+}
+
+// bookmarkedLinks returns the links of message to save, and its text as their summary if it is the app's
+// summary of a single link.
+func bookmarkedLinks(message *slack.Message) ([]string, string) {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, url := range extractURLs(message.Text) {
+		if i := strings.Index(url, "|"); i >= 0 {
+			url = url[:i] // Slack formats labeled links as <url|label>
+		}
+		if !seen[url] && len(urls) < maxBookmarkURLs {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	if message.BotID != "" && len(urls) > 0 {
+		// The app's replies link their sources after the summary; the first one is the page summarized
+		return urls[:1], message.Text
+	}
+	return urls, ""
+}
+
+// readingListText lists items for a DM reply.
+func readingListText(items []readinglist.Item) string {
+	if len(items) == 0 {
+		return "Your reading list is empty. React to a message with :bookmark: to save its links."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Your reading list* (%d)\n", len(items))
+	for i, it := range items {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, it.URL)
+		if p := summaryPreview(it.Summary); p != "" {
+			fmt.Fprintf(&b, "\n      _%s_", p)
+		}
+	}
+	b.WriteString("\n\nRemove a link with `remove <number>` or `remove <url>`.")
+	return b.String()
+}
+
+// summaryPreview returns the first line of summary with content, without list markers.
+func summaryPreview(summary string) string {
+	for _, line := range strings.Split(summary, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-•*>#"))
+		if line == "" || strings.HasPrefix(line, ":") || strings.HasPrefix(line, "http") {
+			continue
+		}
+		return truncateRunes(line, previewRunes)
+	}
+	return ""
+}
+
+// publishHome shows user's reading list on the app's Home tab.
+func (h *SlackHandler) publishHome(user string) {
+	if h.readingList == nil {
+		return
+	}
+	ctx, cancel := h.requestContext(context.Background(), "")
+	defer cancel()
+
+	if _, err := h.SlackClient.PublishViewContext(ctx, user, slack.HomeTabViewRequest{Type: slack.VTHomeTab, Blocks: slack.Blocks{BlockSet: homeBlocks(h.readingList.List(user))}}, ""); err != nil {
+		log.Printf("[ReadingList] Error publishing the Home tab of %s: %v", user, err)
+	}
+}
+
+// homeBlocks lays out items for the Home tab.
+func homeBlocks(items []readinglist.Item) []slack.Block {
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, fmt.Sprintf("Reading list (%d)", len(items)), true, false)),
+	}
+	if len(items) == 0 {
+		return append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "Your reading list is empty. React to a message with :bookmark: to save its links.", false, false), nil, nil))
+	}
+	for i, it := range items {
+		if i == maxHomeItems {
+			text := fmt.Sprintf("…and %d more. DM me `saved` to see them all.", len(items)-maxHomeItems)
+			blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, text, false, false)))
+			break
+		}
+		text := fmt.Sprintf("*%d.* <%s>", i+1, it.URL)
+		if p := summaryPreview(it.Summary); p != "" {
+			text += "\n" + p
+		}
+		text += fmt.Sprintf("\n_Saved %s_", it.Saved.Format("2006-01-02"))
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, truncateRunes(text, maxSectionChars), false, false), nil, nil))
+	}
+	return blocks
+}
@@ -0,0 +1,100 @@
+package slackhandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/history"
+	"github.com/kznrluk/describe-kun/internal/readinglist"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestReadingList(t *testing.T) {
+	messages := map[string]string{
+		"1.0": `{"ts":"1.0","user":"U2","text":"<@UBOT> <https://example.com/b|B> vs <https://example.com/c>"}`,
+		"2.0": `{"ts":"2.0","thread_ts":"1.0","bot_id":"B1","text":"*Page A*\n- Point one\n\nSources: https://example.com/a https://example.com/ref"}`,
+		"3.0": `{"ts":"3.0","thread_ts":"1.0","bot_id":"B1","text":"Compared both pages."}`,
+	}
+	var mu sync.Mutex
+	var posts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path == "/conversations.replies" {
+			w.Write([]byte(`{"ok":true,"messages":[` + messages[r.Form.Get("ts")] + `]}`))
+			return
+		}
+		mu.Lock()
+		posts = append(posts, r.URL.Path+" "+r.Form.Get("user")+" "+r.Form.Get("text"))
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"channel":"C1","ts":"9.0"}`))
+	}))
+	defer srv.Close()
+
+	hist := history.NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	if _, err := hist.Append(history.Entry{URL: "https://example.com/b", Summary: ":memo: *B*\n- B explained"}); err != nil {
+		t.Fatal(err)
+	}
+	store, err := readinglist.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &SlackHandler{SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))}
+	h.SetReadingList(store, hist)
+
+	// A user's message saves each of its links, with the summaries in the history
+	h.handleBookmark("U1", slackevents.Item{Type: "message", Channel: "C1", Timestamp: "1.0"}, true)
+	// The app's summary saves the page it summarized, with the summary itself
+	h.handleBookmark("U1", slackevents.Item{Type: "message", Channel: "C1", Timestamp: "2.0"}, true)
+	items := store.List("U1")
+	if len(items) != 3 || items[0].URL != "https://example.com/a" || items[1].URL != "https://example.com/b" || items[2].URL != "https://example.com/c" {
+		t.Fatalf("Unexpected reading list %+v", items)
+	}
+	if !strings.Contains(items[0].Summary, "Point one") || items[1].Summary == "" || items[2].Summary != "" {
+		t.Errorf("Unexpected summaries %+v", items)
+	}
+	if len(posts) != 2 || !strings.HasPrefix(posts[0], "/chat.postEphemeral U1 :bookmark: Saved 2 link(s)") {
+		t.Errorf("Expected ephemeral confirmations, got %q", posts)
+	}
+
+	// A reply without links saves the links of the thread it answers
+	h.handleBookmark("U3", slackevents.Item{Type: "message", Channel: "C1", Timestamp: "3.0"}, true)
+	if got := store.List("U3"); len(got) != 2 {
+		t.Errorf("Expected the thread's links saved, got %+v", got)
+	}
+
+	// Removing the reaction removes the links
+	h.handleBookmark("U1", slackevents.Item{Type: "message", Channel: "C1", Timestamp: "2.0"}, false)
+	if got := store.List("U1"); len(got) != 2 {
+		t.Errorf("Expected the link to be removed, got %+v", got)
+	}
+
+	// DM commands list and remove saved links
+	posts = nil
+	for _, text := range []string{"saved", "remove 1", "remove <https://example.com/b>", "what is this?"} {
+		ev := &slackevents.MessageEvent{Channel: "D1", ChannelType: "im", User: "U1", Text: text}
+		if m := h.readingListCommand(ev); m != nil {
+			h.handleReadingListCommand(ev, m)
+		}
+	}
+	if len(posts) != 3 {
+		t.Fatalf("Expected three replies, got %q", posts)
+	}
+	if !strings.Contains(posts[0], "1. https://example.com/b\n      _B explained_") || !strings.Contains(posts[0], "2. https://example.com/c") {
+		t.Errorf("Unexpected list %q", posts[0])
+	}
+	if !strings.Contains(posts[1], "Removed https://example.com/b") || !strings.Contains(posts[2], "is not in your reading list") {
+		t.Errorf("Unexpected replies %q", posts[1:])
+	}
+	if h.readingListCommand(&slackevents.MessageEvent{ChannelType: "channel", User: "U1", Text: "saved"}) != nil {
+		t.Error("Expected commands outside DMs to be ignored")
+	}
+
+	if blocks := homeBlocks(store.List("U1")); len(blocks) != 2 {
+		t.Errorf("Expected a header and one item on the Home tab, got %d blocks", len(blocks))
+	}
+}
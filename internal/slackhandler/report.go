@@ -0,0 +1,57 @@
+package slackhandler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/history"
+	"github.com/slack-go/slack"
+)
+
+// Weekly reports are posted on Mondays at this local hour, covering the previous Monday to Sunday.
+const (
+	reportWeekday = time.Monday
+	reportHour    = 9
+)
+
+// RunWeeklyReports posts an analytics report computed from store to channel every week until ctx is done.
+func (h *SlackHandler) RunWeeklyReports(ctx context.Context, channel string, store *history.Store) {
+	for {
+		next := nextReportTime(time.Now())
+		log.Printf("[Report] Next weekly report at %s", next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+		if err := h.PostWeeklyReport(ctx, channel, store, next); err != nil {
+			log.Printf("[Report] Error posting weekly report: %v", err)
+		}
+	}
+}
+
+// PostWeeklyReport posts the report for the seven days before the start of the day of now.
+func (h *SlackHandler) PostWeeklyReport(ctx context.Context, channel string, store *history.Store, now time.Time) error {
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from := to.AddDate(0, 0, -7)
+	entries, err := store.Since(from)
+	if err != nil {
+		return err
+	}
+	report := history.BuildReport(entries, from, to)
+	if _, err := h.postMessage(ctx, channel, slack.MsgOptionText(report.Format(), false)); err != nil {
+		return err
+	}
+	log.Printf("[Report] Posted weekly report to %s (%d summaries)", channel, report.Total)
+	return nil
+}
+
+// nextReportTime returns the next report time strictly after now.
+func nextReportTime(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), reportHour, 0, 0, 0, now.Location())
+	for next.Weekday() != reportWeekday || !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
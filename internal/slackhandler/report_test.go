@@ -0,0 +1,25 @@
+package slackhandler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextReportTime(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		// Friday -> the following Monday
+		{time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		// Monday before the hour -> the same day
+		{time.Date(2026, 10, 19, 8, 59, 0, 0, time.UTC), time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		// Monday at the hour -> next week
+		{time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC), time.Date(2026, 10, 26, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := nextReportTime(tt.now); !got.Equal(tt.want) {
+			t.Errorf("nextReportTime(%s) = %s, want %s", tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package slackhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/budget"
+	"github.com/kznrluk/describe-kun/internal/timeout"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// reportCommandRegex matches a mention asking for a research report of its URLs, e.g. "@bot report: which is faster?".
+var reportCommandRegex = regexp.MustCompile(`(?i)^(report|レポート)\b\s*:?\s*(.*)$`)
+
+// handleReportCommand answers a report command in event with a research report of its URLs, attached as a
+// Markdown file since reports are too long for a message, and reports whether the mention was a report
+// command. posted tells whether a report was posted.
+func (h *SlackHandler) handleReportCommand(ctx context.Context, event *slackevents.AppMentionEvent) (handled, posted bool) {
+	m := reportCommandRegex.FindStringSubmatch(mentionQuestion(event.Text))
+	urls := extractURLs(event.Text)
+	if m == nil || len(urls) == 0 {
+		return false, false
+	}
+	log.Printf("Writing a report of %d URL(s) for user %s", len(urls), event.User)
+
+	loadingTS, postErr := h.postLoading(ctx, event.Channel, event.TimeStamp)
+	if postErr != nil {
+		log.Printf("Error posting loading message to Slack: %v", postErr)
+		h.reportAccessProblem(ctx, event, postErr)
+		return true, false
+	}
+	progressUpdater := h.newProgressUpdater(ctx, event.Channel, loadingTS)
+
+	report, err := h.AppCore.ProcessReport(ctx, urls, m[2], progressUpdater.UpdateProgress)
+	switch {
+	case cancelledBy(ctx) != "":
+		progressUpdater.UpdateProgress(cancelledMessage(ctx))
+		return true, false
+	case errors.Is(err, budget.ErrExhausted):
+		log.Printf("Budget exhausted while writing a report: %v", err)
+		progressUpdater.UpdateProgress(budgetExhaustedMessage(err))
+		return true, false
+	case err != nil:
+		log.Printf("Error writing a report: %v", err)
+		progressUpdater.UpdateProgress(fmt.Sprintf("Error writing a report: %v", err))
+		return true, false
+	}
+
+	progressUpdater.UpdateProgress(":loading: Uploading the report...")
+	content := report.Markdown()
+	err = timeout.Run(ctx, timeout.SlackPost, h.postTimeout, func(ctx context.Context) error {
+		_, err := h.SlackClient.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+			Channel:         event.Channel,
+			ThreadTimestamp: event.TimeStamp,
+			Filename:        "report.md",
+			Title:           report.Title,
+			Content:         content,
+			FileSize:        len(content),
+		})
+		return err
+	})
+	if err != nil {
+		log.Printf("Error uploading a report: %v", err)
+		progressUpdater.UpdateProgress(fmt.Sprintf("Error uploading the report (the files:write scope is needed): %v", err))
+		return true, false
+	}
+	progressUpdater.UpdateProgress(h.withFooter(event.Channel, h.withBudgetWarning(event.Channel, reportMessage(report))))
+	log.Printf("Successfully posted a report to channel %s", event.Channel)
+	return true, true
+}
+
+// reportMessage introduces the attached report with its title and outline.
+func reportMessage(r *app.Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ":page_facing_up: *%s*\n", r.Title)
+	for _, s := range r.Sections {
+		fmt.Fprintf(&b, "• %s\n", s.Heading)
+	}
+	fmt.Fprintf(&b, "\nThe full report, citing %d source(s), is attached as a file.", len(r.Sources))
+	return b.String()
+}
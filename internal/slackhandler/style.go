@@ -0,0 +1,30 @@
+package slackhandler
+
+import (
+	"regexp"
+	"strings"
+)
+
+// styleKeywordRegex matches a mention starting with a summary style, e.g. "@bot eli5 <url>".
+var styleKeywordRegex = regexp.MustCompile(`(?i)^(tl;?dr|executive|eli5|detailed|経営層向け|やさしく|詳しく)(\s|:|$)`)
+
+// styleAliases maps the other keywords to the styles of app.Styles.
+var styleAliases = map[string]string{
+	"tl;dr": "tldr",
+	"経営層向け": "executive",
+	"やさしく":  "eli5",
+	"詳しく":   "detailed",
+}
+
+// mentionStyle returns the summary style a mention asks for with its first word, or "" for the default summary.
+func mentionStyle(text string) string {
+	m := styleKeywordRegex.FindStringSubmatch(mentionQuestion(text))
+	if m == nil {
+		return ""
+	}
+	style := strings.ToLower(m[1])
+	if alias, ok := styleAliases[style]; ok {
+		return alias
+	}
+	return style
+}
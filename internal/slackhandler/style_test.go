@@ -0,0 +1,22 @@
+package slackhandler
+
+import "testing"
+
+func TestMentionStyle(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"<@U123> eli5 <https://example.com>", "eli5"},
+		{"<@U123> <https://example.com> TL;DR", "tldr"},
+		{"<@U123> 詳しく https://example.com", "detailed"},
+		{"<@U123> executive: what is the cost? https://example.com", "executive"},
+		{"<@U123> detailedness https://example.com", ""},
+		{"<@U123> https://example.com", ""},
+	}
+	for _, tt := range tests {
+		if got := mentionStyle(tt.text); got != tt.want {
+			t.Errorf("mentionStyle(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
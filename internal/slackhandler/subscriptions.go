@@ -0,0 +1,104 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/subscription"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// subscriptionCommandRegex matches "subscribe: a, b", "unsubscribe: a" and "subscriptions" in a mention.
+var subscriptionCommandRegex = regexp.MustCompile(`(?i)^(subscribe|unsubscribe|subscriptions)\b\s*:?\s*(.*)$`)
+
+// SetSubscriptions lets users subscribe to topics with mentions ("subscribe: security, golang").
+// When a page summarized from a public channel matches, subscribers get a copy by DM.
+func (h *SlackHandler) SetSubscriptions(store *subscription.Store) {
+	h.subscriptions = store
+	h.AppCore.AddSummaryHook(h.notifySubscribers)
+}
+
+// handleSubscriptionCommand replies to a subscription command in event and reports whether it was one.
+func (h *SlackHandler) handleSubscriptionCommand(event *slackevents.AppMentionEvent) bool {
+	if h.subscriptions == nil {
+		return false
+	}
+	m := subscriptionCommandRegex.FindStringSubmatch(mentionQuestion(event.Text))
+	if m == nil {
+		return false
+	}
+
+	var topics []string
+	for _, t := range strings.Split(m[2], ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			topics = append(topics, t)
+		}
+	}
+
+	var current []string
+	switch strings.ToLower(m[1]) {
+	case "subscribe":
+		if len(topics) == 0 {
+			return false
+		}
+		current = h.subscriptions.Subscribe(event.User, topics)
+	case "unsubscribe":
+		current = h.subscriptions.Unsubscribe(event.User, topics)
+	default:
+		current = h.subscriptions.Topics(event.User)
+	}
+
+	text := "You have no topic subscriptions. Subscribe with `subscribe: security, golang`."
+	if len(current) > 0 {
+		text = fmt.Sprintf("Your topic subscriptions: %s\nSummaries matching them in public channels will be sent to you by DM.", strings.Join(current, ", "))
+	}
+
+	ctx, cancel := h.requestContext(context.Background(), event.Channel)
+	defer cancel()
+	if _, err := h.postMessage(ctx, event.Channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(event.TimeStamp)); err != nil {
+		log.Printf("[Subscriptions] Error replying to %s: %v", event.User, err)
+	}
+	log.Printf("[Subscriptions] %s: %s %v", event.User, strings.ToLower(m[1]), topics)
+	return true
+}
+
+// notifySubscribers DMs the summary in r to users subscribed to a matching topic.
+// Only summaries made in public channels are forwarded, so private content never leaves its channel.
+func (h *SlackHandler) notifySubscribers(ctx context.Context, r *app.Result) {
+	ref, ok := mentionFrom(ctx)
+	if !ok {
+		return
+	}
+	matches := h.subscriptions.Match(r.Metadata.Title, r.Content, r.Summary)
+	delete(matches, ref.User) // The requester already has the summary
+	if len(matches) == 0 {
+		return
+	}
+
+	info, err := h.SlackClient.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: ref.Channel})
+	if err != nil {
+		log.Printf("[Subscriptions] Error checking channel %s, not notifying subscribers: %v", ref.Channel, err)
+		return
+	}
+	if info.IsPrivate || info.IsIM || info.IsMpIM {
+		return
+	}
+
+	title := r.Metadata.Title
+	if title == "" {
+		title = r.URL
+	}
+	for user, topics := range matches {
+		text := fmt.Sprintf(":bell: A summary matching your subscription (%s) was posted in <#%s>: <%s|%s>\n\n%s",
+			strings.Join(topics, ", "), ref.Channel, r.URL, title, r.Summary)
+		// Posting to a user ID delivers the message in the app's DM with them
+		if _, err := h.postMessage(ctx, user, slack.MsgOptionText(text, false)); err != nil {
+			log.Printf("[Subscriptions] Error notifying %s about %s: %v", user, r.URL, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package slackhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/subscription"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestSubscriptions(t *testing.T) {
+	private := false
+	var posts []string
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/conversations.info":
+			if private {
+				w.Write([]byte(`{"ok":true,"channel":{"id":"C1","is_private":true}}`))
+			} else {
+				w.Write([]byte(`{"ok":true,"channel":{"id":"C1"}}`))
+			}
+		default:
+			posts = append(posts, r.Form.Get("channel")+" "+r.Form.Get("text"))
+			w.Write([]byte(`{"ok":true,"channel":"C1","ts":"1.0"}`))
+		}
+	}))
+	defer slackAPI.Close()
+
+	store, err := subscription.NewStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &SlackHandler{
+		SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(slackAPI.URL+"/")),
+		AppCore:     app.NewApp(stubFetcher{text: "A deep dive into Golang generics"}, stubLLM{}),
+	}
+	h.SetSubscriptions(store)
+
+	// Subscription commands are answered in place of a summary
+	for _, user := range []string{"U1", "U2"} {
+		if !h.handleSubscriptionCommand(&slackevents.AppMentionEvent{User: user, Channel: "C1", TimeStamp: "1.0", Text: "<@UBOT> subscribe: security, golang"}) {
+			t.Fatal("Expected the subscribe command to be handled")
+		}
+	}
+	if h.handleSubscriptionCommand(&slackevents.AppMentionEvent{User: "U1", Text: "<@UBOT> <https://example.com> what is this?"}) {
+		t.Error("Expected a regular mention not to be treated as a command")
+	}
+	if len(posts) != 2 || !strings.Contains(posts[0], "Your topic subscriptions: security, golang") {
+		t.Fatalf("Unexpected replies %q", posts)
+	}
+
+	// U1 asked for the summary, so only U2 is notified
+	posts = nil
+	ctx := withMention(context.Background(), &slackevents.AppMentionEvent{Channel: "C1", TimeStamp: "2.0", User: "U1"})
+	if _, err := h.AppCore.ProcessURL(ctx, "https://example.com/generics", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if len(posts) != 1 || !strings.HasPrefix(posts[0], "U2 :bell:") || !strings.Contains(posts[0], "(golang)") {
+		t.Fatalf("Expected a DM to U2, got %q", posts)
+	}
+
+	// Summaries from private channels are never forwarded
+	posts = nil
+	private = true
+	if _, err := h.AppCore.ProcessURL(ctx, "https://example.com/generics", ""); err != nil {
+		t.Fatalf("ProcessURL failed: %v", err)
+	}
+	if len(posts) != 0 {
+		t.Errorf("Expected no DMs for a private channel, got %q", posts)
+	}
+}
@@ -0,0 +1,93 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// threadPageSize is how many replies are requested per page; Slack caps it at 1000 but recommends 200.
+const threadPageSize = 200
+
+// SetThreadLimits limits how much of a long thread is read as context: the parent message plus at most
+// maxMessages of the most recent replies, none older than maxAge. Zero disables a limit.
+func (h *SlackHandler) SetThreadLimits(maxMessages int, maxAge time.Duration) {
+	h.threadMaxMessages = maxMessages
+	h.threadMaxAge = maxAge
+}
+
+// threadMessages reads every page of the thread threadTS in channel, starting after the message at
+// oldest if it is not empty. The parent message is left out of such partial reads.
+func (h *SlackHandler) threadMessages(ctx context.Context, channel, threadTS, oldest string) ([]slack.Message, error) {
+	var messages []slack.Message
+	cursor := ""
+	for {
+		replies, hasMore, next, err := h.SlackClient.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+			ChannelID: channel,
+			Timestamp: threadTS,
+			Inclusive: oldest == "", // Include the parent message
+			Oldest:    oldest,
+			Limit:     threadPageSize,
+			Cursor:    cursor,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversation replies: %w", err)
+		}
+		for _, reply := range replies {
+			// Slack returns the parent with every page
+			if reply.Timestamp == threadTS && (oldest != "" || len(messages) > 0) {
+				continue
+			}
+			if oldest == "" || messageTime(reply.Timestamp).After(messageTime(oldest)) {
+				messages = append(messages, reply)
+			}
+		}
+		if !hasMore || next == "" {
+			break
+		}
+		cursor = next
+	}
+	return messages, nil
+}
+
+// limitThread applies the handler's thread limits to messages; see limitThread.
+func (h *SlackHandler) limitThread(messages []slack.Message) []slack.Message {
+	var cutoff time.Time
+	if h.threadMaxAge > 0 {
+		cutoff = time.Now().Add(-h.threadMaxAge)
+	}
+	return limitThread(messages, h.threadMaxMessages, cutoff)
+}
+
+// limitThread keeps the parent message, which usually carries the link being discussed, and at most
+// max of the latest replies not older than cutoff. Zero values disable the limits.
+func limitThread(messages []slack.Message, max int, cutoff time.Time) []slack.Message {
+	if len(messages) == 0 {
+		return messages
+	}
+	replies := make([]slack.Message, 0, len(messages)-1)
+	for _, message := range messages[1:] {
+		if cutoff.IsZero() || !messageTime(message.Timestamp).Before(cutoff) {
+			replies = append(replies, message)
+		}
+	}
+	if max > 0 && len(replies) > max {
+		replies = replies[len(replies)-max:]
+	}
+	return append([]slack.Message{messages[0]}, replies...)
+}
+
+// messageTime parses a Slack message timestamp such as "1700000000.000100".
+func messageTime(ts string) time.Time {
+	seconds, micros, _ := strings.Cut(ts, ".")
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	usec, _ := strconv.ParseInt(micros, 10, 64)
+	return time.Unix(sec, usec*int64(time.Microsecond))
+}
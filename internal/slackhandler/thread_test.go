@@ -0,0 +1,130 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/slack-go/slack"
+)
+
+func TestThreadMessages_Pagination(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		// Three pages of two messages each
+		page, _ := strconv.Atoi(r.Form.Get("cursor"))
+		next := ""
+		if page < 2 {
+			next = strconv.Itoa(page + 1)
+		}
+		fmt.Fprintf(w, `{"ok":true,"messages":[{"text":"m%d","ts":"%d.0"},{"text":"m%d","ts":"%d.0"}],"has_more":%t,"response_metadata":{"next_cursor":%q}}`,
+			2*page, 1000+2*page, 2*page+1, 1000+2*page+1, next != "", next)
+	}))
+	defer srv.Close()
+	h := &SlackHandler{SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))}
+
+	messages, err := h.threadMessages(context.Background(), "C1", "1000.0", "")
+	if err != nil {
+		t.Fatalf("threadMessages failed: %v", err)
+	}
+	if len(messages) != 6 || messages[5].Text != "m5" {
+		t.Fatalf("Expected all pages to be read, got %d messages", len(messages))
+	}
+
+	h.SetThreadLimits(2, 0)
+	messages = h.limitThread(messages)
+	if len(messages) != 3 || messages[0].Text != "m0" || messages[1].Text != "m4" || messages[2].Text != "m5" {
+		t.Errorf("Expected the parent and the two latest replies, got %+v", messages)
+	}
+}
+
+func TestLimitThread_MaxAge(t *testing.T) {
+	now := time.Now()
+	ts := func(d time.Duration) string { return fmt.Sprintf("%d.000100", now.Add(-d).Unix()) }
+	messages := []slack.Message{
+		{Msg: slack.Msg{Text: "parent", Timestamp: ts(72 * time.Hour)}},
+		{Msg: slack.Msg{Text: "old", Timestamp: ts(48 * time.Hour)}},
+		{Msg: slack.Msg{Text: "recent", Timestamp: ts(time.Hour)}},
+	}
+	got := limitThread(messages, 0, now.Add(-24*time.Hour))
+	if len(got) != 2 || got[0].Text != "parent" || got[1].Text != "recent" {
+		t.Errorf("Expected the parent and the recent reply, got %+v", got)
+	}
+}
+
+// countingFetcher counts fetches per URL.
+type countingFetcher struct {
+	mu      sync.Mutex
+	fetches map[string]int
+}
+
+func (f *countingFetcher) Fetch(ctx context.Context, req fetcher.FetchRequest) (*fetcher.FetchResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches[req.URL]++
+	return &fetcher.FetchResult{Text: "content of " + req.URL}, nil
+}
+
+func TestGetThreadContext_Cache(t *testing.T) {
+	var mu sync.Mutex
+	thread := []string{
+		`{"text":"<https://a.example>","ts":"1000.000100"}`,
+		`{"text":"what is this?","ts":"1001.000100"}`,
+	}
+	var oldest []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mu.Lock()
+		defer mu.Unlock()
+		oldest = append(oldest, r.Form.Get("oldest"))
+		// Like Slack, always return the parent first
+		replies := []string{thread[0]}
+		for _, message := range thread[1:] {
+			var ts float64
+			fmt.Sscanf(message[strings.Index(message, `"ts":"`)+6:], "%f", &ts)
+			since, _ := strconv.ParseFloat(r.Form.Get("oldest"), 64)
+			if ts > since {
+				replies = append(replies, message)
+			}
+		}
+		fmt.Fprintf(w, `{"ok":true,"messages":[%s]}`, strings.Join(replies, ","))
+	}))
+	defer srv.Close()
+	f := &countingFetcher{fetches: make(map[string]int)}
+	h := &SlackHandler{
+		SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/")),
+		AppCore:     app.NewApp(f, stubLLM{}),
+	}
+
+	if _, err := h.getThreadContext(context.Background(), "C1", "1000.000100"); err != nil {
+		t.Fatalf("getThreadContext failed: %v", err)
+	}
+	mu.Lock()
+	thread = append(thread, `{"text":"and <https://b.example>?","ts":"1002.000100"}`)
+	mu.Unlock()
+	threadContext, err := h.getThreadContext(context.Background(), "C1", "1000.000100")
+	if err != nil {
+		t.Fatalf("getThreadContext failed: %v", err)
+	}
+
+	if len(threadContext.Messages) != 3 || threadContext.Messages[2] != "and <https://b.example>?" {
+		t.Errorf("Expected the new message to be added once, got %q", threadContext.Messages)
+	}
+	if threadContext.URLContents["https://a.example"] != "content of https://a.example" || threadContext.URLContents["https://b.example"] != "content of https://b.example" {
+		t.Errorf("Expected contents of both URLs, got %v", threadContext.URLContents)
+	}
+	if f.fetches["https://a.example"] != 1 || f.fetches["https://b.example"] != 1 {
+		t.Errorf("Expected each URL to be fetched once, got %v", f.fetches)
+	}
+	if len(oldest) != 2 || oldest[0] != "" || oldest[1] != "1001.000100" {
+		t.Errorf("Expected the second read to start after the cached messages, got %q", oldest)
+	}
+}
@@ -0,0 +1,78 @@
+package slackhandler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// threadCacheTTL bounds how stale cached page contents can get; messages are refreshed on every use.
+	threadCacheTTL = 10 * time.Minute
+	// threadCacheSize is how many threads are cached at most.
+	threadCacheSize = 100
+)
+
+// cachedThread is what was read of a thread for the last question asked in it.
+type cachedThread struct {
+	messages []slack.Message   // Within the thread limits, oldest first
+	contents map[string]string // URL -> fetched content; failed fetches are not cached
+	created  time.Time
+}
+
+// latest returns the timestamp of the newest cached message.
+func (t *cachedThread) latest() string {
+	return t.messages[len(t.messages)-1].Timestamp
+}
+
+// content returns the cached content of url. It is safe to call on a nil thread.
+func (t *cachedThread) content(url string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	content, ok := t.contents[url]
+	return content, ok
+}
+
+// threadCache keeps recently read threads, so follow-up questions only read the new messages and
+// fetch the URLs they add.
+type threadCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedThread
+}
+
+// get returns the cached thread for key, or nil if there is none or it expired.
+func (c *threadCache) get(key string) *cachedThread {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.entries[key]
+	if entry == nil {
+		return nil
+	}
+	if time.Since(entry.created) > threadCacheTTL {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// put caches entry under key, evicting the oldest entry when the cache is full.
+// Entries are never modified once cached, so readers need no lock.
+func (c *threadCache) put(key string, entry *cachedThread) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*cachedThread)
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= threadCacheSize {
+		oldest := ""
+		for k, e := range c.entries {
+			if oldest == "" || e.created.Before(c.entries[oldest].created) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = entry
+}
@@ -0,0 +1,48 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/reqmeta"
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const (
+	// threadTitlePrefix starts the reply suggesting a thread title; a thread with one gets no other.
+	threadTitlePrefix = ":label: Suggested thread title:"
+	// threadTitleMinMessages is how long a thread must be before a title is suggested, so short exchanges
+	// are left alone.
+	threadTitleMinMessages = 4
+)
+
+// suggestThreadTitle replies in the thread of event with a suggested title once it is long enough, after
+// answer was posted in it. Slack threads have no title of their own, so the reply carries the search terms
+// of the thread and can be pinned or saved. Each thread gets one suggestion.
+func (h *SlackHandler) suggestThreadTitle(ctx context.Context, event *slackevents.AppMentionEvent, threadContext *app.ThreadContext, answer string) {
+	if len(threadContext.Messages) < threadTitleMinMessages {
+		return
+	}
+	for _, message := range threadContext.Messages {
+		if strings.HasPrefix(message, threadTitlePrefix) {
+			return
+		}
+	}
+	title, err := h.AppCore.ThreadTitle(ctx, threadContext, answer)
+	if err != nil {
+		reqmeta.Logf(ctx, "[ThreadTitles] Not suggesting a title for %s/%s: %v", event.Channel, event.ThreadTimeStamp, err)
+		return
+	}
+	if title == "" {
+		return
+	}
+	text := fmt.Sprintf("%s *%s*\n_Pin this reply or add it to the channel's bookmarks so the thread is easy to find later._", threadTitlePrefix, title)
+	if _, err := h.postMessage(ctx, event.Channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(event.ThreadTimeStamp)); err != nil {
+		reqmeta.Logf(ctx, "[ThreadTitles] Error posting the thread title: %v", err)
+		return
+	}
+	reqmeta.Logf(ctx, "[ThreadTitles] Suggested %q for %s/%s", title, event.Channel, event.ThreadTimeStamp)
+}
@@ -0,0 +1,51 @@
+package slackhandler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/feature"
+	"github.com/kznrluk/describe-kun/internal/llm"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// titleLLM names every thread the same.
+type titleLLM struct{}
+
+func (titleLLM) Generate(ctx context.Context, messages []llm.Message, opts llm.Options) (*llm.Response, error) {
+	return &llm.Response{Text: `Title: "Postgres vs MySQL for the billing service"`}, nil
+}
+
+func TestSuggestThreadTitle(t *testing.T) {
+	client, posts := recordingSlack(t)
+	core := app.NewApp(stubFetcher{}, titleLLM{})
+	flags, err := feature.New(map[string]bool{feature.ThreadTitles: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core.SetFeatures(flags)
+	h := &SlackHandler{SlackClient: client, AppCore: core}
+	event := &slackevents.AppMentionEvent{Channel: "C1", ThreadTimeStamp: "1.0"}
+
+	// Too short to need a title
+	h.suggestThreadTitle(context.Background(), event, &app.ThreadContext{Messages: []string{"a", "b"}}, "answer")
+	if got := posts(); len(got) != 0 {
+		t.Fatalf("Expected no title for a short thread, got %q", got)
+	}
+
+	thread := &app.ThreadContext{Messages: []string{"<https://a.example>", "summary", "which one scales?", "answer"}}
+	h.suggestThreadTitle(context.Background(), event, thread, "answer")
+	got := posts()
+	want := "/chat.postMessage " + threadTitlePrefix + " *Postgres vs MySQL for the billing service*\n_Pin this reply or add it to the channel's bookmarks so the thread is easy to find later._"
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("Expected the title suggested, got %q", got)
+	}
+
+	// Once per thread
+	thread.Messages = append(thread.Messages, want[len("/chat.postMessage "):], "and the cost?")
+	h.suggestThreadTitle(context.Background(), event, thread, "answer")
+	if got := posts(); len(got) != 1 {
+		t.Errorf("Expected a single title per thread, got %q", got)
+	}
+}
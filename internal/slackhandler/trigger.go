@@ -0,0 +1,61 @@
+package slackhandler
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/slack-go/slack/slackevents"
+)
+
+// SetTriggerPrefix lets messages starting with prefix (e.g. "!describe") trigger the bot like a
+// mention. It requires subscribing to the message events of the channels the bot is in. Empty disables it.
+func (h *SlackHandler) SetTriggerPrefix(prefix string) {
+	h.triggerPrefix = strings.TrimSpace(prefix)
+}
+
+// triggerMention returns the mention equivalent to ev if it starts with the trigger prefix.
+// The prefix is removed from the text, so the rest is handled exactly like a mention.
+func (h *SlackHandler) triggerMention(ctx context.Context, ev *slackevents.MessageEvent) (*slackevents.AppMentionEvent, bool) {
+	// Edits, joins and other bots' messages never trigger; file_share is a message with attachments
+	if h.triggerPrefix == "" || ev.BotID != "" || (ev.SubType != "" && ev.SubType != "file_share") {
+		return nil, false
+	}
+	text := strings.TrimLeftFunc(ev.Text, unicode.IsSpace)
+	if len(text) < len(h.triggerPrefix) || !strings.EqualFold(text[:len(h.triggerPrefix)], h.triggerPrefix) {
+		return nil, false
+	}
+	rest := text[len(h.triggerPrefix):]
+	if rest != "" && !unicode.IsSpace([]rune(rest)[0]) {
+		// "!describer" is not "!describe"
+		return nil, false
+	}
+	// A message mentioning the bot also arrives as an app_mention, which handles it
+	if bot := h.botUserID(ctx); bot != "" && strings.Contains(rest, "<@"+bot+">") {
+		return nil, false
+	}
+
+	return &slackevents.AppMentionEvent{
+		Type:            "app_mention",
+		User:            ev.User,
+		Text:            strings.TrimSpace(rest),
+		TimeStamp:       ev.TimeStamp,
+		ThreadTimeStamp: ev.ThreadTimeStamp,
+		Channel:         ev.Channel,
+		EventTimeStamp:  ev.EventTimeStamp,
+	}, true
+}
+
+// botUserID returns the bot's own user ID, looked up once. It returns "" if the lookup fails.
+func (h *SlackHandler) botUserID(ctx context.Context) string {
+	h.botUserOnce.Do(func() {
+		resp, err := h.SlackClient.AuthTestContext(ctx)
+		if err != nil {
+			log.Printf("Error looking up the bot user: %v", err)
+			return
+		}
+		h.botUser = resp.UserID
+	})
+	return h.botUser
+}
@@ -0,0 +1,45 @@
+package slackhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+func TestTriggerMention(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"user_id":"B1"}`))
+	}))
+	defer srv.Close()
+	h := &SlackHandler{SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(srv.URL+"/"))}
+
+	message := &slackevents.MessageEvent{User: "U1", Channel: "C1", TimeStamp: "1.0", Text: "!describe <https://example.com> pricing?"}
+	if _, ok := h.triggerMention(context.Background(), message); ok {
+		t.Error("Expected no trigger without a prefix configured")
+	}
+
+	h.SetTriggerPrefix("!describe")
+	mention, ok := h.triggerMention(context.Background(), message)
+	if !ok || mention.Text != "<https://example.com> pricing?" || mention.User != "U1" || mention.TimeStamp != "1.0" {
+		t.Errorf("Expected the message to become a mention without the prefix, got %+v", mention)
+	}
+
+	for _, ev := range []*slackevents.MessageEvent{
+		{User: "U1", Text: "!describer <https://example.com>"},
+		{User: "U1", Text: "see !describe <https://example.com>"},
+		{User: "U1", Text: "!describe <https://example.com>", SubType: "message_changed"},
+		{BotID: "B2", Text: "!describe <https://example.com>"},
+		{User: "U1", Text: "!describe <@B1> <https://example.com>"},
+	} {
+		if _, ok := h.triggerMention(context.Background(), ev); ok {
+			t.Errorf("Expected %+v not to trigger", ev)
+		}
+	}
+	if _, ok := h.triggerMention(context.Background(), &slackevents.MessageEvent{User: "U1", Text: "!DESCRIBE <https://example.com>"}); !ok {
+		t.Error("Expected the prefix to be case-insensitive")
+	}
+}
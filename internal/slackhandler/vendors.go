@@ -0,0 +1,78 @@
+package slackhandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/snapshot"
+	"github.com/kznrluk/describe-kun/internal/tracker"
+	"github.com/slack-go/slack"
+)
+
+// vendorCheckTimeout bounds the check of one page of a vendor.
+const vendorCheckTimeout = 5 * time.Minute
+
+// VendorTracker posts the meaningful changes of vendors' changelog and status pages to Slack on a schedule.
+type VendorTracker struct {
+	Vendors   []tracker.Vendor
+	Channel   string        // Channel of vendors without their own
+	Interval  time.Duration // How often each vendor is checked
+	MinChange float64       // Fraction of changed lines below which a change is ignored, see app.CheckPage
+	State     *tracker.State
+	Snapshots *snapshot.Store
+}
+
+// RunVendorTracker checks each vendor of t whenever it is due, until ctx is done.
+func (h *SlackHandler) RunVendorTracker(ctx context.Context, t *VendorTracker) {
+	for {
+		next := h.checkVendors(ctx, t, time.Now())
+		log.Printf("[Vendors] Next check at %s", next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// checkVendors checks the vendors of t due at now, posting the changes of their pages to their channels,
+// and returns when the next vendor is due.
+func (h *SlackHandler) checkVendors(ctx context.Context, t *VendorTracker, now time.Time) time.Time {
+	next := now.Add(t.Interval)
+	for _, v := range t.Vendors {
+		if due := t.State.Next(v.Name, t.Interval, now); due.After(now) {
+			if due.Before(next) {
+				next = due
+			}
+			continue
+		}
+		channel := v.Channel
+		if channel == "" {
+			channel = t.Channel
+		}
+		for _, url := range v.URLs {
+			checkCtx, cancel := context.WithTimeout(ctx, vendorCheckTimeout)
+			change, err := h.AppCore.CheckPage(checkCtx, t.Snapshots, url, t.MinChange)
+			if err == nil && change != nil {
+				_, err = h.postMessage(checkCtx, channel, slack.MsgOptionText(vendorChangeMessage(v, change), false))
+			}
+			cancel()
+			if err != nil {
+				log.Printf("[Vendors] Error checking %s of %s: %v", url, v.Name, err)
+			} else if change != nil {
+				log.Printf("[Vendors] Posted a change of %s of %s to %s", url, v.Name, channel)
+			}
+		}
+		t.State.Checked(v.Name, now)
+	}
+	return next
+}
+
+// vendorChangeMessage describes change of a page of vendor.
+func vendorChangeMessage(v tracker.Vendor, change *app.Change) string {
+	return fmt.Sprintf(":package: *%s*: <%s> が変更されました（%s 以降、%.0f%%の行）\n%s",
+		v.Name, change.URL, change.Since.Format("2006-01-02 15:04"), change.Ratio*100, change.Description)
+}
@@ -0,0 +1,71 @@
+package slackhandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kznrluk/describe-kun/internal/app"
+	"github.com/kznrluk/describe-kun/internal/fetcher"
+	"github.com/kznrluk/describe-kun/internal/snapshot"
+	"github.com/kznrluk/describe-kun/internal/tracker"
+	"github.com/slack-go/slack"
+)
+
+// pagesFetcher returns the current text of each page.
+type pagesFetcher map[string]string
+
+func (f pagesFetcher) Fetch(ctx context.Context, req fetcher.FetchRequest) (*fetcher.FetchResult, error) {
+	return &fetcher.FetchResult{Text: f[req.URL], FinalURL: req.URL}, nil
+}
+
+func TestCheckVendors(t *testing.T) {
+	var posts []string
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		posts = append(posts, r.Form.Get("channel")+" "+r.Form.Get("text"))
+		w.Write([]byte(`{"ok":true,"channel":"C1","ts":"1.0"}`))
+	}))
+	defer slackAPI.Close()
+
+	pages := pagesFetcher{"https://stripe.example/changelog": "v1 released", "https://github.example/status": "All systems operational"}
+	h := &SlackHandler{SlackClient: slack.New("xoxb-test", slack.OptionAPIURL(slackAPI.URL+"/")), AppCore: app.NewApp(pages, stubLLM{})}
+	snapshots, err := snapshot.NewStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	state, _ := tracker.NewState("")
+	tr := &VendorTracker{
+		Vendors: []tracker.Vendor{
+			{Name: "Stripe", URLs: []string{"https://stripe.example/changelog"}, Channel: "CPAY"},
+			{Name: "GitHub", URLs: []string{"https://github.example/status"}},
+		},
+		Channel:   "CVENDORS",
+		Interval:  time.Hour,
+		State:     state,
+		Snapshots: snapshots,
+	}
+
+	// The first check only takes snapshots
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	if next := h.checkVendors(context.Background(), tr, now); !next.Equal(now.Add(time.Hour)) || len(posts) != 0 {
+		t.Fatalf("Expected no posts and the next check in an hour, got %s, %q", next, posts)
+	}
+
+	// Vendors are not checked again before they are due
+	pages["https://stripe.example/changelog"] = "v2 released with breaking changes"
+	pages["https://github.example/status"] = "Degraded performance of Actions"
+	h.checkVendors(context.Background(), tr, now.Add(30*time.Minute))
+	if len(posts) != 0 {
+		t.Fatalf("Expected no posts before the vendors are due, got %q", posts)
+	}
+
+	// Each change goes to its vendor's channel, or the default one
+	h.checkVendors(context.Background(), tr, now.Add(time.Hour))
+	if len(posts) != 2 || !strings.HasPrefix(posts[0], "CPAY :package: *Stripe*: <https://stripe.example/changelog>") || !strings.HasPrefix(posts[1], "CVENDORS :package: *GitHub*:") {
+		t.Errorf("Expected one post per vendor in its channel, got %q", posts)
+	}
+}
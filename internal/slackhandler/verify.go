@@ -0,0 +1,48 @@
+package slackhandler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/kznrluk/describe-kun/internal/router"
+	"github.com/slack-go/slack"
+)
+
+// maxSlackRequestBytes bounds the body of requests from Slack, which are a few kilobytes.
+const maxSlackRequestBytes = 1 << 20
+
+// VerifySignature rejects requests that are not signed by Slack with secret, for every endpoint Slack
+// calls (events, interactivity, slash commands). It buffers the body, so the handler can still read it.
+func VerifySignature(secret string) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return router.BufferBody(maxSlackRequestBytes)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			verifier, err := slack.NewSecretsVerifier(r.Header, secret)
+			if err != nil {
+				log.Printf("Error verifying request signature: %v", err)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body, err := router.Body(r)
+			if err != nil {
+				log.Printf("Error reading request body: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			verifier.Write(body)
+			if err := verifier.Ensure(); err != nil {
+				log.Printf("Error verifying request signature: %v", err)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// Register mounts the endpoints Slack and the mail pipeline call on r.
+func (h *SlackHandler) Register(r *router.Router) {
+	r.HandleFunc("/slack/events", h.HandleEvent, router.Log, VerifySignature(h.SigningSecret))
+	r.HandleFunc("/slack/interactions", h.HandleInteraction, router.Log, VerifySignature(h.SigningSecret))
+	// Newsletters forwarded by a mail pipe or inbound mail service (disabled unless NEWSLETTER_CHANNEL is set)
+	r.HandleFunc("/email/inbound", h.HandleInboundEmail, router.Log)
+}
@@ -0,0 +1,121 @@
+// Package snapshot keeps successive extracted-content snapshots of watched pages.
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultKeep is how many snapshots of a page are kept when NewStore is given zero.
+const DefaultKeep = 10
+
+// Snapshot is the extracted text of a page at one point in time.
+type Snapshot struct {
+	Time    time.Time `json:"time"`
+	Content string    `json:"content"`
+}
+
+// page is the file format: every snapshot of one URL, oldest first.
+type page struct {
+	URL       string     `json:"url"`
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+// Store keeps the most recent snapshots of each URL in a directory, one JSON file per URL.
+type Store struct {
+	mu   sync.Mutex
+	dir  string
+	keep int
+}
+
+// NewStore creates a Store in dir, creating the directory if needed, keeping up to keep snapshots per URL.
+func NewStore(dir string, keep int) (*Store, error) {
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating snapshot directory: %w", err)
+	}
+	return &Store{dir: dir, keep: keep}, nil
+}
+
+// Latest returns the most recent snapshot of url, or nil if there is none.
+func (s *Store) Latest(url string) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.load(url)
+	if err != nil || len(p.Snapshots) == 0 {
+		return nil, err
+	}
+	return &p.Snapshots[len(p.Snapshots)-1], nil
+}
+
+// History returns the kept snapshots of url, oldest first.
+func (s *Store) History(url string) ([]Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.load(url)
+	return p.Snapshots, err
+}
+
+// Save records snap as the newest snapshot of url, dropping the oldest beyond the limit.
+func (s *Store) Save(url string, snap Snapshot) error {
+	if snap.Time.IsZero() {
+		snap.Time = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.load(url)
+	if err != nil {
+		return err
+	}
+	p.URL = url
+	p.Snapshots = append(p.Snapshots, snap)
+	if len(p.Snapshots) > s.keep {
+		p.Snapshots = p.Snapshots[len(p.Snapshots)-s.keep:]
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("encoding snapshots of %s: %w", url, err)
+	}
+	// Write to a temporary file first so a crash never leaves a truncated file behind
+	path := s.path(url)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("writing snapshots of %s: %w", url, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("writing snapshots of %s: %w", url, err)
+	}
+	return nil
+}
+
+// load reads the snapshots of url. Must be called with mu held.
+func (s *Store) load(url string) (page, error) {
+	var p page
+	data, err := os.ReadFile(s.path(url))
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return p, fmt.Errorf("reading snapshots of %s: %w", url, err)
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("parsing snapshots of %s: %w", url, err)
+	}
+	return p, nil
+}
+
+// path returns the file holding the snapshots of url.
+func (s *Store) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8])+".json")
+}
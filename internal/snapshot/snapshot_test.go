@@ -0,0 +1,36 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir, 2)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if latest, err := s.Latest("https://example.com/pricing"); err != nil || latest != nil {
+		t.Fatalf("Expected no snapshot yet, got %+v, %v", latest, err)
+	}
+
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	for i, content := range []string{"v1", "v2", "v3"} {
+		if err := s.Save("https://example.com/pricing", Snapshot{Time: start.Add(time.Duration(i) * time.Hour), Content: content}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	s.Save("https://example.com/other", Snapshot{Content: "other"})
+
+	// A new store reads what the previous one wrote
+	s, _ = NewStore(dir, 2)
+	latest, err := s.Latest("https://example.com/pricing")
+	if err != nil || latest == nil || latest.Content != "v3" {
+		t.Fatalf("Expected the newest snapshot, got %+v, %v", latest, err)
+	}
+	history, _ := s.History("https://example.com/pricing")
+	if len(history) != 2 || history[0].Content != "v2" {
+		t.Errorf("Expected the two newest snapshots, got %+v", history)
+	}
+}
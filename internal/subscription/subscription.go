@@ -0,0 +1,114 @@
+package subscription
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kznrluk/describe-kun/internal/alert"
+)
+
+// Store keeps the topics each user subscribed to.
+type Store struct {
+	mu     sync.Mutex
+	topics map[string][]string // User ID -> topics in subscription order
+	path   string              // Optional file the subscriptions are persisted to
+}
+
+// NewStore creates a Store. If statePath is set, subscriptions are loaded from and saved to that file.
+func NewStore(statePath string) (*Store, error) {
+	s := &Store{topics: make(map[string][]string), path: statePath}
+	if statePath != "" {
+		data, err := os.ReadFile(statePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read subscriptions: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &s.topics); err != nil {
+				return nil, fmt.Errorf("failed to parse subscriptions %s: %w", statePath, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Subscribe adds topics for user, ignoring ones already subscribed (case-insensitively), and returns the user's topics.
+func (s *Store) Subscribe(user string, topics []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range topics {
+		t = strings.TrimSpace(t)
+		if t != "" && indexOf(s.topics[user], t) < 0 {
+			s.topics[user] = append(s.topics[user], t)
+		}
+	}
+	s.save()
+	return append([]string(nil), s.topics[user]...)
+}
+
+// Unsubscribe removes topics for user; no topics removes all of them. It returns the remaining topics.
+func (s *Store) Unsubscribe(user string, topics []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(topics) == 0 {
+		delete(s.topics, user)
+	}
+	for _, t := range topics {
+		if i := indexOf(s.topics[user], strings.TrimSpace(t)); i >= 0 {
+			s.topics[user] = append(s.topics[user][:i], s.topics[user][i+1:]...)
+		}
+	}
+	if len(s.topics[user]) == 0 {
+		delete(s.topics, user)
+	}
+	s.save()
+	return append([]string(nil), s.topics[user]...)
+}
+
+// Topics returns the topics user subscribed to.
+func (s *Store) Topics(user string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.topics[user]...)
+}
+
+// Match returns, for each user with a subscribed topic found in any of texts, the matching topics.
+func (s *Store) Match(texts ...string) map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matches := make(map[string][]string)
+	for user, topics := range s.topics {
+		if found := alert.NewMatcher(topics).Match(texts...); len(found) > 0 {
+			matches[user] = found
+		}
+	}
+	return matches
+}
+
+// save writes the subscriptions to the state file. Must be called with mu held.
+func (s *Store) save() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.Marshal(s.topics)
+	if err == nil {
+		err = os.WriteFile(s.path, data, 0o644)
+	}
+	if err != nil {
+		log.Printf("[Subscription] Failed to save subscriptions: %v", err)
+	}
+}
+
+// indexOf returns the index of topic in topics ignoring case, or -1.
+func indexOf(topics []string, topic string) int {
+	for i, t := range topics {
+		if strings.EqualFold(t, topic) {
+			return i
+		}
+	}
+	return -1
+}
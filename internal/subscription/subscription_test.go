@@ -0,0 +1,46 @@
+package subscription
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	if got := s.Subscribe("U1", []string{"security", " golang ", "Security"}); !reflect.DeepEqual(got, []string{"security", "golang"}) {
+		t.Errorf("Subscribe = %q", got)
+	}
+	s.Subscribe("U2", []string{"rust"})
+
+	matches := s.Match("A new Golang release", "fixes bugs")
+	if want := map[string][]string{"U1": {"golang"}}; !reflect.DeepEqual(matches, want) {
+		t.Errorf("Match = %v, want %v", matches, want)
+	}
+
+	if got := s.Unsubscribe("U1", []string{"SECURITY"}); !reflect.DeepEqual(got, []string{"golang"}) {
+		t.Errorf("Unsubscribe = %q", got)
+	}
+
+	// Subscriptions survive a restart
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if got := reloaded.Topics("U1"); !reflect.DeepEqual(got, []string{"golang"}) {
+		t.Errorf("Reloaded topics = %q", got)
+	}
+	if got := reloaded.Topics("U2"); !reflect.DeepEqual(got, []string{"rust"}) {
+		t.Errorf("Reloaded topics = %q", got)
+	}
+
+	reloaded.Unsubscribe("U2", nil)
+	if got := reloaded.Topics("U2"); len(got) != 0 {
+		t.Errorf("Expected all topics removed, got %q", got)
+	}
+}
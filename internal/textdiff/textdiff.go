@@ -0,0 +1,131 @@
+// Package textdiff compares texts line by line.
+package textdiff
+
+import "strings"
+
+// Ops of a diff line.
+const (
+	Equal  = ' '
+	Delete = '-'
+	Insert = '+'
+)
+
+// Line is one line of a diff.
+type Line struct {
+	Op   byte // Equal, Delete or Insert
+	Text string
+}
+
+// Lines returns the line diff turning a into b, based on their longest common subsequence.
+func Lines(a, b string) []Line {
+	x, y := split(a), split(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []Line
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			diff = append(diff, Line{Equal, x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, Line{Delete, x[i]})
+			i++
+		default:
+			diff = append(diff, Line{Insert, y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		diff = append(diff, Line{Delete, x[i]})
+	}
+	for ; j < len(y); j++ {
+		diff = append(diff, Line{Insert, y[j]})
+	}
+	return diff
+}
+
+// Changed reports whether the diff contains any insertion or deletion.
+func Changed(diff []Line) bool {
+	for _, l := range diff {
+		if l.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// Ratio returns the fraction of lines in both texts that were deleted or inserted, from 0
+// (identical) to 1 (nothing in common).
+func Ratio(diff []Line) float64 {
+	changed := 0
+	for _, l := range diff {
+		if l.Op != Equal {
+			changed++
+		}
+	}
+	total := 2*(len(diff)-changed) + changed
+	if total == 0 {
+		return 0
+	}
+	return float64(changed) / float64(total)
+}
+
+// Trim keeps only the changes and up to context unchanged lines around each of them.
+// Skipped runs of unchanged lines are replaced by a single "..." line.
+func Trim(diff []Line, context int) []Line {
+	keep := make([]bool, len(diff))
+	for i, l := range diff {
+		if l.Op == Equal {
+			continue
+		}
+		for j := max(0, i-context); j <= min(len(diff)-1, i+context); j++ {
+			keep[j] = true
+		}
+	}
+	var trimmed []Line
+	for i, l := range diff {
+		if keep[i] {
+			trimmed = append(trimmed, l)
+		} else if i == 0 || keep[i-1] {
+			trimmed = append(trimmed, Line{Equal, "..."})
+		}
+	}
+	return trimmed
+}
+
+// Format renders the diff with a "-", "+" or " " prefix on every line.
+func Format(diff []Line) string {
+	var b strings.Builder
+	for _, l := range diff {
+		b.WriteByte(l.Op)
+		b.WriteByte(' ')
+		b.WriteString(l.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// split returns the lines of s without a trailing empty line.
+func split(s string) []string {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
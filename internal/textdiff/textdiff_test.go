@@ -0,0 +1,42 @@
+package textdiff
+
+import "testing"
+
+func TestLines(t *testing.T) {
+	diff := Lines("a\nb\nc\n", "a\nc\nd")
+	want := "  a\n- b\n  c\n+ d\n"
+	if got := Format(diff); got != want {
+		t.Errorf("Format(Lines) = %q, want %q", got, want)
+	}
+	if !Changed(diff) {
+		t.Error("Expected a change")
+	}
+
+	if Changed(Lines("same\r\ntext", "same\ntext\n")) {
+		t.Error("Expected line endings to be ignored")
+	}
+	if got := Format(Lines("", "new")); got != "+ new\n" {
+		t.Errorf("Expected a single insertion, got %q", got)
+	}
+}
+
+func TestRatio(t *testing.T) {
+	if r := Ratio(Lines("a\nb\nc\nd", "a\nb\nc\nd")); r != 0 {
+		t.Errorf("Expected 0 for identical texts, got %v", r)
+	}
+	// One of four lines replaced: 2 of the 8 lines in both texts changed
+	if r := Ratio(Lines("a\nb\nc\nd", "a\nb\nc\ne")); r != 0.25 {
+		t.Errorf("Expected 0.25, got %v", r)
+	}
+	if r := Ratio(Lines("a", "b")); r != 1 {
+		t.Errorf("Expected 1 for unrelated texts, got %v", r)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	diff := Trim(Lines("1\n2\n3\n4\n5\n6\n7", "1\n2\n3\nfour\n5\n6\n7"), 1)
+	want := "  ...\n  3\n- 4\n+ four\n  5\n  ...\n"
+	if got := Format(diff); got != want {
+		t.Errorf("Format(Trim) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Stage names reported in timeout errors.
+const (
+	Navigation = "navigation"
+	Extraction = "extraction"
+	LLM        = "llm"
+	SlackPost  = "slack post"
+)
+
+// Error reports that a specific stage exceeded its own timeout.
+type Error struct {
+	Stage   string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s timeout (%s) exceeded: %v", e.Stage, e.Timeout, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Run calls fn with a context bounded by d and attributes a deadline error to stage.
+// If the parent context expired instead, the error is returned unchanged so the caller's
+// own deadline is reported. A zero d runs fn without a stage-specific limit.
+func Run(parent context.Context, stage string, d time.Duration, fn func(ctx context.Context) error) error {
+	if d <= 0 {
+		return fn(parent)
+	}
+	ctx, cancel := context.WithTimeout(parent, d)
+	defer cancel()
+
+	err := fn(ctx)
+	if err != nil && parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &Error{Stage: stage, Timeout: d, Err: err}
+	}
+	return err
+}
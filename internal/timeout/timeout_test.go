@@ -0,0 +1,51 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun_StageTimeout(t *testing.T) {
+	err := Run(context.Background(), LLM, 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	var te *Error
+	if !errors.As(err, &te) {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+	if te.Stage != LLM || te.Timeout != 10*time.Millisecond {
+		t.Errorf("Unexpected timeout error: %+v", te)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Expected the error to wrap context.DeadlineExceeded")
+	}
+}
+
+func TestRun_ParentTimeout(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := Run(parent, Navigation, time.Hour, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	var te *Error
+	if errors.As(err, &te) {
+		t.Fatalf("Parent deadline must not be attributed to the stage, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRun_NoTimeout(t *testing.T) {
+	want := errors.New("boom")
+	if err := Run(context.Background(), Extraction, 0, func(ctx context.Context) error { return want }); err != want {
+		t.Errorf("Expected error to pass through, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Package tracker keeps the vendors whose changelog and status pages are crawled for changes, and when each
+// was last checked.
+package tracker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Vendor is a tracked vendor: its changelog, release notes and status pages, and where their changes go.
+type Vendor struct {
+	Name    string   `json:"name"`
+	URLs    []string `json:"urls"`
+	Channel string   `json:"channel,omitempty"` // Slack channel ID for this vendor's changes; empty uses the default
+}
+
+// registry is the format of the vendors file.
+type registry struct {
+	Vendors []Vendor `json:"vendors"`
+}
+
+// Load reads the vendors registered in the JSON file at path, e.g.
+//
+//	{"vendors": [{"name": "Stripe", "urls": ["https://docs.stripe.com/changelog"], "channel": "C0PAYMENTS"}]}
+//
+// Every vendor needs a unique name and at least one URL.
+func Load(path string) ([]Vendor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vendors: %w", err)
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	var r registry
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to parse vendors %s: %w", path, err)
+	}
+	seen := make(map[string]bool)
+	for i, v := range r.Vendors {
+		if v.Name == "" {
+			return nil, fmt.Errorf("vendor %d in %s has no name", i+1, path)
+		}
+		if seen[strings.ToLower(v.Name)] {
+			return nil, fmt.Errorf("vendor %q is registered twice in %s", v.Name, path)
+		}
+		seen[strings.ToLower(v.Name)] = true
+		if len(v.URLs) == 0 {
+			return nil, fmt.Errorf("vendor %q in %s has no URLs", v.Name, path)
+		}
+	}
+	return r.Vendors, nil
+}
+
+// State records when each vendor was last checked, so restarts don't check every vendor again at once.
+type State struct {
+	mu      sync.Mutex
+	checked map[string]time.Time // Vendor name -> last check
+	path    string               // Optional file the state is persisted to
+}
+
+// NewState creates a State. If statePath is set, the state is loaded from and saved to that file.
+func NewState(statePath string) (*State, error) {
+	s := &State{checked: make(map[string]time.Time), path: statePath}
+	if statePath != "" {
+		data, err := os.ReadFile(statePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read vendor state: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &s.checked); err != nil {
+				return nil, fmt.Errorf("failed to parse vendor state %s: %w", statePath, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Next returns when vendor is next due for a check every interval; never-checked vendors are due now.
+func (s *State) Next(vendor string, interval time.Duration, now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last, ok := s.checked[vendor]
+	if !ok {
+		return now
+	}
+	return last.Add(interval)
+}
+
+// Checked records that vendor was checked at t.
+func (s *State) Checked(vendor string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked[vendor] = t
+	s.save()
+}
+
+// save writes the state to the state file. Must be called with mu held.
+func (s *State) save() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.Marshal(s.checked)
+	if err == nil {
+		err = os.WriteFile(s.path, data, 0o644)
+	}
+	if err != nil {
+		log.Printf("[Tracker] Failed to save vendor state: %v", err)
+	}
+}
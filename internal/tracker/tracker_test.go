@@ -0,0 +1,60 @@
+package tracker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vendors.json")
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"vendors": [{"name": "Stripe", "urls": ["https://docs.stripe.com/changelog", "https://status.stripe.com"], "channel": "C1"}, {"name": "GitHub", "urls": ["https://www.githubstatus.com"]}]}`)
+	vendors, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(vendors) != 2 || len(vendors[0].URLs) != 2 || vendors[0].Channel != "C1" || vendors[1].Channel != "" {
+		t.Errorf("Unexpected vendors %+v", vendors)
+	}
+
+	for _, bad := range []string{
+		`{"vendors": [{"urls": ["https://example.com"]}]}`,
+		`{"vendors": [{"name": "A"}]}`,
+		`{"vendors": [{"name": "A", "urls": ["https://a.example"]}, {"name": "a", "urls": ["https://b.example"]}]}`,
+		`{"vendors": [{"name": "A", "url": "https://a.example"}]}`,
+	} {
+		write(bad)
+		if _, err := Load(path); err == nil {
+			t.Errorf("Expected an error for %s", bad)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := NewState(path)
+	if err != nil {
+		t.Fatalf("NewState failed: %v", err)
+	}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	if next := s.Next("Stripe", time.Hour, now); !next.Equal(now) {
+		t.Errorf("Expected a vendor never checked to be due now, got %s", next)
+	}
+	s.Checked("Stripe", now)
+
+	// The state survives a restart
+	reloaded, err := NewState(path)
+	if err != nil {
+		t.Fatalf("NewState failed: %v", err)
+	}
+	if next := reloaded.Next("Stripe", time.Hour, now); !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected the next check an hour after the last, got %s", next)
+	}
+}